@@ -3,16 +3,16 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/karolswdev/docloom/internal/agents/csharp/parser"
+	"github.com/karolswdev/docloom/pkg/agentsdk"
 )
 
 // AgentOutput represents the structured output from the agent
@@ -63,18 +63,15 @@ var summarizeReadmeCmd = &cobra.Command{
 
 		// Find README files
 		readmeFiles := []string{}
-		err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
-			name := strings.ToLower(info.Name())
+		err := walkSource(sourcePath, func(path string, d fs.DirEntry) error {
+			name := strings.ToLower(d.Name())
 			if name == "readme.md" || name == "readme.txt" || name == "readme" {
 				readmeFiles = append(readmeFiles, path)
 			}
 			return nil
 		})
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error walking path: %v\n", err)
+			agentsdk.Logf("Error walking path: %v", err)
 		}
 
 		output := map[string]interface{}{
@@ -83,10 +80,7 @@ var summarizeReadmeCmd = &cobra.Command{
 			"summary":     fmt.Sprintf("Found %d README files in the repository", len(readmeFiles)),
 		}
 
-		if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
-			os.Exit(1)
-		}
+		agentsdk.Respond(output)
 	},
 }
 
@@ -99,10 +93,7 @@ var listProjectsCmd = &cobra.Command{
 
 		// Find .csproj files
 		projects := []string{}
-		err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
+		err := walkSource(sourcePath, func(path string, d fs.DirEntry) error {
 			if strings.HasSuffix(path, ".csproj") {
 				relPath, err := filepath.Rel(sourcePath, path)
 				if err != nil {
@@ -113,7 +104,7 @@ var listProjectsCmd = &cobra.Command{
 			return nil
 		})
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error walking path: %v\n", err)
+			agentsdk.Logf("Error walking path: %v", err)
 		}
 
 		output := map[string]interface{}{
@@ -121,10 +112,7 @@ var listProjectsCmd = &cobra.Command{
 			"projects":     projects,
 		}
 
-		if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
-			os.Exit(1)
-		}
+		agentsdk.Respond(output)
 	},
 }
 
@@ -138,8 +126,8 @@ var getDependenciesCmd = &cobra.Command{
 		// Find and analyze .csproj files for dependencies
 		dependencies := map[string][]string{}
 
-		err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil || !strings.HasSuffix(path, ".csproj") {
+		err := walkSource(sourcePath, func(path string, d fs.DirEntry) error {
+			if !strings.HasSuffix(path, ".csproj") {
 				return nil
 			}
 
@@ -173,7 +161,7 @@ var getDependenciesCmd = &cobra.Command{
 			return nil
 		})
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error walking path: %v\n", err)
+			agentsdk.Logf("Error walking path: %v", err)
 		}
 
 		output := map[string]interface{}{
@@ -181,10 +169,7 @@ var getDependenciesCmd = &cobra.Command{
 			"summary":      fmt.Sprintf("Analyzed %d projects", len(dependencies)),
 		}
 
-		if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
-			os.Exit(1)
-		}
+		agentsdk.Respond(output)
 	},
 }
 
@@ -198,7 +183,7 @@ var getAPISurfaceCmd = &cobra.Command{
 		// Find all C# files
 		csFiles, err := findCSharpFiles(sourcePath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error finding C# files: %v\n", err)
+			agentsdk.Logf("Error finding C# files: %v", err)
 		}
 
 		// Parse all files
@@ -256,10 +241,7 @@ var getAPISurfaceCmd = &cobra.Command{
 			"apiSurface": allAPIs,
 		}
 
-		if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
-			os.Exit(1)
-		}
+		agentsdk.Respond(output)
 	},
 }
 
@@ -270,29 +252,24 @@ var getFileContentCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		filePath := args[0]
 
+		// Refuse paths that escape the source root, including via symlinks
+		resolved, err := agentsdk.ConfinePath(agentsdk.Params().SourceRoot(), filePath)
+		if err != nil {
+			agentsdk.RespondError("Access denied: %v", err)
+			return
+		}
+
 		// Check if file exists
-		info, err := os.Stat(filePath)
+		info, err := os.Stat(resolved)
 		if err != nil {
-			output := map[string]interface{}{
-				"error": fmt.Sprintf("File not found: %s", filePath),
-			}
-			if encodeErr := json.NewEncoder(os.Stdout).Encode(output); encodeErr != nil {
-				fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", encodeErr)
-				os.Exit(1)
-			}
+			agentsdk.RespondError("File not found: %s", filePath)
 			return
 		}
 
 		// Read file content
-		content, err := os.ReadFile(filePath)
+		content, err := os.ReadFile(resolved) // #nosec G304 - path is confined to the source root
 		if err != nil {
-			output := map[string]interface{}{
-				"error": fmt.Sprintf("Error reading file: %v", err),
-			}
-			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
-				fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
-				os.Exit(1)
-			}
+			agentsdk.RespondError("Error reading file: %v", err)
 			return
 		}
 
@@ -302,10 +279,7 @@ var getFileContentCmd = &cobra.Command{
 			Content: string(content),
 		}
 
-		if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
-			os.Exit(1)
-		}
+		agentsdk.Respond(output)
 	},
 }
 
@@ -335,8 +309,7 @@ func main() {
 	}
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		agentsdk.Fatalf("Error: %v", err)
 	}
 }
 
@@ -345,30 +318,30 @@ func runLegacyAnalysis(cmd *cobra.Command, args []string) {
 	outputPath := args[1]
 
 	// Read parameters from environment
-	includeInternal := parseBoolParam("PARAM_INCLUDE_INTERNAL", false)
-	maxDepth := parseIntParam("PARAM_MAX_DEPTH", 10)
-	extractMetrics := parseBoolParam("PARAM_EXTRACT_METRICS", true)
-
-	fmt.Fprintf(os.Stderr, "C# Analyzer Agent starting (legacy mode)...\n")
-	fmt.Fprintf(os.Stderr, "Source: %s\n", sourcePath)
-	fmt.Fprintf(os.Stderr, "Output: %s\n", outputPath)
-	fmt.Fprintf(os.Stderr, "Parameters: includeInternal=%v, maxDepth=%d, extractMetrics=%v\n",
+	params := agentsdk.Params()
+	includeInternal := params.Bool("include-internal", false)
+	maxDepth := params.Int("max-depth", 10)
+	extractMetrics := params.Bool("extract-metrics", true)
+
+	agentsdk.Logf("C# Analyzer Agent starting (legacy mode)...")
+	agentsdk.Logf("Source: %s", sourcePath)
+	agentsdk.Logf("Output: %s", outputPath)
+	agentsdk.Logf("Parameters: includeInternal=%v, maxDepth=%d, extractMetrics=%v",
 		includeInternal, maxDepth, extractMetrics)
 
 	// Ensure output directory exists
-	if err := os.MkdirAll(outputPath, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
-		os.Exit(1)
+	out, err := agentsdk.NewOutput(outputPath)
+	if err != nil {
+		agentsdk.Fatalf("Error creating output directory: %v", err)
 	}
 
 	// Find all C# files
 	csFiles, err := findCSharpFiles(sourcePath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error finding C# files: %v\n", err)
-		os.Exit(1)
+		agentsdk.Fatalf("Error finding C# files: %v", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Found %d C# files\n", len(csFiles))
+	agentsdk.Logf("Found %d C# files", len(csFiles))
 
 	// Parse all files
 	p := parser.New()
@@ -376,17 +349,17 @@ func runLegacyAnalysis(cmd *cobra.Command, args []string) {
 	namespaceMap := make(map[string]*parser.Namespace)
 
 	for _, file := range csFiles {
-		fmt.Fprintf(os.Stderr, "Analyzing: %s\n", file)
+		agentsdk.Logf("Analyzing: %s", file)
 
 		content, readErr := os.ReadFile(file)
 		if readErr != nil {
-			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", file, readErr)
+			agentsdk.Logf("Error reading %s: %v", file, readErr)
 			continue
 		}
 
 		api, parseErr := p.ExtractAPISurface(context.Background(), string(content))
 		if parseErr != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", file, parseErr)
+			agentsdk.Logf("Error parsing %s: %v", file, parseErr)
 			continue
 		}
 
@@ -412,50 +385,46 @@ func runLegacyAnalysis(cmd *cobra.Command, args []string) {
 	output := generateOutput(&allAPIs, extractMetrics)
 
 	// Write output files
-	if writeErr := writeProjectSummary(outputPath, &output.ProjectSummary); writeErr != nil {
-		fmt.Fprintf(os.Stderr, "failed to write project summary: %v\n", writeErr)
-		os.Exit(1)
+	if writeErr := writeProjectSummary(out, &output.ProjectSummary); writeErr != nil {
+		agentsdk.Fatalf("failed to write project summary: %v", writeErr)
 	}
-	if writeErr := writeAPISurface(outputPath, &allAPIs); writeErr != nil {
-		fmt.Fprintf(os.Stderr, "failed to write API surface: %v\n", writeErr)
-		os.Exit(1)
+	if writeErr := writeAPISurface(out, &allAPIs); writeErr != nil {
+		agentsdk.Fatalf("failed to write API surface: %v", writeErr)
 	}
-	if writeErr := writeArchitecturalInsights(outputPath, &output.ArchitecturalInsights); writeErr != nil {
-		fmt.Fprintf(os.Stderr, "failed to write architectural insights: %v\n", writeErr)
+	if writeErr := writeArchitecturalInsights(out, &output.ArchitecturalInsights); writeErr != nil {
+		agentsdk.Logf("failed to write architectural insights: %v", writeErr)
 		// Non-fatal, continue
 	}
 
 	// Write JSON output
-	jsonPath := filepath.Join(outputPath, "analysis.json")
-	jsonData, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+	if err := out.WriteJSON("analysis.json", output); err != nil {
+		agentsdk.Logf("Error writing JSON file: %v", err)
 	}
-	if err := os.WriteFile(jsonPath, jsonData, 0600); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing JSON file: %v\n", err)
+	if err := out.Close(); err != nil {
+		agentsdk.Fatalf("%v", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Analysis complete. Output written to %s\n", outputPath)
+	agentsdk.Logf("Analysis complete. Output written to %s", outputPath)
 }
 
 // Helper functions (same as before)
 func findCSharpFiles(root string) ([]string, error) {
 	var files []string
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	err := agentsdk.Walk(root, agentsdk.Params().WalkLimits(), func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Skip common non-source directories
-		if info.IsDir() {
-			name := info.Name()
+		if d.IsDir() {
+			name := d.Name()
 			if name == "bin" || name == "obj" || name == ".git" || name == "packages" {
 				return filepath.SkipDir
 			}
 		}
 
-		if !info.IsDir() && strings.HasSuffix(path, ".cs") {
+		if !d.IsDir() && strings.HasSuffix(path, ".cs") {
 			files = append(files, path)
 		}
 
@@ -465,6 +434,17 @@ func findCSharpFiles(root string) ([]string, error) {
 	return files, err
 }
 
+// walkSource visits the files below root, staying inside it and within the walk
+// limits. Unreadable entries are skipped and reaching the file limit truncates the walk.
+func walkSource(root string, fn func(path string, d fs.DirEntry) error) error {
+	return agentsdk.Walk(root, agentsdk.Params().WalkLimits(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
 func generateOutput(api *parser.APISurface, _ bool) *AgentOutput {
 	output := &AgentOutput{
 		APISurface: api,
@@ -562,7 +542,7 @@ func shouldIncludeNamespace(ns *parser.Namespace) bool {
 	return false
 }
 
-func writeProjectSummary(outputPath string, summary *ProjectSummary) error {
+func writeProjectSummary(out *agentsdk.Output, summary *ProjectSummary) error {
 	content := fmt.Sprintf(`# Project Summary
 
 ## Statistics
@@ -593,11 +573,10 @@ The codebase exposes %d public APIs with a total of %d methods and %d properties
 		summary.TotalProperties,
 	)
 
-	path := filepath.Join(outputPath, "ProjectSummary.md")
-	return os.WriteFile(path, []byte(content), 0600)
+	return out.WriteMarkdown("ProjectSummary.md", content)
 }
 
-func writeAPISurface(outputPath string, api *parser.APISurface) error {
+func writeAPISurface(out *agentsdk.Output, api *parser.APISurface) error {
 	var sb strings.Builder
 
 	sb.WriteString("# API Surface\n\n")
@@ -667,11 +646,10 @@ func writeAPISurface(outputPath string, api *parser.APISurface) error {
 		}
 	}
 
-	path := filepath.Join(outputPath, "ApiSurface.md")
-	return os.WriteFile(path, []byte(sb.String()), 0600)
+	return out.WriteMarkdown("ApiSurface.md", sb.String())
 }
 
-func writeArchitecturalInsights(outputPath string, insights *ArchitecturalInsights) error {
+func writeArchitecturalInsights(out *agentsdk.Output, insights *ArchitecturalInsights) error {
 	var sb strings.Builder
 
 	sb.WriteString("# Architectural Insights\n\n")
@@ -719,26 +697,5 @@ func writeArchitecturalInsights(outputPath string, insights *ArchitecturalInsigh
 		sb.WriteString("- Consider implementing common design patterns where appropriate\n")
 	}
 
-	path := filepath.Join(outputPath, "ArchitecturalInsights.md")
-	return os.WriteFile(path, []byte(sb.String()), 0600)
-}
-
-func parseBoolParam(name string, defaultValue bool) bool {
-	val := os.Getenv(name)
-	if val == "" {
-		return defaultValue
-	}
-	return strings.ToLower(val) == "true"
-}
-
-func parseIntParam(name string, defaultValue int) int {
-	val := os.Getenv(name)
-	if val == "" {
-		return defaultValue
-	}
-	i, err := strconv.Atoi(val)
-	if err != nil {
-		return defaultValue
-	}
-	return i
+	return out.WriteMarkdown("ArchitecturalInsights.md", sb.String())
 }
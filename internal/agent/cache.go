@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -31,15 +32,28 @@ func NewArtifactCache() (*ArtifactCache, error) {
 // CreateRunDirectory creates a unique directory for an agent run.
 func (c *ArtifactCache) CreateRunDirectory(agentName string) (string, error) {
 	// Create timestamp-based unique directory
-	timestamp := time.Now().Format("20060102-150405")
-	runID := fmt.Sprintf("%s-%s-%d", agentName, timestamp, os.Getpid())
+	runID := runID(agentName, time.Now())
 	runDir := filepath.Join(c.baseDir, runID)
-
-	if err := os.MkdirAll(runDir, 0755); err != nil {
+	if err := os.MkdirAll(c.baseDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create run directory: %w", err)
 	}
 
-	return runDir, nil
+	// Runs within the same second (such as a retry) get a numbered directory
+	for attempt := 2; ; attempt++ {
+		err := os.Mkdir(runDir, 0755)
+		if err == nil {
+			return runDir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create run directory: %w", err)
+		}
+		runDir = filepath.Join(c.baseDir, fmt.Sprintf("%s-%d", runID, attempt))
+	}
+}
+
+// runID returns the name of the run directory of an agent run started at now.
+func runID(agentName string, now time.Time) string {
+	return fmt.Sprintf("%s-%s-%d", agentName, now.Format("20060102-150405"), os.Getpid())
 }
 
 // Clean removes old cache directories (older than 24 hours).
@@ -73,7 +87,119 @@ func (c *ArtifactCache) Clean() error {
 	return nil
 }
 
+// Prune removes run directories older than maxAge (no age limit when zero), then the
+// oldest run directories until the cache holds at most maxBytes (no size limit when
+// zero). It returns the number of run directories removed and the bytes reclaimed.
+func (c *ArtifactCache) Prune(maxAge time.Duration, maxBytes int64) (int, int64, error) {
+	entries, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	type run struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var runs []run
+	var total int64
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.baseDir, entry.Name())
+		size := directorySize(path)
+		runs = append(runs, run{path, size, info.ModTime()})
+		total += size
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].modTime.Before(runs[j].modTime) })
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	var reclaimed int64
+	for _, r := range runs {
+		expired := maxAge > 0 && r.modTime.Before(cutoff)
+		if !expired && (maxBytes <= 0 || total <= maxBytes) {
+			break
+		}
+		if err := os.RemoveAll(r.path); err != nil {
+			return removed, reclaimed, fmt.Errorf("failed to remove %s: %w", r.path, err)
+		}
+		total -= r.size
+		removed++
+		reclaimed += r.size
+	}
+	return removed, reclaimed, nil
+}
+
+// directorySize returns the total size of the regular files below dir.
+func directorySize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
 // GetBaseDir returns the base directory for the cache.
 func (c *ArtifactCache) GetBaseDir() string {
 	return c.baseDir
 }
+
+// toolResultsDir is the directory of the artifact cache holding tool results. Like
+// run directories it is removed by Clean and Prune once it grows old or large.
+const toolResultsDir = "tool-results"
+
+// ToolResultCache stores the outputs of agent tools across runs, so analyses of
+// unchanged sources can reuse them instead of running the tools again.
+type ToolResultCache struct {
+	dir string
+}
+
+// ToolResults returns the cache of tool outputs kept in the artifact cache.
+func (c *ArtifactCache) ToolResults() *ToolResultCache {
+	return &ToolResultCache{dir: filepath.Join(c.baseDir, toolResultsDir)}
+}
+
+// Get returns the output stored under key, a hex hash identifying the tool call.
+func (c *ToolResultCache) Get(key string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".txt")) // #nosec G304 - path is derived from a hex hash
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put stores output under key.
+func (c *ToolResultCache) Put(key, output string) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create tool result cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write tool result: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if _, err := tmp.WriteString(output); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write tool result: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write tool result: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(c.dir, key+".txt")); err != nil {
+		return fmt.Errorf("failed to write tool result: %w", err)
+	}
+	return nil
+}
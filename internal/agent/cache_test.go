@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRun creates a run directory holding size bytes, last modified at modTime.
+func writeRun(t *testing.T, cache *ArtifactCache, name string, size int, modTime time.Time) string {
+	t.Helper()
+	dir := filepath.Join(cache.GetBaseDir(), name)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "output.json"), []byte(strings.Repeat("x", size)), 0644))
+	require.NoError(t, os.Chtimes(dir, modTime, modTime))
+	return dir
+}
+
+func TestArtifactCache_Prune(t *testing.T) {
+	now := time.Now()
+
+	t.Run("removes expired runs", func(t *testing.T) {
+		cache := &ArtifactCache{baseDir: t.TempDir()}
+		expired := writeRun(t, cache, "agent-old", 100, now.Add(-72*time.Hour))
+		recent := writeRun(t, cache, "agent-new", 50, now.Add(-time.Hour))
+
+		removed, reclaimed, err := cache.Prune(48*time.Hour, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+		assert.Equal(t, int64(100), reclaimed)
+		assert.NoDirExists(t, expired)
+		assert.DirExists(t, recent)
+	})
+
+	t.Run("removes oldest runs beyond the size limit", func(t *testing.T) {
+		cache := &ArtifactCache{baseDir: t.TempDir()}
+		oldest := writeRun(t, cache, "agent-1", 100, now.Add(-3*time.Hour))
+		middle := writeRun(t, cache, "agent-2", 100, now.Add(-2*time.Hour))
+		newest := writeRun(t, cache, "agent-3", 100, now.Add(-time.Hour))
+
+		removed, reclaimed, err := cache.Prune(0, 150)
+		require.NoError(t, err)
+		assert.Equal(t, 2, removed)
+		assert.Equal(t, int64(200), reclaimed)
+		assert.NoDirExists(t, oldest)
+		assert.NoDirExists(t, middle)
+		assert.DirExists(t, newest)
+	})
+}
+
+func TestToolResultCache(t *testing.T) {
+	cache := (&ArtifactCache{baseDir: t.TempDir()}).ToolResults()
+
+	_, ok := cache.Get("abc123")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Put("abc123", "Payments.csproj\nLedger.csproj"))
+	output, ok := cache.Get("abc123")
+	require.True(t, ok)
+	assert.Equal(t, "Payments.csproj\nLedger.csproj", output)
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog"
 )
@@ -17,6 +19,9 @@ type Executor struct {
 	registry *Registry
 	cache    *ArtifactCache
 	logger   zerolog.Logger
+
+	transcriptMu sync.Mutex
+	transcript   io.Writer // Optional copy of agent output, set by SetTranscript
 }
 
 // NewExecutor creates a new agent executor.
@@ -28,6 +33,12 @@ func NewExecutor(registry *Registry, cache *ArtifactCache, logger zerolog.Logger
 	}
 }
 
+// SetTranscript copies every line agents write to stdout and stderr to w, prefixed
+// with the stream, regardless of the log level. A nil writer disables the copy.
+func (e *Executor) SetTranscript(w io.Writer) {
+	e.transcript = w
+}
+
 // RunOptions contains options for running an agent.
 type RunOptions struct {
 	AgentName  string            // Name of the agent to run
@@ -40,9 +51,13 @@ type RunOptions struct {
 type RunResult struct {
 	OutputPath string // Path to the output directory containing artifacts
 	ExitCode   int    // Exit code from the agent process
+	Retried    bool   // The agent was re-run because its first output failed postconditions
+	Runner     RunnerInfo
 }
 
-// Run executes an agent with the given options.
+// Run executes an agent with the given options. When the agent declares
+// postconditions its output is verified; if they fail and the agent declares a retry,
+// it is run once more with the retry's parameter overrides before failing.
 func (e *Executor) Run(opts RunOptions) (*RunResult, error) {
 	// Look up agent in registry
 	agent, exists := e.registry.Get(opts.AgentName)
@@ -50,54 +65,63 @@ func (e *Executor) Run(opts RunOptions) (*RunResult, error) {
 		return nil, fmt.Errorf("agent not found: %s", opts.AgentName)
 	}
 
-	e.logger.Info().
-		Str("agent", opts.AgentName).
-		Str("source", opts.SourcePath).
-		Msg("Executing agent")
-
-	// Create unique output directory for this run
-	outputPath, err := e.cache.CreateRunDirectory(opts.AgentName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	result, err := e.runOnce(agent, opts)
+	if err != nil || len(agent.Spec.Postconditions) == 0 {
+		return result, err
+	}
+	checkErr := checkPostconditions(agent, result.OutputPath)
+	if checkErr == nil {
+		return result, nil
+	}
+	if agent.Spec.Retry == nil {
+		return nil, checkErr
 	}
 
-	// Prepare command with arguments
-	args := make([]string, 0, len(agent.Spec.Runner.Args)+2)
+	e.logger.Warn().
+		Err(checkErr).
+		Str("agent", opts.AgentName).
+		Interface("parameters", agent.Spec.Retry.Parameters).
+		Msg("Agent output failed postconditions, re-running with adjusted parameters")
 
-	// Add configured args from agent definition
-	for _, arg := range agent.Spec.Runner.Args {
-		// Replace placeholders
-		arg = strings.ReplaceAll(arg, "${SOURCE_PATH}", opts.SourcePath)
-		arg = strings.ReplaceAll(arg, "${OUTPUT_PATH}", outputPath)
-		args = append(args, arg)
+	retryOpts := opts
+	retryOpts.Parameters = make(map[string]string, len(opts.Parameters)+len(agent.Spec.Retry.Parameters))
+	for key, value := range opts.Parameters {
+		retryOpts.Parameters[key] = value
 	}
-
-	// If no args specified, use default pattern (source output)
-	if len(args) == 0 {
-		args = []string{opts.SourcePath, outputPath}
+	for key, value := range agent.Spec.Retry.Parameters {
+		retryOpts.Parameters[key] = value
 	}
+	result, err = e.runOnce(agent, retryOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPostconditions(agent, result.OutputPath); err != nil {
+		return nil, fmt.Errorf("%w (after retry)", err)
+	}
+	result.Retried = true
+	return result, nil
+}
 
-	cmd := exec.Command(agent.Spec.Runner.Command, args...) // #nosec G204 - Agent commands are from trusted configuration
-
-	// Set up environment variables for parameters
-	env := os.Environ()
+// runOnce executes an agent process once.
+func (e *Executor) runOnce(agent *Definition, opts RunOptions) (*RunResult, error) {
+	e.logger.Info().
+		Str("agent", opts.AgentName).
+		Str("source", opts.SourcePath).
+		Msg("Executing agent")
 
-	// First, apply default values from agent definition
-	for _, param := range agent.Spec.Parameters {
-		if param.Default != nil {
-			envKey := fmt.Sprintf("PARAM_%s", strings.ToUpper(param.Name))
-			envVal := fmt.Sprintf("%v", param.Default)
-			env = append(env, fmt.Sprintf("%s=%s", envKey, envVal))
-		}
+	runner, err := verifyRunner(agent)
+	if err != nil {
+		return nil, err
 	}
 
-	// Then apply overrides from options
-	for key, value := range opts.Parameters {
-		envKey := fmt.Sprintf("PARAM_%s", strings.ToUpper(key))
-		env = append(env, fmt.Sprintf("%s=%s", envKey, value))
+	// Create unique output directory for this run
+	outputPath, err := e.cache.CreateRunDirectory(opts.AgentName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	cmd.Env = env
+	cmd := exec.Command(agent.Spec.Runner.Command, runnerArgs(agent, opts.SourcePath, outputPath)...) // #nosec G204 - Agent commands are from trusted configuration
+	cmd.Env = append(os.Environ(), parameterEnv(agent, opts.Parameters)...)
 
 	// Set up stdout and stderr pipes for logging
 	stdout, err := cmd.StdoutPipe()
@@ -115,9 +139,18 @@ func (e *Executor) Run(opts RunOptions) (*RunResult, error) {
 		return nil, fmt.Errorf("failed to start agent: %w", err)
 	}
 
-	// Stream output to logger
-	go e.streamOutput(stdout, "stdout", opts.AgentName)
-	go e.streamOutput(stderr, "stderr", opts.AgentName)
+	// Stream output to logger; the pipes must be drained before waiting
+	var streams sync.WaitGroup
+	streams.Add(2)
+	go func() {
+		defer streams.Done()
+		e.streamOutput(stdout, "stdout", opts.AgentName)
+	}()
+	go func() {
+		defer streams.Done()
+		e.streamOutput(stderr, "stderr", opts.AgentName)
+	}()
+	streams.Wait()
 
 	// Wait for completion
 	err = cmd.Wait()
@@ -144,9 +177,47 @@ func (e *Executor) Run(opts RunOptions) (*RunResult, error) {
 	return &RunResult{
 		OutputPath: outputPath,
 		ExitCode:   exitCode,
+		Runner:     runner,
 	}, nil
 }
 
+// runnerArgs returns the arguments of the agent's runner, with the ${SOURCE_PATH} and
+// ${OUTPUT_PATH} placeholders replaced, or the source and output paths when the
+// definition declares none.
+func runnerArgs(agent *Definition, sourcePath, outputPath string) []string {
+	if len(agent.Spec.Runner.Args) == 0 {
+		return []string{sourcePath, outputPath}
+	}
+	args := make([]string, 0, len(agent.Spec.Runner.Args))
+	for _, arg := range agent.Spec.Runner.Args {
+		arg = strings.ReplaceAll(arg, "${SOURCE_PATH}", sourcePath)
+		arg = strings.ReplaceAll(arg, "${OUTPUT_PATH}", outputPath)
+		args = append(args, arg)
+	}
+	return args
+}
+
+// parameterEnv returns the PARAM_* environment variables passing the parameters to
+// the agent: the defaults of the definition, then the overrides, which take
+// precedence by coming later.
+func parameterEnv(agent *Definition, overrides map[string]string) []string {
+	var env []string
+	for _, param := range agent.Spec.Parameters {
+		if param.Default != nil {
+			env = append(env, fmt.Sprintf("PARAM_%s=%v", strings.ToUpper(param.Name), param.Default))
+		}
+	}
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, fmt.Sprintf("PARAM_%s=%s", strings.ToUpper(key), overrides[key]))
+	}
+	return env
+}
+
 // streamOutput streams output from a reader to the logger.
 func (e *Executor) streamOutput(reader io.Reader, stream string, agentName string) {
 	scanner := bufio.NewScanner(reader)
@@ -156,6 +227,11 @@ func (e *Executor) streamOutput(reader io.Reader, stream string, agentName strin
 			Str("stream", stream).
 			Str("line", scanner.Text()).
 			Msg("Agent output")
+		if e.transcript != nil {
+			e.transcriptMu.Lock()
+			fmt.Fprintf(e.transcript, "[%s %s] %s\n", agentName, stream, scanner.Text())
+			e.transcriptMu.Unlock()
+		}
 	}
 }
 
@@ -187,8 +263,10 @@ func (e *Executor) ValidateOutput(outputPath string) error {
 	return nil
 }
 
-// RunTool executes a specific tool from an agent.
-func (e *Executor) RunTool(agentName, toolName string, params map[string]string) (string, error) {
+// RunTool executes a specific tool from an agent. sourceRoot is the trusted source
+// directory: it is passed to the tool as SOURCE_PATH, whatever params say, and
+// path-like parameters must resolve inside it.
+func (e *Executor) RunTool(agentName, toolName, sourceRoot string, params map[string]string) (string, error) {
 	// Look up agent in registry
 	agent, exists := e.registry.Get(agentName)
 	if !exists {
@@ -208,6 +286,17 @@ func (e *Executor) RunTool(agentName, toolName string, params map[string]string)
 		return "", fmt.Errorf("tool '%s' not found in agent '%s'", toolName, agentName)
 	}
 
+	// Keep model-supplied paths inside the source root
+	params, err := confinePathParams(sourceRoot, params)
+	if err != nil {
+		e.logger.Warn().
+			Str("agent", agentName).
+			Str("tool", toolName).
+			Err(err).
+			Msg("Rejected tool parameters")
+		return "", err
+	}
+
 	e.logger.Info().
 		Str("agent", agentName).
 		Str("tool", toolName).
@@ -36,6 +36,9 @@ if [ "$1" = "list_projects" ]; then
 elif [ "$1" = "get_file_content" ]; then
     echo "File content for: $2"
     exit 0
+elif [ "$1" = "source_root" ]; then
+    echo "Root: $PARAM_SOURCE_PATH"
+    exit 0
 else
     echo "Unknown tool: $1" >&2
     exit 1
@@ -65,6 +68,12 @@ fi
 					Command:     mockScript,
 					Args:        []string{"get_file_content", "${FILE_PATH}"},
 				},
+				{
+					Name:        "source_root",
+					Description: "Prints the source root passed to tools",
+					Command:     mockScript,
+					Args:        []string{"source_root"},
+				},
 			},
 		},
 	}
@@ -88,7 +97,7 @@ fi
 
 	t.Run("list_projects tool", func(t *testing.T) {
 		// Act
-		output, err := executor.RunTool("test-toolkit", "list_projects", nil)
+		output, err := executor.RunTool("test-toolkit", "list_projects", "", nil)
 
 		// Assert
 		require.NoError(t, err)
@@ -102,16 +111,33 @@ fi
 		params := map[string]string{
 			"FILE_PATH": "/test/file.go",
 		}
-		output, err := executor.RunTool("test-toolkit", "get_file_content", params)
+		output, err := executor.RunTool("test-toolkit", "get_file_content", "", params)
 
 		// Assert
 		require.NoError(t, err)
 		assert.Contains(t, output, "File content for: /test/file.go")
 	})
 
+	t.Run("model cannot widen the source root", func(t *testing.T) {
+		sourceDir := filepath.Join(tempDir, "source")
+		require.NoError(t, os.MkdirAll(sourceDir, 0755))
+
+		for _, root := range []string{"/", tempDir, sourceDir + "/.."} {
+			_, err := executor.RunTool("test-toolkit", "get_file_content", sourceDir, map[string]string{
+				"SOURCE_PATH": root,
+				"FILE_PATH":   filepath.Join(tempDir, "mock-agent.sh"),
+			})
+			assert.ErrorIs(t, err, ErrOutsideRoot, "SOURCE_PATH %s", root)
+		}
+
+		output, err := executor.RunTool("test-toolkit", "source_root", sourceDir, map[string]string{"source_path": "/"})
+		require.NoError(t, err)
+		assert.Contains(t, output, "Root: "+sourceDir)
+	})
+
 	t.Run("non-existent tool", func(t *testing.T) {
 		// Act
-		_, err := executor.RunTool("test-toolkit", "unknown_tool", nil)
+		_, err := executor.RunTool("test-toolkit", "unknown_tool", "", nil)
 
 		// Assert
 		assert.Error(t, err)
@@ -120,7 +146,7 @@ fi
 
 	t.Run("non-existent agent", func(t *testing.T) {
 		// Act
-		_, err := executor.RunTool("non-existent", "list_projects", nil)
+		_, err := executor.RunTool("non-existent", "list_projects", "", nil)
 
 		// Assert
 		assert.Error(t, err)
@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryDir is the directory the artifacts of agent runs are kept in.
+var DefaultHistoryDir = filepath.Join(".docloom", "history")
+
+// historyRunFile describes a run in its history directory.
+const historyRunFile = "run.json"
+
+// HistoryRun is an agent run kept in the history, with copies of its artifacts.
+type HistoryRun struct {
+	Agent  string    `json:"agent"`
+	Source string    `json:"source"`
+	Commit string    `json:"commit,omitempty"` // Commit of the analyzed source, when it is a git checkout
+	Time   time.Time `json:"time"`
+	Files  []string  `json:"files"` // Artifacts, slash-separated and relative to Dir
+
+	Dir string `json:"-"` // Directory holding the artifacts
+}
+
+// History keeps the artifacts of agent runs beyond the artifact cache, one
+// directory per agent and source commit, for comparing analyses over time.
+type History struct {
+	dir string
+}
+
+// NewHistory returns the history in dir, DefaultHistoryDir when empty.
+func NewHistory(dir string) *History {
+	if dir == "" {
+		dir = DefaultHistoryDir
+	}
+	return &History{dir: dir}
+}
+
+// Record copies the artifacts in outputDir of a run of agentName on source to the
+// history. A run of the same source commit replaces the earlier one, so that
+// re-running an analysis does not count as a change.
+func (h *History) Record(agentName, source, outputDir string, now time.Time) (*HistoryRun, error) {
+	run := &HistoryRun{Agent: agentName, Source: source, Commit: SourceCommit(source), Time: now.UTC()}
+	agentDir := filepath.Join(h.dir, agentName)
+	if run.Commit != "" {
+		existing, err := h.Runs(agentName, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, previous := range existing {
+			if previous.Commit == run.Commit {
+				if err := os.RemoveAll(previous.Dir); err != nil {
+					return nil, fmt.Errorf("failed to replace run of commit %s: %w", run.Commit, err)
+				}
+			}
+		}
+	}
+
+	name := run.Time.Format("20060102-150405")
+	if run.Commit != "" {
+		name += "-" + shortCommit(run.Commit)
+	}
+	run.Dir = filepath.Join(agentDir, name)
+	if err := os.MkdirAll(run.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	err := filepath.WalkDir(outputDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path) // #nosec G304 - artifacts of the agent run
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(run.Dir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0600); err != nil {
+			return err
+		}
+		run.Files = append(run.Files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy artifacts to history: %w", err)
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(run.Dir, historyRunFile), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write history run: %w", err)
+	}
+	return run, nil
+}
+
+// Runs returns the runs of agentName in the history at or after since, oldest first.
+func (h *History) Runs(agentName string, since time.Time) ([]HistoryRun, error) {
+	entries, err := os.ReadDir(filepath.Join(h.dir, agentName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	var runs []HistoryRun
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(h.dir, agentName, entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, historyRunFile)) // #nosec G304 - history entries
+		if err != nil {
+			continue // Not a recorded run, or one being replaced
+		}
+		var run HistoryRun
+		if err := json.Unmarshal(data, &run); err != nil {
+			return nil, fmt.Errorf("invalid history run %s: %w", dir, err)
+		}
+		if run.Time.Before(since) {
+			continue
+		}
+		run.Dir = dir
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Time.Before(runs[j].Time) })
+	return runs, nil
+}
+
+// SourceCommit returns the commit checked out at path, or an empty string when path
+// is not in a git working tree.
+func SourceCommit(path string) string {
+	dir := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output() // #nosec G204 - fixed git command
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// shortCommit abbreviates a commit hash for directory names.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
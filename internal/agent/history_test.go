@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArtifacts(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestHistory_RecordAndRuns(t *testing.T) {
+	history := NewHistory(t.TempDir())
+	source := t.TempDir() // Not a git checkout
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	first, err := history.Record("csharp-analyzer", source, writeArtifacts(t, map[string]string{
+		"metrics.json":       `{"debt": 10}`,
+		"reports/summary.md": "# Summary",
+	}), start)
+	require.NoError(t, err)
+	assert.Empty(t, first.Commit)
+	assert.ElementsMatch(t, []string{"metrics.json", "reports/summary.md"}, first.Files)
+	data, err := os.ReadFile(filepath.Join(first.Dir, "reports", "summary.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Summary", string(data))
+
+	_, err = history.Record("csharp-analyzer", source, writeArtifacts(t, map[string]string{"metrics.json": `{"debt": 12}`}), start.AddDate(0, 0, 30))
+	require.NoError(t, err)
+	_, err = history.Record("other-agent", source, writeArtifacts(t, map[string]string{"metrics.json": `{}`}), start)
+	require.NoError(t, err)
+
+	runs, err := history.Runs("csharp-analyzer", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.True(t, runs[0].Time.Equal(start))
+	assert.Equal(t, first.Dir, runs[0].Dir)
+
+	runs, err = history.Runs("csharp-analyzer", start.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+
+	runs, err = history.Runs("unknown", time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestHistory_Record_ReplacesRunOfSameCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	source := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		require.NoError(t, exec.Command("git", append([]string{"-C", source}, args...)...).Run())
+	}
+	commit := SourceCommit(source)
+	require.Len(t, commit, 40)
+
+	history := NewHistory(t.TempDir())
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	first, err := history.Record("csharp-analyzer", source, writeArtifacts(t, map[string]string{"metrics.json": `{"debt": 10}`}), start)
+	require.NoError(t, err)
+	assert.Equal(t, commit, first.Commit)
+
+	second, err := history.Record("csharp-analyzer", source, writeArtifacts(t, map[string]string{"metrics.json": `{"debt": 11}`}), start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.NoDirExists(t, first.Dir)
+
+	runs, err := history.Runs("csharp-analyzer", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, second.Dir, runs[0].Dir)
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RunnerInfo identifies the runner binary an agent run used, for run reports.
+type RunnerInfo struct {
+	Agent   string `json:"agent"`
+	Version string `json:"version,omitempty"`
+	Command string `json:"command"`
+	SHA256  string `json:"sha256,omitempty"`
+	Pinned  bool   `json:"pinned"` // The hash was verified against the manifest
+}
+
+// validate checks that a pinned checksum is a hex-encoded SHA-256.
+func (r Runner) validate() error {
+	if r.SHA256 == "" {
+		return nil
+	}
+	if decoded, err := hex.DecodeString(r.SHA256); err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("runner sha256 %q is not a hex-encoded SHA-256 checksum", r.SHA256)
+	}
+	return nil
+}
+
+// verifyRunner resolves the runner command and hashes it. When the manifest pins a
+// checksum, a binary that does not match is rejected so an agent updated on a shared
+// machine cannot silently change behavior. Commands that cannot be resolved are left
+// for exec to report, unless they are pinned.
+func verifyRunner(def *Definition) (RunnerInfo, error) {
+	runner := def.Spec.Runner
+	info := RunnerInfo{
+		Agent:   def.Metadata.Name,
+		Version: runner.Version,
+		Command: runner.Command,
+	}
+
+	path, err := exec.LookPath(runner.Command)
+	if err != nil {
+		if runner.SHA256 != "" {
+			return info, fmt.Errorf("agent %s: cannot verify runner %s: %w", info.Agent, runner.Command, err)
+		}
+		return info, nil
+	}
+	info.SHA256, err = fileSHA256(path)
+	if err != nil {
+		return info, fmt.Errorf("agent %s: failed to hash runner: %w", info.Agent, err)
+	}
+
+	if runner.SHA256 != "" {
+		if !strings.EqualFold(info.SHA256, runner.SHA256) {
+			return info, fmt.Errorf("agent %s: runner %s has sha256 %s, but the manifest pins %s",
+				info.Agent, path, info.SHA256, strings.ToLower(runner.SHA256))
+		}
+		info.Pinned = true
+	}
+	return info, nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path) // #nosec G304 - runner commands are from trusted configuration
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/ignore"
+)
+
+// Default safeguards for directory walks performed on behalf of a model.
+const (
+	DefaultMaxWalkDepth = 20
+	DefaultMaxWalkFiles = 10000
+)
+
+// ErrOutsideRoot is returned when a path resolves outside the source root.
+var ErrOutsideRoot = errors.New("path is outside the source root")
+
+// ErrWalkLimit is returned by WalkConfined when the file count limit is reached.
+var ErrWalkLimit = errors.New("walk file limit reached")
+
+// WalkLimits bounds a directory walk. Zero values fall back to the defaults.
+type WalkLimits struct {
+	MaxDepth int // Maximum directory depth below the root
+	MaxFiles int // Maximum number of files visited
+}
+
+// ConfinePath resolves path against root, following symlinks, and returns the
+// resolved absolute path. Relative paths are interpreted relative to root. An error
+// wrapping ErrOutsideRoot is returned when the resolved path escapes root.
+func ConfinePath(root, path string) (string, error) {
+	resolvedRoot, err := resolveRoot(root)
+	if err != nil {
+		return "", err
+	}
+
+	// Not joined with filepath.Join, which would apply .. elements before the
+	// symlinks they follow are resolved
+	candidate := path
+	if !filepath.IsAbs(candidate) {
+		candidate = resolvedRoot + string(filepath.Separator) + candidate
+	}
+	resolved, err := filepath.EvalSymlinks(candidate)
+	if os.IsNotExist(err) {
+		// A file still to be created: resolve the directories that exist, which may
+		// be symlinks, and append the rest
+		resolved, err = resolveMissing(candidate)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+
+	if !withinRoot(resolvedRoot, resolved) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+	return resolved, nil
+}
+
+// resolveMissing resolves an absolute path that does not exist element by element:
+// existing elements are resolved with their symlinks, and once an element is missing,
+// the remaining ones are appended lexically. A dangling symlink is an error, as
+// creating the file would follow it.
+func resolveMissing(path string) (string, error) {
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(filepath.Separator)
+	elements := strings.Split(path[len(volume):], string(filepath.Separator))
+	for i, element := range elements {
+		switch element {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, element)
+		if _, err := os.Lstat(next); os.IsNotExist(err) {
+			return filepath.Join(append([]string{resolved}, elements[i:]...)...), nil
+		}
+		var err error
+		if resolved, err = filepath.EvalSymlinks(next); err != nil {
+			return "", err
+		}
+	}
+	return resolved, nil
+}
+
+// WalkConfined walks root like filepath.WalkDir but never leaves it: symlinks that
+// resolve outside root are skipped, directories deeper than MaxDepth are not entered
+// and the walk stops with ErrWalkLimit once MaxFiles files have been visited. Paths
+// excluded by the root's .docloomignore file or by .gitignore files are skipped.
+func WalkConfined(root string, limits WalkLimits, fn fs.WalkDirFunc) error {
+	resolvedRoot, err := resolveRoot(root)
+	if err != nil {
+		return err
+	}
+	matcher, err := ignore.Load(root)
+	if err != nil {
+		return err
+	}
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultMaxWalkDepth
+	}
+	if limits.MaxFiles <= 0 {
+		limits.MaxFiles = DefaultMaxWalkFiles
+	}
+
+	files := 0
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(path, d, err)
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, evalErr := filepath.EvalSymlinks(path)
+			if evalErr != nil || !withinRoot(resolvedRoot, target) {
+				return nil
+			}
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr == nil && matcher.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if walkDepth(root, path) > limits.MaxDepth {
+				return filepath.SkipDir
+			}
+			if relErr == nil && rel != "." {
+				if matcher, err = ignore.LoadNested(matcher, root, rel); err != nil {
+					return err
+				}
+			}
+			return fn(path, d, nil)
+		}
+		if files >= limits.MaxFiles {
+			return ErrWalkLimit
+		}
+		files++
+		return fn(path, d, nil)
+	})
+}
+
+// confinePathParams sets the SOURCE_PATH parameter to the trusted source root,
+// replacing any value supplied with the parameters, and resolves path-like parameters
+// against it, rejecting any that escape it. Path parameters are left untouched when
+// no source root is set.
+func confinePathParams(root string, params map[string]string) (map[string]string, error) {
+	confined := make(map[string]string, len(params)+1)
+	for key, value := range params {
+		if strings.EqualFold(key, "SOURCE_PATH") {
+			continue
+		}
+		if root != "" && isPathParam(key) && value != "" {
+			resolved, err := ConfinePath(root, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s parameter: %w", key, err)
+			}
+			value = resolved
+		}
+		confined[key] = value
+	}
+	if root != "" {
+		confined["SOURCE_PATH"] = root
+	}
+	return confined, nil
+}
+
+// isPathParam reports whether a tool parameter names a file or directory.
+func isPathParam(key string) bool {
+	upper := strings.ToUpper(key)
+	switch upper {
+	case "SOURCE_PATH", "OUTPUT_PATH":
+		return false
+	case "PATH", "FILE", "DIR", "DIRECTORY":
+		return true
+	}
+	return strings.HasSuffix(upper, "_PATH") || strings.HasSuffix(upper, "_FILE") || strings.HasSuffix(upper, "_DIR")
+}
+
+// resolveRoot returns the absolute, symlink-free form of root.
+func resolveRoot(root string) (string, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve source root %s: %w", root, err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve source root %s: %w", root, err)
+	}
+	return resolved, nil
+}
+
+// withinRoot reports whether path is root or lies below it.
+func withinRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// walkDepth returns how many directories path lies below root.
+func walkDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfinePath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "app.cs"), []byte("class App {}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")))
+
+	t.Run("relative path inside root", func(t *testing.T) {
+		resolved, err := ConfinePath(root, "src/app.cs")
+		require.NoError(t, err)
+		assert.Equal(t, "app.cs", filepath.Base(resolved))
+	})
+
+	t.Run("absolute path inside root", func(t *testing.T) {
+		_, err := ConfinePath(root, filepath.Join(root, "src", "app.cs"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("parent traversal", func(t *testing.T) {
+		_, err := ConfinePath(root, "../../etc/passwd")
+		assert.True(t, errors.Is(err, ErrOutsideRoot))
+	})
+
+	t.Run("absolute path outside root", func(t *testing.T) {
+		_, err := ConfinePath(root, filepath.Join(outside, "secret.txt"))
+		assert.True(t, errors.Is(err, ErrOutsideRoot))
+	})
+
+	t.Run("symlink escaping root", func(t *testing.T) {
+		_, err := ConfinePath(root, "link.txt")
+		assert.True(t, errors.Is(err, ErrOutsideRoot))
+	})
+
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "out")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "missing.txt"), filepath.Join(root, "dangling.txt")))
+
+	t.Run("new file inside root", func(t *testing.T) {
+		resolved, err := ConfinePath(root, "src/new/report.json")
+		require.NoError(t, err)
+		assert.Equal(t, "report.json", filepath.Base(resolved))
+	})
+
+	t.Run("new file under a symlinked directory escaping root", func(t *testing.T) {
+		_, err := ConfinePath(root, "out/new.txt")
+		assert.True(t, errors.Is(err, ErrOutsideRoot))
+		_, err = ConfinePath(root, "out/sub/new.txt")
+		assert.True(t, errors.Is(err, ErrOutsideRoot))
+	})
+
+	t.Run("parent traversal after a symlinked directory", func(t *testing.T) {
+		// out/.. is the parent of the outside directory, not the root
+		_, err := ConfinePath(root, "out/../new.txt")
+		assert.True(t, errors.Is(err, ErrOutsideRoot))
+	})
+
+	t.Run("dangling symlink", func(t *testing.T) {
+		_, err := ConfinePath(root, "dangling.txt")
+		assert.Error(t, err)
+	})
+}
+
+func TestWalkConfined(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "a", "b", "c"), 0755))
+	for _, name := range []string{"top.cs", "a/one.cs", "a/b/two.cs", "a/b/c/three.cs"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte("x"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.cs"), []byte("x"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.cs"), filepath.Join(root, "secret.cs")))
+
+	collect := func(limits WalkLimits) ([]string, error) {
+		var files []string
+		err := WalkConfined(root, limits, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				rel, relErr := filepath.Rel(root, path)
+				require.NoError(t, relErr)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		return files, err
+	}
+
+	t.Run("skips symlinks escaping root", func(t *testing.T) {
+		files, err := collect(WalkLimits{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"top.cs", "a/one.cs", "a/b/two.cs", "a/b/c/three.cs"}, files)
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		files, err := collect(WalkLimits{MaxDepth: 2})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"top.cs", "a/one.cs", "a/b/two.cs"}, files)
+	})
+
+	t.Run("file limit", func(t *testing.T) {
+		files, err := collect(WalkLimits{MaxFiles: 2})
+		assert.True(t, errors.Is(err, ErrWalkLimit))
+		assert.Len(t, files, 2)
+	})
+
+	t.Run("honors .docloomignore", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, ".docloomignore"), []byte("b/\n.docloomignore\n"), 0644))
+		files, err := collect(WalkLimits{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"top.cs", "a/one.cs"}, files)
+	})
+
+	t.Run("honors nested .gitignore", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, ".docloomignore"), []byte(".docloomignore\n.gitignore\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "a", ".gitignore"), []byte("/one.cs\nc/\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "one.cs"), []byte("x"), 0644))
+		files, err := collect(WalkLimits{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"top.cs", "one.cs", "a/b/two.cs"}, files)
+	})
+}
+
+func TestConfinePathParams(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "file.cs"), []byte("x"), 0644))
+
+	t.Run("no source root leaves paths untouched", func(t *testing.T) {
+		params := map[string]string{"FILE_PATH": "/etc/passwd"}
+		confined, err := confinePathParams("", params)
+		require.NoError(t, err)
+		assert.Equal(t, params, confined)
+	})
+
+	t.Run("resolves paths inside root", func(t *testing.T) {
+		confined, err := confinePathParams(root, map[string]string{
+			"file_path": "file.cs",
+			"language":  "../csharp",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "file.cs", filepath.Base(confined["file_path"]))
+		assert.Equal(t, "../csharp", confined["language"])
+		assert.Equal(t, root, confined["SOURCE_PATH"])
+	})
+
+	t.Run("rejects paths outside root", func(t *testing.T) {
+		_, err := confinePathParams(root, map[string]string{
+			"FILE_PATH": "../outside.cs",
+		})
+		assert.True(t, errors.Is(err, ErrOutsideRoot))
+	})
+
+	t.Run("ignores a supplied source path", func(t *testing.T) {
+		for _, supplied := range []string{"/", filepath.Dir(root), root + "/.."} {
+			_, err := confinePathParams(root, map[string]string{
+				"SOURCE_PATH": supplied,
+				"FILE_PATH":   "../outside.cs",
+			})
+			assert.True(t, errors.Is(err, ErrOutsideRoot), "SOURCE_PATH %s", supplied)
+
+			confined, err := confinePathParams(root, map[string]string{"source_path": supplied})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"SOURCE_PATH": root}, confined)
+		}
+	})
+}
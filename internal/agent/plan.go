@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunPlan describes what Run would execute for a set of options, for checking agent
+// definitions without running them.
+type RunPlan struct {
+	Agent      string
+	Runner     RunnerInfo
+	Resolved   string   // Path of the runner command on PATH; empty when it cannot be found
+	Args       []string // Runner arguments with placeholders replaced
+	Env        []string // PARAM_* variables added to the inherited environment
+	SourcePath string   // Directory the agent reads
+	OutputPath string   // Directory the agent writes its artifacts to, created when it runs
+
+	// The artifact contract: the output must contain a Markdown file and meet the
+	// postconditions, else the agent is re-run once with Retry, if set.
+	Postconditions []Postcondition
+	Retry          *Retry
+}
+
+// Plan resolves the agent, validates the parameters and verifies a pinned runner,
+// and returns the command Run would execute, without running it or creating its
+// output directory.
+func (e *Executor) Plan(opts RunOptions) (*RunPlan, error) {
+	agent, exists := e.registry.Get(opts.AgentName)
+	if !exists {
+		return nil, fmt.Errorf("agent not found: %s", opts.AgentName)
+	}
+	if err := ValidateParameters(agent, opts.Parameters); err != nil {
+		return nil, err
+	}
+	runner, err := verifyRunner(agent)
+	if err != nil {
+		return nil, err
+	}
+
+	outputPath := filepath.Join(e.cache.GetBaseDir(), runID(opts.AgentName, time.Now()))
+	plan := &RunPlan{
+		Agent:          opts.AgentName,
+		Runner:         runner,
+		Args:           runnerArgs(agent, opts.SourcePath, outputPath),
+		Env:            parameterEnv(agent, opts.Parameters),
+		SourcePath:     opts.SourcePath,
+		OutputPath:     outputPath,
+		Postconditions: agent.Spec.Postconditions,
+		Retry:          agent.Spec.Retry,
+	}
+	if path, err := exec.LookPath(agent.Spec.Runner.Command); err == nil {
+		plan.Resolved = path
+	}
+	return plan, nil
+}
+
+// ValidateParameters checks parameter overrides against the agent's declared
+// parameters: every override must be declared and match the declared type (string,
+// integer, number or boolean), and required parameters without a default must be
+// given.
+func ValidateParameters(agent *Definition, params map[string]string) error {
+	declared := make(map[string]Parameter, len(agent.Spec.Parameters))
+	for _, param := range agent.Spec.Parameters {
+		declared[param.Name] = param
+	}
+
+	var problems []string
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		param, ok := declared[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown parameter %s", name))
+			continue
+		}
+		if err := checkParameterType(param.Type, params[name]); err != nil {
+			problems = append(problems, fmt.Sprintf("parameter %s: %v", name, err))
+		}
+	}
+	for _, param := range agent.Spec.Parameters {
+		if _, given := params[param.Name]; param.Required && param.Default == nil && !given {
+			problems = append(problems, fmt.Sprintf("missing required parameter %s", param.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("agent %s: %s", agent.Metadata.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkParameterType checks that value parses as the declared type. Undeclared and
+// unknown types accept any value.
+func checkParameterType(typ, value string) error {
+	var err error
+	switch typ {
+	case "integer":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "number":
+		_, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, typ)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentExecutor_Plan(t *testing.T) {
+	testDir := t.TempDir()
+	definition := `apiVersion: v1
+kind: Agent
+metadata:
+  name: planned-agent
+spec:
+  runner:
+    command: sh
+    args: ["-c", "touch ran", "--source", "${SOURCE_PATH}", "--out", "${OUTPUT_PATH}"]
+  parameters:
+    - name: depth
+      type: integer
+      default: 2
+    - name: project
+      type: string
+      required: true
+    - name: verbose
+      type: boolean
+  postconditions:
+    - file: api-surface.json
+      expr: count(namespaces) > 0
+  retry:
+    parameters:
+      depth: "5"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "planned-agent.agent.yaml"), []byte(definition), 0644))
+	registry := NewRegistry()
+	registry.AddSearchPath(testDir)
+	require.NoError(t, registry.Discover())
+	cache := &ArtifactCache{baseDir: filepath.Join(testDir, "cache")}
+	executor := NewExecutor(registry, cache, zerolog.Nop())
+
+	t.Run("describes the run", func(t *testing.T) {
+		plan, err := executor.Plan(RunOptions{AgentName: "planned-agent", SourcePath: "/src", Parameters: map[string]string{"project": "ledger", "depth": "3"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "sh", plan.Runner.Command)
+		assert.NotEmpty(t, plan.Resolved)
+		assert.Equal(t, []string{"-c", "touch ran", "--source", "/src", "--out", plan.OutputPath}, plan.Args)
+		assert.Equal(t, []string{"PARAM_DEPTH=2", "PARAM_DEPTH=3", "PARAM_PROJECT=ledger"}, plan.Env)
+		assert.Equal(t, filepath.Join(testDir, "cache"), filepath.Dir(plan.OutputPath))
+		assert.Len(t, plan.Postconditions, 1)
+		assert.Equal(t, "5", plan.Retry.Parameters["depth"])
+		_, statErr := os.Stat(filepath.Join(testDir, "cache"))
+		assert.True(t, os.IsNotExist(statErr), "no output directory is created")
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		_, err := executor.Plan(RunOptions{AgentName: "planned-agent", Parameters: map[string]string{"depth": "deep", "colour": "red", "verbose": "yes"}})
+
+		require.Error(t, err)
+		assert.Equal(t, `agent planned-agent: unknown parameter colour; parameter depth: "deep" is not a valid integer; parameter verbose: "yes" is not a valid boolean; missing required parameter project`, err.Error())
+	})
+
+	t.Run("unknown agent", func(t *testing.T) {
+		_, err := executor.Plan(RunOptions{AgentName: "missing"})
+
+		assert.EqualError(t, err, "agent not found: missing")
+	})
+}
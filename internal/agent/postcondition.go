@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+// validate checks that the postcondition names a file and that its expression parses.
+func (p Postcondition) validate() error {
+	if p.File == "" {
+		return fmt.Errorf("postcondition without file")
+	}
+	if _, err := filepath.Match(p.File, ""); err != nil {
+		return fmt.Errorf("postcondition %s: invalid file pattern: %w", p.File, err)
+	}
+	if p.Expr != "" {
+		if err := (validate.Rule{Name: p.File, Expr: p.Expr}).Compile(); err != nil {
+			return fmt.Errorf("postcondition: %w", err)
+		}
+	}
+	return nil
+}
+
+// check verifies the postcondition against the artifacts in outputPath. The expression
+// is evaluated against the first matching file, which must contain JSON.
+func (p Postcondition) check(outputPath string) error {
+	matches, err := filepath.Glob(filepath.Join(outputPath, p.File))
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.File, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("%s: %s", p.File, p.explain("no matching output file"))
+	}
+	if p.Expr == "" {
+		return nil
+	}
+
+	sort.Strings(matches)
+	data, err := os.ReadFile(matches[0]) // #nosec G304 - file is in the agent's output directory
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.File, err)
+	}
+	rule := validate.Rule{Name: filepath.Base(matches[0]), Expr: p.Expr, Message: p.Message}
+	if err := validate.NewValidator().CheckRules(string(data), []validate.Rule{rule}); err != nil {
+		if violation, ok := err.(*validate.RuleViolationError); ok {
+			return fmt.Errorf("%s", violation.Violations[0].String())
+		}
+		return fmt.Errorf("%s: %w", p.File, err)
+	}
+	return nil
+}
+
+// explain returns the postcondition's message, or fallback when it has none.
+func (p Postcondition) explain(fallback string) string {
+	if p.Message != "" {
+		return p.Message
+	}
+	return fallback
+}
+
+// checkPostconditions verifies every postcondition of the agent against outputPath
+// and returns an error listing those that fail.
+func checkPostconditions(def *Definition, outputPath string) error {
+	var failures []string
+	for _, postcondition := range def.Spec.Postconditions {
+		if err := postcondition.check(outputPath); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("agent %s output failed postconditions: %s", def.Metadata.Name, strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/karolswdev/docloom/internal/trust"
 )
 
 // Registry manages discovered agent definitions.
@@ -72,8 +74,12 @@ func (r *Registry) discoverInPath(searchPath string) error {
 	return nil
 }
 
-// loadAgent loads a single agent definition from a file.
+// loadAgent loads a single agent definition from a file. Under a trust policy, the
+// file must carry a trusted signature (<file>.sig).
 func (r *Registry) loadAgent(path string) error {
+	if err := trust.CheckFile("agent definition", path); err != nil {
+		return err
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -94,6 +100,20 @@ func (r *Registry) loadAgent(path string) error {
 	if def.Metadata.Name == "" {
 		return fmt.Errorf("missing metadata.name")
 	}
+	for _, tool := range def.Spec.Tools {
+		if tool.Parameters != nil && tool.Parameters["type"] != "object" {
+			return fmt.Errorf("tool %s: parameters schema must have type object", tool.Name)
+		}
+	}
+
+	if err := def.Spec.Runner.validate(); err != nil {
+		return err
+	}
+	for _, postcondition := range def.Spec.Postconditions {
+		if err := postcondition.validate(); err != nil {
+			return err
+		}
+	}
 
 	r.agents[def.Metadata.Name] = &def
 	return nil
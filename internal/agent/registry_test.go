@@ -156,6 +156,30 @@ spec:
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "missing metadata.name")
 	})
+
+	t.Run("tool parameters not an object schema", func(t *testing.T) {
+		tempDir := t.TempDir()
+		badSchema := `
+apiVersion: v1
+kind: Agent
+metadata:
+  name: bad-schema
+spec:
+  tools:
+    - name: read
+      command: cat
+      parameters:
+        type: string
+`
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "bad.agent.yaml"), []byte(badSchema), 0644))
+
+		registry := NewRegistry()
+		registry.searchPaths = []string{tempDir}
+		err := registry.Discover()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "parameters schema must have type object")
+	})
 }
 
 func TestAgentRegistry_List(t *testing.T) {
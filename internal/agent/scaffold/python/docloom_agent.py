@@ -0,0 +1,338 @@
+"""Helpers for writing docloom Research Agents in Python.
+
+An agent is an executable that docloom runs in one of two ways:
+
+* As a runner, with the source and output directories as arguments
+  (``agent <source> <output>``). The agent writes Markdown and JSON artifacts into
+  the output directory; at least one Markdown file is required.
+* As a tool, with the tool name and its arguments (``agent <tool> <args...>``). The
+  tool writes a single JSON document to stdout, or ``{"error": "..."}`` on failure.
+
+In both modes parameters arrive as ``PARAM_<NAME>`` environment variables and
+anything written to stderr is logged by docloom. This module implements that
+contract with the standard library only, so it can be copied next to an agent or
+installed as a module::
+
+    import docloom_agent as agent
+
+    source, output = agent.runner_args()
+    params = agent.Params()
+    out = agent.Output(output)
+    out.write_markdown("Summary.md", summary)
+    out.close()
+
+It mirrors docloom's Go SDK (pkg/agentsdk).
+"""
+
+import json
+import os
+import re
+import sys
+
+__all__ = [
+    "DEFAULT_MAX_WALK_DEPTH",
+    "DEFAULT_MAX_WALK_FILES",
+    "OutsideRootError",
+    "Output",
+    "Params",
+    "confine_path",
+    "fatal",
+    "log",
+    "respond",
+    "respond_error",
+    "runner_args",
+    "walk",
+]
+
+# Walk limits used when the MAX_WALK_DEPTH and MAX_FILES parameters are not set.
+DEFAULT_MAX_WALK_DEPTH = 20
+DEFAULT_MAX_WALK_FILES = 10000
+
+IGNORE_FILE = ".docloomignore"
+
+
+def log(message, *args):
+    """Write a diagnostic line to stderr. Agents must not log to stdout, which
+    carries tool results."""
+    if args:
+        message = message % args
+    sys.stderr.write(message + "\n")
+    sys.stderr.flush()
+
+
+def fatal(message, *args):
+    """Log the message and exit with status 1, which docloom reports as a failed
+    agent run."""
+    log(message, *args)
+    sys.exit(1)
+
+
+def runner_args(argv=None):
+    """Return the source and output directories of a runner invocation."""
+    if argv is None:
+        argv = sys.argv[1:]
+    if len(argv) < 2:
+        fatal("usage: <source_path> <output_path>")
+    return argv[0], argv[1]
+
+
+class Params:
+    """Agent parameters read from PARAM_<NAME> environment variables."""
+
+    def __init__(self, env=None):
+        self._env = os.environ if env is None else env
+
+    def lookup(self, name):
+        """Return the value of the named parameter, or None. Names are matched as
+        docloom exports them (PARAM_ plus the upper-cased name) and with dashes
+        replaced by underscores, so "include-internal" is found in
+        PARAM_INCLUDE_INTERNAL as well."""
+        upper = name.upper()
+        for key in ("PARAM_" + upper, "PARAM_" + upper.replace("-", "_")):
+            value = self._env.get(key)
+            if value:
+                return value
+        return None
+
+    def str(self, name, default=""):
+        """Return the named parameter, or default when it is not set."""
+        value = self.lookup(name)
+        return default if value is None else value
+
+    def bool(self, name, default=False):
+        """Return the named parameter as a boolean ("true", "1", "yes" are true),
+        or default when it is not set."""
+        value = self.lookup(name)
+        if value is None:
+            return default
+        return value.lower() in ("true", "1", "yes")
+
+    def int(self, name, default=0):
+        """Return the named parameter as an integer, or default when it is not set
+        or not a number."""
+        value = self.lookup(name)
+        try:
+            return default if value is None else int(value)
+        except ValueError:
+            return default
+
+    def source_root(self):
+        """Return the source directory docloom passes to tools as SOURCE_PATH."""
+        return self.str("SOURCE_PATH", ".")
+
+    def walk_limits(self):
+        """Return (max_depth, max_files) from the MAX_WALK_DEPTH and MAX_FILES
+        parameters, falling back to docloom's defaults."""
+        return (
+            self.int("MAX_WALK_DEPTH", DEFAULT_MAX_WALK_DEPTH),
+            self.int("MAX_FILES", DEFAULT_MAX_WALK_FILES),
+        )
+
+
+class Output:
+    """Writes the artifacts of a runner invocation into its output directory."""
+
+    def __init__(self, directory):
+        os.makedirs(directory, exist_ok=True)
+        self.dir = directory
+        self._markdown = 0
+
+    def write_file(self, name, data):
+        """Write an artifact. Names are relative to the output directory and may not
+        leave it."""
+        clean = os.path.normpath(name)
+        if os.path.isabs(clean) or clean == ".." or clean.startswith(".." + os.sep):
+            raise ValueError("artifact %s is outside the output directory" % name)
+        path = os.path.join(self.dir, clean)
+        os.makedirs(os.path.dirname(path) or self.dir, exist_ok=True)
+        if isinstance(data, str):
+            data = data.encode("utf-8")
+        fd = os.open(path, os.O_WRONLY | os.O_CREAT | os.O_TRUNC, 0o600)
+        with os.fdopen(fd, "wb") as f:
+            f.write(data)
+        if clean.lower().endswith(".md"):
+            self._markdown += 1
+
+    def write_markdown(self, name, content):
+        """Write a Markdown artifact, the material docloom feeds to the model."""
+        if not name.lower().endswith(".md"):
+            name += ".md"
+        self.write_file(name, content)
+
+    def write_json(self, name, value):
+        """Write value as an indented JSON artifact, for example structured data
+        that postconditions in the agent manifest check."""
+        self.write_file(name, json.dumps(value, indent=2) + "\n")
+
+    def close(self):
+        """Check the output contract: docloom rejects runs that produce no Markdown
+        artifact, so close fails when none was written."""
+        if self._markdown == 0:
+            raise RuntimeError("agent did not produce any markdown files")
+
+
+class OutsideRootError(ValueError):
+    """Raised by confine_path for paths that escape the source root."""
+
+
+def _within(root, path):
+    return path == root or path.startswith(root.rstrip(os.sep) + os.sep)
+
+
+def confine_path(root, path):
+    """Resolve path against root, following symlinks, and raise OutsideRootError
+    when it escapes root. Tools that read files named by the model must confine
+    them this way."""
+    resolved_root = os.path.realpath(root)
+    if not os.path.isabs(path):
+        path = os.path.join(resolved_root, path)
+    resolved = os.path.realpath(path)
+    if not _within(resolved_root, resolved):
+        raise OutsideRootError("path %s is outside the source root" % path)
+    return resolved
+
+
+def walk(root, max_depth=0, max_files=0):
+    """Yield the files below root without leaving it, skipping paths excluded by
+    .docloomignore and directories deeper than max_depth. Reaching max_files ends
+    the walk early with a warning, so agents analyze a bounded part of very large
+    repositories instead of failing. Zero limits fall back to docloom's defaults."""
+    max_depth = max_depth or DEFAULT_MAX_WALK_DEPTH
+    max_files = max_files or DEFAULT_MAX_WALK_FILES
+    resolved_root = os.path.realpath(root)
+    matcher = _IgnoreMatcher.load(root)
+    files = 0
+    for directory, dirs, names in os.walk(root):
+        rel_dir = os.path.relpath(directory, root)
+        depth = 0 if rel_dir == "." else rel_dir.count(os.sep) + 1
+        kept = []
+        for d in sorted(dirs):
+            rel = os.path.normpath(os.path.join(rel_dir, d))
+            path = os.path.join(directory, d)
+            if matcher.match(rel, True) or depth + 1 > max_depth:
+                continue
+            if os.path.islink(path) and not _within(resolved_root, os.path.realpath(path)):
+                continue
+            kept.append(d)
+        dirs[:] = kept
+        for name in sorted(names):
+            rel = os.path.normpath(os.path.join(rel_dir, name))
+            path = os.path.join(directory, name)
+            if matcher.match(rel, False):
+                continue
+            if os.path.islink(path) and not _within(resolved_root, os.path.realpath(path)):
+                continue
+            if files >= max_files:
+                log("Warning: file limit reached, results are truncated")
+                return
+            files += 1
+            yield path
+
+
+def respond(value):
+    """Print a tool result as a single JSON document."""
+    json.dump(value, sys.stdout)
+    sys.stdout.write("\n")
+    sys.stdout.flush()
+
+
+def respond_error(message, *args):
+    """Print an error envelope with the formatted message. docloom passes it to the
+    model like any other result, so the model can correct its call."""
+    if args:
+        message = message % args
+    respond({"error": message})
+
+
+class _IgnoreMatcher:
+    """Matches paths against .docloomignore patterns in gitignore syntax, as
+    docloom's ingester does."""
+
+    def __init__(self, rules):
+        self.rules = rules
+
+    @classmethod
+    def load(cls, root):
+        try:
+            with open(os.path.join(root, IGNORE_FILE), encoding="utf-8") as f:
+                lines = f.read().splitlines()
+        except FileNotFoundError:
+            return cls([])
+        rules = []
+        for line in lines:
+            line = line.rstrip(" \t\r")
+            if not line or line.startswith("#"):
+                continue
+            negate = False
+            if line.startswith("!"):
+                negate, line = True, line[1:]
+            elif line.startswith("\\"):
+                line = line[1:]
+            dir_only = line.endswith("/")
+            line = line.rstrip("/")
+            if not line:
+                continue
+            prefix = "^" if "/" in line else "^(?:.*/)?"
+            pattern = re.compile(prefix + _glob_to_regexp(line.lstrip("/")) + "$")
+            rules.append((pattern, negate, dir_only))
+        return cls(rules)
+
+    def match(self, rel_path, is_dir):
+        if not self.rules:
+            return False
+        rel_path = rel_path.replace(os.sep, "/")
+        parts = rel_path.split("/")
+        # A file cannot be re-included once a parent directory is excluded
+        for i in range(1, len(parts)):
+            if self._match_path("/".join(parts[:i]), True):
+                return True
+        return self._match_path(rel_path, is_dir)
+
+    def _match_path(self, rel_path, is_dir):
+        ignored = False
+        for pattern, negate, dir_only in self.rules:
+            if dir_only and not is_dir:
+                continue
+            if pattern.match(rel_path):
+                ignored = not negate
+        return ignored
+
+
+def _glob_to_regexp(glob):
+    out = []
+    i = 0
+    while i < len(glob):
+        c = glob[i]
+        if glob.startswith("**/", i):
+            out.append("(?:.*/)?")
+            i += 3
+            continue
+        if glob.startswith("/**", i) and i + 3 == len(glob):
+            out.append("/.*")
+            i += 3
+            continue
+        if glob.startswith("**", i):
+            out.append(".*")
+            i += 2
+            continue
+        if c == "*":
+            out.append("[^/]*")
+        elif c == "?":
+            out.append("[^/]")
+        elif c == "[":
+            end = glob.find("]", i + 1)
+            if end < 0:
+                out.append("\\[")
+            else:
+                cls = glob[i + 1:end]
+                if cls.startswith("!"):
+                    cls = "^" + cls[1:]
+                out.append("[" + cls + "]")
+                i = end
+        elif c == "\\" and i + 1 < len(glob):
+            i += 1
+            out.append(re.escape(glob[i]))
+        else:
+            out.append(re.escape(c))
+        i += 1
+    return "".join(out)
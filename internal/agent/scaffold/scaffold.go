@@ -0,0 +1,121 @@
+// Package scaffold creates new Research Agents from starter files: an agent manifest,
+// an entry point and the contract helpers for the agent's language.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed python
+var starters embed.FS
+
+// namePattern restricts agent names to what works as a file name and in manifests.
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Runners returns the languages agents can be created for.
+func Runners() []string {
+	entries, _ := fs.ReadDir(starters, ".")
+	runners := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		runners = append(runners, entry.Name())
+	}
+	sort.Strings(runners)
+	return runners
+}
+
+// Options describes the agent to create.
+type Options struct {
+	Name   string // Agent name, also used for the manifest and directory names
+	Runner string // Language of the starter files, one of Runners()
+	Dir    string // Agent search path the agent is created in
+}
+
+// Create writes the manifest <Dir>/<Name>.agent.yaml and the starter files into
+// <Dir>/<Name>, returning the paths written. Existing files are never overwritten.
+func Create(opts Options) ([]string, error) {
+	if !namePattern.MatchString(opts.Name) {
+		return nil, fmt.Errorf("invalid agent name %q: use lowercase letters, digits, '-' and '_'", opts.Name)
+	}
+	if _, err := fs.Stat(starters, opts.Runner); err != nil {
+		return nil, fmt.Errorf("unsupported runner %q (available: %s)", opts.Runner, strings.Join(Runners(), ", "))
+	}
+
+	agentDir := filepath.Join(opts.Dir, opts.Name)
+	data := struct{ Name, Dir string }{Name: opts.Name, Dir: filepath.ToSlash(agentDir)}
+
+	// Render everything before writing so a failure leaves nothing behind
+	files := make(map[string][]byte)
+	err := fs.WalkDir(starters, opts.Runner, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := starters.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(agentDir, filepath.FromSlash(strings.TrimPrefix(name, opts.Runner+"/")))
+		if strings.HasSuffix(name, ".tmpl") {
+			if content, err = render(name, content, data); err != nil {
+				return err
+			}
+			target = strings.TrimSuffix(target, ".tmpl")
+		}
+		if path.Base(name) == "agent.yaml.tmpl" {
+			target = filepath.Join(opts.Dir, opts.Name+".agent.yaml")
+		}
+		files[target] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare %s starter files: %w", opts.Runner, err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for target := range files {
+		if _, err := os.Stat(target); err == nil {
+			return nil, fmt.Errorf("%s already exists", target)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to check %s: %w", target, err)
+		}
+		paths = append(paths, target)
+	}
+	sort.Strings(paths)
+
+	for _, target := range paths {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", target, err)
+		}
+		mode := os.FileMode(0644)
+		if bytes.HasPrefix(files[target], []byte("#!")) {
+			mode = 0755 // Scripts run directly
+		}
+		if err := os.WriteFile(target, files[target], mode); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+	return paths, nil
+}
+
+// render executes a starter file template.
+func render(name string, content []byte, data interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
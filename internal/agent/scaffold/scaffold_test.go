@@ -0,0 +1,82 @@
+package scaffold
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/agent"
+)
+
+// TestCreate_Python tests that a new Python agent is discovered and runs against a source tree.
+func TestCreate_Python(t *testing.T) {
+	agentsDir := filepath.Join(t.TempDir(), "agents")
+
+	paths, err := Create(Options{Name: "file-types", Runner: "python", Dir: agentsDir})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(agentsDir, "file-types.agent.yaml"),
+		filepath.Join(agentsDir, "file-types", "docloom_agent.py"),
+		filepath.Join(agentsDir, "file-types", "main.py"),
+	}, paths)
+
+	registry := agent.NewRegistry()
+	registry.AddSearchPath(agentsDir)
+	require.NoError(t, registry.Discover())
+	def, ok := registry.Get("file-types")
+	require.True(t, ok)
+	assert.Equal(t, "python3", def.Spec.Runner.Command)
+
+	// Existing agents are not overwritten
+	_, err = Create(Options{Name: "file-types", Runner: "python", Dir: agentsDir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	source := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "main.go"), []byte("package main\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "util.go"), []byte("package main\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "vendor"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "vendor", "lib.py"), []byte(""), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(source, ".docloomignore"), []byte("vendor/\n.docloomignore\n"), 0600))
+
+	cache, err := agent.NewArtifactCache()
+	require.NoError(t, err)
+	executor := agent.NewExecutor(registry, cache, zerolog.Nop())
+	result, err := executor.Run(agent.RunOptions{AgentName: "file-types", SourcePath: source})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ExitCode)
+
+	summary, err := os.ReadFile(filepath.Join(result.OutputPath, "FileTypes.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(summary), "| .go | 2 |")
+	assert.NotContains(t, string(summary), ".py")
+
+	counts, err := os.ReadFile(filepath.Join(result.OutputPath, "file_types.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{".go": 2}`, string(counts))
+}
+
+// TestCreate_Invalid tests that invalid names and unknown runners are rejected.
+func TestCreate_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Create(Options{Name: "../escape", Runner: "python", Dir: dir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid agent name")
+
+	_, err = Create(Options{Name: "demo", Runner: "cobol", Dir: dir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available: python")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
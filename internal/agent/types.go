@@ -20,12 +20,40 @@ type Spec struct {
 	Runner     Runner      `yaml:"runner,omitempty"` // Deprecated: Use Tools instead
 	Tools      []Tool      `yaml:"tools,omitempty"`
 	Parameters []Parameter `yaml:"parameters"`
+
+	// Postconditions are checked against the output of every run; Retry, if set,
+	// re-runs the agent once with adjusted parameters when one of them fails.
+	Postconditions []Postcondition `yaml:"postconditions,omitempty"`
+	Retry          *Retry          `yaml:"retry,omitempty"`
+}
+
+// Postcondition is a requirement on the artifacts of an agent run, for example that
+// api-surface.json lists at least one namespace:
+//
+//	postconditions:
+//	  - file: api-surface.json
+//	    expr: count(namespaces) > 0
+//	    message: no namespaces found
+type Postcondition struct {
+	File    string `yaml:"file"`              // Glob relative to the output directory; at least one file must match
+	Expr    string `yaml:"expr,omitempty"`    // Consistency rule expression evaluated against the matched JSON file
+	Message string `yaml:"message,omitempty"` // Explanation shown when the postcondition fails
+}
+
+// Retry describes how to re-run an agent whose output fails its postconditions.
+type Retry struct {
+	Parameters map[string]string `yaml:"parameters"` // Parameter overrides for the second run
 }
 
 // Runner specifies how to execute the agent.
 type Runner struct {
 	Command string   `yaml:"command"`
 	Args    []string `yaml:"args,omitempty"`
+
+	// Pin the runner binary: the Executor refuses to run a command whose SHA-256
+	// differs from SHA256. Version is recorded in run reports alongside the hash.
+	Version string `yaml:"version,omitempty"`
+	SHA256  string `yaml:"sha256,omitempty"`
 }
 
 // Tool represents a specific capability that an agent exposes.
@@ -35,6 +63,10 @@ type Tool struct {
 	Description string   `yaml:"description"`    // LLM-facing description of what the tool does
 	Command     string   `yaml:"command"`        // Command to execute (can include the agent binary path)
 	Args        []string `yaml:"args,omitempty"` // Additional arguments to pass
+
+	// Parameters is a JSON Schema (type object) describing the tool's arguments. When
+	// omitted, string parameters are inferred from ${VAR} placeholders in Args.
+	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
 }
 
 // Parameter defines an input parameter for the agent.
@@ -0,0 +1,261 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CassetteVersion is the format version of cassette files.
+const CassetteVersion = 1
+
+// ReplayAPIKey is the placeholder API key of runs replaying a cassette, which never
+// contact the provider.
+const ReplayAPIKey = "replay"
+
+// ErrNoRecording is returned by a replaying cassette for a request it has no
+// recorded response to.
+var ErrNoRecording = errors.New("no recorded response for request")
+
+// Interaction is a recorded provider request and its response. Only the method, path
+// and body of requests are kept, so that API keys and gateway headers are not written
+// to cassettes.
+type Interaction struct {
+	Request  RecordedRequest  `yaml:"request"`
+	Response RecordedResponse `yaml:"response"`
+}
+
+// RecordedRequest is the request of an interaction.
+type RecordedRequest struct {
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+	Body   string `yaml:"body,omitempty"`
+}
+
+// RecordedResponse is the response of an interaction.
+type RecordedResponse struct {
+	Status      int    `yaml:"status"`
+	ContentType string `yaml:"content_type,omitempty"`
+	Body        string `yaml:"body"`
+}
+
+// cassetteFile is the YAML document of a cassette.
+type cassetteFile struct {
+	Version      int           `yaml:"version"`
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Cassette records the requests clients send to the provider and their responses to a
+// file, or replays the responses of a recording without contacting the provider, so
+// that runs can be repeated deterministically and offline, e.g. in CI.
+//
+// A replayed request is answered with the first unused interaction of the same method,
+// path and JSON body; requests sent more than once are answered in recorded order.
+type Cassette struct {
+	mu        sync.Mutex
+	path      string
+	replaying bool
+	file      cassetteFile
+	used      []bool
+}
+
+// RecordCassette returns a cassette recording to path. The file is written after each
+// interaction, so that failed runs keep the interactions up to the failure.
+func RecordCassette(path string) (*Cassette, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	cassette := &Cassette{path: path, file: cassetteFile{Version: CassetteVersion}}
+	if err := cassette.save(); err != nil {
+		return nil, err
+	}
+	return cassette, nil
+}
+
+// ReplayCassette returns a cassette replaying the recording at path.
+func ReplayCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - cassette path provided by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	if file.Version > CassetteVersion {
+		return nil, fmt.Errorf("cassette %s has version %d; this version of docloom reads up to %d", path, file.Version, CassetteVersion)
+	}
+	return &Cassette{path: path, replaying: true, file: file, used: make([]bool, len(file.Interactions))}, nil
+}
+
+// Replaying reports whether the cassette replays a recording rather than recording.
+func (c *Cassette) Replaying() bool {
+	return c != nil && c.replaying
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.file.Interactions...)
+}
+
+// Unused returns the number of loaded interactions no request was answered with.
+func (c *Cassette) Unused() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	unused := 0
+	for _, used := range c.used {
+		if !used {
+			unused++
+		}
+	}
+	return unused
+}
+
+// transport returns a transport recording the exchanges of base, or answering
+// requests from the recording without using base when replaying.
+func (c *Cassette) transport(base http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{cassette: c, base: base}
+}
+
+// cassetteTransport records or replays the requests of a client.
+type cassetteTransport struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{Method: req.Method, Path: req.URL.Path, Body: readableBody(body)}
+
+	if t.cassette.replaying {
+		response, err := t.cassette.replay(recorded)
+		if err != nil {
+			return nil, err
+		}
+		return response.httpResponse(req), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if err := t.cassette.record(Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        readableBody(respBody),
+		},
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay returns the response of the first unused interaction matching req.
+func (c *Cassette) replay(req RecordedRequest) (RecordedResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for idx, interaction := range c.file.Interactions {
+		if c.used[idx] || !interaction.Request.matches(req) {
+			continue
+		}
+		c.used[idx] = true
+		return interaction.Response, nil
+	}
+	return RecordedResponse{}, fmt.Errorf("%w %s %s in %s; record it again with --record", ErrNoRecording, req.Method, req.Path, c.path)
+}
+
+// record appends interaction and writes the cassette.
+func (c *Cassette) record(interaction Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.file.Interactions = append(c.file.Interactions, interaction)
+	return c.save()
+}
+
+// save writes the cassette file; the caller holds the lock or owns the cassette.
+func (c *Cassette) save() error {
+	data, err := yaml.Marshal(&c.file)
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// matches reports whether r and other are the same request, comparing JSON bodies
+// regardless of their formatting.
+func (r RecordedRequest) matches(other RecordedRequest) bool {
+	return r.Method == other.Method && r.Path == other.Path && compactJSON(r.Body) == compactJSON(other.Body)
+}
+
+// httpResponse returns the recorded response as the response to req.
+func (r RecordedResponse) httpResponse(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if r.ContentType != "" {
+		header.Set("Content-Type", r.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// readableBody returns body indented when it is JSON, so that cassettes can be read
+// and edited, and unchanged otherwise, such as streamed events.
+func readableBody(body []byte) string {
+	var indented bytes.Buffer
+	if len(body) > 0 && json.Indent(&indented, body, "", "  ") == nil {
+		return indented.String()
+	}
+	return string(body)
+}
+
+// compactJSON returns body without insignificant whitespace when it is JSON.
+func compactJSON(body string) string {
+	var compacted bytes.Buffer
+	if json.Compact(&compacted, []byte(body)) == nil {
+		return compacted.String()
+	}
+	return body
+}
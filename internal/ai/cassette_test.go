@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCassette_RecordAndReplay tests that recorded responses are replayed without contacting the provider.
+func TestCassette_RecordAndReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		prompt := body.Messages[len(body.Messages)-1].Content
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"index": 0, "message": map[string]interface{}{"role": "assistant", "content": `{"prompt": "` + prompt + `", "n": ` + string(rune('0'+requests)) + `}`}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "run.yaml")
+	recorder, err := RecordCassette(path)
+	require.NoError(t, err)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "sk-secret", Model: "gpt-4", Cassette: recorder})
+	require.NoError(t, err)
+
+	first, err := client.GenerateJSON(context.Background(), "first")
+	require.NoError(t, err)
+	again, err := client.GenerateJSON(context.Background(), "first")
+	require.NoError(t, err)
+	second, err := client.GenerateJSON(context.Background(), "second")
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Len(t, recorder.Interactions(), 3)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-secret")
+
+	// Replay needs neither the provider nor an API key
+	server.Close()
+	player, err := ReplayCassette(path)
+	require.NoError(t, err)
+	assert.True(t, player.Replaying())
+	client, err = NewOpenAIClient(Config{BaseURL: server.URL + "/v1", Model: "gpt-4", Cassette: player})
+	require.NoError(t, err)
+
+	response, err := client.GenerateJSON(context.Background(), "second")
+	require.NoError(t, err)
+	assert.JSONEq(t, second, response)
+	response, err = client.GenerateJSON(context.Background(), "first")
+	require.NoError(t, err)
+	assert.JSONEq(t, first, response)
+	assert.Equal(t, 1, player.Unused())
+	response, err = client.GenerateJSON(context.Background(), "first")
+	require.NoError(t, err)
+	assert.JSONEq(t, again, response)
+
+	_, err = client.GenerateJSON(context.Background(), "first")
+	assert.ErrorIs(t, err, ErrNoRecording)
+	_, err = client.GenerateJSON(context.Background(), "unrecorded")
+	assert.ErrorIs(t, err, ErrNoRecording)
+}
+
+// TestReplayCassette_Invalid tests the errors of unreadable cassettes.
+func TestReplayCassette_Invalid(t *testing.T) {
+	_, err := ReplayCassette(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "future.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("version: 99\ninteractions: []\n"), 0o600))
+	_, err = ReplayCassette(path)
+	assert.ErrorContains(t, err, "version 99")
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -28,17 +30,57 @@ type Config struct {
 	MaxRetries  int
 	RetryDelay  time.Duration
 	Temperature float32
+
+	// OwnParams keeps Seed, MaxRetries and Temperature for every request, instead of
+	// the run-wide request parameters of the context (see WithRequestParams), for
+	// clients with settings of their own such as an evaluation judge
+	OwnParams bool
+
+	// Added to every request, e.g. for gateways that require an organization header.
+	// Values may reference environment variables as ${VAR}.
+	ExtraHeaders map[string]string
+	ExtraQuery   map[string]string
+
+	// Optional connections and rate limit shared with other clients
+	Pool *Pool
+
+	// Provider is openai, azure or ollama; empty detects Azure OpenAI from the base
+	// URL. For Azure the model is the deployment name and APIVersion the api-version
+	// sent with every request (DefaultAzureAPIVersion when empty).
+	Provider   string
+	APIVersion string
+
+	// StructuredOutputs forces sending response schemas as structured outputs on or
+	// off; nil decides from the probed or known model capabilities
+	StructuredOutputs *bool
+
+	// Optional cassette recording the provider's responses, or replaying them without
+	// contacting the provider
+	Cassette *Cassette
 }
 
 // OpenAIClient implements the Client interface using the go-openai library.
 type OpenAIClient struct {
-	client *openai.Client
-	config Config
+	client       *openai.Client
+	httpClient   *http.Client
+	capabilities *Capabilities // Set by Probe
+	config       Config
+
+	schemaRejected atomic.Bool // The provider rejected a structured output schema
 }
 
 // NewOpenAIClient creates a new OpenAI-compatible client.
 func NewOpenAIClient(config Config) (*OpenAIClient, error) {
-	if config.APIKey == "" {
+	provider, err := resolveProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	config.Provider = provider
+	// Ollama and replayed recordings need no API key
+	if config.APIKey == "" && config.Cassette.Replaying() {
+		config.APIKey = ReplayAPIKey
+	}
+	if config.APIKey == "" && provider != ProviderOllama {
 		return nil, errors.New("API key is required")
 	}
 	if config.Model == "" {
@@ -46,6 +88,12 @@ func NewOpenAIClient(config Config) (*OpenAIClient, error) {
 	}
 	if config.BaseURL == "" {
 		config.BaseURL = "https://api.openai.com/v1"
+		if provider == ProviderOllama {
+			config.BaseURL = "http://localhost:11434"
+		}
+	}
+	if provider == ProviderAzure && config.APIVersion == "" {
+		config.APIVersion = DefaultAzureAPIVersion
 	}
 	if config.MaxRetries == 0 {
 		config.MaxRetries = 3
@@ -57,21 +105,58 @@ func NewOpenAIClient(config Config) (*OpenAIClient, error) {
 		config.MaxTokens = 4096
 	}
 
-	clientConfig := openai.DefaultConfig(config.APIKey)
-	clientConfig.BaseURL = config.BaseURL
+	httpClient, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConfig := openAIConfig(config, provider)
+	clientConfig.HTTPClient = httpClient
 
 	return &OpenAIClient{
-		client: openai.NewClientWithConfig(clientConfig),
-		config: config,
+		client:     openai.NewClientWithConfig(clientConfig),
+		httpClient: httpClient,
+		config:     config,
 	}, nil
 }
 
+// Model returns the name of the model the client requests.
+func (c *OpenAIClient) Model() string {
+	return c.config.Model
+}
+
+// MaxTokens returns the most completion tokens the client requests.
+func (c *OpenAIClient) MaxTokens() int {
+	return c.config.MaxTokens
+}
+
 // GenerateJSON implements the Client interface.
 func (c *OpenAIClient) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	params := c.effectiveParams(ctx)
+	c.logRequest("generate_json", params)
+	if err := meterFrom(ctx).check(c.config.Model, estimateTokens(c.config.Model, prompt), c.config.MaxTokens); err != nil {
+		return "", err
+	}
+
+	var response string
+	err := c.withRetries(ctx, params.MaxRetries, func(ctx context.Context) error {
+		var reqErr error
+		response, reqErr = c.makeRequest(ctx, prompt, params)
+		return reqErr
+	})
+	return response, err
+}
+
+// withRetries calls request until it succeeds, fails with a non-retryable error or
+// maxRetries retries have been made. Retries wait as long as the provider asks with
+// Retry-After, and at least for exponential backoff with jitter. When the provider
+// reports its rate limit exhausted, the clients of the pool hold back until it
+// resets.
+func (c *OpenAIClient) withRetries(ctx context.Context, maxRetries int, request func(ctx context.Context) error) error {
 	var lastErr error
-	delay := c.config.RetryDelay
+	var delay time.Duration
 
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			log.Info().
 				Int("attempt", attempt).
@@ -82,36 +167,49 @@ func (c *OpenAIClient) GenerateJSON(ctx context.Context, prompt string) (string,
 			case <-time.After(delay):
 				// Continue with retry
 			case <-ctx.Done():
-				return "", ctx.Err()
+				return ctx.Err()
 			}
-
-			// Exponential backoff
-			delay *= 2
 		}
 
-		response, err := c.makeRequest(ctx, prompt)
+		if err := c.config.Pool.wait(ctx); err != nil {
+			return err
+		}
+		requestCtx, hint := withRateLimitHint(ctx)
+		err := request(requestCtx)
+		retryAfter, reset := hint.waits()
+		if reset > 0 {
+			// Other clients sharing the pool would hit the same limit
+			c.config.Pool.pause(min(reset, maxRetryDelay))
+		}
 		if err == nil {
-			return response, nil
+			return nil
 		}
 
 		lastErr = err
 
 		// Check if error is retryable
 		if !isRetryableError(err) {
-			return "", err
+			return err
+		}
+		delay = retryDelay(c.config.RetryDelay, attempt+1, max(retryAfter, reset))
+		if isRateLimitError(err) {
+			c.config.Pool.pause(delay)
 		}
 
 		log.Warn().
 			Err(err).
 			Int("attempt", attempt).
-			Int("max_retries", c.config.MaxRetries).
+			Int("max_retries", maxRetries).
+			Dur("retry_after", retryAfter).
 			Msg("AI request failed, will retry")
 	}
 
-	return "", fmt.Errorf("failed after %d retries: %w", c.config.MaxRetries+1, lastErr)
+	return fmt.Errorf("failed after %d retries: %w", maxRetries+1, lastErr)
 }
 
-func (c *OpenAIClient) makeRequest(ctx context.Context, prompt string) (string, error) {
+// chatRequest builds the JSON generation request for prompt, constrained to schema
+// if it is not nil.
+func (c *OpenAIClient) chatRequest(prompt string, params RequestParams, schema *ResponseSchema) openai.ChatCompletionRequest {
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -126,18 +224,25 @@ func (c *OpenAIClient) makeRequest(ctx context.Context, prompt string) (string,
 	req := openai.ChatCompletionRequest{
 		Model:       c.config.Model,
 		Messages:    messages,
-		Temperature: c.config.Temperature,
+		Temperature: params.Temperature,
 		MaxTokens:   c.config.MaxTokens,
-		ResponseFormat: &openai.ChatCompletionResponseFormat{
-			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
-		},
-	}
 
-	if c.config.Seed != nil {
-		req.Seed = c.config.Seed
+		ResponseFormat: c.responseFormat(schema),
 	}
 
-	resp, err := c.client.CreateChatCompletion(ctx, req)
+	req.Seed = params.Seed
+	return req
+}
+
+func (c *OpenAIClient) makeRequest(ctx context.Context, prompt string, params RequestParams) (string, error) {
+	if c.config.Provider == ProviderOllama {
+		return c.makeOllamaRequest(ctx, prompt, params)
+	}
+	schema := c.responseSchema(ctx)
+	resp, err := c.client.CreateChatCompletion(ctx, c.chatRequest(prompt, params, schema))
+	if err != nil && c.rejectedSchema(err, schema) {
+		resp, err = c.client.CreateChatCompletion(ctx, c.chatRequest(prompt, params, nil))
+	}
 	if err != nil {
 		return "", fmt.Errorf("AI request failed: %w", err)
 	}
@@ -145,36 +250,58 @@ func (c *OpenAIClient) makeRequest(ctx context.Context, prompt string) (string,
 	if len(resp.Choices) == 0 {
 		return "", errors.New("no response choices from AI model")
 	}
-
 	content := resp.Choices[0].Message.Content
+	meterFrom(ctx).record(c.config.Model, reportedUsage(c.config.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, prompt, content))
+
+	return checkJSON(content)
+}
 
-	// Validate that the response is valid JSON
+// checkJSON returns content if it is valid JSON.
+func checkJSON(content string) (string, error) {
 	var jsonCheck interface{}
 	if err := json.Unmarshal([]byte(content), &jsonCheck); err != nil {
 		return "", fmt.Errorf("AI response is not valid JSON: %w", err)
 	}
-
 	return content, nil
 }
 
+// isRateLimitError reports whether the provider rejected a request because its rate
+// limit was exceeded.
+func isRateLimitError(err error) bool {
+	return httpStatus(err) == http.StatusTooManyRequests
+}
+
+// httpStatus returns the HTTP status code of a failed provider request, whether the
+// provider sent a JSON error or not, or 0.
+func httpStatus(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	if status, ok := ollamaStatus(err); ok {
+		return status
+	}
+	return 0
+}
+
 // isRetryableError determines if an error should trigger a retry.
 func isRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
 
-	// Check for specific OpenAI API errors that are retryable
-	var apiErr *openai.APIError
-	if errors.As(err, &apiErr) {
-		// Retry on rate limit, server errors, and service unavailable
-		switch apiErr.HTTPStatusCode {
-		case 429, // Too Many Requests
-			500, // Internal Server Error
-			502, // Bad Gateway
-			503, // Service Unavailable
-			504: // Gateway Timeout
-			return true
-		}
+	// Retry on rate limit, server errors, and service unavailable
+	switch httpStatus(err) {
+	case 429, // Too Many Requests
+		500, // Internal Server Error
+		502, // Bad Gateway
+		503, // Service Unavailable
+		504: // Gateway Timeout
+		return true
 	}
 
 	// Check for context errors (don't retry on cancellation)
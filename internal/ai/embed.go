@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxEmbeddingBatch is the most texts sent in one embeddings request.
+const maxEmbeddingBatch = 64
+
+// Embed returns the embedding vectors of texts from the provider's embeddings
+// endpoint, using the client's model as the embeddings model. Texts are sent in
+// batches, with the retries and rate limiting of generation requests.
+func (c *OpenAIClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	params := c.effectiveParams(ctx)
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingBatch {
+		batch := texts[start:min(start+maxEmbeddingBatch, len(texts))]
+		var resp openai.EmbeddingResponse
+		err := c.withRetries(ctx, params.MaxRetries, func(ctx context.Context) error {
+			var reqErr error
+			resp, reqErr = c.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+				Input: batch,
+				Model: openai.EmbeddingModel(c.config.Model),
+			})
+			return reqErr
+		})
+		if err != nil {
+			return nil, fmt.Errorf("embeddings request failed: %w", err)
+		}
+		if len(resp.Data) != len(batch) {
+			return nil, fmt.Errorf("embeddings endpoint returned %d vectors for %d texts", len(resp.Data), len(batch))
+		}
+		embeddings := make([][]float32, len(batch))
+		for _, item := range resp.Data {
+			if item.Index < 0 || item.Index >= len(batch) {
+				return nil, fmt.Errorf("embeddings endpoint returned an invalid index %d", item.Index)
+			}
+			embeddings[item.Index] = item.Embedding
+		}
+		vectors = append(vectors, embeddings...)
+		log.Debug().Int("texts", len(batch)).Int("prompt_tokens", resp.Usage.PromptTokens).Msg("Embedded texts")
+	}
+	return vectors, nil
+}
@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAIClient_Embed tests that texts are embedded in batches and returned in order.
+func TestOpenAIClient_Embed(t *testing.T) {
+	var batches []int
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		var req struct {
+			Input []string `json:"input"`
+			Model string   `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "text-embedding-3-small", req.Model)
+		batches = append(batches, len(req.Input))
+
+		// Answer out of order; the index places each vector
+		data := make([]map[string]interface{}, 0, len(req.Input))
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, map[string]interface{}{"object": "embedding", "index": i, "embedding": []float32{float32(len(req.Input[i])), 1}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"object": "list", "data": data, "model": req.Model})
+	}))
+	defer mockServer.Close()
+
+	client, err := NewOpenAIClient(Config{BaseURL: mockServer.URL + "/v1", APIKey: "test-api-key", Model: "text-embedding-3-small"})
+	require.NoError(t, err)
+
+	texts := make([]string, maxEmbeddingBatch+2)
+	for i := range texts {
+		texts[i] = string(make([]byte, i))
+	}
+	vectors, err := client.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	assert.Equal(t, []int{maxEmbeddingBatch, 2}, batches)
+	require.Len(t, vectors, len(texts))
+	for i, vector := range vectors {
+		assert.Equal(t, []float32{float32(i), 1}, vector)
+	}
+}
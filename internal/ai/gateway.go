@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// gatewayTransport adds the configured extra headers and query parameters, such as
+// organization IDs or routing hints required by enterprise gateways, to every request.
+type gatewayTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+	query   map[string]string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *gatewayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	if len(t.query) > 0 {
+		query := req.URL.Query()
+		for name, value := range t.query {
+			query.Set(name, value)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newHTTPClient returns the HTTP client used for all provider requests, applying the
+// extra headers and query parameters of the configuration, using the connections
+// of its pool, if any, and reading the rate limit headers of responses. With a
+// cassette, requests are recorded or replayed below the other transports.
+func newHTTPClient(config Config) (*http.Client, error) {
+	var base http.RoundTripper = http.DefaultTransport
+	if config.Pool != nil {
+		base = config.Pool.transport
+	}
+	if config.Cassette != nil {
+		base = config.Cassette.transport(base)
+	}
+	if len(config.ExtraHeaders) == 0 && len(config.ExtraQuery) == 0 {
+		return &http.Client{Transport: &rateLimitTransport{base: base}}, nil
+	}
+
+	headers, err := resolveEnvValues(config.ExtraHeaders, "header")
+	if err != nil {
+		return nil, err
+	}
+	query, err := resolveEnvValues(config.ExtraQuery, "query parameter")
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: &rateLimitTransport{base: &gatewayTransport{
+		base:    base,
+		headers: headers,
+		query:   query,
+	}}}, nil
+}
+
+// resolveEnvValues expands ${VAR} and $VAR references to environment variables in the
+// values, so that secrets need not be stored in configuration files. Referencing an
+// unset variable is an error.
+func resolveEnvValues(values map[string]string, kind string) (map[string]string, error) {
+	resolved := make(map[string]string, len(values))
+	for name, value := range values {
+		var missing string
+		resolved[name] = os.Expand(value, func(variable string) string {
+			val, ok := os.LookupEnv(variable)
+			if !ok && missing == "" {
+				missing = variable
+			}
+			return val
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("environment variable %s referenced by extra %s %s is not set", missing, kind, name)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAIClient_ExtraHeadersAndQuery tests that gateway headers and query parameters reach every request.
+func TestOpenAIClient_ExtraHeadersAndQuery(t *testing.T) {
+	t.Setenv("DOCLOOM_TEST_ORG", "org-42")
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		assert.Equal(t, "org-42", r.Header.Get("X-Org-Id"))
+		assert.Equal(t, "eu-west", r.Header.Get("X-Route"))
+		assert.Equal(t, "2024-06-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "Bearer test-api-key", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/models" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": []map[string]string{{"id": "gpt-4"}}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "test-id",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"choices": []map[string]interface{}{
+				{"index": 0, "message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}, "finish_reason": "stop"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewOpenAIClient(Config{
+		BaseURL:      server.URL + "/v1",
+		APIKey:       "test-api-key",
+		Model:        "gpt-4",
+		ExtraHeaders: map[string]string{"X-Org-Id": "${DOCLOOM_TEST_ORG}", "X-Route": "eu-west"},
+		ExtraQuery:   map[string]string{"api-version": "2024-06-01"},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Probe(context.Background())
+	require.NoError(t, err)
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+	require.NoError(t, err)
+	_, err = client.ChatWithTools(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/v1/models", "/v1/chat/completions", "/v1/chat/completions"}, paths)
+}
+
+// TestNewOpenAIClient_UnsetHeaderVariable tests that referencing an unset environment variable fails.
+func TestNewOpenAIClient_UnsetHeaderVariable(t *testing.T) {
+	_, err := NewOpenAIClient(Config{
+		APIKey:       "test-api-key",
+		Model:        "gpt-4",
+		ExtraHeaders: map[string]string{"X-Org-Id": "${DOCLOOM_TEST_UNSET_ORG}"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DOCLOOM_TEST_UNSET_ORG")
+	assert.Contains(t, err.Error(), "X-Org-Id")
+}
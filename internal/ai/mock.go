@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MockClient is a Client that answers requests with a fixed list of responses, in
+// order, without contacting a model. It exercises the generation pipeline offline,
+// for example in docloom smoke.
+type MockClient struct {
+	mu        sync.Mutex
+	responses []string
+	prompts   []string
+}
+
+// NewMockClient returns a client answering the first request with the first
+// response, the second with the second, and so on.
+func NewMockClient(responses ...string) *MockClient {
+	return &MockClient{responses: responses}
+}
+
+// GenerateJSON implements the Client interface. Requests beyond the last response
+// fail.
+func (c *MockClient) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prompts = append(c.prompts, prompt)
+	if len(c.prompts) > len(c.responses) {
+		return "", fmt.Errorf("mock client has no response for request %d", len(c.prompts))
+	}
+	return c.responses[len(c.prompts)-1], nil
+}
+
+// Prompts returns the prompts received so far.
+func (c *MockClient) Prompts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.prompts...)
+}
+
+// Ensure MockClient implements Client.
+var _ Client = (*MockClient)(nil)
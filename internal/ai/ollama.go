@@ -0,0 +1,228 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ollamaSystemPrompt asks for bare JSON more insistently than the OpenAI system
+// prompt: small local models often ignore the response format and wrap JSON in
+// Markdown or prose.
+const ollamaSystemPrompt = "You generate structured JSON output based on the provided instructions. " +
+	"Respond with exactly one JSON value and nothing else: no Markdown code fences, no explanations before or after it. " +
+	"Use double quotes for all keys and strings, and do not add comments or trailing commas."
+
+// ollamaError is an error response of the Ollama API.
+type ollamaError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ollamaError) Error() string {
+	return fmt.Sprintf("ollama returned %d: %s", e.StatusCode, e.Message)
+}
+
+// ollamaMessage is a message of an Ollama chat request or response.
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ollamaChatRequest is a request to Ollama's native /api/chat endpoint.
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Format   json.RawMessage `json:"format,omitempty"` // "json" or a JSON schema
+	Stream   bool            `json:"stream"`
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+// ollamaChatResponse is a non-streamed response of /api/chat.
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+// ollamaHost returns the Ollama server address of a base URL, which may also be
+// given with the /v1 path of its OpenAI-compatible API or the /api path.
+func ollamaHost(baseURL string) string {
+	host := strings.TrimRight(baseURL, "/")
+	for _, suffix := range []string{"/v1", "/api"} {
+		host = strings.TrimSuffix(host, suffix)
+	}
+	return host
+}
+
+// makeOllamaRequest generates JSON with Ollama's native chat API. The response is
+// constrained with the format parameter: the response schema when there is one,
+// else plain JSON. A model that is not available locally fails with guidance to
+// pull it.
+func (c *OpenAIClient) makeOllamaRequest(ctx context.Context, prompt string, params RequestParams) (string, error) {
+	format := json.RawMessage(`"json"`)
+	schema := c.responseSchema(ctx)
+	if schema != nil {
+		format = schema.Schema
+	}
+	resp, err := c.ollamaChat(ctx, prompt, params, format)
+	if err != nil && schema != nil && httpStatus(err) == http.StatusBadRequest {
+		c.schemaRejected.Store(true)
+		resp, err = c.ollamaChat(ctx, prompt, params, json.RawMessage(`"json"`))
+	}
+	if err != nil {
+		if httpStatus(err) == http.StatusNotFound {
+			return "", fmt.Errorf("model %q is not available in Ollama at %s; download it with: ollama pull %s", c.config.Model, ollamaHost(c.config.BaseURL), c.config.Model)
+		}
+		return "", fmt.Errorf("AI request failed: %w", err)
+	}
+
+	content := resp.Message.Content
+	meterFrom(ctx).record(c.config.Model, reportedUsage(c.config.Model, resp.PromptEvalCount, resp.EvalCount, prompt, content))
+	return checkJSON(extractJSON(content))
+}
+
+// ollamaChat sends a JSON generation request to /api/chat.
+func (c *OpenAIClient) ollamaChat(ctx context.Context, prompt string, params RequestParams, format json.RawMessage) (*ollamaChatResponse, error) {
+	options := map[string]any{"num_predict": c.config.MaxTokens}
+	if params.Temperature != 0 {
+		options["temperature"] = params.Temperature
+	}
+	if params.Seed != nil {
+		options["seed"] = *params.Seed
+	}
+	body, err := json.Marshal(ollamaChatRequest{
+		Model: c.config.Model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: ollamaSystemPrompt},
+			{Role: "user", Content: prompt},
+		},
+		Format:  format,
+		Options: options,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var resp ollamaChatResponse
+	if err := c.ollamaCall(ctx, http.MethodPost, "/api/chat", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ollamaCall sends a request to the Ollama API and decodes the JSON response into
+// out. Error responses are returned as *ollamaError.
+func (c *OpenAIClient) ollamaCall(ctx context.Context, method, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, ollamaHost(c.config.BaseURL)+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) != nil || apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return &ollamaError{StatusCode: resp.StatusCode, Message: apiErr.Error}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// probeOllama verifies that the model is available locally, listing the local
+// models with /api/tags, and reads its capabilities with /api/show.
+func (c *OpenAIClient) probeOllama(ctx context.Context) (*Capabilities, error) {
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	host := ollamaHost(c.config.BaseURL)
+	if err := c.ollamaCall(ctx, http.MethodGet, "/api/tags", nil, &tags); err != nil {
+		return nil, fmt.Errorf("failed to list the models of Ollama at %s; check that it is running: %w", host, err)
+	}
+	found := false
+	names := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		// Models are listed with their tag; an untagged name means :latest
+		if model.Name == c.config.Model || model.Name == c.config.Model+":latest" {
+			found = true
+		}
+		names = append(names, model.Name)
+	}
+	if !found {
+		slices.Sort(names)
+		if len(names) > maxListedModels {
+			names = append(names[:maxListedModels], "...")
+		}
+		return nil, fmt.Errorf("model %q is not available in Ollama at %s (local models: %s); download it with: ollama pull %s",
+			c.config.Model, host, strings.Join(names, ", "), c.config.Model)
+	}
+
+	// Ollama constrains any model to a format, so structured outputs are supported
+	caps := &Capabilities{Model: c.config.Model, Tools: true, JSONMode: true, Vision: true, StructuredOutputs: true}
+	var show struct {
+		Capabilities []string       `json:"capabilities"`
+		ModelInfo    map[string]any `json:"model_info"`
+	}
+	body, _ := json.Marshal(map[string]string{"model": c.config.Model})
+	if err := c.ollamaCall(ctx, http.MethodPost, "/api/show", body, &show); err != nil {
+		return caps, nil
+	}
+	if len(show.Capabilities) > 0 {
+		caps.Tools = slices.Contains(show.Capabilities, "tools")
+		caps.Vision = slices.Contains(show.Capabilities, "vision")
+	}
+	for key, value := range show.ModelInfo {
+		if window, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			caps.ContextWindow = int(window)
+		}
+	}
+	return caps, nil
+}
+
+// extractJSON returns the JSON value in content, dropping Markdown code fences and
+// text around it, which small models add despite being asked not to. Content
+// without a JSON object or array is returned unchanged.
+func extractJSON(content string) string {
+	trimmed := strings.TrimSpace(content)
+	start := strings.IndexAny(trimmed, "{[")
+	if start < 0 {
+		return content
+	}
+	closing := "}"
+	if trimmed[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(trimmed, closing)
+	if end < start {
+		return content
+	}
+	return trimmed[start : end+1]
+}
+
+// ollamaStatus returns the HTTP status of an error response of the Ollama API.
+func ollamaStatus(err error) (int, bool) {
+	var apiErr *ollamaError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, true
+	}
+	return 0, false
+}
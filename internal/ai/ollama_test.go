@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOllamaServer serves the native Ollama API with llama3.2 pulled, answering chat
+// requests with content and recording their bodies.
+func newOllamaServer(t *testing.T, content string, requests *[]ollamaChatRequest) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags":
+			_, _ = io.WriteString(w, `{"models": [{"name": "llama3.2:latest"}, {"name": "qwen2.5:7b"}]}`)
+		case "/api/show":
+			_, _ = io.WriteString(w, `{"capabilities": ["completion", "tools"], "model_info": {"llama.context_length": 131072}}`)
+		case "/api/chat":
+			var req ollamaChatRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*requests = append(*requests, req)
+			if req.Model != "llama3.2" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error": "model \"`+req.Model+`\" not found, try pulling it first"}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"message":           map[string]string{"role": "assistant", "content": content},
+				"prompt_eval_count": 120,
+				"eval_count":        30,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestOpenAIClient_OllamaGenerateJSON(t *testing.T) {
+	var requests []ollamaChatRequest
+	server := newOllamaServer(t, "Here is the document:\n```json\n{\"title\": \"Ledger\"}\n```\nLet me know!", &requests)
+	defer server.Close()
+	seed := 7
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", Model: "llama3.2", Provider: ProviderOllama, Seed: &seed, Temperature: 0.2})
+	require.NoError(t, err)
+
+	t.Run("JSON is extracted from the response", func(t *testing.T) {
+		response, err := client.GenerateJSON(context.Background(), "Describe the ledger")
+
+		require.NoError(t, err)
+		assert.Equal(t, `{"title": "Ledger"}`, response)
+		require.Len(t, requests, 1)
+		assert.JSONEq(t, `"json"`, string(requests[0].Format))
+		assert.False(t, requests[0].Stream)
+		assert.Equal(t, ollamaSystemPrompt, requests[0].Messages[0].Content)
+		assert.Equal(t, "Describe the ledger", requests[0].Messages[1].Content)
+		assert.EqualValues(t, 7, requests[0].Options["seed"])
+		assert.InDelta(t, 0.2, requests[0].Options["temperature"], 0.001)
+	})
+
+	t.Run("schema is sent as the format", func(t *testing.T) {
+		schema := json.RawMessage(`{"type": "object", "properties": {"title": {"type": "string"}}}`)
+		ctx := WithResponseSchema(context.Background(), ResponseSchema{Name: "doc", Schema: schema})
+
+		_, err := client.GenerateJSON(ctx, "Describe the ledger")
+
+		require.NoError(t, err)
+		assert.JSONEq(t, string(schema), string(requests[len(requests)-1].Format))
+	})
+}
+
+func TestOpenAIClient_OllamaModelNotPulled(t *testing.T) {
+	var requests []ollamaChatRequest
+	server := newOllamaServer(t, `{}`, &requests)
+	defer server.Close()
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL, Model: "mistral", Provider: ProviderOllama, RetryDelay: 1})
+	require.NoError(t, err)
+
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "download it with: ollama pull mistral")
+	assert.Len(t, requests, 1, "a missing model is not retried")
+
+	_, err = client.Probe(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "local models: llama3.2:latest, qwen2.5:7b")
+	assert.Contains(t, err.Error(), "ollama pull mistral")
+}
+
+func TestOpenAIClient_OllamaProbe(t *testing.T) {
+	var requests []ollamaChatRequest
+	server := newOllamaServer(t, `{}`, &requests)
+	defer server.Close()
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/api", Model: "llama3.2", Provider: "ollama"})
+	require.NoError(t, err)
+
+	caps, err := client.Probe(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &Capabilities{Model: "llama3.2", Tools: true, JSONMode: true, StructuredOutputs: true, ContextWindow: 131072}, caps)
+}
+
+func TestOpenAIClient_OllamaRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"error": "server busy"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"message": {"role": "assistant", "content": "{\"ok\": true}"}}`)
+	}))
+	defer server.Close()
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL, Model: "llama3.2", Provider: ProviderOllama, RetryDelay: 1})
+	require.NoError(t, err)
+
+	response, err := client.GenerateJSON(context.Background(), "prompt")
+
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok": true}`, response)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"a": 1}`:                        `{"a": 1}`,
+		"```json\n{\"a\": 1}\n```":        `{"a": 1}`,
+		"Sure! {\"a\": {\"b\": 2}} Done.": `{"a": {"b": 2}}`,
+		"[1, 2]\n":                        `[1, 2]`,
+		"no json here":                    "no json here",
+	}
+	for content, expected := range tests {
+		assert.Equal(t, expected, extractJSON(content), content)
+	}
+}
@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RequestParams holds the sampling and retry settings applied to a model request.
+type RequestParams struct {
+	Seed        *int
+	MaxRetries  int
+	Temperature float32
+}
+
+// requestParamsKey is the context key for request parameter overrides.
+type requestParamsKey struct{}
+
+// WithRequestParams returns a context whose model requests use params instead of the
+// client's configured defaults. It lets a caller apply one set of generation settings
+// to every call of a run, including repairs, derivations and tool-calling turns.
+// Clients configured with OwnParams keep their own settings.
+func WithRequestParams(ctx context.Context, params RequestParams) context.Context {
+	return context.WithValue(ctx, requestParamsKey{}, params)
+}
+
+// effectiveParams returns the request parameters for ctx, falling back to the client
+// configuration when the context carries no overrides or the client keeps its own.
+func (c *OpenAIClient) effectiveParams(ctx context.Context) RequestParams {
+	if params, ok := ctx.Value(requestParamsKey{}).(RequestParams); ok && !c.config.OwnParams {
+		return params
+	}
+	return RequestParams{
+		Seed:        c.config.Seed,
+		MaxRetries:  c.config.MaxRetries,
+		Temperature: c.config.Temperature,
+	}
+}
+
+// logRequest logs the effective parameters of a model request.
+func (c *OpenAIClient) logRequest(kind string, params RequestParams) {
+	event := log.Debug().
+		Str("request", kind).
+		Str("model", c.config.Model).
+		Float32("temperature", params.Temperature).
+		Int("max_retries", params.MaxRetries)
+	if params.Seed != nil {
+		event = event.Int("seed", *params.Seed)
+	}
+	event.Msg("AI request parameters")
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newParamsServer returns a mock server that records request bodies and fails with
+// 503 for the first failures requests.
+func newParamsServer(t *testing.T, failures int32, bodies *[]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	requestCount := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		*bodies = append(*bodies, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&requestCount, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": map[string]interface{}{"message": "Service temporarily unavailable", "type": "service_unavailable"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "test-id",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"choices": []map[string]interface{}{
+				{"index": 0, "message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}, "finish_reason": "stop"},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAIClient_RequestParamsFromContext(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := newParamsServer(t, 0, &bodies)
+
+	configSeed := 1
+	client, err := NewOpenAIClient(Config{
+		BaseURL:     server.URL + "/v1",
+		APIKey:      "test-api-key",
+		Model:       "test-model",
+		Temperature: 0.9,
+		Seed:        &configSeed,
+	})
+	require.NoError(t, err)
+
+	// Without overrides the client configuration applies
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+	require.NoError(t, err)
+
+	// Context overrides apply to both plain and tool-calling requests
+	runSeed := 42
+	ctx := WithRequestParams(context.Background(), RequestParams{Temperature: 0.2, Seed: &runSeed})
+	_, err = client.GenerateJSON(ctx, "prompt")
+	require.NoError(t, err)
+	_, err = client.ChatWithTools(ctx, []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, bodies, 3)
+	assert.InDelta(t, 0.9, bodies[0]["temperature"], 0.001)
+	assert.Equal(t, float64(1), bodies[0]["seed"])
+	for _, body := range bodies[1:] {
+		assert.InDelta(t, 0.2, body["temperature"], 0.001)
+		assert.Equal(t, float64(42), body["seed"])
+	}
+}
+
+func TestOpenAIClient_OwnParamsWinOverContext(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := newParamsServer(t, 0, &bodies)
+
+	judgeSeed := 7
+	judge, err := NewOpenAIClient(Config{
+		BaseURL:     server.URL + "/v1",
+		APIKey:      "test-api-key",
+		Model:       "judge-model",
+		Temperature: 0.1,
+		Seed:        &judgeSeed,
+		OwnParams:   true,
+	})
+	require.NoError(t, err)
+
+	runSeed := 42
+	ctx := WithRequestParams(context.Background(), RequestParams{Temperature: 0.9, Seed: &runSeed})
+	_, err = judge.GenerateJSON(ctx, "prompt")
+	require.NoError(t, err)
+
+	require.Len(t, bodies, 1)
+	assert.InDelta(t, 0.1, bodies[0]["temperature"], 0.001)
+	assert.Equal(t, float64(7), bodies[0]["seed"])
+}
+
+func TestOpenAIClient_MaxRetriesFromContext(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := newParamsServer(t, 2, &bodies)
+
+	client, err := NewOpenAIClient(Config{
+		BaseURL:    server.URL + "/v1",
+		APIKey:     "test-api-key",
+		Model:      "test-model",
+		MaxRetries: 5,
+		RetryDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// A run configured with one retry gives up before the third attempt
+	ctx := WithRequestParams(context.Background(), RequestParams{MaxRetries: 1})
+	_, err = client.ChatWithTools(ctx, []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed after 2 retries")
+	assert.Len(t, bodies, 2)
+}
@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Pool shares HTTP connections and a request rate limit between clients, so that
+// concurrent generation jobs against one provider reuse connections and together
+// stay within its limits. A nil Pool leaves clients independent.
+type Pool struct {
+	transport *http.Transport
+
+	mu       sync.Mutex
+	interval time.Duration // Minimum spacing of requests; 0 disables the rate limit
+	next     time.Time     // Earliest start of the next request
+}
+
+// NewPool creates a pool keeping up to maxConns idle connections per host and
+// admitting at most requestsPerMinute requests across all its clients (0 for no
+// limit).
+func NewPool(maxConns, requestsPerMinute int) *Pool {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if maxConns > transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConnsPerHost = maxConns
+	}
+	pool := &Pool{transport: transport}
+	if requestsPerMinute > 0 {
+		pool.interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	return pool
+}
+
+// wait blocks until the rate limit admits another request or ctx is done.
+func (p *Pool) wait(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	now := time.Now()
+	start := p.next
+	if start.Before(now) {
+		start = now
+	}
+	p.next = start.Add(p.interval)
+	p.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause holds back every client of the pool for d, after the provider reported that
+// its rate limit was exceeded.
+func (p *Pool) pause(d time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if resume := time.Now().Add(d); resume.After(p.next) {
+		p.next = resume
+	}
+}
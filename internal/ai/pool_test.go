@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_RateLimit(t *testing.T) {
+	pool := NewPool(4, 6000) // One request every 10ms
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.wait(ctx))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	// A pause holds back the next request
+	pool.pause(50 * time.Millisecond)
+	start = time.Now()
+	require.NoError(t, pool.wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// Waiting stops when the context is done
+	pool.pause(time.Hour)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, pool.wait(cancelled), context.Canceled)
+}
+
+func TestPool_Unlimited(t *testing.T) {
+	var pool *Pool
+	require.NoError(t, pool.wait(context.Background()))
+	pool.pause(time.Hour) // No-op without a pool
+
+	pool = NewPool(16, 0)
+	assert.Equal(t, 16, pool.transport.MaxIdleConnsPerHost)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, pool.wait(context.Background()))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestPool_SharedByClients(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"message": "slow down"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}}},
+		})
+	}))
+	defer server.Close()
+
+	pool := NewPool(2, 0)
+	newClient := func(headers map[string]string) *OpenAIClient {
+		client, err := NewOpenAIClient(Config{
+			BaseURL:      server.URL + "/v1",
+			APIKey:       "test-key",
+			Model:        "test-model",
+			MaxRetries:   1,
+			RetryDelay:   30 * time.Millisecond,
+			ExtraHeaders: headers,
+			Pool:         pool,
+		})
+		require.NoError(t, err)
+		return client
+	}
+	first := newClient(nil)
+	second := newClient(map[string]string{"X-Team": "docs"})
+	assert.Same(t, pool.transport, first.httpClient.Transport.(*rateLimitTransport).base)
+	assert.Same(t, pool.transport, second.httpClient.Transport.(*rateLimitTransport).base.(*gatewayTransport).base)
+
+	// The rate-limited request pauses the pool for the retry delay
+	_, err := first.GenerateJSON(context.Background(), "prompt")
+	require.NoError(t, err)
+	pool.mu.Lock()
+	next := pool.next
+	pool.mu.Unlock()
+	assert.False(t, next.IsZero())
+
+	_, err = second.GenerateJSON(context.Background(), "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
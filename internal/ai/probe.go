@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxListedModels bounds the model names quoted when the configured model is unknown.
+const maxListedModels = 10
+
+// Capabilities describes what the configured model supports, as advertised by the
+// models endpoint of the provider or proxy. Capabilities that are not advertised are
+// assumed to be supported, except structured outputs, which are taken from
+// KnownCapabilities.
+type Capabilities struct {
+	Model             string `json:"model"`
+	Tools             bool   `json:"tools"`                       // Tool (function) calling
+	JSONMode          bool   `json:"json_mode"`                   // response_format json_object
+	Vision            bool   `json:"vision"`                      // Image input
+	StructuredOutputs bool   `json:"structured_outputs"`          // response_format json_schema
+	ContextWindow     int    `json:"context_window,omitempty"`    // Maximum context in tokens; 0 when unknown
+	MaxOutputTokens   int    `json:"max_output_tokens,omitempty"` // Maximum response in tokens; 0 when unknown
+}
+
+// KnownCapabilities are the capabilities of common OpenAI models, used to check
+// template model requirements without probing. Capabilities configured for a model
+// take precedence.
+var KnownCapabilities = map[string]Capabilities{
+	"gpt-4":         {Model: "gpt-4", Tools: true, ContextWindow: 8192, MaxOutputTokens: 8192},
+	"gpt-4-turbo":   {Model: "gpt-4-turbo", Tools: true, JSONMode: true, Vision: true, ContextWindow: 128000, MaxOutputTokens: 4096},
+	"gpt-4o":        {Model: "gpt-4o", Tools: true, JSONMode: true, Vision: true, StructuredOutputs: true, ContextWindow: 128000, MaxOutputTokens: 16384},
+	"gpt-4o-mini":   {Model: "gpt-4o-mini", Tools: true, JSONMode: true, Vision: true, StructuredOutputs: true, ContextWindow: 128000, MaxOutputTokens: 16384},
+	"gpt-3.5-turbo": {Model: "gpt-3.5-turbo", Tools: true, JSONMode: true, ContextWindow: 16385, MaxOutputTokens: 4096},
+}
+
+// LookupCapabilities returns the capabilities of model from configured or, failing
+// that, from KnownCapabilities, matching dated versions like LookupPrice. The
+// returned capabilities name model.
+func LookupCapabilities(configured map[string]Capabilities, model string) (*Capabilities, bool) {
+	caps, ok := lookupModel(model, configured, KnownCapabilities)
+	if !ok {
+		return nil, false
+	}
+	caps.Model = model
+	return &caps, true
+}
+
+// lookupModel returns the entry of model in the first table that has one, by exact
+// name or else by the longest name model starts with followed by "-".
+func lookupModel[T any](model string, tables ...map[string]T) (T, bool) {
+	for _, table := range tables {
+		if entry, ok := table[model]; ok {
+			return entry, true
+		}
+		best := ""
+		for name := range table {
+			if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+				best = name
+			}
+		}
+		if best != "" {
+			return table[best], true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// modelEntry is one entry of a models listing. Besides the OpenAI fields it reads
+// the capability metadata published by common gateways: OpenRouter (context_length,
+// supported_parameters, architecture), Groq (context_window), vLLM (max_model_len)
+// and Mistral (max_context_length, capabilities).
+type modelEntry struct {
+	ID                  string          `json:"id"`
+	ContextLength       int             `json:"context_length"`
+	ContextWindow       int             `json:"context_window"`
+	MaxModelLen         int             `json:"max_model_len"`
+	MaxContextLength    int             `json:"max_context_length"`
+	SupportedParameters []string        `json:"supported_parameters"`
+	Capabilities        json.RawMessage `json:"capabilities"`
+	Architecture        struct {
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+}
+
+// capabilities derives the model capabilities from the entry's metadata.
+func (e modelEntry) capabilities() *Capabilities {
+	caps := &Capabilities{Model: e.ID, Tools: true, JSONMode: true, Vision: true}
+	if known, ok := LookupCapabilities(nil, e.ID); ok {
+		caps.StructuredOutputs = known.StructuredOutputs
+	}
+	for _, window := range []int{e.ContextLength, e.ContextWindow, e.MaxModelLen, e.MaxContextLength} {
+		if window > 0 {
+			caps.ContextWindow = window
+			break
+		}
+	}
+
+	if len(e.SupportedParameters) > 0 {
+		caps.Tools = slices.Contains(e.SupportedParameters, "tools")
+		caps.JSONMode = slices.Contains(e.SupportedParameters, "response_format")
+		caps.StructuredOutputs = slices.Contains(e.SupportedParameters, "structured_outputs")
+	}
+	// Capability flags are only read when published as an object of booleans
+	var flags map[string]bool
+	if json.Unmarshal(e.Capabilities, &flags) == nil {
+		if supported, ok := flags["function_calling"]; ok {
+			caps.Tools = supported
+		}
+		if supported, ok := flags["json_mode"]; ok {
+			caps.JSONMode = supported
+		}
+		if supported, ok := flags["vision"]; ok {
+			caps.Vision = supported
+		}
+	}
+	if len(e.Architecture.InputModalities) > 0 {
+		caps.Vision = slices.Contains(e.Architecture.InputModalities, "image")
+	}
+	return caps
+}
+
+// Probe queries the models endpoint of the configured base URL, verifies that the
+// configured model is served and detects its capabilities. The capabilities are kept
+// on the client: requests omit response_format when JSON mode is not supported and
+// send response schemas only when structured outputs are.
+//
+// Azure OpenAI lists the models of the resource rather than its deployments, so for
+// Azure the probe only verifies the endpoint and key, and the capabilities of the
+// deployment are those known for the model of the same name. For Ollama the probe
+// checks that the model has been pulled.
+func (c *OpenAIClient) Probe(ctx context.Context) (*Capabilities, error) {
+	if c.config.Provider == ProviderOllama {
+		caps, err := c.probeOllama(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.setCapabilities(caps)
+		return caps, nil
+	}
+	url := strings.TrimRight(c.config.BaseURL, "/") + "/models"
+	if c.config.Provider == ProviderAzure {
+		endpoint, _ := azureEndpoint(c.config.BaseURL)
+		url = endpoint + "/openai/models?api-version=" + c.config.APIVersion
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create models request: %w", err)
+	}
+	if c.config.Provider == ProviderAzure {
+		req.Header.Set("api-key", c.config.APIKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("models endpoint %s returned %s; check the base URL and API key", url, resp.Status)
+	}
+
+	var listing struct {
+		Data []modelEntry `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("failed to parse models response from %s: %w", url, err)
+	}
+
+	if c.config.Provider == ProviderAzure {
+		caps, ok := LookupCapabilities(nil, c.config.Model)
+		if !ok {
+			caps = &Capabilities{Model: c.config.Model, Tools: true, JSONMode: true, Vision: true}
+		}
+		c.setCapabilities(caps)
+		return caps, nil
+	}
+
+	ids := make([]string, 0, len(listing.Data))
+	for _, entry := range listing.Data {
+		if entry.ID == c.config.Model {
+			caps := entry.capabilities()
+			c.setCapabilities(caps)
+			return caps, nil
+		}
+		ids = append(ids, entry.ID)
+	}
+
+	sort.Strings(ids)
+	if len(ids) > maxListedModels {
+		ids = append(ids[:maxListedModels], "...")
+	}
+	return nil, fmt.Errorf("model %q is not served by %s (available: %s)", c.config.Model, c.config.BaseURL, strings.Join(ids, ", "))
+}
+
+// setCapabilities keeps the probed capabilities on the client and logs them.
+func (c *OpenAIClient) setCapabilities(caps *Capabilities) {
+	c.capabilities = caps
+	log.Info().
+		Str("model", caps.Model).
+		Bool("tools", caps.Tools).
+		Bool("json_mode", caps.JSONMode).
+		Bool("vision", caps.Vision).
+		Bool("structured_outputs", caps.StructuredOutputs).
+		Int("context_window", caps.ContextWindow).
+		Msg("Model capabilities detected")
+}
+
+// Capabilities returns the capabilities detected by Probe, or nil when the client
+// has not been probed.
+func (c *OpenAIClient) Capabilities() *Capabilities {
+	return c.capabilities
+}
@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProbeServer serves a models listing and records the body of the last chat request.
+func newProbeServer(t *testing.T, models string, chatBody *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/models":
+			assert.Equal(t, "Bearer test-api-key", r.Header.Get("Authorization"))
+			_, _ = io.WriteString(w, models)
+		case "/v1/chat/completions":
+			if chatBody != nil {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(chatBody))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":      "test-id",
+				"object":  "chat.completion",
+				"created": time.Now().Unix(),
+				"choices": []map[string]interface{}{
+					{"index": 0, "message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}, "finish_reason": "stop"},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestOpenAIClient_Probe_DetectsCapabilities tests capability detection from gateway metadata.
+func TestOpenAIClient_Probe_DetectsCapabilities(t *testing.T) {
+	models := `{"object": "list", "data": [
+		{"id": "gpt-4", "object": "model"},
+		{"id": "local-llama", "context_length": 8192, "supported_parameters": ["temperature", "tools"]},
+		{"id": "mistral-small", "max_context_length": 32768, "capabilities": {"function_calling": false, "completion_chat": true}},
+		{"id": "pixtral-12b", "capabilities": {"vision": true}},
+		{"id": "router/text-only", "architecture": {"input_modalities": ["text"]}},
+		{"id": "gpt-4o-2024-08-06", "object": "model"},
+		{"id": "router/schema", "supported_parameters": ["response_format", "structured_outputs"]}
+	]}`
+	server := newProbeServer(t, models, nil)
+
+	tests := []struct {
+		model    string
+		expected Capabilities
+	}{
+		{"gpt-4", Capabilities{Model: "gpt-4", Tools: true, JSONMode: true, Vision: true}},
+		{"local-llama", Capabilities{Model: "local-llama", Tools: true, JSONMode: false, Vision: true, ContextWindow: 8192}},
+		{"mistral-small", Capabilities{Model: "mistral-small", Tools: false, JSONMode: true, Vision: true, ContextWindow: 32768}},
+		{"pixtral-12b", Capabilities{Model: "pixtral-12b", Tools: true, JSONMode: true, Vision: true}},
+		{"router/text-only", Capabilities{Model: "router/text-only", Tools: true, JSONMode: true, Vision: false}},
+		{"gpt-4o-2024-08-06", Capabilities{Model: "gpt-4o-2024-08-06", Tools: true, JSONMode: true, Vision: true, StructuredOutputs: true}},
+		{"router/schema", Capabilities{Model: "router/schema", Tools: false, JSONMode: true, Vision: true, StructuredOutputs: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: tt.model})
+			require.NoError(t, err)
+
+			caps, err := client.Probe(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *caps)
+			assert.Equal(t, caps, client.Capabilities())
+		})
+	}
+}
+
+// TestOpenAIClient_Probe_UnknownModel tests that an unknown model fails with the available models.
+func TestOpenAIClient_Probe_UnknownModel(t *testing.T) {
+	server := newProbeServer(t, `{"data": [{"id": "gpt-4o"}, {"id": "gpt-4o-mini"}]}`, nil)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: "gpt-5-turbo"})
+	require.NoError(t, err)
+
+	_, err = client.Probe(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `model "gpt-5-turbo" is not served`)
+	assert.Contains(t, err.Error(), "gpt-4o, gpt-4o-mini")
+	assert.Nil(t, client.Capabilities())
+}
+
+// TestOpenAIClient_Probe_EndpointError tests the error for an unreachable or failing models endpoint.
+func TestOpenAIClient_Probe_EndpointError(t *testing.T) {
+	server := newProbeServer(t, "", nil)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/missing", APIKey: "test-api-key", Model: "gpt-4"})
+	require.NoError(t, err)
+
+	_, err = client.Probe(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+// TestOpenAIClient_Probe_DisablesResponseFormat tests that JSON mode is dropped for models without it.
+func TestOpenAIClient_Probe_DisablesResponseFormat(t *testing.T) {
+	var body map[string]interface{}
+	server := newProbeServer(t, `{"data": [{"id": "local-llama", "supported_parameters": ["tools"]}]}`, &body)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: "local-llama"})
+	require.NoError(t, err)
+
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+	require.NoError(t, err)
+	assert.Contains(t, body, "response_format", "unprobed clients request JSON mode")
+
+	_, err = client.Probe(context.Background())
+	require.NoError(t, err)
+	body = nil
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+	require.NoError(t, err)
+	assert.NotContains(t, body, "response_format")
+}
+
+// TestLookupCapabilities tests capabilities of known and configured models.
+func TestLookupCapabilities(t *testing.T) {
+	caps, ok := LookupCapabilities(nil, "gpt-4o-2024-08-06")
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4o-2024-08-06", caps.Model)
+	assert.True(t, caps.Vision)
+	assert.Equal(t, 128000, caps.ContextWindow)
+
+	configured := map[string]Capabilities{"gpt-4o": {ContextWindow: 64000}}
+	caps, ok = LookupCapabilities(configured, "gpt-4o")
+	require.True(t, ok)
+	assert.Equal(t, 64000, caps.ContextWindow, "configured capabilities take precedence")
+
+	_, ok = LookupCapabilities(nil, "local-llama")
+	assert.False(t, ok)
+}
@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Providers selectable with Config.Provider.
+const (
+	ProviderOpenAI = "openai" // OpenAI and OpenAI-compatible APIs
+	ProviderAzure  = "azure"  // Azure OpenAI deployments
+	ProviderOllama = "ollama" // Local models served by Ollama, using its native API
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is
+// configured; it supports tools and structured outputs.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// azureHostSuffixes are the host names of Azure OpenAI resource endpoints.
+var azureHostSuffixes = []string{".openai.azure.com", ".cognitiveservices.azure.com"}
+
+// DetectProvider returns the provider serving baseURL: azure for the endpoints of
+// Azure OpenAI resources and openai for everything else.
+func DetectProvider(baseURL string) string {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return ProviderOpenAI
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, suffix := range azureHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return ProviderAzure
+		}
+	}
+	return ProviderOpenAI
+}
+
+// resolveProvider returns the configured provider, or the one detected from the
+// base URL when none is configured.
+func resolveProvider(config Config) (string, error) {
+	switch provider := strings.ToLower(config.Provider); provider {
+	case "":
+		return DetectProvider(config.BaseURL), nil
+	case ProviderOpenAI, ProviderAzure, ProviderOllama:
+		return provider, nil
+	default:
+		return "", fmt.Errorf("unknown provider %q (expected %s, %s or %s)", config.Provider, ProviderOpenAI, ProviderAzure, ProviderOllama)
+	}
+}
+
+// azureEndpoint returns the resource endpoint of an Azure OpenAI base URL, which
+// may also be given with the /openai path or a deployment path, and the deployment
+// named in the URL, if any.
+func azureEndpoint(baseURL string) (endpoint, deployment string) {
+	endpoint = strings.TrimRight(baseURL, "/")
+	if i := strings.Index(endpoint, "/openai/deployments/"); i >= 0 {
+		deployment = strings.SplitN(endpoint[i+len("/openai/deployments/"):], "/", 2)[0]
+		return endpoint[:i], deployment
+	}
+	return strings.TrimSuffix(endpoint, "/openai"), ""
+}
+
+// openAIConfig returns the go-openai configuration for the provider. Azure requests
+// authenticate with the api-key header, carry the api-version query parameter and
+// address the deployment named by the model, or by the base URL when it includes a
+// deployment path.
+//
+// Ollama JSON requests use its native API; the client gets the address of its
+// OpenAI-compatible API for tool calls and streaming.
+func openAIConfig(config Config, provider string) openai.ClientConfig {
+	switch provider {
+	case ProviderOllama:
+		clientConfig := openai.DefaultConfig(config.APIKey)
+		clientConfig.BaseURL = ollamaHost(config.BaseURL) + "/v1"
+		return clientConfig
+	case ProviderOpenAI:
+		clientConfig := openai.DefaultConfig(config.APIKey)
+		clientConfig.BaseURL = config.BaseURL
+		return clientConfig
+	}
+
+	endpoint, deployment := azureEndpoint(config.BaseURL)
+	clientConfig := openai.DefaultAzureConfig(config.APIKey, endpoint)
+	clientConfig.APIVersion = config.APIVersion
+	clientConfig.AzureModelMapperFunc = func(model string) string {
+		if deployment != "" {
+			return deployment
+		}
+		return model
+	}
+	return clientConfig
+}
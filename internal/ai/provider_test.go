@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectProvider(t *testing.T) {
+	tests := map[string]string{
+		"https://api.openai.com/v1":                             ProviderOpenAI,
+		"http://localhost:11434/v1":                             ProviderOpenAI,
+		"https://contoso.openai.azure.com":                      ProviderAzure,
+		"https://Contoso.OpenAI.Azure.com/openai/deployments/x": ProviderAzure,
+		"https://contoso.cognitiveservices.azure.com/":          ProviderAzure,
+		"https://gateway.example.com/openai.azure.com":          ProviderOpenAI,
+	}
+	for baseURL, expected := range tests {
+		assert.Equal(t, expected, DetectProvider(baseURL), baseURL)
+	}
+}
+
+func TestAzureEndpoint(t *testing.T) {
+	tests := []struct {
+		baseURL, endpoint, deployment string
+	}{
+		{"https://contoso.openai.azure.com/", "https://contoso.openai.azure.com", ""},
+		{"https://contoso.openai.azure.com/openai", "https://contoso.openai.azure.com", ""},
+		{"https://contoso.openai.azure.com/openai/deployments/docs-gpt4o", "https://contoso.openai.azure.com", "docs-gpt4o"},
+		{"https://contoso.openai.azure.com/openai/deployments/docs-gpt4o/chat/completions", "https://contoso.openai.azure.com", "docs-gpt4o"},
+	}
+	for _, tt := range tests {
+		endpoint, deployment := azureEndpoint(tt.baseURL)
+		assert.Equal(t, tt.endpoint, endpoint, tt.baseURL)
+		assert.Equal(t, tt.deployment, deployment, tt.baseURL)
+	}
+}
+
+// newAzureServer serves chat completions and the models listing the way Azure OpenAI
+// does, checking the api-key header and api-version parameter.
+func newAzureServer(t *testing.T, apiVersion string, paths *[]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		assert.Equal(t, "azure-key", r.Header.Get("api-key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Equal(t, apiVersion, r.URL.Query().Get("api-version"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/openai/models" {
+			_, _ = io.WriteString(w, `{"data": [{"id": "gpt-4o-2024-08-06"}]}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}}},
+		})
+	}))
+}
+
+func TestOpenAIClient_AzureDeployment(t *testing.T) {
+	var paths []string
+	server := newAzureServer(t, DefaultAzureAPIVersion, &paths)
+	defer server.Close()
+
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL, APIKey: "azure-key", Model: "docs-gpt-4.1", Provider: ProviderAzure})
+	require.NoError(t, err)
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/openai/deployments/docs-gpt-4.1/chat/completions"}, paths)
+}
+
+func TestOpenAIClient_AzureDeploymentFromBaseURL(t *testing.T) {
+	var paths []string
+	server := newAzureServer(t, "2024-08-01-preview", &paths)
+	defer server.Close()
+
+	client, err := NewOpenAIClient(Config{
+		BaseURL:    server.URL + "/openai/deployments/docs/",
+		APIKey:     "azure-key",
+		Model:      "gpt-4o",
+		Provider:   "Azure",
+		APIVersion: "2024-08-01-preview",
+	})
+	require.NoError(t, err)
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+	require.NoError(t, err)
+	caps, err := client.Probe(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/openai/deployments/docs/chat/completions", "/openai/models"}, paths)
+	assert.True(t, caps.StructuredOutputs, "capabilities of the model the deployment is named after")
+}
+
+func TestNewOpenAIClient_UnknownProvider(t *testing.T) {
+	_, err := NewOpenAIClient(Config{APIKey: "key", Model: "gpt-4o", Provider: "bedrock"})
+
+	assert.EqualError(t, err, `unknown provider "bedrock" (expected openai, azure or ollama)`)
+}
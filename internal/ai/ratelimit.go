@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRetryDelay caps the wait before a retry, including waits the provider asks for.
+const maxRetryDelay = 2 * time.Minute
+
+// rateLimitHint holds what a provider's response headers said about when to send
+// the next request.
+type rateLimitHint struct {
+	mu         sync.Mutex
+	retryAfter time.Duration // Requested by a failed response (Retry-After, retry-after-ms)
+	reset      time.Duration // Until an exhausted request or token limit resets (x-ratelimit-*)
+}
+
+// rateLimitHintKey is the context key for the rate limit hint of a request.
+type rateLimitHintKey struct{}
+
+// withRateLimitHint returns a context whose requests record their rate limit
+// headers in the returned hint.
+func withRateLimitHint(ctx context.Context) (context.Context, *rateLimitHint) {
+	hint := &rateLimitHint{}
+	return context.WithValue(ctx, rateLimitHintKey{}, hint), hint
+}
+
+// waits returns the wait requested by the last response and the time until its
+// exhausted rate limit resets.
+func (h *rateLimitHint) waits() (retryAfter, reset time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.retryAfter, h.reset
+}
+
+// record reads the rate limit headers of a response.
+func (h *rateLimitHint) record(header http.Header, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retryAfter = parseRetryAfter(header, now)
+	h.reset = parseRateLimitReset(header)
+}
+
+// rateLimitTransport records the rate limit headers of every response in the hint of
+// its request's context, if any.
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		if hint, ok := req.Context().Value(rateLimitHintKey{}).(*rateLimitHint); ok {
+			hint.record(resp.Header, time.Now())
+		}
+	}
+	return resp, err
+}
+
+// parseRetryAfter returns the wait requested by the retry-after-ms header (Azure
+// OpenAI) or the Retry-After header, in seconds or as an HTTP date, or 0.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// parseRateLimitReset returns the time until the request or token limit resets when
+// the x-ratelimit-remaining-* headers report it exhausted, or 0. Reset times are
+// durations such as "1s" or "6m0s", as sent by OpenAI, or plain seconds.
+func parseRateLimitReset(header http.Header) time.Duration {
+	var reset time.Duration
+	for _, limit := range []string{"requests", "tokens"} {
+		if header.Get("x-ratelimit-remaining-"+limit) != "0" {
+			continue
+		}
+		value := header.Get("x-ratelimit-reset-" + limit)
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			d = time.Duration(seconds * float64(time.Second))
+		}
+		reset = max(reset, d)
+	}
+	return reset
+}
+
+// retryDelay returns the wait before retry attempt (1 for the first retry): the
+// longer of the wait the provider asked for and exponential backoff from base with
+// jitter, so clients failing together do not retry together. It is capped at
+// maxRetryDelay.
+func retryDelay(base time.Duration, attempt int, retryAfter time.Duration) time.Duration {
+	backoff := base << (attempt - 1)
+	if backoff <= 0 || backoff > maxRetryDelay {
+		backoff = maxRetryDelay
+	}
+	// Equal jitter: between half and all of the backoff
+	delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)) // #nosec G404 -- jitter needs no cryptographic randomness
+	return min(max(delay, retryAfter), maxRetryDelay)
+}
@@ -0,0 +1,149 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{name: "seconds", header: http.Header{"Retry-After": {"3"}}, expected: 3 * time.Second},
+		{name: "HTTP date", header: http.Header{"Retry-After": {"Fri, 31 Jan 2025 09:00:20 GMT"}}, expected: 20 * time.Second},
+		{name: "past date", header: http.Header{"Retry-After": {"Fri, 31 Jan 2025 08:00:00 GMT"}}},
+		{name: "milliseconds take precedence", header: http.Header{"Retry-After": {"1"}, "Retry-After-Ms": {"250"}}, expected: 250 * time.Millisecond},
+		{name: "invalid", header: http.Header{"Retry-After": {"soon"}}},
+		{name: "missing", header: http.Header{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseRetryAfter(tt.header, now))
+		})
+	}
+}
+
+func TestParseRateLimitReset(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   map[string]string
+		expected time.Duration
+	}{
+		{name: "requests exhausted", header: map[string]string{"x-ratelimit-remaining-requests": "0", "x-ratelimit-reset-requests": "6m0s"}, expected: 6 * time.Minute},
+		{name: "tokens exhausted in seconds", header: map[string]string{"x-ratelimit-remaining-tokens": "0", "x-ratelimit-reset-tokens": "1.5"}, expected: 1500 * time.Millisecond},
+		{name: "longest reset of both", header: map[string]string{
+			"x-ratelimit-remaining-requests": "0", "x-ratelimit-reset-requests": "20ms",
+			"x-ratelimit-remaining-tokens": "0", "x-ratelimit-reset-tokens": "2s",
+		}, expected: 2 * time.Second},
+		{name: "limit not exhausted", header: map[string]string{"x-ratelimit-remaining-requests": "42", "x-ratelimit-reset-requests": "1s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.header {
+				header.Set(name, value)
+			}
+			assert.Equal(t, tt.expected, parseRateLimitReset(header))
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 1; attempt <= 4; attempt++ {
+		backoff := base << (attempt - 1)
+		for i := 0; i < 20; i++ {
+			delay := retryDelay(base, attempt, 0)
+			assert.GreaterOrEqual(t, delay, backoff/2)
+			assert.LessOrEqual(t, delay, backoff)
+		}
+	}
+	assert.Equal(t, 5*time.Second, retryDelay(base, 1, 5*time.Second), "the provider's wait takes precedence")
+	assert.Equal(t, maxRetryDelay, retryDelay(base, 1, time.Hour))
+	assert.LessOrEqual(t, retryDelay(base, 40, 0), maxRetryDelay)
+}
+
+func TestOpenAIClient_RespectsRetryAfter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("retry-after-ms", "150")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error": {"message": "Rate limit reached", "type": "requests"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}}},
+		})
+	}))
+	defer server.Close()
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-key", Model: "test-model", MaxRetries: 1, RetryDelay: time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestOpenAIClient_RetriesGatewayErrorsWithoutJSON(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html>502 Bad Gateway</html>`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}}},
+		})
+	}))
+	defer server.Close()
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-key", Model: "test-model", MaxRetries: 1, RetryDelay: time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestOpenAIClient_ExhaustedRateLimitPausesPool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-ratelimit-remaining-requests", "0")
+		w.Header().Set("x-ratelimit-reset-requests", "10s")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}}},
+		})
+	}))
+	defer server.Close()
+	pool := NewPool(1, 0)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-key", Model: "test-model", Pool: pool})
+	require.NoError(t, err)
+
+	_, err = client.GenerateJSON(context.Background(), "prompt")
+
+	require.NoError(t, err)
+	pool.mu.Lock()
+	next := pool.next
+	pool.mu.Unlock()
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), next, 2*time.Second, "the next request waits for the limit to reset")
+}
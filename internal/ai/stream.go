@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// StreamProgress reports how much of a streamed response has arrived.
+type StreamProgress struct {
+	Tokens  int           // Content deltas received; each is usually one token
+	Bytes   int           // Bytes of content received
+	Elapsed time.Duration // Time since the request was sent
+	Done    bool          // The stream has ended, successfully or not
+}
+
+// ProgressFunc is called for every content delta of a streamed response.
+type ProgressFunc func(StreamProgress)
+
+// StreamingClient is a Client that can stream responses, reporting progress while a
+// long generation is in flight.
+type StreamingClient interface {
+	Client
+	// GenerateJSONStream is GenerateJSON with the response streamed; progress, if not
+	// nil, is called as content arrives. Cancelling ctx aborts the stream.
+	GenerateJSONStream(ctx context.Context, prompt string, progress ProgressFunc) (string, error)
+}
+
+// GenerateJSONStream implements the StreamingClient interface using server-sent events.
+// A stream that fails with a retryable error is restarted from the beginning. Ollama
+// responses are not streamed, so that they get its JSON constraints.
+func (c *OpenAIClient) GenerateJSONStream(ctx context.Context, prompt string, progress ProgressFunc) (string, error) {
+	if c.config.Provider == ProviderOllama {
+		return c.GenerateJSON(ctx, prompt)
+	}
+	params := c.effectiveParams(ctx)
+	c.logRequest("generate_json_stream", params)
+	if err := meterFrom(ctx).check(c.config.Model, estimateTokens(c.config.Model, prompt), c.config.MaxTokens); err != nil {
+		return "", err
+	}
+
+	var response string
+	err := c.withRetries(ctx, params.MaxRetries, func(ctx context.Context) error {
+		var reqErr error
+		response, reqErr = c.makeStreamRequest(ctx, prompt, params, progress)
+		return reqErr
+	})
+	return response, err
+}
+
+func (c *OpenAIClient) makeStreamRequest(ctx context.Context, prompt string, params RequestParams, progress ProgressFunc) (string, error) {
+	start := time.Now()
+	schema := c.responseSchema(ctx)
+	req := c.chatRequest(prompt, params, schema)
+	req.Stream = true
+
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil && c.rejectedSchema(err, schema) {
+		req = c.chatRequest(prompt, params, nil)
+		req.Stream = true
+		stream, err = c.client.CreateChatCompletionStream(ctx, req)
+	}
+	if err != nil {
+		return "", fmt.Errorf("AI request failed: %w", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var content strings.Builder
+	tokens := 0
+	// Streams do not report usage; the tokens received so far are billed even when
+	// the stream fails
+	defer func() {
+		meterFrom(ctx).record(c.config.Model, reportedUsage(c.config.Model, 0, 0, prompt, content.String()))
+	}()
+	if progress != nil {
+		defer func() {
+			progress(StreamProgress{Tokens: tokens, Bytes: content.Len(), Elapsed: time.Since(start), Done: true})
+		}()
+	}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				err = ctxErr
+			}
+			return "", fmt.Errorf("AI stream failed after %d tokens: %w", tokens, err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		tokens++
+		if progress != nil {
+			progress(StreamProgress{Tokens: tokens, Bytes: content.Len(), Elapsed: time.Since(start)})
+		}
+	}
+
+	if tokens == 0 {
+		return "", errors.New("no response content from AI model")
+	}
+	return checkJSON(content.String())
+}
+
+// Ensure OpenAIClient streams.
+var _ StreamingClient = (*OpenAIClient)(nil)
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamServer streams deltas as server-sent events, then blocks on block, if set,
+// before ending the stream.
+func newStreamServer(t *testing.T, deltas []string, block chan struct{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, true, req["stream"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range deltas {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"id":      "test-id",
+				"object":  "chat.completion.chunk",
+				"choices": []map[string]interface{}{{"index": 0, "delta": map[string]string{"content": delta}}},
+			})
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		if block != nil {
+			select {
+			case <-block:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestOpenAIClient_GenerateJSONStream tests that deltas are assembled and progress reported.
+func TestOpenAIClient_GenerateJSONStream(t *testing.T) {
+	server := newStreamServer(t, []string{`{"title": `, `"Streamed`, `"}`}, nil)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: "gpt-4"})
+	require.NoError(t, err)
+
+	var updates []StreamProgress
+	response, err := client.GenerateJSONStream(context.Background(), "prompt", func(p StreamProgress) {
+		updates = append(updates, p)
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title": "Streamed"}`, response)
+
+	require.Len(t, updates, 4)
+	assert.Equal(t, 1, updates[0].Tokens)
+	assert.False(t, updates[2].Done)
+	last := updates[3]
+	assert.True(t, last.Done)
+	assert.Equal(t, 3, last.Tokens)
+	assert.Equal(t, len(response), last.Bytes)
+}
+
+// TestOpenAIClient_GenerateJSONStream_InvalidJSON tests that the assembled response is validated.
+func TestOpenAIClient_GenerateJSONStream_InvalidJSON(t *testing.T) {
+	server := newStreamServer(t, []string{`{"title": `}, nil)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: "gpt-4"})
+	require.NoError(t, err)
+
+	_, err = client.GenerateJSONStream(context.Background(), "prompt", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not valid JSON")
+}
+
+// TestOpenAIClient_GenerateJSONStream_Cancel tests that cancelling the context aborts a stream mid-response.
+func TestOpenAIClient_GenerateJSONStream_Cancel(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	server := newStreamServer(t, []string{`{"title": `, `"Partial`}, block)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: "gpt-4"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var done bool
+	_, err = client.GenerateJSONStream(ctx, "prompt", func(p StreamProgress) {
+		if p.Tokens == 2 && !p.Done {
+			cancel()
+		}
+		done = p.Done
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "after 2 tokens")
+	assert.True(t, done)
+}
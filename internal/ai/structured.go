@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ResponseSchema is the JSON schema a model response must conform to.
+type ResponseSchema struct {
+	Name   string // Identifies the schema to the provider, e.g. the template name
+	Schema json.RawMessage
+}
+
+// responseSchemaKey is the context key for the response schema.
+type responseSchemaKey struct{}
+
+// WithResponseSchema returns a context whose JSON requests ask for responses
+// conforming to schema, using structured outputs (response_format json_schema) on
+// models that support them. Other models get the usual JSON mode and rely on the
+// schema in the prompt.
+func WithResponseSchema(ctx context.Context, schema ResponseSchema) context.Context {
+	if len(schema.Schema) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, responseSchemaKey{}, schema)
+}
+
+// schemaNameInvalid matches the characters providers reject in schema names.
+var schemaNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// responseSchema returns the response schema of ctx when the client can send it as
+// a structured output, or nil.
+func (c *OpenAIClient) responseSchema(ctx context.Context) *ResponseSchema {
+	schema, ok := ctx.Value(responseSchemaKey{}).(ResponseSchema)
+	if !ok || !c.structuredOutputs() {
+		return nil
+	}
+	schema.Name = schemaNameInvalid.ReplaceAllString(schema.Name, "_")
+	if schema.Name == "" {
+		schema.Name = "document"
+	}
+	if len(schema.Name) > 64 {
+		schema.Name = schema.Name[:64]
+	}
+	return &schema
+}
+
+// structuredOutputs reports whether requests may constrain responses to a JSON
+// schema: as configured, else as probed, else as known for the model. A provider
+// that rejected a schema is not sent one again.
+func (c *OpenAIClient) structuredOutputs() bool {
+	if c.schemaRejected.Load() {
+		return false
+	}
+	if c.config.StructuredOutputs != nil {
+		return *c.config.StructuredOutputs
+	}
+	if c.config.Provider == ProviderOllama {
+		return true // The format parameter constrains any local model
+	}
+	if c.capabilities != nil {
+		return c.capabilities.StructuredOutputs
+	}
+	caps, ok := LookupCapabilities(nil, c.config.Model)
+	return ok && caps.StructuredOutputs
+}
+
+// rejectedSchema reports whether err is the provider rejecting a request with a
+// response schema, in which case later requests are sent without one.
+func (c *OpenAIClient) rejectedSchema(err error, schema *ResponseSchema) bool {
+	var apiErr *openai.APIError
+	if schema == nil || !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		return false
+	}
+	c.schemaRejected.Store(true)
+	log.Warn().Err(err).Str("model", c.config.Model).Msg("Provider rejected the structured output schema; falling back to JSON mode")
+	return true
+}
+
+// responseFormat returns the response format of a JSON request: the schema as a
+// structured output when there is one, else JSON mode unless the model was probed
+// without it.
+func (c *OpenAIClient) responseFormat(schema *ResponseSchema) *openai.ChatCompletionResponseFormat {
+	if schema != nil {
+		return &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   schema.Name,
+				Schema: schema.Schema,
+			},
+		}
+	}
+	// Models probed without JSON mode rely on the prompt and response validation alone
+	if c.capabilities == nil || c.capabilities.JSONMode {
+		return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+	return nil
+}
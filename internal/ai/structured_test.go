@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const documentSchema = `{"type":"object","properties":{"title":{"type":"string"}},"required":["title"]}`
+
+// newSchemaServer records the response_format of every chat request and rejects
+// json_schema formats when reject is set.
+func newSchemaServer(t *testing.T, reject bool, formats *[]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResponseFormat map[string]interface{} `json:"response_format"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		*formats = append(*formats, body.ResponseFormat)
+		w.Header().Set("Content-Type", "application/json")
+		if reject && body.ResponseFormat["type"] == "json_schema" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": {"message": "Invalid parameter: response_format of type 'json_schema' is not supported with this model.", "type": "invalid_request_error"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "test-id",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"choices": []map[string]interface{}{
+				{"index": 0, "message": map[string]interface{}{"role": "assistant", "content": `{"title": "Ledger"}`}, "finish_reason": "stop"},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAIClient_StructuredOutputs(t *testing.T) {
+	ctx := WithResponseSchema(context.Background(), ResponseSchema{Name: "architecture vision", Schema: json.RawMessage(documentSchema)})
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name       string
+		model      string
+		configured *bool
+		wantType   string
+	}{
+		{name: "known model", model: "gpt-4o-2024-08-06", wantType: "json_schema"},
+		{name: "model without structured outputs", model: "gpt-3.5-turbo", wantType: "json_object"},
+		{name: "unknown model", model: "local-llama", wantType: "json_object"},
+		{name: "enabled by configuration", model: "local-llama", configured: &enabled, wantType: "json_schema"},
+		{name: "disabled by configuration", model: "gpt-4o", configured: &disabled, wantType: "json_object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var formats []map[string]interface{}
+			server := newSchemaServer(t, false, &formats)
+			client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: tt.model, StructuredOutputs: tt.configured})
+			require.NoError(t, err)
+
+			_, err = client.GenerateJSON(ctx, "prompt")
+
+			require.NoError(t, err)
+			require.Len(t, formats, 1)
+			assert.Equal(t, tt.wantType, formats[0]["type"])
+			if tt.wantType == "json_schema" {
+				jsonSchema := formats[0]["json_schema"].(map[string]interface{})
+				assert.Equal(t, "architecture_vision", jsonSchema["name"])
+				assert.Equal(t, false, jsonSchema["strict"])
+				schema, err := json.Marshal(jsonSchema["schema"])
+				require.NoError(t, err)
+				assert.JSONEq(t, documentSchema, string(schema))
+			}
+		})
+	}
+
+	t.Run("requests without a schema use JSON mode", func(t *testing.T) {
+		var formats []map[string]interface{}
+		server := newSchemaServer(t, false, &formats)
+		client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: "gpt-4o"})
+		require.NoError(t, err)
+
+		_, err = client.GenerateJSON(context.Background(), "prompt")
+
+		require.NoError(t, err)
+		assert.Equal(t, "json_object", formats[0]["type"])
+	})
+}
+
+func TestOpenAIClient_StructuredOutputs_FallsBackWhenRejected(t *testing.T) {
+	ctx := WithResponseSchema(context.Background(), ResponseSchema{Name: "brief", Schema: json.RawMessage(documentSchema)})
+	var formats []map[string]interface{}
+	server := newSchemaServer(t, true, &formats)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-api-key", Model: "gpt-4o", MaxRetries: 1})
+	require.NoError(t, err)
+
+	response, err := client.GenerateJSON(ctx, "prompt")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title": "Ledger"}`, response)
+	_, err = client.GenerateJSON(ctx, "prompt")
+	require.NoError(t, err)
+
+	types := make([]interface{}, 0, len(formats))
+	for _, format := range formats {
+		types = append(types, format["type"])
+	}
+	assert.Equal(t, []interface{}{"json_schema", "json_object", "json_object"}, types, "the schema is not sent again after a rejection")
+	assert.True(t, client.schemaRejected.Load())
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -100,12 +101,15 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, messages []ChatMessage
 		})
 	}
 
-	// Create the request
+	// Create the request with the effective sampling parameters
+	params := c.effectiveParams(ctx)
+	c.logRequest("chat_with_tools", params)
 	req := openai.ChatCompletionRequest{
 		Model:       c.config.Model,
 		Messages:    openaiMessages,
 		MaxTokens:   c.config.MaxTokens,
-		Temperature: c.config.Temperature,
+		Temperature: params.Temperature,
+		Seed:        params.Seed,
 	}
 
 	// Add tools if provided
@@ -113,15 +117,30 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, messages []ChatMessage
 		req.Tools = openaiTools
 	}
 
-	// Add seed if configured
-	if c.config.Seed != nil {
-		req.Seed = c.config.Seed
+	var conversation strings.Builder
+	for _, msg := range openaiMessages {
+		conversation.WriteString(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			conversation.WriteString(tc.Function.Arguments)
+		}
+	}
+	meter := meterFrom(ctx)
+	if err := meter.check(c.config.Model, estimateTokens(c.config.Model, conversation.String()), c.config.MaxTokens); err != nil {
+		return nil, err
 	}
 
-	// Make the API call
-	resp, err := c.client.CreateChatCompletion(ctx, req)
+	// Make the API call, retrying transient failures like GenerateJSON
+	var resp openai.ChatCompletionResponse
+	err := c.withRetries(ctx, params.MaxRetries, func(ctx context.Context) error {
+		var reqErr error
+		resp, reqErr = c.client.CreateChatCompletion(ctx, req)
+		if reqErr != nil {
+			return fmt.Errorf("chat completion failed: %w", reqErr)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("chat completion failed: %w", err)
+		return nil, err
 	}
 
 	if len(resp.Choices) == 0 {
@@ -129,6 +148,7 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, messages []ChatMessage
 	}
 
 	choice := resp.Choices[0]
+	meter.record(c.config.Model, reportedUsage(c.config.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, conversation.String(), choice.Message.Content))
 	result := &ChatResponse{
 		FinishReason: string(choice.FinishReason),
 	}
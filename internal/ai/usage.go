@@ -0,0 +1,222 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/karolswdev/docloom/internal/tokens"
+)
+
+// Usage counts the tokens of model requests.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	Requests         int `json:"requests"`
+
+	// Some counts come from the model's tokenizer because the provider did not
+	// report them, as for streamed responses
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// TotalTokens returns the prompt and completion tokens together.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// add adds the counts of other to u.
+func (u *Usage) add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.Requests += other.Requests
+	u.Estimated = u.Estimated || other.Estimated
+}
+
+// Price is the cost of a model's tokens in US dollars per million tokens.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Cost returns the cost of usage in US dollars.
+func (p Price) Cost(usage Usage) float64 {
+	return (float64(usage.PromptTokens)*p.Input + float64(usage.CompletionTokens)*p.Output) / 1e6
+}
+
+// DefaultPrices are the list prices of common OpenAI models. Prices configured for
+// a model take precedence.
+var DefaultPrices = map[string]Price{
+	"gpt-4":         {Input: 30, Output: 60},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4o":        {Input: 2.5, Output: 10},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.6},
+	"gpt-3.5-turbo": {Input: 0.5, Output: 1.5},
+}
+
+// LookupPrice returns the price of model from prices or, failing that, from
+// DefaultPrices. Dated model versions such as "gpt-4o-2024-08-06" use the price of
+// the longest model name they start with.
+func LookupPrice(prices map[string]Price, model string) (Price, bool) {
+	return lookupModel(model, prices, DefaultPrices)
+}
+
+// BudgetError reports a request that was not sent because its projected cost would
+// take the run over its budget.
+type BudgetError struct {
+	Model     string
+	Budget    float64 // US dollars
+	Spent     float64 // Cost of the requests made so far
+	Projected float64 // Spent plus the cost of the request if it used its maximum tokens
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("projected cost $%.4f of the next %s request exceeds the budget of $%.4f ($%.4f spent)", e.Projected, e.Model, e.Budget, e.Spent)
+}
+
+// ModelUsage is the usage and cost of one model's requests.
+type ModelUsage struct {
+	Model  string  `json:"model"`
+	Usage  Usage   `json:"usage"`
+	Cost   float64 `json:"cost_usd"`
+	Priced bool    `json:"priced"` // False when the model has no known price and Cost is 0
+}
+
+// UsageSummary is the token usage and cost of a run.
+type UsageSummary struct {
+	Models []ModelUsage `json:"models"`
+	Total  Usage        `json:"total"`
+	Cost   float64      `json:"cost_usd"`             // Cost of the priced models
+	Budget float64      `json:"budget_usd,omitempty"` // The run's budget; 0 for none
+}
+
+// Unpriced returns the models without a known price, whose cost is missing from
+// the summary.
+func (s *UsageSummary) Unpriced() []string {
+	var models []string
+	for _, model := range s.Models {
+		if !model.Priced {
+			models = append(models, model.Model)
+		}
+	}
+	return models
+}
+
+// Meter accumulates the token usage of the model requests made with a context, by
+// model, and stops requests that would exceed an optional budget. Clients record
+// into the meter of the context a request is made with (see WithMeter), so one
+// meter covers a run's generation, repairs, quality stages and agent analysis
+// turns. A nil Meter records nothing.
+type Meter struct {
+	prices map[string]Price
+	budget float64
+
+	mu     sync.Mutex
+	models map[string]*Usage
+}
+
+// NewMeter creates a meter pricing tokens with prices (see LookupPrice). A budget
+// above 0 limits the cost of the metered requests in US dollars.
+func NewMeter(prices map[string]Price, budget float64) *Meter {
+	return &Meter{prices: prices, budget: budget, models: make(map[string]*Usage)}
+}
+
+// meterKey is the context key for the usage meter.
+type meterKey struct{}
+
+// WithMeter returns a context whose model requests are recorded in meter.
+func WithMeter(ctx context.Context, meter *Meter) context.Context {
+	if meter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, meterKey{}, meter)
+}
+
+// meterFrom returns the meter of ctx, or nil.
+func meterFrom(ctx context.Context) *Meter {
+	meter, _ := ctx.Value(meterKey{}).(*Meter)
+	return meter
+}
+
+// CheckPriced returns an error when the meter has a budget and model has no known
+// price, so the budget could not be enforced for its requests.
+func (m *Meter) CheckPriced(model string) error {
+	if m == nil || m.budget <= 0 {
+		return nil
+	}
+	if _, ok := LookupPrice(m.prices, model); !ok {
+		return fmt.Errorf("no price is known for model %s; configure its price to use a budget", model)
+	}
+	return nil
+}
+
+// check returns a *BudgetError when a request to model with a prompt of about
+// promptTokens and a response of up to maxTokens could take the cost over budget.
+func (m *Meter) check(model string, promptTokens, maxTokens int) error {
+	if m == nil || m.budget <= 0 {
+		return nil
+	}
+	if err := m.CheckPriced(model); err != nil {
+		return err
+	}
+	price, _ := LookupPrice(m.prices, model)
+	spent := m.Summary().Cost
+	projected := spent + price.Cost(Usage{PromptTokens: promptTokens, CompletionTokens: maxTokens})
+	if projected > m.budget {
+		return &BudgetError{Model: model, Budget: m.budget, Spent: spent, Projected: projected}
+	}
+	return nil
+}
+
+// record adds the usage of a request to model.
+func (m *Meter) record(model string, usage Usage) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total, ok := m.models[model]
+	if !ok {
+		total = &Usage{}
+		m.models[model] = total
+	}
+	total.add(usage)
+}
+
+// Summary returns the usage and cost recorded so far, by model in name order, or
+// nil for a nil meter.
+func (m *Meter) Summary() *UsageSummary {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	summary := &UsageSummary{Models: []ModelUsage{}, Budget: m.budget}
+	for model, usage := range m.models {
+		price, priced := LookupPrice(m.prices, model)
+		modelUsage := ModelUsage{Model: model, Usage: *usage, Priced: priced}
+		if priced {
+			modelUsage.Cost = price.Cost(*usage)
+		}
+		summary.Models = append(summary.Models, modelUsage)
+		summary.Total.add(*usage)
+		summary.Cost += modelUsage.Cost
+	}
+	sort.Slice(summary.Models, func(i, j int) bool { return summary.Models[i].Model < summary.Models[j].Model })
+	return summary
+}
+
+// estimateTokens counts the tokens of text with model's tokenizer, for budget checks
+// and providers that do not report usage.
+func estimateTokens(model, text string) int {
+	return tokens.ForModel(model).Count(text)
+}
+
+// reportedUsage converts the usage reported for a request, counting it from the
+// prompt and response with model's tokenizer when the provider reported none.
+func reportedUsage(model string, promptTokens, completionTokens int, prompt, response string) Usage {
+	if promptTokens == 0 && completionTokens == 0 {
+		return Usage{PromptTokens: estimateTokens(model, prompt), CompletionTokens: estimateTokens(model, response), Requests: 1, Estimated: true}
+	}
+	return Usage{PromptTokens: promptTokens, CompletionTokens: completionTokens, Requests: 1}
+}
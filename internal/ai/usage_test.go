@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUsageServer returns a server answering chat completions with usage reported
+// for every request.
+func newUsageServer(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"ok": true}`}}},
+			"usage":   map[string]interface{}{"prompt_tokens": 1000, "completion_tokens": 200, "total_tokens": 1200},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMeter_RecordsReportedUsage(t *testing.T) {
+	var requests int32
+	server := newUsageServer(t, &requests)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-key", Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	meter := NewMeter(nil, 0)
+	ctx := WithMeter(context.Background(), meter)
+	for i := 0; i < 2; i++ {
+		_, err := client.GenerateJSON(ctx, "Describe the system")
+		require.NoError(t, err)
+	}
+	_, err = client.ChatWithTools(ctx, []ChatMessage{{Role: "user", Content: "Analyze"}}, nil)
+	require.NoError(t, err)
+
+	summary := meter.Summary()
+	require.Len(t, summary.Models, 1)
+	assert.Equal(t, Usage{PromptTokens: 3000, CompletionTokens: 600, Requests: 3}, summary.Total)
+	assert.True(t, summary.Models[0].Priced)
+	assert.InDelta(t, 3000*2.5/1e6+600*10/1e6, summary.Cost, 1e-9)
+	assert.Empty(t, summary.Unpriced())
+
+	// Requests without a meter are not recorded
+	_, err = client.GenerateJSON(context.Background(), "Describe the system")
+	require.NoError(t, err)
+	assert.Equal(t, 3, meter.Summary().Total.Requests)
+}
+
+func TestMeter_Budget(t *testing.T) {
+	var requests int32
+	server := newUsageServer(t, &requests)
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL + "/v1", APIKey: "test-key", Model: "priced-model", MaxTokens: 1000})
+	require.NoError(t, err)
+
+	// Each request costs $0.0012; the projection assumes 1000 completion tokens
+	meter := NewMeter(map[string]Price{"priced-model": {Input: 1, Output: 1}}, 0.004)
+	ctx := WithMeter(context.Background(), meter)
+	for i := 0; i < 3; i++ {
+		_, err := client.GenerateJSON(ctx, "Describe the system")
+		require.NoError(t, err)
+	}
+
+	_, err = client.GenerateJSON(ctx, "Describe the system")
+	var budgetErr *BudgetError
+	require.ErrorAs(t, err, &budgetErr)
+	assert.InDelta(t, 0.0036, budgetErr.Spent, 1e-9)
+	assert.Greater(t, budgetErr.Projected, 0.004)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "the request over budget is not sent")
+}
+
+func TestMeter_UnpricedModel(t *testing.T) {
+	meter := NewMeter(nil, 1)
+	assert.Error(t, meter.CheckPriced("local-llama"))
+	assert.NoError(t, NewMeter(nil, 0).CheckPriced("local-llama"), "without a budget prices are optional")
+
+	meter.record("local-llama", Usage{PromptTokens: 10, CompletionTokens: 5, Requests: 1})
+	summary := meter.Summary()
+	assert.Equal(t, []string{"local-llama"}, summary.Unpriced())
+	assert.Zero(t, summary.Cost)
+
+	var nilMeter *Meter
+	nilMeter.record("gpt-4", Usage{Requests: 1})
+	assert.Nil(t, nilMeter.Summary())
+}
+
+func TestLookupPrice(t *testing.T) {
+	price, ok := LookupPrice(nil, "gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, DefaultPrices["gpt-4o-mini"], price)
+
+	price, ok = LookupPrice(map[string]Price{"gpt-4o": {Input: 1, Output: 2}}, "gpt-4o")
+	require.True(t, ok)
+	assert.Equal(t, Price{Input: 1, Output: 2}, price, "configured prices take precedence")
+
+	_, ok = LookupPrice(nil, "gpt-4x")
+	assert.False(t, ok)
+}
@@ -0,0 +1,157 @@
+// Package batch runs many document generation jobs concurrently, each isolated from
+// the failures of the others.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Job is a single document to generate.
+type Job struct {
+	Name    string   `yaml:"name" json:"name"`
+	Type    string   `yaml:"type" json:"type"` // Template type
+	Sources []string `yaml:"sources" json:"sources"`
+	Out     string   `yaml:"out" json:"out"` // Output path; may be a filename pattern such as "{{project}}-{{date}}.html"
+
+	// Vars are the variables for the output filename pattern
+	Vars map[string]string `yaml:"vars" json:"vars,omitempty"`
+}
+
+// File is the layout of a jobs file.
+type File struct {
+	Jobs []Job `yaml:"jobs" json:"jobs"`
+}
+
+// LoadJobs reads and validates the jobs of a jobs file, which is JSON when its name
+// ends in .json and YAML otherwise.
+func LoadJobs(path string) ([]Job, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- the jobs file is user-provided
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs file: %w", err)
+	}
+	var file File
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jobs file %s: %w", path, err)
+	}
+	if len(file.Jobs) == 0 {
+		return nil, fmt.Errorf("jobs file %s defines no jobs", path)
+	}
+
+	names := make(map[string]bool, len(file.Jobs))
+	for i, job := range file.Jobs {
+		if job.Name == "" {
+			return nil, fmt.Errorf("job #%d is missing a name", i+1)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("duplicate job name: %s", job.Name)
+		}
+		names[job.Name] = true
+		if job.Type == "" || job.Out == "" || len(job.Sources) == 0 {
+			return nil, fmt.Errorf("job %s requires type, sources and out", job.Name)
+		}
+	}
+	return file.Jobs, nil
+}
+
+// RunFunc generates the document of a job and returns its output path.
+type RunFunc func(ctx context.Context, job Job) (string, error)
+
+// Outcome is the result of a job.
+type Outcome struct {
+	Job      Job
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// Run executes the jobs with at most parallel running at a time and returns their
+// outcomes in job order. Every job runs with its own context derived from ctx, so a
+// failing job neither cancels nor delays the others; cancelling ctx stops them all.
+func Run(ctx context.Context, jobs []Job, parallel int, run RunFunc) []Outcome {
+	if parallel < 1 {
+		parallel = 1
+	}
+	outcomes := make([]Outcome, len(jobs))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for idx, job := range jobs {
+		wg.Add(1)
+		go func(idx int, job Job) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				outcomes[idx] = Outcome{Job: job, Err: ctx.Err()}
+				return
+			}
+			outcomes[idx] = runJob(ctx, job, run)
+		}(idx, job)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// runJob runs a single job in its own context, turning a panic into a failure of the
+// job alone.
+func runJob(ctx context.Context, job Job, run RunFunc) (outcome Outcome) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	outcome.Job = job
+	defer func() {
+		if r := recover(); r != nil {
+			outcome.Err = fmt.Errorf("job panicked: %v", r)
+		}
+		outcome.Duration = time.Since(start)
+		if outcome.Err != nil {
+			log.Error().Err(outcome.Err).Str("job", job.Name).Msg("Batch job failed")
+		} else {
+			log.Info().Str("job", job.Name).Str("output", outcome.Output).Dur("duration", outcome.Duration).Msg("Batch job completed")
+		}
+	}()
+
+	log.Info().Str("job", job.Name).Str("template", job.Type).Msg("Running batch job")
+	outcome.Output, outcome.Err = run(jobCtx, job)
+	return outcome
+}
+
+// DefaultParallel returns the number of jobs to run at once against the provider at
+// baseURL: one for local servers, which usually serve a single request at a time,
+// four for the large hosted providers and two for other endpoints such as gateways.
+func DefaultParallel(baseURL string) int {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return 2
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" {
+		return 1
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return 1
+	}
+	switch {
+	case host == "api.openai.com", host == "api.anthropic.com", strings.HasSuffix(host, ".openai.azure.com"):
+		return 4
+	}
+	return 2
+}
@@ -0,0 +1,126 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`jobs:
+  - name: payments
+    type: architecture-vision
+    sources: [./payments]
+    out: out/{{project}}.html
+    vars: {project: payments}
+  - name: ledger
+    type: technical-debt-summary
+    sources: [./ledger]
+    out: out/ledger.html
+`), 0644))
+
+	jobs, err := LoadJobs(path)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "payments", jobs[0].Vars["project"])
+	assert.Equal(t, []string{"./ledger"}, jobs[1].Sources)
+}
+
+func TestLoadJobs_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+	"jobs": [
+		{"name": "payments", "type": "architecture-vision", "sources": ["./payments"], "out": "out/{{project}}.html", "vars": {"project": "payments"}}
+	]
+}`), 0644))
+
+	jobs, err := LoadJobs(path)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "payments", jobs[0].Vars["project"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"jobs": [{"name": "payments"}]}`), 0644))
+	_, err = LoadJobs(path)
+	assert.ErrorContains(t, err, "requires type, sources and out")
+}
+
+func TestLoadJobs_Validation(t *testing.T) {
+	cases := map[string]string{
+		"no jobs":   "jobs: []\n",
+		"no name":   "jobs:\n  - type: a\n    sources: [x]\n    out: o.html\n",
+		"duplicate": "jobs:\n  - {name: a, type: t, sources: [x], out: a.html}\n  - {name: a, type: t, sources: [x], out: b.html}\n",
+		"no out":    "jobs:\n  - {name: a, type: t, sources: [x]}\n",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jobs.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+			_, err := LoadJobs(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRun_IsolatesFailures(t *testing.T) {
+	jobs := []Job{{Name: "fails"}, {Name: "panics"}, {Name: "slow"}, {Name: "fast"}}
+	var running, peak int32
+	outcomes := Run(context.Background(), jobs, 2, func(ctx context.Context, job Job) (string, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+
+		switch job.Name {
+		case "fails":
+			return "", errors.New("model unavailable")
+		case "panics":
+			panic("boom")
+		case "slow":
+			select {
+			case <-time.After(20 * time.Millisecond):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		return job.Name + ".html", nil
+	})
+
+	require.Len(t, outcomes, 4)
+	assert.EqualError(t, outcomes[0].Err, "model unavailable")
+	assert.ErrorContains(t, outcomes[1].Err, "job panicked: boom")
+	assert.NoError(t, outcomes[2].Err, "a failing job must not cancel the others")
+	assert.Equal(t, "slow.html", outcomes[2].Output)
+	assert.Equal(t, "fast.html", outcomes[3].Output)
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	outcomes := Run(ctx, []Job{{Name: "a"}, {Name: "b"}}, 1, func(ctx context.Context, job Job) (string, error) {
+		return "", ctx.Err()
+	})
+	for _, outcome := range outcomes {
+		assert.ErrorIs(t, outcome.Err, context.Canceled)
+	}
+}
+
+func TestDefaultParallel(t *testing.T) {
+	assert.Equal(t, 1, DefaultParallel("http://localhost:11434/v1"))
+	assert.Equal(t, 1, DefaultParallel("http://127.0.0.1:1234/v1"))
+	assert.Equal(t, 4, DefaultParallel("https://api.openai.com/v1"))
+	assert.Equal(t, 4, DefaultParallel("https://acme.openai.azure.com/openai"))
+	assert.Equal(t, 2, DefaultParallel("https://gateway.example.com/v1"))
+}
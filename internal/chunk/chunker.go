@@ -6,26 +6,34 @@ import (
 	"unicode"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/tokens"
 )
 
 // Chunker handles content chunking and selection based on token limits.
 type Chunker struct {
 	// MaxTokens defines the maximum number of tokens allowed in the output.
 	MaxTokens int
-	// TokensPerChar is an approximation of tokens per character (default: 0.25 = ~4 chars per token).
+	// TokensPerChar is an approximation of tokens per character (default: 0.25 = ~4 chars per token),
+	// used to find where to truncate content.
 	TokensPerChar float64
+	// Tokenizer counts tokens; nil falls back to a word and character heuristic.
+	Tokenizer *tokens.Counter
 }
 
-// NewChunker creates a new Chunker with default settings.
-func NewChunker(maxTokens int) *Chunker {
+// NewChunker creates a new Chunker with default settings, counting tokens with
+// tokenizer, such as tokens.ForModel of the model the chunks are sent to.
+func NewChunker(maxTokens int, tokenizer *tokens.Counter) *Chunker {
 	return &Chunker{
 		MaxTokens:     maxTokens,
 		TokensPerChar: 0.25, // Approximation: 1 token ≈ 4 characters
+		Tokenizer:     tokenizer,
 	}
 }
 
 // ChunkAndSelect takes input text and returns a truncated version that fits within token limits.
 // This implements a simple heuristic: estimate token count and truncate if necessary.
+// Retriever selects content by relevance instead.
 func (c *Chunker) ChunkAndSelect(content string) string {
 	if content == "" {
 		return ""
@@ -62,11 +70,15 @@ func (c *Chunker) ChunkAndSelect(content string) string {
 	return truncated
 }
 
-// EstimateTokens estimates the number of tokens in the given text.
+// EstimateTokens counts the number of tokens in the given text with the chunker's
+// tokenizer, or estimates it without one.
 func (c *Chunker) EstimateTokens(text string) int {
 	if text == "" {
 		return 0
 	}
+	if c.Tokenizer != nil {
+		return c.Tokenizer.Count(text)
+	}
 
 	// Simple heuristic: count words and punctuation as rough token estimate
 	// This is a simplified version; real tokenizers are more complex
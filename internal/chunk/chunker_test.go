@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/tokens"
 )
 
 // TestChunker_SimpleChunking tests the simple heuristic chunking functionality (TC-11.1).
@@ -15,7 +17,7 @@ func TestChunker_SimpleChunking(t *testing.T) {
 
 	// Set a small token limit (e.g., 50 tokens)
 	maxTokens := 50
-	chunker := NewChunker(maxTokens)
+	chunker := NewChunker(maxTokens, tokens.ForModel(""))
 
 	// Act: Run the chunking and selection logic
 	result := chunker.ChunkAndSelect(longText)
@@ -37,7 +39,7 @@ func TestChunker_ContentWithinLimit(t *testing.T) {
 	// Arrange
 	shortText := "This is a short text that fits within the token limit."
 	maxTokens := 100 // Generous limit
-	chunker := NewChunker(maxTokens)
+	chunker := NewChunker(maxTokens, tokens.ForModel(""))
 
 	// Act
 	result := chunker.ChunkAndSelect(shortText)
@@ -50,7 +52,7 @@ func TestChunker_ContentWithinLimit(t *testing.T) {
 // TestChunker_EmptyContent tests handling of empty content.
 func TestChunker_EmptyContent(t *testing.T) {
 	// Arrange
-	chunker := NewChunker(100)
+	chunker := NewChunker(100, tokens.ForModel(""))
 
 	// Act
 	result := chunker.ChunkAndSelect("")
@@ -61,7 +63,7 @@ func TestChunker_EmptyContent(t *testing.T) {
 
 // TestChunker_EstimateTokens tests token estimation.
 func TestChunker_EstimateTokens(t *testing.T) {
-	chunker := NewChunker(100)
+	chunker := NewChunker(100, tokens.ForModel(""))
 
 	tests := []struct {
 		name      string
@@ -113,7 +115,7 @@ Second paragraph with more information. This is important.
 
 Third paragraph that might get truncated. It contains additional details that may not fit.`
 
-	chunker := NewChunker(30) // Small limit to force truncation
+	chunker := NewChunker(30, tokens.ForModel("")) // Small limit to force truncation
 
 	// Act
 	result := chunker.ChunkAndSelect(content)
@@ -136,7 +138,7 @@ Third paragraph containing important information that should be preserved.
 
 Fourth paragraph with concluding remarks and final thoughts.`
 
-	chunker := NewChunker(20) // Small limit to create multiple chunks
+	chunker := NewChunker(20, tokens.ForModel("")) // Small limit to create multiple chunks
 
 	// Act
 	chunks := chunker.ChunkByParagraphs(content)
@@ -162,7 +164,7 @@ Fourth paragraph with concluding remarks and final thoughts.`
 func TestChunker_VeryLongSingleParagraph(t *testing.T) {
 	// Arrange
 	longParagraph := strings.Repeat("This is a very long sentence without paragraph breaks. ", 50)
-	chunker := NewChunker(50)
+	chunker := NewChunker(50, tokens.ForModel(""))
 
 	// Act
 	result := chunker.ChunkAndSelect(longParagraph)
@@ -179,7 +181,7 @@ func TestChunker_VeryLongSingleParagraph(t *testing.T) {
 func TestChunker_SpecialCharacters(t *testing.T) {
 	// Arrange
 	content := "Special chars: & < > \" ' © ™ émojis: 🚀 🎉 中文字符"
-	chunker := NewChunker(100)
+	chunker := NewChunker(100, tokens.ForModel(""))
 
 	// Act
 	result := chunker.ChunkAndSelect(content)
@@ -191,3 +193,18 @@ func TestChunker_SpecialCharacters(t *testing.T) {
 	tokens := chunker.EstimateTokens(content)
 	assert.Greater(t, tokens, 0, "Should estimate tokens for special characters")
 }
+
+// TestChunkByParagraphs_ModelTokenizer tests that chunks are sized with the tokenizer
+// of the model they are sent to rather than the default encoding.
+func TestChunkByParagraphs_ModelTokenizer(t *testing.T) {
+	paragraph := "नमस्ते दुनिया, यह भुगतान सेवा का विवरण है।"
+	modelTokens := tokens.ForModel("gpt-4o").Count(paragraph)
+	require.Greater(t, tokens.ForModel("").Count(paragraph), modelTokens, "the encodings must count the paragraph differently")
+	content := strings.Repeat(paragraph+"\n\n", 4)
+
+	chunks := NewChunker(2*modelTokens, tokens.ForModel("gpt-4o")).ChunkByParagraphs(content)
+	assert.Len(t, chunks, 2, "two paragraphs fit in a chunk with the model's tokenizer")
+
+	chunks = NewChunker(2*modelTokens, tokens.ForModel("")).ChunkByParagraphs(content)
+	assert.Len(t, chunks, 4)
+}
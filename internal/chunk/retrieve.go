@@ -0,0 +1,186 @@
+package chunk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/tokens"
+)
+
+// Defaults of a Retriever.
+const (
+	DefaultChunkTokens = 400
+	DefaultTopK        = 20
+)
+
+// Headers the ingester writes before each source root and file.
+const (
+	sourceHeaderPrefix = "=== Source: "
+	fileHeaderPrefix   = "--- File: "
+)
+
+// Embedder turns texts into embedding vectors, such as ai.OpenAIClient with an
+// embeddings model.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Retriever selects the parts of ingested source content most relevant to a query,
+// such as a template's prompt and fields, by the similarity of their embeddings.
+type Retriever struct {
+	chunker  *Chunker
+	embedder Embedder
+	topK     int
+}
+
+// NewRetriever creates a retriever splitting content into paragraph chunks of about
+// chunkTokens tokens, counted with tokenizer, and keeping the topK chunks most
+// relevant to the query. Values of zero or less select the defaults.
+func NewRetriever(embedder Embedder, chunkTokens, topK int, tokenizer *tokens.Counter) *Retriever {
+	if chunkTokens <= 0 {
+		chunkTokens = DefaultChunkTokens
+	}
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+	return &Retriever{chunker: NewChunker(chunkTokens, tokenizer), embedder: embedder, topK: topK}
+}
+
+// passage is a chunk of a source file.
+type passage struct {
+	source string // Source header line, or empty
+	file   string // File header line, or empty
+	text   string
+}
+
+// String returns the passage with its headers, as it is embedded.
+func (p passage) String() string {
+	return strings.TrimSpace(p.source + "\n" + p.file + "\n" + p.text)
+}
+
+// Select splits ingested content into paragraph chunks within each file, embeds them
+// together with query and returns the chunks most similar to the query in their
+// original order, under their source and file headers. Content with no more chunks
+// than are kept is returned unchanged.
+func (r *Retriever) Select(ctx context.Context, content, query string) (string, error) {
+	passages := r.split(content)
+	if len(passages) <= r.topK {
+		log.Debug().Int("chunks", len(passages)).Msg("Sources fit in the retrieved chunks, using all content")
+		return content, nil
+	}
+
+	texts := make([]string, 0, len(passages)+1)
+	texts = append(texts, query)
+	for _, p := range passages {
+		texts = append(texts, p.String())
+	}
+	vectors, err := r.embedder.Embed(ctx, texts)
+	if err != nil {
+		return "", err
+	}
+	if len(vectors) != len(texts) {
+		return "", fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	scores := make([]scored, len(passages))
+	for i := range passages {
+		score, err := cosineSimilarity(vectors[0], vectors[i+1])
+		if err != nil {
+			return "", err
+		}
+		scores[i] = scored{index: i, score: score}
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+	selected := make([]int, 0, r.topK)
+	for _, s := range scores[:r.topK] {
+		selected = append(selected, s.index)
+	}
+	sort.Ints(selected)
+
+	log.Info().
+		Int("chunks", len(passages)).
+		Int("selected", len(selected)).
+		Msg("Selected the source chunks most relevant to the template")
+	return join(passages, selected), nil
+}
+
+// split divides content into passages of paragraph chunks that do not cross file
+// boundaries.
+func (r *Retriever) split(content string) []passage {
+	var passages []passage
+	var source, file string
+	var body strings.Builder
+	flush := func() {
+		for _, text := range r.chunker.ChunkByParagraphs(body.String()) {
+			passages = append(passages, passage{source: source, file: file, text: text})
+		}
+		body.Reset()
+	}
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, sourceHeaderPrefix):
+			flush()
+			source, file = line, ""
+		case strings.HasPrefix(line, fileHeaderPrefix):
+			flush()
+			file = line
+		default:
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+	}
+	flush()
+	return passages
+}
+
+// join writes the selected passages with their headers, marking the passages left
+// out between selected ones of the same file.
+func join(passages []passage, selected []int) string {
+	var b strings.Builder
+	source, file, last := "", "", -1
+	for _, i := range selected {
+		p := passages[i]
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		if p.source != source && p.source != "" {
+			b.WriteString(p.source + "\n")
+			file = ""
+		}
+		switch {
+		case p.file != file && p.file != "":
+			b.WriteString(p.file + "\n")
+		case last >= 0 && i > last+1:
+			b.WriteString("[...]\n\n")
+		}
+		b.WriteString(p.text)
+		source, file, last = p.source, p.file, i
+	}
+	return b.String()
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors.
+func cosineSimilarity(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("embeddings have different dimensions (%d and %d)", len(a), len(b))
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
@@ -0,0 +1,96 @@
+package chunk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/tokens"
+)
+
+// keywordEmbedder embeds texts by counting topic keywords, so similarity follows topics.
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, len(e.keywords)+1)
+		for k, keyword := range e.keywords {
+			vector[k] = float32(strings.Count(strings.ToLower(text), keyword))
+		}
+		vector[len(e.keywords)] = 0.1
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// TestRetriever_Select tests that the chunks most relevant to the query are kept in
+// source order under their file headers.
+func TestRetriever_Select(t *testing.T) {
+	content := strings.Join([]string{
+		"=== Source: api (./api) ===",
+		"--- File: api/security.md ---",
+		"Authentication uses OAuth tokens issued by the identity service.",
+		"",
+		"The office kitchen is cleaned on Fridays.",
+		"",
+		"Tokens expire after one hour and authentication is refreshed silently.",
+		"",
+		"--- File: api/history.md ---",
+		"The company was founded in a garage.",
+		"",
+		"=== Source: web (./web) ===",
+		"--- File: web/login.md ---",
+		"The login page starts the authentication flow.",
+	}, "\n")
+
+	embedder := &keywordEmbedder{keywords: []string{"authentication", "kitchen", "garage"}}
+	selected, err := NewRetriever(embedder, 5, 3, tokens.ForModel("")).Select(context.Background(), content, "Describe authentication")
+	require.NoError(t, err)
+
+	assert.Equal(t, strings.Join([]string{
+		"=== Source: api (./api) ===",
+		"--- File: api/security.md ---",
+		"Authentication uses OAuth tokens issued by the identity service.",
+		"",
+		"[...]",
+		"",
+		"Tokens expire after one hour and authentication is refreshed silently.",
+		"",
+		"=== Source: web (./web) ===",
+		"--- File: web/login.md ---",
+		"The login page starts the authentication flow.",
+	}, "\n"), selected)
+	assert.Equal(t, 1, embedder.calls)
+}
+
+// TestRetriever_Select_FewChunks tests that content with no more chunks than are kept
+// is returned unchanged without embedding requests.
+func TestRetriever_Select_FewChunks(t *testing.T) {
+	content := "--- File: notes.md ---\nFirst paragraph.\n\nSecond paragraph."
+	embedder := &keywordEmbedder{}
+	selected, err := NewRetriever(embedder, 0, 0, tokens.ForModel("")).Select(context.Background(), content, "query")
+	require.NoError(t, err)
+	assert.Equal(t, content, selected)
+	assert.Zero(t, embedder.calls)
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	score, err := cosineSimilarity([]float32{1, 0}, []float32{2, 0})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, score, 1e-9)
+
+	score, err = cosineSimilarity([]float32{1, 0}, []float32{0, 3})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.0, score, 1e-9)
+
+	_, err = cosineSimilarity([]float32{1, 0}, []float32{1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+// Package ci detects the continuous integration system a run executes in, so
+// generated documents can be traced to the pipeline run that produced them.
+package ci
+
+import (
+	"os"
+	"strings"
+)
+
+// Info describes the CI pipeline run a document was generated in.
+type Info struct {
+	Provider   string `json:"provider"`
+	PipelineID string `json:"pipeline_id,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+	URL        string `json:"url,omitempty"` // Link to the pipeline run, when known
+}
+
+// String summarizes the run, e.g. "github-actions run 42 at 1a2b3c4 on main".
+func (i *Info) String() string {
+	parts := []string{i.Provider}
+	if i.PipelineID != "" {
+		parts = append(parts, "run "+i.PipelineID)
+	}
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		parts = append(parts, "at "+commit)
+	}
+	if i.Branch != "" {
+		parts = append(parts, "on "+i.Branch)
+	}
+	return strings.Join(parts, " ")
+}
+
+// provider maps a CI system's environment variables to Info fields.
+type provider struct {
+	name     string
+	detect   func(getenv func(string) string) bool
+	pipeline []string // Candidate variables, the first one set wins
+	commit   []string
+	branch   []string
+	actor    []string
+	url      func(getenv func(string) string) string
+}
+
+// set reports whether an environment variable is set to a non-empty value.
+func set(name string) func(getenv func(string) string) bool {
+	return func(getenv func(string) string) bool { return getenv(name) != "" }
+}
+
+// variable returns a url function reading a single environment variable.
+func variable(name string) func(getenv func(string) string) string {
+	return func(getenv func(string) string) string { return getenv(name) }
+}
+
+// providers are checked in order; the generic CI variable comes last.
+var providers = []provider{
+	{
+		name:     "github-actions",
+		detect:   set("GITHUB_ACTIONS"),
+		pipeline: []string{"GITHUB_RUN_ID"},
+		commit:   []string{"GITHUB_SHA"},
+		branch:   []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME"},
+		actor:    []string{"GITHUB_ACTOR"},
+		url: func(getenv func(string) string) string {
+			server, repo, run := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID")
+			if server == "" || repo == "" || run == "" {
+				return ""
+			}
+			return server + "/" + repo + "/actions/runs/" + run
+		},
+	},
+	{
+		name:     "gitlab-ci",
+		detect:   set("GITLAB_CI"),
+		pipeline: []string{"CI_PIPELINE_ID"},
+		commit:   []string{"CI_COMMIT_SHA"},
+		branch:   []string{"CI_COMMIT_REF_NAME"},
+		actor:    []string{"GITLAB_USER_LOGIN"},
+		url:      variable("CI_PIPELINE_URL"),
+	},
+	{
+		name:     "azure-pipelines",
+		detect:   set("TF_BUILD"),
+		pipeline: []string{"BUILD_BUILDID"},
+		commit:   []string{"BUILD_SOURCEVERSION"},
+		branch:   []string{"BUILD_SOURCEBRANCHNAME"},
+		actor:    []string{"BUILD_REQUESTEDFOR"},
+		url: func(getenv func(string) string) string {
+			collection, project, build := getenv("SYSTEM_COLLECTIONURI"), getenv("SYSTEM_TEAMPROJECT"), getenv("BUILD_BUILDID")
+			if collection == "" || project == "" || build == "" {
+				return ""
+			}
+			return strings.TrimSuffix(collection, "/") + "/" + project + "/_build/results?buildId=" + build
+		},
+	},
+	{
+		name:     "circleci",
+		detect:   set("CIRCLECI"),
+		pipeline: []string{"CIRCLE_WORKFLOW_ID", "CIRCLE_BUILD_NUM"},
+		commit:   []string{"CIRCLE_SHA1"},
+		branch:   []string{"CIRCLE_BRANCH"},
+		actor:    []string{"CIRCLE_USERNAME"},
+		url:      variable("CIRCLE_BUILD_URL"),
+	},
+	{
+		name:     "bitbucket-pipelines",
+		detect:   set("BITBUCKET_BUILD_NUMBER"),
+		pipeline: []string{"BITBUCKET_BUILD_NUMBER"},
+		commit:   []string{"BITBUCKET_COMMIT"},
+		branch:   []string{"BITBUCKET_BRANCH"},
+		actor:    []string{"BITBUCKET_STEP_TRIGGERER_UUID"},
+	},
+	{
+		name:     "jenkins",
+		detect:   set("JENKINS_URL"),
+		pipeline: []string{"BUILD_TAG", "BUILD_NUMBER"},
+		commit:   []string{"GIT_COMMIT"},
+		branch:   []string{"BRANCH_NAME", "GIT_BRANCH"},
+		actor:    []string{"BUILD_USER_ID", "CHANGE_AUTHOR"},
+		url:      variable("BUILD_URL"),
+	},
+	{
+		name: "ci",
+		detect: func(getenv func(string) string) bool {
+			value := strings.ToLower(getenv("CI"))
+			return value != "" && value != "false" && value != "0"
+		},
+	},
+}
+
+// Detect returns the CI pipeline run described by the process environment, or nil
+// when the process does not run in CI.
+func Detect() *Info {
+	return DetectFrom(os.Getenv)
+}
+
+// DetectFrom returns the CI pipeline run described by the environment variables
+// that getenv returns, or nil when none identifies a CI system.
+func DetectFrom(getenv func(string) string) *Info {
+	for _, p := range providers {
+		if !p.detect(getenv) {
+			continue
+		}
+		info := &Info{
+			Provider:   p.name,
+			PipelineID: first(getenv, p.pipeline),
+			Commit:     first(getenv, p.commit),
+			Branch:     first(getenv, p.branch),
+			Actor:      first(getenv, p.actor),
+		}
+		if p.url != nil {
+			info.URL = p.url(getenv)
+		}
+		return info
+	}
+	return nil
+}
+
+// first returns the value of the first variable in names that is set.
+func first(getenv func(string) string, names []string) string {
+	for _, name := range names {
+		if value := getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestDetectFrom_GitHubActions(t *testing.T) {
+	info := DetectFrom(env(map[string]string{
+		"CI":                "true",
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_RUN_ID":     "42",
+		"GITHUB_SHA":        "1a2b3c4d5e6f",
+		"GITHUB_REF_NAME":   "main",
+		"GITHUB_ACTOR":      "octocat",
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_REPOSITORY": "acme/docs",
+	}))
+	require.NotNil(t, info)
+	assert.Equal(t, Info{
+		Provider:   "github-actions",
+		PipelineID: "42",
+		Commit:     "1a2b3c4d5e6f",
+		Branch:     "main",
+		Actor:      "octocat",
+		URL:        "https://github.com/acme/docs/actions/runs/42",
+	}, *info)
+	assert.Equal(t, "github-actions run 42 at 1a2b3c4 on main", info.String())
+}
+
+func TestDetectFrom_FallbackVariables(t *testing.T) {
+	info := DetectFrom(env(map[string]string{
+		"JENKINS_URL":  "https://jenkins.example.com/",
+		"BUILD_NUMBER": "7",
+		"GIT_BRANCH":   "origin/release",
+	}))
+	require.NotNil(t, info)
+	assert.Equal(t, "jenkins", info.Provider)
+	assert.Equal(t, "7", info.PipelineID)
+	assert.Equal(t, "origin/release", info.Branch)
+	assert.Empty(t, info.URL)
+}
+
+func TestDetectFrom_GenericAndNone(t *testing.T) {
+	info := DetectFrom(env(map[string]string{"CI": "1"}))
+	require.NotNil(t, info)
+	assert.Equal(t, Info{Provider: "ci"}, *info)
+
+	assert.Nil(t, DetectFrom(env(map[string]string{"CI": "false"})))
+	assert.Nil(t, DetectFrom(env(nil)))
+}
@@ -1,12 +1,24 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/karolswdev/docloom/internal/agent"
+	"github.com/karolswdev/docloom/internal/agent/scaffold"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	agentsJSON     bool
+	newAgentRunner string
+	newAgentDir    string
 )
 
 // agentsCmd represents the agents command
@@ -40,8 +52,11 @@ The list shows each agent's name and description.`,
 
 		// Get all agents
 		agents := registry.List()
+		if agentsJSON {
+			return writeAgentsJSON(cmd.OutOrStdout(), agents)
+		}
 		if len(agents) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "No agents found. Place agent definition files in .docloom/agents/ or ~/.docloom/agents/")
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("agents.none"))
 			return nil
 		}
 
@@ -62,6 +77,50 @@ The list shows each agent's name and description.`,
 	},
 }
 
+// agentInfo is the machine-readable description of an agent printed by
+// agents list --json, from which tools can build generation forms.
+type agentInfo struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Parameters  []agentParameterInfo `json:"parameters"`
+}
+
+// agentParameterInfo describes an agent parameter.
+type agentParameterInfo struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// writeAgentsJSON prints the agents and their parameters as a JSON array sorted by name.
+func writeAgentsJSON(out io.Writer, agents []*agent.Definition) error {
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Metadata.Name < agents[j].Metadata.Name })
+	infos := make([]agentInfo, 0, len(agents))
+	for _, def := range agents {
+		info := agentInfo{
+			Name:        def.Metadata.Name,
+			Description: def.Metadata.Description,
+			Parameters:  make([]agentParameterInfo, 0, len(def.Spec.Parameters)),
+		}
+		for _, param := range def.Spec.Parameters {
+			info.Parameters = append(info.Parameters, agentParameterInfo{
+				Name:        param.Name,
+				Type:        param.Type,
+				Description: param.Description,
+				Required:    param.Required,
+				Default:     param.Default,
+			})
+		}
+		infos = append(infos, info)
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(infos)
+}
+
 // agentsDescribeCmd represents the agents describe command
 var agentsDescribeCmd = &cobra.Command{
 	Use:   "describe <agent-name>",
@@ -125,8 +184,96 @@ name, description, runner command, and parameters.`,
 	},
 }
 
+// printAgentPlan prints what an agent run would execute and the artifacts it must
+// produce, in the format of agents describe.
+func printAgentPlan(out io.Writer, plan *agent.RunPlan) {
+	fmt.Fprintf(out, "Agent: %s (dry run, nothing was executed)\n", plan.Agent)
+
+	fmt.Fprintf(out, "\nCommand: %s\n", plan.Runner.Command)
+	switch {
+	case plan.Resolved == "":
+		fmt.Fprintf(out, "  Resolved: not found on PATH\n")
+	case plan.Resolved != plan.Runner.Command:
+		fmt.Fprintf(out, "  Resolved: %s\n", plan.Resolved)
+	}
+	if plan.Runner.Version != "" {
+		fmt.Fprintf(out, "  Version: %s\n", plan.Runner.Version)
+	}
+	if plan.Runner.SHA256 != "" {
+		fmt.Fprintf(out, "  SHA-256: %s (pinned: %v)\n", plan.Runner.SHA256, plan.Runner.Pinned)
+	}
+	fmt.Fprintf(out, "  Args:\n")
+	for _, arg := range plan.Args {
+		fmt.Fprintf(out, "    - %s\n", arg)
+	}
+
+	fmt.Fprintf(out, "\nEnvironment (added to the inherited environment):\n")
+	if len(plan.Env) == 0 {
+		fmt.Fprintf(out, "  (no parameters)\n")
+	}
+	for _, variable := range plan.Env {
+		fmt.Fprintf(out, "  %s\n", variable)
+	}
+
+	fmt.Fprintf(out, "\nPaths:\n")
+	fmt.Fprintf(out, "  Source (read): %s\n", plan.SourcePath)
+	fmt.Fprintf(out, "  Output (written): %s\n", plan.OutputPath)
+
+	fmt.Fprintf(out, "\nExpected artifacts:\n")
+	fmt.Fprintf(out, "  - At least one Markdown file (*.md) in the output directory\n")
+	for _, postcondition := range plan.Postconditions {
+		line := postcondition.File
+		if postcondition.Expr != "" {
+			line += " where " + postcondition.Expr
+		}
+		if postcondition.Message != "" {
+			line += " (" + postcondition.Message + ")"
+		}
+		fmt.Fprintf(out, "  - %s\n", line)
+	}
+	if plan.Retry != nil {
+		keys := make([]string, 0, len(plan.Retry.Parameters))
+		for key := range plan.Retry.Parameters {
+			keys = append(keys, key+"="+plan.Retry.Parameters[key])
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(out, "  On failure, re-run once with: %s\n", strings.Join(keys, ", "))
+	}
+}
+
+// agentsNewCmd represents the agents new command
+var agentsNewCmd = &cobra.Command{
+	Use:   "new <agent-name>",
+	Short: "Create a new Research Agent from a starter",
+	Long: `Create a Research Agent manifest and starter files in the project-local
+agent directory. The starter includes the contract helpers for the runner's
+language (argument parsing, PARAM_* parameters, artifact writing and tool
+responses) and a small working analysis to replace with your own.`,
+	Example: `  docloom agents new file-types --runner python
+  docloom generate --agent file-types --source ./src --type architecture-vision --out doc.html`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := scaffold.Create(scaffold.Options{Name: args[0], Runner: newAgentRunner, Dir: newAgentDir})
+		if err != nil {
+			return fmt.Errorf("failed to create agent: %w", err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, i18n.T("agents.created", args[0], newAgentRunner))
+		for _, path := range paths {
+			fmt.Fprintf(out, "  %s\n", path)
+		}
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(agentsCmd)
 	agentsCmd.AddCommand(agentsListCmd)
 	agentsCmd.AddCommand(agentsDescribeCmd)
+	agentsCmd.AddCommand(agentsNewCmd)
+
+	agentsListCmd.Flags().BoolVar(&agentsJSON, "json", false, "Print the agents with their parameters as JSON")
+	agentsNewCmd.Flags().StringVar(&newAgentRunner, "runner", "python", "Language of the starter files ("+strings.Join(scaffold.Runners(), ", ")+")")
+	agentsNewCmd.Flags().StringVar(&newAgentDir, "dir", ".docloom/agents", "Agent directory to create the agent in")
 }
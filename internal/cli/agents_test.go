@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -230,3 +231,126 @@ func TestAgentsListCmd_Empty(t *testing.T) {
 	assert.Contains(t, output, "No agents found")
 	assert.Contains(t, output, ".docloom/agents/")
 }
+
+// Test that agents list --json describes agents and their parameters
+func TestAgentsListCmd_JSON(t *testing.T) {
+	testDir := t.TempDir()
+	agentsDir := filepath.Join(testDir, ".docloom", "agents")
+	require.NoError(t, os.MkdirAll(agentsDir, 0755))
+	definition := `
+apiVersion: v1
+kind: ResearchAgent
+metadata:
+  name: json-agent
+  description: Agent listed as JSON
+spec:
+  runner:
+    command: echo
+  parameters:
+    - name: depth
+      type: integer
+      description: Analysis depth
+      default: 2
+`
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "json.agent.yaml"), []byte(definition), 0644))
+
+	originalWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(testDir))
+	defer os.Chdir(originalWd)
+	t.Cleanup(func() { agentsJSON = false })
+
+	rootCmd.SetArgs([]string{"agents", "list", "--json"})
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stdout)
+	require.NoError(t, rootCmd.Execute())
+
+	var agents []agentInfo
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &agents))
+	require.Len(t, agents, 1)
+	assert.Equal(t, "json-agent", agents[0].Name)
+	assert.Equal(t, "Agent listed as JSON", agents[0].Description)
+	require.Len(t, agents[0].Parameters, 1)
+	assert.Equal(t, "depth", agents[0].Parameters[0].Name)
+	assert.Equal(t, "integer", agents[0].Parameters[0].Type)
+	assert.EqualValues(t, 2, agents[0].Parameters[0].Default)
+}
+
+// TestAgentsNewCmd tests that a created agent is listed by the registry.
+func TestAgentsNewCmd(t *testing.T) {
+	testDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(testDir))
+	defer os.Chdir(originalWd)
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stdout)
+	rootCmd.SetArgs([]string{"agents", "new", "file-types", "--runner", "python", "--dir", ".docloom/agents"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Contains(t, stdout.String(), "Created file-types agent (python runner):")
+	assert.Contains(t, stdout.String(), filepath.Join(".docloom", "agents", "file-types", "docloom_agent.py"))
+
+	stdout.Reset()
+	rootCmd.SetArgs([]string{"agents", "list"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Contains(t, stdout.String(), "file-types")
+
+	rootCmd.SetArgs([]string{"agents", "new", "file-types", "--runner", "python", "--dir", ".docloom/agents"})
+	err := rootCmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+// TestGenerateCmd_AgentDryRun tests that --agent-dry-run prints the agent's command
+// and contract without running it.
+func TestGenerateCmd_AgentDryRun(t *testing.T) {
+	testDir := t.TempDir()
+	agentsDir := filepath.Join(testDir, ".docloom", "agents")
+	require.NoError(t, os.MkdirAll(agentsDir, 0755))
+	agentDef := `
+apiVersion: v1
+kind: ResearchAgent
+metadata:
+  name: dry-agent
+spec:
+  runner:
+    command: sh
+    args: ["-c", "touch ran", "${SOURCE_PATH}", "${OUTPUT_PATH}"]
+  parameters:
+    - name: depth
+      type: integer
+  postconditions:
+    - file: api-surface.json
+      expr: count(namespaces) > 0
+      message: no namespaces found
+`
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "dry.agent.yaml"), []byte(agentDef), 0644))
+	originalWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(testDir))
+	defer os.Chdir(originalWd)
+	defer func() { agentName, agentParams, agentDryRun, sources = "", nil, false, nil }()
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stdout)
+	rootCmd.SetArgs([]string{"generate", "--type", "architecture-vision", "--out", "doc.html", "--source", "./repo",
+		"--agent", "dry-agent", "--agent-param", "depth=3", "--agent-dry-run"})
+	require.NoError(t, rootCmd.Execute())
+
+	output := stdout.String()
+	assert.Contains(t, output, "Agent: dry-agent (dry run, nothing was executed)")
+	assert.Contains(t, output, "Command: sh")
+	assert.Contains(t, output, "    - ./repo\n")
+	assert.Contains(t, output, "  PARAM_DEPTH=3\n")
+	assert.Contains(t, output, "Source (read): ./repo")
+	assert.Contains(t, output, "  - api-surface.json where count(namespaces) > 0 (no namespaces found)")
+	_, err := os.Stat(filepath.Join(testDir, "ran"))
+	assert.True(t, os.IsNotExist(err), "the agent must not run")
+
+	rootCmd.SetArgs([]string{"generate", "--type", "architecture-vision", "--out", "doc.html",
+		"--agent", "dry-agent", "--agent-param", "depth=deep", "--agent-dry-run"})
+	err = rootCmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `parameter depth: "deep" is not a valid integer`)
+}
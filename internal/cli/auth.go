@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/secrets"
+)
+
+var authReveal bool
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage API keys stored in the OS keychain",
+	Long: `Store, show and delete API keys in the operating system's keychain: the macOS
+Keychain, the Windows Credential Manager or the Secret Service (GNOME Keyring,
+KWallet; requires secret-tool).
+
+Keys are stored under a name, "default" when omitted. When no API key is given by
+flag, environment or api_key in the configuration, the key named by api_key_keychain
+is used, or the default key when neither api_key_keychain nor api_key_cmd is set.
+Profiles can name their own key:
+
+  profiles:
+    quality:
+      base_url: https://llm-gateway.example.com/v1
+      api_key_keychain: gateway`,
+}
+
+// authSetCmd represents the auth set command
+var authSetCmd = &cobra.Command{
+	Use:   "set [name]",
+	Short: "Store an API key in the keychain",
+	Long: `Store an API key in the keychain, read from standard input: typed at the prompt
+without echo, or piped from another command.
+
+Example:
+  docloom auth set
+  op read op://Engineering/gateway/key | docloom auth set gateway`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account := authAccount(args)
+		keychain, err := secrets.NewKeychain()
+		if err != nil {
+			return err
+		}
+		key, err := readSecret(cmd, i18n.T("auth.prompt", account))
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return fmt.Errorf("no API key given")
+		}
+		if err := keychain.Set(context.Background(), account, key); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("auth.stored", account, keychain.Name()))
+		return nil
+	},
+}
+
+// authGetCmd represents the auth get command
+var authGetCmd = &cobra.Command{
+	Use:   "get [name]",
+	Short: "Show an API key stored in the keychain",
+	Long: `Show an API key stored in the keychain, masked unless --reveal is given.
+
+Example:
+  docloom auth get gateway
+  export OPENAI_API_KEY=$(docloom auth get --reveal)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account := authAccount(args)
+		keychain, err := secrets.NewKeychain()
+		if err != nil {
+			return err
+		}
+		key, err := keychain.Get(context.Background(), account)
+		if errors.Is(err, secrets.ErrNotFound) {
+			return fmt.Errorf("no API key named %s in the %s (store one with 'docloom auth set %s')", account, keychain.Name(), account)
+		}
+		if err != nil {
+			return err
+		}
+		if !authReveal {
+			key = secrets.Mask(key)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), key)
+		return nil
+	},
+}
+
+// authDeleteCmd represents the auth delete command
+var authDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete an API key from the keychain",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account := authAccount(args)
+		keychain, err := secrets.NewKeychain()
+		if err != nil {
+			return err
+		}
+		err = keychain.Delete(context.Background(), account)
+		if errors.Is(err, secrets.ErrNotFound) {
+			return fmt.Errorf("no API key named %s in the %s", account, keychain.Name())
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("auth.deleted", account, keychain.Name()))
+		return nil
+	},
+}
+
+// authAccount returns the key name given as argument, or the default name.
+func authAccount(args []string) string {
+	if len(args) > 0 && args[0] != "" {
+		return args[0]
+	}
+	return secrets.DefaultAccount
+}
+
+// readSecret reads a line from the command's input. At a terminal, the prompt is
+// shown and, where stty is available, the input is not echoed.
+func readSecret(cmd *cobra.Command, prompt string) (string, error) {
+	in := cmd.InOrStdin()
+	if file, ok := in.(*os.File); ok && isTerminal(file) {
+		fmt.Fprint(cmd.ErrOrStderr(), prompt)
+		if runtime.GOOS != "windows" && setEcho(file, false) == nil {
+			defer func() {
+				_ = setEcho(file, true)
+				fmt.Fprintln(cmd.ErrOrStderr())
+			}()
+		}
+	}
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read API key: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// setEcho turns the echo of the terminal on or off with stty.
+func setEcho(terminal *os.File, on bool) error {
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	stty := exec.Command("stty", mode)
+	stty.Stdin = terminal
+	return stty.Run()
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authSetCmd)
+	authCmd.AddCommand(authGetCmd)
+	authCmd.AddCommand(authDeleteCmd)
+
+	authGetCmd.Flags().BoolVar(&authReveal, "reveal", false, "Print the key unmasked")
+}
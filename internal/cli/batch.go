@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/batch"
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	batchConfigFile        string
+	batchParallel          int
+	batchRequestsPerMinute int
+	batchJSON              bool
+)
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:   "batch <jobs.yaml|jobs.json>",
+	Short: "Generate many documents concurrently",
+	Long: `Generate the documents listed in a jobs file concurrently, using the model settings
+from the configuration. Jobs share the provider's connections and rate limit; a job
+that fails does not stop the others. Outputs are always overwritten.
+
+  jobs:
+    - name: payments
+      type: architecture-vision
+      sources: [./services/payments]
+      out: docs/{{project}}-architecture.html
+      vars: {project: payments}
+
+The jobs file may also be JSON with the same fields. Templates are loaded once
+and shared by all jobs.
+
+--parallel defaults to the provider: 1 for local servers, 4 for the large hosted
+providers and 2 otherwise.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(batchConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyOutputModes(cfg)
+		applyTrustPolicy(cfg)
+		jobs, err := batch.LoadJobs(args[0])
+		if err != nil {
+			return err
+		}
+
+		parallel := batchParallel
+		if parallel <= 0 {
+			parallel = batch.DefaultParallel(cfg.BaseURL)
+		}
+		parallel = min(parallel, len(jobs))
+		requestsPerMinute := cfg.RequestsPerMinute
+		if batchRequestsPerMinute > 0 {
+			requestsPerMinute = batchRequestsPerMinute
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		aiClient, err := newConfiguredAIClient(ctx, cfg, ai.NewPool(parallel, requestsPerMinute))
+		if err != nil {
+			return err
+		}
+		// Templates are loaded once for all jobs
+		orchestrator, err := newConfiguredOrchestrator(cfg, aiClient)
+		if err != nil {
+			return err
+		}
+
+		if !batchJSON {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("batch.started", len(jobs), parallel))
+		}
+		outcomes := batch.Run(ctx, jobs, parallel, runBatchJob(cfg, orchestrator))
+		return printBatchOutcomes(cmd, outcomes)
+	},
+}
+
+// runBatchJob returns a RunFunc that generates a job's document with the shared
+// orchestrator, notifying and recording it like a scheduled run.
+func runBatchJob(cfg *config.Config, orchestrator *generate.Orchestrator) batch.RunFunc {
+	// The document registry is rewritten on every update
+	var registryMu sync.Mutex
+	return func(ctx context.Context, job batch.Job) (string, error) {
+		startTime := time.Now()
+		meter := newConfiguredMeter(cfg, 0)
+		result, err := generateWithOrchestrator(ai.WithMeter(ctx, meter), cfg, orchestrator, job.Type, job.Sources, "", job.Out, job.Vars)
+		notifyCompletion(ctx, cfg.Notifications, job.Type, job.Out, result, meter.Summary(), err, time.Since(startTime))
+		if err != nil {
+			return "", err
+		}
+		registryMu.Lock()
+		recordDocument(cfg.DocsRegistry, result, job.Sources, cfg.Model, cfg.Owner)
+		registryMu.Unlock()
+		return result.OutputFile, nil
+	}
+}
+
+// batchJobResult is the JSON form of a job outcome.
+type batchJobResult struct {
+	Name     string  `json:"name"`
+	Template string  `json:"template"`
+	Status   string  `json:"status"` // success or failure
+	Output   string  `json:"output,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Seconds  float64 `json:"duration_seconds"`
+}
+
+// printBatchOutcomes prints a table, or with --json a JSON array, of the job outcomes
+// and fails when any job failed.
+func printBatchOutcomes(cmd *cobra.Command, outcomes []batch.Outcome) error {
+	if batchJSON {
+		return printBatchOutcomesJSON(cmd, outcomes)
+	}
+	failed := 0
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tDURATION\tOUTPUT")
+	for _, outcome := range outcomes {
+		status := "success"
+		if outcome.Err != nil {
+			failed++
+			status = "failure (" + outcome.Err.Error() + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", outcome.Job.Name, status, outcome.Duration.Round(time.Second), outcome.Output)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch jobs failed", failed, len(outcomes))
+	}
+	return nil
+}
+
+// printBatchOutcomesJSON prints the job outcomes as a JSON array and fails when any
+// job failed.
+func printBatchOutcomesJSON(cmd *cobra.Command, outcomes []batch.Outcome) error {
+	results := make([]batchJobResult, len(outcomes))
+	failed := 0
+	for idx, outcome := range outcomes {
+		results[idx] = batchJobResult{
+			Name:     outcome.Job.Name,
+			Template: outcome.Job.Type,
+			Status:   "success",
+			Output:   outcome.Output,
+			Seconds:  outcome.Duration.Seconds(),
+		}
+		if outcome.Err != nil {
+			failed++
+			results[idx].Status = "failure"
+			results[idx].Error = outcome.Err.Error()
+		}
+	}
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch jobs failed", failed, len(outcomes))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+
+	batchCmd.Flags().StringVar(&batchConfigFile, "config", "", "Config file path")
+	batchCmd.Flags().IntVar(&batchParallel, "parallel", 0, "Number of jobs to run at once (defaults to a limit suited to the provider)")
+	batchCmd.Flags().BoolVar(&batchJSON, "json", false, "Print the job outcomes as JSON")
+	batchCmd.Flags().IntVar(&batchRequestsPerMinute, "requests-per-minute", 0, "Limit on model requests per minute across all jobs (defaults to config requests_per_minute; 0 for none)")
+}
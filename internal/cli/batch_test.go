@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/batch"
+)
+
+func TestBatchCmd_IsolatesFailingJobs(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"title": "Payments"}`}}},
+		})
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	templateDir := filepath.Join(tempDir, "templates", "brief")
+	require.NoError(t, os.MkdirAll(templateDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "template.json"), []byte(`{"name": "brief", "prompt": "Write a title"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}}, "required": ["title"]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "template.html"), []byte(`<h1><!-- data-field="title" --></h1>`), 0644))
+	sourceDir := filepath.Join(tempDir, "docs")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "notes.md"), []byte("Payments service"), 0644))
+
+	configPath := filepath.Join(tempDir, "docloom.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("base_url: "+server.URL+"/v1\napi_key: test-key\nmodel: test-model\ntemplate_dir: "+filepath.Join(tempDir, "templates")+"\ndocs_registry: \"\"\n"), 0644))
+	jobsPath := filepath.Join(tempDir, "jobs.yaml")
+	require.NoError(t, os.WriteFile(jobsPath, []byte(`jobs:
+  - {name: payments, type: brief, sources: [`+sourceDir+`], out: `+filepath.Join(tempDir, "out", "payments.html")+`}
+  - {name: missing, type: no-such-template, sources: [`+sourceDir+`], out: `+filepath.Join(tempDir, "out", "missing.html")+`}
+  - {name: ledger, type: brief, sources: [`+sourceDir+`], out: `+filepath.Join(tempDir, "out", "ledger.html")+`}
+`), 0644))
+
+	cmd := GetRootCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"batch", jobsPath, "--config", configPath, "--parallel", "2"})
+	t.Cleanup(func() { batchParallel = 0 })
+	err := cmd.Execute()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 3 batch jobs failed")
+	assert.Contains(t, buf.String(), "Running 3 job(s), 2 at a time")
+	assert.Contains(t, buf.String(), "no-such-template")
+	assert.FileExists(t, filepath.Join(tempDir, "out", "payments.html"))
+	assert.FileExists(t, filepath.Join(tempDir, "out", "ledger.html"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestPrintBatchOutcomesJSON(t *testing.T) {
+	outcomes := []batch.Outcome{
+		{Job: batch.Job{Name: "payments", Type: "brief"}, Output: "out/payments.html", Duration: 2 * time.Second},
+		{Job: batch.Job{Name: "ledger", Type: "brief"}, Err: errors.New("template 'brief' not found")},
+	}
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+
+	err := printBatchOutcomesJSON(cmd, outcomes)
+	assert.EqualError(t, err, "1 of 2 batch jobs failed")
+	var results []batchJobResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &results))
+	assert.Equal(t, []batchJobResult{
+		{Name: "payments", Template: "brief", Status: "success", Output: "out/payments.html", Seconds: 2},
+		{Name: "ledger", Template: "brief", Status: "failure", Error: "template 'brief' not found"},
+	}, results)
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/agent"
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	cacheConfigFile string
+	cacheMaxAge     time.Duration
+	cacheMaxSizeMB  int
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage local caches",
+	Long:  `Commands for managing the ingest cache of extracted source text and the agent artifact cache.`,
+}
+
+// cacheGCCmd represents the cache gc command
+var cacheGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove expired and excess cache entries",
+	Long: `Apply the retention policy to the local caches: entries not used within --max-age
+are removed, then the least recently used entries until each cache fits its size
+limit. The ingest cache limit defaults to ingest_cache_max_mb from the configuration;
+--max-size-mb overrides it and also limits the agent artifact cache.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cacheConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		if cacheMaxSizeMB > 0 {
+			cfg.IngestCacheMaxMB = cacheMaxSizeMB
+		}
+		removed, reclaimed, err := newIngestCache(cfg).Prune(cacheMaxAge)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("cache.gc_result", "ingest", removed, formatBytes(reclaimed)))
+
+		artifacts, err := agent.NewArtifactCache()
+		if err != nil {
+			return err
+		}
+		removed, reclaimed, err = artifacts.Prune(cacheMaxAge, int64(cacheMaxSizeMB)<<20)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("cache.gc_result", "agent artifacts", removed, formatBytes(reclaimed)))
+		return nil
+	},
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheGCCmd)
+
+	cacheGCCmd.Flags().StringVar(&cacheConfigFile, "config", "", "Config file path")
+	cacheGCCmd.Flags().DurationVar(&cacheMaxAge, "max-age", 30*24*time.Hour, "Remove entries not used for this long (0 disables the age limit)")
+	cacheGCCmd.Flags().IntVar(&cacheMaxSizeMB, "max-size-mb", 0, "Size limit per cache in MiB (default: ingest_cache_max_mb for the ingest cache, none for agent artifacts)")
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	configShowFile    string
+	configShowProfile string
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	Long: `Commands for inspecting the configuration.
+
+Configuration is read from the user file (~/.config/docloom/config.yaml, or under
+$XDG_CONFIG_HOME), then the project file (.docloom.yaml in the working directory, or
+the file given with --config), then DOCLOOM_* environment variables and finally
+command-line flags, each overriding the values set before. Secret values in files,
+such as api_key, may reference environment variables as ${VAR}.`,
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration with secrets masked",
+	Long: `Print the effective configuration, after merging the configuration files and
+environment variables, as YAML. API keys, header and query values and webhook URLs
+are masked.
+
+Example:
+  docloom config show
+  docloom config show --config ci.yaml
+  docloom config show --profile quality`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configShowFile, map[string]interface{}{"profile": configShowProfile})
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		data, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if len(cfg.Files) == 0 {
+			fmt.Fprintf(out, "# %s\n", i18n.T("config.no_files"))
+		} else {
+			fmt.Fprintf(out, "# %s\n", i18n.T("config.files"))
+			for _, file := range cfg.Files {
+				fmt.Fprintf(out, "#   %s\n", file)
+			}
+		}
+		_, err = out.Write(data)
+		return err
+	},
+}
+
+// configSchemaCmd represents the config schema command
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of configuration files",
+	Long: `Print the JSON Schema documenting every configuration key, for validating
+configuration files and for completion in editors. With the YAML language server,
+reference a saved copy from the first line of a configuration file:
+
+  # yaml-language-server: $schema=./docloom.schema.json
+
+Example:
+  docloom config schema > docloom.schema.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(config.Schema())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSchemaCmd)
+
+	configShowCmd.Flags().StringVar(&configShowFile, "config", "", "Config file path (default: .docloom.yaml)")
+	configShowCmd.Flags().StringVar(&configShowProfile, "profile", "", "Model profile to apply")
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/docregistry"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	listDocsConfigFile string
+	listDocsRegistry   string
+	listDocsTemplate   string
+	listDocsOwner      string
+	listDocsJSON       bool
+)
+
+// listDocsCmd represents the list-docs command
+var listDocsCmd = &cobra.Command{
+	Use:   "list-docs",
+	Short: "List documents recorded in the document registry",
+	Long: `List the generated documents recorded in the document registry (docs-registry.json
+by default). Every successful generation adds or updates an entry with the document id,
+template, version, sources hash, output location and owner.
+
+Examples:
+  docloom list-docs
+  docloom list-docs --template technical-debt-summary --owner platform-team
+  docloom list-docs --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := docsRegistryPath(listDocsConfigFile, listDocsRegistry)
+		if err != nil {
+			return err
+		}
+
+		entries, err := docregistry.New(path).List()
+		if err != nil {
+			return err
+		}
+
+		filtered := make([]docregistry.Entry, 0, len(entries))
+		for _, entry := range entries {
+			if listDocsTemplate != "" && entry.Template != listDocsTemplate {
+				continue
+			}
+			if listDocsOwner != "" && entry.Owner != listDocsOwner {
+				continue
+			}
+			filtered = append(filtered, entry)
+		}
+
+		if listDocsJSON {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(filtered)
+		}
+
+		if len(filtered) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("docs.none"))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTEMPLATE\tVERSION\tOWNER\tGENERATED\tSOURCES HASH\tOUTPUT")
+		for _, entry := range filtered {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+				entry.ID, entry.Template, entry.Version, entry.Owner,
+				entry.GeneratedAt.Format(time.RFC3339), shortHash(entry.SourcesHash), entry.Output)
+		}
+		return w.Flush()
+	},
+}
+
+// docsRegistryPath resolves the registry path from an explicit flag or the configuration.
+func docsRegistryPath(configPath, override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	cfg, err := config.Load(configPath, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return cfg.DocsRegistry, nil
+}
+
+// recordDocument adds a successful generation to the document registry.
+// Registry failures are logged and never fail the run.
+func recordDocument(registryPath string, result *generate.Result, sources []string, model, owner string) {
+	if registryPath == "" || result == nil {
+		return
+	}
+
+	manifest, err := docregistry.BuildManifest(sources)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to hash sources for document registry")
+		return
+	}
+
+	entry, err := docregistry.New(registryPath).Record(docregistry.Entry{
+		Title:       result.Title(),
+		Template:    result.Template,
+		Output:      result.OutputFile,
+		JSONFile:    result.JSONFile,
+		Owner:       owner,
+		Model:       model,
+		Sources:     sources,
+		SourcesHash: docregistry.ManifestHash(manifest),
+		Manifest:    manifest,
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to update document registry")
+		return
+	}
+	log.Debug().Str("id", entry.ID).Int("version", entry.Version).Str("registry", registryPath).Msg("Recorded document in registry")
+}
+
+// shortHash abbreviates a hash for table output.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func init() {
+	rootCmd.AddCommand(listDocsCmd)
+
+	listDocsCmd.Flags().StringVar(&listDocsConfigFile, "config", "", "Config file path")
+	listDocsCmd.Flags().StringVar(&listDocsRegistry, "registry", "", "Document registry file (defaults to config docs_registry)")
+	listDocsCmd.Flags().StringVar(&listDocsTemplate, "template", "", "Only list documents generated from this template")
+	listDocsCmd.Flags().StringVar(&listDocsOwner, "owner", "", "Only list documents with this owner")
+	listDocsCmd.Flags().BoolVar(&listDocsJSON, "json", false, "Output entries as JSON")
+}
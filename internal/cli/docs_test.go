@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/docregistry"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+func TestListDocsCmd_FiltersRegistry(t *testing.T) {
+	tempDir := t.TempDir()
+	registryPath := filepath.Join(tempDir, "docs-registry.json")
+	sourceDir := t.TempDir()
+
+	recordDocument(registryPath, &generate.Result{Template: "architecture-vision", OutputFile: "out/arch.html"}, []string{sourceDir}, "gpt-4", "platform")
+	recordDocument(registryPath, &generate.Result{Template: "technical-debt-summary", OutputFile: "out/debt.html"}, []string{sourceDir}, "gpt-4", "payments")
+	recordDocument(registryPath, &generate.Result{Template: "architecture-vision", OutputFile: "out/arch.html"}, []string{sourceDir}, "gpt-4", "platform")
+
+	entries, err := docregistry.New(registryPath).List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 2, entries[0].Version)
+
+	cmd := GetRootCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"list-docs", "--registry", registryPath, "--owner", "platform"})
+	t.Cleanup(func() { listDocsOwner = "" })
+
+	require.NoError(t, cmd.Execute())
+	output := buf.String()
+	assert.Contains(t, output, "out/arch")
+	assert.Contains(t, output, "architecture-vision")
+	assert.NotContains(t, output, "out/debt")
+}
+
+func TestListDocsCmd_Localized(t *testing.T) {
+	cmd := GetRootCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"list-docs", "--registry", filepath.Join(t.TempDir(), "docs-registry.json"), "--lang", "de"})
+	t.Cleanup(func() {
+		language = ""
+		_ = i18n.SetLanguage(i18n.DefaultLanguage)
+	})
+
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "Keine Dokumente erfasst.")
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+var (
+	experimentConfigFile string
+	experimentType       string
+	experimentSources    []string
+	experimentVariants   []string
+	experimentSamples    int
+	experimentModel      string
+	experimentReport     string
+)
+
+// experimentCmd represents the experiment command
+var experimentCmd = &cobra.Command{
+	Use:   "experiment",
+	Short: "Compare prompt variants for a template",
+	Long: `Run generations for each prompt variant against the same sources and model settings,
+then compare first-attempt validation pass rates, estimated token usage and lint scores.
+Each variant file replaces the template prompt. Nothing is rendered or written except
+the optional report.
+
+Example:
+  docloom experiment --type architecture-vision --source ./docs \
+    --variants prompts/v1.txt,prompts/v2.txt --samples 3 --report experiment.md`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(experimentConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyOutputModes(cfg)
+		applyTrustPolicy(cfg)
+		if experimentModel != "" {
+			cfg.Model = experimentModel
+		}
+
+		variants := make([]generate.PromptVariant, 0, len(experimentVariants))
+		for _, path := range experimentVariants {
+			data, err := os.ReadFile(path) // #nosec G304 -- variant files are user-provided
+			if err != nil {
+				return fmt.Errorf("failed to read prompt variant: %w", err)
+			}
+			variants = append(variants, generate.PromptVariant{
+				Name:   strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+				Prompt: string(data),
+			})
+		}
+
+		aiClient, err := newConfiguredAIClient(context.Background(), cfg, nil)
+		if err != nil {
+			return err
+		}
+
+		orchestrator := generate.NewOrchestrator(aiClient)
+		if err := loadPromptOverrides(orchestrator, cfg); err != nil {
+			return err
+		}
+		report, err := orchestrator.Experiment(context.Background(), generate.ExperimentOptions{
+			TemplateType: experimentType,
+			Sources:      experimentSources,
+			Variants:     variants,
+			Samples:      experimentSamples,
+		})
+		if err != nil {
+			return err
+		}
+
+		printExperimentReport(cmd.OutOrStdout(), report)
+
+		if experimentReport != "" {
+			if err := writeExperimentReport(experimentReport, report); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "\n"+i18n.T("experiment.report_written", experimentReport))
+		}
+		return nil
+	},
+}
+
+// printExperimentReport prints the variant comparison as a table.
+func printExperimentReport(out io.Writer, report *generate.ExperimentReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIANT\tPASS RATE\tVALID\tPROMPT TOKENS\tRESPONSE TOKENS\tLINT SCORE\tAVG SECONDS")
+	for _, v := range report.Variants {
+		fmt.Fprintf(w, "%s\t%.0f%%\t%d/%d\t%d\t%d\t%.2f\t%.1f\n",
+			v.Name, v.PassRate*100, v.Valid, v.Samples, v.PromptTokens, v.ResponseTokens, v.AverageLint, v.AverageSeconds)
+	}
+	_ = w.Flush()
+}
+
+// writeExperimentReport writes the report as Markdown (.md) or JSON (any other extension).
+func writeExperimentReport(path string, report *generate.ExperimentReport) error {
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".md") {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# Prompt Experiment: %s\n\n", report.Template)
+		fmt.Fprintf(&b, "Run %s against %s.\n\n", report.Started.Format("2006-01-02 15:04 MST"), strings.Join(report.Sources, ", "))
+		fmt.Fprintln(&b, "| Variant | Pass rate | Valid | Prompt tokens | Response tokens | Lint score | Avg seconds |")
+		fmt.Fprintln(&b, "|---|---|---|---|---|---|---|")
+		for _, v := range report.Variants {
+			fmt.Fprintf(&b, "| %s | %.0f%% | %d/%d | %d | %d | %.2f | %.1f |\n",
+				v.Name, v.PassRate*100, v.Valid, v.Samples, v.PromptTokens, v.ResponseTokens, v.AverageLint, v.AverageSeconds)
+		}
+		for _, v := range report.Variants {
+			if len(v.Errors) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n## Failures: %s\n\n", v.Name)
+			for _, e := range v.Errors {
+				fmt.Fprintf(&b, "- %s\n", e)
+			}
+		}
+		data = []byte(b.String())
+	} else {
+		var err error
+		data, err = json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal experiment report: %w", err)
+		}
+	}
+
+	if err := render.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write experiment report: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(experimentCmd)
+
+	experimentCmd.Flags().StringVarP(&experimentType, "type", "t", "", "Template type to experiment with (required)")
+	experimentCmd.Flags().StringSliceVarP(&experimentSources, "source", "s", []string{}, "Source paths (files or directories)")
+	experimentCmd.Flags().StringSliceVar(&experimentVariants, "variants", []string{}, "Comma-separated prompt variant files (required)")
+	experimentCmd.Flags().IntVar(&experimentSamples, "samples", 3, "Generations per variant")
+	experimentCmd.Flags().StringVar(&experimentModel, "model", "", "Model to use (defaults to config model)")
+	experimentCmd.Flags().StringVar(&experimentReport, "report", "", "Write the comparison report to a file (.md for Markdown, otherwise JSON)")
+	experimentCmd.Flags().StringVar(&experimentConfigFile, "config", "", "Config file path")
+
+	_ = experimentCmd.MarkFlagRequired("type")
+	_ = experimentCmd.MarkFlagRequired("variants")
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/jira"
+)
+
+var (
+	jiraFrom      string
+	jiraURL       string
+	jiraProject   string
+	jiraIssueType string
+	jiraItemsPath string
+	jiraLabels    []string
+	jiraRate      float64
+	jiraDryRun    bool
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export generated document data to external systems",
+}
+
+// exportJiraCmd represents the export jira command
+var exportJiraCmd = &cobra.Command{
+	Use:   "jira",
+	Short: "Create or update Jira issues from technical-debt items",
+	Long: `Turn the structured debt items of a technical-debt-summary JSON sidecar into Jira issues.
+
+Each item is labelled with a stable key derived from its title, so running the export
+again updates the existing issue instead of creating a duplicate.
+
+Credentials are read from the environment:
+  JIRA_URL        Jira base URL (or --url)
+  JIRA_USER       Account email for Jira Cloud basic auth (omit to use a bearer token)
+  JIRA_API_TOKEN  API token or personal access token
+
+Examples:
+  # Preview which issues would be created or updated
+  docloom export jira --from debt.json --project DEBT --dry-run
+
+  # Export, limited to two requests per second
+  docloom export jira --from debt.json --project DEBT --rate 2`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(jiraFrom)
+		if err != nil {
+			return fmt.Errorf("failed to read JSON sidecar: %w", err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("failed to parse JSON sidecar: %w", err)
+		}
+
+		items, err := jira.ExtractItems(fields, jiraItemsPath)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("export.no_items"))
+			return nil
+		}
+
+		baseURL := jiraURL
+		if baseURL == "" {
+			baseURL = os.Getenv("JIRA_URL")
+		}
+		if baseURL == "" {
+			return fmt.Errorf("jira URL is required (use --url or JIRA_URL)")
+		}
+
+		client := jira.NewClient(baseURL, os.Getenv("JIRA_USER"), os.Getenv("JIRA_API_TOKEN"), jiraRate)
+		exporter := jira.NewExporter(client, jiraProject, jiraIssueType, jiraLabels)
+
+		ctx := context.Background()
+		actions, err := exporter.Plan(ctx, items)
+		if err != nil {
+			return err
+		}
+
+		if !jiraDryRun {
+			if actions, err = exporter.Apply(ctx, actions); err != nil {
+				printJiraActions(cmd, actions)
+				return err
+			}
+		}
+
+		printJiraActions(cmd, actions)
+		if jiraDryRun {
+			fmt.Fprintln(cmd.OutOrStdout(), "\n"+i18n.T("export.dry_run"))
+		}
+		return nil
+	},
+}
+
+// printJiraActions prints the planned or applied actions as a table.
+func printJiraActions(cmd *cobra.Command, actions []jira.Action) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tISSUE\tPRIORITY\tTITLE")
+	for _, action := range actions {
+		issue := action.IssueKey
+		if issue == "" {
+			issue = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", action.Operation, issue, action.Item.Priority, action.Item.Title)
+	}
+	_ = w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportJiraCmd)
+
+	exportJiraCmd.Flags().StringVar(&jiraFrom, "from", "", "JSON sidecar of a generated technical-debt document (required)")
+	exportJiraCmd.Flags().StringVar(&jiraProject, "project", "", "Jira project key (required)")
+	exportJiraCmd.Flags().StringVar(&jiraURL, "url", "", "Jira base URL (defaults to JIRA_URL)")
+	exportJiraCmd.Flags().StringVar(&jiraIssueType, "issue-type", "Task", "Issue type for created issues")
+	exportJiraCmd.Flags().StringVar(&jiraItemsPath, "items-path", jira.DefaultItemsPath, "Dot-separated path to the debt items array")
+	exportJiraCmd.Flags().StringSliceVar(&jiraLabels, "label", []string{}, "Additional labels for exported issues")
+	exportJiraCmd.Flags().Float64Var(&jiraRate, "rate", 5, "Maximum Jira requests per second (0 for unlimited)")
+	exportJiraCmd.Flags().BoolVar(&jiraDryRun, "dry-run", false, "Preview creates and updates without modifying Jira")
+
+	_ = exportJiraCmd.MarkFlagRequired("from")
+	_ = exportJiraCmd.MarkFlagRequired("project")
+}
@@ -3,32 +3,88 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
 	"github.com/karolswdev/docloom/internal/agent"
 	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/chunk"
+	"github.com/karolswdev/docloom/internal/config"
 	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/ingest"
+	"github.com/karolswdev/docloom/internal/notify"
+	"github.com/karolswdev/docloom/internal/prompt"
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/tokens"
+	"github.com/karolswdev/docloom/internal/trust"
 )
 
 var (
-	templateType string
-	sources      []string
-	outputFile   string
-	model        string
-	baseURL      string
-	apiKey       string
-	temperature  float64
-	seed         int
-	maxRetries   int
-	dryRun       bool
-	force        bool
-	configFile   string
-	agentName    string
-	agentParams  []string
+	templateType   string
+	sources        []string
+	sourceRef      string
+	outputFile     string
+	model          string
+	baseURL        string
+	provider       string
+	apiKey         string
+	temperature    float64
+	seed           int
+	maxRetries     int
+	dryRun         bool
+	dryRunJSON     bool
+	force          bool
+	configFile     string
+	profileName    string
+	agentName      string
+	agentParams    []string
+	agentDryRun    bool
+	agentTools     bool
+	stripToolText  bool
+	owner          string
+	evaluate       bool
+	evalModel      string
+	evalMinScore   float64
+	grounded       string
+	ensembleWith   string
+	templateDir    string
+	archiveSources bool
+	provenance     bool
+	outputVars     []string
+	noIngestCache  bool
+	includeGlobs   []string
+	excludeGlobs   []string
+	sourceErrors   string
+	probeModel     bool
+	streamOutput   bool
+	perField       bool
+	triageDir      string
+	resume         bool
+	stateDir       string
+	budget         float64
+	usageLog       string
+	lessonsDir     string
+	noRetrieval    bool
+	interactive    bool
+	formats        []string
+	confidence     bool
+	confBadge      float64
+	minConfidence  float64
+	glossaryFile   string
+	recordFile     string
+	replayFile     string
+
+	// aiCassette records or replays the model requests of the generate run, if set
+	aiCassette *ai.Cassette
 )
 
 // generateCmd represents the generate command
@@ -42,130 +98,880 @@ Example:
   docloom generate --type architecture-vision --source ./docs --out output.html
   docloom generate --agent research-agent --source ./repo --type report --out analysis.html`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
-
-		// If agent is specified, run it first
-		actualSources := sources
-		if agentName != "" {
-			// Parse agent parameters
-			params := make(map[string]string)
-			for _, param := range agentParams {
-				parts := strings.SplitN(param, "=", 2)
-				if len(parts) != 2 {
-					return fmt.Errorf("invalid agent parameter format: %s (expected key=value)", param)
-				}
-				params[parts[0]] = parts[1]
-			}
+		if agentDryRun {
+			return runAgentDryRun(cmd.OutOrStdout())
+		}
+		cfg, err := config.Load(configFile, map[string]interface{}{"profile": profileName})
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyOutputModes(cfg)
+		applyTrustPolicy(cfg)
+		applyProfileFlags(cmd, cfg)
 
-			// Create agent registry and discover agents
-			registry := agent.NewRegistry()
-			if err := registry.Discover(); err != nil {
-				return fmt.Errorf("failed to discover agents: %w", err)
-			}
+		// Ctrl-C cancels the run, aborting a streamed response mid-generation
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		startTime := time.Now()
+		var triage *generate.Triage
+		if triageDir != "" {
+			triage = generate.NewTriage()
+			triage.AddSecrets(cfg.APIKey)
+			triage.SetConfig(cfg.Redacted())
+		}
+		meter, err := newUsageMeter(cfg)
+		if err != nil {
+			return err
+		}
+		result, runErr := runGenerate(ctx, cfg, triage, meter)
 
-			// Create artifact cache
-			cache, err := agent.NewArtifactCache()
-			if err != nil {
-				return fmt.Errorf("failed to create artifact cache: %w", err)
+		if !dryRun {
+			reportUsage(cfg, meter, result, runErr)
+			notifyCompletion(context.Background(), cfg.Notifications, templateType, outputFile, result, meter.Summary(), runErr, time.Since(startTime))
+		}
+		if runErr != nil {
+			writeTriageBundle(triage, runErr)
+			return runErr
+		}
+
+		if aiCassette.Replaying() && aiCassette.Unused() > 0 {
+			log.Warn().Int("unused", aiCassette.Unused()).Str("cassette", replayFile).Msg("Recorded responses were not requested; the prompts may have changed since recording")
+		}
+		if !dryRun {
+			documentOwner := owner
+			if documentOwner == "" {
+				documentOwner = cfg.Owner
 			}
+			recordDocument(cfg.DocsRegistry, result, sources, model, documentOwner)
+			fmt.Println(i18n.T("generate.success", result.OutputFile))
+			printReportSummary(result)
+		}
 
-			// Create executor
-			executor := agent.NewExecutor(registry, cache, logger)
+		return nil
+	},
+}
 
-			// Prepare source path (use first source or current directory)
-			sourcePath := "."
-			if len(sources) > 0 {
-				sourcePath = sources[0]
-			}
+// writeTriageBundle writes the triage bundle of a failed run, when requested, and
+// prints its path.
+func writeTriageBundle(triage *generate.Triage, runErr error) {
+	if triage == nil {
+		return
+	}
+	path, err := triage.Write(triageDir, runErr)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to write triage bundle")
+		return
+	}
+	fmt.Fprintln(os.Stderr, i18n.T("generate.triage_written", path))
+}
 
-			// Run the agent
-			fmt.Printf("Running agent '%s' on source: %s\n", agentName, sourcePath)
-			result, err := executor.Run(agent.RunOptions{
-				AgentName:  agentName,
-				SourcePath: sourcePath,
-				Parameters: params,
-			})
-			if err != nil {
-				return fmt.Errorf("agent execution failed: %w", err)
-			}
+// modelCatalog returns the capabilities of the models described in the configuration.
+func modelCatalog(cfg *config.Config) map[string]ai.Capabilities {
+	models := make(map[string]ai.Capabilities, len(cfg.Models))
+	for name, described := range cfg.Models {
+		models[name] = ai.Capabilities{Model: name, ContextWindow: described.ContextWindow, MaxOutputTokens: described.MaxOutputTokens, Tools: described.Tools, Vision: described.Vision, JSONMode: true, StructuredOutputs: described.StructuredOutputs}
+	}
+	return models
+}
 
-			// Validate agent output
-			if err := executor.ValidateOutput(result.OutputPath); err != nil {
-				return fmt.Errorf("agent output validation failed: %w", err)
-			}
+// applyProfileFlags takes the model settings of the profile in effect for the model
+// flags not given on the command line.
+func applyProfileFlags(cmd *cobra.Command, cfg *config.Config) {
+	if cfg.Profile == "" {
+		return
+	}
+	profile := cfg.Profiles[cfg.Profile]
+	flags := cmd.Flags()
+	for flag, value := range map[string]string{"model": profile.Model, "base-url": profile.BaseURL, "api-key": profile.APIKey} {
+		if value != "" && !flags.Changed(flag) {
+			_ = flags.Set(flag, value)
+		}
+	}
+	if profile.Temperature != nil && !flags.Changed("temperature") {
+		temperature = *profile.Temperature
+	}
+	log.Debug().Str("profile", cfg.Profile).Str("model", model).Msg("Using model profile")
+}
+
+// runProvider returns the provider of --provider or, failing that, the
+// configuration; empty detects it from the base URL.
+func runProvider(cfg *config.Config) string {
+	if provider != "" {
+		return provider
+	}
+	return cfg.Provider
+}
 
-			// Replace sources with agent output directory
-			actualSources = []string{result.OutputPath}
-			fmt.Printf("Agent completed. Using artifacts from: %s\n", result.OutputPath)
+// apiKeyFor returns key, or a placeholder when the provider is Ollama, which needs
+// no API key.
+func apiKeyFor(key, provider string) string {
+	if key == "" && strings.EqualFold(provider, ai.ProviderOllama) {
+		return "ollama"
+	}
+	return key
+}
+
+// applyOutputModes sets the permissions of the files the command writes to those of
+// the configuration, where set.
+func applyOutputModes(cfg *config.Config) {
+	// Checked when the configuration was loaded
+	file, dir, _ := cfg.OutputModes()
+	if file == 0 {
+		file = render.DefaultOutputFileMode
+	}
+	if dir == 0 {
+		dir = render.DefaultOutputDirMode
+	}
+	render.SetOutputModes(file, dir)
+}
+
+// applyTrustPolicy verifies the signatures of the template packs and agents the
+// command loads from now on against the keys and policy configured under trust.
+func applyTrustPolicy(cfg *config.Config) {
+	// Checked when the configuration was loaded
+	verifier, _ := cfg.Verifier()
+	trust.SetDefault(verifier)
+}
+
+// structuredOutputs returns whether the configuration says model supports structured
+// outputs, or nil when the model is not described there.
+func structuredOutputs(cfg *config.Config, name string) *bool {
+	described, ok := cfg.Models[name]
+	if !ok {
+		return nil
+	}
+	return &described.StructuredOutputs
+}
+
+// newUsageMeter returns the meter of the run's token usage, with the budget of
+// --budget or the configuration. Every model of the run needs a price when there
+// is a budget.
+func newUsageMeter(cfg *config.Config) (*ai.Meter, error) {
+	runBudget := budget
+	if runBudget == 0 {
+		runBudget = cfg.Budget
+	}
+	meter := newConfiguredMeter(cfg, runBudget)
+	if dryRun {
+		return meter, nil
+	}
+	for _, runModel := range []string{model, ensembleWith, evalModel} {
+		if runModel == "" {
+			continue
 		}
+		if err := meter.CheckPriced(runModel); err != nil {
+			return nil, err
+		}
+	}
+	return meter, nil
+}
 
-		// Get API key from flag or environment
+// newConfiguredMeter returns a usage meter pricing tokens with the configured prices,
+// limiting the run's cost to budget when above 0.
+func newConfiguredMeter(cfg *config.Config, budget float64) *ai.Meter {
+	prices := make(map[string]ai.Price, len(cfg.Prices))
+	for name, price := range cfg.Prices {
+		prices[name] = ai.Price{Input: price.Input, Output: price.Output}
+	}
+	return ai.NewMeter(prices, budget)
+}
+
+// reportUsage prints the token usage and cost of a run, also when it failed, and
+// appends them to the usage log of --usage-log or the configuration.
+func reportUsage(cfg *config.Config, meter *ai.Meter, result *generate.Result, runErr error) {
+	summary := meter.Summary()
+	out := os.Stdout
+	if runErr != nil {
+		out = os.Stderr
+	}
+	fmt.Fprintln(out, i18n.T("generate.usage", summary.Total.PromptTokens, summary.Total.CompletionTokens, summary.Total.Requests, summary.Cost))
+	if unpriced := summary.Unpriced(); len(unpriced) > 0 {
+		fmt.Fprintln(out, i18n.T("generate.usage_unpriced", strings.Join(unpriced, ", ")))
+	}
+
+	path := usageLog
+	if path == "" {
+		path = cfg.UsageLog
+	}
+	if path == "" {
+		return
+	}
+	record := generate.UsageRecord{Time: time.Now().UTC(), Template: templateType, Output: outputFile, UsageSummary: *summary}
+	if result != nil && result.Report != nil {
+		record.RunID = result.Report.RunID
+		record.Output = result.OutputFile
+	}
+	if runErr != nil {
+		record.Error = runErr.Error()
+	}
+	if err := generate.AppendUsageLog(path, record); err != nil {
+		log.Warn().Err(err).Msg("Failed to write usage log")
+	}
+}
+
+// printReportSummary prints the results of the optional quality stages.
+func printReportSummary(result *generate.Result) {
+	report := result.Report
+	if report == nil {
+		return
+	}
+	if report.Ensemble != nil && len(report.Ensemble.Disagreements) > 0 {
+		fmt.Println(i18n.T("generate.ensemble", strings.Join(report.Ensemble.Disagreements, ", ")))
+	}
+	if report.Evaluation != nil {
+		fmt.Println(i18n.T("generate.quality", report.Evaluation.Overall, result.ReportFile))
+	}
+	if g := report.Groundedness; g != nil {
+		fmt.Println(i18n.T("generate.groundedness", g.Checked-len(g.Unsupported), g.Checked, result.ReportFile))
+	}
+	if c := report.Confidence; c != nil && len(c.Low) > 0 {
+		fmt.Println(i18n.T("generate.confidence", len(c.Low), len(c.Fields), strings.Join(c.Low, ", "), result.ReportFile))
+	}
+	if len(report.SourceErrors) > 0 {
+		fmt.Println(i18n.T("generate.source_errors", len(report.SourceErrors), result.ReportFile))
+	}
+}
+
+// parseAgentParams parses the key=value parameters of --agent-param.
+func parseAgentParams() (map[string]string, error) {
+	params := make(map[string]string)
+	for _, param := range agentParams {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid agent parameter format: %s (expected key=value)", param)
+		}
+		params[parts[0]] = parts[1]
+	}
+	return params, nil
+}
+
+// agentSourcePath returns the path the agent analyzes: the first of sources, or the
+// current directory.
+func agentSourcePath(sources []string) string {
+	if len(sources) > 0 {
+		_, sourcePath := ingest.SplitSource(sources[0])
+		return sourcePath
+	}
+	return "."
+}
+
+// runAgentDryRun resolves the agent of --agent and validates its parameters, then
+// prints the command it would run and the artifacts expected of it, without running
+// the agent or generating anything.
+func runAgentDryRun(out io.Writer) error {
+	if agentName == "" {
+		return fmt.Errorf("--agent-dry-run requires --agent")
+	}
+	params, err := parseAgentParams()
+	if err != nil {
+		return err
+	}
+	registry := agent.NewRegistry()
+	if err := registry.Discover(); err != nil {
+		return fmt.Errorf("failed to discover agents: %w", err)
+	}
+	cache, err := agent.NewArtifactCache()
+	if err != nil {
+		return fmt.Errorf("failed to create artifact cache: %w", err)
+	}
+
+	plan, err := agent.NewExecutor(registry, cache, log.Logger).Plan(agent.RunOptions{
+		AgentName:  agentName,
+		SourcePath: agentSourcePath(sources),
+		Parameters: params,
+	})
+	if err != nil {
+		return fmt.Errorf("agent dry run failed: %w", err)
+	}
+	printAgentPlan(out, plan)
+	return nil
+}
+
+// runGenerate runs the optional research agent and the generation workflow. The
+// configuration provides the template directory, used when --template-dir is not
+// set, and the ingest cache settings. A non-nil triage collects agent output and the
+// run's model exchanges for a triage bundle.
+func runGenerate(ctx context.Context, cfg *config.Config, triage *generate.Triage, meter *ai.Meter) (*generate.Result, error) {
+	if dryRunJSON && !dryRun {
+		return nil, fmt.Errorf("--json requires --dry-run")
+	}
+	if agentTools && agentName == "" {
+		return nil, fmt.Errorf("--agent-tools requires --agent")
+	}
+
+	variables, err := parseVariables(outputVars)
+	if err != nil {
+		return nil, err
+	}
+	if aiCassette, err = openCassette(recordFile, replayFile); err != nil {
+		return nil, err
+	}
+
+	// Agent execution logs go through the configured logger so module filters apply
+	logger := log.Logger
+
+	// Keep stdout clean for the JSON dry-run plan
+	progress := os.Stdout
+	if dryRunJSON {
+		progress = os.Stderr
+	}
+
+	// Clone git sources and download web pages; the copies are removed when the run ends
+	actualSources, cleanup, err := ingest.FetchRemoteSources(ctx, sources, sourceRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote source: %w", err)
+	}
+	defer cleanup()
+
+	// With --agent-tools, the model calls the agent's tools while generating
+	var analysis *generate.AnalysisOptions
+	if agentName != "" && agentTools {
+		params, err := parseAgentParams()
+		if err != nil {
+			return nil, err
+		}
+		analysis = &generate.AnalysisOptions{
+			AgentName:         agentName,
+			SourcePath:        agentSourcePath(actualSources),
+			AgentParams:       params,
+			StripInstructions: stripToolText || cfg.StripToolInstructions,
+		}
+		if cache, err := agent.NewArtifactCache(); err == nil {
+			analysis.ToolCache = cache.ToolResults()
+		}
+	}
+
+	// Otherwise a specified agent runs first, and its output is the source
+	var agentRuns []agent.RunnerInfo
+	if agentName != "" && !agentTools {
+		params, err := parseAgentParams()
+		if err != nil {
+			return nil, err
+		}
+
+		// Create agent registry and discover agents
+		registry := agent.NewRegistry()
+		if err := registry.Discover(); err != nil {
+			return nil, fmt.Errorf("failed to discover agents: %w", err)
+		}
+
+		// Create artifact cache
+		cache, err := agent.NewArtifactCache()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create artifact cache: %w", err)
+		}
+
+		// Create executor
+		executor := agent.NewExecutor(registry, cache, logger)
+		if triage != nil {
+			executor.SetTranscript(triage.AgentLog())
+		}
+
+		sourcePath := agentSourcePath(actualSources)
+		// Run the agent
+		fmt.Fprintln(progress, i18n.T("generate.agent_running", agentName, sourcePath))
+		result, err := executor.Run(agent.RunOptions{
+			AgentName:  agentName,
+			SourcePath: sourcePath,
+			Parameters: params,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("agent execution failed: %w", err)
+		}
+
+		// Validate agent output
+		if err := executor.ValidateOutput(result.OutputPath); err != nil {
+			return nil, fmt.Errorf("agent output validation failed: %w", err)
+		}
+
+		// Keep the artifacts per source commit for trends
+		if _, err := agent.NewHistory(cfg.AgentHistoryDir).Record(agentName, sourcePath, result.OutputPath, time.Now()); err != nil {
+			log.Warn().Err(err).Msg("Failed to record agent run history")
+		}
+
+		// Replace sources with agent output directory
+		actualSources = []string{result.OutputPath}
+		agentRuns = append(agentRuns, result.Runner)
+		fmt.Fprintln(progress, i18n.T("generate.agent_done", result.OutputPath))
+	}
+
+	// Get API key from flag or environment, then the configured secret sources
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
 		if apiKey == "" {
-			apiKey = os.Getenv("OPENAI_API_KEY")
-			if apiKey == "" {
-				apiKey = os.Getenv("DOCLOOM_API_KEY")
-			}
+			apiKey = os.Getenv("DOCLOOM_API_KEY")
 		}
+	}
+	if apiKey == "" && !dryRun && !aiCassette.Replaying() {
+		if err := cfg.ResolveAPIKey(ctx); err != nil {
+			return nil, err
+		}
+		apiKey = cfg.APIKey
+	}
+	apiKey = apiKeyFor(apiKey, runProvider(cfg))
+	if triage != nil {
+		triage.AddSecrets(apiKey)
+	}
+	// Replayed recordings need no API key
+	if apiKey == "" && aiCassette.Replaying() {
+		apiKey = ai.ReplayAPIKey
+	}
 
-		// For dry-run, we don't need to create a real AI client
-		var aiClient ai.Client
-		if !dryRun {
-			// Create AI client configuration
-			aiConfig := ai.Config{
-				BaseURL:     baseURL,
-				APIKey:      apiKey,
-				Model:       model,
-				Temperature: float32(temperature),
-				MaxTokens:   4096,
-				MaxRetries:  maxRetries,
-			}
+	// For dry-run, we don't need to create a real AI client
+	var aiClient ai.Client
+	if !dryRun {
+		// Create AI client configuration
+		aiConfig := ai.Config{
+			BaseURL:     baseURL,
+			APIKey:      apiKey,
+			Model:       model,
+			Temperature: float32(temperature),
+			MaxTokens:   cfg.MaxTokens,
+			MaxRetries:  maxRetries,
 
-			if seed > 0 {
-				aiConfig.Seed = &seed
-			}
+			ExtraHeaders:      cfg.ExtraHeaders,
+			ExtraQuery:        cfg.ExtraQuery,
+			Provider:          runProvider(cfg),
+			APIVersion:        cfg.APIVersion,
+			StructuredOutputs: structuredOutputs(cfg, model),
+			Cassette:          aiCassette,
+		}
 
-			// Create AI client
-			var err error
-			aiClient, err = ai.NewOpenAIClient(aiConfig)
-			if err != nil {
-				return fmt.Errorf("failed to create AI client: %w", err)
+		if seed > 0 {
+			aiConfig.Seed = &seed
+		}
+
+		// Create AI client
+		openaiClient, err := ai.NewOpenAIClient(aiConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AI client: %w", err)
+		}
+		if probeModel || cfg.Probe {
+			if err := probeAIClient(ctx, openaiClient); err != nil {
+				return nil, err
 			}
 		}
+		aiClient = openaiClient
+	}
 
-		// Create orchestrator
-		orchestrator := generate.NewOrchestrator(aiClient)
+	// Create orchestrator
+	orchestrator := generate.NewOrchestrator(aiClient)
+	if err := loadUserTemplates(orchestrator, templateDir, cfg.TemplateDir); err != nil {
+		return nil, err
+	}
+	if err := loadPromptOverrides(orchestrator, cfg); err != nil {
+		return nil, err
+	}
+	orchestrator.SetModelCatalog(modelCatalog(cfg))
+	if !noIngestCache {
+		orchestrator.SetIngestCache(newIngestCache(cfg))
+	}
+	filter, err := newSourceFilter(cfg, includeGlobs, excludeGlobs)
+	if err != nil {
+		return nil, err
+	}
+	orchestrator.SetSourceFilter(filter)
+	if !noRetrieval && !dryRun {
+		retriever, err := newRetriever(cfg, baseURL, apiKey, model)
+		if err != nil {
+			return nil, err
+		}
+		orchestrator.SetRetriever(retriever)
+	}
+	if streamOutput {
+		orchestrator.SetStreamProgress(newStreamPrinter(os.Stderr))
+	}
+	if interactive && !dryRun {
+		if !isTerminal(os.Stdin) {
+			return nil, fmt.Errorf("--interactive requires a terminal")
+		}
+		orchestrator.SetReviewer(newTerminalReviewer(os.Stdin, os.Stdout))
+	}
 
-		// Prepare options
-		opts := generate.Options{
-			TemplateType: templateType,
-			Sources:      actualSources,
-			OutputFile:   outputFile,
-			Model:        model,
-			BaseURL:      baseURL,
-			APIKey:       apiKey,
-			Temperature:  float32(temperature),
-			MaxRetries:   maxRetries,
-			DryRun:       dryRun,
-			Force:        force,
-			MaxRepairs:   3, // Default to 3 repair attempts
+	if analysis != nil {
+		if err := orchestrator.DiscoverAgents(); err != nil {
+			return nil, err
 		}
+	}
 
-		if seed > 0 {
-			opts.Seed = &seed
+	// Configure the optional ensemble and judge models
+	judgeModel, err := configureAuxiliaryModels(orchestrator, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare options
+	opts := generate.Options{
+		TemplateType:   templateType,
+		Sources:        actualSources,
+		OutputFile:     outputFile,
+		Model:          model,
+		BaseURL:        baseURL,
+		APIKey:         apiKey,
+		Temperature:    float32(temperature),
+		MaxRetries:     maxRetries,
+		DryRun:         dryRun,
+		DryRunJSON:     dryRunJSON,
+		Force:          force,
+		ArchiveSources: archiveSources,
+		Provenance:     provenance,
+		Formats:        formats,
+		Variables:      variables,
+		SourceErrors:   sourceErrors,
+		MaxRepairs:     3, // Default to 3 repair attempts
+
+		Evaluate:            evaluate,
+		EvaluationModel:     judgeModel,
+		EvaluationThreshold: evalMinScore,
+		Grounded:            grounded,
+		Confidence:          confidence,
+		ConfidenceBadge:     confBadge,
+		MinConfidence:       minConfidence,
+		EnsembleModel:       ensembleWith,
+		PerField:            perField,
+		Agents:              agentRuns,
+		Analysis:            analysis,
+		Triage:              triage,
+		Resume:              resume,
+		StateDir:            stateDir,
+		Usage:               meter,
+		LessonsDir:          lessonsDir,
+		Glossary:            glossaryFile,
+	}
+	if opts.LessonsDir == "" {
+		opts.LessonsDir = cfg.LessonsDir
+	}
+	if opts.Glossary == "" {
+		opts.Glossary = cfg.Glossary
+	}
+
+	if seed > 0 {
+		opts.Seed = &seed
+	}
+
+	// Run generation
+	return orchestrator.Run(ctx, opts)
+}
+
+// configureAuxiliaryModels sets up the secondary ensemble model and the evaluation judge
+// model requested by flags. It returns the judge model name recorded in the run report.
+func configureAuxiliaryModels(orchestrator *generate.Orchestrator, cfg *config.Config) (string, error) {
+	judgeModel := model
+	if dryRun {
+		return judgeModel, nil
+	}
+
+	// Generate with a second model and reconcile when an ensemble is requested
+	if ensembleWith != "" {
+		secondary, err := ai.NewOpenAIClient(ai.Config{
+			BaseURL:     baseURL,
+			APIKey:      apiKey,
+			Model:       ensembleWith,
+			Temperature: float32(temperature),
+			MaxRetries:  maxRetries,
+
+			ExtraHeaders:      cfg.ExtraHeaders,
+			ExtraQuery:        cfg.ExtraQuery,
+			Provider:          runProvider(cfg),
+			APIVersion:        cfg.APIVersion,
+			StructuredOutputs: structuredOutputs(cfg, ensembleWith),
+			Cassette:          aiCassette,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create ensemble client: %w", err)
 		}
+		orchestrator.SetEnsembleClient(secondary)
+	}
 
-		// Run generation
-		ctx := context.Background()
-		if err := orchestrator.Generate(ctx, opts); err != nil {
-			return err
+	// Use a separate judge model for the evaluation stage when requested
+	if evaluate && evalModel != "" {
+		judgeModel = evalModel
+		judge, err := ai.NewOpenAIClient(ai.Config{
+			BaseURL:    baseURL,
+			APIKey:     apiKey,
+			Model:      evalModel,
+			MaxRetries: maxRetries,
+			OwnParams:  true,
+
+			ExtraHeaders: cfg.ExtraHeaders,
+			ExtraQuery:   cfg.ExtraQuery,
+			Provider:     runProvider(cfg),
+			APIVersion:   cfg.APIVersion,
+			Cassette:     aiCassette,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create evaluation client: %w", err)
 		}
+		orchestrator.SetEvaluator(judge)
+	}
+	return judgeModel, nil
+}
 
-		if !dryRun {
-			fmt.Printf("Successfully generated document: %s\n", outputFile)
+// generateFromConfig generates a document non-interactively using the model settings
+// from the configuration, overwriting any existing output. It is used by commands that
+// regenerate documents without generate's flags (schedules, status --fix). Git sources
+// are checked out at sourceRef, when set, unless they name their own ref.
+func generateFromConfig(ctx context.Context, cfg *config.Config, templateType string, sources []string, sourceRef, output string, variables map[string]string) (*generate.Result, error) {
+	aiClient, err := newConfiguredAIClient(ctx, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	orchestrator, err := newConfiguredOrchestrator(cfg, aiClient)
+	if err != nil {
+		return nil, err
+	}
+	return generateWithOrchestrator(ctx, cfg, orchestrator, templateType, sources, sourceRef, output, variables)
+}
+
+// newConfiguredOrchestrator creates an orchestrator generating with aiClient, with the
+// configured user templates, ingest cache, source filter and model catalog. It may be shared by
+// concurrent runs, which then load the templates only once.
+func newConfiguredOrchestrator(cfg *config.Config, aiClient ai.Client) (*generate.Orchestrator, error) {
+	orchestrator := generate.NewOrchestrator(aiClient)
+	if err := loadUserTemplates(orchestrator, "", cfg.TemplateDir); err != nil {
+		return nil, err
+	}
+	if err := loadPromptOverrides(orchestrator, cfg); err != nil {
+		return nil, err
+	}
+	orchestrator.SetIngestCache(newIngestCache(cfg))
+	orchestrator.SetModelCatalog(modelCatalog(cfg))
+	filter, err := newSourceFilter(cfg, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	orchestrator.SetSourceFilter(filter)
+	retriever, err := newRetriever(cfg, cfg.BaseURL, apiKeyFor(cfg.APIKey, cfg.Provider), cfg.Model)
+	if err != nil {
+		return nil, err
+	}
+	orchestrator.SetRetriever(retriever)
+	return orchestrator, nil
+}
+
+// generateWithOrchestrator generates a document like generateFromConfig using an
+// existing orchestrator.
+func generateWithOrchestrator(ctx context.Context, cfg *config.Config, orchestrator *generate.Orchestrator, templateType string, sources []string, sourceRef, output string, variables map[string]string) (*generate.Result, error) {
+	sources, cleanup, err := ingest.FetchRemoteSources(ctx, sources, sourceRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote source: %w", err)
+	}
+	defer cleanup()
+
+	opts := generate.Options{
+		TemplateType: templateType,
+		Sources:      sources,
+		OutputFile:   output,
+		Model:        cfg.Model,
+		BaseURL:      cfg.BaseURL,
+		APIKey:       apiKeyFor(cfg.APIKey, cfg.Provider),
+		Temperature:  float32(cfg.Temperature),
+		MaxRetries:   cfg.MaxRetries,
+		Force:        true,
+		MaxRepairs:   3,
+		Variables:    variables,
+		LessonsDir:   cfg.LessonsDir,
+		Glossary:     cfg.Glossary,
+	}
+	if cfg.Seed > 0 {
+		opts.Seed = &cfg.Seed
+	}
+	return orchestrator.Run(ctx, opts)
+}
+
+// newIngestCache returns the cache of extracted source text configured by cache_dir
+// and ingest_cache_max_mb.
+func newIngestCache(cfg *config.Config) *ingest.Cache {
+	dir := cfg.CacheDir
+	if dir == "" {
+		dir = ingest.DefaultCacheDir()
+	}
+	return ingest.NewCache(dir, int64(cfg.IngestCacheMaxMB)<<20)
+}
+
+// newSourceFilter returns the filter of the files ingested below source directories:
+// the configured include and exclude globs together with those of the flags.
+func newSourceFilter(cfg *config.Config, include, exclude []string) (*ingest.Filter, error) {
+	return ingest.NewFilter(append(slices.Clone(cfg.Include), include...), append(slices.Clone(cfg.Exclude), exclude...))
+}
+
+// parseVariables parses output filename variables given as key=value.
+func parseVariables(values []string) (map[string]string, error) {
+	variables := make(map[string]string)
+	for _, value := range values {
+		name, val, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable format: %s (expected key=value)", value)
 		}
+		variables[name] = val
+	}
+	return variables, nil
+}
 
+// loadUserTemplates loads templates from flagDir or, when it is empty, from the
+// configured template directory (config file or DOCLOOM_TEMPLATE_DIR). A configured
+// directory that does not exist is skipped, since the default need not exist.
+func loadUserTemplates(orchestrator *generate.Orchestrator, flagDir, configDir string) error {
+	dir := flagDir
+	if dir == "" {
+		if configDir == "" {
+			return nil
+		}
+		if _, err := os.Stat(configDir); err != nil {
+			log.Debug().Str("dir", configDir).Msg("Template directory not found, using built-in templates")
+			return nil
+		}
+		dir = configDir
+	}
+	return orchestrator.LoadTemplates(dir)
+}
+
+// loadPromptOverrides loads the prompt templates of the configured prompt directory
+// (config file or DOCLOOM_PROMPT_DIR), or of ~/.docloom/prompts by default.
+func loadPromptOverrides(orchestrator *generate.Orchestrator, cfg *config.Config) error {
+	dir := cfg.PromptDir
+	if dir == "" {
+		dir = prompt.DefaultPromptDir()
+	}
+	if dir == "" {
 		return nil
-	},
+	}
+	return orchestrator.LoadPromptOverrides(dir)
+}
+
+// newRetriever returns the retrieval stage configured under embeddings, with the
+// endpoint and API key of generation unless the embeddings have their own, or nil
+// when no embeddings model is configured. Chunks are sized with the tokenizer of
+// model, the generation model they are sent to.
+func newRetriever(cfg *config.Config, baseURL, apiKey, model string) (*chunk.Retriever, error) {
+	embeddings := cfg.Embeddings
+	if embeddings.Model == "" {
+		return nil, nil
+	}
+	embedConfig := ai.Config{
+		BaseURL:      baseURL,
+		APIKey:       apiKey,
+		Model:        embeddings.Model,
+		MaxRetries:   cfg.MaxRetries,
+		ExtraHeaders: cfg.ExtraHeaders,
+		ExtraQuery:   cfg.ExtraQuery,
+		Provider:     runProvider(cfg),
+		APIVersion:   cfg.APIVersion,
+		Cassette:     aiCassette,
+	}
+	if embeddings.BaseURL != "" {
+		// A separate endpoint may be of another provider, detected from its URL
+		embedConfig.BaseURL, embedConfig.Provider = embeddings.BaseURL, ""
+	}
+	if embeddings.APIKey != "" {
+		embedConfig.APIKey = embeddings.APIKey
+	}
+	client, err := ai.NewOpenAIClient(embedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings client: %w", err)
+	}
+	return chunk.NewRetriever(client, embeddings.ChunkTokens, embeddings.TopK, tokens.ForModel(model)), nil
+}
+
+// newConfiguredAIClient creates an AI client from the model settings in the
+// configuration, probing the model first when the configuration enables it. A non-nil
+// pool shares connections and the rate limit with other clients.
+func newConfiguredAIClient(ctx context.Context, cfg *config.Config, pool *ai.Pool) (ai.Client, error) {
+	if err := cfg.ResolveAPIKey(ctx); err != nil {
+		return nil, err
+	}
+	aiConfig := ai.Config{
+		BaseURL:     cfg.BaseURL,
+		APIKey:      cfg.APIKey,
+		Model:       cfg.Model,
+		Temperature: float32(cfg.Temperature),
+		MaxTokens:   cfg.MaxTokens,
+		MaxRetries:  cfg.MaxRetries,
+
+		ExtraHeaders:      cfg.ExtraHeaders,
+		ExtraQuery:        cfg.ExtraQuery,
+		Provider:          cfg.Provider,
+		APIVersion:        cfg.APIVersion,
+		Pool:              pool,
+		StructuredOutputs: structuredOutputs(cfg, cfg.Model),
+	}
+	if cfg.Seed > 0 {
+		aiConfig.Seed = &cfg.Seed
+	}
+
+	aiClient, err := ai.NewOpenAIClient(aiConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI client: %w", err)
+	}
+	if cfg.Probe {
+		if err := probeAIClient(ctx, aiClient); err != nil {
+			return nil, err
+		}
+	}
+	return aiClient, nil
+}
+
+// openCassette returns the cassette recording the run's model requests to record or
+// replaying them from replay, or nil when neither is set.
+func openCassette(record, replay string) (*ai.Cassette, error) {
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("--record and --replay cannot be combined")
+	case record != "":
+		return ai.RecordCassette(record)
+	case replay != "":
+		return ai.ReplayCassette(replay)
+	}
+	return nil, nil
+}
+
+// probeAIClient verifies that the configured model is served by the base URL and
+// records its capabilities on the client before any generation request is made.
+func probeAIClient(ctx context.Context, client *ai.OpenAIClient) error {
+	if _, err := client.Probe(ctx); err != nil {
+		return fmt.Errorf("model probe failed: %w", err)
+	}
+	return nil
+}
+
+// notifyCompletion posts the run outcome and, when usage is not nil, its cost to
+// configured notification webhooks. Notification failures are logged and never fail
+// the run.
+func notifyCompletion(ctx context.Context, cfg config.NotificationsConfig, template, output string, result *generate.Result, usage *ai.UsageSummary, runErr error, duration time.Duration) {
+	notifier := notify.NewNotifier(cfg)
+	if !notifier.Enabled() {
+		return
+	}
+
+	event := notify.Event{
+		Title:    filepath.Base(output),
+		Template: template,
+		Status:   notify.StatusSuccess,
+		Output:   output,
+		Link:     notify.OutputLink(cfg.LinkBaseURL, output),
+		Duration: duration,
+	}
+	if result != nil {
+		// The output may have been named from a filename pattern
+		event.Title = result.Title()
+		event.Output = result.OutputFile
+		event.Link = notify.OutputLink(cfg.LinkBaseURL, result.OutputFile)
+	}
+	if usage != nil && len(usage.Models) > 0 {
+		event.Cost = fmt.Sprintf("$%.4f", usage.Cost)
+		if unpriced := usage.Unpriced(); len(unpriced) > 0 {
+			event.Cost += fmt.Sprintf(" (without unpriced %s)", strings.Join(unpriced, ", "))
+		}
+	}
+	if runErr != nil {
+		event.Status = notify.StatusFailure
+		event.Error = runErr.Error()
+		event.Link = ""
+	}
+
+	if err := notifier.Notify(ctx, event); err != nil {
+		log.Warn().Err(err).Msg("Failed to send notification")
+	}
 }
 
 func init() {
@@ -173,25 +979,67 @@ func init() {
 
 	// Required flags
 	generateCmd.Flags().StringVarP(&templateType, "type", "t", "", "Template type to use (required)")
-	generateCmd.Flags().StringSliceVarP(&sources, "source", "s", []string{}, "Source paths (files or directories)")
+	generateCmd.Flags().StringSliceVarP(&sources, "source", "s", []string{}, "Source paths (files, directories, git URLs to clone or web pages), optionally labeled as label=path")
+	generateCmd.Flags().StringVar(&sourceRef, "source-ref", "", "Branch, tag or commit to check out from git sources (a URL may name its own as url#ref)")
 	generateCmd.Flags().StringVarP(&outputFile, "out", "o", "", "Output file path (required)")
 
 	// Model configuration flags
 	generateCmd.Flags().StringVar(&model, "model", "gpt-4", "Model to use for generation")
 	generateCmd.Flags().StringVar(&baseURL, "base-url", "", "Base URL for OpenAI-compatible API")
+	generateCmd.Flags().StringVar(&provider, "provider", "", "API provider: openai, azure (Azure OpenAI deployments; --model names the deployment) or ollama (local models, native API; no API key needed); azure is detected from the base URL when not set")
 	generateCmd.Flags().StringVar(&apiKey, "api-key", "", "API key (can also use OPENAI_API_KEY env var)")
 	generateCmd.Flags().Float64Var(&temperature, "temperature", 0.7, "Temperature for model generation")
 	generateCmd.Flags().IntVar(&seed, "seed", 0, "Seed for reproducible generation")
 	generateCmd.Flags().IntVar(&maxRetries, "retries", 3, "Maximum number of retries for model calls")
+	generateCmd.Flags().BoolVar(&streamOutput, "stream", false, "Stream model responses and show progress while they arrive (Ctrl-C cancels)")
+	generateCmd.Flags().BoolVar(&perField, "per-field", false, "Generate each top-level template field, or each x-section of fields, with its own request, following the schema's x-dependsOn order")
+	generateCmd.Flags().StringVar(&recordFile, "record", "", "Record the model requests and responses of the run to a cassette file, e.g. cassette.yaml")
+	generateCmd.Flags().StringVar(&replayFile, "replay", "", "Answer model requests from a cassette file recorded with --record instead of calling the model (no API key needed)")
+	generateCmd.Flags().BoolVar(&probeModel, "probe", false, "Check the model against the base URL's models endpoint and detect its capabilities before generating")
 
 	// Operational flags
 	generateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview without making API calls")
+	generateCmd.Flags().BoolVar(&dryRunJSON, "json", false, "With --dry-run, print the plan (template, sources, tokens, schema, full prompt) as JSON")
 	generateCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing output files")
+	generateCmd.Flags().StringSliceVar(&includeGlobs, "include", []string{}, "Ingest only files below source directories matching a glob, e.g. docs/adr/*.md (repeatable)")
+	generateCmd.Flags().StringSliceVar(&excludeGlobs, "exclude", []string{}, "Skip files below source directories matching a glob, e.g. vendor/** (repeatable)")
+	generateCmd.Flags().BoolVar(&noRetrieval, "no-retrieval", false, "Send all sources instead of the chunks most relevant to the template selected with the configured embeddings model")
+	generateCmd.Flags().BoolVar(&interactive, "interactive", false, "Review the generated fields on the terminal, accepting, editing or regenerating each, before the document is written")
+	generateCmd.Flags().BoolVar(&noIngestCache, "no-ingest-cache", false, "Extract source text (PDFs) again instead of using the ingest cache")
+	generateCmd.Flags().StringSliceVar(&outputVars, "var", []string{}, "Variable for output filename patterns such as {{project}} (format: key=value, can be specified multiple times)")
+	generateCmd.Flags().BoolVar(&archiveSources, "archive-sources", false, "Store a compressed snapshot of the ingested files next to the output (<name>.sources.tar.gz)")
+	generateCmd.Flags().StringSliceVar(&formats, "format", []string{"html"}, "Output formats: html (always written) and txt, a plain-text version (<name>.txt) for email and screen readers, e.g. --format html,txt")
+	generateCmd.Flags().BoolVar(&provenance, "provenance", false, "Annotate rendered fields with their field path, run ID and model, with a hover overlay toggled by Alt+P")
 	generateCmd.Flags().StringVar(&configFile, "config", "", "Config file path")
+	generateCmd.Flags().StringVar(&profileName, "profile", "", "Model profile from the configuration's profiles, e.g. fast, quality or local (defaults to config profile)")
+	generateCmd.Flags().StringVar(&templateDir, "template-dir", "", "Directory of user templates; overrides built-ins with the same name (defaults to config template_dir)")
+	generateCmd.Flags().StringVar(&owner, "owner", "", "Owner recorded in the document registry (defaults to config owner)")
+
+	generateCmd.Flags().StringVar(&sourceErrors, "source-errors", "warn", "Handling of sources that cannot be read: fail, warn (skip with a warning) or ignore")
+	generateCmd.Flags().Float64Var(&budget, "budget", 0, "Abort before a model request whose projected cost would take the run over this many US dollars (defaults to config budget)")
+	generateCmd.Flags().StringVar(&usageLog, "usage-log", "", "Append the run's token usage and cost as a JSON line to this file (defaults to config usage_log)")
+	generateCmd.Flags().BoolVar(&resume, "resume", false, "Checkpoint the run and continue a failed run with the same template, model, sources and output from its last completed step")
+	generateCmd.Flags().StringVar(&stateDir, "state-dir", generate.DefaultStateDir, "Directory of run checkpoints used by --resume")
+	generateCmd.Flags().StringVar(&lessonsDir, "lessons-dir", "", "Directory of per-template lessons files recording recurring validation failures, which are added to generation prompts (defaults to config lessons_dir)")
+	generateCmd.Flags().StringVar(&triageDir, "triage-dir", "", "When the run fails, write a triage bundle (redacted prompt, last model response, validation errors, agent output, configuration) to a zip file in this directory")
+
+	// Evaluation flags
+	generateCmd.Flags().BoolVar(&evaluate, "evaluate", false, "Score the generated document with an LLM judge (completeness, groundedness, clarity)")
+	generateCmd.Flags().StringVar(&evalModel, "eval-model", "", "Model used as judge (defaults to --model)")
+	generateCmd.Flags().Float64Var(&evalMinScore, "eval-threshold", 0, "Fail the run when the overall score (0-10) is below this threshold")
+	generateCmd.Flags().BoolVar(&confidence, "confidence", false, "Ask the model for a confidence score (0-1) per top-level field, recorded in the run report")
+	generateCmd.Flags().Float64Var(&confBadge, "confidence-badge", 0, "Badge fields scoring below this confidence (0-1) in the HTML for reviewer attention (implies --confidence)")
+	generateCmd.Flags().Float64Var(&minConfidence, "min-confidence", 0, "Fail the run when a field scores below this confidence (0-1); the outputs are kept (implies --confidence)")
+	generateCmd.Flags().StringVar(&glossaryFile, "glossary", "", "Glossary file (YAML or JSON) of systems, teams and acronyms whose first occurrences link to their pages (defaults to config glossary)")
+	generateCmd.Flags().StringVar(&ensembleWith, "ensemble-model", "", "Also generate with this model and reconcile both candidates field by field")
+	generateCmd.Flags().StringVar(&grounded, "grounded", "off", "Check generated claims against the sources: off, warn or strict (fail on unsupported claims)")
 
 	// Agent flags
 	generateCmd.Flags().StringVar(&agentName, "agent", "", "Research agent to run before generation")
 	generateCmd.Flags().StringSliceVar(&agentParams, "agent-param", []string{}, "Agent parameters (format: key=value, can be specified multiple times)")
+	generateCmd.Flags().BoolVar(&agentTools, "agent-tools", false, "Let the model call the agent's tools to explore the source while generating, instead of running the agent first")
+	generateCmd.Flags().BoolVar(&stripToolText, "strip-tool-instructions", false, "Remove instruction-like text from agent tool outputs (with --agent-tools; or strip_tool_instructions in the config)")
+	generateCmd.Flags().BoolVar(&agentDryRun, "agent-dry-run", false, "Resolve the agent and validate its parameters, then print the command, environment and paths it would run with and the artifacts expected of it, without running anything")
 
 	// Mark required flags
 	if err := generateCmd.MarkFlagRequired("type"); err != nil {
@@ -2,13 +2,25 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/notify"
 )
 
 // TestGenerateCommand_ModelAndBaseURLFlags tests that model and base-url flags are properly configured.
@@ -287,7 +299,7 @@ func TestGenerateCmd_VerboseLogging(t *testing.T) {
 
 		// Set verbose flag globally before creating command
 		oldVerbose := verbose
-		verbose = true
+		verbose = 1
 		defer func() { verbose = oldVerbose }()
 
 		// Create command with verbose flag
@@ -312,7 +324,7 @@ func TestGenerateCmd_VerboseLogging(t *testing.T) {
 		// The verbose flag affects logging level, and since we're using dry-run,
 		// we verify the command executes successfully with verbose enabled
 		assert.NoError(t, err, "Command should execute successfully")
-		assert.True(t, verbose, "Verbose flag should be set")
+		assert.Equal(t, 1, verbose, "Verbose flag should be set")
 
 		// The actual verbose logs appear in stderr when running,
 		// but in tests they may not be captured properly due to console writer
@@ -439,3 +451,229 @@ func TestGenerateCmd_DryRun(t *testing.T) {
 		// Here we just verify the flag is properly set and no API key is required
 	})
 }
+
+// TestRunGenerate_JSONRequiresDryRun tests that --json is only accepted with --dry-run.
+func TestRunGenerate_JSONRequiresDryRun(t *testing.T) {
+	dryRunJSON = true
+	dryRun = false
+	t.Cleanup(func() { dryRunJSON = false })
+
+	_, err := runGenerate(context.Background(), &config.Config{}, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--json requires --dry-run")
+}
+
+// TestNewUsageMeter tests that a budget requires a price for every model of the run.
+func TestNewUsageMeter(t *testing.T) {
+	model, budget, dryRun = "local-llama", 0, false
+	t.Cleanup(func() { model, budget = "gpt-4", 0 })
+
+	_, err := newUsageMeter(&config.Config{})
+	require.NoError(t, err, "without a budget prices are optional")
+
+	_, err = newUsageMeter(&config.Config{Budget: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "local-llama")
+
+	meter, err := newUsageMeter(&config.Config{Budget: 1, Prices: map[string]config.ModelPrice{"local-llama": {Input: 0.1, Output: 0.1}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, meter.Summary().Budget)
+}
+
+// TestOpenCassette tests selecting recording or replay of model requests.
+func TestOpenCassette(t *testing.T) {
+	cassette, err := openCassette("", "")
+	require.NoError(t, err)
+	assert.Nil(t, cassette)
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	cassette, err = openCassette(path, "")
+	require.NoError(t, err)
+	assert.False(t, cassette.Replaying())
+
+	cassette, err = openCassette("", path)
+	require.NoError(t, err)
+	assert.True(t, cassette.Replaying())
+
+	_, err = openCassette(path, path)
+	assert.ErrorContains(t, err, "cannot be combined")
+}
+
+// TestParseVariables tests parsing of --var output filename variables.
+func TestParseVariables(t *testing.T) {
+	variables, err := parseVariables([]string{"project=payments", "team=core=platform"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"project": "payments", "team": "core=platform"}, variables)
+
+	_, err = parseVariables([]string{"project"})
+	assert.Error(t, err)
+}
+
+// TestLoadUserTemplates tests that --template-dir and the configured template directory
+// make user templates available to generation.
+func TestLoadUserTemplates(t *testing.T) {
+	templatesDir := t.TempDir()
+	templateDir := filepath.Join(templatesDir, "team-report")
+	require.NoError(t, os.MkdirAll(templateDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "template.json"), []byte(`{"prompt": "Write the team report"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "team-report.html"), []byte(`<html><!-- data-field="title" --></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}}}`), 0644))
+
+	sourceFile := filepath.Join(t.TempDir(), "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes"), 0644))
+	dryRunOpts := generate.Options{
+		TemplateType: "team-report",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(t.TempDir(), "out.html"),
+		DryRun:       true,
+	}
+
+	t.Run("flag directory", func(t *testing.T) {
+		orchestrator := generate.NewOrchestrator(nil)
+		require.NoError(t, loadUserTemplates(orchestrator, templatesDir, "/nonexistent"))
+
+		_, err := orchestrator.Run(context.Background(), dryRunOpts)
+		assert.NoError(t, err)
+	})
+
+	t.Run("configured directory", func(t *testing.T) {
+		orchestrator := generate.NewOrchestrator(nil)
+		require.NoError(t, loadUserTemplates(orchestrator, "", templatesDir))
+
+		_, err := orchestrator.Run(context.Background(), dryRunOpts)
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing configured directory is skipped", func(t *testing.T) {
+		orchestrator := generate.NewOrchestrator(nil)
+		assert.NoError(t, loadUserTemplates(orchestrator, "", filepath.Join(templatesDir, "missing")))
+	})
+
+	t.Run("missing flag directory fails", func(t *testing.T) {
+		orchestrator := generate.NewOrchestrator(nil)
+		assert.Error(t, loadUserTemplates(orchestrator, filepath.Join(templatesDir, "missing"), ""))
+	})
+}
+
+func TestApplyProfileFlags(t *testing.T) {
+	t.Cleanup(func() {
+		model, baseURL, apiKey, temperature = "gpt-4", "", "", 0.7
+	})
+	newCommand := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "generate"}
+		cmd.Flags().StringVar(&model, "model", "gpt-4", "Model to use for generation")
+		cmd.Flags().StringVar(&baseURL, "base-url", "", "Base URL for OpenAI-compatible API")
+		cmd.Flags().StringVar(&apiKey, "api-key", "", "API key")
+		cmd.Flags().Float64Var(&temperature, "temperature", 0.7, "Temperature for model generation")
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+	quality := 0.2
+	cfg := &config.Config{Profiles: map[string]config.ProfileConfig{
+		"quality": {Model: "gpt-4o", BaseURL: "https://gateway.example.com/v1", APIKey: "sk-gateway", Temperature: &quality},
+	}}
+
+	// Without a profile in effect the flags keep their values
+	applyProfileFlags(newCommand(), cfg)
+	assert.Equal(t, "gpt-4", model)
+	assert.Equal(t, "", baseURL)
+
+	// The profile fills the flags not given, and flags given override it
+	require.NoError(t, cfg.ApplyProfile("quality"))
+	applyProfileFlags(newCommand("--model", "gpt-4o-mini"), cfg)
+	assert.Equal(t, "gpt-4o-mini", model)
+	assert.Equal(t, "https://gateway.example.com/v1", baseURL)
+	assert.Equal(t, "sk-gateway", apiKey)
+	assert.Equal(t, 0.2, temperature)
+}
+
+func TestNotifyCompletion_ReportsCost(t *testing.T) {
+	var events []notify.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event notify.Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+	}))
+	defer server.Close()
+	cfg := config.NotificationsConfig{Webhooks: []config.WebhookConfig{{URL: server.URL, Type: "generic"}}}
+
+	notifyCompletion(context.Background(), cfg, "architecture-vision", "out.html", nil, &ai.UsageSummary{
+		Models: []ai.ModelUsage{{Model: "gpt-4o", Cost: 0.0123, Priced: true}},
+		Cost:   0.0123,
+	}, nil, time.Second)
+	notifyCompletion(context.Background(), cfg, "architecture-vision", "out.html", nil, &ai.UsageSummary{
+		Models: []ai.ModelUsage{{Model: "gpt-4o", Cost: 0.5, Priced: true}, {Model: "house-model"}},
+		Cost:   0.5,
+	}, nil, time.Second)
+	notifyCompletion(context.Background(), cfg, "architecture-vision", "out.html", nil, nil, nil, time.Second)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, "$0.0123", events[0].Cost)
+	assert.Equal(t, "$0.5000 (without unpriced house-model)", events[1].Cost)
+	assert.Empty(t, events[2].Cost)
+}
+
+// TestGenerateCmd_ReplayWithoutAPIKey tests that a run replaying a cassette needs no
+// API key.
+func TestGenerateCmd_ReplayWithoutAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"title": "Payments"}`}}},
+		})
+	}))
+
+	tempDir := t.TempDir()
+	briefDir := filepath.Join(tempDir, "templates", "brief")
+	require.NoError(t, os.MkdirAll(briefDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(briefDir, "template.json"), []byte(`{"name": "brief", "prompt": "Write a title"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(briefDir, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}}, "required": ["title"]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(briefDir, "template.html"), []byte(`<h1><!-- data-field="title" --></h1>`), 0644))
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("Payments service"), 0644))
+	cassettePath := filepath.Join(tempDir, "cassette.yaml")
+
+	t.Setenv("HOME", tempDir)
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("DOCLOOM_API_KEY", "")
+	originalWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(tempDir))
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	resetGenerateFlags(t)
+
+	args := []string{"generate", "--type", "brief", "--source", sourceFile, "--template-dir", filepath.Join(tempDir, "templates"),
+		"--model", "test-model", "--base-url", server.URL + "/v1", "--force"}
+	rootCmd := GetRootCmd()
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append(args, "--out", filepath.Join(tempDir, "recorded.html"), "--api-key", "test-key", "--record", cassettePath))
+	require.NoError(t, rootCmd.Execute())
+	server.Close()
+
+	resetGenerateFlags(t)
+	replayedFile := filepath.Join(tempDir, "replayed.html")
+	rootCmd.SetArgs(append(args, "--out", replayedFile, "--replay", cassettePath))
+	require.NoError(t, rootCmd.Execute())
+
+	html, err := os.ReadFile(replayedFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Payments")
+}
+
+// resetGenerateFlags restores the generate flags, which earlier commands of the shared
+// root command may have set, to their defaults now and when the test ends.
+func resetGenerateFlags(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		generateCmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			if slice, ok := flag.Value.(pflag.SliceValue); ok {
+				_ = slice.Replace(nil)
+			} else {
+				_ = flag.Value.Set(flag.DefValue)
+			}
+			flag.Changed = false
+		})
+	}
+	reset()
+	t.Cleanup(reset)
+}
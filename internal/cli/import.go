@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	importConfigFile  string
+	importType        string
+	importFields      string
+	importOut         string
+	importTemplateDir string
+	importVars        []string
+	importForce       bool
+	importProvenance  bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Validate and render document fields produced outside DocLoom",
+	Long: `Validate a JSON file of document fields, for example drafted in another tool from a
+context pack, against the template's schema and consistency rules, and render it like a
+generated document: the HTML, its JSON sidecar and a run report. Every field that does
+not conform is listed and nothing is written.
+
+Example:
+  docloom import --type architecture-vision --fields external.json --out doc.html`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(importConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyOutputModes(cfg)
+		applyTrustPolicy(cfg)
+		variables, err := parseVariables(importVars)
+		if err != nil {
+			return err
+		}
+
+		orchestrator := generate.NewOrchestrator(nil)
+		if err := loadUserTemplates(orchestrator, importTemplateDir, cfg.TemplateDir); err != nil {
+			return err
+		}
+		result, err := orchestrator.Import(generate.ImportOptions{
+			TemplateType: importType,
+			FieldsFile:   importFields,
+			OutputFile:   importOut,
+			Force:        importForce,
+			Provenance:   importProvenance,
+			Variables:    variables,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("import.rendered", importFields, result.OutputFile))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVarP(&importType, "type", "t", "", "Template type the fields belong to (required)")
+	importCmd.Flags().StringVar(&importFields, "fields", "", "JSON file with the document fields (required)")
+	importCmd.Flags().StringVarP(&importOut, "out", "o", "", "Output file path (required)")
+	importCmd.Flags().StringVar(&importTemplateDir, "template-dir", "", "Directory of user templates; overrides built-ins with the same name (defaults to config template_dir)")
+	importCmd.Flags().StringSliceVar(&importVars, "var", []string{}, "Variable for output filename patterns such as {{project}} (format: key=value, can be specified multiple times)")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite existing output files")
+	importCmd.Flags().BoolVar(&importProvenance, "provenance", false, "Annotate rendered fields with their field path and run ID")
+	importCmd.Flags().StringVar(&importConfigFile, "config", "", "Config file path")
+
+	_ = importCmd.MarkFlagRequired("type")
+	_ = importCmd.MarkFlagRequired("fields")
+	_ = importCmd.MarkFlagRequired("out")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// verbosityLevel maps the number of -v flags to a log level.
+func verbosityLevel(count int) zerolog.Level {
+	switch {
+	case count >= 2:
+		return zerolog.TraceLevel
+	case count == 1:
+		return zerolog.DebugLevel
+	default:
+		return zerolog.InfoLevel
+	}
+}
+
+// parseLogFilter parses a per-module filter such as "ai=debug,agent=trace" into the
+// log level of each module.
+func parseLogFilter(spec string) (map[string]zerolog.Level, error) {
+	modules := make(map[string]zerolog.Level)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		module, levelName, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(module) == "" {
+			return nil, fmt.Errorf("invalid log filter %q (expected module=level)", entry)
+		}
+		level, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(levelName)))
+		if err != nil || level == zerolog.NoLevel {
+			return nil, fmt.Errorf("invalid log level %q for module %s", levelName, module)
+		}
+		modules[strings.TrimSpace(module)] = level
+	}
+	return modules, nil
+}
+
+// minLevel returns the most verbose of the base level and the module levels. It is
+// used as the global level so that events for verbose modules are not dropped before
+// reaching the filter.
+func minLevel(base zerolog.Level, modules map[string]zerolog.Level) zerolog.Level {
+	level := base
+	for _, moduleLevel := range modules {
+		if moduleLevel < level {
+			level = moduleLevel
+		}
+	}
+	return level
+}
+
+// moduleFilterWriter drops log events below the level configured for the module that
+// emitted them. The module is the "module" field when present, otherwise the package
+// directory below internal/ taken from the caller (e.g. "ai", "agent", "generate").
+type moduleFilterWriter struct {
+	out     io.Writer
+	modules map[string]zerolog.Level
+	base    zerolog.Level
+}
+
+// Write implements io.Writer for events without a level.
+func (w moduleFilterWriter) Write(p []byte) (int, error) {
+	return w.out.Write(p)
+}
+
+// WriteLevel implements zerolog.LevelWriter.
+func (w moduleFilterWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	threshold := w.base
+	if moduleLevel, ok := w.modules[eventModule(p)]; ok {
+		threshold = moduleLevel
+	}
+	if level < threshold {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// eventModule returns the module an encoded log event belongs to.
+func eventModule(p []byte) string {
+	var event struct {
+		Module string `json:"module"`
+		Caller string `json:"caller"`
+	}
+	if err := json.Unmarshal(p, &event); err != nil {
+		return ""
+	}
+	if event.Module != "" {
+		return event.Module
+	}
+	return callerModule(event.Caller)
+}
+
+// callerModule derives the module from a caller such as
+// "/src/docloom/internal/generate/orchestrator.go:120".
+func callerModule(caller string) string {
+	dir := filepath.ToSlash(filepath.Dir(caller))
+	if idx := strings.LastIndex(dir, "/internal/"); idx >= 0 {
+		module, _, _ := strings.Cut(dir[idx+len("/internal/"):], "/")
+		return module
+	}
+	return filepath.Base(dir)
+}
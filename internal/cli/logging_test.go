@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerbosityLevel(t *testing.T) {
+	assert.Equal(t, zerolog.InfoLevel, verbosityLevel(0))
+	assert.Equal(t, zerolog.DebugLevel, verbosityLevel(1))
+	assert.Equal(t, zerolog.TraceLevel, verbosityLevel(2))
+	assert.Equal(t, zerolog.TraceLevel, verbosityLevel(5))
+}
+
+func TestParseLogFilter(t *testing.T) {
+	modules, err := parseLogFilter("ai=debug, agent=TRACE,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]zerolog.Level{"ai": zerolog.DebugLevel, "agent": zerolog.TraceLevel}, modules)
+	assert.Equal(t, zerolog.TraceLevel, minLevel(zerolog.InfoLevel, modules))
+
+	_, err = parseLogFilter("ai")
+	assert.Error(t, err)
+	_, err = parseLogFilter("ai=loud")
+	assert.Error(t, err)
+}
+
+func TestModuleFilterWriter(t *testing.T) {
+	previous := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	t.Cleanup(func() { zerolog.SetGlobalLevel(previous) })
+
+	var out bytes.Buffer
+	writer := moduleFilterWriter{
+		out:     &out,
+		base:    zerolog.InfoLevel,
+		modules: map[string]zerolog.Level{"generate": zerolog.DebugLevel, "ingest": zerolog.WarnLevel},
+	}
+	logger := zerolog.New(writer)
+
+	logger.Debug().Str("caller", "/src/docloom/internal/generate/orchestrator.go:120").Msg("repair attempt")
+	logger.Debug().Str("caller", "/src/docloom/internal/ai/client.go:80").Msg("request parameters")
+	logger.Info().Str("caller", "/src/docloom/internal/ingest/ingester.go:40").Msg("ingested file")
+	logger.Info().Str("module", "agent").Msg("agent started")
+
+	output := out.String()
+	assert.Contains(t, output, "repair attempt", "debug events of a debug-filtered module are kept")
+	assert.NotContains(t, output, "request parameters", "debug events of other modules use the base level")
+	assert.NotContains(t, output, "ingested file", "modules can be quieter than the base level")
+	assert.Contains(t, output, "agent started")
+}
+
+func TestCallerModule(t *testing.T) {
+	assert.Equal(t, "agent", callerModule("/home/u/docloom/internal/agent/executor.go:210"))
+	assert.Equal(t, "generate", callerModule("internal/generate/analysis.go:12"))
+	assert.Equal(t, "docloom", callerModule("/home/u/cmd/docloom/main.go:8"))
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	packConfigFile   string
+	packType         string
+	packSources      []string
+	packOut          string
+	packTemplateDir  string
+	packSourceErrors string
+	packForce        bool
+)
+
+// packCmd represents the pack command
+var packCmd = &cobra.Command{
+	Use:   "pack",
+	Short: "Export the assembled generation context for use in other tools",
+	Long: `Assemble the context a generation would send to the model, the complete prompt,
+the template's instructions and schema and the text of every ingested source, and
+write it to a gzip-compressed tar archive without calling a model. The archive layout
+is documented in docs/guides/context-packs.md.
+
+Example:
+  docloom pack --type architecture-vision --source ./docs --out context.tar.gz`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(packConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyOutputModes(cfg)
+		applyTrustPolicy(cfg)
+
+		orchestrator := generate.NewOrchestrator(nil)
+		if err := loadUserTemplates(orchestrator, packTemplateDir, cfg.TemplateDir); err != nil {
+			return err
+		}
+		if err := loadPromptOverrides(orchestrator, cfg); err != nil {
+			return err
+		}
+		orchestrator.SetIngestCache(newIngestCache(cfg))
+		filter, err := newSourceFilter(cfg, nil, nil)
+		if err != nil {
+			return err
+		}
+		orchestrator.SetSourceFilter(filter)
+
+		manifest, err := orchestrator.Pack(generate.PackOptions{
+			TemplateType: packType,
+			Sources:      packSources,
+			OutputFile:   packOut,
+			SourceErrors: packSourceErrors,
+			Force:        packForce,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("pack.written", packOut, len(manifest.Files), manifest.EstimatedTokens))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packCmd)
+
+	packCmd.Flags().StringVarP(&packType, "type", "t", "", "Template type whose context to export (required)")
+	packCmd.Flags().StringSliceVarP(&packSources, "source", "s", []string{}, "Source paths (files or directories), optionally labeled as label=path")
+	packCmd.Flags().StringVarP(&packOut, "out", "o", "", "Output archive path, e.g. context.tar.gz (required)")
+	packCmd.Flags().StringVar(&packTemplateDir, "template-dir", "", "Directory of user templates; overrides built-ins with the same name (defaults to config template_dir)")
+	packCmd.Flags().StringVar(&packSourceErrors, "source-errors", "warn", "Handling of sources that cannot be read: fail, warn (skip with a warning) or ignore")
+	packCmd.Flags().BoolVar(&packForce, "force", false, "Overwrite an existing archive")
+	packCmd.Flags().StringVar(&packConfigFile, "config", "", "Config file path")
+
+	_ = packCmd.MarkFlagRequired("type")
+	_ = packCmd.MarkFlagRequired("out")
+}
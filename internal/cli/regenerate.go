@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	regenerateConfigFile  string
+	regenerateFrom        string
+	regenerateFields      []string
+	regenerateType        string
+	regenerateSources     []string
+	regenerateOut         string
+	regenerateModel       string
+	regenerateTemplateDir string
+	regenerateMaxRepairs  int
+	regenerateForce       bool
+	regenerateProvenance  bool
+)
+
+// regenerateCmd represents the regenerate command
+var regenerateCmd = &cobra.Command{
+	Use:   "regenerate",
+	Short: "Regenerate selected fields of an existing document",
+	Long: `Reload the JSON sidecar of a generated document and ask the model for new values of
+the named fields only, with the sources and the document's other fields as context. The
+document is validated and repaired like a new one, then rendered again with its JSON
+sidecar and a run report. The template and sources default to those in the document's
+run report, and the document is replaced unless --out names another file.
+
+Example:
+  docloom regenerate --from output.json --fields summary,risks`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(regenerateConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyOutputModes(cfg)
+		applyTrustPolicy(cfg)
+		if regenerateModel != "" {
+			cfg.Model = regenerateModel
+		}
+		if regenerateTemplateDir != "" {
+			cfg.TemplateDir = regenerateTemplateDir
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		aiClient, err := newConfiguredAIClient(ctx, cfg, nil)
+		if err != nil {
+			return err
+		}
+		orchestrator, err := newConfiguredOrchestrator(cfg, aiClient)
+		if err != nil {
+			return err
+		}
+		opts := generate.RegenerateOptions{
+			FromFile:     regenerateFrom,
+			Fields:       regenerateFields,
+			TemplateType: regenerateType,
+			Sources:      regenerateSources,
+			OutputFile:   regenerateOut,
+			Force:        regenerateForce,
+			Model:        cfg.Model,
+			Temperature:  float32(cfg.Temperature),
+			MaxRetries:   cfg.MaxRetries,
+			MaxRepairs:   regenerateMaxRepairs,
+			Provenance:   regenerateProvenance,
+		}
+		if cfg.Seed > 0 {
+			opts.Seed = &cfg.Seed
+		}
+		result, err := orchestrator.Regenerate(ctx, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("regenerate.rendered", strings.Join(result.Report.Regenerated, ", "), result.OutputFile))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(regenerateCmd)
+
+	regenerateCmd.Flags().StringVar(&regenerateFrom, "from", "", "JSON sidecar of the document to regenerate (required)")
+	regenerateCmd.Flags().StringSliceVar(&regenerateFields, "fields", []string{}, "Comma-separated top-level fields to regenerate (required)")
+	regenerateCmd.Flags().StringVarP(&regenerateType, "type", "t", "", "Template type of the document (defaults to the one in its run report)")
+	regenerateCmd.Flags().StringSliceVarP(&regenerateSources, "source", "s", []string{}, "Source paths (defaults to those in the document's run report)")
+	regenerateCmd.Flags().StringVarP(&regenerateOut, "out", "o", "", "Output file path (defaults to the regenerated document)")
+	regenerateCmd.Flags().StringVar(&regenerateModel, "model", "", "Model to use (defaults to config model)")
+	regenerateCmd.Flags().StringVar(&regenerateTemplateDir, "template-dir", "", "Directory of user templates; overrides built-ins with the same name (defaults to config template_dir)")
+	regenerateCmd.Flags().IntVar(&regenerateMaxRepairs, "max-repairs", 3, "Maximum number of repair attempts")
+	regenerateCmd.Flags().BoolVar(&regenerateForce, "force", false, "Overwrite an existing document named by --out")
+	regenerateCmd.Flags().BoolVar(&regenerateProvenance, "provenance", false, "Annotate rendered fields with their field path and run ID")
+	regenerateCmd.Flags().StringVar(&regenerateConfigFile, "config", "", "Config file path")
+
+	_ = regenerateCmd.MarkFlagRequired("from")
+	_ = regenerateCmd.MarkFlagRequired("fields")
+}
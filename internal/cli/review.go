@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+// errReviewQuit is returned when the reviewer quits without writing the document.
+var errReviewQuit = errors.New("quit by the reviewer")
+
+// terminalReviewer reviews generated documents on a terminal, one field at a time:
+// each field can be accepted, edited in $VISUAL or $EDITOR, or regenerated by the
+// model with optional instructions.
+type terminalReviewer struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// edit opens text in an editor and returns the edited text
+	edit func(text string) (string, error)
+}
+
+// newTerminalReviewer creates a reviewer reading answers from in and writing to out.
+func newTerminalReviewer(in io.Reader, out io.Writer) *terminalReviewer {
+	return &terminalReviewer{in: bufio.NewReader(in), out: out, edit: editInEditor}
+}
+
+// isTerminal reports whether f is a terminal, which interactive review requires.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Review implements generate.Reviewer. Fields are reviewed in order until the
+// document validates; an invalid document is reviewed again from the start.
+func (r *terminalReviewer) Review(ctx context.Context, review *generate.Review) error {
+	for {
+		acceptAll := false
+		for i, field := range review.Fields {
+			if acceptAll {
+				break
+			}
+			var err error
+			acceptAll, err = r.reviewField(ctx, review, field, i+1, len(review.Fields))
+			if err != nil {
+				return err
+			}
+		}
+		err := review.Validate()
+		if err == nil {
+			return nil
+		}
+		fmt.Fprintln(r.out, i18n.T("review.invalid", err))
+	}
+}
+
+// reviewField asks what to do with a field until it is accepted, reporting whether
+// the remaining fields are accepted as well.
+func (r *terminalReviewer) reviewField(ctx context.Context, review *generate.Review, field string, n, total int) (bool, error) {
+	for {
+		fmt.Fprintf(r.out, "\n%s\n%s\n", i18n.T("review.field", n, total, field), formatValue(review.Value(field)))
+		fmt.Fprint(r.out, i18n.T("review.choices"))
+		answer, err := r.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch answer {
+		case "", "a":
+			return false, nil
+		case "A":
+			return true, nil
+		case "q":
+			return false, errReviewQuit
+		case "e":
+			value, err := r.editValue(review.Value(field))
+			if err != nil {
+				fmt.Fprintln(r.out, i18n.T("review.edit_failed", err))
+				continue
+			}
+			review.Set(field, value)
+		case "r":
+			fmt.Fprint(r.out, i18n.T("review.instructions"))
+			instructions, err := r.readLine()
+			if err != nil {
+				return false, err
+			}
+			if _, err := review.Regenerate(ctx, field, instructions); err != nil {
+				fmt.Fprintln(r.out, i18n.T("review.regenerate_failed", err))
+			}
+		default:
+			fmt.Fprintln(r.out, i18n.T("review.unknown_choice", answer))
+		}
+	}
+}
+
+// readLine reads an answer; the end of input quits the review.
+func (r *terminalReviewer) readLine() (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+		if line == "" {
+			return "", errReviewQuit
+		}
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// editValue edits a value in the editor: strings as plain text, other values as JSON.
+func (r *terminalReviewer) editValue(value interface{}) (interface{}, error) {
+	if text, ok := value.(string); ok {
+		edited, err := r.edit(text)
+		if err != nil {
+			return nil, err
+		}
+		return strings.TrimRight(edited, "\n"), nil
+	}
+	edited, err := r.edit(formatValue(value) + "\n")
+	if err != nil {
+		return nil, err
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(edited), &parsed); err != nil {
+		return nil, fmt.Errorf("edited value is not valid JSON: %w", err)
+	}
+	return parsed, nil
+}
+
+// formatValue shows a value: strings as they are, other values as indented JSON.
+func formatValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// editInEditor opens text in $VISUAL, $EDITOR or vi and returns the saved text.
+func editInEditor(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	file, err := os.CreateTemp("", "docloom-review-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file to edit: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write file to edit: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write file to edit: %w", err)
+	}
+
+	// The editor command may carry arguments, as in EDITOR="code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], file.Name())...) // #nosec G204 -- the editor is chosen by the user
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	return string(edited), nil
+}
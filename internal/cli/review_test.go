@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/generate"
+)
+
+// queuedClient returns its responses in order.
+type queuedClient struct {
+	responses []string
+}
+
+func (c *queuedClient) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+// runReview generates a two-field document, reviewing it with answers typed on a
+// terminal.
+func runReview(t *testing.T, answers string, edit func(string) (string, error), responses ...string) (*generate.Result, string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	templateDir := filepath.Join(dir, "templates", "review")
+	require.NoError(t, os.MkdirAll(templateDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "template.json"), []byte(`{"name": "review", "prompt": "Describe the ledger"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "schema.json"), []byte(`{"type":"object","properties":{"title":{"type":"string"},"owners":{"type":"array","items":{"type":"string"}}},"required":["title","owners"]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "template.html"), []byte(`<html><body><!-- data-field="title" --><!-- data-field="owners" --></body></html>`), 0644))
+	source := filepath.Join(dir, "notes.md")
+	require.NoError(t, os.WriteFile(source, []byte("The ledger team owns balances."), 0644))
+
+	orchestrator := generate.NewOrchestrator(&queuedClient{responses: responses})
+	require.NoError(t, orchestrator.LoadTemplates(filepath.Join(dir, "templates")))
+	var out bytes.Buffer
+	reviewer := newTerminalReviewer(strings.NewReader(answers), &out)
+	reviewer.edit = edit
+	orchestrator.SetReviewer(reviewer)
+
+	result, err := orchestrator.Run(context.Background(), generate.Options{
+		TemplateType: "review",
+		Sources:      []string{source},
+		OutputFile:   filepath.Join(dir, "out.html"),
+		APIKey:       "test-key",
+	})
+	return result, out.String(), err
+}
+
+func TestTerminalReviewer(t *testing.T) {
+	edit := func(text string) (string, error) {
+		assert.Equal(t, "Ledger", text, "strings are edited as plain text")
+		return "Ledger Service\n", nil
+	}
+	result, out, err := runReview(t, "e\na\nr\nList the teams\na\n", edit,
+		`{"title": "Ledger", "owners": ["Unknown"]}`,
+		`{"value": ["Ledger team"]}`,
+	)
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "[1/2] title\nLedger\n")
+	assert.Contains(t, out, "[1/2] title\nLedger Service\n")
+	assert.Contains(t, out, "[2/2] owners\n[\n  \"Unknown\"\n]")
+	assert.Equal(t, "Ledger Service", result.Fields["title"])
+	assert.Equal(t, []interface{}{"Ledger team"}, result.Fields["owners"])
+	assert.Equal(t, []string{"title"}, result.Report.Review.Edited)
+	assert.Equal(t, []string{"owners"}, result.Report.Review.Regenerated)
+}
+
+func TestTerminalReviewer_InvalidEditIsReviewedAgain(t *testing.T) {
+	edits := []string{"{\"not\": \"an array\"}\n", "[\"Ledger team\"]\n"}
+	edit := func(text string) (string, error) {
+		edited := edits[0]
+		edits = edits[1:]
+		return edited, nil
+	}
+	result, out, err := runReview(t, "a\ne\na\na\ne\na\n", edit, `{"title": "Ledger", "owners": ["Unknown"]}`)
+	require.NoError(t, err)
+	assert.Contains(t, out, "The reviewed document is invalid, review it again")
+	assert.Equal(t, []interface{}{"Ledger team"}, result.Fields["owners"])
+}
+
+func TestTerminalReviewer_Quit(t *testing.T) {
+	_, _, err := runReview(t, "q\n", nil, `{"title": "Ledger", "owners": ["Unknown"]}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "review aborted: quit by the reviewer")
+}
@@ -6,11 +6,15 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/i18n"
 )
 
 var (
-	verbose bool
-	logger  zerolog.Logger
+	verbose   int
+	logFilter string
+	language  string
+	logger    zerolog.Logger
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -21,23 +25,33 @@ var rootCmd = &cobra.Command{
 by combining structured templates with source materials and model-assisted content. 
 The aim is consistent, branded, and reviewable outputs that you can print, share, 
 and iterate on quickly.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Configure logging based on verbose flag
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Configure logging based on the verbosity level and module filters
 		zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 
-		if verbose {
-			zerolog.SetGlobalLevel(zerolog.DebugLevel)
-		} else {
-			zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		modules, err := parseLogFilter(logFilter)
+		if err != nil {
+			return err
 		}
+		base := verbosityLevel(verbose)
+		zerolog.SetGlobalLevel(minLevel(base, modules))
 
-		// Human-readable console output
-		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
+		// Human-readable console output; the caller identifies the module for
+		// filtering and is only printed at trace level
+		console := zerolog.ConsoleWriter{Out: os.Stderr}
+		if base > zerolog.TraceLevel {
+			console.PartsExclude = []string{zerolog.CallerFieldName}
+		}
+		logger = zerolog.New(moduleFilterWriter{out: console, modules: modules, base: base}).
 			With().
 			Timestamp().
+			Caller().
 			Logger()
 
 		log.Logger = logger
+
+		// Select the language of user-facing messages from --lang or the locale
+		return i18n.SetLanguage(i18n.Detect(language))
 	},
 }
 
@@ -48,7 +62,9 @@ func Execute() error {
 
 func init() {
 	// Persistent flags available to all commands
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().CountVarP(&verbose, "verbose", "v", "Increase log verbosity (-v debug, -vv trace)")
+	rootCmd.PersistentFlags().StringVar(&logFilter, "log-filter", "", "Per-module log levels, e.g. ai=debug,agent=trace")
+	rootCmd.PersistentFlags().StringVar(&language, "lang", "", "Language of CLI messages (en, de, ja); defaults to LANG")
 }
 
 // GetRootCmd returns the root command for testing
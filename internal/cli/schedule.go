@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/schedule"
+)
+
+var (
+	scheduleConfigFile string
+	scheduleHistoryMax int
+)
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run scheduled document generation",
+	Long: `Run document generation on a schedule defined in the configuration file.
+
+Schedules use five-field cron expressions (minute hour day-of-month month day-of-week)
+or the shorthands @hourly, @daily, @weekly, @monthly and @yearly:
+
+  schedules:
+    - name: weekly-architecture
+      cron: "0 6 * * 1"
+      type: architecture-vision
+      sources: [./docs]
+      out: reports/architecture-status.html`,
+}
+
+// scheduleListCmd represents the schedule list command
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured schedules and their next run",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, service, err := loadScheduleService(cmd.Context(), false)
+		if err != nil {
+			return err
+		}
+
+		jobs := service.Jobs()
+		if len(jobs) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("schedule.none"))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCRON\tTEMPLATE\tOUTPUT\tNEXT RUN")
+		now := time.Now()
+		for _, job := range jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				job.Config.Name, job.Cron, job.Config.Type, job.Config.Out,
+				job.Cron.Next(now).Format(time.RFC3339))
+		}
+		return w.Flush()
+	},
+}
+
+// scheduleRunCmd represents the schedule run command
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Start the long-running scheduler",
+	Long: `Start the scheduler and keep running until interrupted. Due schedules are
+executed at the start of each minute and every run is recorded in the schedule history.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, service, err := loadScheduleService(cmd.Context(), true)
+		if err != nil {
+			return err
+		}
+		if len(service.Jobs()) == 0 {
+			return fmt.Errorf("no schedules configured")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return service.Start(ctx)
+	},
+}
+
+// scheduleTriggerCmd represents the schedule trigger command
+var scheduleTriggerCmd = &cobra.Command{
+	Use:   "trigger <name>",
+	Short: "Run a single schedule immediately",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, service, err := loadScheduleService(cmd.Context(), true)
+		if err != nil {
+			return err
+		}
+
+		for _, job := range service.Jobs() {
+			if job.Config.Name != args[0] {
+				continue
+			}
+			entry := service.RunJob(context.Background(), job)
+			if entry.Status != schedule.StatusSuccess {
+				return fmt.Errorf("schedule %s failed: %s", entry.Name, entry.Error)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("schedule.completed", entry.Name, entry.Output))
+			return nil
+		}
+		return fmt.Errorf("schedule '%s' not found", args[0])
+	},
+}
+
+// scheduleHistoryCmd represents the schedule history command
+var scheduleHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the history of scheduled runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(scheduleConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		entries, err := schedule.NewHistory(cfg.ScheduleHistory).Load()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("schedule.no_runs"))
+			return nil
+		}
+		if scheduleHistoryMax > 0 && len(entries) > scheduleHistoryMax {
+			entries = entries[len(entries)-scheduleHistoryMax:]
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STARTED\tNAME\tSTATUS\tDURATION\tOUTPUT")
+		for _, entry := range entries {
+			status := entry.Status
+			if entry.Error != "" {
+				status += " (" + entry.Error + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				entry.Started.Format(time.RFC3339), entry.Name, status,
+				entry.Finished.Sub(entry.Started).Round(time.Second), entry.Output)
+		}
+		return w.Flush()
+	},
+}
+
+// loadScheduleService loads configuration and builds the schedule service. With
+// resolveKey, the API key is resolved once for all jobs, so that api_key_cmd or the
+// keychain is not queried again by every run.
+func loadScheduleService(ctx context.Context, resolveKey bool) (*config.Config, *schedule.Service, error) {
+	cfg, err := config.Load(scheduleConfigFile, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	applyOutputModes(cfg)
+	applyTrustPolicy(cfg)
+	if resolveKey {
+		if err := cfg.ResolveAPIKey(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	service, err := schedule.NewService(cfg.Schedules, runScheduledJob(cfg), schedule.NewHistory(cfg.ScheduleHistory))
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, service, nil
+}
+
+// runScheduledJob returns a RunFunc that generates a scheduled document using the
+// model settings from the configuration. Scheduled outputs are always overwritten.
+// Jobs may run concurrently, so each gets its own copy of the configuration.
+func runScheduledJob(shared *config.Config) schedule.RunFunc {
+	return func(ctx context.Context, job config.ScheduleConfig) error {
+		copied := *shared
+		cfg := &copied
+		startTime := time.Now()
+		meter := newConfiguredMeter(cfg, 0)
+		result, err := generateFromConfig(ai.WithMeter(ctx, meter), cfg, job.Type, job.Sources, job.Ref, job.Out, job.Vars)
+		notifyCompletion(ctx, cfg.Notifications, job.Type, job.Out, result, meter.Summary(), err, time.Since(startTime))
+		if err != nil {
+			return err
+		}
+		recordDocument(cfg.DocsRegistry, result, job.Sources, cfg.Model, cfg.Owner)
+		return nil
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	scheduleCmd.AddCommand(scheduleTriggerCmd)
+	scheduleCmd.AddCommand(scheduleHistoryCmd)
+
+	scheduleCmd.PersistentFlags().StringVar(&scheduleConfigFile, "config", "", "Config file path containing schedule definitions")
+	scheduleHistoryCmd.Flags().IntVar(&scheduleHistoryMax, "limit", 20, "Maximum number of history entries to show (0 for all)")
+}
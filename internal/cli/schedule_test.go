@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScheduleService_ConcurrentJobsShareResolvedKey runs two due jobs at once (run
+// with -race) and checks that api_key_cmd runs once for both.
+func TestScheduleService_ConcurrentJobsShareResolvedKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer cmd-key", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": `{"title": "Payments"}`}}},
+		})
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("DOCLOOM_API_KEY", "")
+	briefDir := filepath.Join(tempDir, "templates", "brief")
+	require.NoError(t, os.MkdirAll(briefDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(briefDir, "template.json"), []byte(`{"name": "brief", "prompt": "Write a title"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(briefDir, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}}, "required": ["title"]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(briefDir, "template.html"), []byte(`<h1><!-- data-field="title" --></h1>`), 0644))
+	sourceDir := filepath.Join(tempDir, "docs")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "notes.md"), []byte("Payments service"), 0644))
+
+	calls := filepath.Join(tempDir, "calls")
+	configPath := filepath.Join(tempDir, "docloom.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`base_url: `+server.URL+`/v1
+api_key_cmd: echo called >> `+calls+` && echo cmd-key
+model: test-model
+template_dir: `+filepath.Join(tempDir, "templates")+`
+docs_registry: ""
+schedule_history: `+filepath.Join(tempDir, "history.jsonl")+`
+schedules:
+  - {name: payments, cron: "* * * * *", type: brief, sources: [`+sourceDir+`], out: `+filepath.Join(tempDir, "out", "payments.html")+`}
+  - {name: ledger, cron: "* * * * *", type: brief, sources: [`+sourceDir+`], out: `+filepath.Join(tempDir, "out", "ledger.html")+`}
+`), 0644))
+	scheduleConfigFile = configPath
+	t.Cleanup(func() { scheduleConfigFile = "" })
+
+	_, service, err := loadScheduleService(context.Background(), true)
+	require.NoError(t, err)
+	service.RunDue(context.Background(), time.Now().Truncate(time.Minute))
+	service.Wait()
+
+	assert.FileExists(t, filepath.Join(tempDir, "out", "payments.html"))
+	assert.FileExists(t, filepath.Join(tempDir, "out", "ledger.html"))
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "called"))
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+var (
+	serveConfigFile  string
+	serveOutput      string
+	serveTemplate    string
+	serveTemplateDir string
+	serveAddr        string
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Preview a generated document, re-rendering on change",
+	Long: `Serve a generated document on localhost for preview in a browser. The document is
+rendered from its JSON sidecar (<name>.json) with its template, named in its run
+manifest or run report, or given with --type. Whenever the sidecar or a file of the
+template directory changes, the fields are validated against the template's schema
+and the page is re-rendered; open pages reload themselves. Errors are shown in the
+page instead of the document.
+
+The preview is rendered in memory: the output file is not changed and no AI requests
+are made. Use docloom import to write a document from an edited sidecar.
+
+Example:
+  docloom serve --out vision.html
+  docloom serve --out vision.html --template-dir ./templates --addr 127.0.0.1:9000`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(serveConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyTrustPolicy(cfg)
+		templateDir := serveTemplateDir
+		if templateDir == "" {
+			templateDir = cfg.TemplateDir
+		}
+
+		preview, err := newOutputPreview(serveOutput, serveTemplate, templateDir)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return servePreview(ctx, preview, serveAddr, cmd.OutOrStdout())
+	},
+}
+
+// newOutputPreview returns the preview of the JSON sidecar of output with its
+// template, templateType or the one recorded for the output, loaded from the built-in
+// templates and templateDir on every rebuild.
+func newOutputPreview(output, templateType, templateDir string) (*devPreview, error) {
+	fieldsPath := render.SidecarPath(output, ".json")
+	if _, err := os.Stat(fieldsPath); err != nil {
+		return nil, fmt.Errorf("JSON sidecar %s of %s not found; generate the document first", fieldsPath, output)
+	}
+
+	version := ""
+	if templateType == "" {
+		if manifest, err := generate.ReadManifest(generate.ManifestPath(output)); err == nil {
+			templateType, version = manifest.Template, manifest.TemplateVersion
+		} else if report, err := generate.ReportFor(output); err == nil {
+			templateType = report.Template
+		}
+	}
+	if templateType == "" {
+		return nil, fmt.Errorf("no run manifest or run report names the template of %s; pass it with --type", output)
+	}
+
+	watch := []string{fieldsPath}
+	if templateDir != "" {
+		if _, err := os.Stat(templateDir); err == nil {
+			watch = append(watch, templateDir)
+		} else {
+			templateDir = ""
+		}
+	}
+	return &devPreview{
+		name:       output,
+		watch:      watch,
+		fieldsPath: fieldsPath,
+		load: func() (*templates.Template, error) {
+			registry := templates.NewRegistry()
+			if err := registry.LoadDefaults(); err != nil {
+				return nil, err
+			}
+			if templateDir != "" {
+				if err := registry.LoadFromDirectory(templateDir); err != nil {
+					return nil, err
+				}
+			}
+			if version != "" {
+				if tmpl, err := registry.GetVersion(templateType, version); err == nil {
+					return tmpl, nil
+				}
+			}
+			return registry.Get(templateType)
+		},
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveConfigFile, "config", "", "Config file path")
+	serveCmd.Flags().StringVarP(&serveOutput, "out", "o", "", "Generated document to preview (required)")
+	serveCmd.Flags().StringVarP(&serveTemplate, "type", "t", "", "Template to render with (default: the template the document was generated with)")
+	serveCmd.Flags().StringVar(&serveTemplateDir, "template-dir", "", "Directory of user templates, watched for changes (defaults to config template_dir)")
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8765", "Address to serve the preview on")
+	_ = serveCmd.MarkFlagRequired("out")
+}
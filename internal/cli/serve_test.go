@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the output preview renders the sidecar with the recorded template and re-renders on template changes
+func TestOutputPreview_Refresh(t *testing.T) {
+	templateDir := t.TempDir()
+	dir := filepath.Join(templateDir, "runbook")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "template.json"), []byte(`{"name": "runbook", "prompt": "Write a runbook"}`), 0644))
+	htmlPath := filepath.Join(dir, "template.html")
+	require.NoError(t, os.WriteFile(htmlPath, []byte(`<html><body><h1><!-- data-field="title" --></h1></body></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}}, "required": ["title"]}`), 0644))
+
+	outDir := t.TempDir()
+	output := filepath.Join(outDir, "runbook.html")
+	require.NoError(t, os.WriteFile(filepath.Join(outDir, "runbook.json"), []byte(`{"title": "Payments runbook"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outDir, "runbook.report.json"), []byte(`{"template": "runbook"}`), 0644))
+
+	preview, err := newOutputPreview(output, "", templateDir)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.True(t, preview.refresh(&out))
+	assert.Contains(t, out.String(), "rendered runbook")
+	assert.Contains(t, fetchDevPreview(t, preview, "/"), "<h1>Payments runbook</h1>")
+
+	require.NoError(t, os.WriteFile(htmlPath, []byte(`<html><body><h2><!-- data-field="title" --></h2></body></html>`), 0644))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(htmlPath, later, later))
+	require.True(t, preview.refresh(&out))
+	assert.Contains(t, fetchDevPreview(t, preview, "/"), "<h2>Payments runbook</h2>")
+	assert.Equal(t, "2", fetchDevPreview(t, preview, devVersionPath))
+}
+
+func TestOutputPreview_Errors(t *testing.T) {
+	outDir := t.TempDir()
+	output := filepath.Join(outDir, "vision.html")
+
+	_, err := newOutputPreview(output, "", "")
+	assert.ErrorContains(t, err, "JSON sidecar")
+
+	require.NoError(t, os.WriteFile(filepath.Join(outDir, "vision.json"), []byte(`{}`), 0644))
+	_, err = newOutputPreview(output, "", "")
+	assert.ErrorContains(t, err, "--type")
+
+	_, err = newOutputPreview(output, "architecture-vision", "")
+	assert.NoError(t, err)
+}
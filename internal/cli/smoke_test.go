@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmokeCmd(t *testing.T) {
+	cmd := GetRootCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"smoke"})
+	require.NoError(t, cmd.Execute())
+
+	out := buf.String()
+	assert.Contains(t, out, "PASS  validation and repair")
+	assert.Contains(t, out, "PASS: DocLoom works on this machine")
+	assert.NotContains(t, out, "FAIL")
+	assert.Equal(t, 6, strings.Count(out, "PASS"))
+}
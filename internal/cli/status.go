@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/docregistry"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+var (
+	statusConfigFile string
+	statusRegistry   string
+	statusFix        bool
+	statusDetails    bool
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report generated documents whose sources have changed",
+	Long: `Compare each document in the registry against the current state of its sources
+and report which documents are stale (sources added, removed or modified) or missing.
+
+The command exits with an error while stale or missing documents remain, so it can be
+used as a documentation freshness check in CI. With --fix, stale and missing documents
+are regenerated using the model settings from the configuration file.
+
+Examples:
+  docloom status
+  docloom status --details
+  docloom status --fix --config docloom.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(statusConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		registryPath := cfg.DocsRegistry
+		if statusRegistry != "" {
+			registryPath = statusRegistry
+		}
+
+		entries, err := docregistry.New(registryPath).List()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("docs.none"))
+			return nil
+		}
+
+		statuses := make([]docregistry.Status, 0, len(entries))
+		for _, entry := range entries {
+			status, err := docregistry.Check(entry)
+			if err != nil {
+				return fmt.Errorf("failed to check %s: %w", entry.ID, err)
+			}
+			statuses = append(statuses, status)
+		}
+
+		printStatuses(cmd, statuses)
+
+		outdated := 0
+		for _, status := range statuses {
+			if status.State != docregistry.StateFresh {
+				outdated++
+			}
+		}
+		if outdated == 0 {
+			return nil
+		}
+		if !statusFix {
+			return fmt.Errorf("%d of %d documents are out of date (run 'docloom status --fix' to regenerate)", outdated, len(statuses))
+		}
+
+		return regenerateOutdated(cmd, cfg, registryPath, statuses)
+	},
+}
+
+// printStatuses prints one row per document, followed by changed files when requested.
+func printStatuses(cmd *cobra.Command, statuses []docregistry.Status) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tADDED\tREMOVED\tCHANGED\tOUTPUT")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n",
+			status.Entry.ID, status.State, len(status.Added), len(status.Removed), len(status.Changed), status.Entry.Output)
+	}
+	_ = w.Flush()
+
+	if !statusDetails {
+		return
+	}
+	for _, status := range statuses {
+		if status.State != docregistry.StateStale {
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\n%s:\n", status.Entry.ID)
+		for _, path := range status.Added {
+			fmt.Fprintf(cmd.OutOrStdout(), "  + %s\n", path)
+		}
+		for _, path := range status.Removed {
+			fmt.Fprintf(cmd.OutOrStdout(), "  - %s\n", path)
+		}
+		for _, path := range status.Changed {
+			fmt.Fprintf(cmd.OutOrStdout(), "  ~ %s\n", path)
+		}
+	}
+}
+
+// regenerateOutdated regenerates stale and missing documents and records them in the registry.
+func regenerateOutdated(cmd *cobra.Command, cfg *config.Config, registryPath string, statuses []docregistry.Status) error {
+	ctx := context.Background()
+	failed := 0
+	for _, status := range statuses {
+		if status.State == docregistry.StateFresh {
+			continue
+		}
+		entry := status.Entry
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("status.regenerating", entry.ID, entry.Template))
+
+		result, err := generateFromConfig(ctx, cfg, entry.Template, entry.Sources, "", entry.Output, nil)
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.T("status.failed", err))
+			failed++
+			continue
+		}
+		recordDocument(registryPath, result, entry.Sources, cfg.Model, entry.Owner)
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("status.updated", entry.Output))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to regenerate %d documents", failed)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringVar(&statusConfigFile, "config", "", "Config file path")
+	statusCmd.Flags().StringVar(&statusRegistry, "registry", "", "Document registry file (defaults to config docs_registry)")
+	statusCmd.Flags().BoolVar(&statusFix, "fix", false, "Regenerate stale and missing documents")
+	statusCmd.Flags().BoolVar(&statusDetails, "details", false, "List the added, removed and changed source files")
+}
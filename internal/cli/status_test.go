@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/generate"
+)
+
+func TestStatusCmd_ReportsStaleDocuments(t *testing.T) {
+	tempDir := t.TempDir()
+	registryPath := filepath.Join(tempDir, "docs-registry.json")
+	sourceDir := filepath.Join(tempDir, "docs")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	sourceFile := filepath.Join(sourceDir, "overview.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Overview"), 0600))
+	output := filepath.Join(tempDir, "arch.html")
+	require.NoError(t, os.WriteFile(output, []byte("<html></html>"), 0600))
+
+	recordDocument(registryPath, &generate.Result{Template: "architecture-vision", OutputFile: output}, []string{sourceDir}, "gpt-4", "")
+
+	run := func() (string, error) {
+		cmd := GetRootCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetErr(buf)
+		cmd.SetArgs([]string{"status", "--registry", registryPath, "--details"})
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+	t.Cleanup(func() { statusDetails = false })
+
+	out, err := run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "fresh")
+
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Overview v2"), 0600))
+	out, err = run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 1 documents are out of date")
+	assert.Contains(t, out, "stale")
+	assert.Contains(t, out, "~ "+filepath.ToSlash(sourceFile))
+}
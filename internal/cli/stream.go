@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+// streamRefresh limits how often the streaming progress line is redrawn.
+const streamRefresh = 100 * time.Millisecond
+
+// newStreamPrinter returns a progress function that keeps a single line on w updated
+// with the tokens and bytes received so far, ending it when the stream is done.
+func newStreamPrinter(w io.Writer) ai.ProgressFunc {
+	var last time.Time
+	return func(p ai.StreamProgress) {
+		if !p.Done && time.Since(last) < streamRefresh {
+			return
+		}
+		last = time.Now()
+		fmt.Fprintf(w, "\r%s", i18n.T("generate.streaming", p.Tokens, float64(p.Bytes)/1024, p.Elapsed.Seconds()))
+		if p.Done {
+			fmt.Fprintln(w)
+			last = time.Time{}
+		}
+	}
+}
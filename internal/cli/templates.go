@@ -1,9 +1,34 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+var (
+	templatesConfigFile string
+	templatesJSON       bool
+	updateFixtures      bool
+	searchIndexes       []string
+	searchJSON          bool
+	diffJSON            bool
+	describeJSON        bool
+	promptPreviewLines  int
 )
 
 // templatesCmd represents the templates command
@@ -19,36 +44,426 @@ var listCmd = &cobra.Command{
 	Short: "List available templates",
 	Long:  `List all available document templates that can be used with the generate command.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Placeholder list of templates
-		templates := []struct {
-			Name        string
-			Description string
-		}{
-			{
-				Name:        "architecture-vision",
-				Description: "Architecture Vision document template",
-			},
-			{
-				Name:        "technical-debt-summary",
-				Description: "Technical Debt Summary template",
-			},
-			{
-				Name:        "reference-architecture",
-				Description: "Reference Architecture template",
-			},
-		}
-
-		fmt.Println("Available templates:")
-		fmt.Println()
-		for _, tmpl := range templates {
-			fmt.Printf("  %s\n    %s\n\n", tmpl.Name, tmpl.Description)
+		cfg, err := config.Load(templatesConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyTrustPolicy(cfg)
+		infos, err := listTemplates(cfg.TemplateDir)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if templatesJSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(infos)
+		}
+
+		fmt.Fprintln(out, i18n.T("templates.available"))
+		fmt.Fprintln(out)
+		for _, tmpl := range infos {
+			fmt.Fprintf(out, "  %s\n    %s\n\n", tmpl.Name, tmpl.Description)
+		}
+
+		return nil
+	},
+}
+
+// templateInfo is the machine-readable description of a template printed by
+// templates list --json, from which tools can build generation forms.
+type templateInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Origin      string          `json:"origin"`
+	Output      string          `json:"output,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
+// listTemplates returns the built-in templates and those in the template directory,
+// sorted by name. A template directory that does not exist is skipped.
+func listTemplates(templateDir string) ([]templateInfo, error) {
+	registry := templates.NewRegistry()
+	if err := registry.LoadDefaults(); err != nil {
+		return nil, err
+	}
+	if templateDir != "" {
+		if _, err := os.Stat(templateDir); err == nil {
+			if err := registry.LoadFromDirectory(templateDir); err != nil {
+				return nil, fmt.Errorf("failed to load templates from %s: %w", templateDir, err)
+			}
+		}
+	}
+
+	names := registry.List()
+	sort.Strings(names)
+	infos := make([]templateInfo, 0, len(names))
+	for _, name := range names {
+		tmpl, err := registry.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, templateInfo{
+			Name:        tmpl.Name,
+			Description: tmpl.Description,
+			Origin:      registry.Origin(name),
+			Output:      tmpl.Output,
+			Schema:      tmpl.Schema,
+		})
+	}
+	return infos, nil
+}
+
+// describeTemplateCmd represents the templates describe command
+var describeTemplateCmd = &cobra.Command{
+	Use:   "describe <name>",
+	Short: "Show the fields, placeholders and prompt of a template",
+	Long: `Show what a template expects: the fields of its schema with their types,
+the data-field placeholders its HTML fills and a preview of its prompt. The template
+is given as a name, name@version or a template directory.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(templatesConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyTrustPolicy(cfg)
+		tmpl, err := resolveTemplateRef(args[0], cfg.TemplateDir)
+		if err != nil {
+			return err
+		}
+		description, err := templates.Describe(tmpl)
+		if err != nil {
+			return err
 		}
 
+		out := cmd.OutOrStdout()
+		if describeJSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(description)
+		}
+		printTemplateDescription(out, description, promptPreviewLines)
 		return nil
 	},
 }
 
+// printTemplateDescription writes a template description as text, showing at most
+// previewLines lines of the prompt (all when not positive).
+func printTemplateDescription(out io.Writer, description *templates.Description, previewLines int) {
+	fmt.Fprintln(out, i18n.T("templates.describe_header", templateName(description)))
+	if description.Description != "" {
+		fmt.Fprintf(out, "  %s\n", description.Description)
+	}
+	if description.Output != "" {
+		fmt.Fprintln(out, i18n.T("templates.describe_output", description.Output))
+	}
+	if description.ModelRequirements != nil {
+		fmt.Fprintln(out, i18n.T("templates.describe_models", description.ModelRequirements))
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, i18n.T("templates.diff_fields"))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, field := range description.Fields {
+		fmt.Fprintf(w, "  %s\t%s\n", field.Path, field.Signature)
+	}
+	_ = w.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, i18n.T("templates.diff_placeholders"))
+	unknown := make(map[string]bool, len(description.UnknownPlaceholders))
+	for _, placeholder := range description.UnknownPlaceholders {
+		unknown[placeholder] = true
+	}
+	for _, placeholder := range description.Placeholders {
+		if unknown[placeholder] {
+			fmt.Fprintf(out, "  %s %s\n", placeholder, i18n.T("templates.placeholder_unknown"))
+			continue
+		}
+		fmt.Fprintf(out, "  %s\n", placeholder)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, i18n.T("templates.diff_prompt"))
+	lines := strings.Split(strings.TrimSpace(description.Prompt), "\n")
+	shown := lines
+	if previewLines > 0 && len(lines) > previewLines {
+		shown = lines[:previewLines]
+	}
+	for _, line := range shown {
+		fmt.Fprintf(out, "  %s\n", line)
+	}
+	if more := len(lines) - len(shown); more > 0 {
+		fmt.Fprintln(out, i18n.T("templates.prompt_more", more))
+	}
+}
+
+// templateName names a described template as name@version, or just name when unversioned.
+func templateName(description *templates.Description) string {
+	if description.Version != "" {
+		return description.Name + "@" + description.Version
+	}
+	return description.Name
+}
+
+// validateTemplatesCmd represents the templates validate command
+var validateTemplatesCmd = &cobra.Command{
+	Use:   "validate [dir]",
+	Short: "Validate templates against their schemas and test fixtures",
+	Long: `Load the templates in a directory (default: the configured template directory),
+check that every data-field placeholder names a schema field and every schema field
+has a placeholder (fields marked "x-render": false excepted), and render each
+template's fixtures/fields.json, comparing the result with the golden
+fixtures/expected.html. Indentation, trailing whitespace, line endings and blank lines
+are ignored. Use --update to write the golden files from the current rendering.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := ""
+		if len(args) > 0 {
+			dir = args[0]
+		} else {
+			cfg, err := config.Load(templatesConfigFile, nil)
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			dir = cfg.TemplateDir
+		}
+		return validateTemplates(dir, updateFixtures)
+	},
+}
+
+// validateTemplates checks the placeholders of every template in dir against its
+// schema and the template against its fixtures, printing a line per problem or one
+// for a template without any. It fails when a template cannot be loaded, has
+// placeholders and schema fields that do not match or renders differently from the
+// golden output.
+func validateTemplates(dir string, update bool) error {
+	// Templates that load are still checked when others in the directory fail
+	registry := templates.NewRegistry()
+	loadErr := registry.LoadFromDirectory(dir)
+
+	names := registry.List()
+	sort.Strings(names)
+	failures := 0
+	for _, name := range names {
+		tmpl, err := registry.Get(name)
+		if err != nil {
+			return err
+		}
+		failed := false
+		var verification *templates.PlaceholderError
+		if err := templates.VerifyPlaceholders(tmpl); errors.As(err, &verification) {
+			failed = true
+			for _, placeholder := range verification.Unmapped {
+				fmt.Println(i18n.T("templates.placeholder_failed", name, placeholder))
+			}
+			for _, field := range verification.Unused {
+				fmt.Println(i18n.T("templates.unused_failed", name, field))
+			}
+		} else if err != nil {
+			failed = true
+			fmt.Println(i18n.T("templates.fixture_failed", name, err))
+		}
+		result, err := templates.CheckFixture(tmpl, registry.Origin(name), update)
+		switch {
+		case err != nil:
+			failed = true
+			fmt.Println(i18n.T("templates.fixture_failed", name, err))
+		case result.Missing:
+			fmt.Println(i18n.T("templates.fixture_missing", name, path.Join(templates.FixturesDir, templates.FixtureFieldsFile)))
+		case result.Updated:
+			fmt.Println(i18n.T("templates.fixture_updated", name, path.Join(templates.FixturesDir, templates.FixtureExpectedFile)))
+		case result.Diff != "":
+			failed = true
+			fmt.Println(i18n.T("templates.fixture_differs", name, result.Diff))
+		case !failed:
+			fmt.Println(i18n.T("templates.fixture_ok", name))
+		}
+		if failed {
+			failures++
+		}
+	}
+
+	if loadErr != nil {
+		return fmt.Errorf("failed to load templates from %s: %w", dir, loadErr)
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d template(s) failed validation", failures, len(names))
+	}
+	return nil
+}
+
+// searchTemplatesCmd represents the templates search command
+var searchTemplatesCmd = &cobra.Command{
+	Use:   "search [keyword]",
+	Short: "Search template indexes for shared templates",
+	Long: `Search the template indexes configured under template_indexes (and those given
+with --index) for templates whose name, description or tags contain the keyword.
+Without a keyword all indexed templates are listed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(templatesConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		keyword := ""
+		if len(args) > 0 {
+			keyword = args[0]
+		}
+
+		locations := append(append([]string{}, cfg.TemplateIndexes...), searchIndexes...)
+		matches, err := templates.NewIndexClient().Search(context.Background(), locations, keyword)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if searchJSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(matches)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintln(out, i18n.T("templates.search_none", keyword))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVERSION\tTAGS\tDESCRIPTION\tSOURCE")
+		for _, entry := range matches {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Name, entry.Version, strings.Join(entry.Tags, ","), entry.Description, entry.Source)
+		}
+		return w.Flush()
+	},
+}
+
+// diffTemplatesCmd represents the templates diff command
+var diffTemplatesCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Show what changes between two template versions",
+	Long: `Compare two versions of a template: schema fields added, removed or changed,
+prompt changes and HTML placeholders added or removed. Each template is given as
+name@version (the version declared in template.json of a template in the template
+directory), a template name, or a template directory:
+
+  docloom templates diff runbook@1.0.0 runbook@2.0.0
+  docloom templates diff ./templates/runbook-v1 ./templates/runbook-v2`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(templatesConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyTrustPolicy(cfg)
+		oldTmpl, err := resolveTemplateRef(args[0], cfg.TemplateDir)
+		if err != nil {
+			return err
+		}
+		newTmpl, err := resolveTemplateRef(args[1], cfg.TemplateDir)
+		if err != nil {
+			return err
+		}
+		diff, err := templates.Diff(oldTmpl, newTmpl)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if diffJSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(diff)
+		}
+		printTemplateDiff(out, diff)
+		return nil
+	},
+}
+
+// resolveTemplateRef loads a template given as a template directory, name@version
+// or name. Names are looked up among the built-in templates and those in templateDir.
+func resolveTemplateRef(ref, templateDir string) (*templates.Template, error) {
+	if _, err := os.Stat(filepath.Join(ref, "template.json")); err == nil {
+		return templates.LoadTemplateDir(ref)
+	}
+
+	registry := templates.NewRegistry()
+	if err := registry.LoadDefaults(); err != nil {
+		return nil, err
+	}
+	if templateDir != "" {
+		if _, err := os.Stat(templateDir); err == nil {
+			if err := registry.LoadFromDirectory(templateDir); err != nil {
+				return nil, fmt.Errorf("failed to load templates from %s: %w", templateDir, err)
+			}
+		}
+	}
+	if name, version, ok := strings.Cut(ref, "@"); ok {
+		return registry.GetVersion(name, version)
+	}
+	return registry.Get(ref)
+}
+
+// printTemplateDiff writes a template diff as text.
+func printTemplateDiff(out io.Writer, diff *templates.TemplateDiff) {
+	fmt.Fprintln(out, i18n.T("templates.diff_header", diff.Old, diff.New))
+	if diff.Empty() {
+		fmt.Fprintln(out, i18n.T("templates.diff_none"))
+		return
+	}
+
+	if len(diff.FieldsAdded)+len(diff.FieldsRemoved)+len(diff.FieldsChanged) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, i18n.T("templates.diff_fields"))
+		for _, field := range diff.FieldsAdded {
+			fmt.Fprintf(out, "  + %s\n", field)
+		}
+		for _, field := range diff.FieldsRemoved {
+			fmt.Fprintf(out, "  - %s\n", field)
+		}
+		for _, change := range diff.FieldsChanged {
+			fmt.Fprintf(out, "  ~ %s: %s -> %s\n", change.Field, change.Old, change.New)
+		}
+	}
+	if diff.PromptChanged {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, i18n.T("templates.diff_prompt"))
+		for _, line := range diff.PromptDiff {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+	if len(diff.PlaceholdersAdded)+len(diff.PlaceholdersRemoved) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, i18n.T("templates.diff_placeholders"))
+		for _, field := range diff.PlaceholdersAdded {
+			fmt.Fprintf(out, "  + %s\n", field)
+		}
+		for _, field := range diff.PlaceholdersRemoved {
+			fmt.Fprintf(out, "  - %s\n", field)
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(templatesCmd)
 	templatesCmd.AddCommand(listCmd)
+	templatesCmd.AddCommand(describeTemplateCmd)
+	templatesCmd.AddCommand(validateTemplatesCmd)
+	templatesCmd.AddCommand(searchTemplatesCmd)
+	templatesCmd.AddCommand(diffTemplatesCmd)
+	templatesCmd.AddCommand(devTemplatesCmd)
+
+	templatesCmd.PersistentFlags().StringVar(&templatesConfigFile, "config", "", "Config file path")
+
+	listCmd.Flags().BoolVar(&templatesJSON, "json", false, "Print the templates with their field schemas as JSON")
+
+	searchTemplatesCmd.Flags().StringSliceVar(&searchIndexes, "index", []string{}, "Template index URL or file to search in addition to template_indexes (can be specified multiple times)")
+	searchTemplatesCmd.Flags().BoolVar(&searchJSON, "json", false, "Print the matching index entries as JSON")
+	describeTemplateCmd.Flags().BoolVar(&describeJSON, "json", false, "Print the description as JSON")
+	describeTemplateCmd.Flags().IntVar(&promptPreviewLines, "prompt-lines", 10, "Number of prompt lines to show (0 shows the whole prompt)")
+	diffTemplatesCmd.Flags().BoolVar(&diffJSON, "json", false, "Print the differences as JSON")
+	devTemplatesCmd.Flags().StringVar(&devFieldsFile, "fields", "", "Sample fields JSON file (default: <dir>/fixtures/fields.json)")
+	devTemplatesCmd.Flags().StringVar(&devAddr, "addr", "127.0.0.1:8765", "Address to serve the preview on")
+	validateTemplatesCmd.Flags().BoolVar(&updateFixtures, "update", false, "Write fixtures/expected.html from the current rendering instead of comparing")
 }
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+// devVersionPath is polled by the live-reload script of previewed pages.
+const devVersionPath = "/__docloom/version"
+
+// devPollInterval is how often the template directory is checked for changes.
+const devPollInterval = 500 * time.Millisecond
+
+var (
+	devFieldsFile string
+	devAddr       string
+)
+
+// devTemplatesCmd represents the templates dev command
+var devTemplatesCmd = &cobra.Command{
+	Use:   "dev <dir>",
+	Short: "Preview a template with sample fields, re-rendering on change",
+	Long: `Render a template directory with sample field values and serve the result for
+preview in a browser. Whenever a file of the template or the fields file changes, the
+template is reloaded, the fields are validated against its schema and the page is
+re-rendered; open pages reload themselves. Errors are shown in the page instead of the
+document. No AI requests are made.
+
+The fields default to the template's fixtures/fields.json.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return serveTemplateDev(ctx, args[0], devFieldsFile, devAddr, os.Stdout)
+	},
+}
+
+// serveTemplateDev serves the preview of the template in dir on addr until ctx is
+// done, rebuilding it when a file changes.
+func serveTemplateDev(ctx context.Context, dir, fieldsPath, addr string, out io.Writer) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("template directory %s does not exist", dir)
+	}
+	if fieldsPath == "" {
+		fieldsPath = filepath.Join(dir, templates.FixturesDir, templates.FixtureFieldsFile)
+	}
+
+	return servePreview(ctx, newDevPreview(dir, fieldsPath), addr, out)
+}
+
+// servePreview serves preview on addr until ctx is done, rebuilding it when one of
+// its files changes.
+func servePreview(ctx context.Context, preview *devPreview, addr string, out io.Writer) error {
+	preview.refresh(out)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: preview, ReadHeaderTimeout: 5 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+	fmt.Fprintln(out, i18n.T("templates.dev_serving", preview.name, "http://"+listener.Addr().String()))
+
+	ticker := time.NewTicker(devPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		case err := <-serveErr:
+			return fmt.Errorf("preview server failed: %w", err)
+		case <-ticker.C:
+			preview.refresh(out)
+		}
+	}
+}
+
+// devPreview holds the latest rendering of fields with a template and serves it with
+// the template's assets.
+type devPreview struct {
+	name       string   // What is previewed, for messages
+	watch      []string // Files and directories whose changes rebuild the preview
+	fieldsPath string
+	load       func() (*templates.Template, error)
+
+	mu      sync.Mutex
+	stamp   string // Fingerprint of the watched files at the last build
+	version int    // Incremented by every build
+	page    []byte // Rendered document, or an error page
+	assets  map[string][]byte
+}
+
+// newDevPreview returns the preview of the fields in fieldsPath with the template in
+// dir.
+func newDevPreview(dir, fieldsPath string) *devPreview {
+	return &devPreview{
+		name:       dir,
+		watch:      []string{dir, fieldsPath},
+		fieldsPath: fieldsPath,
+		load:       func() (*templates.Template, error) { return templates.LoadTemplateDir(dir) },
+	}
+}
+
+// refresh rebuilds the preview when a watched file changed since the last build,
+// printing the outcome to out, and reports whether it rebuilt.
+func (p *devPreview) refresh(out io.Writer) bool {
+	stamp := p.fingerprint()
+	p.mu.Lock()
+	unchanged := stamp == p.stamp
+	p.mu.Unlock()
+	if unchanged {
+		return false
+	}
+
+	tmpl, page, err := p.build()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stamp = stamp
+	p.version++
+	if err != nil {
+		// Keep the previous assets so the error page does not lose the styles
+		p.page = devErrorPage(err)
+		fmt.Fprintln(out, i18n.T("templates.dev_failed", p.name, err))
+		return true
+	}
+	p.page = page
+	p.assets = tmpl.Assets
+	var verification *templates.PlaceholderError
+	if errors.As(templates.VerifyPlaceholders(tmpl), &verification) {
+		for _, placeholder := range verification.Unmapped {
+			fmt.Fprintln(out, i18n.T("templates.placeholder_warning", tmpl.Name, placeholder))
+		}
+		for _, field := range verification.Unused {
+			fmt.Fprintln(out, i18n.T("templates.unused_warning", tmpl.Name, field))
+		}
+	}
+	fmt.Fprintln(out, i18n.T("templates.dev_rendered", tmpl.Name))
+	return true
+}
+
+// fingerprint describes the size and modification time of every watched file,
+// including those in watched directories, changing whenever one of them does.
+func (p *devPreview) fingerprint() string {
+	var b strings.Builder
+	for _, watched := range p.watch {
+		if _, err := os.Stat(watched); err != nil {
+			fmt.Fprintf(&b, "%s missing\n", watched)
+			continue
+		}
+		err := filepath.WalkDir(watched, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(&b, "error %v\n", err)
+		}
+	}
+	return b.String()
+}
+
+// build loads the template, checks the sample fields against its schema and rules
+// and renders them.
+func (p *devPreview) build() (*templates.Template, []byte, error) {
+	tmpl, err := p.load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load template: %w", err)
+	}
+	fieldsData, err := os.ReadFile(p.fieldsPath) // #nosec G304 - the fields file is user-provided
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read fields: %w", err)
+	}
+	validator := validate.NewValidator()
+	if err := validator.Validate(string(fieldsData), string(tmpl.Schema)); err != nil {
+		return nil, nil, fmt.Errorf("fields do not match the schema: %w", err)
+	}
+	if len(tmpl.Rules) > 0 {
+		if err := validator.CheckRules(string(fieldsData), tmpl.Rules); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(fieldsData, &fields); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse fields: %w", err)
+	}
+	rendered, err := render.HTMLWithOptions(tmpl.HTMLContent, fields, render.Options{Transforms: tmpl.Transforms})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return tmpl, []byte(rendered), nil
+}
+
+// ServeHTTP serves the preview at /, the build version for the live-reload script
+// and the template's assets by their paths in the template directory.
+func (p *devPreview) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	version, page, assets := p.version, p.page, p.assets
+	p.mu.Unlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	switch r.URL.Path {
+	case devVersionPath:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, strconv.Itoa(version))
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(withLiveReload(page, version))
+	default:
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		data, ok := assets[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+	}
+}
+
+// withLiveReload adds a script to page that reloads it once the preview has been
+// rebuilt since version.
+func withLiveReload(page []byte, version int) []byte {
+	script := fmt.Sprintf(`<script>
+(function() {
+  var version = %q;
+  setInterval(function() {
+    fetch(%q, {cache: "no-store"})
+      .then(function(response) { return response.text(); })
+      .then(function(current) { if (current !== version) { location.reload(); } })
+      .catch(function() {});
+  }, %d);
+})();
+</script>
+`, strconv.Itoa(version), devVersionPath, devPollInterval.Milliseconds())
+
+	end := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if end < 0 {
+		return append(append([]byte{}, page...), script...)
+	}
+	result := make([]byte, 0, len(page)+len(script))
+	result = append(result, page[:end]...)
+	result = append(result, script...)
+	return append(result, page[end:]...)
+}
+
+// devErrorPage renders err as a page shown in place of the document.
+func devErrorPage(err error) []byte {
+	return []byte(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Template error</title></head>
+<body style="font-family: sans-serif; margin: 2em;">
+<h1 style="color: #b00020;">Template error</h1>
+<pre style="white-space: pre-wrap;">` + html.EscapeString(err.Error()) + `</pre>
+</body>
+</html>
+`)
+}
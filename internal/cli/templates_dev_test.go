@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the dev preview re-renders the sample fields when they change and shows errors in the page
+func TestDevPreview_Refresh(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "template.json"), []byte(`{"name": "runbook", "prompt": "Write a runbook"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "template.html"), []byte(`<html><body><h1><!-- data-field="title" --></h1></body></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}}, "required": ["title"]}`), 0644))
+	fieldsPath := filepath.Join(t.TempDir(), "sample.json")
+	require.NoError(t, os.WriteFile(fieldsPath, []byte(`{"title": "Payments runbook"}`), 0644))
+
+	preview := newDevPreview(dir, fieldsPath)
+	var out bytes.Buffer
+	require.True(t, preview.refresh(&out))
+	assert.Contains(t, out.String(), "rendered runbook")
+	assert.False(t, preview.refresh(&out), "unchanged files must not trigger a rebuild")
+
+	page := fetchDevPreview(t, preview, "/")
+	assert.Contains(t, page, "Payments runbook")
+	assert.Contains(t, page, devVersionPath)
+	assert.Equal(t, "1", fetchDevPreview(t, preview, devVersionPath))
+
+	require.NoError(t, os.WriteFile(fieldsPath, []byte(`{"heading": "Payments runbook"}`), 0644))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(fieldsPath, later, later))
+	require.True(t, preview.refresh(&out))
+	assert.Contains(t, out.String(), "failed")
+	assert.Contains(t, fetchDevPreview(t, preview, "/"), "Template error")
+	assert.Equal(t, "2", fetchDevPreview(t, preview, devVersionPath))
+}
+
+func fetchDevPreview(t *testing.T, preview *devPreview, path string) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	preview.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	body, err := io.ReadAll(recorder.Result().Body)
+	require.NoError(t, err)
+	return string(body)
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that templates list --json includes built-in and user templates with their schemas
+func TestTemplatesListCmd_JSON(t *testing.T) {
+	templateDir := t.TempDir()
+	userTemplate := filepath.Join(templateDir, "runbook")
+	require.NoError(t, os.MkdirAll(userTemplate, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(userTemplate, "template.json"), []byte(`{"description": "Service runbook", "prompt": "Write a runbook"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(userTemplate, "template.html"), []byte(`<html><!-- data-field="title" --></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(userTemplate, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}}}`), 0644))
+
+	t.Setenv("DOCLOOM_TEMPLATE_DIR", templateDir)
+	t.Cleanup(func() { templatesJSON = false })
+
+	rootCmd.SetArgs([]string{"templates", "list", "--json"})
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stdout)
+	require.NoError(t, rootCmd.Execute())
+
+	var infos []templateInfo
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &infos))
+	byName := make(map[string]templateInfo)
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+
+	require.Contains(t, byName, "architecture-vision")
+	assert.Equal(t, "built-in", byName["architecture-vision"].Origin)
+
+	require.Contains(t, byName, "runbook")
+	assert.Equal(t, "Service runbook", byName["runbook"].Description)
+	assert.Equal(t, userTemplate, byName["runbook"].Origin)
+	assert.JSONEq(t, `{"type": "object", "properties": {"title": {"type": "string"}}}`, string(byName["runbook"].Schema))
+}
+
+// Test that templates diff resolves name@version references in the template directory
+func TestTemplatesDiffCmd(t *testing.T) {
+	templateDir := t.TempDir()
+	for version, field := range map[string]string{"1.0.0": "owner", "2.0.0": "reviewer"} {
+		dir := filepath.Join(templateDir, "runbook-"+version)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "template.json"), []byte(`{"name": "runbook", "version": "`+version+`", "prompt": "Write a runbook"}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "template.html"), []byte(`<html><!-- data-field="`+field+`" --></html>`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.json"), []byte(`{"type": "object", "properties": {"`+field+`": {"type": "string"}}}`), 0644))
+	}
+
+	t.Setenv("DOCLOOM_TEMPLATE_DIR", templateDir)
+	t.Cleanup(func() { diffJSON = false })
+
+	rootCmd.SetArgs([]string{"templates", "diff", "runbook@1.0.0", "runbook@2.0.0", "--json"})
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stdout)
+	require.NoError(t, rootCmd.Execute())
+
+	var diff map[string]interface{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &diff))
+	assert.Equal(t, []interface{}{"reviewer"}, diff["fields_added"])
+	assert.Equal(t, []interface{}{"owner"}, diff["fields_removed"])
+	assert.Equal(t, []interface{}{"reviewer"}, diff["placeholders_added"])
+	assert.Equal(t, false, diff["prompt_changed"])
+
+	_, err := resolveTemplateRef("runbook@3.0.0", templateDir)
+	assert.ErrorContains(t, err, "version '3.0.0' not found")
+}
+
+// Test that templates describe lists schema fields, placeholders and a prompt preview
+func TestTemplatesDescribeCmd(t *testing.T) {
+	templateDir := t.TempDir()
+	userTemplate := filepath.Join(templateDir, "runbook")
+	require.NoError(t, os.MkdirAll(userTemplate, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(userTemplate, "template.json"), []byte(`{"description": "Service runbook", "prompt": "Write a runbook\nCover alerts\nCover rollbacks"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(userTemplate, "template.html"), []byte(`<html><!-- data-field="title" --><!-- data-field="owner" --></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(userTemplate, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}, "alerts": {"type": "array", "items": {"type": "string"}}}, "required": ["title"]}`), 0644))
+
+	t.Setenv("DOCLOOM_TEMPLATE_DIR", templateDir)
+	t.Cleanup(func() { describeJSON = false; promptPreviewLines = 10 })
+
+	rootCmd.SetArgs([]string{"templates", "describe", "runbook", "--prompt-lines", "2"})
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stdout)
+	require.NoError(t, rootCmd.Execute())
+
+	output := stdout.String()
+	assert.Contains(t, output, "Template runbook")
+	assert.Regexp(t, `title\s+string, required`, output)
+	assert.Regexp(t, `alerts\s+array`, output)
+	assert.Contains(t, output, "owner (no schema field)")
+	assert.Contains(t, output, "Cover alerts")
+	assert.NotContains(t, output, "Cover rollbacks")
+	assert.Contains(t, output, "1 more line(s)")
+
+	rootCmd.SetArgs([]string{"templates", "describe", userTemplate, "--json"})
+	stdout.Reset()
+	require.NoError(t, rootCmd.Execute())
+
+	var description map[string]interface{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &description))
+	assert.Equal(t, []interface{}{"owner", "title"}, description["placeholders"])
+	assert.Equal(t, []interface{}{"owner"}, description["unknown_placeholders"])
+	assert.Equal(t, []interface{}{"alerts"}, description["unused_fields"])
+	assert.Len(t, description["fields"], 2)
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/docregistry"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/track"
+)
+
+var (
+	trackConfigFile     string
+	trackRepo           string
+	trackBranch         string
+	trackTypes          []string
+	trackSources        []string
+	trackOutDir         string
+	trackWorkDir        string
+	trackInterval       time.Duration
+	trackListen         string
+	trackWebhookSecret  string
+	trackPublishCommand string
+	trackOnce           bool
+)
+
+// trackCmd represents the track command
+var trackCmd = &cobra.Command{
+	Use:   "track",
+	Short: "Keep documents up to date with a git branch",
+	Long: `Follow a branch of a repository and regenerate the documents affected by each new
+commit until interrupted.
+
+The branch is kept in a working copy (--work-dir, .docloom/track/<repository> by
+default), updated every --interval and, with --listen, whenever a push webhook from
+GitHub, GitLab or Gitea arrives at /webhook. On a new commit, each document whose
+sources in the working copy (--source, the whole repository by default) changed since
+it was recorded in the document registry, or that was never generated, is
+regenerated with the model settings from the configuration file.
+
+Regenerated documents are published: written to --out-dir as <type>.html, recorded in
+the document registry, announced through the configured notifications and, with
+--publish-command, passed to a shell command with DOCLOOM_OUTPUT, DOCLOOM_TEMPLATE,
+DOCLOOM_COMMIT and DOCLOOM_BRANCH set, e.g. to upload them.
+
+Set --webhook-secret (or DOCLOOM_WEBHOOK_SECRET) to the webhook's secret to reject
+requests not signed with it.
+
+Examples:
+  docloom track --repo https://github.com/acme/billing.git --branch main --type reference-architecture
+  docloom track --repo git@github.com:acme/billing.git --type architecture-vision --source docs \
+    --listen :8080 --interval 1h --publish-command 'aws s3 cp "$DOCLOOM_OUTPUT" s3://acme-docs/billing/'
+  docloom track --repo https://github.com/acme/billing.git --type reference-architecture --once`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(trackConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyOutputModes(cfg)
+		applyTrustPolicy(cfg)
+
+		if !trackOnce && trackInterval <= 0 && trackListen == "" {
+			return fmt.Errorf("set --interval or --listen to be notified of new commits")
+		}
+
+		checkout := &track.Checkout{Repo: trackRepo, Branch: trackBranch, Dir: trackWorkDir}
+		if checkout.Dir == "" {
+			checkout.Dir = track.DefaultDir(trackRepo)
+		}
+		docs := make([]track.Document, 0, len(trackTypes))
+		for _, templateType := range trackTypes {
+			docs = append(docs, track.Document{
+				Type:    templateType,
+				Out:     filepath.Join(trackOutDir, templateType+".html"),
+				Sources: trackSources,
+			})
+		}
+		tracker, err := track.NewTracker(checkout, docs, publishTrackedDocument(cfg), trackedDocumentAffected(cfg.DocsRegistry))
+		if err != nil {
+			return err
+		}
+		report := func(update track.Update) {
+			if update.Changed() {
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.T("track.updated", shortHash(update.Commit), len(update.Regenerated), len(update.Unaffected), len(update.Failed)))
+			}
+		}
+
+		if trackOnce {
+			update, err := tracker.Update(cmd.Context())
+			if err != nil {
+				return err
+			}
+			report(update)
+			if len(update.Failed) > 0 {
+				return fmt.Errorf("failed to regenerate %s", strings.Join(update.Failed, ", "))
+			}
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if trackListen != "" {
+			secret := trackWebhookSecret
+			if secret == "" {
+				secret = os.Getenv("DOCLOOM_WEBHOOK_SECRET")
+			}
+			if secret == "" {
+				log.Warn().Msg("Webhooks are accepted without verification; set --webhook-secret")
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/webhook", tracker.WebhookHandler(secret))
+			server := &http.Server{Addr: trackListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-ctx.Done()
+				_ = server.Close()
+			}()
+			go func() {
+				log.Info().Str("address", trackListen).Msg("Listening for push webhooks on /webhook")
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Error().Err(err).Msg("Webhook server failed")
+					stop()
+				}
+			}()
+		}
+		return tracker.Start(ctx, trackInterval, report)
+	},
+}
+
+// trackedDocumentAffected returns an AffectedFunc that considers a tracked document
+// affected when the registry has no fresh entry for its output.
+func trackedDocumentAffected(registryPath string) track.AffectedFunc {
+	return func(doc track.Document, sources []string) (bool, error) {
+		if registryPath == "" {
+			return true, nil
+		}
+		entries, err := docregistry.New(registryPath).List()
+		if err != nil {
+			return true, err
+		}
+		id := docregistry.DocumentID(doc.Out)
+		for _, entry := range entries {
+			if entry.ID != id {
+				continue
+			}
+			entry.Sources = sources
+			status, err := docregistry.Check(entry)
+			if err != nil {
+				return true, err
+			}
+			return status.State != docregistry.StateFresh, nil
+		}
+		return true, nil
+	}
+}
+
+// publishTrackedDocument returns a RunFunc that regenerates a tracked document, records
+// it in the registry, sends notifications and runs the publish command.
+func publishTrackedDocument(cfg *config.Config) track.RunFunc {
+	return func(ctx context.Context, doc track.Document, sources []string, commit string) error {
+		startTime := time.Now()
+		meter := newConfiguredMeter(cfg, 0)
+		result, err := generateFromConfig(ai.WithMeter(ctx, meter), cfg, doc.Type, sources, "", doc.Out, nil)
+		notifyCompletion(ctx, cfg.Notifications, doc.Type, doc.Out, result, meter.Summary(), err, time.Since(startTime))
+		if err != nil {
+			return err
+		}
+		recordDocument(cfg.DocsRegistry, result, sources, cfg.Model, cfg.Owner)
+		log.Info().Str("output", result.OutputFile).Str("commit", commit).Msg("Regenerated tracked document")
+
+		if trackPublishCommand == "" {
+			return nil
+		}
+		publish := exec.CommandContext(ctx, "sh", "-c", trackPublishCommand) // #nosec G204 - command configured by the user
+		publish.Env = append(os.Environ(),
+			"DOCLOOM_OUTPUT="+result.OutputFile,
+			"DOCLOOM_TEMPLATE="+doc.Type,
+			"DOCLOOM_COMMIT="+commit,
+			"DOCLOOM_BRANCH="+trackBranch,
+		)
+		if output, err := publish.CombinedOutput(); err != nil {
+			return fmt.Errorf("publish command failed for %s: %w: %s", result.OutputFile, err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(trackCmd)
+
+	trackCmd.Flags().StringVar(&trackConfigFile, "config", "", "Config file path")
+	trackCmd.Flags().StringVar(&trackRepo, "repo", "", "Repository URL to track (required)")
+	trackCmd.Flags().StringVar(&trackBranch, "branch", "main", "Branch to track")
+	trackCmd.Flags().StringSliceVar(&trackTypes, "type", nil, "Template of a document to keep up to date (repeatable, required)")
+	trackCmd.Flags().StringSliceVar(&trackSources, "source", nil, "Source path within the repository (repeatable; default: the whole repository)")
+	trackCmd.Flags().StringVar(&trackOutDir, "out-dir", "docs", "Directory the documents are written to, as <type>.html")
+	trackCmd.Flags().StringVar(&trackWorkDir, "work-dir", "", "Working copy of the branch (default: .docloom/track/<repository>)")
+	trackCmd.Flags().DurationVar(&trackInterval, "interval", 5*time.Minute, "How often to poll the branch (0 to rely on webhooks)")
+	trackCmd.Flags().StringVar(&trackListen, "listen", "", "Address to receive push webhooks on, e.g. :8080")
+	trackCmd.Flags().StringVar(&trackWebhookSecret, "webhook-secret", "", "Secret webhooks are signed with (or DOCLOOM_WEBHOOK_SECRET)")
+	trackCmd.Flags().StringVar(&trackPublishCommand, "publish-command", "", "Shell command run for each regenerated document")
+	trackCmd.Flags().BoolVar(&trackOnce, "once", false, "Update once and exit instead of tracking")
+	_ = trackCmd.MarkFlagRequired("repo")
+	_ = trackCmd.MarkFlagRequired("type")
+}
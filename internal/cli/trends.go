@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/agent"
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/trends"
+)
+
+var (
+	trendsConfigFile string
+	trendsAgent      string
+	trendsSince      string
+	trendsHistoryDir string
+	trendsOut        string
+	trendsJSON       bool
+)
+
+// trendsCmd represents the trends command
+var trendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "Report how an agent's analyses changed across runs",
+	Long: `Compare the artifacts of an agent's runs over a period and report the trends of
+their metrics (metrics.json: growing, shrinking or stable) and the churn of their
+dependencies (dependencies.json: added, removed and changed versions).
+
+Each generate run with --agent keeps its artifacts in the agent history
+(agent_history_dir, .docloom/history by default), keyed by the commit of the analyzed
+source; re-running an analysis of the same commit replaces the earlier run. The
+Markdown report can be used as a source for generating a document.
+
+Examples:
+  docloom trends --agent csharp-analyzer --since 90d
+  docloom trends --agent csharp-analyzer --since 2024-01-01 --out trends.md
+  docloom generate --type architecture-vision --source trends.md --out debt-trends.html`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(trendsConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		historyDir := cfg.AgentHistoryDir
+		if trendsHistoryDir != "" {
+			historyDir = trendsHistoryDir
+		}
+		since, err := trends.ParseSince(trendsSince, time.Now())
+		if err != nil {
+			return err
+		}
+
+		runs, err := agent.NewHistory(historyDir).Runs(trendsAgent, since)
+		if err != nil {
+			return err
+		}
+		report, err := trends.Build(trendsAgent, runs, since)
+		if err != nil {
+			return err
+		}
+
+		var output []byte
+		if trendsJSON {
+			if output, err = json.MarshalIndent(report, "", "  "); err != nil {
+				return err
+			}
+			output = append(output, '\n')
+		} else {
+			output = []byte(report.Markdown())
+		}
+		if trendsOut == "" {
+			_, err = cmd.OutOrStdout().Write(output)
+			return err
+		}
+		if err := os.WriteFile(trendsOut, output, 0o600); err != nil {
+			return fmt.Errorf("failed to write trend report: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("trends.written", len(report.Runs), trendsOut))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trendsCmd)
+
+	trendsCmd.Flags().StringVar(&trendsConfigFile, "config", "", "Config file path")
+	trendsCmd.Flags().StringVar(&trendsAgent, "agent", "", "Agent whose runs to compare (required)")
+	trendsCmd.Flags().StringVar(&trendsSince, "since", "", "Start of the period, e.g. 90d, 12w or 2024-01-31 (default: all runs)")
+	trendsCmd.Flags().StringVar(&trendsHistoryDir, "history-dir", "", "Agent history directory (overrides agent_history_dir)")
+	trendsCmd.Flags().StringVarP(&trendsOut, "out", "o", "", "Write the report to a file instead of stdout")
+	trendsCmd.Flags().BoolVar(&trendsJSON, "json", false, "Output the report as JSON")
+	_ = trendsCmd.MarkFlagRequired("agent")
+}
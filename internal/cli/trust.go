@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/trust"
+)
+
+var (
+	trustConfigFile string
+	trustKeyFile    string
+	trustPublisher  string
+	trustForce      bool
+)
+
+// trustCmd represents the trust command
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Sign and verify template packs and agents",
+	Long: `Commands for publishing and checking signed template packs and agent definitions.
+
+Templates and agents carry prompts and commands that affect outputs and security.
+Under the trust policy configured in the configuration file (trust.policy: warn or
+strict), DocLoom verifies their signatures against the publisher keys listed under
+trust.keys whenever it loads them; a strict policy refuses packs and agents that are
+unsigned, signed by an unknown publisher or modified after signing.`,
+}
+
+// trustKeygenCmd represents the trust keygen command
+var trustKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Create a publisher key pair",
+	Long: `Create an Ed25519 key pair for signing template packs and agents. The private key
+is written to --key; the public key is printed for the configuration of those who
+trust the publisher.
+
+Example:
+  docloom trust keygen --key platform-team.key --publisher platform-team`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := render.CheckOverwrite(trustForce, trustKeyFile); err != nil {
+			return err
+		}
+		publicKey, privateKey, err := trust.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+		if err := os.WriteFile(trustKeyFile, []byte(privateKey+"\n"), 0o600); err != nil {
+			return fmt.Errorf("failed to write private key: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("trust.key_written", trustKeyFile, trustPublisher, publicKey))
+		return nil
+	},
+}
+
+// trustSignCmd represents the trust sign command
+var trustSignCmd = &cobra.Command{
+	Use:   "sign <template-pack-dir|agent-file>",
+	Short: "Sign a template pack or agent definition",
+	Long: `Sign a template pack, a directory of one or more templates, or an agent definition
+file with the publisher's private key. A pack's signature (docloom.sig) covers every
+file in the directory except hidden ones; an agent's (<file>.sig) covers the file.
+Sign again after every change.
+
+Example:
+  docloom trust sign ./templates/runbook --key platform-team.key --publisher platform-team
+  docloom trust sign .docloom/agents/csharp-cc-cli.agent.yaml --key platform-team.key --publisher platform-team`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		privateKey, err := trust.LoadPrivateKey(trustKeyFile)
+		if err != nil {
+			return err
+		}
+		signature, err := trust.Sign(args[0], trustPublisher, privateKey)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("trust.signed", args[0], trustPublisher, signature))
+		return nil
+	},
+}
+
+// trustVerifyCmd represents the trust verify command
+var trustVerifyCmd = &cobra.Command{
+	Use:   "verify <template-pack-dir|agent-file>",
+	Short: "Verify the signature of a template pack or agent definition",
+	Long: `Check that a template pack or agent definition is signed by a publisher listed under
+trust.keys in the configuration and unchanged since, whatever the trust policy.
+
+Example:
+  docloom trust verify ./templates/runbook`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(trustConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		verifier, err := cfg.Verifier()
+		if err != nil {
+			return err
+		}
+		publisher, err := verifier.Verify(args[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.T("trust.verified", args[0], publisher))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+	trustCmd.AddCommand(trustKeygenCmd)
+	trustCmd.AddCommand(trustSignCmd)
+	trustCmd.AddCommand(trustVerifyCmd)
+
+	for _, cmd := range []*cobra.Command{trustKeygenCmd, trustSignCmd} {
+		cmd.Flags().StringVar(&trustKeyFile, "key", "", "Private key file (required)")
+		cmd.Flags().StringVar(&trustPublisher, "publisher", "", "Publisher name, as listed under trust.keys by those who trust it (required)")
+		_ = cmd.MarkFlagRequired("key")
+		_ = cmd.MarkFlagRequired("publisher")
+	}
+	trustKeygenCmd.Flags().BoolVar(&trustForce, "force", false, "Overwrite an existing key file")
+	trustVerifyCmd.Flags().StringVar(&trustConfigFile, "config", "", "Config file path")
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/karolswdev/docloom/internal/config"
+	"github.com/karolswdev/docloom/internal/docregistry"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+var (
+	verifyConfigFile  string
+	verifyTemplateDir string
+	verifyDetails     bool
+	verifyJSON        bool
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [output...]",
+	Short: "Check generated documents against their run manifests",
+	Long: `Check whether generated documents are up to date, using the run manifest
+(<name>.manifest.json) written next to each document. A document is stale when files
+of its sources were added, removed or modified, or its template changed, since it
+was generated, and missing when one of its outputs no longer exists. Outputs edited
+since generation are reported but do not make a document stale.
+
+Pass documents or their manifests; without arguments, every manifest below the
+working directory is checked. Run the command from the directory generate was run
+in, as the manifests record source paths as given. The command exits with an error
+while stale or missing documents remain, so CI can regenerate only those.
+
+Examples:
+  docloom verify docs/architecture.html
+  docloom verify --details
+  docloom verify --json | jq -r '.[] | select(.state != "fresh") | .manifest'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(verifyConfigFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		templateDir := verifyTemplateDir
+		if templateDir == "" {
+			templateDir = cfg.TemplateDir
+		}
+		registry := templates.NewRegistry()
+		if err := registry.LoadDefaults(); err != nil {
+			return err
+		}
+		if templateDir != "" {
+			if _, err := os.Stat(templateDir); err == nil {
+				if err := registry.LoadFromDirectory(templateDir); err != nil {
+					return fmt.Errorf("failed to load templates from %s: %w", templateDir, err)
+				}
+			}
+		}
+
+		manifests, err := manifestPaths(args)
+		if err != nil {
+			return err
+		}
+		if len(manifests) == 0 {
+			return fmt.Errorf("no run manifests found; generate documents first")
+		}
+
+		verifications := make([]*generate.Verification, 0, len(manifests))
+		for _, path := range manifests {
+			manifest, err := generate.ReadManifest(path)
+			if err != nil {
+				return err
+			}
+			verification, err := generate.VerifyManifest(path, manifestTemplate(registry, manifest))
+			if err != nil {
+				return fmt.Errorf("failed to verify %s: %w", path, err)
+			}
+			verifications = append(verifications, verification)
+		}
+
+		if verifyJSON {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(verifications); err != nil {
+				return err
+			}
+		} else {
+			printVerifications(cmd, verifications)
+		}
+
+		outdated := 0
+		for _, verification := range verifications {
+			if verification.State != docregistry.StateFresh {
+				outdated++
+			}
+		}
+		if outdated > 0 {
+			return fmt.Errorf("%d of %d documents are out of date", outdated, len(verifications))
+		}
+		return nil
+	},
+}
+
+// manifestPaths returns the manifests of the documents or manifests in args, or of
+// every manifest below the working directory, skipping hidden directories.
+func manifestPaths(args []string) ([]string, error) {
+	if len(args) > 0 {
+		paths := make([]string, 0, len(args))
+		for _, arg := range args {
+			if strings.HasSuffix(arg, generate.ManifestSuffix) {
+				paths = append(paths, arg)
+			} else {
+				paths = append(paths, generate.ManifestPath(arg))
+			}
+		}
+		return paths, nil
+	}
+
+	var paths []string
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != "." && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), generate.ManifestSuffix) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// manifestTemplate returns the template a manifest was generated with, preferring
+// the recorded version, or nil when it is not installed.
+func manifestTemplate(registry *templates.Registry, manifest *generate.Manifest) *templates.Template {
+	if manifest.TemplateVersion != "" {
+		if tmpl, err := registry.GetVersion(manifest.Template, manifest.TemplateVersion); err == nil {
+			return tmpl
+		}
+	}
+	tmpl, err := registry.Get(manifest.Template)
+	if err != nil {
+		return nil
+	}
+	return tmpl
+}
+
+// printVerifications prints one row per document, followed by the changed files of
+// stale documents when requested.
+func printVerifications(cmd *cobra.Command, verifications []*generate.Verification) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OUTPUT\tSTATE\tADDED\tREMOVED\tCHANGED\tTEMPLATE\tEDITED")
+	for _, verification := range verifications {
+		template := "unchanged"
+		switch {
+		case verification.TemplateUnknown:
+			template = "unknown"
+		case verification.TemplateChanged:
+			template = "changed"
+		}
+		output := filepath.Join(filepath.Dir(verification.Path), verification.Manifest.Output)
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+			output, verification.State, len(verification.Added), len(verification.Removed), len(verification.Changed),
+			template, strings.Join(verification.Modified, ", "))
+	}
+	_ = w.Flush()
+
+	if !verifyDetails {
+		return
+	}
+	for _, verification := range verifications {
+		if verification.State == docregistry.StateFresh {
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\n%s:\n", verification.Path)
+		for _, path := range verification.Added {
+			fmt.Fprintf(cmd.OutOrStdout(), "  + %s\n", path)
+		}
+		for _, path := range verification.Removed {
+			fmt.Fprintf(cmd.OutOrStdout(), "  - %s\n", path)
+		}
+		for _, path := range verification.Changed {
+			fmt.Fprintf(cmd.OutOrStdout(), "  ~ %s\n", path)
+		}
+		for _, name := range verification.Missing {
+			fmt.Fprintf(cmd.OutOrStdout(), "  missing output %s\n", name)
+		}
+		if verification.TemplateChanged {
+			fmt.Fprintf(cmd.OutOrStdout(), "  template %s changed\n", verification.Manifest.Template)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVar(&verifyConfigFile, "config", "", "Config file path")
+	verifyCmd.Flags().StringVar(&verifyTemplateDir, "template-dir", "", "Directory of user templates (defaults to config template_dir)")
+	verifyCmd.Flags().BoolVar(&verifyDetails, "details", false, "List the added, removed and changed source files of out-of-date documents")
+	verifyCmd.Flags().BoolVar(&verifyJSON, "json", false, "Print the results as JSON")
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/docregistry"
+	"github.com/karolswdev/docloom/internal/generate"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+func TestVerifyCmd_ReportsStaleDocuments(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "docs")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	sourceFile := filepath.Join(sourceDir, "overview.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Overview"), 0600))
+	output := filepath.Join(tempDir, "arch.html")
+	require.NoError(t, os.WriteFile(output, []byte("<html></html>"), 0600))
+
+	registry := templates.NewRegistry()
+	require.NoError(t, registry.LoadDefaults())
+	tmpl, err := registry.Get("architecture-vision")
+	require.NoError(t, err)
+	files, err := docregistry.BuildManifest([]string{sourceDir})
+	require.NoError(t, err)
+	data, err := json.Marshal(generate.Manifest{
+		Version:        generate.ManifestVersion,
+		Template:       tmpl.Name,
+		TemplateDigest: tmpl.Digest(),
+		Sources:        []string{sourceDir},
+		SourcesHash:    docregistry.ManifestHash(files),
+		Files:          files,
+		Output:         "arch.html",
+		Outputs:        map[string]string{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(generate.ManifestPath(output), data, 0600))
+
+	run := func() (string, error) {
+		cmd := GetRootCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetErr(buf)
+		cmd.SetArgs([]string{"verify", output, "--details"})
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+	t.Cleanup(func() { verifyDetails = false })
+
+	out, err := run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "fresh")
+	assert.Contains(t, out, "unchanged")
+
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Overview, revised"), 0600))
+	out, err = run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 1 documents are out of date")
+	assert.Contains(t, out, "stale")
+	assert.Contains(t, out, "~ "+filepath.ToSlash(sourceFile))
+}
+
+func TestManifestPaths(t *testing.T) {
+	paths, err := manifestPaths([]string{"docs/arch.html", "docs/runbook.manifest.json"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/arch.manifest.json", "docs/runbook.manifest.json"}, paths)
+}
@@ -1,13 +1,31 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+
+	"github.com/karolswdev/docloom/internal/secrets"
+	"github.com/karolswdev/docloom/internal/trust"
 )
 
+// ProjectConfigFile is the configuration file of a project, loaded from the working
+// directory when no configuration file is given.
+const ProjectConfigFile = ".docloom.yaml"
+
+// secretReferencePattern matches ${VAR} references to environment variables
+var secretReferencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 // Config represents the application configuration
 type Config struct {
 	Model       string  `yaml:"model" env:"DOCLOOM_MODEL"`
@@ -20,38 +38,235 @@ type Config struct {
 	Force       bool    `yaml:"force" env:"DOCLOOM_FORCE"`
 	Verbose     bool    `yaml:"verbose" env:"DOCLOOM_VERBOSE"`
 	DryRun      bool    `yaml:"dry_run" env:"DOCLOOM_DRY_RUN"`
+	Probe       bool    `yaml:"probe"` // Verify the model against the models endpoint before generating
+
+	// Sources of the API key when none is given by flag, environment or api_key: a
+	// command printing it, such as a password manager CLI, or an entry of the OS
+	// keychain stored with docloom auth set (the default entry when neither is set)
+	APIKeyCmd      string `yaml:"api_key_cmd"`
+	APIKeyKeychain string `yaml:"api_key_keychain"`
+
+	// Provider is openai, azure or ollama; empty detects Azure OpenAI from the base URL.
+	// APIVersion is the Azure OpenAI api-version (a recent GA version when empty).
+	Provider   string `yaml:"provider" env:"DOCLOOM_PROVIDER"`
+	APIVersion string `yaml:"api_version"`
+
+	// Most completion tokens requested per model call (4096 when 0)
+	MaxTokens int `yaml:"max_tokens"`
+
+	// Named presets of model settings, applied with --profile or, by default, profile
+	Profiles map[string]ProfileConfig `yaml:"profiles"`
+	Profile  string                   `yaml:"profile" env:"DOCLOOM_PROFILE"`
+
+	// Limit on model requests across the concurrent jobs of a batch run (0 for none)
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+
+	// Sent with every model request, e.g. organization headers or routing hints for
+	// gateways; values may reference environment variables as ${VAR}
+	ExtraHeaders map[string]string `yaml:"extra_headers"`
+	ExtraQuery   map[string]string `yaml:"extra_query"`
+
+	Notifications   NotificationsConfig `yaml:"notifications"`
+	Schedules       []ScheduleConfig    `yaml:"schedules"`
+	ScheduleHistory string              `yaml:"schedule_history"`
+	DocsRegistry    string              `yaml:"docs_registry"` // Inventory of generated documents
+	Owner           string              `yaml:"owner"`         // Owner recorded for generated documents
+
+	// Globs selecting the files ingested below source directories (gitignore syntax,
+	// relative to each directory), e.g. include: ["docs/**/*.md"], exclude: ["vendor/"]
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	// Template indexes (URLs or file paths) queried by templates search
+	TemplateIndexes []string `yaml:"template_indexes"`
+
+	// Publisher keys and policy for the signatures of template packs and agents
+	Trust TrustConfig `yaml:"trust"`
+
+	// Token prices by model for the cost summary and budget, overriding the built-in
+	// list prices; the budget limits the cost of a generate run in US dollars (0 for none)
+	Prices   map[string]ModelPrice `yaml:"prices"`
+	Budget   float64               `yaml:"budget"`
+	UsageLog string                `yaml:"usage_log"` // JSON lines file each run's usage is appended to
+
+	// Directory of per-template lessons files aggregating the validation failures of
+	// repairs; recurring ones are added to generation prompts. Empty disables lessons.
+	LessonsDir string `yaml:"lessons_dir"`
+
+	// Directory the artifacts of agent runs are kept in per source commit, for trends
+	// (.docloom/history when empty)
+	AgentHistoryDir string `yaml:"agent_history_dir"`
+
+	// Remove instruction-like text from the outputs of agent tools the model calls, in
+	// addition to delimiting them as data
+	StripToolInstructions bool `yaml:"strip_tool_instructions"`
+
+	// Glossary file of organization entities whose first occurrences in generated
+	// documents link to their canonical pages
+	Glossary string `yaml:"glossary"`
+
+	// Capabilities of the models in use, for checking template model requirements
+	// without probing and suggesting compatible models
+	Models map[string]ModelConfig `yaml:"models"`
+
+	// Permissions of generated files and the directories created for them, as octal
+	// strings such as "0644"; the umask applies. Defaults to 0600 and 0755.
+	OutputFileMode string `yaml:"output_file_mode" env:"DOCLOOM_OUTPUT_FILE_MODE"`
+	OutputDirMode  string `yaml:"output_dir_mode" env:"DOCLOOM_OUTPUT_DIR_MODE"`
+
+	// Embeddings endpoint of the optional retrieval stage
+	Embeddings EmbeddingsConfig `yaml:"embeddings"`
+
+	// Directory of prompt template overrides; defaults to ~/.docloom/prompts
+	PromptDir string `yaml:"prompt_dir" env:"DOCLOOM_PROMPT_DIR"`
+
+	// Cache of extracted source text (PDFs); defaults to the user cache directory
+	CacheDir         string `yaml:"cache_dir" env:"DOCLOOM_CACHE_DIR"`
+	IngestCacheMaxMB int    `yaml:"ingest_cache_max_mb"`
+
+	// Configuration files loaded, lowest precedence first
+	Files []string `yaml:"-"`
+}
+
+// ProfileConfig is a named preset of model settings; the settings it leaves empty
+// keep their configured values
+type ProfileConfig struct {
+	Model          string   `yaml:"model"`
+	BaseURL        string   `yaml:"base_url"`
+	APIKey         string   `yaml:"api_key"`
+	APIKeyCmd      string   `yaml:"api_key_cmd"`
+	APIKeyKeychain string   `yaml:"api_key_keychain"`
+	Provider       string   `yaml:"provider"`
+	APIVersion     string   `yaml:"api_version"`
+	Temperature    *float64 `yaml:"temperature"`
+	MaxTokens      int      `yaml:"max_tokens"`
+}
+
+// NotificationsConfig configures chat notifications sent after generation runs
+type NotificationsConfig struct {
+	LinkBaseURL string          `yaml:"link_base_url"` // Base URL used to build links to outputs
+	Webhooks    []WebhookConfig `yaml:"webhooks"`
+}
+
+// ScheduleConfig defines a recurring document generation job
+type ScheduleConfig struct {
+	Name    string   `yaml:"name"`
+	Cron    string   `yaml:"cron"` // Five-field cron expression or @hourly/@daily/@weekly/@monthly
+	Type    string   `yaml:"type"` // Template type
+	Sources []string `yaml:"sources"`
+	Ref     string   `yaml:"ref"` // Branch, tag or commit checked out of git sources, like --source-ref
+	Out     string   `yaml:"out"` // Output path; may be a filename pattern such as "{{project}}-{{date}}.html"
+
+	// Vars are the variables for the output filename pattern
+	Vars map[string]string `yaml:"vars"`
+}
+
+// ModelPrice is the price of a model's tokens in US dollars per million tokens
+type ModelPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// TrustConfig configures the verification of template pack and agent signatures
+type TrustConfig struct {
+	Policy string      `yaml:"policy"` // off (default), warn or strict
+	Keys   []trust.Key `yaml:"keys"`   // Trusted publisher keys
+}
+
+// EmbeddingsConfig configures the retrieval stage, which sends the generation prompt
+// only the source chunks most relevant to the template, ranked by embedding
+// similarity, instead of all sources
+type EmbeddingsConfig struct {
+	Model       string `yaml:"model"`        // Embeddings model; retrieval is enabled when set
+	BaseURL     string `yaml:"base_url"`     // Defaults to base_url
+	APIKey      string `yaml:"api_key"`      // Defaults to api_key
+	ChunkTokens int    `yaml:"chunk_tokens"` // Size of source chunks (default 400)
+	TopK        int    `yaml:"top_k"`        // Chunks kept (default 20)
+}
+
+// ModelConfig describes what a model supports
+type ModelConfig struct {
+	ContextWindow   int  `yaml:"context_window"`    // In tokens; 0 when unknown
+	MaxOutputTokens int  `yaml:"max_output_tokens"` // In tokens; 0 when unknown
+	Tools           bool `yaml:"tools"`
+	Vision          bool `yaml:"vision"`
+
+	// Responses can be constrained to the template schema (response_format json_schema)
+	StructuredOutputs bool `yaml:"structured_outputs"`
+}
+
+// WebhookConfig describes a single notification webhook
+type WebhookConfig struct {
+	URL  string   `yaml:"url"`
+	Type string   `yaml:"type"` // slack, teams or generic
+	On   []string `yaml:"on"`   // success and/or failure (default: both)
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		Model:       "gpt-4",
-		BaseURL:     "https://api.openai.com/v1",
-		Temperature: 0.7,
-		MaxRetries:  3,
-		TemplateDir: "templates",
-		Force:       false,
-		Verbose:     false,
-		DryRun:      false,
+		Model:            "gpt-4",
+		BaseURL:          "https://api.openai.com/v1",
+		Temperature:      0.7,
+		MaxRetries:       3,
+		TemplateDir:      "templates",
+		ScheduleHistory:  filepath.Join(".docloom", "schedule-history.jsonl"),
+		DocsRegistry:     "docs-registry.json",
+		IngestCacheMaxMB: 512,
+		Force:            false,
+		Verbose:          false,
+		DryRun:           false,
 	}
 }
 
-// Load loads configuration with proper precedence: CLI flags > ENV > File > Defaults
-// This function demonstrates the precedence but actual implementation will need
-// proper YAML parsing and flag integration
+// Load loads configuration with proper precedence: CLI flags > ENV > project file >
+// user file > Defaults. The project file is configFile or, when empty,
+// ProjectConfigFile if it exists; the user file is UserConfigFile if it exists. Each
+// file overrides the values it sets: maps are merged and lists replaced. The
+// ProjectConfigFile found in the working directory cannot set api_key_cmd.
 func Load(configFile string, cliOverrides map[string]interface{}) (*Config, error) {
 	// Start with defaults
 	cfg := DefaultConfig()
 
-	// Load from file if provided
+	// Load the user file, then the project file
+	var files []string
+	if userFile := UserConfigFile(); userFile != "" && fileExists(userFile) {
+		files = append(files, userFile)
+	}
+	for _, file := range files {
+		if err := loadFromFile(cfg, file); err != nil {
+			return nil, err
+		}
+	}
 	if configFile != "" {
-		loadFromFile(cfg, configFile)
-		// TODO: When YAML parsing is implemented, handle errors here
+		if err := loadFromFile(cfg, configFile); err != nil {
+			return nil, err
+		}
+		files = append(files, configFile)
+	}
+	// Environment references are expanded in the trusted files only, so that the
+	// project file cannot copy environment variables into the values it sets
+	expandSecrets(cfg)
+	if configFile == "" && fileExists(ProjectConfigFile) {
+		if err := loadProjectFile(cfg, ProjectConfigFile); err != nil {
+			return nil, err
+		}
+		files = append(files, ProjectConfigFile)
 	}
+	cfg.Files = files
 
 	// Override with environment variables
 	loadFromEnv(cfg)
 
+	// Override with the selected profile
+	profile := cfg.Profile
+	if name, ok := cliOverrides["profile"].(string); ok && name != "" {
+		profile = name
+	}
+	if err := cfg.ApplyProfile(profile); err != nil {
+		return nil, err
+	}
+
 	// Override with CLI flags (highest precedence)
 	applyCliOverrides(cfg, cliOverrides)
 
@@ -86,13 +301,210 @@ func LoadWithPrecedence(fileValue, envValue, cliValue string, field string) stri
 	return result
 }
 
-// loadFromFile loads configuration from a YAML file
-func loadFromFile(cfg *Config, path string) {
-	// For now, we'll stub this out as YAML parsing will be added when needed
-	// In a real implementation, this would use a YAML library to unmarshal the file
+// loadFromFile loads configuration from a YAML file, overlaying values present in the file
+func loadFromFile(cfg *Config, path string) error {
 	log.Debug().Str("path", path).Msg("Loading config from file")
-	// TODO: Implement YAML parsing and populate cfg
-	_ = cfg // cfg will be used when YAML parsing is implemented
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadProjectFile loads the project file found in the working directory like
+// loadFromFile, except for the settings that run commands or decide where API keys
+// and notifications are sent: api_key_cmd, base_url and embeddings.base_url, top-level
+// or in a profile, and the notification webhooks keep the values of the files loaded
+// before, as do extra headers and query parameters referencing the environment. The
+// file comes with the repository being documented, so it could otherwise run commands
+// as the user, send the user's key to another host or copy environment variables into
+// requests. These settings are set in the user file or a --config file.
+func loadProjectFile(cfg *Config, path string) error {
+	apiKeyCmd, baseURL, embeddingsURL := cfg.APIKeyCmd, cfg.BaseURL, cfg.Embeddings.BaseURL
+	webhooks := slices.Clone(cfg.Notifications.Webhooks)
+	profiles := maps.Clone(cfg.Profiles)
+	headers, query := maps.Clone(cfg.ExtraHeaders), maps.Clone(cfg.ExtraQuery)
+	if err := loadFromFile(cfg, path); err != nil {
+		return err
+	}
+
+	ignored := func(setting, profile string) {
+		event := log.Warn().Str("path", path).Str("setting", setting)
+		if profile != "" {
+			event = event.Str("profile", profile)
+		}
+		event.Msg("Ignoring a setting of the project config file; set it in the user config file or pass the file with --config")
+	}
+	keep := func(value *string, previous, setting, profile string) {
+		if *value != previous {
+			ignored(setting, profile)
+			*value = previous
+		}
+	}
+	keep(&cfg.APIKeyCmd, apiKeyCmd, "api_key_cmd", "")
+	keep(&cfg.BaseURL, baseURL, "base_url", "")
+	keep(&cfg.Embeddings.BaseURL, embeddingsURL, "embeddings.base_url", "")
+	if !slices.EqualFunc(cfg.Notifications.Webhooks, webhooks, webhookEqual) {
+		ignored("notifications.webhooks", "")
+		cfg.Notifications.Webhooks = webhooks
+	}
+	keepReferences := func(values, previous map[string]string, setting string) {
+		for key, value := range values {
+			if strings.Contains(value, "$") && value != previous[key] {
+				ignored(setting+"."+key, "")
+				if old, ok := previous[key]; ok {
+					values[key] = old
+				} else {
+					delete(values, key)
+				}
+			}
+		}
+	}
+	keepReferences(cfg.ExtraHeaders, headers, "extra_headers")
+	keepReferences(cfg.ExtraQuery, query, "extra_query")
+	for name, profile := range cfg.Profiles {
+		keep(&profile.APIKeyCmd, profiles[name].APIKeyCmd, "api_key_cmd", name)
+		keep(&profile.BaseURL, profiles[name].BaseURL, "base_url", name)
+		cfg.Profiles[name] = profile
+	}
+	return nil
+}
+
+// webhookEqual reports whether two webhooks are configured the same.
+func webhookEqual(a, b WebhookConfig) bool {
+	return a.URL == b.URL && a.Type == b.Type && slices.Equal(a.On, b.On)
+}
+
+// ApplyProfile overrides the model settings with those the profile name sets, and
+// records it as the profile in effect. An empty name applies no profile.
+func (c *Config) ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for known := range c.Profiles {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q: no profiles are configured", name)
+		}
+		return fmt.Errorf("unknown profile %q (configured: %s)", name, strings.Join(names, ", "))
+	}
+
+	for target, value := range map[*string]string{
+		&c.Model:          profile.Model,
+		&c.BaseURL:        profile.BaseURL,
+		&c.APIKey:         profile.APIKey,
+		&c.APIKeyCmd:      profile.APIKeyCmd,
+		&c.APIKeyKeychain: profile.APIKeyKeychain,
+		&c.Provider:       profile.Provider,
+		&c.APIVersion:     profile.APIVersion,
+	} {
+		if value != "" {
+			*target = value
+		}
+	}
+	if profile.Temperature != nil {
+		c.Temperature = *profile.Temperature
+	}
+	if profile.MaxTokens > 0 {
+		c.MaxTokens = profile.MaxTokens
+	}
+	c.Profile = name
+	return nil
+}
+
+// newKeychain returns the OS keychain; it is replaced in tests.
+var newKeychain = secrets.NewKeychain
+
+// ResolveAPIKey fills an empty API key from api_key_cmd or, failing that, the keychain
+// entry api_key_keychain. Without either, the keychain's default entry is used when
+// there is one.
+func (c *Config) ResolveAPIKey(ctx context.Context) error {
+	if c.APIKey != "" {
+		return nil
+	}
+	if c.APIKeyCmd != "" {
+		key, err := secrets.FromCommand(ctx, c.APIKeyCmd)
+		if err != nil {
+			return fmt.Errorf("api_key_cmd: %w", err)
+		}
+		c.APIKey = key
+		return nil
+	}
+
+	account := c.APIKeyKeychain
+	if account == "" {
+		account = secrets.DefaultAccount
+	}
+	keychain, err := newKeychain()
+	if err == nil {
+		c.APIKey, err = keychain.Get(ctx, account)
+	}
+	if err != nil {
+		if c.APIKeyKeychain != "" {
+			return fmt.Errorf("api_key_keychain %s: %w", account, err)
+		}
+		log.Debug().Err(err).Msg("No API key in the keychain")
+	}
+	return nil
+}
+
+// UserConfigFile returns the path of the user's configuration file,
+// $XDG_CONFIG_HOME/docloom/config.yaml or ~/.config/docloom/config.yaml, or an empty
+// string when the home directory is unknown.
+func UserConfigFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "docloom", "config.yaml")
+}
+
+// fileExists reports whether path is an existing file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// expandSecrets replaces ${VAR} references in secret values from configuration files
+// with the environment variables, so that files need not contain secrets.
+func expandSecrets(cfg *Config) {
+	cfg.APIKey = expandEnvReferences(cfg.APIKey)
+	cfg.Embeddings.APIKey = expandEnvReferences(cfg.Embeddings.APIKey)
+	for name, profile := range cfg.Profiles {
+		profile.APIKey = expandEnvReferences(profile.APIKey)
+		cfg.Profiles[name] = profile
+	}
+	for idx := range cfg.Notifications.Webhooks {
+		cfg.Notifications.Webhooks[idx].URL = expandEnvReferences(cfg.Notifications.Webhooks[idx].URL)
+	}
+}
+
+// expandEnvReferences replaces ${VAR} references in value with the environment
+// variables; unset variables are replaced with an empty string.
+func expandEnvReferences(value string) string {
+	return secretReferencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		name := secretReferencePattern.FindStringSubmatch(reference)[1]
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			log.Warn().Str("variable", name).Msg("Configuration references an unset environment variable")
+		}
+		return resolved
+	})
 }
 
 // loadFromEnv loads configuration from environment variables
@@ -114,16 +526,74 @@ func loadFromEnv(cfg *Config) {
 		cfg.APIKey = val
 	}
 
-	// Check for temperature override
+	// Check for provider override
+	if val := os.Getenv("DOCLOOM_PROVIDER"); val != "" {
+		cfg.Provider = val
+	}
+
+	// Check for profile selection
+	if val := os.Getenv("DOCLOOM_PROFILE"); val != "" {
+		cfg.Profile = val
+	}
+
+	// Check for numeric and boolean overrides; invalid values are ignored
 	if val := os.Getenv("DOCLOOM_TEMPERATURE"); val != "" {
-		// In production, parse to float64
-		log.Debug().Str("temperature", val).Msg("Temperature override from env")
+		if temperature, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Temperature = temperature
+		} else {
+			log.Warn().Str("DOCLOOM_TEMPERATURE", val).Msg("Ignoring invalid temperature")
+		}
 	}
+	loadIntFromEnv(&cfg.Seed, "DOCLOOM_SEED")
+	loadIntFromEnv(&cfg.MaxRetries, "DOCLOOM_MAX_RETRIES")
+	loadBoolFromEnv(&cfg.Force, "DOCLOOM_FORCE")
+	loadBoolFromEnv(&cfg.Verbose, "DOCLOOM_VERBOSE")
+	loadBoolFromEnv(&cfg.DryRun, "DOCLOOM_DRY_RUN")
 
 	// Check for template directory override
 	if val := os.Getenv("DOCLOOM_TEMPLATE_DIR"); val != "" {
 		cfg.TemplateDir = val
 	}
+
+	// Check for prompt directory override
+	if val := os.Getenv("DOCLOOM_PROMPT_DIR"); val != "" {
+		cfg.PromptDir = val
+	}
+
+	// Check for cache directory override
+	if val := os.Getenv("DOCLOOM_CACHE_DIR"); val != "" {
+		cfg.CacheDir = val
+	}
+
+	// Check for output permission overrides
+	if val := os.Getenv("DOCLOOM_OUTPUT_FILE_MODE"); val != "" {
+		cfg.OutputFileMode = val
+	}
+	if val := os.Getenv("DOCLOOM_OUTPUT_DIR_MODE"); val != "" {
+		cfg.OutputDirMode = val
+	}
+}
+
+// loadIntFromEnv sets target from the environment variable name if it is an integer
+func loadIntFromEnv(target *int, name string) {
+	if val := os.Getenv(name); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			*target = n
+		} else {
+			log.Warn().Str(name, val).Msg("Ignoring invalid integer")
+		}
+	}
+}
+
+// loadBoolFromEnv sets target from the environment variable name if it is a boolean
+func loadBoolFromEnv(target *bool, name string) {
+	if val := os.Getenv(name); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			*target = b
+		} else {
+			log.Warn().Str(name, val).Msg("Ignoring invalid boolean")
+		}
+	}
 }
 
 // applyStringOverride applies a string override if valid
@@ -193,11 +663,21 @@ func (c *Config) Validate() error {
 	if c.MaxRetries < 0 {
 		c.MaxRetries = 0
 	}
+	if c.MaxTokens < 0 {
+		c.MaxTokens = 0
+	}
 
 	if c.Temperature < 0 || c.Temperature > 2 {
 		c.Temperature = 0.7 // Reset to default if out of range
 	}
 
+	if _, _, err := c.OutputModes(); err != nil {
+		return err
+	}
+	if _, err := c.Verifier(); err != nil {
+		return err
+	}
+
 	// Ensure template directory is absolute or relative to working directory
 	if c.TemplateDir != "" && !filepath.IsAbs(c.TemplateDir) {
 		if wd, err := os.Getwd(); err == nil {
@@ -208,6 +688,84 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// OutputModes returns the permissions of generated files and directories, or 0 for
+// those that are not configured.
+func (c *Config) OutputModes() (file, dir os.FileMode, err error) {
+	if file, err = parseMode(c.OutputFileMode); err != nil {
+		return 0, 0, fmt.Errorf("invalid output_file_mode: %w", err)
+	}
+	if dir, err = parseMode(c.OutputDirMode); err != nil {
+		return 0, 0, fmt.Errorf("invalid output_dir_mode: %w", err)
+	}
+	return file, dir, nil
+}
+
+// Verifier returns the verifier of template pack and agent signatures configured
+// under trust.
+func (c *Config) Verifier() (*trust.Verifier, error) {
+	verifier, err := trust.NewVerifier(c.Trust.Policy, c.Trust.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust configuration: %w", err)
+	}
+	return verifier, nil
+}
+
+// parseMode parses an octal permission such as "0644" or "755"; empty is 0.
+func parseMode(value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(strings.TrimPrefix(value, "0o"), 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("%q is not an octal permission such as 0644", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// Redacted returns a copy of the config safe to share, for example in a triage bundle:
+// the API key, extra header and query values and webhook URLs are replaced.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	if redacted.APIKey != "" {
+		redacted.APIKey = RedactedValue
+	}
+	if redacted.Embeddings.APIKey != "" {
+		redacted.Embeddings.APIKey = RedactedValue
+	}
+	if c.Profiles != nil {
+		redacted.Profiles = make(map[string]ProfileConfig, len(c.Profiles))
+		for name, profile := range c.Profiles {
+			if profile.APIKey != "" {
+				profile.APIKey = RedactedValue
+			}
+			redacted.Profiles[name] = profile
+		}
+	}
+	redacted.ExtraHeaders = redactValues(c.ExtraHeaders)
+	redacted.ExtraQuery = redactValues(c.ExtraQuery)
+	redacted.Notifications.Webhooks = make([]WebhookConfig, len(c.Notifications.Webhooks))
+	for idx, webhook := range c.Notifications.Webhooks {
+		webhook.URL = RedactedValue
+		redacted.Notifications.Webhooks[idx] = webhook
+	}
+	return &redacted
+}
+
+// RedactedValue replaces sensitive values in shared configuration.
+const RedactedValue = "[REDACTED]"
+
+// redactValues returns a copy of values with every value replaced.
+func redactValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(values))
+	for key := range values {
+		redacted[key] = RedactedValue
+	}
+	return redacted
+}
+
 // String returns a string representation of the config (hiding sensitive values)
 func (c *Config) String() string {
 	apiKeyDisplay := "<not set>"
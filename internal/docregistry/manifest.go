@@ -0,0 +1,79 @@
+package docregistry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/ingest"
+)
+
+// BuildManifest hashes every file under the source paths. Hidden files and
+// directories (such as .git) are skipped. Source labels ("label=path") are ignored.
+func BuildManifest(sources []string) (map[string]string, error) {
+	manifest := make(map[string]string)
+	for _, source := range ingest.SourcePaths(sources) {
+		err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != source && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			sum, err := HashFile(path)
+			if err != nil {
+				return err
+			}
+			manifest[filepath.ToSlash(path)] = sum
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash source %s: %w", source, err)
+		}
+	}
+	return manifest, nil
+}
+
+// ManifestHash combines a manifest into a single hash.
+func ManifestHash(manifest map[string]string) string {
+	paths := make([]string, 0, len(manifest))
+	for path := range manifest {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s %s\n", manifest[path], path)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashFile returns the hex-encoded SHA-256 of a file's contents, as recorded in
+// source manifests and run manifests.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path) // #nosec G304 -- paths of sources and outputs
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,150 @@
+// Package docregistry maintains an inventory of generated documents across runs.
+package docregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry describes a generated document.
+type Entry struct {
+	CreatedAt   time.Time         `json:"created_at"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Manifest    map[string]string `json:"manifest,omitempty"` // Source file path -> SHA-256
+	ID          string            `json:"id"`
+	Title       string            `json:"title,omitempty"`
+	Template    string            `json:"template"`
+	Output      string            `json:"output"`
+	JSONFile    string            `json:"json_file,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	Model       string            `json:"model,omitempty"`
+	SourcesHash string            `json:"sources_hash"`
+	Sources     []string          `json:"sources"`
+	Version     int               `json:"version"` // Incremented on every regeneration
+}
+
+// file is the on-disk layout of the registry.
+type file struct {
+	Documents []Entry `json:"documents"`
+}
+
+// Registry persists document entries in a JSON file.
+type Registry struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New creates a registry backed by the given file.
+func New(path string) *Registry {
+	return &Registry{path: path}
+}
+
+// Path returns the registry file path.
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// DocumentID derives a stable document identifier from its output path.
+func DocumentID(output string) string {
+	cleaned := filepath.ToSlash(filepath.Clean(output))
+	return strings.TrimSuffix(cleaned, filepath.Ext(cleaned))
+}
+
+// Record inserts or replaces the entry with the same ID, incrementing its version
+// and preserving its creation time. The stored entry is returned.
+func (r *Registry) Record(entry Entry) (Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := r.load()
+	if err != nil {
+		return entry, err
+	}
+
+	if entry.ID == "" {
+		entry.ID = DocumentID(entry.Output)
+	}
+	if entry.GeneratedAt.IsZero() {
+		entry.GeneratedAt = time.Now().UTC()
+	}
+	entry.CreatedAt = entry.GeneratedAt
+	entry.Version = 1
+
+	replaced := false
+	for i, existing := range entries {
+		if existing.ID != entry.ID {
+			continue
+		}
+		entry.CreatedAt = existing.CreatedAt
+		entry.Version = existing.Version + 1
+		entries[i] = entry
+		replaced = true
+		break
+	}
+	if !replaced {
+		entries = append(entries, entry)
+	}
+
+	if err := r.save(entries); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// List returns all entries sorted by ID. A missing registry file yields no entries.
+func (r *Registry) List() ([]Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load()
+}
+
+// load reads the registry file.
+func (r *Registry) load() ([]Entry, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read document registry: %w", err)
+	}
+
+	var contents file
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, fmt.Errorf("failed to parse document registry %s: %w", r.path, err)
+	}
+	sort.Slice(contents.Documents, func(i, j int) bool {
+		return contents.Documents[i].ID < contents.Documents[j].ID
+	})
+	return contents.Documents, nil
+}
+
+// save writes the registry file atomically.
+func (r *Registry) save(entries []Entry) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	data, err := json.MarshalIndent(file{Documents: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal document registry: %w", err)
+	}
+
+	if dir := filepath.Dir(r.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create registry directory: %w", err)
+		}
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write document registry: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to replace document registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package docregistry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_RecordVersionsAcrossRuns(t *testing.T) {
+	reg := New(filepath.Join(t.TempDir(), "docs", "docs-registry.json"))
+
+	first := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	entry, err := reg.Record(Entry{Template: "architecture-vision", Output: "out/arch.html", SourcesHash: "a", GeneratedAt: first})
+	require.NoError(t, err)
+	assert.Equal(t, "out/arch", entry.ID)
+	assert.Equal(t, 1, entry.Version)
+
+	_, err = reg.Record(Entry{Template: "technical-debt-summary", Output: "out/debt.html", SourcesHash: "b"})
+	require.NoError(t, err)
+
+	second := first.Add(24 * time.Hour)
+	entry, err = reg.Record(Entry{Template: "architecture-vision", Output: "out/arch.html", SourcesHash: "c", GeneratedAt: second})
+	require.NoError(t, err)
+	assert.Equal(t, 2, entry.Version)
+	assert.Equal(t, first, entry.CreatedAt)
+	assert.Equal(t, second, entry.GeneratedAt)
+
+	entries, err := reg.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "out/arch", entries[0].ID)
+	assert.Equal(t, "c", entries[0].SourcesHash)
+	assert.Equal(t, "out/debt", entries[1].ID)
+}
+
+func TestRegistry_ListMissingFile(t *testing.T) {
+	entries, err := New(filepath.Join(t.TempDir(), "missing.json")).List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestBuildManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("alpha"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0600))
+
+	manifest, err := BuildManifest([]string{dir})
+	require.NoError(t, err)
+	require.Len(t, manifest, 1)
+	assert.Contains(t, manifest, filepath.ToSlash(filepath.Join(dir, "a.md")))
+
+	hash := ManifestHash(manifest)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("changed"), 0600))
+	changed, err := BuildManifest([]string{dir})
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, ManifestHash(changed))
+}
@@ -0,0 +1,80 @@
+package docregistry
+
+import (
+	"os"
+	"sort"
+
+	"github.com/karolswdev/docloom/internal/ingest"
+)
+
+// Freshness states reported for registry entries.
+const (
+	StateFresh   = "fresh"
+	StateStale   = "stale"
+	StateMissing = "missing" // Output file no longer exists
+)
+
+// Status describes whether a generated document is up to date with its sources.
+type Status struct {
+	State   string
+	Added   []string // Source files created since generation
+	Removed []string // Source files deleted since generation
+	Changed []string // Source files modified since generation
+	Entry   Entry
+}
+
+// Check compares an entry's recorded source manifest against the current files.
+func Check(entry Entry) (Status, error) {
+	status := Status{Entry: entry, State: StateFresh}
+
+	if _, err := os.Stat(entry.Output); os.IsNotExist(err) {
+		status.State = StateMissing
+		return status, nil
+	}
+
+	current, err := BuildManifest(ExistingSources(entry.Sources))
+	if err != nil {
+		return status, err
+	}
+
+	status.Added, status.Removed, status.Changed = DiffManifests(entry.Manifest, current)
+	if ManifestHash(current) != entry.SourcesHash {
+		status.State = StateStale
+	}
+	return status, nil
+}
+
+// DiffManifests lists files added, removed and changed between two manifests.
+func DiffManifests(previous, current map[string]string) (added, removed, changed []string) {
+	for path, sum := range current {
+		old, ok := previous[path]
+		switch {
+		case !ok:
+			added = append(added, path)
+		case old != sum:
+			changed = append(changed, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// ExistingSources drops source paths that no longer exist, so their files are
+// reported as removed rather than failing the check.
+func ExistingSources(sources []string) []string {
+	existing := make([]string, 0, len(sources))
+	for _, source := range sources {
+		_, path := ingest.SplitSource(source)
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, source)
+		}
+	}
+	return existing
+}
@@ -0,0 +1,46 @@
+package docregistry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck_DetectsStaleDocuments(t *testing.T) {
+	sourceDir := t.TempDir()
+	keep := filepath.Join(sourceDir, "keep.md")
+	edit := filepath.Join(sourceDir, "edit.md")
+	drop := filepath.Join(sourceDir, "drop.md")
+	for _, path := range []string{keep, edit, drop} {
+		require.NoError(t, os.WriteFile(path, []byte(filepath.Base(path)), 0600))
+	}
+	output := filepath.Join(t.TempDir(), "doc.html")
+	require.NoError(t, os.WriteFile(output, []byte("<html></html>"), 0600))
+
+	manifest, err := BuildManifest([]string{sourceDir})
+	require.NoError(t, err)
+	entry := Entry{Output: output, Sources: []string{sourceDir}, Manifest: manifest, SourcesHash: ManifestHash(manifest)}
+
+	status, err := Check(entry)
+	require.NoError(t, err)
+	assert.Equal(t, StateFresh, status.State)
+
+	require.NoError(t, os.WriteFile(edit, []byte("edited"), 0600))
+	require.NoError(t, os.Remove(drop))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "new.md"), []byte("new"), 0600))
+
+	status, err = Check(entry)
+	require.NoError(t, err)
+	assert.Equal(t, StateStale, status.State)
+	assert.Equal(t, []string{filepath.ToSlash(edit)}, status.Changed)
+	assert.Equal(t, []string{filepath.ToSlash(drop)}, status.Removed)
+	assert.Equal(t, []string{filepath.ToSlash(filepath.Join(sourceDir, "new.md"))}, status.Added)
+
+	require.NoError(t, os.Remove(output))
+	status, err = Check(entry)
+	require.NoError(t, err)
+	assert.Equal(t, StateMissing, status.State)
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/karolswdev/docloom/internal/templates"
 )
 
+// DefaultMaxTurns bounds the turns of an analysis loop whose options set none.
+const DefaultMaxTurns = 20
+
 // AnalysisOptions contains configuration for the analysis loop.
 type AnalysisOptions struct {
 	AgentName   string
@@ -21,6 +24,36 @@ type AnalysisOptions struct {
 	SourcePath  string
 	MaxTurns    int
 	AgentParams map[string]string
+
+	// RequestParams overrides the client's temperature, seed and retries for every
+	// turn of the loop and the final repairs; nil keeps the client defaults.
+	RequestParams *ai.RequestParams
+
+	// Usage, if set, meters the tokens of every turn and repair against its budget.
+	Usage *ai.Meter
+
+	// MaxRepairs bounds the schema repair attempts made on the final document,
+	// matching Options.MaxRepairs on the direct generation path.
+	MaxRepairs int
+
+	// StripInstructions removes instruction-like text from tool outputs in addition
+	// to delimiting them as data.
+	StripInstructions bool
+
+	// ToolCache, if set, keeps tool outputs across runs so repeated analyses of
+	// unchanged sources reuse them. Within a run, repeated calls are always memoized.
+	ToolCache *agent.ToolResultCache
+
+	memo *toolMemo // Set by RunAnalysisLoop
+}
+
+// DiscoverAgents loads the agents of the default search paths, whose tools analysis
+// loops call.
+func (o *Orchestrator) DiscoverAgents() error {
+	if err := o.agentRegistry.Discover(); err != nil {
+		return fmt.Errorf("failed to discover agents: %w", err)
+	}
+	return nil
 }
 
 // RunAnalysisLoop executes the multi-turn conversation between AI and agent tools.
@@ -30,9 +63,22 @@ func (o *Orchestrator) RunAnalysisLoop(ctx context.Context, opts AnalysisOptions
 	if !exists {
 		return "", fmt.Errorf("agent not found: %s", opts.AgentName)
 	}
+	if err := o.requireToolCalling(opts.AgentName); err != nil {
+		return "", err
+	}
+
+	if opts.MaxTurns <= 0 {
+		opts.MaxTurns = DefaultMaxTurns
+	}
 
 	// Convert agent tools to AI tools
 	aiTools := convertAgentTools(agentDef)
+	opts.memo = newToolMemo(agentDef, opts)
+
+	if opts.RequestParams != nil {
+		ctx = ai.WithRequestParams(ctx, *opts.RequestParams)
+	}
+	ctx = ai.WithMeter(ctx, opts.Usage)
 
 	// Initialize conversation
 	messages := o.initializeConversation(opts)
@@ -44,12 +90,13 @@ func (o *Orchestrator) RunAnalysisLoop(ctx context.Context, opts AnalysisOptions
 
 	// Analysis loop
 	for turn := 0; turn < opts.MaxTurns; turn++ {
-		result, shouldContinue, err := o.executeAnalysisTurn(ctx, turn, messages, aiTools, opts)
+		result, shouldContinue, err := o.executeAnalysisTurn(ctx, turn, &messages, aiTools, opts)
 		if err != nil {
 			return "", err
 		}
 		if result != "" {
-			return result, nil
+			// Validate and repair the final document like the direct generation path
+			return o.repairUntilValid(ctx, o.aiClient, analysisContext(messages), result, opts.Template, opts.MaxRepairs)
 		}
 		if !shouldContinue {
 			break
@@ -61,14 +108,15 @@ func (o *Orchestrator) RunAnalysisLoop(ctx context.Context, opts AnalysisOptions
 
 // initializeConversation sets up the initial message context.
 func (o *Orchestrator) initializeConversation(opts AnalysisOptions) []ai.ChatMessage {
+	analysis := analysisPrompts(opts.Template)
 	messages := []ai.ChatMessage{
 		{
 			Role:    "system",
-			Content: opts.Template.Analysis.SystemPrompt,
+			Content: analysis.SystemPrompt + toolOutputReminder,
 		},
 		{
 			Role:    "user",
-			Content: opts.Template.Analysis.InitialUserPrompt,
+			Content: analysis.InitialUserPrompt,
 		},
 	}
 
@@ -80,11 +128,69 @@ func (o *Orchestrator) initializeConversation(opts AnalysisOptions) []ai.ChatMes
 	return messages
 }
 
+// analysisPrompts returns the analysis prompts of a template. Prompts the template
+// does not declare are synthesized from its description, prompt and schema fields,
+// with a warning, so any template can be generated with an agent.
+func analysisPrompts(tmpl *templates.Template) templates.Analysis {
+	var analysis templates.Analysis
+	if tmpl.Analysis != nil {
+		analysis = *tmpl.Analysis
+	}
+	if analysis.SystemPrompt != "" && analysis.InitialUserPrompt != "" {
+		return analysis
+	}
+	log.Warn().Str("template", tmpl.Name).Msg("Template has no analysis prompts; synthesizing them from its description and schema")
+
+	if analysis.SystemPrompt == "" {
+		var system strings.Builder
+		system.WriteString(fmt.Sprintf("You are a technical analyst writing a %s document", tmpl.Name))
+		if tmpl.Description != "" {
+			system.WriteString(fmt.Sprintf(" (%s)", strings.TrimSuffix(tmpl.Description, ".")))
+		}
+		system.WriteString(". Use the available tools to explore the repository's code, configuration and documentation, ")
+		system.WriteString("and base every field of the document on what you find rather than on assumptions. ")
+		system.WriteString("When you have gathered enough information, respond with the final document as a single JSON object and no other text.")
+		analysis.SystemPrompt = system.String()
+	}
+	if analysis.InitialUserPrompt == "" {
+		var user strings.Builder
+		user.WriteString(fmt.Sprintf("Analyze the repository and write the %s document.", tmpl.Name))
+		if description, err := templates.Describe(tmpl); err == nil && len(description.Fields) > 0 {
+			user.WriteString("\n\nThe document has these fields:\n")
+			for _, field := range description.Fields {
+				user.WriteString(fmt.Sprintf("- %s: %s\n", field.Path, field.Signature))
+			}
+		}
+		if tmpl.Prompt != "" {
+			user.WriteString("\n## Template Instructions\n")
+			user.WriteString(tmpl.Prompt)
+			user.WriteString("\n")
+		}
+		analysis.InitialUserPrompt = strings.TrimRight(user.String(), "\n")
+	}
+	return analysis
+}
+
+// analysisContext returns the system and user prompts that started the analysis,
+// used as the original context for repair prompts.
+func analysisContext(messages []ai.ChatMessage) string {
+	var parts []string
+	for _, msg := range messages {
+		if msg.Role == "system" || msg.Role == "user" {
+			parts = append(parts, msg.Content)
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 // executeAnalysisTurn performs a single turn of the analysis loop.
-func (o *Orchestrator) executeAnalysisTurn(ctx context.Context, turn int, messages []ai.ChatMessage, aiTools []ai.Tool, opts AnalysisOptions) (string, bool, error) {
+func (o *Orchestrator) executeAnalysisTurn(ctx context.Context, turn int, messages *[]ai.ChatMessage, aiTools []ai.Tool, opts AnalysisOptions) (string, bool, error) {
 	log.Debug().
 		Int("turn", turn+1).
-		Int("messages", len(messages)).
+		Int("messages", len(*messages)).
 		Msg("Sending request to AI")
 
 	// Get AI response
@@ -93,14 +199,14 @@ func (o *Orchestrator) executeAnalysisTurn(ctx context.Context, turn int, messag
 		return "", false, fmt.Errorf("AI client does not support tool calling")
 	}
 
-	response, err := openaiClient.ChatWithTools(ctx, messages, aiTools)
+	response, err := openaiClient.ChatWithTools(ctx, *messages, aiTools)
 	if err != nil {
 		return "", false, fmt.Errorf("AI request failed: %w", err)
 	}
 
 	// Check if AI wants to call tools
 	if len(response.ToolCalls) > 0 {
-		err := o.handleToolCalls(response.ToolCalls, &messages, opts)
+		err := o.handleToolCalls(response.ToolCalls, messages, opts)
 		if err != nil {
 			return "", false, err
 		}
@@ -108,7 +214,7 @@ func (o *Orchestrator) executeAnalysisTurn(ctx context.Context, turn int, messag
 	}
 
 	// AI provided a response
-	return o.handleAIResponse(response, &messages)
+	return o.handleAIResponse(response, messages)
 }
 
 // handleToolCalls processes and executes requested tool calls.
@@ -142,11 +248,13 @@ func (o *Orchestrator) executeSingleTool(toolCall ai.ToolCall, messages *[]ai.Ch
 		Str("id", toolCall.ID).
 		Msg("Executing tool")
 
-	// Parse and prepare arguments
-	args := o.prepareToolArguments(toolCall, opts)
-
-	// Execute the tool
-	toolOutput, err := o.agentExecutor.RunTool(opts.AgentName, toolCall.Name, args)
+	// Reject arguments that do not match the tool's declared schema before running it
+	var toolOutput, note string
+	err := o.validateToolArguments(toolCall, opts)
+	if err == nil {
+		args := o.prepareToolArguments(toolCall, opts)
+		toolOutput, note, err = o.runToolMemoized(toolCall.Name, args, opts)
+	}
 	if err != nil {
 		// Add error as tool response
 		toolOutput = fmt.Sprintf("Error executing tool: %v", err)
@@ -156,10 +264,10 @@ func (o *Orchestrator) executeSingleTool(toolCall ai.ToolCall, messages *[]ai.Ch
 			Msg("Tool execution failed")
 	}
 
-	// Add tool response to conversation
+	// Add tool response to conversation, delimited as untrusted data
 	toolMsg := ai.ChatMessage{
 		Role:       "tool",
-		Content:    toolOutput,
+		Content:    note + SanitizeToolOutput(toolCall.Name, toolOutput, opts.StripInstructions),
 		ToolCallID: toolCall.ID,
 	}
 	*messages = append(*messages, toolMsg)
@@ -172,13 +280,72 @@ func (o *Orchestrator) executeSingleTool(toolCall ai.ToolCall, messages *[]ai.Ch
 	return nil
 }
 
+// runToolMemoized runs a tool, answering calls already made with the same arguments
+// from the run's memo along with a note saying so. Failed calls are not memoized.
+func (o *Orchestrator) runToolMemoized(tool string, args map[string]string, opts AnalysisOptions) (output string, note string, err error) {
+	if opts.memo == nil {
+		output, err = o.agentExecutor.RunTool(opts.AgentName, tool, opts.SourcePath, args)
+		return output, "", err
+	}
+	key := opts.memo.key(tool, args)
+	if output, note, ok := opts.memo.lookup(key); ok {
+		log.Info().Str("tool", tool).Msg("Reusing memoized tool result")
+		return output, note, nil
+	}
+	output, err = o.agentExecutor.RunTool(opts.AgentName, tool, opts.SourcePath, args)
+	if err != nil {
+		return "", "", err
+	}
+	opts.memo.store(key, output)
+	return output, "", nil
+}
+
+// validateToolArguments checks model-supplied arguments against the tool's declared
+// parameters schema. Tools without a schema accept any arguments.
+func (o *Orchestrator) validateToolArguments(toolCall ai.ToolCall, opts AnalysisOptions) error {
+	agentDef, exists := o.agentRegistry.Get(opts.AgentName)
+	if !exists {
+		return nil
+	}
+
+	for _, tool := range agentDef.Spec.Tools {
+		if tool.Name != toolCall.Name || tool.Parameters == nil {
+			continue
+		}
+		schema, err := json.Marshal(tool.Parameters)
+		if err != nil {
+			return fmt.Errorf("invalid parameters schema for tool %s: %w", tool.Name, err)
+		}
+		arguments := strings.TrimSpace(string(toolCall.Arguments))
+		if arguments == "" {
+			arguments = "{}"
+		}
+		if err := o.validator.Validate(arguments, string(schema)); err != nil {
+			return fmt.Errorf("invalid arguments for tool %s: %w", tool.Name, err)
+		}
+	}
+	return nil
+}
+
 // prepareToolArguments prepares arguments for tool execution.
 func (o *Orchestrator) prepareToolArguments(toolCall ai.ToolCall, opts AnalysisOptions) map[string]string {
-	// Parse tool arguments
-	var args map[string]string
-	if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+	// Parse tool arguments; typed values are passed to the tool in their JSON form
+	var raw map[string]interface{}
+	args := map[string]string{}
+	if err := json.Unmarshal(toolCall.Arguments, &raw); err != nil {
 		// If arguments are not a map, try as a simple string
-		args = map[string]string{"input": string(toolCall.Arguments)}
+		args["input"] = string(toolCall.Arguments)
+	}
+	for k, v := range raw {
+		if str, ok := v.(string); ok {
+			args[k] = str
+			continue
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		args[k] = string(encoded)
 	}
 
 	// Merge with agent parameters
@@ -188,8 +355,13 @@ func (o *Orchestrator) prepareToolArguments(toolCall ai.ToolCall, opts AnalysisO
 		}
 	}
 
-	// Add source path if not present
-	if _, exists := args["SOURCE_PATH"]; !exists && opts.SourcePath != "" {
+	// The source path is never taken from the model
+	for k := range args {
+		if strings.EqualFold(k, "SOURCE_PATH") {
+			delete(args, k)
+		}
+	}
+	if opts.SourcePath != "" {
 		args["SOURCE_PATH"] = opts.SourcePath
 	}
 
@@ -230,30 +402,10 @@ func convertAgentTools(agentDef *agent.Definition) []ai.Tool {
 	tools := make([]ai.Tool, 0, len(agentDef.Spec.Tools))
 
 	for _, agentTool := range agentDef.Spec.Tools {
-		// Create parameter schema based on the tool's expected inputs
-		params := map[string]interface{}{
-			"type":       "object",
-			"properties": map[string]interface{}{},
-		}
-
-		// Parse arguments to determine expected parameters
-		for _, arg := range agentTool.Args {
-			if strings.Contains(arg, "${") {
-				// Extract parameter name
-				start := strings.Index(arg, "${")
-				end := strings.Index(arg[start:], "}")
-				if end > 0 {
-					paramName := arg[start+2 : start+end]
-					// Convert to lowercase for consistency
-					paramKey := strings.ToLower(paramName)
-					if props, ok := params["properties"].(map[string]interface{}); ok {
-						props[paramKey] = map[string]interface{}{
-							"type":        "string",
-							"description": fmt.Sprintf("Value for %s", paramName),
-						}
-					}
-				}
-			}
+		// Prefer the schema declared in the manifest over inferred parameters
+		params := agentTool.Parameters
+		if params == nil {
+			params = inferToolParameters(agentTool)
 		}
 
 		aiTool := ai.Tool{
@@ -267,3 +419,29 @@ func convertAgentTools(agentDef *agent.Definition) []ai.Tool {
 
 	return tools
 }
+
+// inferToolParameters builds a string-only parameter schema from the ${VAR}
+// placeholders in a tool's arguments.
+func inferToolParameters(agentTool agent.Tool) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for _, arg := range agentTool.Args {
+		if strings.Contains(arg, "${") {
+			// Extract parameter name
+			start := strings.Index(arg, "${")
+			end := strings.Index(arg[start:], "}")
+			if end > 0 {
+				paramName := arg[start+2 : start+end]
+				// Convert to lowercase for consistency
+				properties[strings.ToLower(paramName)] = map[string]interface{}{
+					"type":        "string",
+					"description": fmt.Sprintf("Value for %s", paramName),
+				}
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
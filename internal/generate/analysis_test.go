@@ -0,0 +1,277 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/agent"
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+const schemaAgentYAML = `apiVersion: docloom.io/v1alpha1
+kind: Agent
+metadata:
+  name: schema-agent
+  description: Agent with declared tool schemas
+spec:
+  tools:
+    - name: get_file_content
+      description: Reads a file
+      command: cat
+      args: ["${FILE_PATH}"]
+      parameters:
+        type: object
+        properties:
+          file_path:
+            type: string
+            description: Path relative to the source root
+          max_lines:
+            type: integer
+            minimum: 1
+        required: [file_path]
+        additionalProperties: false
+    - name: list_projects
+      description: Lists projects
+      command: echo
+      args: ["${SOURCE_PATH}"]
+`
+
+func setupSchemaAgent(t *testing.T) *Orchestrator {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.agent.yaml"), []byte(schemaAgentYAML), 0644))
+
+	orchestrator := NewOrchestrator(&promptCapturingClient{})
+	orchestrator.agentRegistry = agent.NewRegistry()
+	orchestrator.agentRegistry.AddSearchPath(dir)
+	require.NoError(t, orchestrator.agentRegistry.Discover())
+	return orchestrator
+}
+
+func TestConvertAgentTools_UsesDeclaredSchema(t *testing.T) {
+	orchestrator := setupSchemaAgent(t)
+	agentDef, exists := orchestrator.agentRegistry.Get("schema-agent")
+	require.True(t, exists)
+
+	tools := convertAgentTools(agentDef)
+	require.Len(t, tools, 2)
+
+	// Declared schema is passed through unchanged
+	declared, err := json.Marshal(tools[0].Parameters)
+	require.NoError(t, err)
+	assert.Contains(t, string(declared), `"max_lines":{"minimum":1,"type":"integer"}`)
+	assert.Contains(t, string(declared), `"required":["file_path"]`)
+
+	// Tools without a schema fall back to placeholder inference
+	inferred := tools[1].Parameters.(map[string]interface{})
+	props := inferred["properties"].(map[string]interface{})
+	assert.Contains(t, props, "source_path")
+}
+
+func TestValidateToolArguments(t *testing.T) {
+	orchestrator := setupSchemaAgent(t)
+	opts := AnalysisOptions{AgentName: "schema-agent"}
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments string
+		wantErr   bool
+	}{
+		{name: "valid arguments", tool: "get_file_content", arguments: `{"file_path": "src/app.cs", "max_lines": 20}`},
+		{name: "missing required", tool: "get_file_content", arguments: `{"max_lines": 20}`, wantErr: true},
+		{name: "wrong type", tool: "get_file_content", arguments: `{"file_path": "a.cs", "max_lines": "many"}`, wantErr: true},
+		{name: "unknown property", tool: "get_file_content", arguments: `{"file_path": "a.cs", "mode": "raw"}`, wantErr: true},
+		{name: "tool without schema", tool: "list_projects", arguments: `{"anything": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolCall := ai.ToolCall{ID: "1", Name: tt.tool, Arguments: json.RawMessage(tt.arguments)}
+			err := orchestrator.validateToolArguments(toolCall, opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid arguments for tool "+tt.tool)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrepareToolArguments_EncodesTypedValues(t *testing.T) {
+	orchestrator := NewOrchestrator(&promptCapturingClient{})
+	toolCall := ai.ToolCall{Name: "get_file_content", Arguments: json.RawMessage(`{"file_path": "a.cs", "max_lines": 20, "tags": ["x"]}`)}
+
+	args := orchestrator.prepareToolArguments(toolCall, AnalysisOptions{SourcePath: "/src"})
+
+	assert.Equal(t, "a.cs", args["file_path"])
+	assert.Equal(t, "20", args["max_lines"])
+	assert.Equal(t, `["x"]`, args["tags"])
+	assert.Equal(t, "/src", args["SOURCE_PATH"])
+}
+
+func TestPrepareToolArguments_OverridesModelSourcePath(t *testing.T) {
+	orchestrator := NewOrchestrator(&promptCapturingClient{})
+	toolCall := ai.ToolCall{Name: "get_file_content", Arguments: json.RawMessage(`{"SOURCE_PATH": "/", "source_path": "..", "file_path": "/etc/passwd"}`)}
+
+	args := orchestrator.prepareToolArguments(toolCall, AnalysisOptions{SourcePath: "/src"})
+
+	assert.Equal(t, map[string]string{"SOURCE_PATH": "/src", "file_path": "/etc/passwd"}, args)
+}
+
+func TestRepairUntilValid_RepairsAnalysisOutput(t *testing.T) {
+	tmpl := &templates.Template{
+		Name:   "agent-template",
+		Schema: json.RawMessage(`{"type":"object","properties":{"body":{"type":"string"}},"required":["body"]}`),
+	}
+	messages := []ai.ChatMessage{
+		{Role: "system", Content: "You analyze repositories."},
+		{Role: "user", Content: "Describe the ledger service."},
+	}
+
+	t.Run("repairs invalid final document", func(t *testing.T) {
+		client := &promptCapturingClient{responses: []string{`{"body": "Ledger owns balances."}`}}
+		orchestrator := NewOrchestrator(client)
+
+		repaired, err := orchestrator.repairUntilValid(context.Background(), client, analysisContext(messages), `{"summary": "wrong field"}`, tmpl, 2)
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"body": "Ledger owns balances."}`, repaired)
+		require.Len(t, client.prompts, 1)
+		assert.Contains(t, client.prompts[0], "failed validation")
+		assert.Contains(t, client.prompts[0], "Describe the ledger service.")
+	})
+
+	t.Run("valid document needs no repair", func(t *testing.T) {
+		client := &promptCapturingClient{}
+		orchestrator := NewOrchestrator(client)
+
+		_, err := orchestrator.repairUntilValid(context.Background(), client, "", `{"body": "ok"}`, tmpl, 2)
+
+		require.NoError(t, err)
+		assert.Empty(t, client.prompts)
+	})
+
+	t.Run("gives up after max repairs", func(t *testing.T) {
+		client := &promptCapturingClient{responses: []string{`{}`, `{}`}}
+		orchestrator := NewOrchestrator(client)
+
+		_, err := orchestrator.repairUntilValid(context.Background(), client, "", `{}`, tmpl, 2)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "after 3 attempts")
+		assert.Len(t, client.prompts, 2)
+	})
+}
+
+func TestRepairUntilValid_ConsistencyRules(t *testing.T) {
+	tmpl := &templates.Template{
+		Name:   "inventory",
+		Schema: json.RawMessage(`{"type":"object"}`),
+		Rules: []validate.Rule{{
+			Name:    "component-total",
+			Expr:    "sum(components[*].count) == total",
+			Message: "total must equal the sum of the component counts",
+		}},
+	}
+	client := &promptCapturingClient{responses: []string{`{"components": [{"count": 2}, {"count": 3}], "total": 5}`}}
+	orchestrator := NewOrchestrator(client)
+
+	repaired, err := orchestrator.repairUntilValid(context.Background(), client, "", `{"components": [{"count": 2}, {"count": 3}], "total": 4}`, tmpl, 1)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"components": [{"count": 2}, {"count": 3}], "total": 5}`, repaired)
+	require.Len(t, client.prompts, 1)
+	assert.Contains(t, client.prompts[0], "component-total: total must equal the sum of the component counts")
+}
+
+func TestInitializeConversation_SynthesizesMissingAnalysisPrompts(t *testing.T) {
+	orchestrator := NewOrchestrator(&promptCapturingClient{})
+	tmpl := &templates.Template{
+		Name:        "service-brief",
+		Description: "One-page summary of a service.",
+		Prompt:      "Keep the overview under 100 words.",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"overview":{"type":"string"},"owners":{"type":"array","items":{"type":"string"}}},"required":["overview"]}`),
+	}
+
+	messages := orchestrator.initializeConversation(AnalysisOptions{Template: tmpl, SourcePath: "/src"})
+
+	require.Len(t, messages, 2)
+	assert.Contains(t, messages[0].Content, "writing a service-brief document (One-page summary of a service)")
+	assert.Contains(t, messages[0].Content, "single JSON object")
+	assert.Contains(t, messages[0].Content, toolOutputReminder)
+	assert.Contains(t, messages[1].Content, "- overview: string, required\n")
+	assert.Contains(t, messages[1].Content, "- owners: array")
+	assert.Contains(t, messages[1].Content, "## Template Instructions\nKeep the overview under 100 words.")
+	assert.Contains(t, messages[1].Content, "Repository path: /src")
+
+	t.Run("declared prompts are kept", func(t *testing.T) {
+		tmpl.Analysis = &templates.Analysis{SystemPrompt: "You review services."}
+
+		messages := orchestrator.initializeConversation(AnalysisOptions{Template: tmpl})
+
+		assert.Equal(t, "You review services."+toolOutputReminder, messages[0].Content)
+		assert.Contains(t, messages[1].Content, "Analyze the repository and write the service-brief document.")
+	})
+}
+
+func TestRun_AgentAnalysis(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "repo")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "Payments.csproj"), []byte("<Project/>"), 0644))
+
+	// The model lists the projects, then answers with the document
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+		message := `{"role": "assistant", "tool_calls": [{"id": "call-1", "type": "function", "function": {"name": "list_projects", "arguments": "{\"SOURCE_PATH\": \"/\"}"}}]}`
+		if len(requests) > 1 {
+			message = `{"role": "assistant", "content": "{\"title\": \"Payments\", \"risks\": \"None found\"}"}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices": [{"index": 0, "message": %s, "finish_reason": "stop"}], "usage": {"prompt_tokens": 10, "completion_tokens": 5}}`, message)
+	}))
+	defer server.Close()
+	client, err := ai.NewOpenAIClient(ai.Config{BaseURL: server.URL, APIKey: "test-key", Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	orchestrator, opts := setupConfidenceTest(t, &promptCapturingClient{})
+	orchestrator.aiClient = client
+	orchestrator.agentRegistry.AddSearchPath(setupAgentDir(t, schemaAgentYAML))
+	require.NoError(t, orchestrator.DiscoverAgents())
+	opts.Analysis = &AnalysisOptions{AgentName: "schema-agent", SourcePath: sourceDir}
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Payments", result.Fields["title"])
+
+	// The tool ran in the given source path, whatever the model asked for
+	require.Len(t, requests, 2)
+	messages := requests[1]["messages"].([]interface{})
+	toolMessage := messages[len(messages)-1].(map[string]interface{})
+	assert.Equal(t, "tool", toolMessage["role"])
+	assert.Contains(t, toolMessage["content"], sourceDir)
+}
+
+// setupAgentDir writes an agent definition to a new directory and returns it.
+func setupAgentDir(t *testing.T, definition string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.agent.yaml"), []byte(definition), 0644))
+	return dir
+}
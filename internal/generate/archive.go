@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/karolswdev/docloom/internal/ingest"
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+// sourceArchiveSuffix is appended to the output base name for the source snapshot.
+const sourceArchiveSuffix = ".sources.tar.gz"
+
+// SourceArchive describes the snapshot of the ingested files stored with a document.
+type SourceArchive struct {
+	Path  string           `json:"path"`
+	Files []ArchivedSource `json:"files"`
+}
+
+// ArchivedSource is one file in a source snapshot.
+type ArchivedSource struct {
+	Path   string `json:"path"`   // Path as ingested
+	Entry  string `json:"entry"`  // Path inside the archive
+	SHA256 string `json:"sha256"` // Hex-encoded content hash
+	Size   int64  `json:"size"`
+}
+
+// archiveSources stores the files the sources resolve to in a gzip-compressed tar
+// next to the output, together with a manifest.json listing each file's hash. The
+// archive lets a document be audited or regenerated after the sources have changed.
+func (o *Orchestrator) archiveSources(sources []string, policy ingest.ErrorPolicy, outputFile string) (*SourceArchive, error) {
+	files, err := o.ingester.ResolveSources(sources, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve sources for archiving: %w", err)
+	}
+
+	archive := &SourceArchive{Path: render.SidecarPath(outputFile, sourceArchiveSuffix)}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	modTime := time.Now().UTC()
+
+	for _, file := range files {
+		// #nosec G304 -- files come from the user-provided sources being ingested
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source %s for archiving: %w", file, err)
+		}
+		sum := sha256.Sum256(content)
+		entry := ArchivedSource{
+			Path:   file,
+			Entry:  archiveEntryName(file),
+			SHA256: hex.EncodeToString(sum[:]),
+			Size:   int64(len(content)),
+		}
+		if err := writeTarFile(tw, entry.Entry, content, modTime); err != nil {
+			return nil, err
+		}
+		archive.Files = append(archive.Files, entry)
+	}
+
+	manifest, err := json.MarshalIndent(archive.Files, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal source manifest: %w", err)
+	}
+	if err := writeTarFile(tw, "manifest.json", manifest, modTime); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish source archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress source archive: %w", err)
+	}
+
+	if err := render.WriteFileAtomic(archive.Path, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write source archive: %w", err)
+	}
+	return archive, nil
+}
+
+// writeTarFile adds a regular file to the archive.
+func writeTarFile(tw *tar.Writer, name string, content []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
+
+// archiveEntryName returns the archive path of a source file: its path relative to
+// the working directory when it lies below it, otherwise its absolute path, always
+// under "sources/" and without parent references.
+func archiveEntryName(file string) string {
+	name := file
+	if abs, err := filepath.Abs(file); err == nil {
+		name = abs
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+		}
+	}
+	name = strings.TrimPrefix(filepath.ToSlash(name), filepath.ToSlash(filepath.VolumeName(name)))
+	return path.Join("sources", path.Clean("/"+name))
+}
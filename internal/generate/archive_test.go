@@ -0,0 +1,77 @@
+package generate
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_ArchiveSources(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "The ledger service owns balances."}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	opts.ArchiveSources = true
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	archive := result.Report.SourceArchive
+	require.NotNil(t, archive)
+	require.Len(t, archive.Files, 1)
+	assert.FileExists(t, archive.Path)
+
+	source, err := os.ReadFile(archive.Files[0].Path)
+	require.NoError(t, err)
+	sum := sha256.Sum256(source)
+	assert.Equal(t, hex.EncodeToString(sum[:]), archive.Files[0].SHA256)
+
+	// The archive holds the exact source content and a manifest
+	entries := readArchive(t, archive.Path)
+	assert.Equal(t, string(source), entries[archive.Files[0].Entry])
+	assert.Contains(t, entries["manifest.json"], archive.Files[0].SHA256)
+
+	// An existing archive blocks a second run without --force
+	_, err = orchestrator.Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestArchiveEntryName(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	assert.Equal(t, "sources/docs/a.md", archiveEntryName("docs/a.md"))
+	assert.Equal(t, "sources/docs/a.md", archiveEntryName(wd+"/docs/a.md"))
+	assert.Equal(t, "sources/etc/notes.md", archiveEntryName("/etc/notes.md"))
+}
+
+// readArchive returns the contents of a .tar.gz archive by entry name.
+func readArchive(t *testing.T, path string) map[string]string {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+
+	entries := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[header.Name] = string(content)
+	}
+	return entries
+}
@@ -0,0 +1,149 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/tokens"
+)
+
+// SetModelCatalog sets the capabilities of the configured models. They describe
+// models that are not probed when checking template model requirements, taking
+// precedence over ai.KnownCapabilities, and are suggested when the selected model
+// does not meet a template's requirements.
+func (o *Orchestrator) SetModelCatalog(models map[string]ai.Capabilities) {
+	o.models = models
+}
+
+// modelCapabilities returns the capabilities detected by probing the AI client, or
+// nil when the client has not been probed.
+func (o *Orchestrator) modelCapabilities() *ai.Capabilities {
+	if client, ok := o.aiClient.(*ai.OpenAIClient); ok {
+		return client.Capabilities()
+	}
+	return nil
+}
+
+// tokenCounter returns the tokenizer of model, or of the AI client's model when
+// model is empty.
+func (o *Orchestrator) tokenCounter(model string) *tokens.Counter {
+	if client, ok := o.aiClient.(*ai.OpenAIClient); ok && model == "" {
+		model = client.Model()
+	}
+	return tokens.ForModel(model)
+}
+
+// checkContextWindow warns when the prompt likely exceeds the context window of the
+// probed model. The token count is an estimate, so the request is still attempted.
+func (o *Orchestrator) checkContextWindow(generationPrompt string) {
+	caps := o.modelCapabilities()
+	if caps == nil || caps.ContextWindow == 0 {
+		return
+	}
+	if count := o.tokenCounter(caps.Model).Count(generationPrompt); count > caps.ContextWindow {
+		log.Warn().
+			Str("model", caps.Model).
+			Int("estimated_tokens", count).
+			Int("context_window", caps.ContextWindow).
+			Msg("Prompt likely exceeds the model's context window; consider fewer sources")
+	}
+}
+
+// requireToolCalling fails when the probed model does not support tool calling.
+func (o *Orchestrator) requireToolCalling(agentName string) error {
+	if caps := o.modelCapabilities(); caps != nil && !caps.Tools {
+		return fmt.Errorf("model %s does not support tool calling, which agent %s requires", caps.Model, agentName)
+	}
+	return nil
+}
+
+// ModelRequirementError reports a model that does not meet the model requirements of
+// a template, with the configured models that do.
+type ModelRequirementError struct {
+	Model      string
+	Template   string
+	Problems   []string
+	Compatible []string // Configured models meeting the requirements
+}
+
+func (e *ModelRequirementError) Error() string {
+	message := fmt.Sprintf("model %s does not meet the requirements of template %s: %s", e.Model, e.Template, strings.Join(e.Problems, "; "))
+	if len(e.Compatible) > 0 {
+		return message + fmt.Sprintf(" (compatible configured models: %s)", strings.Join(e.Compatible, ", "))
+	}
+	return message + " (no configured model is compatible)"
+}
+
+// checkModelRequirements fails when one of the models does not meet the template's
+// model requirements. Capabilities come from probing, the model catalog or the
+// known models; requirements that cannot be checked because a model's capabilities
+// are unknown are skipped with a warning.
+func (o *Orchestrator) checkModelRequirements(tmpl *templates.Template, models ...string) error {
+	requirements := tmpl.ModelRequirements
+	if requirements == nil {
+		return nil
+	}
+	for _, model := range models {
+		if model == "" {
+			continue
+		}
+		caps, known := o.capabilitiesOf(model)
+		if !known && (requirements.MinContextWindow > 0 || requirements.Tools || requirements.Vision) {
+			log.Warn().Str("model", model).Str("template", tmpl.Name).Msg("Model capabilities are unknown; probe the model or describe it under models in the configuration to check the template's requirements")
+		}
+		if problems := unmetRequirements(requirements, model, caps); len(problems) > 0 {
+			return &ModelRequirementError{Model: model, Template: tmpl.Name, Problems: problems, Compatible: o.compatibleModels(requirements)}
+		}
+	}
+	return nil
+}
+
+// capabilitiesOf returns the capabilities of model: those probed on the AI client
+// when it serves model, else those of the catalog or the known models.
+func (o *Orchestrator) capabilitiesOf(model string) (*ai.Capabilities, bool) {
+	if caps := o.modelCapabilities(); caps != nil && caps.Model == model {
+		return caps, true
+	}
+	return ai.LookupCapabilities(o.models, model)
+}
+
+// unmetRequirements lists the requirements model does not meet. Capabilities that
+// are unknown, a nil caps or a context window of 0, are not held against it.
+func unmetRequirements(requirements *templates.ModelRequirements, model string, caps *ai.Capabilities) []string {
+	var problems []string
+	if !requirements.Allows(model) {
+		problems = append(problems, fmt.Sprintf("not an allowed model (allowed: %s)", strings.Join(requirements.Allowed, ", ")))
+	}
+	if caps == nil {
+		return problems
+	}
+	if requirements.MinContextWindow > 0 && caps.ContextWindow > 0 && caps.ContextWindow < requirements.MinContextWindow {
+		problems = append(problems, fmt.Sprintf("context window of %d tokens is below the required %d", caps.ContextWindow, requirements.MinContextWindow))
+	}
+	if requirements.Tools && !caps.Tools {
+		problems = append(problems, "tool calling is required")
+	}
+	if requirements.Vision && !caps.Vision {
+		problems = append(problems, "image input (vision) is required")
+	}
+	return problems
+}
+
+// compatibleModels returns the catalog models that meet the requirements, in name
+// order.
+func (o *Orchestrator) compatibleModels(requirements *templates.ModelRequirements) []string {
+	var compatible []string
+	for model, caps := range o.models {
+		caps.Model = model
+		if len(unmetRequirements(requirements, model, &caps)) == 0 {
+			compatible = append(compatible, model)
+		}
+	}
+	sort.Strings(compatible)
+	return compatible
+}
@@ -0,0 +1,68 @@
+package generate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+func TestOrchestrator_RequireToolCalling(t *testing.T) {
+	// Unprobed clients are assumed to support tool calling
+	assert.NoError(t, NewOrchestrator(&promptCapturingClient{}).requireToolCalling("csharp-analyzer"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data": [{"id": "small-model", "supported_parameters": ["response_format"]}]}`)
+	}))
+	defer server.Close()
+
+	client, err := ai.NewOpenAIClient(ai.Config{BaseURL: server.URL, APIKey: "test-key", Model: "small-model"})
+	require.NoError(t, err)
+	_, err = client.Probe(context.Background())
+	require.NoError(t, err)
+
+	err = NewOrchestrator(client).requireToolCalling("csharp-analyzer")
+	assert.ErrorContains(t, err, "does not support tool calling")
+}
+
+func TestRun_ModelRequirements(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"title": "Payments"}`}}
+	orchestrator := NewOrchestrator(client)
+	opts := setupResumeTest(t, orchestrator)
+	opts.Resume = false
+	opts.Model = "gpt-4"
+	tmpl, err := orchestrator.registry.Get(opts.TemplateType)
+	require.NoError(t, err)
+	tmpl.ModelRequirements = &templates.ModelRequirements{MinContextWindow: 32000, Vision: true}
+	orchestrator.SetModelCatalog(map[string]ai.Capabilities{
+		"gpt-4o":      {ContextWindow: 128000, Tools: true, Vision: true},
+		"local-llama": {ContextWindow: 8192, Tools: true},
+	})
+
+	// The known gpt-4 context window is too small and it takes no images
+	_, err = orchestrator.Run(context.Background(), opts)
+	var requirementErr *ModelRequirementError
+	require.ErrorAs(t, err, &requirementErr)
+	assert.Len(t, requirementErr.Problems, 2)
+	assert.Equal(t, []string{"gpt-4o"}, requirementErr.Compatible)
+	assert.Empty(t, client.prompts, "no tokens are spent on an incompatible model")
+
+	// Models outside the allowed list are refused even when capable
+	tmpl.ModelRequirements = &templates.ModelRequirements{Allowed: []string{"gpt-4o*"}}
+	_, err = orchestrator.Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "not an allowed model")
+
+	// Requirements that cannot be checked for an unknown model do not block it
+	tmpl.ModelRequirements = &templates.ModelRequirements{MinContextWindow: 32000}
+	opts.Model = "custom-model"
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Payments", result.Fields["title"])
+}
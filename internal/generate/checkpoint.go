@@ -0,0 +1,336 @@
+package generate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ingest"
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// DefaultStateDir is the directory run checkpoints are written to.
+var DefaultStateDir = filepath.Join(".docloom", "state")
+
+// Checkpoint steps, in the order a run reaches them.
+const (
+	StepIngested  = "ingested"  // Sources were ingested
+	StepPrompted  = "prompted"  // The generation prompt was built
+	StepGenerated = "generated" // The model produced valid JSON
+)
+
+// FileStamp identifies the version of a source file by its size and modification time.
+type FileStamp struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Checkpoint is the intermediate state of a resumable run, saved as
+// <state dir>/<run-id>.json after every step. A run with the same template, sources,
+// output and model resumes from it after the last step that succeeded, so a failure
+// after the model call does not cost the call again. The checkpoint is removed when
+// the run succeeds.
+type Checkpoint struct {
+	RunID     string    `json:"run_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Step      string    `json:"step"`
+
+	// Inputs that identify the run
+	Template   string   `json:"template"`
+	Model      string   `json:"model,omitempty"`
+	Sources    []string `json:"sources"`
+	OutputFile string   `json:"output_file"` // As requested, before patterns are expanded
+
+	// Ingestion: the resolved source files and the ingested content
+	SourceFiles  []FileStamp          `json:"source_files,omitempty"`
+	ContentHash  string               `json:"content_hash,omitempty"`
+	Content      string               `json:"content,omitempty"`
+	Roots        []ingest.Root        `json:"roots,omitempty"`
+	Files        []string             `json:"files,omitempty"`
+	Extents      []ingest.Extent      `json:"extents,omitempty"`
+	SourceErrors []ingest.SourceError `json:"source_errors,omitempty"`
+
+	// Generation: the prompt, raw model responses and failed validations
+	Prompt        string              `json:"prompt,omitempty"`
+	Responses     []string            `json:"responses,omitempty"`
+	Validation    []ValidationFailure `json:"validation,omitempty"`
+	GeneratedJSON string              `json:"generated_json,omitempty"`
+
+	mu              sync.Mutex
+	path            string
+	recordResponses bool   // Responses can seed a resumed repair loop
+	resumeResponse  string // Last response of the resumed run, taken once
+}
+
+// checkpointKey is the context key of the run's checkpoint.
+type checkpointKey struct{}
+
+// withCheckpoint returns a context carrying c, so model calls and validation deep in
+// the run can record into it.
+func withCheckpoint(ctx context.Context, c *Checkpoint) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, checkpointKey{}, c)
+}
+
+// checkpointFrom returns the run's checkpoint, or nil when the run is not resumable.
+// Its record methods accept a nil receiver.
+func checkpointFrom(ctx context.Context) *Checkpoint {
+	c, _ := ctx.Value(checkpointKey{}).(*Checkpoint)
+	return c
+}
+
+// openCheckpoint returns the checkpoint of an earlier failed run with the same inputs
+// whose sources are unchanged, or a new checkpoint when there is none.
+func (o *Orchestrator) openCheckpoint(opts Options, tmpl *templates.Template) (*Checkpoint, error) {
+	dir := opts.StateDir
+	if dir == "" {
+		dir = DefaultStateDir
+	}
+	stamps, err := o.stampSources(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := &Checkpoint{
+		RunID:       newRunID(),
+		Template:    tmpl.Name,
+		Model:       opts.Model,
+		Sources:     opts.Sources,
+		OutputFile:  opts.OutputFile,
+		SourceFiles: stamps,
+	}
+	fresh.path = filepath.Join(dir, fresh.RunID+".json")
+	fresh.recordResponses = o.ensemble == nil && !opts.PerField
+
+	previous, err := findCheckpoint(dir, fresh)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return fresh, nil
+	}
+	if !sameStamps(previous.SourceFiles, stamps) {
+		log.Warn().Str("run_id", previous.RunID).Msg("Sources changed since the checkpoint was written, starting over")
+		if err := os.Remove(previous.path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale checkpoint: %w", err)
+		}
+		return fresh, nil
+	}
+
+	previous.recordResponses = fresh.recordResponses
+	if previous.recordResponses && previous.GeneratedJSON == "" && len(previous.Responses) > 0 {
+		previous.resumeResponse = previous.Responses[len(previous.Responses)-1]
+	}
+	log.Info().Str("run_id", previous.RunID).Str("step", previous.Step).Str("file", previous.path).Msg("Resuming from checkpoint")
+	return previous, nil
+}
+
+// stampSources returns the files the sources resolve to with their sizes and
+// modification times, without reading them.
+func (o *Orchestrator) stampSources(opts Options) ([]FileStamp, error) {
+	files, err := o.ingester.ResolveSources(opts.Sources, ingest.ErrorPolicy(opts.SourceErrors))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve sources: %w", err)
+	}
+	stamps := make([]FileStamp, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat source %s: %w", file, err)
+		}
+		stamps = append(stamps, FileStamp{Path: file, Size: info.Size(), ModTime: info.ModTime().UTC()})
+	}
+	return stamps, nil
+}
+
+// sameStamps reports whether two lists of file stamps describe the same files.
+func sameStamps(a, b []FileStamp) bool {
+	return slices.EqualFunc(a, b, func(x, y FileStamp) bool {
+		return x.Path == y.Path && x.Size == y.Size && x.ModTime.Equal(y.ModTime)
+	})
+}
+
+// findCheckpoint returns the most recent checkpoint in dir with the inputs of want.
+func findCheckpoint(dir string, want *Checkpoint) (*Checkpoint, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var found *Checkpoint
+	for _, path := range paths {
+		data, err := os.ReadFile(path) // #nosec G304 -- checkpoints are written by DocLoom
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+		var candidate Checkpoint
+		if err := json.Unmarshal(data, &candidate); err != nil {
+			log.Warn().Err(err).Str("file", path).Msg("Skipping unreadable checkpoint")
+			continue
+		}
+		if candidate.Template != want.Template || candidate.Model != want.Model ||
+			candidate.OutputFile != want.OutputFile || !slices.Equal(candidate.Sources, want.Sources) {
+			continue
+		}
+		if found == nil || candidate.UpdatedAt.After(found.UpdatedAt) {
+			candidate.path = path
+			found = &candidate
+		}
+	}
+	return found, nil
+}
+
+// ingestion returns the ingestion result saved in the checkpoint.
+func (c *Checkpoint) ingestion() *ingest.Result {
+	return &ingest.Result{Content: c.Content, Files: c.Files, Roots: c.Roots, Errors: c.SourceErrors, Extents: c.Extents}
+}
+
+// recordIngestion saves the ingested sources.
+func (c *Checkpoint) recordIngestion(ingestion *ingest.Result) error {
+	if c == nil {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(ingestion.Content))
+	return c.update(StepIngested, func() {
+		c.ContentHash = hex.EncodeToString(sum[:])
+		c.Content = ingestion.Content
+		c.Roots = ingestion.Roots
+		c.Files = ingestion.Files
+		c.Extents = ingestion.Extents
+		c.SourceErrors = ingestion.Errors
+	})
+}
+
+// recordPrompt saves the generation prompt. When it differs from the prompt of the
+// resumed run, for example because the template changed, the model's output is
+// discarded and generation starts over.
+func (c *Checkpoint) recordPrompt(prompt string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	changed := c.Prompt != "" && c.Prompt != prompt
+	c.mu.Unlock()
+	if changed {
+		log.Warn().Str("run_id", c.RunID).Msg("Prompt changed since the checkpoint was written, generating again")
+		c.mu.Lock()
+		c.Step = StepIngested
+		c.Responses, c.Validation, c.GeneratedJSON, c.resumeResponse = nil, nil, "", ""
+		c.mu.Unlock()
+	}
+	return c.update(StepPrompted, func() { c.Prompt = prompt })
+}
+
+// recordResponse saves a raw model response of the generation or repair loop.
+func (c *Checkpoint) recordResponse(response string) {
+	if c == nil || !c.recordResponses {
+		return
+	}
+	c.save(func() { c.Responses = append(c.Responses, response) })
+}
+
+// recordValidation saves a failed validation of generated JSON.
+func (c *Checkpoint) recordValidation(attempt int, err error) {
+	if c == nil {
+		return
+	}
+	c.save(func() { c.Validation = append(c.Validation, ValidationFailure{Attempt: attempt, Error: err.Error()}) })
+}
+
+// recordGenerated saves the validated document JSON.
+func (c *Checkpoint) recordGenerated(generatedJSON string) error {
+	if c == nil {
+		return nil
+	}
+	return c.update(StepGenerated, func() { c.GeneratedJSON = generatedJSON })
+}
+
+// takeResponse returns the last model response of the resumed run, once, so the
+// repair loop continues from it instead of calling the model again.
+func (c *Checkpoint) takeResponse() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response := c.resumeResponse
+	c.resumeResponse = ""
+	return response
+}
+
+// checkpointSteps are the steps of a run in order.
+var checkpointSteps = []string{StepIngested, StepPrompted, StepGenerated}
+
+// reached reports whether the checkpoint has reached step.
+func (c *Checkpoint) reached(step string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reachedLocked(step)
+}
+
+// reachedLocked is reached with c.mu held.
+func (c *Checkpoint) reachedLocked(step string) bool {
+	return c.Step != "" && slices.Index(checkpointSteps, c.Step) >= slices.Index(checkpointSteps, step)
+}
+
+// update applies change, advances the checkpoint to step unless it is past it
+// already, and saves it.
+func (c *Checkpoint) update(step string, change func()) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	change()
+	if !c.reachedLocked(step) {
+		c.Step = step
+	}
+	return c.writeLocked()
+}
+
+// save applies change and saves the checkpoint, logging failures: losing a response
+// only makes a resumed run repeat the model call.
+func (c *Checkpoint) save(change func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	change()
+	if err := c.writeLocked(); err != nil {
+		log.Warn().Err(err).Msg("Failed to save checkpoint")
+	}
+}
+
+// writeLocked writes the checkpoint file; c.mu must be held.
+func (c *Checkpoint) writeLocked() error {
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if err := render.WriteFileAtomic(c.path, data); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the checkpoint after the run succeeded.
+func (c *Checkpoint) remove() {
+	if c == nil {
+		return
+	}
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("file", c.path).Msg("Failed to remove checkpoint")
+	}
+}
@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// setupResumeTest registers a template and writes a source, returning the options of
+// a resumable run.
+func setupResumeTest(t *testing.T, orchestrator *Orchestrator) Options {
+	t.Helper()
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Payments\n\nThe ledger settles nightly."), 0644))
+	require.NoError(t, orchestrator.registry.Register("resume-template", &templates.Template{
+		Name:        "resume-template",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"title":{"type":"string"}},"required":["title"]}`),
+		Prompt:      "Write a title",
+		HTMLContent: `<body><!-- data-field="title" --></body>`,
+	}))
+	return Options{
+		TemplateType: "resume-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "out", "doc.html"),
+		Model:        "test-model",
+		APIKey:       "test-key",
+		MaxRepairs:   1,
+		Resume:       true,
+		StateDir:     filepath.Join(tempDir, "state"),
+	}
+}
+
+// loadCheckpoints returns the checkpoints saved in dir.
+func loadCheckpoints(t *testing.T, dir string) []*Checkpoint {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	checkpoints := make([]*Checkpoint, len(paths))
+	for idx, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		checkpoints[idx] = &Checkpoint{}
+		require.NoError(t, json.Unmarshal(data, checkpoints[idx]))
+	}
+	return checkpoints
+}
+
+func TestResume_ContinuesRepairFromLastResponse(t *testing.T) {
+	failing := &promptCapturingClient{responses: []string{`{"title": 1}`, `{"title": 2}`}}
+	orchestrator := NewOrchestrator(failing)
+	opts := setupResumeTest(t, orchestrator)
+
+	_, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	checkpoints := loadCheckpoints(t, opts.StateDir)
+	require.Len(t, checkpoints, 1)
+	saved := checkpoints[0]
+	assert.Equal(t, StepPrompted, saved.Step)
+	assert.Equal(t, []string{`{"title": 1}`, `{"title": 2}`}, saved.Responses)
+	assert.Len(t, saved.Validation, 2)
+	assert.NotEmpty(t, saved.ContentHash)
+	assert.Equal(t, failing.prompts[0], saved.Prompt)
+
+	// The resumed run asks only for a repair of the last response
+	repairing := &promptCapturingClient{responses: []string{`{"title": "Payments"}`}}
+	orchestrator.aiClient = repairing
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, repairing.prompts, 1)
+	assert.Contains(t, repairing.prompts[0], `{"title": 2}`)
+	assert.Equal(t, "Payments", result.Fields["title"])
+	assert.Equal(t, saved.RunID, result.Report.RunID)
+	assert.Empty(t, loadCheckpoints(t, opts.StateDir), "the checkpoint is removed after success")
+}
+
+func TestResume_SkipsGenerationAfterLaterFailure(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"title": "Payments"}`}}
+	orchestrator := NewOrchestrator(client)
+	opts := setupResumeTest(t, orchestrator)
+
+	// Rendering fails because the output directory is a file
+	outDir := filepath.Dir(opts.OutputFile)
+	require.NoError(t, os.WriteFile(outDir, []byte("not a directory"), 0644))
+	_, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	checkpoints := loadCheckpoints(t, opts.StateDir)
+	require.Len(t, checkpoints, 1)
+	assert.Equal(t, StepGenerated, checkpoints[0].Step)
+
+	require.NoError(t, os.Remove(outDir))
+	_, err = orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, client.prompts, 1, "the resumed run must not call the model again")
+	assert.FileExists(t, opts.OutputFile)
+}
+
+func TestResume_StartsOverWhenSourcesChanged(t *testing.T) {
+	failing := &promptCapturingClient{responses: []string{`{"title": 1}`, `{"title": 2}`}}
+	orchestrator := NewOrchestrator(failing)
+	opts := setupResumeTest(t, orchestrator)
+	_, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.WriteFile(opts.Sources[0], []byte("# Payments\n\nThe ledger settles hourly."), 0644))
+	require.NoError(t, os.Chtimes(opts.Sources[0], later, later))
+
+	client := &promptCapturingClient{responses: []string{`{"title": "Payments"}`}}
+	orchestrator.aiClient = client
+	_, err = orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, client.prompts, 1)
+	assert.Contains(t, client.prompts[0], "settles hourly", "the sources are ingested again")
+	assert.Empty(t, loadCheckpoints(t, opts.StateDir))
+}
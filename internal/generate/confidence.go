@@ -0,0 +1,114 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Confidence holds the model's confidence in each top-level field of a generated
+// document, from 0 (guessed) to 1 (stated in the sources).
+type Confidence struct {
+	Fields  map[string]float64 `json:"fields"`
+	Reasons map[string]string  `json:"reasons,omitempty"` // Why fields scored low
+
+	// Fields scoring below Badge are badged in the HTML for reviewers, and a field
+	// scoring below Threshold fails the run
+	Badge     float64  `json:"badge,omitempty"`
+	Threshold float64  `json:"threshold,omitempty"`
+	Low       []string `json:"low,omitempty"` // Fields below Badge, least confident first
+}
+
+// scoresConfidence reports whether the run asks the model for per-field confidence.
+func (opts Options) scoresConfidence() bool {
+	return opts.Confidence || opts.ConfidenceBadge > 0 || opts.MinConfidence > 0
+}
+
+// scoreConfidence asks the generation model how confident it is in each top-level
+// field of the generated document, in a follow-up request with the sources.
+func (o *Orchestrator) scoreConfidence(ctx context.Context, sourceContent, generatedJSON string, opts Options) (*Confidence, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(generatedJSON), &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse generated JSON for confidence scoring: %w", err)
+	}
+	fieldNames := make([]string, 0, len(fields))
+	for name := range fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+
+	log.Info().Msg("Scoring confidence per field")
+	response, err := o.aiClient.GenerateJSON(ctx, o.builder.BuildConfidencePrompt(sourceContent, generatedJSON, fieldNames))
+	if err != nil {
+		return nil, fmt.Errorf("confidence scoring failed: %w", err)
+	}
+	var scored struct {
+		Fields  map[string]float64 `json:"fields"`
+		Reasons map[string]string  `json:"reasons"`
+	}
+	if err := json.Unmarshal([]byte(response), &scored); err != nil {
+		return nil, fmt.Errorf("invalid confidence response: %w", err)
+	}
+
+	confidence := &Confidence{
+		Fields:    make(map[string]float64, len(fieldNames)),
+		Reasons:   make(map[string]string),
+		Badge:     opts.ConfidenceBadge,
+		Threshold: opts.MinConfidence,
+	}
+	for _, name := range fieldNames {
+		score, ok := scored.Fields[name]
+		if !ok {
+			log.Warn().Str("field", name).Msg("Model gave no confidence score for field")
+			continue
+		}
+		if score < 0 || score > 1 {
+			return nil, fmt.Errorf("invalid confidence response: score %v of field %s is outside 0-1", score, name)
+		}
+		confidence.Fields[name] = score
+		if reason := scored.Reasons[name]; reason != "" {
+			confidence.Reasons[name] = reason
+		}
+		if score < opts.ConfidenceBadge {
+			confidence.Low = append(confidence.Low, name)
+		}
+	}
+	sort.SliceStable(confidence.Low, func(i, j int) bool {
+		return confidence.Fields[confidence.Low[i]] < confidence.Fields[confidence.Low[j]]
+	})
+	for _, name := range confidence.Low {
+		log.Warn().Str("field", name).Float64("confidence", confidence.Fields[name]).Str("reason", confidence.Reasons[name]).Msg("Low confidence in field")
+	}
+	log.Info().Int("scored", len(confidence.Fields)).Int("low", len(confidence.Low)).Msg("Confidence scoring complete")
+	return confidence, nil
+}
+
+// lowConfidence returns the scores of the fields to badge in the HTML, or nil.
+func (c *Confidence) lowConfidence() map[string]float64 {
+	if c == nil || len(c.Low) == 0 {
+		return nil
+	}
+	scores := make(map[string]float64, len(c.Low))
+	for _, name := range c.Low {
+		scores[name] = c.Fields[name]
+	}
+	return scores
+}
+
+// belowThreshold returns the fields scoring below the run's minimum confidence.
+func (c *Confidence) belowThreshold() []string {
+	if c == nil {
+		return nil
+	}
+	var fields []string
+	for name, score := range c.Fields {
+		if score < c.Threshold {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
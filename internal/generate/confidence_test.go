@@ -0,0 +1,105 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// setupConfidenceTest registers a template with two fields and returns an
+// orchestrator and options.
+func setupConfidenceTest(t *testing.T, client *promptCapturingClient) (*Orchestrator, Options) {
+	t.Helper()
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger service owns balances."), 0644))
+
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("confidence-template", &templates.Template{
+		Name:        "confidence-template",
+		Prompt:      "Generate the document",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"title":{"type":"string"},"risks":{"type":"string"}},"required":["title","risks"]}`),
+		HTMLContent: `<html><head><title><!-- data-field="title" --></title></head><body><h1><!-- data-field="title" --></h1><p><!-- data-field="risks" --></p></body></html>`,
+	}))
+	return orchestrator, Options{
+		TemplateType: "confidence-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "out.html"),
+		APIKey:       "test-key",
+	}
+}
+
+func TestGenerate_ConfidenceBadges(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "risks": "Vendor lock-in"}`,
+		`{"fields": {"title": 0.95, "risks": 0.4}, "reasons": {"risks": "The sources name no risks"}}`,
+	}}
+	orchestrator, opts := setupConfidenceTest(t, client)
+	opts.ConfidenceBadge = 0.6
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 2)
+	assert.Contains(t, client.prompts[1], "The ledger service owns balances.")
+	assert.Contains(t, client.prompts[1], "risks, title")
+
+	confidence := result.Report.Confidence
+	require.NotNil(t, confidence)
+	assert.Equal(t, map[string]float64{"title": 0.95, "risks": 0.4}, confidence.Fields)
+	assert.Equal(t, []string{"risks"}, confidence.Low)
+	assert.Equal(t, "The sources name no risks", confidence.Reasons["risks"])
+
+	html, err := os.ReadFile(opts.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<p><span class="docloom-low-confidence" data-docloom-confidence="Low confidence 40%"`)
+	assert.Contains(t, string(html), `<h1>Ledger</h1>`)
+	assert.Contains(t, string(html), `id="docloom-confidence-style"`)
+
+	// The scores are persisted in the run report
+	data, err := os.ReadFile(result.ReportFile)
+	require.NoError(t, err)
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.NotNil(t, report.Confidence)
+	assert.Equal(t, 0.4, report.Confidence.Fields["risks"])
+}
+
+func TestGenerate_MinConfidenceFailsRun(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "risks": "Vendor lock-in"}`,
+		`{"fields": {"title": 0.9, "risks": 0.1}}`,
+	}}
+	orchestrator, opts := setupConfidenceTest(t, client)
+	opts.MinConfidence = 0.2
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "confidence check failed: risks below the minimum confidence 0.20")
+
+	// The outputs are kept for inspection, without badges
+	require.NotNil(t, result)
+	html, err := os.ReadFile(opts.OutputFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(html), "docloom-low-confidence")
+}
+
+func TestGenerate_ConfidenceRejectsScoresOutOfRange(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "risks": "Vendor lock-in"}`,
+		`{"fields": {"title": 9, "risks": 4}}`,
+	}}
+	orchestrator, opts := setupConfidenceTest(t, client)
+	opts.Confidence = true
+
+	_, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside 0-1")
+}
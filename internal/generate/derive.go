@@ -0,0 +1,123 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// deriveFields runs the optional derivation stage: each derived field declared by the
+// template is regenerated from the complete document so it reflects the actual content.
+func (o *Orchestrator) deriveFields(ctx context.Context, generatedJSON string, tmpl *templates.Template) (string, error) {
+	if len(tmpl.Derived) == 0 {
+		return generatedJSON, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(generatedJSON), &fields); err != nil {
+		return "", fmt.Errorf("failed to parse generated JSON for derivation: %w", err)
+	}
+
+	for _, derived := range tmpl.Derived {
+		if derived.Field == "" {
+			return "", fmt.Errorf("derived field declaration is missing a field path")
+		}
+
+		log.Info().Str("field", derived.Field).Msg("Deriving field from generated document")
+
+		// The derived field itself must not leak into its own context
+		docContext := copyFields(fields)
+		deleteFieldPath(docContext, derived.Field)
+		contextJSON, err := json.MarshalIndent(docContext, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal document for derivation: %w", err)
+		}
+
+		derivationPrompt := o.builder.BuildDerivationPrompt(string(contextJSON), derived.Field, derived.Prompt)
+		response, err := o.aiClient.GenerateJSON(ctx, derivationPrompt)
+		if err != nil {
+			return "", fmt.Errorf("AI derivation of field %s failed: %w", derived.Field, err)
+		}
+
+		var result struct {
+			Value interface{} `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(response), &result); err != nil {
+			return "", fmt.Errorf("invalid derivation response for field %s: %w", derived.Field, err)
+		}
+		if result.Value == nil {
+			return "", fmt.Errorf("derivation response for field %s has no value", derived.Field)
+		}
+
+		if err := setFieldPath(fields, derived.Field, result.Value); err != nil {
+			return "", err
+		}
+		log.Debug().Str("field", derived.Field).Msg("Derived field applied")
+	}
+
+	derivedJSON, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal derived document: %w", err)
+	}
+
+	// Derived values must still satisfy the template schema
+	if err := o.validator.Validate(string(derivedJSON), string(tmpl.Schema)); err != nil {
+		return "", fmt.Errorf("derived fields failed validation: %w", err)
+	}
+
+	return string(derivedJSON), nil
+}
+
+// setFieldPath sets a value at a dot-separated path, creating intermediate objects as needed.
+func setFieldPath(fields map[string]interface{}, path string, value interface{}) error {
+	parts := strings.Split(path, ".")
+	current := fields
+	for _, part := range parts[:len(parts)-1] {
+		next, exists := current[part]
+		if !exists {
+			child := make(map[string]interface{})
+			current[part] = child
+			current = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set field %s: %s is not an object", path, part)
+		}
+		current = child
+	}
+	current[parts[len(parts)-1]] = value
+	return nil
+}
+
+// deleteFieldPath removes the value at a dot-separated path if present.
+func deleteFieldPath(fields map[string]interface{}, path string) {
+	parts := strings.Split(path, ".")
+	current := fields
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = child
+	}
+	delete(current, parts[len(parts)-1])
+}
+
+// copyFields returns a deep copy of nested field maps (slices and scalars are shared).
+func copyFields(fields map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if nested, ok := value.(map[string]interface{}); ok {
+			result[key] = copyFields(nested)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// promptCapturingClient records prompts and returns canned responses in order.
+type promptCapturingClient struct {
+	prompts   []string
+	responses []string
+}
+
+func (c *promptCapturingClient) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	c.prompts = append(c.prompts, prompt)
+	if len(c.prompts) <= len(c.responses) {
+		return c.responses[len(c.prompts)-1], nil
+	}
+	return "", nil
+}
+
+// TestGenerate_DerivedSummaryStage tests that derived fields are generated from the document.
+func TestGenerate_DerivedSummaryStage(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nRaw source material."), 0644))
+
+	tmpl := &templates.Template{
+		Name:        "derived-template",
+		Prompt:      "Generate the document",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"body":{"type":"string"},"meta":{"type":"object","properties":{"summary":{"type":"string"}}}},"required":["body"]}`),
+		HTMLContent: `<html><body><!-- data-field="meta.summary" --><!-- data-field="body" --></body></html>`,
+		Derived: []templates.DerivedField{
+			{Field: "meta.summary", Prompt: "Summarize the document in one sentence."},
+		},
+	}
+
+	client := &promptCapturingClient{
+		responses: []string{
+			`{"body": "The ledger service owns all balances.", "meta": {"summary": "placeholder"}}`,
+			`{"value": "Ledger owns balances."}`,
+		},
+	}
+
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("derived-template", tmpl))
+
+	outputFile := filepath.Join(tempDir, "out.html")
+	err := orchestrator.Generate(context.Background(), Options{
+		TemplateType: "derived-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   outputFile,
+		Model:        "test-model",
+		APIKey:       "test-key",
+		MaxRepairs:   1,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 2)
+	derivationPrompt := client.prompts[1]
+	assert.Contains(t, derivationPrompt, "Summarize the document in one sentence.")
+	assert.Contains(t, derivationPrompt, "The ledger service owns all balances.")
+	assert.NotContains(t, derivationPrompt, "placeholder", "derived field must not feed its own derivation")
+	assert.NotContains(t, derivationPrompt, "Raw source material.", "derivation must use the document, not sources")
+
+	html, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(html), "Ledger owns balances."))
+}
+
+// TestDeriveFields_InvalidResponse tests that malformed derivation responses fail the run.
+func TestDeriveFields_InvalidResponse(t *testing.T) {
+	tmpl := &templates.Template{
+		Schema:  json.RawMessage(`{"type":"object"}`),
+		Derived: []templates.DerivedField{{Field: "summary", Prompt: "Summarize"}},
+	}
+	orchestrator := NewOrchestrator(&promptCapturingClient{responses: []string{`{"other": 1}`}})
+
+	_, err := orchestrator.deriveFields(context.Background(), `{"body": "text"}`, tmpl)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no value")
+}
+
+// TestSetFieldPath tests nested field assignment.
+func TestSetFieldPath(t *testing.T) {
+	fields := map[string]interface{}{"a": map[string]interface{}{"b": "old"}, "scalar": "x"}
+
+	require.NoError(t, setFieldPath(fields, "a.b", "new"))
+	require.NoError(t, setFieldPath(fields, "c.d", 1))
+	assert.Equal(t, "new", fields["a"].(map[string]interface{})["b"])
+	assert.Equal(t, 1, fields["c"].(map[string]interface{})["d"])
+
+	assert.Error(t, setFieldPath(fields, "scalar.child", "v"))
+}
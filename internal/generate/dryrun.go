@@ -0,0 +1,134 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/ingest"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// dryRunPreviewLength is the number of prompt characters shown in the text preview.
+const dryRunPreviewLength = 1000
+
+// DryRunPlan describes what a run would send to the model. It is printed as JSON
+// with --dry-run --json so wrapper tooling can inspect a run before approving it.
+type DryRunPlan struct {
+	Template        string          `json:"template"`
+	Output          string          `json:"output"`
+	Model           string          `json:"model"`
+	Prompt          string          `json:"prompt"`
+	Schema          json.RawMessage `json:"schema"`
+	Sources         []string        `json:"sources"`
+	ResolvedSources []string        `json:"resolved_sources"`
+	EstimatedTokens int             `json:"estimated_tokens"`
+	SourceStats     *ingest.Stats   `json:"source_stats,omitempty"`
+}
+
+// handleDryRun prints the dry-run plan, as text or as JSON, and returns it.
+func (o *Orchestrator) handleDryRun(opts Options, tmpl *templates.Template, generationPrompt string, sourceStats *ingest.Stats) (*DryRunPlan, error) {
+	resolved, err := o.ingester.ResolveSources(opts.Sources, ingest.ErrorPolicy(opts.SourceErrors))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve sources: %w", err)
+	}
+
+	schema := tmpl.Schema
+	if !json.Valid(schema) {
+		log.Warn().Msg("Template schema is not valid JSON, omitting it from the dry-run plan")
+		schema = json.RawMessage("{}")
+	}
+
+	plan := &DryRunPlan{
+		Template:        tmpl.Name,
+		Sources:         opts.Sources,
+		ResolvedSources: resolved,
+		Output:          opts.OutputFile,
+		Model:           opts.Model,
+		EstimatedTokens: o.tokenCounter(opts.Model).Count(generationPrompt),
+		Schema:          schema,
+		Prompt:          generationPrompt,
+		SourceStats:     sourceStats,
+	}
+
+	if opts.DryRunJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(plan); err != nil {
+			return nil, fmt.Errorf("failed to write dry-run plan: %w", err)
+		}
+		return plan, nil
+	}
+
+	printDryRunPlan(plan)
+	return plan, nil
+}
+
+// printDryRunPlan prints the human-readable dry-run summary.
+func printDryRunPlan(plan *DryRunPlan) {
+	fmt.Println("\n" + i18n.T("dryrun.banner"))
+	fmt.Println(i18n.T("dryrun.template", plan.Template))
+	fmt.Println(i18n.T("dryrun.sources", plan.Sources))
+	fmt.Println(i18n.T("dryrun.output", plan.Output))
+	fmt.Println(i18n.T("dryrun.model", plan.Model))
+	fmt.Println(i18n.T("dryrun.tokens", plan.EstimatedTokens))
+	if plan.SourceStats != nil {
+		printSourceStats(plan.SourceStats)
+	}
+	fmt.Println("\n" + i18n.T("dryrun.prompt_preview"))
+	if len(plan.Prompt) > dryRunPreviewLength {
+		fmt.Println(plan.Prompt[:dryRunPreviewLength] + "...")
+	} else {
+		fmt.Println(plan.Prompt)
+	}
+	fmt.Println("\n" + i18n.T("dryrun.schema"))
+	schemaBytes, err := json.MarshalIndent(plan.Schema, "", "  ")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to marshal schema for display")
+		schemaBytes = []byte("{}")
+	}
+	fmt.Println(string(schemaBytes))
+}
+
+// printSourceStats prints the files by extension and language and the token share
+// of the top-level source folders.
+func printSourceStats(stats *ingest.Stats) {
+	fmt.Println("\n" + i18n.T("dryrun.source_stats"))
+	fmt.Println(i18n.T("dryrun.source_files", stats.Files, stats.Bytes, countList(stats.Extensions)))
+	languages := make(map[string]int, len(stats.Languages)+len(stats.CodeLanguages))
+	for _, counts := range []map[string]int{stats.Languages, stats.CodeLanguages} {
+		for language, count := range counts {
+			languages[language] += count
+		}
+	}
+	if len(languages) > 0 {
+		fmt.Println(i18n.T("dryrun.source_languages", countList(languages)))
+	}
+	for _, folder := range stats.Folders {
+		fmt.Println(i18n.T("dryrun.source_folder", folder.Share*100, folder.Folder, folder.Tokens, folder.Files))
+	}
+}
+
+// countList formats counts as "name count" pairs, largest first.
+func countList(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,111 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// EnsembleResult records how an ensemble generation was reconciled.
+type EnsembleResult struct {
+	Models        []string `json:"models"`
+	Disagreements []string `json:"disagreements,omitempty"` // Top-level fields where the candidates differed
+	Reconciled    bool     `json:"reconciled"`              // Whether a reconciliation pass produced the output
+}
+
+// SetEnsembleClient enables ensemble generation: the document is generated by both the
+// primary and this secondary client, and disagreements are reconciled by the primary.
+func (o *Orchestrator) SetEnsembleClient(client ai.Client) {
+	o.ensemble = client
+}
+
+// generateDocument generates the document JSON, using ensemble generation when configured.
+func (o *Orchestrator) generateDocument(ctx context.Context, generationPrompt string, tmpl *templates.Template, opts Options) (string, *EnsembleResult, error) {
+	if o.ensemble == nil {
+		generatedJSON, err := o.generateCandidate(ctx, o.aiClient, generationPrompt, tmpl, opts)
+		return generatedJSON, nil, err
+	}
+
+	log.Info().Str("model", opts.Model).Msg("Generating ensemble candidate A")
+	candidateA, err := o.generateCandidate(ctx, o.aiClient, generationPrompt, tmpl, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("ensemble candidate A: %w", err)
+	}
+	log.Info().Str("model", opts.EnsembleModel).Msg("Generating ensemble candidate B")
+	candidateB, err := o.generateCandidate(ctx, o.ensemble, generationPrompt, tmpl, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("ensemble candidate B: %w", err)
+	}
+
+	disagreements, err := compareCandidates(candidateA, candidateB)
+	if err != nil {
+		return "", nil, err
+	}
+	result := &EnsembleResult{
+		Models:        []string{opts.Model, opts.EnsembleModel},
+		Disagreements: disagreements,
+	}
+	if len(disagreements) == 0 {
+		log.Info().Msg("Ensemble candidates agree on all fields")
+		return candidateA, result, nil
+	}
+
+	log.Info().Strs("fields", disagreements).Msg("Reconciling ensemble disagreements")
+	reconciled, err := o.reconcile(ctx, candidateA, candidateB, disagreements, tmpl)
+	if err != nil {
+		// Candidate A is already valid; keep it rather than failing the run
+		log.Warn().Err(err).Msg("Ensemble reconciliation failed, using candidate A")
+		return candidateA, result, nil
+	}
+	result.Reconciled = true
+	return reconciled, result, nil
+}
+
+// reconcile asks the primary model to merge the candidates and validates the result.
+func (o *Orchestrator) reconcile(ctx context.Context, candidateA, candidateB string, disagreements []string, tmpl *templates.Template) (string, error) {
+	reconciliationPrompt, err := o.builder.BuildReconciliationPrompt(candidateA, candidateB, disagreements, tmpl.Schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to build reconciliation prompt: %w", err)
+	}
+
+	reconciled, err := o.aiClient.GenerateJSON(ctx, reconciliationPrompt)
+	if err != nil {
+		return "", fmt.Errorf("AI reconciliation failed: %w", err)
+	}
+	if err := o.validator.Validate(reconciled, string(tmpl.Schema)); err != nil {
+		return "", fmt.Errorf("reconciled document failed validation: %w", err)
+	}
+	return reconciled, nil
+}
+
+// compareCandidates returns the top-level fields whose values differ between candidates.
+func compareCandidates(candidateA, candidateB string) ([]string, error) {
+	var a, b map[string]interface{}
+	if err := json.Unmarshal([]byte(candidateA), &a); err != nil {
+		return nil, fmt.Errorf("failed to parse ensemble candidate A: %w", err)
+	}
+	if err := json.Unmarshal([]byte(candidateB), &b); err != nil {
+		return nil, fmt.Errorf("failed to parse ensemble candidate B: %w", err)
+	}
+
+	var fields []string
+	for key, value := range a {
+		if !reflect.DeepEqual(value, b[key]) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
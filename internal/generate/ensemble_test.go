@@ -0,0 +1,97 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// setupEnsembleTest registers a two-field template and returns an orchestrator and options.
+func setupEnsembleTest(t *testing.T, primary, secondary *promptCapturingClient) (*Orchestrator, Options) {
+	t.Helper()
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger service owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:        "ensemble-template",
+		Prompt:      "Generate the document",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"title":{"type":"string"},"body":{"type":"string"}},"required":["title","body"]}`),
+		HTMLContent: `<html><body><!-- data-field="title" --><!-- data-field="body" --></body></html>`,
+	}
+	orchestrator := NewOrchestrator(primary)
+	orchestrator.SetEnsembleClient(secondary)
+	require.NoError(t, orchestrator.registry.Register("ensemble-template", tmpl))
+
+	return orchestrator, Options{
+		TemplateType:  "ensemble-template",
+		Sources:       []string{sourceFile},
+		OutputFile:    filepath.Join(tempDir, "out.html"),
+		APIKey:        "test-key",
+		Model:         "model-a",
+		EnsembleModel: "model-b",
+	}
+}
+
+func TestGenerate_EnsembleReconcilesDisagreements(t *testing.T) {
+	primary := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "body": "The ledger owns balances."}`,
+		`{"title": "Ledger", "body": "The ledger service owns all balances."}`,
+	}}
+	secondary := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "body": "Balances live in the ledger service."}`,
+	}}
+
+	orchestrator, opts := setupEnsembleTest(t, primary, secondary)
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	require.Len(t, primary.prompts, 2, "primary generates candidate A and reconciles")
+	require.Len(t, secondary.prompts, 1)
+	assert.Contains(t, primary.prompts[1], "## Fields in Disagreement\nbody")
+
+	ensemble := result.Report.Ensemble
+	require.NotNil(t, ensemble)
+	assert.Equal(t, []string{"model-a", "model-b"}, ensemble.Models)
+	assert.Equal(t, []string{"body"}, ensemble.Disagreements)
+	assert.True(t, ensemble.Reconciled)
+	assert.Equal(t, "The ledger service owns all balances.", result.Fields["body"])
+}
+
+func TestGenerate_EnsembleAgreementSkipsReconciliation(t *testing.T) {
+	candidate := `{"title": "Ledger", "body": "The ledger owns balances."}`
+	primary := &promptCapturingClient{responses: []string{candidate}}
+	secondary := &promptCapturingClient{responses: []string{candidate}}
+
+	orchestrator, opts := setupEnsembleTest(t, primary, secondary)
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	assert.Len(t, primary.prompts, 1)
+	assert.Empty(t, result.Report.Ensemble.Disagreements)
+	assert.False(t, result.Report.Ensemble.Reconciled)
+}
+
+func TestGenerate_EnsembleFallsBackOnInvalidReconciliation(t *testing.T) {
+	primary := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "body": "Candidate A body."}`,
+		`{"title": "Ledger"}`,
+	}}
+	secondary := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "body": "Candidate B body."}`,
+	}}
+
+	orchestrator, opts := setupEnsembleTest(t, primary, secondary)
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	assert.False(t, result.Report.Ensemble.Reconciled)
+	assert.Equal(t, "Candidate A body.", result.Fields["body"])
+}
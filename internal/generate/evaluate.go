@@ -0,0 +1,78 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ai"
+)
+
+// Evaluation holds the rubric scores assigned by the judge model (0-10 scale).
+type Evaluation struct {
+	Fields       map[string]float64 `json:"fields,omitempty"` // Completeness per top-level field
+	Comments     []string           `json:"comments,omitempty"`
+	Model        string             `json:"model,omitempty"`
+	Completeness float64            `json:"completeness"`
+	Groundedness float64            `json:"groundedness"`
+	Clarity      float64            `json:"clarity"`
+	Overall      float64            `json:"overall"`
+	Threshold    float64            `json:"threshold,omitempty"`
+	Passed       bool               `json:"passed"`
+}
+
+// SetEvaluator sets the client used for the evaluation stage. By default the
+// generation client is used; a separate (cheaper) judge model can be configured here.
+func (o *Orchestrator) SetEvaluator(client ai.Client) {
+	o.evaluator = client
+}
+
+// evaluate scores the generated document against the rubric using the judge model.
+func (o *Orchestrator) evaluate(ctx context.Context, sourceContent, generatedJSON string, opts Options) (*Evaluation, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(generatedJSON), &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse generated JSON for evaluation: %w", err)
+	}
+	fieldNames := make([]string, 0, len(fields))
+	for name := range fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+
+	judge := o.evaluator
+	if judge == nil {
+		judge = o.aiClient
+	}
+
+	log.Info().Msg("Evaluating generated document")
+	response, err := judge.GenerateJSON(ctx, o.builder.BuildEvaluationPrompt(sourceContent, generatedJSON, fieldNames))
+	if err != nil {
+		return nil, fmt.Errorf("AI evaluation failed: %w", err)
+	}
+
+	var evaluation Evaluation
+	if err := json.Unmarshal([]byte(response), &evaluation); err != nil {
+		return nil, fmt.Errorf("invalid evaluation response: %w", err)
+	}
+	for _, score := range []float64{evaluation.Completeness, evaluation.Groundedness, evaluation.Clarity} {
+		if score < 0 || score > 10 {
+			return nil, fmt.Errorf("invalid evaluation response: score %v is outside 0-10", score)
+		}
+	}
+
+	evaluation.Model = opts.EvaluationModel
+	evaluation.Overall = (evaluation.Completeness + evaluation.Groundedness + evaluation.Clarity) / 3
+	evaluation.Threshold = opts.EvaluationThreshold
+	evaluation.Passed = evaluation.Overall >= opts.EvaluationThreshold
+
+	log.Info().
+		Float64("overall", evaluation.Overall).
+		Float64("completeness", evaluation.Completeness).
+		Float64("groundedness", evaluation.Groundedness).
+		Float64("clarity", evaluation.Clarity).
+		Msg("Evaluation complete")
+	return &evaluation, nil
+}
@@ -0,0 +1,105 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// setupEvaluationTest registers a small template and returns an orchestrator and options.
+func setupEvaluationTest(t *testing.T, client *promptCapturingClient) (*Orchestrator, Options) {
+	t.Helper()
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger service owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:        "eval-template",
+		Prompt:      "Generate the document",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"body":{"type":"string"}},"required":["body"]}`),
+		HTMLContent: `<html><body><!-- data-field="body" --></body></html>`,
+	}
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("eval-template", tmpl))
+
+	return orchestrator, Options{
+		TemplateType: "eval-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "out.html"),
+		APIKey:       "test-key",
+		Evaluate:     true,
+	}
+}
+
+func TestGenerate_EvaluationStage(t *testing.T) {
+	generator := &promptCapturingClient{responses: []string{`{"body": "The ledger service owns balances."}`}}
+	judge := &promptCapturingClient{responses: []string{
+		`{"completeness": 9, "groundedness": 8, "clarity": 7, "fields": {"body": 9}, "comments": ["Concise"]}`,
+	}}
+
+	orchestrator, opts := setupEvaluationTest(t, generator)
+	orchestrator.SetEvaluator(judge)
+	opts.EvaluationModel = "judge-mini"
+	opts.EvaluationThreshold = 7
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	require.Len(t, judge.prompts, 1, "the judge client should score the document")
+	assert.Len(t, generator.prompts, 1)
+	assert.Contains(t, judge.prompts[0], "The ledger service owns balances.")
+
+	require.NotNil(t, result.Report.Evaluation)
+	evaluation := result.Report.Evaluation
+	assert.InDelta(t, 8.0, evaluation.Overall, 0.001)
+	assert.True(t, evaluation.Passed)
+	assert.Equal(t, "judge-mini", evaluation.Model)
+	assert.Equal(t, 9.0, evaluation.Fields["body"])
+
+	// The scores are persisted in the run report
+	data, err := os.ReadFile(result.ReportFile)
+	require.NoError(t, err)
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.NotNil(t, report.Evaluation)
+	assert.Equal(t, []string{"Concise"}, report.Evaluation.Comments)
+}
+
+func TestGenerate_EvaluationGateFails(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"body": "Unrelated content."}`,
+		`{"completeness": 5, "groundedness": 2, "clarity": 5}`,
+	}}
+
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.EvaluationThreshold = 6
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "quality gate failed")
+
+	// Outputs are kept for inspection
+	require.NotNil(t, result)
+	assert.FileExists(t, result.OutputFile)
+	assert.FileExists(t, result.ReportFile)
+	assert.False(t, result.Report.Evaluation.Passed)
+}
+
+func TestGenerate_EvaluationRejectsInvalidScores(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"body": "Content."}`,
+		`{"completeness": 42, "groundedness": 2, "clarity": 5}`,
+	}}
+
+	orchestrator, opts := setupEvaluationTest(t, client)
+	_, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside 0-10")
+}
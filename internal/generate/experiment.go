@@ -0,0 +1,127 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PromptVariant is an alternative template prompt evaluated by an experiment.
+type PromptVariant struct {
+	Name   string
+	Prompt string
+}
+
+// ExperimentOptions configures a prompt comparison experiment.
+type ExperimentOptions struct {
+	TemplateType string
+	Sources      []string
+	Variants     []PromptVariant
+	Samples      int // Generations per variant
+}
+
+// VariantResult aggregates the samples generated for one prompt variant.
+type VariantResult struct {
+	Errors         []string `json:"errors,omitempty"`
+	Name           string   `json:"name"`
+	Samples        int      `json:"samples"`
+	Valid          int      `json:"valid"`
+	PassRate       float64  `json:"pass_rate"`
+	PromptTokens   int      `json:"prompt_tokens"`   // Estimated, per sample
+	ResponseTokens int      `json:"response_tokens"` // Estimated, total over samples
+	AverageLint    float64  `json:"average_lint_score"`
+	AverageSeconds float64  `json:"average_seconds"`
+}
+
+// ExperimentReport compares prompt variants for a template.
+type ExperimentReport struct {
+	Started  time.Time       `json:"started"`
+	Template string          `json:"template"`
+	Sources  []string        `json:"sources"`
+	Variants []VariantResult `json:"variants"`
+}
+
+// Experiment generates documents for each prompt variant against the same sources and
+// collects first-attempt validation pass rates, estimated token usage and lint scores.
+// No repairs are attempted so the results reflect the prompt alone, and nothing is written.
+func (o *Orchestrator) Experiment(ctx context.Context, opts ExperimentOptions) (*ExperimentReport, error) {
+	if len(opts.Variants) == 0 {
+		return nil, fmt.Errorf("at least one prompt variant is required")
+	}
+	if len(opts.Sources) == 0 {
+		return nil, fmt.Errorf("at least one source is required")
+	}
+	if opts.Samples < 1 {
+		opts.Samples = 1
+	}
+
+	tmpl, err := o.registry.Get(opts.TemplateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template %s: %w", opts.TemplateType, err)
+	}
+
+	sourceContent, err := o.ingester.IngestSources(opts.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest sources: %w", err)
+	}
+
+	report := &ExperimentReport{
+		Started:  time.Now().UTC(),
+		Template: tmpl.Name,
+		Sources:  opts.Sources,
+	}
+
+	counter := o.tokenCounter("")
+	for _, variant := range opts.Variants {
+		generationPrompt, err := o.builder.BuildGenerationPrompt(sourceContent, variant.Prompt, tmpl.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build prompt for variant %s: %w", variant.Name, err)
+		}
+
+		result := VariantResult{
+			Name:         variant.Name,
+			Samples:      opts.Samples,
+			PromptTokens: counter.Count(generationPrompt),
+		}
+
+		var totalDuration time.Duration
+		var totalLint float64
+		for sample := 1; sample <= opts.Samples; sample++ {
+			log.Info().Str("variant", variant.Name).Int("sample", sample).Msg("Generating experiment sample")
+
+			startTime := time.Now()
+			response, err := o.aiClient.GenerateJSON(ctx, generationPrompt)
+			totalDuration += time.Since(startTime)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("sample %d: %v", sample, err))
+				continue
+			}
+			result.ResponseTokens += counter.Count(response)
+
+			if err := o.validator.Validate(response, string(tmpl.Schema)); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("sample %d: %v", sample, err))
+				continue
+			}
+
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(response), &fields); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("sample %d: %v", sample, err))
+				continue
+			}
+			result.Valid++
+			totalLint += LintDocument(fields).Score
+		}
+
+		result.PassRate = float64(result.Valid) / float64(opts.Samples)
+		result.AverageSeconds = totalDuration.Seconds() / float64(opts.Samples)
+		if result.Valid > 0 {
+			result.AverageLint = totalLint / float64(result.Valid)
+		}
+		report.Variants = append(report.Variants, result)
+	}
+
+	return report, nil
+}
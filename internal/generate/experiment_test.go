@@ -0,0 +1,67 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+func TestExperiment_ComparesVariants(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger service owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:   "experiment-template",
+		Prompt: "Default prompt",
+		Schema: json.RawMessage(`{"type":"object","properties":{"body":{"type":"string"}},"required":["body"]}`),
+	}
+
+	client := &promptCapturingClient{
+		responses: []string{
+			// Variant v1: one valid, one invalid
+			`{"body": "The ledger service owns all balances."}`,
+			`{"other": "missing body"}`,
+			// Variant v2: two valid, one with placeholder content
+			`{"body": "Ledger owns balances."}`,
+			`{"body": "TBD"}`,
+		},
+	}
+
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("experiment-template", tmpl))
+
+	report, err := orchestrator.Experiment(context.Background(), ExperimentOptions{
+		TemplateType: "experiment-template",
+		Sources:      []string{sourceFile},
+		Variants: []PromptVariant{
+			{Name: "v1", Prompt: "Prompt variant one"},
+			{Name: "v2", Prompt: "Prompt variant two"},
+		},
+		Samples: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Variants, 2)
+
+	v1, v2 := report.Variants[0], report.Variants[1]
+	assert.Equal(t, 1, v1.Valid)
+	assert.InDelta(t, 0.5, v1.PassRate, 0.001)
+	assert.Len(t, v1.Errors, 1)
+	assert.InDelta(t, 1.0, v1.AverageLint, 0.001)
+
+	assert.Equal(t, 2, v2.Valid)
+	assert.InDelta(t, 1.0, v2.PassRate, 0.001)
+	assert.InDelta(t, 0.5, v2.AverageLint, 0.001)
+	assert.Positive(t, v2.PromptTokens)
+
+	require.Len(t, client.prompts, 4)
+	assert.Contains(t, client.prompts[0], "Prompt variant one")
+	assert.Contains(t, client.prompts[2], "Prompt variant two")
+}
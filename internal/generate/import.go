@@ -0,0 +1,127 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ci"
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+// ImportOptions configures rendering externally produced field JSON with a template.
+type ImportOptions struct {
+	TemplateType string
+	FieldsFile   string // JSON file with the document fields
+	OutputFile   string
+	Force        bool
+	Provenance   bool
+
+	// Variables for output filename patterns such as "{{project}}-{{date}}.html"
+	Variables map[string]string
+}
+
+// ImportError reports imported fields that do not conform to the template.
+type ImportError struct {
+	File   string
+	Issues []validate.ValidationIssue
+}
+
+func (e *ImportError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for idx, issue := range e.Issues {
+		messages[idx] = issue.Message
+	}
+	return fmt.Sprintf("%s does not conform to the template (%d issue(s)):\n  %s", e.File, len(e.Issues), strings.Join(messages, "\n  "))
+}
+
+// Import validates field JSON produced outside DocLoom, for example from a context
+// pack, against the template's schema and consistency rules and renders it like a
+// generated document: the HTML, its JSON sidecar and a run report. Values are
+// normalized first, as for generated JSON. Fields that do not conform are reported
+// together as an *ImportError and nothing is written.
+func (o *Orchestrator) Import(opts ImportOptions) (*Result, error) {
+	if opts.TemplateType == "" {
+		return nil, errors.New(i18n.T("error.template_required"))
+	}
+	if opts.OutputFile == "" {
+		return nil, errors.New(i18n.T("error.output_required"))
+	}
+	tmpl, err := o.registry.Get(opts.TemplateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template %s: %w", opts.TemplateType, err)
+	}
+
+	// Output names follow the same rules as generated documents
+	runOpts := Options{TemplateType: opts.TemplateType, OutputFile: opts.OutputFile, Force: opts.Force, Variables: opts.Variables}
+	runOpts.OutputFile = expandOutputVariables(outputTarget(runOpts.OutputFile, tmpl.Output), runOpts.outputVariables(tmpl))
+	if err := checkResolvedOutput(runOpts); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(opts.FieldsFile) // #nosec G304 -- the fields file is user-provided
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fields file: %w", err)
+	}
+	fieldsJSON := o.normalize(string(data), string(tmpl.Schema))
+	result, err := o.validator.ValidateWithDetails(fieldsJSON, string(tmpl.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", tmpl.Name, err)
+	}
+	if !result.Valid {
+		return nil, &ImportError{File: opts.FieldsFile, Issues: result.Errors}
+	}
+	if err := o.validator.CheckRules(fieldsJSON, tmpl.Rules); err != nil {
+		return nil, &ImportError{File: opts.FieldsFile, Issues: []validate.ValidationIssue{{Type: "rule_error", Message: err.Error()}}}
+	}
+	log.Info().Str("file", opts.FieldsFile).Str("template", tmpl.Name).Msg("Imported fields are valid")
+
+	runOpts.OutputFile, err = resolveOutputFields(runOpts, fieldsJSON)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(fieldsJSON), &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse imported fields: %w", err)
+	}
+
+	report := &Report{
+		RunID:        newRunID(),
+		Template:     tmpl.Name,
+		CI:           ci.Detect(),
+		OutputFile:   runOpts.OutputFile,
+		ImportedFrom: opts.FieldsFile,
+	}
+	runOpts.Provenance = opts.Provenance
+	if err := o.renderer.RenderWithOptions(tmpl.HTMLContent, fields, runOpts.OutputFile, render.Options{
+		Provenance: provenanceFor(runOpts, report),
+		Transforms: tmpl.Transforms,
+		Assets:     tmpl.Assets,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render output: %w", err)
+	}
+
+	report.GeneratedAt = time.Now().UTC()
+	report.JSONFile = render.SidecarPath(runOpts.OutputFile, ".json")
+	reportFile := reportPath(runOpts.OutputFile)
+	if err := writeReport(reportFile, report); err != nil {
+		return nil, err
+	}
+	log.Info().Str("html_file", runOpts.OutputFile).Str("json_file", report.JSONFile).Msg("Imported document rendered")
+
+	return &Result{
+		Fields:     fields,
+		Report:     report,
+		Template:   tmpl.Name,
+		OutputFile: runOpts.OutputFile,
+		JSONFile:   report.JSONFile,
+		ReportFile: reportFile,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+func setupImportTest(t *testing.T) (*Orchestrator, string) {
+	t.Helper()
+	orchestrator := NewOrchestrator(nil)
+	require.NoError(t, orchestrator.registry.Register("import-template", &templates.Template{
+		Name:        "import-template",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"title":{"type":"string"},"count":{"type":"number"},"items":{"type":"array","items":{"type":"string"}}},"required":["title","count"]}`),
+		HTMLContent: `<html><body><!-- data-field="title" --> <!-- data-field="count" --></body></html>`,
+		Rules:       []validate.Rule{{Name: "count-items", Expr: "count == count(items)", Message: "count must match the items"}},
+	}))
+	return orchestrator, t.TempDir()
+}
+
+func TestImport_RendersValidFields(t *testing.T) {
+	orchestrator, tempDir := setupImportTest(t)
+	fieldsFile := filepath.Join(tempDir, "external.json")
+	require.NoError(t, os.WriteFile(fieldsFile, []byte(`{"title": "Ledger", "count": "2", "items": ["a", "b"]}`), 0644))
+
+	out := filepath.Join(tempDir, "doc.html")
+	result, err := orchestrator.Import(ImportOptions{TemplateType: "import-template", FieldsFile: fieldsFile, OutputFile: out})
+	require.NoError(t, err)
+
+	html, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Ledger")
+	assert.Equal(t, float64(2), result.Fields["count"], "values are normalized like generated JSON")
+	assert.FileExists(t, result.JSONFile)
+	assert.Equal(t, fieldsFile, result.Report.ImportedFrom)
+	assert.FileExists(t, result.ReportFile)
+
+	// Existing outputs are kept unless forced
+	_, err = orchestrator.Import(ImportOptions{TemplateType: "import-template", FieldsFile: fieldsFile, OutputFile: out})
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestImport_RecordsCIRun(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_SHA", "1a2b3c4d5e6f")
+	t.Setenv("GITHUB_REF_NAME", "main")
+	orchestrator, tempDir := setupImportTest(t)
+	fieldsFile := filepath.Join(tempDir, "external.json")
+	require.NoError(t, os.WriteFile(fieldsFile, []byte(`{"title": "Ledger", "count": 0}`), 0644))
+
+	out := filepath.Join(tempDir, "doc.html")
+	result, err := orchestrator.Import(ImportOptions{TemplateType: "import-template", FieldsFile: fieldsFile, OutputFile: out, Provenance: true})
+	require.NoError(t, err)
+	require.NotNil(t, result.Report.CI)
+	assert.Equal(t, "github-actions", result.Report.CI.Provider)
+	assert.Equal(t, "42", result.Report.CI.PipelineID)
+
+	html, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "github-actions run 42 at 1a2b3c4 on main")
+}
+
+func TestImport_ReportsEveryIssue(t *testing.T) {
+	orchestrator, tempDir := setupImportTest(t)
+	fieldsFile := filepath.Join(tempDir, "external.json")
+	require.NoError(t, os.WriteFile(fieldsFile, []byte(`{"title": 7, "items": ["a", 1]}`), 0644))
+
+	out := filepath.Join(tempDir, "doc.html")
+	_, err := orchestrator.Import(ImportOptions{TemplateType: "import-template", FieldsFile: fieldsFile, OutputFile: out})
+	var importErr *ImportError
+	require.True(t, errors.As(err, &importErr), "got %v", err)
+	fields := make([]string, 0, len(importErr.Issues))
+	for _, issue := range importErr.Issues {
+		fields = append(fields, issue.Field)
+	}
+	assert.ElementsMatch(t, []string{"/", "/title", "/items/1"}, fields)
+	assert.NoFileExists(t, out)
+
+	// Consistency rules apply after the schema
+	require.NoError(t, os.WriteFile(fieldsFile, []byte(`{"title": "Ledger", "count": 3, "items": ["a"]}`), 0644))
+	_, err = orchestrator.Import(ImportOptions{TemplateType: "import-template", FieldsFile: fieldsFile, OutputFile: out})
+	assert.ErrorContains(t, err, "count must match the items")
+}
@@ -0,0 +1,253 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+const (
+	// maxPitfalls is the most recurring failures added to a generation prompt.
+	maxPitfalls = 5
+
+	// minPitfallRuns is the number of runs a failure must have occurred in before it
+	// is added to generation prompts.
+	minPitfallRuns = 2
+
+	// maxPatternLength bounds failure patterns taken from unstructured errors.
+	maxPatternLength = 200
+)
+
+// lessonsMu serializes updates of lessons files by concurrent runs, such as batches.
+var lessonsMu sync.Mutex
+
+// RepairAttempt is a repair of an invalid document recorded in the run report.
+type RepairAttempt struct {
+	Attempt  int      `json:"attempt"`            // Validation attempt the repair followed, from 1
+	Error    string   `json:"error"`              // Validation error the model was asked to fix
+	Patterns []string `json:"patterns,omitempty"` // Forms of the error aggregated in lessons
+	Targeted bool     `json:"targeted"`           // A targeted repair prompt was used
+	Response string   `json:"response"`           // The model's repaired document
+	Fixed    bool     `json:"fixed"`              // The repaired document validated
+}
+
+// repairLog collects the repair attempts of a run.
+type repairLog struct {
+	mu       sync.Mutex
+	attempts []RepairAttempt
+}
+
+// repairLogKey is the context key of the run's repair log.
+type repairLogKey struct{}
+
+// withRepairLog returns a context carrying l, so repair loops deep in the run can
+// record into it.
+func withRepairLog(ctx context.Context, l *repairLog) context.Context {
+	return context.WithValue(ctx, repairLogKey{}, l)
+}
+
+// repairLogFrom returns the run's repair log, or nil when there is none. Its methods
+// accept a nil receiver.
+func repairLogFrom(ctx context.Context) *repairLog {
+	l, _ := ctx.Value(repairLogKey{}).(*repairLog)
+	return l
+}
+
+// record adds a repair attempt.
+func (l *repairLog) record(attempt RepairAttempt) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts = append(l.attempts, attempt)
+}
+
+// list returns the recorded repair attempts.
+func (l *repairLog) list() []RepairAttempt {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]RepairAttempt(nil), l.attempts...)
+}
+
+// failurePatterns returns the forms of a validation failure that recur across runs:
+// each failing field, with array indexes replaced by *, and its violated constraint,
+// each violated rule, or else the first line of the error.
+func (o *Orchestrator) failurePatterns(generatedJSON, schemaStr string, validationErr error) []string {
+	var patterns []string
+	var ruleErr *validate.RuleViolationError
+	if errors.As(validationErr, &ruleErr) {
+		for _, violation := range ruleErr.Violations {
+			patterns = append(patterns, violation.String())
+		}
+		return patterns
+	}
+	if issues, narrowed, err := o.validator.FieldIssues(generatedJSON, schemaStr); err == nil && narrowed {
+		for _, issue := range issues {
+			patterns = append(patterns, fmt.Sprintf("`%s`: %s", generalizeField(issue.Field), issue.Problem))
+		}
+		if len(patterns) > 0 {
+			return patterns
+		}
+	}
+	pattern, _, _ := strings.Cut(validationErr.Error(), "\n")
+	if len(pattern) > maxPatternLength {
+		pattern = pattern[:maxPatternLength] + "..."
+	}
+	return []string{pattern}
+}
+
+// generalizeField replaces the array indexes of a JSON pointer with *, e.g.
+// /risks/0/level becomes /risks/*/level.
+func generalizeField(field string) string {
+	if field == "" {
+		return "/"
+	}
+	segments := strings.Split(field, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Lessons aggregates the validation failures of a template's runs. It is kept in
+// <template>.lessons.json in the lessons directory, and its most frequent pitfalls
+// are added to the template's generation prompts.
+type Lessons struct {
+	Template string    `json:"template"`
+	Runs     int       `json:"runs"` // Runs recorded, including those without failures
+	Pitfalls []Pitfall `json:"pitfalls"`
+}
+
+// Pitfall is a recurring validation failure.
+type Pitfall struct {
+	Pattern  string    `json:"pattern"`
+	Runs     int       `json:"runs"` // Runs in which it occurred
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// lessonsPath returns the path of a template's lessons file in dir.
+func lessonsPath(dir, template string) string {
+	return filepath.Join(dir, template+".lessons.json")
+}
+
+// LoadLessons reads the lessons of a template from dir, returning empty lessons when
+// none have been recorded.
+func LoadLessons(dir, template string) (*Lessons, error) {
+	data, err := os.ReadFile(lessonsPath(dir, template)) // #nosec G304 -- the lessons directory is user-provided
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Lessons{Template: template}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lessons: %w", err)
+	}
+	var lessons Lessons
+	if err := json.Unmarshal(data, &lessons); err != nil {
+		return nil, fmt.Errorf("failed to parse lessons %s: %w", lessonsPath(dir, template), err)
+	}
+	lessons.Template = template
+	return &lessons, nil
+}
+
+// Top returns the patterns of up to n pitfalls that occurred in at least minRuns
+// runs, most frequent first.
+func (l *Lessons) Top(n, minRuns int) []string {
+	pitfalls := append([]Pitfall(nil), l.Pitfalls...)
+	sort.SliceStable(pitfalls, func(i, j int) bool {
+		if pitfalls[i].Runs != pitfalls[j].Runs {
+			return pitfalls[i].Runs > pitfalls[j].Runs
+		}
+		return pitfalls[i].LastSeen.After(pitfalls[j].LastSeen)
+	})
+	var patterns []string
+	for _, pitfall := range pitfalls {
+		if len(patterns) == n || pitfall.Runs < minRuns {
+			break
+		}
+		patterns = append(patterns, pitfall.Pattern)
+	}
+	return patterns
+}
+
+// record counts a run and the distinct failure patterns of its repairs.
+func (l *Lessons) record(attempts []RepairAttempt, now time.Time) {
+	l.Runs++
+	seen := make(map[string]bool)
+	for _, attempt := range attempts {
+		for _, pattern := range attempt.Patterns {
+			if seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			l.addPitfall(pattern, now)
+		}
+	}
+}
+
+// addPitfall counts a run in which pattern occurred.
+func (l *Lessons) addPitfall(pattern string, now time.Time) {
+	for i := range l.Pitfalls {
+		if l.Pitfalls[i].Pattern == pattern {
+			l.Pitfalls[i].Runs++
+			l.Pitfalls[i].LastSeen = now
+			return
+		}
+	}
+	l.Pitfalls = append(l.Pitfalls, Pitfall{Pattern: pattern, Runs: 1, LastSeen: now})
+}
+
+// addPitfalls appends the recurring failures recorded for the template in dir to a
+// generation prompt. Lessons that cannot be read are skipped with a warning.
+func (o *Orchestrator) addPitfalls(generationPrompt, dir, template string) string {
+	lessons, err := LoadLessons(dir, template)
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring lessons")
+		return generationPrompt
+	}
+	pitfalls := lessons.Top(maxPitfalls, minPitfallRuns)
+	if len(pitfalls) == 0 {
+		return generationPrompt
+	}
+	log.Info().Int("pitfalls", len(pitfalls)).Msg("Adding recurring validation failures to the prompt")
+	return o.builder.AppendPitfalls(generationPrompt, pitfalls)
+}
+
+// recordLessons adds the repairs of a run to the template's lessons in dir. Lessons
+// are hints, so failures to update them are logged rather than failing the run.
+func recordLessons(dir, template string, attempts []RepairAttempt) {
+	lessonsMu.Lock()
+	defer lessonsMu.Unlock()
+	lessons, err := LoadLessons(dir, template)
+	if err != nil {
+		log.Warn().Err(err).Msg("Not updating lessons")
+		return
+	}
+	lessons.record(attempts, time.Now().UTC())
+	data, err := json.MarshalIndent(lessons, "", "  ")
+	if err != nil {
+		log.Warn().Err(err).Msg("Not updating lessons")
+		return
+	}
+	if err := render.WriteFileAtomic(lessonsPath(dir, template), data); err != nil {
+		log.Warn().Err(err).Msg("Failed to update lessons")
+	}
+}
@@ -0,0 +1,82 @@
+package generate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_RepairHistoryAndLessons(t *testing.T) {
+	lessonsDir := t.TempDir()
+	run := func(responses ...string) (*promptCapturingClient, *Result) {
+		client := &promptCapturingClient{responses: responses}
+		orchestrator, opts := setupEvaluationTest(t, client)
+		opts.Evaluate = false
+		opts.MaxRepairs = 2
+		opts.LessonsDir = lessonsDir
+		result, err := orchestrator.Run(context.Background(), opts)
+		require.NoError(t, err)
+		return client, result
+	}
+
+	// The first run needs a repair, which is recorded in the report
+	client, result := run(`{"body": 42}`, `{"body": "The ledger service owns balances."}`)
+	require.Len(t, result.Report.Repairs, 1)
+	repair := result.Report.Repairs[0]
+	assert.Equal(t, 1, repair.Attempt)
+	assert.NotEmpty(t, repair.Error)
+	assert.Equal(t, []string{"`/body`: expected string, but got number"}, repair.Patterns)
+	assert.JSONEq(t, `{"body": "The ledger service owns balances."}`, repair.Response)
+	assert.True(t, repair.Fixed)
+	assert.NotContains(t, client.prompts[0], "## Common Pitfalls", "a failure seen once is not a pitfall yet")
+
+	lessons, err := LoadLessons(lessonsDir, "eval-template")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lessons.Runs)
+	require.Len(t, lessons.Pitfalls, 1)
+	assert.Equal(t, 1, lessons.Pitfalls[0].Runs)
+
+	// Once the failure recurs, later prompts warn about it up front
+	run(`{"body": 7}`, `{"body": "The ledger service owns balances."}`)
+	client, result = run(`{"body": "The ledger service owns balances."}`)
+	assert.Empty(t, result.Report.Repairs)
+	assert.Contains(t, client.prompts[0], "## Common Pitfalls\n")
+	assert.Contains(t, client.prompts[0], "- `/body`: expected string, but got number\n")
+
+	lessons, err = LoadLessons(lessonsDir, "eval-template")
+	require.NoError(t, err)
+	assert.Equal(t, 3, lessons.Runs)
+	assert.Equal(t, 2, lessons.Pitfalls[0].Runs)
+}
+
+func TestLessons_Top(t *testing.T) {
+	now := time.Now()
+	lessons := &Lessons{}
+	lessons.record([]RepairAttempt{{Patterns: []string{"a", "b"}}, {Patterns: []string{"a"}}}, now)
+	lessons.record([]RepairAttempt{{Patterns: []string{"b"}}}, now.Add(time.Minute))
+	lessons.record([]RepairAttempt{{Patterns: []string{"c"}}}, now.Add(2*time.Minute))
+	lessons.record([]RepairAttempt{{Patterns: []string{"c"}}}, now.Add(3*time.Minute))
+	lessons.record(nil, now.Add(4*time.Minute))
+
+	assert.Equal(t, 5, lessons.Runs)
+	assert.Equal(t, []string{"c", "b"}, lessons.Top(5, 2), "patterns count once per run; ties go to the most recent")
+	assert.Equal(t, []string{"c"}, lessons.Top(1, 2))
+	assert.Equal(t, []string{"c", "b", "a"}, lessons.Top(5, 1))
+}
+
+func TestLoadLessons_Missing(t *testing.T) {
+	lessons, err := LoadLessons(filepath.Join(t.TempDir(), "lessons"), "architecture-vision")
+	require.NoError(t, err)
+	assert.Equal(t, "architecture-vision", lessons.Template)
+	assert.Empty(t, lessons.Top(maxPitfalls, minPitfallRuns))
+}
+
+func TestGeneralizeField(t *testing.T) {
+	assert.Equal(t, "/risks/*/level", generalizeField("/risks/0/level"))
+	assert.Equal(t, "/owner", generalizeField("/owner"))
+	assert.Equal(t, "/", generalizeField(""))
+}
@@ -0,0 +1,98 @@
+package generate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholderPattern matches filler text that models emit instead of real content.
+var placeholderPattern = regexp.MustCompile(`(?i)^(todo|tbd|n/?a|none|placeholder|lorem ipsum.*|\[.*\]|<.*>|\.\.\.)$`)
+
+// LintResult summarizes content problems found in a generated document.
+type LintResult struct {
+	Issues []string // One entry per problematic field path
+	Score  float64  // Share of leaf values without problems (0.0-1.0)
+}
+
+// LintDocument checks every leaf value of a generated document for empty values,
+// placeholder text and duplicated strings.
+func LintDocument(fields map[string]interface{}) LintResult {
+	leaves := make(map[string]interface{})
+	collectLeaves("", fields, leaves)
+	if len(leaves) == 0 {
+		return LintResult{Score: 0, Issues: []string{"document has no fields"}}
+	}
+
+	paths := make([]string, 0, len(leaves))
+	for path := range leaves {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var issues []string
+	seen := make(map[string]string)
+	for _, path := range paths {
+		if issue := lintValue(leaves[path], seen, path); issue != "" {
+			issues = append(issues, fmt.Sprintf("%s: %s", path, issue))
+		}
+	}
+
+	return LintResult{
+		Score:  1 - float64(len(issues))/float64(len(leaves)),
+		Issues: issues,
+	}
+}
+
+// lintValue returns a description of the problem with a leaf value, or "".
+func lintValue(value interface{}, seen map[string]string, path string) string {
+	switch v := value.(type) {
+	case nil:
+		return "null value"
+	case string:
+		text := strings.TrimSpace(v)
+		if text == "" {
+			return "empty value"
+		}
+		if placeholderPattern.MatchString(text) {
+			return fmt.Sprintf("placeholder text %q", text)
+		}
+		// Longer texts repeated verbatim across fields usually indicate copy-paste filler
+		if len(text) > 40 {
+			if other, ok := seen[text]; ok {
+				return "duplicates " + other
+			}
+			seen[text] = path
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return "empty list"
+		}
+	}
+	return ""
+}
+
+// collectLeaves flattens nested objects and arrays into dot/index paths.
+func collectLeaves(prefix string, value interface{}, leaves map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			collectLeaves(path, child, leaves)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			leaves[prefix] = v
+			return
+		}
+		for i, child := range v {
+			collectLeaves(fmt.Sprintf("%s[%d]", prefix, i), child, leaves)
+		}
+	default:
+		leaves[prefix] = v
+	}
+}
@@ -0,0 +1,36 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintDocument(t *testing.T) {
+	repeated := "This paragraph is repeated verbatim in two different fields."
+	fields := map[string]interface{}{
+		"title": "Payments Architecture",
+		"overview": map[string]interface{}{
+			"summary": repeated,
+			"details": repeated,
+		},
+		"risks":  []interface{}{},
+		"owner":  "TODO",
+		"status": "",
+		"count":  3.0,
+	}
+
+	result := LintDocument(fields)
+	assert.Len(t, result.Issues, 4)
+	assert.Contains(t, result.Issues, "owner: placeholder text \"TODO\"")
+	assert.Contains(t, result.Issues, "overview.summary: duplicates overview.details")
+	assert.Contains(t, result.Issues, "risks: empty list")
+	assert.Contains(t, result.Issues, "status: empty value")
+	assert.InDelta(t, 3.0/7.0, result.Score, 0.001)
+}
+
+func TestLintDocument_Empty(t *testing.T) {
+	result := LintDocument(map[string]interface{}{})
+	assert.Zero(t, result.Score)
+	assert.NotEmpty(t, result.Issues)
+}
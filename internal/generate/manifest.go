@@ -0,0 +1,187 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/karolswdev/docloom/internal/docregistry"
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// ManifestVersion is the format version of run manifests.
+const ManifestVersion = 1
+
+// ManifestSuffix is the suffix of run manifests, written next to the output as
+// <name>.manifest.json.
+const ManifestSuffix = ".manifest.json"
+
+// Manifest records the inputs a document was generated from, lockfile-style, so that
+// docloom verify can tell whether the document is stale without generating it again.
+// Identical runs produce identical manifests: it holds no timestamps or run IDs, which
+// are in the run report.
+type Manifest struct {
+	Version         int               `json:"version"`
+	Template        string            `json:"template"`
+	TemplateVersion string            `json:"template_version,omitempty"`
+	TemplateDigest  string            `json:"template_digest"` // templates.Template.Digest
+	Model           string            `json:"model"`
+	Temperature     float32           `json:"temperature"`
+	Seed            *int              `json:"seed,omitempty"`
+	PromptHash      string            `json:"prompt_hash"` // SHA-256 of the generation prompt
+	Sources         []string          `json:"sources"`
+	SourcesHash     string            `json:"sources_hash"`
+	Files           map[string]string `json:"files"`   // Source file path -> SHA-256
+	Output          string            `json:"output"`  // File name of the document
+	Outputs         map[string]string `json:"outputs"` // Output file name -> SHA-256, with sidecars
+}
+
+// ManifestPath returns the run manifest path for an output file.
+func ManifestPath(outputFile string) string {
+	return render.SidecarPath(outputFile, ManifestSuffix)
+}
+
+// newManifest builds the manifest of a run that wrote outputs, the document first,
+// hashing the outputs and the files of the sources; sources skipped because they do
+// not exist are recorded without files.
+func newManifest(opts Options, tmpl *templates.Template, generationPrompt string, outputs []string) (*Manifest, error) {
+	files, err := docregistry.BuildManifest(docregistry.ExistingSources(opts.Sources))
+	if err != nil {
+		return nil, err
+	}
+	promptSum := sha256.Sum256([]byte(generationPrompt))
+	manifest := &Manifest{
+		Version:         ManifestVersion,
+		Template:        tmpl.Name,
+		TemplateVersion: tmpl.Version,
+		TemplateDigest:  tmpl.Digest(),
+		Model:           opts.Model,
+		Temperature:     opts.Temperature,
+		Seed:            opts.Seed,
+		PromptHash:      hex.EncodeToString(promptSum[:]),
+		Sources:         opts.Sources,
+		SourcesHash:     docregistry.ManifestHash(files),
+		Files:           files,
+		Output:          filepath.Base(outputs[0]),
+		Outputs:         make(map[string]string, len(outputs)),
+	}
+	for _, output := range outputs {
+		sum, err := docregistry.HashFile(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash output: %w", err)
+		}
+		manifest.Outputs[filepath.Base(output)] = sum
+	}
+	return manifest, nil
+}
+
+// writeManifest saves a run manifest.
+func writeManifest(path string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run manifest: %w", err)
+	}
+	if err := render.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest loads a run manifest.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - manifest path provided by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse run manifest %s: %w", path, err)
+	}
+	if manifest.Version > ManifestVersion {
+		return nil, fmt.Errorf("run manifest %s has version %d; this version of docloom reads up to %d", path, manifest.Version, ManifestVersion)
+	}
+	return &manifest, nil
+}
+
+// Verification is the result of checking a document against its run manifest.
+type Verification struct {
+	Manifest *Manifest `json:"-"`
+	Path     string    `json:"manifest"`
+
+	// State is fresh, stale (sources or template changed) or missing (an output no
+	// longer exists), as in the document registry
+	State string `json:"state"`
+
+	Added   []string `json:"added,omitempty"`   // Source files created since generation
+	Removed []string `json:"removed,omitempty"` // Source files deleted since generation
+	Changed []string `json:"changed,omitempty"` // Source files modified since generation
+
+	// TemplateChanged is set when the template differs from the one the document was
+	// generated with; TemplateUnknown when the template is not installed to compare
+	TemplateChanged bool `json:"template_changed,omitempty"`
+	TemplateUnknown bool `json:"template_unknown,omitempty"`
+
+	// Outputs that were edited since generation, and those that are missing
+	Modified []string `json:"modified,omitempty"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// VerifyManifest checks the document of the manifest at path against the current
+// sources and, unless tmpl is nil, the current template. Outputs edited since
+// generation are reported but do not make the document stale.
+func VerifyManifest(path string, tmpl *templates.Template) (*Verification, error) {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	verification := &Verification{Manifest: manifest, Path: path, State: docregistry.StateFresh}
+
+	dir := filepath.Dir(path)
+	names := make([]string, 0, len(manifest.Outputs))
+	for name := range manifest.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sum, err := docregistry.HashFile(filepath.Join(dir, name))
+		switch {
+		case os.IsNotExist(err):
+			verification.Missing = append(verification.Missing, name)
+		case err != nil:
+			return nil, fmt.Errorf("failed to hash output: %w", err)
+		case sum != manifest.Outputs[name]:
+			verification.Modified = append(verification.Modified, name)
+		}
+	}
+
+	// The sources are compared as those of registry entries
+	status, err := docregistry.Check(docregistry.Entry{
+		Output:      filepath.Join(dir, manifest.Output),
+		Sources:     manifest.Sources,
+		Manifest:    manifest.Files,
+		SourcesHash: manifest.SourcesHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	verification.Added, verification.Removed, verification.Changed = status.Added, status.Removed, status.Changed
+
+	if tmpl == nil {
+		verification.TemplateUnknown = true
+	} else {
+		verification.TemplateChanged = tmpl.Digest() != manifest.TemplateDigest
+	}
+
+	switch {
+	case len(verification.Missing) > 0:
+		verification.State = docregistry.StateMissing
+	case status.State == docregistry.StateStale || verification.TemplateChanged:
+		verification.State = docregistry.StateStale
+	}
+	return verification, nil
+}
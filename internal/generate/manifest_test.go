@@ -0,0 +1,94 @@
+package generate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/docregistry"
+)
+
+func TestGenerate_Manifest(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "The ledger service owns balances."}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	opts.Model = "gpt-4o"
+	opts.Temperature = 0.2
+	seed := 7
+	opts.Seed = &seed
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, ManifestPath(opts.OutputFile), result.ManifestFile)
+
+	manifest, err := ReadManifest(result.ManifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, "eval-template", manifest.Template)
+	assert.Equal(t, "gpt-4o", manifest.Model)
+	assert.Equal(t, float32(0.2), manifest.Temperature)
+	assert.Equal(t, 7, *manifest.Seed)
+	assert.Len(t, manifest.PromptHash, 64)
+	assert.Len(t, manifest.Files, 1)
+	assert.Equal(t, "out.html", manifest.Output)
+	assert.Contains(t, manifest.Outputs, "out.html")
+	assert.Contains(t, manifest.Outputs, "out.json")
+
+	tmpl, err := orchestrator.registry.Get("eval-template")
+	require.NoError(t, err)
+	assert.Equal(t, tmpl.Digest(), manifest.TemplateDigest)
+
+	verification, err := VerifyManifest(result.ManifestFile, tmpl)
+	require.NoError(t, err)
+	assert.Equal(t, docregistry.StateFresh, verification.State)
+
+	// Editing the output is reported without making the document stale
+	require.NoError(t, os.WriteFile(opts.OutputFile, []byte("<html>edited</html>"), 0o600))
+	verification, err = VerifyManifest(result.ManifestFile, tmpl)
+	require.NoError(t, err)
+	assert.Equal(t, docregistry.StateFresh, verification.State)
+	assert.Equal(t, []string{"out.html"}, verification.Modified)
+
+	// A changed template makes it stale
+	changed := *tmpl
+	changed.Prompt = "Generate a shorter document"
+	verification, err = VerifyManifest(result.ManifestFile, &changed)
+	require.NoError(t, err)
+	assert.Equal(t, docregistry.StateStale, verification.State)
+	assert.True(t, verification.TemplateChanged)
+
+	// So do changed sources
+	require.NoError(t, os.WriteFile(opts.Sources[0], []byte("# Notes\n\nThe ledger service is retired."), 0o600))
+	verification, err = VerifyManifest(result.ManifestFile, nil)
+	require.NoError(t, err)
+	assert.Equal(t, docregistry.StateStale, verification.State)
+	assert.True(t, verification.TemplateUnknown)
+	assert.Len(t, verification.Changed, 1)
+
+	require.NoError(t, os.Remove(filepath.Join(filepath.Dir(opts.OutputFile), "out.json")))
+	verification, err = VerifyManifest(result.ManifestFile, tmpl)
+	require.NoError(t, err)
+	assert.Equal(t, docregistry.StateMissing, verification.State)
+	assert.Equal(t, []string{"out.json"}, verification.Missing)
+}
+
+func TestGenerate_ManifestIsDeterministic(t *testing.T) {
+	response := `{"body": "The ledger service owns balances."}`
+	orchestrator, opts := setupEvaluationTest(t, &promptCapturingClient{responses: []string{response, response}})
+	opts.Evaluate = false
+	opts.Force = true
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	first, err := os.ReadFile(result.ManifestFile)
+	require.NoError(t, err)
+
+	result, err = orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	second, err := os.ReadFile(result.ManifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+}
@@ -4,15 +4,22 @@ package generate
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/karolswdev/docloom/internal/agent"
 	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/chunk"
+	"github.com/karolswdev/docloom/internal/ci"
+	"github.com/karolswdev/docloom/internal/grounding"
+	"github.com/karolswdev/docloom/internal/i18n"
 	"github.com/karolswdev/docloom/internal/ingest"
 	"github.com/karolswdev/docloom/internal/prompt"
 	"github.com/karolswdev/docloom/internal/render"
@@ -33,12 +40,84 @@ type Options struct {
 	MaxRepairs   int
 	Temperature  float32
 	DryRun       bool
+	DryRunJSON   bool // Print the dry-run plan as JSON instead of text
 	Force        bool
+
+	// Store a snapshot of the ingested files next to the output
+	ArchiveSources bool
+
+	// Annotate rendered fields with their path, run ID and model
+	Provenance bool
+
+	// Output formats: html, always written, and txt, a plain-text version of the
+	// document (<name>.txt) for email and screen readers
+	Formats []string
+
+	// Variables for output filename patterns such as "{{project}}-{{date}}.html"
+	Variables map[string]string
+
+	// Optional LLM-as-judge evaluation stage
+	EvaluationModel     string  // Judge model name recorded in the run report
+	EvaluationThreshold float64 // Minimum overall score (0-10) required to pass
+	Evaluate            bool
+
+	// Groundedness checking against the ingested sources: off, warn or strict
+	Grounded string
+
+	// Per-field confidence scores (0-1) asked of the model after generation and
+	// recorded in the run report. Fields scoring below ConfidenceBadge are badged in
+	// the HTML for reviewers, and a field below MinConfidence fails the run; setting
+	// either asks for the scores.
+	Confidence      bool
+	ConfidenceBadge float64
+	MinConfidence   float64
+
+	// Handling of sources that cannot be read: fail, warn (default) or ignore
+	SourceErrors string
+
+	// Second model used for ensemble generation (see SetEnsembleClient)
+	EnsembleModel string
+
+	// Generate each top-level field, or each x-section of fields, with its own
+	// request, in x-dependsOn order
+	PerField bool
+
+	// Research agents that produced the sources, recorded in the run report
+	Agents []agent.RunnerInfo
+
+	// Optional agent analysis generating the document instead of a single request:
+	// the model calls the tools of the agent to explore its source path and answers
+	// with the document (see RunAnalysisLoop). The template and MaxRepairs of the run
+	// apply; the ensemble and per-field generation do not.
+	Analysis *AnalysisOptions
+
+	// Optional collector of the prompt, response and validation errors for a
+	// triage bundle when the run fails
+	Triage *Triage
+
+	// Save a checkpoint after every step and resume a failed run with the same
+	// inputs from it; checkpoints are kept in StateDir (default .docloom/state)
+	Resume   bool
+	StateDir string
+
+	// Optional meter of the run's token usage and cost, including repairs, quality
+	// stages and the ensemble, with an optional budget; recorded in the run report
+	Usage *ai.Meter
+
+	// Directory of per-template lessons files aggregating the failures of repairs;
+	// recurring ones are added to generation prompts. Empty disables lessons.
+	LessonsDir string
+
+	// Glossary file of organization entities (systems, teams, acronyms) whose first
+	// occurrences in the HTML link to their canonical pages; see render.LoadGlossary
+	Glossary string
 }
 
 // Orchestrator coordinates the document generation workflow.
 type Orchestrator struct {
 	aiClient      ai.Client
+	evaluator     ai.Client
+	ensemble      ai.Client
 	ingester      *ingest.Ingester
 	builder       *prompt.Builder
 	validator     *validate.Validator
@@ -47,6 +126,11 @@ type Orchestrator struct {
 	outputDir     string
 	agentRegistry *agent.Registry
 	agentExecutor *agent.Executor
+
+	streamProgress ai.ProgressFunc            // Set by SetStreamProgress
+	models         map[string]ai.Capabilities // Set by SetModelCatalog
+	retriever      *chunk.Retriever           // Set by SetRetriever
+	reviewer       Reviewer                   // Set by SetReviewer
 }
 
 // NewOrchestrator creates a new generation orchestrator.
@@ -80,98 +164,348 @@ func NewOrchestrator(aiClient ai.Client) *Orchestrator {
 	}
 }
 
+// LoadTemplates loads user templates from dir; they replace built-in templates with the
+// same name.
+func (o *Orchestrator) LoadTemplates(dir string) error {
+	if err := o.registry.LoadFromDirectory(dir); err != nil {
+		return fmt.Errorf("failed to load templates from %s: %w", dir, err)
+	}
+	return nil
+}
+
+// LoadPromptOverrides replaces the built-in generation and repair prompt templates
+// with those in dir; see prompt.Builder.LoadOverrides.
+func (o *Orchestrator) LoadPromptOverrides(dir string) error {
+	if err := o.builder.LoadOverrides(dir); err != nil {
+		return fmt.Errorf("failed to load prompt templates from %s: %w", dir, err)
+	}
+	return nil
+}
+
+// SetIngestCache enables caching of extracted source text (such as PDF text) across runs.
+func (o *Orchestrator) SetIngestCache(cache *ingest.Cache) {
+	o.ingester.SetCache(cache)
+}
+
+// SetSourceFilter restricts the files ingested below source directories to those the
+// filter keeps, for every run.
+func (o *Orchestrator) SetSourceFilter(filter *ingest.Filter) {
+	o.ingester.SetFilter(filter)
+}
+
+// SetStreamProgress streams generation and repair responses from clients that
+// support it, calling progress as content arrives, so long generations are not a
+// silent wait. Cancelling the run's context aborts a stream mid-response.
+func (o *Orchestrator) SetStreamProgress(progress ai.ProgressFunc) {
+	o.streamProgress = progress
+}
+
+// complete sends prompt to client, streaming the response when stream progress is
+// enabled and the client supports streaming. The prompt and response are recorded
+// for the run's triage bundle.
+func (o *Orchestrator) complete(ctx context.Context, client ai.Client, prompt string) (string, error) {
+	triage := triageFrom(ctx)
+	triage.recordPrompt(prompt)
+
+	var response string
+	var err error
+	if streaming, ok := client.(ai.StreamingClient); ok && o.streamProgress != nil {
+		response, err = streaming.GenerateJSONStream(ctx, prompt, o.streamProgress)
+	} else {
+		response, err = client.GenerateJSON(ctx, prompt)
+	}
+	if err == nil {
+		triage.recordResponse(response)
+	}
+	return response, err
+}
+
+// requestParams returns the model request parameters configured by the options.
+func (opts Options) requestParams() ai.RequestParams {
+	return ai.RequestParams{
+		Seed:        opts.Seed,
+		MaxRetries:  opts.MaxRetries,
+		Temperature: opts.Temperature,
+	}
+}
+
+// Output formats of a document.
+const (
+	FormatHTML = "html"
+	FormatText = "txt"
+)
+
+// writesText reports whether the run also writes a plain-text version of the document.
+func (opts Options) writesText() bool {
+	return slices.Contains(opts.Formats, FormatText)
+}
+
+// outputPaths returns the files a run writes that must not be overwritten without
+// Force. The run report is always replaced.
+func (opts Options) outputPaths() []string {
+	paths := []string{opts.OutputFile, render.SidecarPath(opts.OutputFile, ".json")}
+	if opts.writesText() {
+		paths = append(paths, render.SidecarPath(opts.OutputFile, render.TextSuffix))
+	}
+	if opts.ArchiveSources {
+		paths = append(paths, render.SidecarPath(opts.OutputFile, sourceArchiveSuffix))
+	}
+	return paths
+}
+
+// runAnalysis generates the document with the agent analysis of the options.
+func (o *Orchestrator) runAnalysis(ctx context.Context, tmpl *templates.Template, opts Options) (string, error) {
+	analysis := *opts.Analysis
+	analysis.Template = tmpl
+	analysis.MaxRepairs = opts.MaxRepairs
+	return o.RunAnalysisLoop(withTemplateSchema(ctx, tmpl), analysis)
+}
+
 // generateWithRetries attempts to generate valid JSON with retries
-func (o *Orchestrator) generateWithRetries(ctx context.Context, generationPrompt string, tmpl *templates.Template, opts Options) (string, error) {
-	var generatedJSON string
-	var lastError error
-	maxAttempts := opts.MaxRepairs + 1 // Initial attempt + repairs
+func (o *Orchestrator) generateWithRetries(ctx context.Context, client ai.Client, generationPrompt string, tmpl *templates.Template, opts Options) (string, error) {
+	ctx = withTemplateSchema(ctx, tmpl)
+	if response := checkpointFrom(ctx).takeResponse(); response != "" {
+		log.Info().Msg("Continuing from the last model response in the checkpoint")
+		return o.repairUntilValid(ctx, client, generationPrompt, response, tmpl, opts.MaxRepairs)
+	}
 
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		var currentPrompt string
+	log.Info().Msg("Calling AI model for initial generation")
+	log.Debug().Str("model", opts.Model).Float32("temperature", opts.Temperature).Msg("Model parameters")
 
-		if attempt == 1 {
-			currentPrompt = generationPrompt
-			log.Info().Msg("Calling AI model for initial generation")
-			log.Debug().Str("model", opts.Model).Float32("temperature", opts.Temperature).Msg("Model parameters")
-		} else {
-			// Build repair prompt
-			log.Info().Int("attempt", attempt).Int("max_attempts", maxAttempts).Msg("Attempting repair")
-			repairPrompt, err := o.builder.BuildRepairPrompt(generationPrompt, generatedJSON, lastError.Error(), tmpl.Schema)
-			if err != nil {
-				return "", fmt.Errorf("failed to build repair prompt: %w", err)
-			}
-			currentPrompt = repairPrompt
+	startTime := time.Now()
+	generatedJSON, err := o.complete(ctx, client, generationPrompt)
+	if err != nil {
+		return "", fmt.Errorf("AI generation failed: %w", err)
+	}
+	log.Info().Dur("duration", time.Since(startTime)).Int("response_bytes", len(generatedJSON)).Msg("Received AI response")
+	checkpointFrom(ctx).recordResponse(generatedJSON)
+
+	return o.repairUntilValid(ctx, client, generationPrompt, generatedJSON, tmpl, opts.MaxRepairs)
+}
+
+// repairUntilValid validates generated JSON against the template schema and its
+// consistency rules and, while it is invalid, asks the model to repair it using the
+// validation error, up to maxRepairs times. Near-valid documents get a targeted
+// repair prompt (see buildRepairPrompt). Values are normalized before each
+// validation. Both the direct and the agent analysis paths finish through here.
+func (o *Orchestrator) repairUntilValid(ctx context.Context, client ai.Client, originalPrompt string, generatedJSON string, tmpl *templates.Template, maxRepairs int) (string, error) {
+	ctx = withTemplateSchema(ctx, tmpl)
+	schemaStr, err := json.Marshal(tmpl.Schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	maxAttempts := maxRepairs + 1 // Initial attempt + repairs
+	targeted, lastErr := false, ""
+	var repair *RepairAttempt
+
+	for attempt := 1; ; attempt++ {
+		generatedJSON = o.normalize(generatedJSON, string(schemaStr))
+		validationErr := o.validator.Validate(generatedJSON, string(schemaStr))
+		if validationErr == nil {
+			validationErr = o.validator.CheckRules(generatedJSON, tmpl.Rules)
+		}
+		if repair != nil {
+			repair.Fixed = validationErr == nil
+			repairLogFrom(ctx).record(*repair)
+		}
+		if validationErr == nil {
+			log.Info().Msg("JSON validation successful")
+			return generatedJSON, nil
+		}
+		log.Warn().Err(validationErr).Int("attempt", attempt).Msg("JSON validation failed")
+		triageFrom(ctx).recordValidation(attempt, validationErr)
+		checkpointFrom(ctx).recordValidation(attempt, validationErr)
+		if attempt >= maxAttempts {
+			return "", fmt.Errorf("failed to generate valid JSON after %d attempts: %w", maxAttempts, validationErr)
+		}
+
+		// A targeted repair that left the same error gets the full schema next
+		log.Info().Int("attempt", attempt+1).Int("max_attempts", maxAttempts).Msg("Attempting repair")
+		retarget := !targeted || validationErr.Error() != lastErr
+		var repairPrompt string
+		repairPrompt, targeted, err = o.buildRepairPrompt(originalPrompt, generatedJSON, string(schemaStr), validationErr, tmpl, retarget)
+		if err != nil {
+			return "", err
+		}
+		lastErr = validationErr.Error()
+		repair = &RepairAttempt{
+			Attempt:  attempt,
+			Error:    validationErr.Error(),
+			Patterns: o.failurePatterns(generatedJSON, string(schemaStr), validationErr),
+			Targeted: targeted,
 		}
 
-		// Call AI model
 		startTime := time.Now()
-		generatedJSON, err := o.aiClient.GenerateJSON(ctx, currentPrompt)
+		generatedJSON, err = o.complete(ctx, client, repairPrompt)
 		if err != nil {
+			repairLogFrom(ctx).record(*repair)
 			return "", fmt.Errorf("AI generation failed: %w", err)
 		}
 		log.Info().Dur("duration", time.Since(startTime)).Int("response_bytes", len(generatedJSON)).Msg("Received AI response")
+		checkpointFrom(ctx).recordResponse(generatedJSON)
+		repair.Response = generatedJSON
+	}
+}
 
-		// Validate the generated JSON
-		schemaStr, schemaErr := json.Marshal(tmpl.Schema)
-		if schemaErr != nil {
-			return "", fmt.Errorf("failed to marshal schema: %w", schemaErr)
-		}
+// withTemplateSchema returns a context whose requests ask for documents conforming
+// to the template schema, as structured outputs where the model supports them.
+func withTemplateSchema(ctx context.Context, tmpl *templates.Template) context.Context {
+	return ai.WithResponseSchema(ctx, ai.ResponseSchema{Name: tmpl.Name, Schema: tmpl.Schema})
+}
 
-		validationErr := o.validator.Validate(generatedJSON, string(schemaStr))
-		if validationErr == nil {
-			log.Info().Msg("JSON validation successful")
-			return generatedJSON, nil
-		}
+// normalize rewrites numeric and date values the schema types into canonical form,
+// so formatting slips such as "1,250" or "March 3, 2025" do not cost a repair round.
+// JSON that cannot be normalized is returned unchanged for validation to report.
+func (o *Orchestrator) normalize(generatedJSON string, schemaStr string) string {
+	normalized, changes, err := o.validator.Normalize(generatedJSON, schemaStr)
+	if err != nil {
+		return generatedJSON
+	}
+	for _, change := range changes {
+		log.Debug().Str("field", change.Field).Interface("from", change.From).Interface("to", change.To).Msg("Normalized field value")
+	}
+	if len(changes) > 0 {
+		log.Info().Int("fields", len(changes)).Msg("Normalized numeric and date fields")
+	}
+	return normalized
+}
 
-		lastError = validationErr
-		log.Warn().Err(validationErr).Int("attempt", attempt).Msg("JSON validation failed")
+// Result describes the outcome of a generation run.
+type Result struct {
+	Fields     map[string]interface{}
+	Report     *Report
+	Template   string
+	OutputFile string
+	JSONFile   string
+	ReportFile string
+	DryRun     bool
+
+	// ManifestFile records the inputs of the outputs, for docloom verify
+	ManifestFile string
+
+	// Plan describes what a dry run would have sent to the model
+	Plan *DryRunPlan
+}
+
+// Title returns the document title from the generated fields, preferring a top-level
+// "title" field and falling back to the first nested one (in key order).
+func (r *Result) Title() string {
+	if title := findTitle(r.Fields); title != "" {
+		return title
 	}
+	return filepath.Base(r.OutputFile)
+}
 
-	return "", fmt.Errorf("failed to generate valid JSON after %d attempts: %w", maxAttempts, lastError)
+// findTitle searches the fields for a string "title" value.
+func findTitle(fields map[string]interface{}) string {
+	if title, ok := fields["title"].(string); ok && title != "" {
+		return title
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if nested, ok := fields[key].(map[string]interface{}); ok {
+			if title := findTitle(nested); title != "" {
+				return title
+			}
+		}
+	}
+	return ""
 }
 
-// handleDryRun prints dry-run information and returns
-func (o *Orchestrator) handleDryRun(opts Options, tmpl *templates.Template, generationPrompt string) error {
-	fmt.Println("\n=== DRY RUN MODE ===")
-	fmt.Printf("Template: %s\n", opts.TemplateType)
-	fmt.Printf("Sources: %v\n", opts.Sources)
-	fmt.Printf("Output: %s\n", opts.OutputFile)
-	fmt.Printf("Model: %s\n", opts.Model)
-	fmt.Printf("Estimated tokens: %d\n", o.builder.EstimateTokens(generationPrompt))
-	fmt.Println("\n=== PROMPT PREVIEW (first 1000 chars) ===")
-	if len(generationPrompt) > 1000 {
-		fmt.Println(generationPrompt[:1000] + "...")
-	} else {
-		fmt.Println(generationPrompt)
+// warnUnknownSources warns about template fields whose x-source names no source root,
+// which usually means a mistyped label.
+func warnUnknownSources(tmpl *templates.Template, roots []ingest.Root) {
+	labels := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		labels[root.Label] = true
 	}
-	fmt.Println("\n=== SCHEMA ===")
-	schemaBytes, schemaErr := json.MarshalIndent(tmpl.Schema, "", "  ")
-	if schemaErr != nil {
-		log.Warn().Err(schemaErr).Msg("Failed to marshal schema for display")
-		schemaBytes = []byte("{}")
+	for field, sources := range prompt.FieldSources(string(tmpl.Schema)) {
+		for _, label := range sources {
+			if !labels[label] {
+				log.Warn().Str("field", field).Str("source", label).Msg("Template field names a source that was not given")
+			}
+		}
 	}
-	fmt.Println(string(schemaBytes))
-	return nil
 }
 
 // Generate performs the complete document generation workflow.
 func (o *Orchestrator) Generate(ctx context.Context, opts Options) error {
+	_, err := o.Run(ctx, opts)
+	return err
+}
+
+// Run performs the complete document generation workflow and returns a Result
+// describing the generated document.
+func (o *Orchestrator) Run(ctx context.Context, opts Options) (*Result, error) {
 	// Validate options
 	if err := o.validateOptions(opts); err != nil {
-		return fmt.Errorf("invalid options: %w", err)
+		return nil, fmt.Errorf("invalid options: %w", err)
 	}
 
-	// Check if output file exists and handle force flag
-	if !opts.Force {
-		if _, err := os.Stat(opts.OutputFile); err == nil {
-			return fmt.Errorf("output file %s already exists (use --force to overwrite)", opts.OutputFile)
+	// Apply the run's sampling and retry settings to every model call, including
+	// repairs, derivations and quality stages
+	ctx = ai.WithRequestParams(ctx, opts.requestParams())
+	ctx = ai.WithMeter(ctx, opts.Usage)
+	ctx = withTriage(ctx, opts.Triage)
+	opts.Triage.recordOptions(opts)
+	repairs := &repairLog{}
+	ctx = withRepairLog(ctx, repairs)
+	log.Debug().
+		Float32("temperature", opts.Temperature).
+		Int("max_retries", opts.MaxRetries).
+		Bool("seeded", opts.Seed != nil).
+		Msg("Effective generation parameters")
+
+	// Check if output files exist and handle force flag. Patterns and directories
+	// are checked once the output name is resolved.
+	if !isOutputPattern(opts.OutputFile) && !isDirectoryTarget(opts.OutputFile) {
+		if err := render.CheckOverwrite(opts.Force, opts.outputPaths()...); err != nil {
+			return nil, err
 		}
 	}
 
 	// Get template from registry
 	tmpl, err := o.registry.Get(opts.TemplateType)
 	if err != nil {
-		return fmt.Errorf("failed to get template %s: %w", opts.TemplateType, err)
+		return nil, fmt.Errorf("failed to get template %s: %w", opts.TemplateType, err)
+	}
+	log.Info().Str("template", tmpl.Name).Str("origin", o.registry.Origin(opts.TemplateType)).Msg("Using template")
+
+	// Refuse models the template does not allow before any tokens are spent
+	if err := o.checkModelRequirements(tmpl, opts.Model, opts.EnsembleModel); err != nil {
+		return nil, err
+	}
+	// So are templates whose placeholders and schema fields do not match, instead of
+	// leaving placeholders in the document
+	if err := templates.VerifyPlaceholders(tmpl); err != nil {
+		return nil, err
+	}
+	var glossary *render.Glossary
+	if opts.Glossary != "" {
+		if glossary, err = render.LoadGlossary(opts.Glossary); err != nil {
+			return nil, err
+		}
+	}
+	opts.PerField = o.usePerField(tmpl, opts)
+
+	// Apply the template's output filename pattern and the run variables
+	opts.OutputFile = expandOutputVariables(outputTarget(opts.OutputFile, tmpl.Output), opts.outputVariables(tmpl))
+	if err := checkResolvedOutput(opts); err != nil {
+		return nil, err
+	}
+
+	// Resumable runs continue from the checkpoint of an earlier failed run
+	var checkpoint *Checkpoint
+	if opts.Resume && !opts.DryRun {
+		checkpoint, err = o.openCheckpoint(opts, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		ctx = withCheckpoint(ctx, checkpoint)
 	}
 
 	// Step 1: Ingest source documents
@@ -179,84 +513,251 @@ func (o *Orchestrator) Generate(ctx context.Context, opts Options) error {
 	log.Debug().Str("template", opts.TemplateType).Msg("Using template for generation")
 	log.Debug().Str("model", opts.Model).Msg("Selected AI model")
 	log.Debug().Int("max_repairs", opts.MaxRepairs).Msg("Maximum repair attempts configured")
-	sourceContent, err := o.ingester.IngestSources(opts.Sources)
+	ingestion, err := o.ingest(checkpoint, opts)
 	if err != nil {
-		return fmt.Errorf("failed to ingest sources: %w", err)
+		return nil, err
 	}
+	sourceContent := ingestion.Content
 	log.Info().Int("bytes", len(sourceContent)).Msg("Source ingestion complete")
-	log.Debug().Int("source_files", len(opts.Sources)).Msg("Total source files processed")
+	log.Debug().Int("source_files", len(ingestion.Files)).Int("source_errors", len(ingestion.Errors)).Msg("Total source files processed")
+	warnUnknownSources(tmpl, ingestion.Roots)
+	sourceStats := ingestion.Stats(o.tokenCounter(opts.Model).Count)
 
 	// Step 2: Build generation prompt
 	log.Info().Msg("Building generation prompt")
 	log.Debug().Str("template_prompt", tmpl.Prompt[:min(100, len(tmpl.Prompt))]).Msg("Template prompt preview")
-	generationPrompt, err := o.builder.BuildGenerationPrompt(sourceContent, tmpl.Prompt, tmpl.Schema)
+	promptSources, err := o.retrieveSources(ctx, sourceContent, tmpl, opts.DryRun)
 	if err != nil {
-		return fmt.Errorf("failed to build prompt: %w", err)
+		return nil, err
+	}
+	generationPrompt, err := o.builder.BuildGenerationPrompt(promptSources, tmpl.Prompt, tmpl.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	}
+	if opts.LessonsDir != "" {
+		generationPrompt = o.addPitfalls(generationPrompt, opts.LessonsDir, tmpl.Name)
 	}
 	log.Debug().Int("prompt_length", len(generationPrompt)).Msg("Generation prompt built")
+	opts.Triage.recordPrompt(generationPrompt)
+	if err := checkpoint.recordPrompt(generationPrompt); err != nil {
+		return nil, err
+	}
+	o.checkContextWindow(generationPrompt)
 
 	if opts.DryRun {
-		return o.handleDryRun(opts, tmpl, generationPrompt)
+		plan, err := o.handleDryRun(opts, tmpl, generationPrompt, sourceStats)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Template: tmpl.Name, OutputFile: opts.OutputFile, DryRun: true, Plan: plan}, nil
+	}
+
+	// Step 3: Generate with validation and repair loop, followed by the optional
+	// derivation stage (e.g., executive summaries based on the generated document)
+	var generatedJSON string
+	var ensemble *EnsembleResult
+	if checkpoint.reached(StepGenerated) {
+		log.Info().Msg("Using the generated document from the checkpoint")
+		generatedJSON = checkpoint.GeneratedJSON
+	} else {
+		if opts.Analysis != nil {
+			generatedJSON, err = o.runAnalysis(ctx, tmpl, opts)
+		} else {
+			generatedJSON, ensemble, err = o.generateDocument(ctx, generationPrompt, tmpl, opts)
+		}
+		if opts.LessonsDir != "" {
+			recordLessons(opts.LessonsDir, tmpl.Name, repairs.list())
+		}
+		if err != nil {
+			return nil, err
+		}
+		generatedJSON, err = o.deriveFields(ctx, generatedJSON, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkpoint.recordGenerated(generatedJSON); err != nil {
+			return nil, err
+		}
 	}
 
-	// Step 3: Generate with validation and repair loop
-	generatedJSON, err := o.generateWithRetries(ctx, generationPrompt, tmpl, opts)
+	// Optional review of the document by a person before it is written
+	var review *ReviewSummary
+	if o.reviewer != nil {
+		generatedJSON, review, err = o.reviewDocument(ctx, generatedJSON, generationPrompt, tmpl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts.OutputFile, err = resolveOutputFields(opts, generatedJSON)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	jsonFile := render.SidecarPath(opts.OutputFile, ".json")
 
-	// Step 4: Save JSON sidecar file
-	jsonFile := strings.TrimSuffix(opts.OutputFile, ".html") + ".json"
-	if err := os.WriteFile(jsonFile, []byte(generatedJSON), 0600); err != nil {
-		return fmt.Errorf("failed to write JSON file: %w", err)
+	// Optional quality stages (LLM-as-judge evaluation, groundedness checking)
+	report := &Report{
+		RunID:        newRunID(),
+		Ensemble:     ensemble,
+		CI:           ci.Detect(),
+		Template:     tmpl.Name,
+		Model:        opts.Model,
+		OutputFile:   opts.OutputFile,
+		Sources:      opts.Sources,
+		Agents:       opts.Agents,
+		SourceFiles:  ingestion.Files,
+		SourceErrors: ingestion.Errors,
+		SourceStats:  sourceStats,
+		Repairs:      repairs.list(),
+		Review:       review,
+	}
+	if checkpoint != nil {
+		report.RunID = checkpoint.RunID
+	}
+	if err := o.runQualityStages(ctx, sourceContent, generatedJSON, opts, report); err != nil {
+		return nil, err
 	}
-	log.Info().Str("file", jsonFile).Msg("Saved JSON sidecar file")
 
-	// Step 5: Render HTML output
+	// Step 4: Render HTML output and its JSON sidecar
 	log.Info().Msg("Rendering HTML output")
 	log.Debug().Msg("Parsing generated JSON for rendering")
 
 	// Parse the JSON into a map for rendering
 	var fields map[string]interface{}
 	if err := json.Unmarshal([]byte(generatedJSON), &fields); err != nil {
-		return fmt.Errorf("failed to parse generated JSON: %w", err)
+		return nil, fmt.Errorf("failed to parse generated JSON: %w", err)
 	}
 	log.Debug().Int("field_count", len(fields)).Msg("Parsed JSON fields")
 
-	// Use the renderer to render and save both HTML and JSON
+	// The renderer writes both the HTML and the JSON sidecar
 	log.Debug().Str("output_file", opts.OutputFile).Msg("Writing rendered HTML")
-	if err := o.renderer.Render(tmpl.HTMLContent, fields, opts.OutputFile); err != nil {
-		return fmt.Errorf("failed to render output: %w", err)
+	if err := o.renderer.RenderWithOptions(tmpl.HTMLContent, fields, opts.OutputFile, render.Options{
+		Provenance: provenanceFor(opts, report),
+		Transforms: tmpl.Transforms,
+		Assets:     tmpl.Assets,
+		Text:       opts.writesText(),
+
+		LowConfidence: report.Confidence.lowConfidence(),
+		Glossary:      glossary,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render output: %w", err)
+	}
+	log.Info().Str("file", jsonFile).Msg("Saved JSON sidecar file")
+	if opts.writesText() {
+		report.TextFile = render.SidecarPath(opts.OutputFile, render.TextSuffix)
+	}
+
+	// Step 5: Snapshot the sources and save the run report
+	if opts.ArchiveSources {
+		archive, err := o.archiveSources(opts.Sources, ingest.ErrorPolicy(opts.SourceErrors), opts.OutputFile)
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("file", archive.Path).Int("files", len(archive.Files)).Msg("Archived source snapshot")
+		report.SourceArchive = archive
+	}
+	report.GeneratedAt = time.Now().UTC()
+	report.JSONFile = jsonFile
+	report.Usage = opts.Usage.Summary()
+	reportFile := reportPath(opts.OutputFile)
+	if err := writeReport(reportFile, report); err != nil {
+		return nil, err
+	}
+
+	// Step 6: Record the inputs of the outputs for docloom verify
+	outputs := []string{opts.OutputFile, jsonFile}
+	if report.TextFile != "" {
+		outputs = append(outputs, report.TextFile)
+	}
+	manifest, err := newManifest(opts, tmpl, generationPrompt, outputs)
+	if err != nil {
+		return nil, err
+	}
+	manifestFile := ManifestPath(opts.OutputFile)
+	if err := writeManifest(manifestFile, manifest); err != nil {
+		return nil, err
 	}
 
 	log.Info().
 		Str("html_file", opts.OutputFile).
 		Str("json_file", jsonFile).
+		Str("report_file", reportFile).
+		Str("manifest_file", manifestFile).
 		Msg("Document generation complete")
 	log.Debug().Msg("Generation workflow completed successfully")
+	checkpoint.remove()
 
-	return nil
+	result := &Result{
+		Fields:     fields,
+		Report:     report,
+		Template:   tmpl.Name,
+		OutputFile: opts.OutputFile,
+		JSONFile:   jsonFile,
+		ReportFile: reportFile,
+
+		ManifestFile: manifestFile,
+	}
+
+	// Quality gates: outputs are kept for inspection but the run fails
+	if err := report.gateError(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ingest reads the sources, or takes the ingested content from a checkpoint that got
+// past ingestion, and saves it to the checkpoint.
+func (o *Orchestrator) ingest(checkpoint *Checkpoint, opts Options) (*ingest.Result, error) {
+	if checkpoint.reached(StepIngested) {
+		log.Info().Int("files", len(checkpoint.Files)).Msg("Using the ingested sources from the checkpoint")
+		return checkpoint.ingestion(), nil
+	}
+	ingestion, err := o.ingester.Ingest(opts.Sources, ingest.ErrorPolicy(opts.SourceErrors))
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest sources: %w", err)
+	}
+	if err := checkpoint.recordIngestion(ingestion); err != nil {
+		return nil, err
+	}
+	return ingestion, nil
 }
 
 // validateOptions checks that all required options are provided.
 func (o *Orchestrator) validateOptions(opts Options) error {
 	if opts.TemplateType == "" {
-		return fmt.Errorf("template type is required")
+		return errors.New(i18n.T("error.template_required"))
 	}
 	if len(opts.Sources) == 0 {
-		return fmt.Errorf("at least one source is required")
+		return errors.New(i18n.T("error.source_required"))
 	}
 	if opts.OutputFile == "" {
-		return fmt.Errorf("output file is required")
+		return errors.New(i18n.T("error.output_required"))
 	}
 	if !opts.DryRun && opts.APIKey == "" {
 		// Check environment variable
 		if os.Getenv("OPENAI_API_KEY") == "" {
-			return fmt.Errorf("API key is required (use --api-key or OPENAI_API_KEY env var)")
+			return errors.New(i18n.T("error.api_key_required"))
 		}
 	}
 	if opts.MaxRepairs < 0 {
 		return fmt.Errorf("max repairs must be non-negative")
 	}
+	if opts.EvaluationThreshold < 0 || opts.EvaluationThreshold > 10 {
+		return fmt.Errorf("evaluation threshold must be between 0 and 10")
+	}
+	if err := grounding.ValidateMode(opts.Grounded); err != nil {
+		return err
+	}
+	if opts.ConfidenceBadge < 0 || opts.ConfidenceBadge > 1 || opts.MinConfidence < 0 || opts.MinConfidence > 1 {
+		return fmt.Errorf("confidence thresholds must be between 0 and 1")
+	}
+	if _, err := ingest.ParseErrorPolicy(opts.SourceErrors); err != nil {
+		return err
+	}
+	for _, format := range opts.Formats {
+		if format != FormatHTML && format != FormatText {
+			return fmt.Errorf("unknown output format %q (use %s or %s)", format, FormatHTML, FormatText)
+		}
+	}
 	return nil
 }
@@ -13,7 +13,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/karolswdev/docloom/internal/ai"
 	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/tokens"
 )
 
 // MockAIClient is a mock implementation of the AI client for testing.
@@ -126,6 +128,74 @@ func TestGenerationFlow_RepairLoop(t *testing.T) {
 	assert.Equal(t, 2, mockClient.callCount)
 }
 
+func TestOrchestrator_Run_TextFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "test.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Test Document"), 0644))
+
+	orchestrator := NewOrchestrator(&MockAIClient{responses: []string{`{"title": "Test Title"}`}})
+	require.NoError(t, orchestrator.registry.Register("text-template", &templates.Template{
+		Name:        "text-template",
+		Schema:      json.RawMessage(`{"type": "object", "properties": {"title": {"type": "string"}}, "required": ["title"]}`),
+		Prompt:      "Generate a title",
+		HTMLContent: `<html><body><h1><!-- data-field="title" --></h1></body></html>`,
+	}))
+
+	outputFile := filepath.Join(tempDir, "output.html")
+	result, err := orchestrator.Run(context.Background(), Options{
+		TemplateType: "text-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   outputFile,
+		APIKey:       "test-key",
+		Formats:      []string{FormatHTML, FormatText},
+	})
+	require.NoError(t, err)
+
+	textFile := filepath.Join(tempDir, "output.txt")
+	assert.Equal(t, textFile, result.Report.TextFile)
+	text, err := os.ReadFile(textFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Test Title\n==========\n", string(text))
+	assert.FileExists(t, outputFile)
+}
+
+func TestOrchestrator_Run_Glossary(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "test.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Test Document"), 0644))
+	glossaryFile := filepath.Join(tempDir, "glossary.yaml")
+	require.NoError(t, os.WriteFile(glossaryFile, []byte("entries:\n  - name: Ledger\n    url: https://wiki.example.com/ledger\n"), 0644))
+
+	client := &MockAIClient{responses: []string{`{"summary": "The Ledger settles payments."}`}}
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("glossary-template", &templates.Template{
+		Name:        "glossary-template",
+		Schema:      json.RawMessage(`{"type": "object", "properties": {"summary": {"type": "string"}}, "required": ["summary"]}`),
+		Prompt:      "Summarize",
+		HTMLContent: `<html><body><p><!-- data-field="summary" --></p></body></html>`,
+	}))
+	opts := Options{
+		TemplateType: "glossary-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "output.html"),
+		APIKey:       "test-key",
+		Glossary:     glossaryFile,
+	}
+
+	_, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	content, err := os.ReadFile(opts.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `The <a class="docloom-entity" href="https://wiki.example.com/ledger">Ledger</a> settles`)
+
+	// An unreadable glossary fails the run before the model is called
+	opts.Glossary = filepath.Join(tempDir, "missing.yaml")
+	opts.Force = true
+	_, err = orchestrator.Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "failed to read glossary")
+	assert.Equal(t, 1, client.callCount)
+}
+
 // TestConfig_SecretRedactionInLogs tests that API keys are redacted in logs.
 func TestConfig_SecretRedactionInLogs(t *testing.T) {
 	// Arrange: Set up a custom logger that captures output
@@ -232,6 +302,17 @@ func TestOrchestrator_ValidateOptions(t *testing.T) {
 			},
 			expectError: "",
 		},
+		{
+			name: "unknown output format",
+			opts: Options{
+				TemplateType: "test",
+				Sources:      []string{"test.md"},
+				OutputFile:   "output.html",
+				APIKey:       "test-key",
+				Formats:      []string{"html", "pdf"},
+			},
+			expectError: `unknown output format "pdf"`,
+		},
 		{
 			name: "dry run without API key is allowed",
 			opts: Options{
@@ -371,7 +452,7 @@ func TestOrchestrator_Generate_DryRun(t *testing.T) {
 		Description: "Template for dry-run testing",
 		Schema:      json.RawMessage(schemaBytes),
 		Prompt:      "Test prompt",
-		HTMLContent: `<!DOCTYPE html><html><body>Test</body></html>`,
+		HTMLContent: `<!DOCTYPE html><html><body><!-- data-field="title" --></body></html>`,
 	}
 
 	orchestrator := NewOrchestrator(nil) // No AI client needed for dry-run
@@ -397,6 +478,53 @@ func TestOrchestrator_Generate_DryRun(t *testing.T) {
 	assert.NoFileExists(t, filepath.Join(tempDir, "output.json"))
 }
 
+// TestOrchestrator_Run_DryRunJSON tests the machine-readable dry-run plan.
+func TestOrchestrator_Run_DryRunJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "docs")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	sourceFile := filepath.Join(sourceDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes"), 0644))
+
+	orchestrator := NewOrchestrator(nil)
+	require.NoError(t, orchestrator.registry.Register("dry-test", &templates.Template{
+		Name:        "dry-test",
+		Schema:      json.RawMessage(`{"type":"object","required":["title"]}`),
+		Prompt:      "Test prompt",
+		HTMLContent: `<html></html>`,
+	}))
+
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+
+	result, runErr := orchestrator.Run(context.Background(), Options{
+		TemplateType: "dry-test",
+		Sources:      []string{sourceDir},
+		OutputFile:   filepath.Join(tempDir, "output.html"),
+		Model:        "gpt-4",
+		DryRun:       true,
+		DryRunJSON:   true,
+	})
+	w.Close()
+	os.Stdout = oldStdout
+	require.NoError(t, runErr)
+
+	// Stdout holds exactly the plan
+	var plan DryRunPlan
+	require.NoError(t, json.NewDecoder(r).Decode(&plan))
+	assert.Equal(t, "dry-test", plan.Template)
+	assert.Equal(t, []string{sourceDir}, plan.Sources)
+	assert.Equal(t, []string{sourceFile}, plan.ResolvedSources)
+	assert.Contains(t, plan.Prompt, "Test prompt")
+	assert.Contains(t, plan.Prompt, "# Notes", "the full prompt includes the sources")
+	assert.Equal(t, tokens.ForModel("gpt-4").Count(plan.Prompt), plan.EstimatedTokens, "counted with the model's tokenizer")
+	assert.JSONEq(t, `{"type":"object","required":["title"]}`, string(plan.Schema))
+	require.NotNil(t, result.Plan)
+	assert.Equal(t, plan.Prompt, result.Plan.Prompt)
+}
+
 // TestOrchestrator_Generate_ForceOverwrite tests the force flag.
 func TestOrchestrator_Generate_ForceOverwrite(t *testing.T) {
 	tempDir := t.TempDir()
@@ -425,3 +553,56 @@ func TestOrchestrator_Generate_ForceOverwrite(t *testing.T) {
 	opts.Force = true
 	// This would proceed if we had a valid setup
 }
+
+// TestResult_Title tests document title resolution for run results.
+func TestResult_Title(t *testing.T) {
+	nested := &Result{
+		OutputFile: "out/doc.html",
+		Fields: map[string]interface{}{
+			"document": map[string]interface{}{"title": "Nested Title"},
+		},
+	}
+	assert.Equal(t, "Nested Title", nested.Title())
+
+	topLevel := &Result{Fields: map[string]interface{}{"title": "Top", "document": map[string]interface{}{"title": "Nested"}}}
+	assert.Equal(t, "Top", topLevel.Title())
+
+	untitled := &Result{OutputFile: "out/doc.html", Fields: map[string]interface{}{}}
+	assert.Equal(t, "doc.html", untitled.Title())
+}
+
+// streamingMockClient records whether responses were requested as a stream.
+type streamingMockClient struct {
+	MockAIClient
+	streamed int
+}
+
+func (m *streamingMockClient) GenerateJSONStream(ctx context.Context, prompt string, progress ai.ProgressFunc) (string, error) {
+	m.streamed++
+	progress(ai.StreamProgress{Tokens: 1, Bytes: 2, Done: true})
+	return m.GenerateJSON(ctx, prompt)
+}
+
+// TestOrchestrator_Complete_Streams tests that responses are streamed only when progress is enabled.
+func TestOrchestrator_Complete_Streams(t *testing.T) {
+	client := &streamingMockClient{MockAIClient: MockAIClient{responses: []string{`{}`, `{}`}}}
+	orchestrator := NewOrchestrator(client)
+
+	_, err := orchestrator.complete(context.Background(), client, "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, 0, client.streamed)
+
+	var updates []ai.StreamProgress
+	orchestrator.SetStreamProgress(func(p ai.StreamProgress) { updates = append(updates, p) })
+	_, err = orchestrator.complete(context.Background(), client, "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.streamed)
+	require.Len(t, updates, 1)
+	assert.True(t, updates[0].Done)
+
+	// Clients that cannot stream fall back to a regular request
+	plain := &MockAIClient{responses: []string{`{}`}}
+	_, err = orchestrator.complete(context.Background(), plain, "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, plain.callCount)
+}
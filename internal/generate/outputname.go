@@ -0,0 +1,151 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// outputVariablePattern matches a variable in an output filename pattern, e.g. {{project}}.
+var outputVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// unsafeNameChars matches characters replaced in substituted values so that a value
+// cannot introduce directories or characters that are awkward in filenames.
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// isOutputPattern reports whether an output path still contains variables.
+func isOutputPattern(path string) bool {
+	return outputVariablePattern.MatchString(path)
+}
+
+// isDirectoryTarget reports whether out names a directory: an existing one, or a path
+// ending with a separator.
+func isDirectoryTarget(out string) bool {
+	if strings.HasSuffix(out, "/") || strings.HasSuffix(out, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(out)
+	return err == nil && info.IsDir()
+}
+
+// outputTarget returns the output path of a run. When out is a directory and the
+// template defines an output pattern, the pattern names the file inside it;
+// otherwise out is used as given.
+func outputTarget(out, templatePattern string) string {
+	if templatePattern != "" && isDirectoryTarget(out) {
+		return filepath.Join(out, templatePattern)
+	}
+	return out
+}
+
+// checkResolvedOutput checks the files of a resolved output path for overwrites.
+// Paths that still name generated fields are checked by resolveOutputFields.
+func checkResolvedOutput(opts Options) error {
+	if isOutputPattern(opts.OutputFile) {
+		return nil
+	}
+	if isDirectoryTarget(opts.OutputFile) {
+		return fmt.Errorf("output %s is a directory and template %s defines no output pattern", opts.OutputFile, opts.TemplateType)
+	}
+	return render.CheckOverwrite(opts.Force, opts.outputPaths()...)
+}
+
+// outputVariables returns the variables available to output patterns: the built-in
+// template, model and date (YYYY-MM-DD), overridden by the run's variables.
+func (opts Options) outputVariables(tmpl *templates.Template) map[string]string {
+	vars := map[string]string{
+		"template": tmpl.Name,
+		"model":    opts.Model,
+		"date":     time.Now().Format("2006-01-02"),
+	}
+	for name, value := range opts.Variables {
+		vars[name] = value
+	}
+	return vars
+}
+
+// expandOutputPattern substitutes the variables lookup knows and returns the names of
+// those it does not, which are left in place.
+func expandOutputPattern(pattern string, lookup func(name string) (string, bool)) (string, []string) {
+	var missing []string
+	expanded := outputVariablePattern.ReplaceAllStringFunc(pattern, func(match string) string {
+		name := outputVariablePattern.FindStringSubmatch(match)[1]
+		value, ok := lookup(name)
+		if ok {
+			value = strings.Trim(unsafeNameChars.ReplaceAllString(value, "-"), "-")
+		}
+		if !ok || value == "" {
+			missing = append(missing, name)
+			return match
+		}
+		return value
+	})
+	return expanded, missing
+}
+
+// expandOutputVariables substitutes the run variables in an output path. Variables
+// naming generated fields are left for resolveOutputFields.
+func expandOutputVariables(path string, vars map[string]string) string {
+	expanded, _ := expandOutputPattern(path, func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+	return expanded
+}
+
+// resolveOutputFields substitutes generated field values (dot-separated paths such as
+// {{document.title}}) in an output path and checks the resulting files for overwrites.
+// Paths without variables are returned unchanged.
+func resolveOutputFields(opts Options, generatedJSON string) (string, error) {
+	if !isOutputPattern(opts.OutputFile) {
+		return opts.OutputFile, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(generatedJSON), &fields); err != nil {
+		return "", fmt.Errorf("failed to parse generated JSON for output name: %w", err)
+	}
+	resolved, missing := expandOutputPattern(opts.OutputFile, func(name string) (string, bool) {
+		return fieldString(fields, name)
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("output pattern %s: unknown or empty variable(s) %s", opts.OutputFile, strings.Join(missing, ", "))
+	}
+
+	opts.OutputFile = resolved
+	if err := render.CheckOverwrite(opts.Force, opts.outputPaths()...); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// fieldString returns the scalar value at a dot-separated field path as a string.
+func fieldString(fields map[string]interface{}, path string) (string, bool) {
+	var value interface{} = fields
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if value, ok = object[key]; !ok {
+			return "", false
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,101 @@
+package generate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandOutputPattern(t *testing.T) {
+	vars := map[string]string{"project": "Payments API", "template": "architecture-vision", "path": "../etc"}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+
+	expanded, missing := expandOutputPattern("out/{{project}}-{{ template }}.html", lookup)
+	assert.Equal(t, "out/Payments-API-architecture-vision.html", expanded)
+	assert.Empty(t, missing)
+
+	// Values cannot introduce directories
+	expanded, _ = expandOutputPattern("{{path}}.html", lookup)
+	assert.Equal(t, "..-etc.html", expanded)
+
+	// Unknown variables are left in place and reported
+	expanded, missing = expandOutputPattern("{{project}}-{{document.title}}.html", lookup)
+	assert.Equal(t, "Payments-API-{{document.title}}.html", expanded)
+	assert.Equal(t, []string{"document.title"}, missing)
+}
+
+func TestFieldString(t *testing.T) {
+	fields := map[string]interface{}{
+		"document": map[string]interface{}{"title": "Ledger", "version": float64(2)},
+		"items":    []interface{}{"a"},
+	}
+	value, ok := fieldString(fields, "document.title")
+	assert.True(t, ok)
+	assert.Equal(t, "Ledger", value)
+	value, ok = fieldString(fields, "document.version")
+	assert.True(t, ok)
+	assert.Equal(t, "2", value)
+
+	_, ok = fieldString(fields, "items")
+	assert.False(t, ok, "only scalar fields can name outputs")
+	_, ok = fieldString(fields, "document.missing")
+	assert.False(t, ok)
+}
+
+func TestOutputTarget(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, filepath.Join(dir, "{{template}}.html"), outputTarget(dir, "{{template}}.html"))
+	assert.Equal(t, filepath.Join("out", "{{template}}.html"), outputTarget("out/", "{{template}}.html"))
+	assert.Equal(t, "out/doc.html", outputTarget("out/doc.html", "{{template}}.html"))
+	assert.Equal(t, dir, outputTarget(dir, ""))
+}
+
+func TestGenerate_OutputPattern(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "Ledger overview"}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	outDir := t.TempDir()
+	opts.OutputFile = filepath.Join(outDir, "{{project}}-{{template}}-{{body}}-{{date}}.html")
+	opts.Variables = map[string]string{"project": "payments"}
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	expected := filepath.Join(outDir, "payments-eval-template-Ledger-overview-"+time.Now().Format("2006-01-02")+".html")
+	assert.Equal(t, expected, result.OutputFile)
+	assert.FileExists(t, expected)
+	assert.FileExists(t, result.JSONFile)
+	assert.Equal(t, expected, result.Report.OutputFile)
+}
+
+func TestGenerate_TemplateOutputPattern(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "Ledger"}`, `{"body": "Ledger"}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	tmpl, err := orchestrator.registry.Get("eval-template")
+	require.NoError(t, err)
+	tmpl.Output = "{{template}}-{{missing}}.html"
+
+	// An output directory takes the template's pattern
+	opts.OutputFile = t.TempDir()
+	_, err = orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown or empty variable(s) missing")
+
+	tmpl.Output = "{{template}}.html"
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(opts.OutputFile, "eval-template.html"), result.OutputFile)
+
+	// Directories without a pattern are rejected
+	tmpl.Output = ""
+	_, err = orchestrator.Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "is a directory")
+}
@@ -0,0 +1,74 @@
+package generate
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// outputLimit returns the most tokens a response of model can have: the smaller of
+// the completion tokens the AI client requests and the model's maximum output, or 0
+// when neither is known.
+func (o *Orchestrator) outputLimit(model string) int {
+	limit := 0
+	if client, ok := o.aiClient.(*ai.OpenAIClient); ok {
+		limit = client.MaxTokens()
+	}
+	if caps, ok := o.capabilitiesOf(model); ok && caps.MaxOutputTokens > 0 && (limit == 0 || caps.MaxOutputTokens < limit) {
+		limit = caps.MaxOutputTokens
+	}
+	return limit
+}
+
+// usePerField reports whether to generate the document field by field: when the
+// options ask for it, or when the template's schema predicts a response too large
+// for the model's output limit, which would be truncated. Requests of per-field
+// generation that are still likely too large are logged as warnings.
+func (o *Orchestrator) usePerField(tmpl *templates.Template, opts Options) bool {
+	limit := o.outputLimit(opts.Model)
+	if limit == 0 {
+		return opts.PerField
+	}
+	estimates, err := templates.EstimateOutputTokens(tmpl.Schema)
+	if err != nil {
+		return opts.PerField
+	}
+	groups, err := templates.FieldGroups(tmpl.Schema)
+	if err != nil {
+		return opts.PerField
+	}
+
+	total, largest := 2, 0 // Braces of the document
+	var oversized []string
+	for _, group := range groups {
+		tokens := 0
+		for _, field := range group.Fields {
+			tokens += estimates[field]
+		}
+		total += tokens
+		largest = max(largest, tokens)
+		if tokens > limit {
+			oversized = append(oversized, groupName(group))
+		}
+	}
+	if total <= limit {
+		return opts.PerField
+	}
+
+	if !opts.PerField {
+		log.Warn().
+			Str("template", tmpl.Name).
+			Int("estimated_output_tokens", total).
+			Int("max_output_tokens", limit).
+			Msg("The document likely exceeds the model's output limit in one response; generating it field by field")
+	}
+	if len(oversized) > 0 {
+		log.Warn().
+			Strs("requests", oversized).
+			Int("estimated_output_tokens", largest).
+			Int("max_output_tokens", limit).
+			Msg("Some fields or sections likely exceed the model's output limit and may be truncated; bound them with maxLength and maxItems or split sections")
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// TestGenerate_OutputLimitSplitsGeneration tests that a template predicted to exceed the
+// model's output limit in one response is generated field by field.
+func TestGenerate_OutputLimitSplitsGeneration(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:   "large-template",
+		Prompt: "Generate the document",
+		Schema: json.RawMessage(`{"type":"object","properties":{
+			"overview":{"type":"string","maxLength":400},
+			"components":{"type":"array","items":{"type":"string","maxLength":200},"maxItems":2}
+		},"required":["overview","components"]}`),
+		HTMLContent: `<html><body><!-- data-field="overview" --><!-- data-field="components" --></body></html>`,
+	}
+	client := &promptCapturingClient{
+		responses: []string{
+			`{"value": "The ledger owns balances."}`,
+			`{"value": ["Ledger service"]}`,
+		},
+	}
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("large-template", tmpl))
+	orchestrator.SetModelCatalog(map[string]ai.Capabilities{"small-output": {MaxOutputTokens: 150}})
+
+	result, err := orchestrator.Run(context.Background(), Options{
+		TemplateType: "large-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "out.html"),
+		Model:        "small-output",
+		APIKey:       "test-key",
+		MaxRepairs:   1,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 2)
+	assert.Contains(t, client.prompts[0], "Generate only the field 'overview'")
+	assert.Contains(t, client.prompts[1], "Generate only the field 'components'")
+	assert.Equal(t, []interface{}{"Ledger service"}, result.Fields["components"])
+}
+
+func TestUsePerField(t *testing.T) {
+	tmpl := &templates.Template{Schema: json.RawMessage(`{"properties":{"body":{"type":"string","maxLength":400}}}`)}
+	orchestrator := NewOrchestrator(&promptCapturingClient{})
+	orchestrator.SetModelCatalog(map[string]ai.Capabilities{"small-output": {MaxOutputTokens: 50}, "large-output": {MaxOutputTokens: 4096}})
+
+	assert.True(t, orchestrator.usePerField(tmpl, Options{Model: "small-output"}))
+	assert.False(t, orchestrator.usePerField(tmpl, Options{Model: "large-output"}))
+	assert.False(t, orchestrator.usePerField(tmpl, Options{Model: "unknown-model"}), "unknown limits do not split generation")
+	assert.True(t, orchestrator.usePerField(tmpl, Options{Model: "unknown-model", PerField: true}))
+}
@@ -0,0 +1,175 @@
+package generate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/i18n"
+	"github.com/karolswdev/docloom/internal/ingest"
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+// PackFormat identifies the layout of context packs; see docs/guides/context-packs.md.
+const PackFormat = "docloom-context-pack/v1"
+
+// PackOptions configures a context pack export.
+type PackOptions struct {
+	TemplateType string
+	Sources      []string
+	OutputFile   string
+	SourceErrors string // Handling of sources that cannot be read: fail, warn (default) or ignore
+	Force        bool   // Overwrite an existing pack
+}
+
+// PackManifest is the manifest.json of a context pack.
+type PackManifest struct {
+	CreatedAt       time.Time            `json:"created_at"`
+	Format          string               `json:"format"`
+	Template        string               `json:"template"`
+	TemplateVersion string               `json:"template_version,omitempty"`
+	Sources         []string             `json:"sources"`
+	Files           []ArchivedSource     `json:"files"` // Extracted text of each ingested file
+	SourceErrors    []ingest.SourceError `json:"source_errors,omitempty"`
+	EstimatedTokens int                  `json:"estimated_tokens"`
+}
+
+// packReadme explains a context pack to whoever unpacks it.
+const packReadme = `# DocLoom context pack
+
+This archive holds the exact context DocLoom would send to a model to generate a
+%s document, for drafting the document with other tools.
+
+- prompt.md: the complete generation prompt, including the sources. Paste it as is.
+- instructions.md: the template's instructions on their own.
+- schema.json: the JSON Schema the document's fields must follow.
+- sources/: the text of every ingested file, as extracted by DocLoom, for tools
+  that take files separately (for example as project knowledge).
+- manifest.json: the template, sources, file hashes and estimated prompt tokens.
+`
+
+// Pack assembles the context of a generation run, the prompt, the template schema and
+// instructions and the ingested source text, without calling a model, and writes it
+// to a gzip-compressed tar archive.
+func (o *Orchestrator) Pack(opts PackOptions) (*PackManifest, error) {
+	if opts.TemplateType == "" {
+		return nil, errors.New(i18n.T("error.template_required"))
+	}
+	if len(opts.Sources) == 0 {
+		return nil, errors.New(i18n.T("error.source_required"))
+	}
+	if opts.OutputFile == "" {
+		return nil, errors.New(i18n.T("error.output_required"))
+	}
+	if _, err := ingest.ParseErrorPolicy(opts.SourceErrors); err != nil {
+		return nil, err
+	}
+	if !opts.Force {
+		if _, err := os.Stat(opts.OutputFile); err == nil {
+			return nil, errors.New(i18n.T("error.output_exists", opts.OutputFile))
+		}
+	}
+
+	tmpl, err := o.registry.Get(opts.TemplateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template %s: %w", opts.TemplateType, err)
+	}
+	policy := ingest.ErrorPolicy(opts.SourceErrors)
+	ingestion, err := o.ingester.Ingest(opts.Sources, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest sources: %w", err)
+	}
+	generationPrompt, err := o.builder.BuildGenerationPrompt(ingestion.Content, tmpl.Prompt, tmpl.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	}
+
+	manifest := &PackManifest{
+		CreatedAt:       time.Now().UTC(),
+		Format:          PackFormat,
+		Template:        tmpl.Name,
+		TemplateVersion: tmpl.Version,
+		Sources:         opts.Sources,
+		SourceErrors:    ingestion.Errors,
+		EstimatedTokens: o.tokenCounter("").Count(generationPrompt),
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range ingestion.Files {
+		text, err := o.ingester.ReadText(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source %s for packing: %w", file, err)
+		}
+		sum := sha256.Sum256([]byte(text))
+		entry := ArchivedSource{
+			Path:   file,
+			Entry:  packEntryName(file),
+			SHA256: hex.EncodeToString(sum[:]),
+			Size:   int64(len(text)),
+		}
+		if err := writeTarFile(tw, entry.Entry, []byte(text), manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pack manifest: %w", err)
+	}
+	var schema bytes.Buffer
+	if err := json.Indent(&schema, tmpl.Schema, "", "  "); err != nil {
+		return nil, fmt.Errorf("template %s: invalid schema: %w", tmpl.Name, err)
+	}
+	for _, file := range []struct {
+		name    string
+		content []byte
+	}{
+		{"README.md", []byte(fmt.Sprintf(packReadme, tmpl.Name))},
+		{"manifest.json", manifestJSON},
+		{"prompt.md", []byte(generationPrompt)},
+		{"instructions.md", []byte(tmpl.Prompt)},
+		{"schema.json", schema.Bytes()},
+	} {
+		if err := writeTarFile(tw, file.name, file.content, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish context pack: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress context pack: %w", err)
+	}
+
+	if err := render.WriteFileAtomic(opts.OutputFile, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write context pack: %w", err)
+	}
+	log.Info().Str("file", opts.OutputFile).Int("files", len(manifest.Files)).Int("estimated_tokens", manifest.EstimatedTokens).Msg("Context pack written")
+	return manifest, nil
+}
+
+// packEntryName returns the pack path of a source file's text. Text extracted from
+// other formats, such as PDF, gets a .txt extension.
+func packEntryName(file string) string {
+	name := archiveEntryName(file)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".txt":
+		return name
+	default:
+		return name + ".txt"
+	}
+}
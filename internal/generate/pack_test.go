@@ -0,0 +1,61 @@
+package generate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+func TestPack(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger service owns balances."), 0644))
+
+	orchestrator := NewOrchestrator(nil)
+	require.NoError(t, orchestrator.registry.Register("pack-template", &templates.Template{
+		Name:    "pack-template",
+		Version: "1.2.0",
+		Prompt:  "Describe the ledger",
+		Schema:  json.RawMessage(`{"type":"object","properties":{"body":{"type":"string"}}}`),
+	}))
+
+	out := filepath.Join(tempDir, "context.tar.gz")
+	opts := PackOptions{TemplateType: "pack-template", Sources: []string{sourceFile}, OutputFile: out}
+	manifest, err := orchestrator.Pack(opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, PackFormat, manifest.Format)
+	assert.Equal(t, "1.2.0", manifest.TemplateVersion)
+	require.Len(t, manifest.Files, 1)
+	assert.Positive(t, manifest.EstimatedTokens)
+
+	entries := readArchive(t, out)
+	assert.Equal(t, "# Notes\n\nThe ledger service owns balances.", entries[manifest.Files[0].Entry])
+	assert.Contains(t, entries["prompt.md"], "Describe the ledger")
+	assert.Contains(t, entries["prompt.md"], "The ledger service owns balances.")
+	assert.Equal(t, "Describe the ledger", entries["instructions.md"])
+	assert.JSONEq(t, `{"type":"object","properties":{"body":{"type":"string"}}}`, entries["schema.json"])
+	assert.Contains(t, entries["README.md"], "pack-template")
+
+	var packed PackManifest
+	require.NoError(t, json.Unmarshal([]byte(entries["manifest.json"]), &packed))
+	assert.Equal(t, manifest.Files, packed.Files)
+
+	// An existing pack is only replaced with Force
+	_, err = orchestrator.Pack(opts)
+	assert.ErrorContains(t, err, "already exists")
+	opts.Force = true
+	_, err = orchestrator.Pack(opts)
+	assert.NoError(t, err)
+}
+
+func TestPackEntryName(t *testing.T) {
+	assert.Equal(t, "sources/docs/a.md", packEntryName("docs/a.md"))
+	assert.Equal(t, "sources/docs/spec.pdf.txt", packEntryName("docs/spec.pdf"))
+}
@@ -0,0 +1,169 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// generateCandidate generates one document with client, field by field when the
+// options ask for per-field generation and in a single request otherwise.
+func (o *Orchestrator) generateCandidate(ctx context.Context, client ai.Client, generationPrompt string, tmpl *templates.Template, opts Options) (string, error) {
+	if opts.PerField {
+		return o.generatePerField(ctx, client, generationPrompt, tmpl, opts)
+	}
+	return o.generateWithRetries(ctx, client, generationPrompt, tmpl, opts)
+}
+
+// generatePerField generates the top-level fields of the template with a request per
+// field, or per section for fields that declare an x-section, in dependency order.
+// Every request receives the values of the fields its fields declare in x-dependsOn.
+// The assembled document is then validated and repaired as a whole.
+func (o *Orchestrator) generatePerField(ctx context.Context, client ai.Client, generationPrompt string, tmpl *templates.Template, opts Options) (string, error) {
+	groups, err := templates.FieldGroups(tmpl.Schema)
+	if err != nil {
+		return "", fmt.Errorf("template %s: %w", tmpl.Name, err)
+	}
+	if len(groups) == 0 {
+		return "", fmt.Errorf("template %s: schema declares no fields for per-field generation", tmpl.Name)
+	}
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(tmpl.Schema, &schema); err != nil {
+		return "", fmt.Errorf("failed to parse schema: %w", err)
+	}
+	log.Info().Int("requests", len(groups)).Msg("Generating document field by field")
+
+	fields := make(map[string]interface{})
+	for _, group := range groups {
+		inputsJSON := ""
+		if len(group.DependsOn) > 0 {
+			inputs := make(map[string]interface{}, len(group.DependsOn))
+			for _, dependency := range group.DependsOn {
+				if value, ok := fields[dependency]; ok {
+					inputs[dependency] = value
+				}
+			}
+			data, err := json.MarshalIndent(inputs, "", "  ")
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal inputs of %s: %w", groupName(group), err)
+			}
+			inputsJSON = string(data)
+		}
+
+		var values map[string]interface{}
+		if group.Section == "" {
+			values, err = o.generateField(ctx, client, generationPrompt, group.Fields[0], schema.Properties[group.Fields[0]], inputsJSON)
+		} else {
+			values, err = o.generateSection(ctx, client, generationPrompt, group, sectionSchema(group.Fields, schema.Properties, schema.Required), inputsJSON)
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, field := range group.Fields {
+			if values[field] == nil {
+				// Left out; validation reports it if the field is required
+				log.Warn().Str("field", field).Msg("Model returned no value for field")
+				continue
+			}
+			fields[field] = values[field]
+		}
+	}
+
+	generatedJSON, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal generated document: %w", err)
+	}
+	return o.repairUntilValid(ctx, client, generationPrompt, string(generatedJSON), tmpl, opts.MaxRepairs)
+}
+
+// generateField generates a single field, returning its value keyed by its name.
+func (o *Orchestrator) generateField(ctx context.Context, client ai.Client, generationPrompt, field string, fieldSchema json.RawMessage, inputsJSON string) (map[string]interface{}, error) {
+	log.Info().Str("field", field).Msg("Generating field")
+	fieldPrompt := o.builder.BuildFieldPrompt(generationPrompt, field, fieldSchema, inputsJSON)
+	startTime := time.Now()
+	response, err := o.complete(ctx, client, fieldPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("AI generation of field %s failed: %w", field, err)
+	}
+	log.Debug().Str("field", field).Dur("duration", time.Since(startTime)).Int("response_bytes", len(response)).Msg("Received field response")
+
+	var result struct {
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		return nil, fmt.Errorf("invalid response for field %s: %w", field, err)
+	}
+	return map[string]interface{}{field: result.Value}, nil
+}
+
+// generateSection generates the fields of a section together, returning their values
+// by name. Fields the response adds beyond the section are dropped by the caller.
+func (o *Orchestrator) generateSection(ctx context.Context, client ai.Client, generationPrompt string, group templates.FieldGroup, schema json.RawMessage, inputsJSON string) (map[string]interface{}, error) {
+	log.Info().Str("section", group.Section).Strs("fields", group.Fields).Msg("Generating section")
+	sectionPrompt := o.builder.BuildSectionPrompt(generationPrompt, group.Section, schema, inputsJSON)
+	startTime := time.Now()
+	response, err := o.complete(ctx, client, sectionPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("AI generation of section %s failed: %w", group.Section, err)
+	}
+	log.Debug().Str("section", group.Section).Dur("duration", time.Since(startTime)).Int("response_bytes", len(response)).Msg("Received section response")
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &values); err != nil {
+		return nil, fmt.Errorf("invalid response for section %s: %w", group.Section, err)
+	}
+	return values, nil
+}
+
+// sectionSchema returns an object schema of the given top-level fields, requiring
+// those the document requires.
+func sectionSchema(fields []string, properties map[string]json.RawMessage, required []string) json.RawMessage {
+	var b strings.Builder
+	b.WriteString(`{"type":"object","properties":{`)
+	var sectionRequired []string
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		name, _ := json.Marshal(field)
+		b.Write(name)
+		b.WriteString(":")
+		b.Write(properties[field])
+		if slices.Contains(required, field) {
+			sectionRequired = append(sectionRequired, field)
+		}
+	}
+	b.WriteString("}")
+	if len(sectionRequired) > 0 {
+		names, _ := json.Marshal(sectionRequired)
+		b.WriteString(`,"required":`)
+		b.Write(names)
+	}
+	b.WriteString("}")
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(b.String()), "", "  "); err != nil {
+		return json.RawMessage(b.String())
+	}
+	return indented.Bytes()
+}
+
+// groupName names a field group in messages.
+func groupName(group templates.FieldGroup) string {
+	if group.Section != "" {
+		return "section " + group.Section
+	}
+	return "field " + group.Fields[0]
+}
@@ -0,0 +1,115 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// TestGenerate_PerFieldDependencyOrder tests that per-field generation produces fields
+// after the fields they depend on and passes those values along.
+func TestGenerate_PerFieldDependencyOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:   "per-field-template",
+		Prompt: "Generate the document",
+		Schema: json.RawMessage(`{"type":"object","properties":{
+			"summary":{"type":"string","x-dependsOn":["sections"]},
+			"title":{"type":"string"},
+			"sections":{"type":"array","items":{"type":"string"},"x-render":false}
+		},"required":["summary","title","sections"]}`),
+		HTMLContent: `<html><body><!-- data-field="title" --><!-- data-field="summary" --></body></html>`,
+	}
+
+	client := &promptCapturingClient{
+		responses: []string{
+			`{"value": ["Ledger ownership", "Balance queries"]}`,
+			`{"value": "Covers ledger ownership and balance queries."}`,
+			`{"value": "Ledger"}`,
+		},
+	}
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("per-field-template", tmpl))
+
+	result, err := orchestrator.Run(context.Background(), Options{
+		TemplateType: "per-field-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "out.html"),
+		Model:        "test-model",
+		APIKey:       "test-key",
+		MaxRepairs:   1,
+		PerField:     true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 3)
+	assert.Contains(t, client.prompts[0], "Generate only the field 'sections'")
+	assert.Contains(t, client.prompts[1], "Generate only the field 'summary'")
+	assert.Contains(t, client.prompts[1], "Balance queries", "dependent field must receive its inputs")
+	assert.Contains(t, client.prompts[2], "Generate only the field 'title'")
+	assert.NotContains(t, client.prompts[2], "Balance queries", "independent field must not receive other fields")
+
+	assert.Equal(t, "Covers ledger ownership and balance queries.", result.Fields["summary"])
+	assert.Equal(t, "Ledger", result.Fields["title"])
+}
+
+// TestGenerate_PerFieldSections tests that per-field generation produces the fields of
+// a section with one request and merges them into the document.
+func TestGenerate_PerFieldSections(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:   "section-template",
+		Prompt: "Generate the document",
+		Schema: json.RawMessage(`{"type":"object","properties":{
+			"title":{"type":"string"},
+			"components":{"type":"array","items":{"type":"string"},"x-section":"architecture"},
+			"interfaces":{"type":"array","items":{"type":"string"},"x-section":"architecture"},
+			"summary":{"type":"string","x-dependsOn":["components"]}
+		},"required":["title","components","summary"]}`),
+		HTMLContent: `<html><body><!-- data-field="title" --><!-- data-field="components" --><!-- data-field="interfaces" --><!-- data-field="summary" --></body></html>`,
+	}
+
+	client := &promptCapturingClient{
+		responses: []string{
+			`{"value": "Ledger"}`,
+			`{"components": ["Ledger service"], "interfaces": ["Balance API"], "extra": "dropped"}`,
+			`{"value": "The ledger service owns balances."}`,
+		},
+	}
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("section-template", tmpl))
+
+	result, err := orchestrator.Run(context.Background(), Options{
+		TemplateType: "section-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "out.html"),
+		Model:        "test-model",
+		APIKey:       "test-key",
+		MaxRepairs:   1,
+		PerField:     true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 3)
+	assert.Contains(t, client.prompts[1], "Generate only the fields of the section 'architecture'")
+	assert.Contains(t, client.prompts[1], `"required": [`+"\n"+`    "components"`+"\n  ]", "the section schema requires the document's required fields")
+	assert.Contains(t, client.prompts[2], "Ledger service", "dependent field must receive the section's values")
+
+	assert.Equal(t, []interface{}{"Ledger service"}, result.Fields["components"])
+	assert.Equal(t, []interface{}{"Balance API"}, result.Fields["interfaces"])
+	assert.NotContains(t, result.Fields, "extra")
+	assert.Equal(t, "The ledger service owns balances.", result.Fields["summary"])
+}
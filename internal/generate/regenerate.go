@@ -0,0 +1,253 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/ci"
+	"github.com/karolswdev/docloom/internal/ingest"
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+// RegenerateOptions configures regenerating some fields of an existing document.
+type RegenerateOptions struct {
+	FromFile string   // JSON sidecar of the document
+	Fields   []string // Top-level fields to regenerate
+
+	// Template and sources of the document; default to those in its run report
+	TemplateType string
+	Sources      []string
+
+	// Output document; defaults to the document of the sidecar, which is replaced.
+	// Another existing document is only overwritten with Force.
+	OutputFile string
+	Force      bool
+
+	Model       string
+	Seed        *int
+	Temperature float32
+	MaxRetries  int
+	MaxRepairs  int
+	Provenance  bool
+
+	// Handling of sources that cannot be read: fail, warn (default) or ignore
+	SourceErrors string
+
+	// Optional meter of the run's token usage and cost, recorded in the run report
+	Usage *ai.Meter
+}
+
+// Regenerate asks the model for new values of the named fields of a generated
+// document, keeping the others. Each field is generated with the sources and the
+// current values of all other fields as context, in x-dependsOn order. The document
+// is then validated and repaired like a generated one and rendered again with its
+// JSON sidecar and a run report listing the regenerated fields.
+func (o *Orchestrator) Regenerate(ctx context.Context, opts RegenerateOptions) (*Result, error) {
+	if opts.FromFile == "" {
+		return nil, errors.New("the JSON sidecar of the document to regenerate is required")
+	}
+	if len(opts.Fields) == 0 {
+		return nil, errors.New("at least one field to regenerate is required")
+	}
+	if opts.MaxRepairs < 0 {
+		return nil, fmt.Errorf("max repairs must be non-negative")
+	}
+	data, err := os.ReadFile(opts.FromFile) // #nosec G304 -- the sidecar is user-provided
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON sidecar: %w", err)
+	}
+
+	// The run report of the earlier run supplies what the options leave out
+	previous, err := readReport(reportPath(opts.FromFile))
+	if err != nil && (opts.TemplateType == "" || len(opts.Sources) == 0) {
+		return nil, fmt.Errorf("%w; pass the template and sources explicitly", err)
+	}
+	if opts.TemplateType == "" {
+		opts.TemplateType = previous.Template
+	}
+	if len(opts.Sources) == 0 {
+		opts.Sources = previous.Sources
+	}
+	if len(opts.Sources) == 0 {
+		return nil, errors.New("the run report of the document lists no sources; pass them explicitly")
+	}
+	policy, err := ingest.ParseErrorPolicy(opts.SourceErrors)
+	if err != nil {
+		return nil, err
+	}
+	if opts.OutputFile == "" {
+		opts.OutputFile = render.SidecarPath(opts.FromFile, ".html")
+		if previous != nil && previous.OutputFile != "" {
+			opts.OutputFile = previous.OutputFile
+		}
+	} else if render.SidecarPath(opts.OutputFile, ".json") != opts.FromFile {
+		if err := render.CheckOverwrite(opts.Force, opts.OutputFile, render.SidecarPath(opts.OutputFile, ".json")); err != nil {
+			return nil, err
+		}
+	}
+
+	tmpl, err := o.registry.Get(opts.TemplateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template %s: %w", opts.TemplateType, err)
+	}
+	review, err := o.newReview(string(data), "", tmpl)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0, len(opts.Fields))
+	for _, field := range review.Fields {
+		if slices.Contains(opts.Fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range opts.Fields {
+		if review.Schema(field) == nil {
+			return nil, fmt.Errorf("field %s is not declared in the schema of template %s", field, tmpl.Name)
+		}
+	}
+
+	runOpts := Options{
+		TemplateType: opts.TemplateType,
+		OutputFile:   opts.OutputFile,
+		Model:        opts.Model,
+		Seed:         opts.Seed,
+		Temperature:  opts.Temperature,
+		MaxRetries:   opts.MaxRetries,
+		Provenance:   opts.Provenance,
+	}
+	ctx = ai.WithRequestParams(ctx, runOpts.requestParams())
+	ctx = ai.WithMeter(ctx, opts.Usage)
+	repairs := &repairLog{}
+	ctx = withRepairLog(ctx, repairs)
+
+	ingestion, err := o.ingester.Ingest(opts.Sources, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest sources: %w", err)
+	}
+	promptSources, err := o.retrieveSources(ctx, ingestion.Content, tmpl, false)
+	if err != nil {
+		return nil, err
+	}
+	generationPrompt, err := o.builder.BuildGenerationPrompt(promptSources, tmpl.Prompt, tmpl.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	}
+
+	log.Info().Strs("fields", fields).Str("from", opts.FromFile).Msg("Regenerating fields")
+	for _, field := range fields {
+		if err := o.regenerateField(ctx, generationPrompt, field, review); err != nil {
+			return nil, err
+		}
+	}
+	documentJSON, err := json.Marshal(review.values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal regenerated document: %w", err)
+	}
+	generatedJSON, err := o.repairUntilValid(ctx, o.aiClient, generationPrompt, string(documentJSON), tmpl, opts.MaxRepairs)
+	if err != nil {
+		return nil, err
+	}
+	var rendered map[string]interface{}
+	if err := json.Unmarshal([]byte(generatedJSON), &rendered); err != nil {
+		return nil, fmt.Errorf("failed to parse regenerated JSON: %w", err)
+	}
+
+	report := &Report{
+		RunID:           newRunID(),
+		CI:              ci.Detect(),
+		Template:        tmpl.Name,
+		Model:           opts.Model,
+		OutputFile:      opts.OutputFile,
+		Sources:         opts.Sources,
+		SourceFiles:     ingestion.Files,
+		SourceErrors:    ingestion.Errors,
+		SourceStats:     ingestion.Stats(o.tokenCounter(opts.Model).Count),
+		Repairs:         repairs.list(),
+		RegeneratedFrom: opts.FromFile,
+		Regenerated:     fields,
+	}
+	// A document that had a plain-text version keeps one
+	writeText := previous != nil && previous.TextFile != ""
+	if err := o.renderer.RenderWithOptions(tmpl.HTMLContent, rendered, opts.OutputFile, render.Options{
+		Provenance: provenanceFor(runOpts, report),
+		Transforms: tmpl.Transforms,
+		Assets:     tmpl.Assets,
+		Text:       writeText,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render output: %w", err)
+	}
+	if writeText {
+		report.TextFile = render.SidecarPath(opts.OutputFile, render.TextSuffix)
+	}
+
+	report.GeneratedAt = time.Now().UTC()
+	report.JSONFile = render.SidecarPath(opts.OutputFile, ".json")
+	report.Usage = opts.Usage.Summary()
+	reportFile := reportPath(opts.OutputFile)
+	if err := writeReport(reportFile, report); err != nil {
+		return nil, err
+	}
+	log.Info().Str("html_file", opts.OutputFile).Str("json_file", report.JSONFile).Msg("Regenerated document rendered")
+
+	return &Result{
+		Fields:     rendered,
+		Report:     report,
+		Template:   tmpl.Name,
+		OutputFile: opts.OutputFile,
+		JSONFile:   report.JSONFile,
+		ReportFile: reportFile,
+	}, nil
+}
+
+// regenerateField replaces the value of a field of the document under review with a
+// new one, passing the model the values of all other fields.
+func (o *Orchestrator) regenerateField(ctx context.Context, generationPrompt, field string, review *Review) error {
+	inputs := make(map[string]interface{}, len(review.values))
+	for name, value := range review.values {
+		if name != field {
+			inputs[name] = value
+		}
+	}
+	inputsJSON := ""
+	if len(inputs) > 0 {
+		data, err := json.MarshalIndent(inputs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal context of field %s: %w", field, err)
+		}
+		inputsJSON = string(data)
+	}
+
+	values, err := o.generateField(ctx, o.aiClient, generationPrompt, field, review.Schema(field), inputsJSON)
+	if err != nil {
+		return err
+	}
+	if values[field] == nil {
+		// Validation reports it if the field is required
+		log.Warn().Str("field", field).Msg("Model returned no value for field")
+		delete(review.values, field)
+		return nil
+	}
+	review.values[field] = values[field]
+	return nil
+}
+
+// readReport reads the run report at path.
+func readReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- reports are written next to user-chosen outputs
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse run report %s: %w", path, err)
+	}
+	return &report, nil
+}
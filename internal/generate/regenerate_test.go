@@ -0,0 +1,80 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// TestRegenerate tests that regenerating a field of an existing document keeps the
+// other fields, passes them to the model and records the run in the report.
+func TestRegenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:   "regenerate-template",
+		Prompt: "Generate the document",
+		Schema: json.RawMessage(`{"type":"object","properties":{
+			"title":{"type":"string"},
+			"summary":{"type":"string"}
+		},"required":["title","summary"]}`),
+		HTMLContent: `<html><body><!-- data-field="title" --><!-- data-field="summary" --></body></html>`,
+	}
+	client := &promptCapturingClient{
+		responses: []string{
+			`{"title": "Ledger", "summary": "Too vague."}`,
+			`{"value": "The ledger service owns account balances."}`,
+		},
+	}
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("regenerate-template", tmpl))
+
+	outputFile := filepath.Join(tempDir, "out.html")
+	_, err := orchestrator.Run(context.Background(), Options{
+		TemplateType: "regenerate-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   outputFile,
+		Model:        "test-model",
+		APIKey:       "test-key",
+	})
+	require.NoError(t, err)
+
+	result, err := orchestrator.Regenerate(context.Background(), RegenerateOptions{
+		FromFile: filepath.Join(tempDir, "out.json"),
+		Fields:   []string{"summary"},
+		Model:    "test-model",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 2)
+	assert.Contains(t, client.prompts[1], "Generate only the field 'summary'")
+	assert.Contains(t, client.prompts[1], `"title": "Ledger"`, "other fields must be passed as context")
+	assert.Contains(t, client.prompts[1], "The ledger owns balances.", "sources must be taken from the run report")
+
+	assert.Equal(t, outputFile, result.OutputFile)
+	assert.Equal(t, "Ledger", result.Fields["title"])
+	assert.Equal(t, "The ledger service owns account balances.", result.Fields["summary"])
+	html, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "The ledger service owns account balances.")
+
+	report, err := readReport(result.ReportFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summary"}, report.Regenerated)
+	assert.Equal(t, filepath.Join(tempDir, "out.json"), report.RegeneratedFrom)
+
+	_, err = orchestrator.Regenerate(context.Background(), RegenerateOptions{
+		FromFile: filepath.Join(tempDir, "out.json"),
+		Fields:   []string{"owner"},
+	})
+	assert.ErrorContains(t, err, "field owner is not declared")
+}
@@ -0,0 +1,71 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/prompt"
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+// maxTargetedIssues is the most failing fields a targeted repair prompt lists.
+// Documents with more are far from valid and are repaired with the full schema.
+const maxTargetedIssues = 8
+
+// buildRepairPrompt builds the prompt to repair generatedJSON after validationErr.
+// Near-valid documents get a targeted prompt naming each failing field with an
+// example of a valid value, without the schema, and without the original prompt
+// unless a required field is missing and must be written from it. Documents that
+// do not parse, fail on many fields or fail in ways that cannot be narrowed to
+// fields get the full repair prompt, as do all repairs when targeted is false.
+// It reports whether the prompt is targeted.
+func (o *Orchestrator) buildRepairPrompt(originalPrompt, generatedJSON, schemaStr string, validationErr error, tmpl *templates.Template, targeted bool) (string, bool, error) {
+	if targeted {
+		if issues, missing, ok := o.repairIssues(generatedJSON, schemaStr, validationErr); ok {
+			log.Debug().Int("fields", len(issues)).Bool("missing_fields", missing).Msg("Using targeted repair prompt")
+			sources := ""
+			if missing {
+				sources = originalPrompt
+			}
+			repairPrompt, err := o.builder.BuildTargetedRepairPrompt(generatedJSON, issues, sources)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to build repair prompt: %w", err)
+			}
+			return repairPrompt, true, nil
+		}
+	}
+	repairPrompt, err := o.builder.BuildRepairPrompt(originalPrompt, generatedJSON, validationErr.Error(), tmpl.Schema)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to build repair prompt: %w", err)
+	}
+	return repairPrompt, false, nil
+}
+
+// repairIssues narrows validationErr to the fields of generatedJSON to repair. Rule
+// violations concern the whole document and are listed without a field. missing
+// reports a required field is absent. ok is false when the failure cannot be
+// narrowed or spans more than maxTargetedIssues.
+func (o *Orchestrator) repairIssues(generatedJSON, schemaStr string, validationErr error) (issues []prompt.RepairIssue, missing bool, ok bool) {
+	var ruleErr *validate.RuleViolationError
+	if errors.As(validationErr, &ruleErr) {
+		for _, violation := range ruleErr.Violations {
+			issues = append(issues, prompt.RepairIssue{Problem: violation.String()})
+		}
+	} else {
+		fieldIssues, narrowed, err := o.validator.FieldIssues(generatedJSON, schemaStr)
+		if err != nil || !narrowed || len(fieldIssues) == 0 {
+			return nil, false, false
+		}
+		for _, issue := range fieldIssues {
+			missing = missing || issue.Missing
+			issues = append(issues, prompt.RepairIssue{Field: issue.Field, Problem: issue.Problem, Example: issue.Example, Remove: issue.Remove})
+		}
+	}
+	if len(issues) > maxTargetedIssues {
+		return nil, false, false
+	}
+	return issues, missing, true
+}
@@ -0,0 +1,104 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+func TestRepairUntilValid_TargetedRepair(t *testing.T) {
+	tmpl := &templates.Template{
+		Name:   "repair-template",
+		Schema: json.RawMessage(`{"type":"object","properties":{"title":{"type":"string"},"level":{"type":"string","enum":["low","high"]}},"required":["title","level"]}`),
+	}
+	originalPrompt := "Write about the ledger. " + strings.Repeat("Source material. ", 50)
+
+	t.Run("near-valid document gets a targeted prompt", func(t *testing.T) {
+		client := &promptCapturingClient{responses: []string{`{"title": "Ledger", "level": "low"}`}}
+		orchestrator := NewOrchestrator(client)
+
+		repaired, err := orchestrator.repairUntilValid(context.Background(), client, originalPrompt, `{"title": "Ledger", "level": "severe"}`, tmpl, 2)
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"title": "Ledger", "level": "low"}`, repaired)
+		require.Len(t, client.prompts, 1)
+		assert.Contains(t, client.prompts[0], "## Fields to Fix\n- `/level`")
+		assert.Contains(t, client.prompts[0], "Example of a valid value: `\"low\"`")
+		assert.NotContains(t, client.prompts[0], "## Required Schema")
+		assert.NotContains(t, client.prompts[0], "## Original Context")
+		assert.Less(t, len(client.prompts[0]), len(originalPrompt), "the targeted prompt is smaller than the original prompt alone")
+	})
+
+	t.Run("falls back to the full prompt when the error repeats", func(t *testing.T) {
+		client := &promptCapturingClient{responses: []string{`{"title": "Ledger", "level": "severe"}`, `{"title": "Ledger", "level": "high"}`}}
+		orchestrator := NewOrchestrator(client)
+
+		_, err := orchestrator.repairUntilValid(context.Background(), client, originalPrompt, `{"title": "Ledger", "level": "severe"}`, tmpl, 2)
+
+		require.NoError(t, err)
+		require.Len(t, client.prompts, 2)
+		assert.Contains(t, client.prompts[0], "## Fields to Fix")
+		assert.Contains(t, client.prompts[1], "## Required Schema")
+		assert.Contains(t, client.prompts[1], originalPrompt)
+	})
+
+	t.Run("far from valid document gets the full prompt", func(t *testing.T) {
+		properties := map[string]interface{}{}
+		required := []string{}
+		for i := 0; i <= maxTargetedIssues; i++ {
+			name := fmt.Sprintf("field%d", i)
+			properties[name] = map[string]interface{}{"type": "string"}
+			required = append(required, name)
+		}
+		schema, err := json.Marshal(map[string]interface{}{"type": "object", "properties": properties, "required": required})
+		require.NoError(t, err)
+		client := &promptCapturingClient{}
+		orchestrator := NewOrchestrator(client)
+
+		_, err = orchestrator.repairUntilValid(context.Background(), client, originalPrompt, `{}`, &templates.Template{Name: "wide", Schema: schema}, 1)
+
+		require.Error(t, err)
+		require.Len(t, client.prompts, 1)
+		assert.Contains(t, client.prompts[0], "## Required Schema")
+	})
+}
+
+func TestRepairIssues(t *testing.T) {
+	orchestrator := NewOrchestrator(&promptCapturingClient{})
+	schema := `{"type":"object","properties":{"start":{"type":"string"},"end":{"type":"string"}},"required":["start","end"]}`
+
+	t.Run("missing fields need the original context", func(t *testing.T) {
+		issues, missing, ok := orchestrator.repairIssues(`{"start": "2025-01-01"}`, schema, fmt.Errorf("validation failed"))
+
+		require.True(t, ok)
+		assert.True(t, missing)
+		require.Len(t, issues, 1)
+		assert.Equal(t, "/end", issues[0].Field)
+	})
+
+	t.Run("rule violations concern the whole document", func(t *testing.T) {
+		ruleErr := &validate.RuleViolationError{Violations: []validate.RuleViolation{{Rule: validate.Rule{Expr: "end >= start", Message: "end must not be before start"}}}}
+
+		issues, missing, ok := orchestrator.repairIssues(`{"start": "2025-02-01", "end": "2025-01-01"}`, schema, ruleErr)
+
+		require.True(t, ok)
+		assert.False(t, missing)
+		require.Len(t, issues, 1)
+		assert.Empty(t, issues[0].Field)
+		assert.Contains(t, issues[0].Problem, "end must not be before start")
+	})
+
+	t.Run("unparsable JSON cannot be targeted", func(t *testing.T) {
+		_, _, ok := orchestrator.repairIssues(`{"start": `, schema, fmt.Errorf("invalid JSON"))
+
+		assert.False(t, ok)
+	})
+}
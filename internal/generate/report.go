@@ -0,0 +1,167 @@
+package generate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/agent"
+	"github.com/karolswdev/docloom/internal/ai"
+	"github.com/karolswdev/docloom/internal/ci"
+	"github.com/karolswdev/docloom/internal/grounding"
+	"github.com/karolswdev/docloom/internal/ingest"
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+// Report is the run report written next to the output as <name>.report.json. It
+// records how a document was produced and the results of optional quality stages.
+type Report struct {
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Evaluation    *Evaluation       `json:"evaluation,omitempty"`
+	Groundedness  *grounding.Report `json:"groundedness,omitempty"`
+	Confidence    *Confidence       `json:"confidence,omitempty"`
+	Ensemble      *EnsembleResult   `json:"ensemble,omitempty"`
+	SourceArchive *SourceArchive    `json:"source_archive,omitempty"`
+	RunID         string            `json:"run_id"`
+	Template      string            `json:"template"`
+	Model         string            `json:"model,omitempty"`
+	OutputFile    string            `json:"output_file"`
+	JSONFile      string            `json:"json_file"`
+	TextFile      string            `json:"text_file,omitempty"` // Plain-text version, when written
+	Sources       []string          `json:"sources"`
+
+	// CI pipeline run the document was produced in, detected from the environment
+	CI *ci.Info `json:"ci,omitempty"`
+
+	// Token usage and cost of the run's model requests
+	Usage *ai.UsageSummary `json:"usage,omitempty"`
+
+	// Field JSON rendered by docloom import instead of being generated
+	ImportedFrom string `json:"imported_from,omitempty"`
+
+	// Research agents that produced the sources, with the runner version and hash
+	Agents []agent.RunnerInfo `json:"agents,omitempty"`
+
+	// Repairs of invalid documents, with the validation errors and the model's fixes
+	Repairs []RepairAttempt `json:"repairs,omitempty"`
+
+	// Fields a person edited or had regenerated in the review before writing
+	Review *ReviewSummary `json:"review,omitempty"`
+
+	// JSON sidecar of the document whose fields docloom regenerate replaced, and
+	// those fields; the others were kept
+	RegeneratedFrom string   `json:"regenerated_from,omitempty"`
+	Regenerated     []string `json:"regenerated,omitempty"`
+
+	// Files that were ingested and the sources skipped because of errors
+	SourceFiles  []string             `json:"source_files"`
+	SourceErrors []ingest.SourceError `json:"source_errors,omitempty"`
+
+	// Files by extension and language, bytes by directory and token share by
+	// top-level folder of the ingested sources
+	SourceStats *ingest.Stats `json:"source_stats,omitempty"`
+}
+
+// runQualityStages runs the optional evaluation, groundedness and confidence
+// stages, recording their results in the report.
+func (o *Orchestrator) runQualityStages(ctx context.Context, sourceContent, generatedJSON string, opts Options, report *Report) error {
+	if opts.Evaluate {
+		evaluation, err := o.evaluate(ctx, sourceContent, generatedJSON, opts)
+		if err != nil {
+			return err
+		}
+		report.Evaluation = evaluation
+	}
+
+	if opts.Grounded != "" && opts.Grounded != grounding.ModeOff {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(generatedJSON), &fields); err != nil {
+			return fmt.Errorf("failed to parse generated JSON for grounding: %w", err)
+		}
+		result := grounding.Check(fields, sourceContent, grounding.DefaultMaxClaims, grounding.DefaultThreshold, o.tokenCounter(opts.Model))
+		result.Mode = opts.Grounded
+		for _, finding := range result.Unsupported {
+			log.Warn().Str("field", finding.Field).Float64("confidence", finding.Confidence).Str("claim", finding.Claim).Msg("Possibly unsupported claim")
+		}
+		log.Info().Int("checked", result.Checked).Int("unsupported", len(result.Unsupported)).Float64("score", result.Score).Msg("Groundedness check complete")
+		report.Groundedness = &result
+	}
+
+	if opts.scoresConfidence() {
+		confidence, err := o.scoreConfidence(ctx, sourceContent, generatedJSON, opts)
+		if err != nil {
+			return err
+		}
+		report.Confidence = confidence
+	}
+	return nil
+}
+
+// gateError returns an error when a quality gate failed.
+func (r *Report) gateError() error {
+	if r.Evaluation != nil && !r.Evaluation.Passed {
+		return fmt.Errorf("quality gate failed: overall score %.1f is below threshold %.1f", r.Evaluation.Overall, r.Evaluation.Threshold)
+	}
+	if r.Groundedness != nil && r.Groundedness.Mode == grounding.ModeStrict && len(r.Groundedness.Unsupported) > 0 {
+		return fmt.Errorf("groundedness check failed: %d of %d sampled claims lack supporting evidence", len(r.Groundedness.Unsupported), r.Groundedness.Checked)
+	}
+	if fields := r.Confidence.belowThreshold(); len(fields) > 0 {
+		return fmt.Errorf("confidence check failed: %s below the minimum confidence %.2f", strings.Join(fields, ", "), r.Confidence.Threshold)
+	}
+	return nil
+}
+
+// newRunID returns an identifier for a generation run, e.g. "20250101T120000Z-1a2b3c4d".
+func newRunID() string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		log.Warn().Err(err).Msg("Failed to generate random run ID suffix")
+	}
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// provenanceFor returns the provenance to annotate the rendered document with, or
+// nil when provenance annotations are disabled.
+func provenanceFor(opts Options, report *Report) *render.Provenance {
+	if !opts.Provenance {
+		return nil
+	}
+	provenance := &render.Provenance{
+		RunID:  report.RunID,
+		Model:  report.Model,
+		Report: filepath.Base(reportPath(opts.OutputFile)),
+	}
+	if report.CI != nil {
+		provenance.CI = report.CI.String()
+	}
+	return provenance
+}
+
+// reportPath returns the run report path for an output file.
+func reportPath(outputFile string) string {
+	return render.SidecarPath(outputFile, ".report.json")
+}
+
+// ReportFor reads the run report of an output file.
+func ReportFor(outputFile string) (*Report, error) {
+	return readReport(reportPath(outputFile))
+}
+
+// writeReport saves the run report.
+func writeReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run report: %w", err)
+	}
+	if err := render.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write run report: %w", err)
+	}
+	return nil
+}
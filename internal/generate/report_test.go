@@ -0,0 +1,115 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/agent"
+	"github.com/karolswdev/docloom/internal/grounding"
+)
+
+func TestGenerate_GroundednessStrictFails(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"body": "The ledger service owns balances. Quantum mainframes replicate every transaction across twelve galaxies."}`,
+	}}
+
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	opts.Grounded = grounding.ModeStrict
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "groundedness check failed")
+
+	require.NotNil(t, result)
+	report := result.Report.Groundedness
+	require.NotNil(t, report)
+	assert.Equal(t, grounding.ModeStrict, report.Mode)
+	require.Len(t, report.Unsupported, 1)
+	assert.Equal(t, "body", report.Unsupported[0].Field)
+	assert.Contains(t, report.Unsupported[0].Claim, "Quantum mainframes")
+	assert.FileExists(t, result.ReportFile)
+}
+
+func TestGenerate_GroundednessWarnPasses(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"body": "Quantum mainframes replicate every transaction across twelve galaxies."}`,
+	}}
+
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	opts.Grounded = grounding.ModeWarn
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Report.Groundedness.Unsupported, 1)
+}
+
+func TestGenerate_GroundednessInvalidMode(t *testing.T) {
+	orchestrator, opts := setupEvaluationTest(t, &promptCapturingClient{})
+	opts.Grounded = "paranoid"
+
+	_, err := orchestrator.Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "unknown grounding mode")
+}
+
+func TestGenerate_Provenance(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "The ledger service owns balances."}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	opts.Provenance = true
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Report.RunID)
+
+	content, err := os.ReadFile(result.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `data-docloom-run="`+result.Report.RunID+`"`)
+	assert.Contains(t, string(content), `data-docloom-field="body"`)
+}
+
+func TestGenerate_SourceErrors(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "The ledger service owns balances."}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	missing := filepath.Join(filepath.Dir(opts.OutputFile), "missing.md")
+	opts.Sources = append(opts.Sources, missing)
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Report.SourceFiles, 1)
+	require.Len(t, result.Report.SourceErrors, 1)
+	assert.Equal(t, missing, result.Report.SourceErrors[0].Path)
+
+	opts.SourceErrors = "fail"
+	opts.Force = true
+	_, err = orchestrator.Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "failed to stat path")
+
+	opts.SourceErrors = "skip"
+	_, err = orchestrator.Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "unknown source error policy")
+}
+
+func TestGenerate_ReportRecordsAgents(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "The ledger service owns balances."}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	opts.Agents = []agent.RunnerInfo{{Agent: "csharp-analyzer", Version: "1.4.2", Command: "./docloom-agent-csharp", SHA256: "ab12", Pinned: true}}
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(result.ReportFile)
+	require.NoError(t, err)
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, opts.Agents, report.Agents)
+}
@@ -0,0 +1,71 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/chunk"
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// SetRetriever enables the retrieval stage: instead of the whole ingested content,
+// generation prompts get the source chunks most relevant to the template's prompt
+// and fields. Quality stages still check the document against all sources.
+func (o *Orchestrator) SetRetriever(retriever *chunk.Retriever) {
+	o.retriever = retriever
+}
+
+// retrieveSources returns the source content for the generation prompt: the chunks
+// selected by the retriever, or all content without one and in dry runs, which make
+// no requests.
+func (o *Orchestrator) retrieveSources(ctx context.Context, sourceContent string, tmpl *templates.Template, dryRun bool) (string, error) {
+	if o.retriever == nil {
+		return sourceContent, nil
+	}
+	if dryRun {
+		log.Info().Msg("Dry run: skipping source retrieval, the prompt shows all sources")
+		return sourceContent, nil
+	}
+	selected, err := o.retriever.Select(ctx, sourceContent, retrievalQuery(tmpl))
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve relevant sources: %w", err)
+	}
+	log.Info().Int("bytes", len(selected)).Int("source_bytes", len(sourceContent)).Msg("Source retrieval complete")
+	return selected, nil
+}
+
+// retrievalQuery returns the text source chunks are ranked against: the template's
+// prompt followed by its top-level fields with their titles and descriptions.
+func retrievalQuery(tmpl *templates.Template) string {
+	var schema struct {
+		Properties map[string]struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+		} `json:"properties"`
+	}
+	_ = json.Unmarshal(tmpl.Schema, &schema)
+
+	fields := make([]string, 0, len(schema.Properties))
+	for field := range schema.Properties {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var query strings.Builder
+	query.WriteString(tmpl.Prompt)
+	for _, field := range fields {
+		property := schema.Properties[field]
+		query.WriteString("\n" + field)
+		for _, text := range []string{property.Title, property.Description} {
+			if text != "" {
+				query.WriteString(" " + text)
+			}
+		}
+	}
+	return query.String()
+}
@@ -0,0 +1,70 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/chunk"
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/tokens"
+)
+
+// topicEmbedder embeds texts by whether they are about the document's subject, the
+// ledger, or about lunch.
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		text = strings.ToLower(text)
+		vectors[i] = []float32{
+			float32(strings.Count(text, "ledger") + strings.Count(text, "document")),
+			float32(strings.Count(text, "lunch")),
+		}
+	}
+	return vectors, nil
+}
+
+func TestGenerate_Retrieval(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{`{"body": "The ledger service owns balances."}`}}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	notes := "The ledger service owns balances.\n\nThe team lunch is on Thursdays.\n\nThe ledger is append-only."
+	require.NoError(t, os.WriteFile(opts.Sources[0], []byte(notes), 0644))
+	orchestrator.SetRetriever(chunk.NewRetriever(topicEmbedder{}, 5, 2, tokens.ForModel("")))
+
+	_, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 1)
+	assert.Contains(t, client.prompts[0], "The ledger service owns balances.\n\n[...]\n\nThe ledger is append-only.")
+	assert.NotContains(t, client.prompts[0], "lunch")
+}
+
+func TestRetrievalQuery(t *testing.T) {
+	tmpl := &templates.Template{
+		Prompt: "Describe the architecture",
+		Schema: json.RawMessage(`{"type":"object","properties":{"risks":{"type":"array","description":"Known risks"},"components":{"type":"array","title":"Components"}}}`),
+	}
+	assert.Equal(t, "Describe the architecture\ncomponents Components\nrisks Known risks", retrievalQuery(tmpl))
+}
+
+func TestGenerate_RetrievalSkippedInDryRun(t *testing.T) {
+	client := &promptCapturingClient{}
+	orchestrator, opts := setupEvaluationTest(t, client)
+	opts.Evaluate = false
+	opts.DryRun = true
+	opts.OutputFile = filepath.Join(t.TempDir(), "out.html")
+	orchestrator.SetRetriever(chunk.NewRetriever(nil, 5, 1, tokens.ForModel("")))
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+}
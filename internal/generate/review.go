@@ -0,0 +1,213 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// Reviewer lets a person review a generated document before it is written,
+// accepting, editing or regenerating its fields (see SetReviewer).
+type Reviewer interface {
+	// Review goes through the fields of the document, changing them through the
+	// review. An error aborts the run without writing the document.
+	Review(ctx context.Context, review *Review) error
+}
+
+// SetReviewer enables the review stage: after the document is generated and
+// validated, the reviewer can change it before the quality stages and rendering.
+func (o *Orchestrator) SetReviewer(reviewer Reviewer) {
+	o.reviewer = reviewer
+}
+
+// ReviewSummary records the fields a reviewer changed, in the run report.
+type ReviewSummary struct {
+	Edited      []string `json:"edited,omitempty"`      // Fields edited by hand
+	Regenerated []string `json:"regenerated,omitempty"` // Fields regenerated by the model
+}
+
+// Review is a generated document under review.
+type Review struct {
+	Template string
+	Fields   []string // Top-level fields in generation order
+
+	values       map[string]interface{}
+	dependencies map[string][]string
+	schemas      map[string]json.RawMessage
+	summary      ReviewSummary
+
+	validate func(documentJSON string) (string, error)
+	prompt   string // Generation prompt of the document
+	o        *Orchestrator
+}
+
+// newReview starts the review of a generated document.
+func (o *Orchestrator) newReview(generatedJSON, generationPrompt string, tmpl *templates.Template) (*Review, error) {
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(generatedJSON), &values); err != nil {
+		return nil, fmt.Errorf("failed to parse generated JSON: %w", err)
+	}
+	order, dependencies, err := templates.FieldOrder(tmpl.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", tmpl.Name, err)
+	}
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(tmpl.Schema, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	// Fields the schema does not declare, if it allows them, come last
+	var extra []string
+	for field := range values {
+		if _, ok := schema.Properties[field]; !ok {
+			extra = append(extra, field)
+		}
+	}
+	sort.Strings(extra)
+
+	return &Review{
+		Template:     tmpl.Name,
+		Fields:       append(order, extra...),
+		values:       values,
+		dependencies: dependencies,
+		schemas:      schema.Properties,
+		prompt:       generationPrompt,
+		o:            o,
+		validate: func(documentJSON string) (string, error) {
+			return o.validateDocument(documentJSON, tmpl)
+		},
+	}, nil
+}
+
+// Value returns the current value of a field, or nil when it has none.
+func (r *Review) Value(field string) interface{} {
+	return r.values[field]
+}
+
+// Schema returns the schema of a field, or nil for fields the schema does not declare.
+func (r *Review) Schema(field string) json.RawMessage {
+	return r.schemas[field]
+}
+
+// Set replaces the value of a field with one edited by the reviewer.
+func (r *Review) Set(field string, value interface{}) {
+	r.values[field] = value
+	r.summary.Edited = appendOnce(r.summary.Edited, field)
+}
+
+// Regenerate asks the model for a new value of a field, passing it the current value
+// the reviewer rejected and their optional instructions, and returns the new value.
+func (r *Review) Regenerate(ctx context.Context, field, instructions string) (interface{}, error) {
+	schema, ok := r.schemas[field]
+	if !ok {
+		return nil, fmt.Errorf("field %s is not declared in the schema and cannot be regenerated", field)
+	}
+	inputsJSON := ""
+	if len(r.dependencies[field]) > 0 {
+		inputs := make(map[string]interface{}, len(r.dependencies[field]))
+		for _, dependency := range r.dependencies[field] {
+			inputs[dependency] = r.values[dependency]
+		}
+		data, err := json.MarshalIndent(inputs, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal inputs of field %s: %w", field, err)
+		}
+		inputsJSON = string(data)
+	}
+	current, err := json.MarshalIndent(r.values[field], "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal field %s: %w", field, err)
+	}
+
+	log.Info().Str("field", field).Msg("Regenerating field for review")
+	fieldPrompt := r.o.builder.BuildFieldPrompt(r.prompt, field, schema, inputsJSON)
+	fieldPrompt = r.o.builder.AppendReviewFeedback(fieldPrompt, string(current), instructions)
+	response, err := r.o.complete(ctx, r.o.aiClient, fieldPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("AI generation of field %s failed: %w", field, err)
+	}
+	var result struct {
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		return nil, fmt.Errorf("invalid response for field %s: %w", field, err)
+	}
+	r.values[field] = result.Value
+	r.summary.Regenerated = appendOnce(r.summary.Regenerated, field)
+	return result.Value, nil
+}
+
+// Validate checks the reviewed document against the template schema and rules.
+func (r *Review) Validate() error {
+	documentJSON, err := json.Marshal(r.values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reviewed document: %w", err)
+	}
+	_, err = r.validate(string(documentJSON))
+	return err
+}
+
+// document returns the reviewed document, normalized and validated.
+func (r *Review) document() (string, error) {
+	documentJSON, err := json.Marshal(r.values)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal reviewed document: %w", err)
+	}
+	normalized, err := r.validate(string(documentJSON))
+	if err != nil {
+		return "", fmt.Errorf("reviewed document is invalid: %w", err)
+	}
+	return normalized, nil
+}
+
+// reviewDocument hands a generated document to the reviewer and returns the reviewed
+// document with a summary of the changes.
+func (o *Orchestrator) reviewDocument(ctx context.Context, generatedJSON, generationPrompt string, tmpl *templates.Template) (string, *ReviewSummary, error) {
+	review, err := o.newReview(generatedJSON, generationPrompt, tmpl)
+	if err != nil {
+		return "", nil, err
+	}
+	log.Info().Int("fields", len(review.Fields)).Msg("Reviewing generated document")
+	if err := o.reviewer.Review(ctx, review); err != nil {
+		return "", nil, fmt.Errorf("review aborted: %w", err)
+	}
+	reviewed, err := review.document()
+	if err != nil {
+		return "", nil, err
+	}
+	log.Info().Strs("edited", review.summary.Edited).Strs("regenerated", review.summary.Regenerated).Msg("Review complete")
+	return reviewed, &review.summary, nil
+}
+
+// validateDocument normalizes a document and checks it against the template schema
+// and rules, returning the normalized document.
+func (o *Orchestrator) validateDocument(documentJSON string, tmpl *templates.Template) (string, error) {
+	schemaStr, err := json.Marshal(tmpl.Schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	documentJSON = o.normalize(documentJSON, string(schemaStr))
+	if err := o.validator.Validate(documentJSON, string(schemaStr)); err != nil {
+		return "", err
+	}
+	if err := o.validator.CheckRules(documentJSON, tmpl.Rules); err != nil {
+		return "", err
+	}
+	return documentJSON, nil
+}
+
+// appendOnce appends value to values unless it is already present.
+func appendOnce(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,110 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/templates"
+)
+
+// scriptedReviewer reviews documents with a fixed function.
+type scriptedReviewer func(ctx context.Context, review *Review) error
+
+func (f scriptedReviewer) Review(ctx context.Context, review *Review) error {
+	return f(ctx, review)
+}
+
+func setupReviewTest(t *testing.T, client *promptCapturingClient) (*Orchestrator, Options) {
+	t.Helper()
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "notes.md")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("# Notes\n\nThe ledger owns balances."), 0644))
+
+	tmpl := &templates.Template{
+		Name:   "review-template",
+		Prompt: "Generate the document",
+		Schema: json.RawMessage(`{"type":"object","properties":{
+			"title":{"type":"string"},
+			"summary":{"type":"string","x-dependsOn":["title"]}
+		},"required":["title","summary"]}`),
+		HTMLContent: `<html><body><!-- data-field="title" --><!-- data-field="summary" --></body></html>`,
+	}
+	orchestrator := NewOrchestrator(client)
+	require.NoError(t, orchestrator.registry.Register("review-template", tmpl))
+	return orchestrator, Options{
+		TemplateType: "review-template",
+		Sources:      []string{sourceFile},
+		OutputFile:   filepath.Join(tempDir, "out.html"),
+		APIKey:       "test-key",
+	}
+}
+
+func TestGenerate_Review(t *testing.T) {
+	client := &promptCapturingClient{responses: []string{
+		`{"title": "Ledger", "summary": "Too vague."}`,
+		`{"value": "The ledger service owns all balances."}`,
+	}}
+	orchestrator, opts := setupReviewTest(t, client)
+	orchestrator.SetReviewer(scriptedReviewer(func(ctx context.Context, review *Review) error {
+		assert.Equal(t, []string{"title", "summary"}, review.Fields)
+		assert.Equal(t, "Ledger", review.Value("title"))
+		review.Set("title", "Ledger Service")
+		value, err := review.Regenerate(ctx, "summary", "Name the owner of the balances")
+		require.NoError(t, err)
+		assert.Equal(t, "The ledger service owns all balances.", value)
+		return review.Validate()
+	}))
+
+	result, err := orchestrator.Run(context.Background(), opts)
+	require.NoError(t, err)
+
+	require.Len(t, client.prompts, 2)
+	assert.Contains(t, client.prompts[1], "Generate only the field 'summary'")
+	assert.Contains(t, client.prompts[1], "## Reviewer Feedback\n")
+	assert.Contains(t, client.prompts[1], `"Too vague."`)
+	assert.Contains(t, client.prompts[1], "Name the owner of the balances")
+	assert.Contains(t, client.prompts[1], `"title": "Ledger Service"`, "regeneration receives the reviewed dependencies")
+
+	assert.Equal(t, "Ledger Service", result.Fields["title"])
+	assert.Equal(t, "The ledger service owns all balances.", result.Fields["summary"])
+	require.NotNil(t, result.Report.Review)
+	assert.Equal(t, []string{"title"}, result.Report.Review.Edited)
+	assert.Equal(t, []string{"summary"}, result.Report.Review.Regenerated)
+}
+
+func TestGenerate_ReviewAbortedOrInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		review func(ctx context.Context, review *Review) error
+		want   string
+	}{
+		"aborted": {
+			review: func(ctx context.Context, review *Review) error { return errors.New("quit") },
+			want:   "review aborted: quit",
+		},
+		"invalid": {
+			review: func(ctx context.Context, review *Review) error {
+				review.Set("title", 42)
+				return nil
+			},
+			want: "reviewed document is invalid",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := &promptCapturingClient{responses: []string{`{"title": "Ledger", "summary": "The ledger owns balances."}`}}
+			orchestrator, opts := setupReviewTest(t, client)
+			orchestrator.SetReviewer(scriptedReviewer(tc.review))
+
+			_, err := orchestrator.Run(context.Background(), opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+			assert.NoFileExists(t, opts.OutputFile, "nothing is written")
+		})
+	}
+}
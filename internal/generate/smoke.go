@@ -0,0 +1,148 @@
+package generate
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/karolswdev/docloom/internal/ai"
+)
+
+// smokeFS holds the tiny template and sample sources of the smoke test.
+//
+//go:embed smoke
+var smokeFS embed.FS
+
+// smokeTemplate is the name of the smoke test template.
+const smokeTemplate = "smoke-test"
+
+// Responses of the mock model: the first lacks the required components, so the
+// document only validates after a repair.
+const (
+	smokeInvalidResponse = `{"title": "Lantern", "summary": "Lantern turns sensor readings into alerts."}`
+	smokeValidResponse   = `{"title": "Lantern", "summary": "Lantern turns sensor readings into alerts.", "components": ["Collector", "Notifier"]}`
+)
+
+// SmokeCheck is the outcome of one stage of the smoke test.
+type SmokeCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// SmokeResult is the outcome of the smoke test.
+type SmokeResult struct {
+	Checks   []SmokeCheck
+	Duration time.Duration
+}
+
+// Passed reports whether every check passed.
+func (r *SmokeResult) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return len(r.Checks) > 0
+}
+
+// add records a check.
+func (r *SmokeResult) add(name string, passed bool, detail string, args ...interface{}) {
+	r.Checks = append(r.Checks, SmokeCheck{Name: name, Passed: passed, Detail: fmt.Sprintf(detail, args...)})
+}
+
+// Smoke runs a complete generation with a mock model, a built-in tiny template and
+// bundled sample sources in a temporary directory, checking that template loading,
+// ingestion, prompting, validation, repair and rendering work on this machine. An
+// error means the test could not be set up; failed stages are reported as checks.
+func Smoke(ctx context.Context) (*SmokeResult, error) {
+	start := time.Now()
+	dir, err := os.MkdirTemp("", "docloom-smoke-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create smoke test directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	if err := extractSmokeFiles(dir); err != nil {
+		return nil, err
+	}
+
+	result := &SmokeResult{}
+	defer func() { result.Duration = time.Since(start) }()
+
+	client := ai.NewMockClient(smokeInvalidResponse, smokeValidResponse)
+	orchestrator := NewOrchestrator(client)
+	if err := orchestrator.LoadTemplates(filepath.Join(dir, "templates")); err != nil {
+		result.add("templates", false, "%v", err)
+		return result, nil
+	}
+	result.add("templates", true, "loaded %s", smokeTemplate)
+
+	sourceDir := filepath.Join(dir, "sources")
+	outputFile := filepath.Join(dir, "out", "smoke.html")
+	run, err := orchestrator.Run(ctx, Options{
+		TemplateType: smokeTemplate,
+		Sources:      []string{sourceDir},
+		OutputFile:   outputFile,
+		Model:        "mock",
+		APIKey:       "mock",
+		MaxRepairs:   1,
+		SourceErrors: "fail",
+	})
+	prompts := client.Prompts()
+	if err != nil {
+		result.add("generation", false, "%v", err)
+		return result, nil
+	}
+
+	sourceFiles := len(run.Report.SourceFiles)
+	result.add("ingestion", sourceFiles == 2, "%d of 2 sample files (Markdown and HTML)", sourceFiles)
+
+	// The HTML source's script must have been stripped
+	prompt := ""
+	if len(prompts) > 0 {
+		prompt = prompts[0]
+	}
+	promptOK := strings.Contains(prompt, "Collector") && strings.Contains(prompt, "Beacon message queue") &&
+		strings.Contains(prompt, "names of its components") && !strings.Contains(prompt, "track()")
+	result.add("prompting", promptOK, "sources and instructions in a %d-byte prompt", len(prompt))
+
+	components, _ := run.Fields["components"].([]interface{})
+	repaired := len(prompts) == 2 && len(components) == 2
+	result.add("validation and repair", repaired, "invalid response repaired in %d request(s)", len(prompts))
+
+	html, err := os.ReadFile(run.OutputFile) // #nosec G304 -- written by this run
+	if err != nil {
+		result.add("rendering", false, "%v", err)
+		return result, nil
+	}
+	_, sidecarErr := os.Stat(run.JSONFile)
+	rendered := strings.Contains(string(html), "<li>Notifier</li>") && !strings.Contains(string(html), "data-field") && sidecarErr == nil
+	result.add("rendering", rendered, "HTML and JSON sidecar written (%d bytes)", len(html))
+	return result, nil
+}
+
+// extractSmokeFiles copies the smoke test template and sources into dir.
+func extractSmokeFiles(dir string) error {
+	return fs.WalkDir(smokeFS, "smoke", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, "smoke")))
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := smokeFS.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0600); err != nil {
+			return fmt.Errorf("failed to write smoke test file: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,33 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmoke(t *testing.T) {
+	result, err := Smoke(context.Background())
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Checks))
+	for _, check := range result.Checks {
+		names = append(names, check.Name)
+		assert.True(t, check.Passed, "%s: %s", check.Name, check.Detail)
+	}
+	assert.Equal(t, []string{"templates", "ingestion", "prompting", "validation and repair", "rendering"}, names)
+	assert.True(t, result.Passed())
+	assert.Positive(t, result.Duration)
+}
+
+func TestSmokeResult_Passed(t *testing.T) {
+	assert.False(t, (&SmokeResult{}).Passed())
+	result := &SmokeResult{}
+	result.add("ingestion", true, "ok")
+	assert.True(t, result.Passed())
+	result.add("rendering", false, "missing %s", "sidecar")
+	assert.False(t, result.Passed())
+	assert.Equal(t, "missing sidecar", result.Checks[1].Detail)
+}
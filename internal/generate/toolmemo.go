@@ -0,0 +1,121 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/agent"
+)
+
+// Notes prefixed to memoized tool results, outside the untrusted data block.
+const (
+	repeatedToolCallNote = "Note: this tool was already called with the same arguments in this analysis; the earlier output is returned again.\n"
+	cachedToolCallNote   = "Note: this output was cached by an earlier analysis of the same sources.\n"
+)
+
+// toolMemo memoizes the outputs of an analysis run's tool calls by tool and
+// arguments, so repeated calls are answered without running the tool again. With a
+// cache, outputs are also reused across runs over unchanged sources.
+type toolMemo struct {
+	results map[string]string
+	cache   *agent.ToolResultCache // nil when outputs are kept within the run only
+	scope   string                 // Agent, tool definitions and source fingerprint
+}
+
+// newToolMemo creates the memo of an analysis run. Outputs are cached across runs
+// only when the sources can be fingerprinted.
+func newToolMemo(agentDef *agent.Definition, opts AnalysisOptions) *toolMemo {
+	memo := &toolMemo{results: make(map[string]string)}
+	if opts.ToolCache == nil {
+		return memo
+	}
+	fingerprint, err := sourceFingerprint(opts.SourcePath)
+	if err != nil {
+		log.Debug().Err(err).Msg("Sources cannot be fingerprinted; tool results are not cached across runs")
+		return memo
+	}
+	tools, err := json.Marshal(agentDef.Spec.Tools)
+	if err != nil {
+		return memo
+	}
+	memo.cache = opts.ToolCache
+	memo.scope = fmt.Sprintf("%s\x00%s\x00%s", agentDef.Metadata.Name, tools, fingerprint)
+	return memo
+}
+
+// key returns the key of a call to tool with args. Arguments are encoded with sorted
+// keys, so their order does not matter.
+func (m *toolMemo) key(tool string, args map[string]string) string {
+	encoded, _ := json.Marshal(args)
+	hash := sha256.New()
+	hash.Write([]byte(m.scope))
+	hash.Write([]byte{0})
+	hash.Write([]byte(tool))
+	hash.Write([]byte{0})
+	hash.Write(encoded)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// lookup returns the memoized output of a call and the note to send with it.
+func (m *toolMemo) lookup(key string) (output string, note string, ok bool) {
+	if output, ok := m.results[key]; ok {
+		return output, repeatedToolCallNote, true
+	}
+	if m.cache != nil {
+		if output, ok := m.cache.Get(key); ok {
+			m.results[key] = output
+			return output, cachedToolCallNote, true
+		}
+	}
+	return "", "", false
+}
+
+// store memoizes the output of a successful call.
+func (m *toolMemo) store(key, output string) {
+	m.results[key] = output
+	if m.cache != nil {
+		if err := m.cache.Put(key, output); err != nil {
+			log.Warn().Err(err).Msg("Failed to cache tool result")
+		}
+	}
+}
+
+// sourceFingerprint hashes the path, size and modification time of every file
+// below root, except version control metadata, so it changes when the sources do.
+func sourceFingerprint(root string) (string, error) {
+	if root == "" {
+		return "", fmt.Errorf("no source path")
+	}
+	hash := sha256.New()
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", filepath.ToSlash(rel), info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+package generate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/agent"
+	"github.com/karolswdev/docloom/internal/ai"
+)
+
+const countingAgentYAML = `apiVersion: docloom.io/v1alpha1
+kind: Agent
+metadata:
+  name: counting-agent
+  description: Agent whose tool records every run
+spec:
+  tools:
+    - name: list_projects
+      description: Lists projects
+      command: sh
+      args: ["-c", "echo run >> \"$TOOL_RUNS\"; echo Payments.csproj"]
+`
+
+func TestExecuteSingleTool_MemoizesRepeatedCalls(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "counting.agent.yaml"), []byte(countingAgentYAML), 0644))
+	runs := filepath.Join(t.TempDir(), "runs.txt")
+	t.Setenv("TOOL_RUNS", runs)
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "Payments.csproj"), []byte("<Project/>"), 0644))
+
+	orchestrator := NewOrchestrator(&promptCapturingClient{})
+	orchestrator.agentRegistry = agent.NewRegistry()
+	orchestrator.agentRegistry.AddSearchPath(dir)
+	require.NoError(t, orchestrator.agentRegistry.Discover())
+	agentDef, exists := orchestrator.agentRegistry.Get("counting-agent")
+	require.True(t, exists)
+	t.Setenv("TMPDIR", t.TempDir())
+	artifacts, err := agent.NewArtifactCache()
+	require.NoError(t, err)
+	orchestrator.agentExecutor = agent.NewExecutor(orchestrator.agentRegistry, artifacts, zerolog.Nop())
+	cache := artifacts.ToolResults()
+
+	newRun := func() AnalysisOptions {
+		opts := AnalysisOptions{AgentName: "counting-agent", SourcePath: sourceDir, ToolCache: cache}
+		opts.memo = newToolMemo(agentDef, opts)
+		return opts
+	}
+	call := func(opts AnalysisOptions, arguments string) string {
+		var messages []ai.ChatMessage
+		require.NoError(t, orchestrator.executeSingleTool(ai.ToolCall{ID: "1", Name: "list_projects", Arguments: json.RawMessage(arguments)}, &messages, opts))
+		require.Len(t, messages, 1)
+		return messages[0].Content
+	}
+	toolRuns := func() int {
+		data, err := os.ReadFile(runs)
+		require.NoError(t, err)
+		return strings.Count(string(data), "run")
+	}
+
+	opts := newRun()
+	first := call(opts, `{"depth": 1, "filter": "csproj"}`)
+	assert.Contains(t, first, "Payments.csproj")
+	assert.NotContains(t, first, "Note:")
+
+	repeated := call(opts, `{"filter": "csproj", "depth": 1}`)
+	assert.True(t, strings.HasPrefix(repeated, repeatedToolCallNote), "argument order does not matter")
+	assert.Contains(t, repeated, "Payments.csproj")
+	assert.Equal(t, 1, toolRuns())
+
+	call(opts, `{"depth": 2, "filter": "csproj"}`)
+	assert.Equal(t, 2, toolRuns(), "different arguments run the tool")
+
+	// A later run over unchanged sources reuses the cached output
+	cached := call(newRun(), `{"depth": 1, "filter": "csproj"}`)
+	assert.True(t, strings.HasPrefix(cached, cachedToolCallNote))
+	assert.Equal(t, 2, toolRuns())
+
+	// Changed sources invalidate the cache
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(sourceDir, "Payments.csproj"), later, later))
+	call(newRun(), `{"depth": 1, "filter": "csproj"}`)
+	assert.Equal(t, 3, toolRuns())
+}
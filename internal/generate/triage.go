@@ -0,0 +1,265 @@
+package generate
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/version"
+)
+
+// TriageFormat identifies the layout of triage bundles.
+const TriageFormat = "docloom-triage/v1"
+
+// triageFiles are the files of every triage bundle, in archive order. Files with
+// nothing recorded are written empty so the layout does not depend on where a run
+// failed.
+var triageFiles = []string{
+	"manifest.json",
+	"error.txt",
+	"prompt.md",
+	"response.txt",
+	"validation.json",
+	"agent.log",
+	"config.json",
+}
+
+// redacted replaces secrets in triage bundles.
+const redacted = "[REDACTED]"
+
+// secretPatterns match credentials that may appear in prompts, responses and logs.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\b(?:ghp|gho|ghs|ghu|github_pat|glpat|xox[abprs])[-_][A-Za-z0-9_-]{10,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]{8,}=*`),
+	regexp.MustCompile(`(?i)\b((?:api[_-]?key|secret|token|password|passwd)["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+}
+
+// ValidationFailure is a validation error recorded for a triage bundle.
+type ValidationFailure struct {
+	Attempt int    `json:"attempt"`
+	Error   string `json:"error"`
+}
+
+// TriageManifest is the manifest.json of a triage bundle.
+type TriageManifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	Format    string    `json:"format"`
+	Version   string    `json:"docloom_version"`
+	Error     string    `json:"error"`
+	Files     []string  `json:"files"`
+}
+
+// Triage collects what a run leaves for diagnosing a failure: the last prompt sent to
+// the model and its response, the validation errors, the output of research agents
+// and a snapshot of the configuration. Pass it in Options.Triage and write it with
+// Write when the run fails. Secrets are redacted when the bundle is written.
+type Triage struct {
+	mu         sync.Mutex
+	prompt     string
+	response   string
+	validation []ValidationFailure
+	agentLog   bytes.Buffer
+	options    interface{}
+	config     interface{}
+	secrets    []string
+}
+
+// NewTriage creates an empty triage collector.
+func NewTriage() *Triage {
+	return &Triage{}
+}
+
+// AddSecrets registers values, such as API keys, that must not appear in the bundle.
+func (t *Triage) AddSecrets(values ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, value := range values {
+		if len(value) >= 4 {
+			t.secrets = append(t.secrets, value)
+		}
+	}
+}
+
+// SetConfig records the configuration of the run. Pass a copy with secrets removed,
+// such as config.Config.Redacted.
+func (t *Triage) SetConfig(cfg interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = cfg
+}
+
+// AgentLog returns a writer collecting research agent output, for
+// agent.Executor.SetTranscript.
+func (t *Triage) AgentLog() io.Writer {
+	return triageLogWriter{t}
+}
+
+// triageLogWriter appends to a triage's agent log.
+type triageLogWriter struct{ t *Triage }
+
+func (w triageLogWriter) Write(p []byte) (int, error) {
+	w.t.mu.Lock()
+	defer w.t.mu.Unlock()
+	return w.t.agentLog.Write(p)
+}
+
+// triageKey is the context key of the run's triage collector.
+type triageKey struct{}
+
+// withTriage returns a context carrying t, so model calls and validation deep in the
+// run can record into it.
+func withTriage(ctx context.Context, t *Triage) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, triageKey{}, t)
+}
+
+// triageFrom returns the run's triage collector, or nil when there is none. Its
+// record methods accept a nil receiver.
+func triageFrom(ctx context.Context) *Triage {
+	t, _ := ctx.Value(triageKey{}).(*Triage)
+	return t
+}
+
+// recordOptions records the run options, without the API key.
+func (t *Triage) recordOptions(opts Options) {
+	if t == nil {
+		return
+	}
+	t.AddSecrets(opts.APIKey)
+	if opts.APIKey != "" {
+		opts.APIKey = redacted
+	}
+	opts.Triage = nil
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.options = opts
+}
+
+// recordPrompt records a prompt sent to the model.
+func (t *Triage) recordPrompt(prompt string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prompt = prompt
+}
+
+// recordResponse records a model response.
+func (t *Triage) recordResponse(response string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.response = response
+}
+
+// recordValidation records a failed validation of generated JSON.
+func (t *Triage) recordValidation(attempt int, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.validation = append(t.validation, ValidationFailure{Attempt: attempt, Error: err.Error()})
+}
+
+// Write saves a triage bundle for the failed run to a zip file in dir and returns
+// its path.
+func (t *Triage) Write(dir string, runErr error) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	errorText := ""
+	if runErr != nil {
+		errorText = t.redact(runErr.Error())
+	}
+	validation := make([]ValidationFailure, len(t.validation))
+	for idx, failure := range t.validation {
+		validation[idx] = ValidationFailure{Attempt: failure.Attempt, Error: t.redact(failure.Error)}
+	}
+	manifest := TriageManifest{
+		CreatedAt: time.Now().UTC(),
+		Format:    TriageFormat,
+		Version:   version.Version,
+		Error:     errorText,
+		Files:     triageFiles,
+	}
+	snapshot := struct {
+		Options interface{} `json:"options"`
+		Config  interface{} `json:"config"`
+	}{t.options, t.config}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal triage manifest: %w", err)
+	}
+	validationJSON, err := json.MarshalIndent(validation, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal validation errors: %w", err)
+	}
+	configJSON, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal configuration snapshot: %w", err)
+	}
+	contents := map[string]string{
+		"manifest.json":   string(manifestJSON),
+		"error.txt":       errorText,
+		"prompt.md":       t.redact(t.prompt),
+		"response.txt":    t.redact(t.response),
+		"validation.json": string(validationJSON),
+		"agent.log":       t.redact(t.agentLog.String()),
+		"config.json":     t.redact(string(configJSON)),
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range triageFiles {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: manifest.CreatedAt})
+		if err != nil {
+			return "", fmt.Errorf("failed to add %s to triage bundle: %w", name, err)
+		}
+		if _, err := io.WriteString(w, contents[name]); err != nil {
+			return "", fmt.Errorf("failed to add %s to triage bundle: %w", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return "", fmt.Errorf("failed to write triage bundle: %w", err)
+	}
+
+	path := filepath.Join(dir, "docloom-triage-"+newRunID()+".zip")
+	if err := render.WriteFileAtomic(path, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to write triage bundle: %w", err)
+	}
+	return path, nil
+}
+
+// redact removes registered secrets and text that looks like credentials.
+func (t *Triage) redact(text string) string {
+	for _, secret := range t.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	for _, pattern := range secretPatterns {
+		if pattern.NumSubexp() > 0 {
+			text = pattern.ReplaceAllString(text, "${1}"+redacted)
+		} else {
+			text = pattern.ReplaceAllString(text, redacted)
+		}
+	}
+	return text
+}
@@ -0,0 +1,44 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/karolswdev/docloom/internal/ai"
+)
+
+// UsageRecord is a run's entry in a usage log.
+type UsageRecord struct {
+	Time     time.Time `json:"time"`
+	RunID    string    `json:"run_id,omitempty"` // Empty when the run failed before its report
+	Template string    `json:"template"`
+	Output   string    `json:"output"`
+	Error    string    `json:"error,omitempty"`
+
+	ai.UsageSummary
+}
+
+// AppendUsageLog appends record as a JSON line to the usage log at path, creating
+// the file and its directory if necessary.
+func AppendUsageLog(path string, record UsageRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create usage log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 -- the usage log path is user-provided
+	if err != nil {
+		return fmt.Errorf("failed to open usage log: %w", err)
+	}
+	defer file.Close()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage record: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write usage record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package generate
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/ai"
+)
+
+func TestAppendUsageLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "usage.jsonl")
+	summary := ai.UsageSummary{
+		Models: []ai.ModelUsage{{Model: "gpt-4o", Usage: ai.Usage{PromptTokens: 1000, CompletionTokens: 100, Requests: 2}, Cost: 0.0035, Priced: true}},
+		Total:  ai.Usage{PromptTokens: 1000, CompletionTokens: 100, Requests: 2},
+		Cost:   0.0035,
+	}
+	require.NoError(t, AppendUsageLog(path, UsageRecord{Time: time.Now(), RunID: "run-1", Template: "architecture-vision", Output: "a.html", UsageSummary: summary}))
+	require.NoError(t, AppendUsageLog(path, UsageRecord{Time: time.Now(), Template: "architecture-vision", Output: "b.html", Error: "budget exceeded", UsageSummary: summary}))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "run-1", records[0]["run_id"])
+	assert.InDelta(t, 0.0035, records[0]["cost_usd"], 1e-9, "the summary is inlined")
+	assert.Equal(t, "budget exceeded", records[1]["error"])
+}
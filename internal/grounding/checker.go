@@ -0,0 +1,223 @@
+// Package grounding checks generated content for supporting evidence in the ingested sources.
+package grounding
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/karolswdev/docloom/internal/chunk"
+	"github.com/karolswdev/docloom/internal/tokens"
+)
+
+// Grounding modes accepted by the generation workflow.
+const (
+	ModeOff    = "off"
+	ModeWarn   = "warn"   // Report unsupported claims
+	ModeStrict = "strict" // Fail the run when unsupported claims are found
+)
+
+const (
+	// DefaultMaxClaims limits how many claims are sampled from a document.
+	DefaultMaxClaims = 50
+	// DefaultThreshold is the minimum support score for a claim to count as grounded.
+	DefaultThreshold = 0.5
+
+	minClaimTerms  = 4   // Sentences with fewer content words are not treated as claims
+	chunkMaxTokens = 200 // Size of the evidence windows searched for support
+)
+
+// Claim is a factual statement sampled from a generated field.
+type Claim struct {
+	Field string
+	Text  string
+}
+
+// Finding records the evidence found for a claim.
+type Finding struct {
+	Field      string  `json:"field"`
+	Claim      string  `json:"claim"`
+	Evidence   string  `json:"evidence,omitempty"` // Best matching source excerpt
+	Support    float64 `json:"support"`            // Share of the claim's terms found in the evidence
+	Confidence float64 `json:"confidence"`         // Confidence that the claim is hallucinated
+}
+
+// Report summarizes a groundedness check.
+type Report struct {
+	Mode        string    `json:"mode"`
+	Unsupported []Finding `json:"unsupported,omitempty"`
+	Checked     int       `json:"checked"`
+	Score       float64   `json:"score"` // Average support over checked claims
+	Threshold   float64   `json:"threshold"`
+}
+
+// ValidateMode checks that a grounding mode is known.
+func ValidateMode(mode string) error {
+	switch mode {
+	case "", ModeOff, ModeWarn, ModeStrict:
+		return nil
+	default:
+		return fmt.Errorf("unknown grounding mode %q (use off, warn or strict)", mode)
+	}
+}
+
+var (
+	sentencePattern = regexp.MustCompile(`[^.!?\n]+[.!?]?`)
+	stopWords       = map[string]bool{
+		"the": true, "and": true, "for": true, "are": true, "was": true, "were": true, "with": true,
+		"that": true, "this": true, "these": true, "those": true, "from": true, "into": true, "its": true,
+		"has": true, "have": true, "had": true, "will": true, "can": true, "not": true, "but": true,
+		"all": true, "any": true, "our": true, "their": true, "which": true, "while": true, "also": true,
+		"been": true, "being": true, "such": true, "each": true, "more": true, "most": true, "other": true,
+		"should": true, "would": true, "could": true, "may": true, "must": true, "than": true, "then": true,
+	}
+)
+
+// Check samples claims from the generated fields and looks for supporting evidence
+// in the source content using term overlap against paragraph chunks, whose size is
+// counted with tokenizer.
+func Check(fields map[string]interface{}, sourceContent string, maxClaims int, threshold float64, tokenizer *tokens.Counter) Report {
+	report := Report{Threshold: threshold}
+
+	claims := SampleClaims(ExtractClaims(fields), maxClaims)
+	if len(claims) == 0 {
+		report.Score = 1
+		return report
+	}
+
+	chunks := chunk.NewChunker(chunkMaxTokens, tokenizer).ChunkByParagraphs(sourceContent)
+	chunkTerms := make([]map[string]bool, len(chunks))
+	for i, c := range chunks {
+		chunkTerms[i] = termSet(c)
+	}
+
+	var totalSupport float64
+	for _, claim := range claims {
+		finding := checkClaim(claim, chunks, chunkTerms)
+		totalSupport += finding.Support
+		if finding.Support < threshold {
+			report.Unsupported = append(report.Unsupported, finding)
+		}
+	}
+
+	report.Checked = len(claims)
+	report.Score = totalSupport / float64(len(claims))
+	return report
+}
+
+// checkClaim finds the chunk with the highest term overlap for a claim.
+func checkClaim(claim Claim, chunks []string, chunkTerms []map[string]bool) Finding {
+	finding := Finding{Field: claim.Field, Claim: claim.Text, Confidence: 1}
+	terms := claimTerms(claim.Text)
+
+	for i, set := range chunkTerms {
+		found := 0
+		for _, term := range terms {
+			if set[term] {
+				found++
+			}
+		}
+		support := float64(found) / float64(len(terms))
+		if support > finding.Support {
+			finding.Support = support
+			finding.Evidence = excerpt(chunks[i], 200)
+		}
+	}
+	finding.Confidence = 1 - finding.Support
+	return finding
+}
+
+// ExtractClaims splits every string value of the generated fields into sentences and
+// keeps those with enough content words to be checked.
+func ExtractClaims(fields map[string]interface{}) []Claim {
+	var claims []Claim
+	walkStrings("", fields, func(path, value string) {
+		for _, sentence := range sentencePattern.FindAllString(value, -1) {
+			sentence = strings.TrimSpace(sentence)
+			if len(claimTerms(sentence)) >= minClaimTerms {
+				claims = append(claims, Claim{Field: path, Text: sentence})
+			}
+		}
+	})
+	return claims
+}
+
+// SampleClaims selects up to max claims spread evenly across the document.
+func SampleClaims(claims []Claim, max int) []Claim {
+	if max <= 0 || len(claims) <= max {
+		return claims
+	}
+	sampled := make([]Claim, 0, max)
+	step := float64(len(claims)) / float64(max)
+	for i := 0; i < max; i++ {
+		sampled = append(sampled, claims[int(float64(i)*step)])
+	}
+	return sampled
+}
+
+// walkStrings visits string values in sorted key order.
+func walkStrings(prefix string, value interface{}, visit func(path, value string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			walkStrings(path, v[key], visit)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkStrings(fmt.Sprintf("%s[%d]", prefix, i), item, visit)
+		}
+	case string:
+		visit(prefix, v)
+	}
+}
+
+// claimTerms returns the distinct content words of a claim.
+func claimTerms(text string) []string {
+	set := termSet(text)
+	terms := make([]string, 0, len(set))
+	for term := range set {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// termSet tokenizes text into lowercase content words (numbers are always kept).
+func termSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		isNumber := strings.IndexFunc(word, unicode.IsDigit) >= 0
+		if (len(word) < 3 && !isNumber) || stopWords[word] {
+			continue
+		}
+		set[word] = true
+	}
+	return set
+}
+
+// excerpt shortens text to at most max characters on a word boundary.
+func excerpt(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= max {
+		return text
+	}
+	cut := strings.LastIndex(text[:max], " ")
+	if cut <= 0 {
+		cut = max
+	}
+	return text[:cut] + "..."
+}
@@ -0,0 +1,67 @@
+package grounding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/tokens"
+)
+
+const sources = `--- File: docs/ledger.md ---
+The ledger service owns all account balances and publishes balance events to Kafka.
+
+Payments are settled nightly by the settlement batch job running in the EU region.`
+
+func TestCheck_FlagsUnsupportedClaims(t *testing.T) {
+	fields := map[string]interface{}{
+		"title": "Payments",
+		"overview": map[string]interface{}{
+			"ledger": "The ledger service owns account balances and publishes balance events to Kafka.",
+			"risks": []interface{}{
+				"Quantum encryption protects every mainframe transaction since 1987.",
+			},
+		},
+	}
+
+	report := Check(fields, sources, DefaultMaxClaims, DefaultThreshold, tokens.ForModel(""))
+	assert.Equal(t, 2, report.Checked)
+	require.Len(t, report.Unsupported, 1)
+
+	finding := report.Unsupported[0]
+	assert.Equal(t, "overview.risks[0]", finding.Field)
+	assert.Contains(t, finding.Claim, "Quantum encryption")
+	assert.Greater(t, finding.Confidence, 0.5)
+	assert.Greater(t, report.Score, 0.4)
+	assert.Less(t, report.Score, 1.0)
+}
+
+func TestExtractClaims_SkipsShortValues(t *testing.T) {
+	claims := ExtractClaims(map[string]interface{}{
+		"title":  "Payments Platform",
+		"status": "Draft",
+		"body":   "Settlement runs nightly in the EU region. Short one.",
+	})
+	require.Len(t, claims, 1)
+	assert.Equal(t, "body", claims[0].Field)
+	assert.Equal(t, "Settlement runs nightly in the EU region.", claims[0].Text)
+}
+
+func TestSampleClaims_SpreadsAcrossDocument(t *testing.T) {
+	claims := make([]Claim, 10)
+	for i := range claims {
+		claims[i] = Claim{Field: fmt.Sprintf("f%d", i)}
+	}
+	sampled := SampleClaims(claims, 5)
+	require.Len(t, sampled, 5)
+	assert.Equal(t, "f0", sampled[0].Field)
+	assert.Equal(t, "f8", sampled[4].Field)
+}
+
+func TestValidateMode(t *testing.T) {
+	assert.NoError(t, ValidateMode(""))
+	assert.NoError(t, ValidateMode(ModeStrict))
+	assert.Error(t, ValidateMode("paranoid"))
+}
@@ -0,0 +1,441 @@
+// Package htmltext converts HTML to text that keeps the structure of the page:
+// headings, lists, tables, preformatted blocks and paragraphs.
+package htmltext
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style selects how the structure of a page is written.
+type Style int
+
+const (
+	// Markdown writes Markdown-like text for prompts: headings become # lines, list
+	// items - lines, preformatted blocks ``` fences and inline code `code`.
+	Markdown Style = iota
+
+	// Plain writes text for readers: h1 and h2 headings are underlined (= and -),
+	// list items are indented by nesting and numbered in ordered lists, and links are
+	// followed by their URL.
+	Plain
+)
+
+// Options configures a conversion.
+type Options struct {
+	Style Style
+
+	// Skip reports whether an element is left out with its content; nil keeps all
+	// elements.
+	Skip func(name string, attrs map[string]string) bool
+}
+
+var (
+	// rawTextElements hold text that is not markup, so it is skipped up to the closing tag
+	rawTextElements = map[string]bool{"script": true, "style": true, "textarea": true}
+
+	// voidElements have no closing tag
+	voidElements = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+	}
+
+	// blockElements start a new paragraph
+	blockElements = map[string]bool{
+		"p": true, "div": true, "section": true, "article": true, "main": true, "header": true,
+		"footer": true, "nav": true, "aside": true, "ul": true, "ol": true, "table": true, "tr": true,
+		"blockquote": true, "dl": true, "dt": true, "dd": true, "figure": true, "figcaption": true,
+		"details": true, "summary": true, "address": true,
+	}
+
+	// attributePattern matches the attributes of a start tag
+	attributePattern = regexp.MustCompile(`([A-Za-z_:][-A-Za-z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+	// blankLinesPattern matches runs of blank lines
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// Convert returns the text of page. Whitespace is collapsed outside <pre> elements,
+// table cells are separated by |, images are replaced by their alt text and comments
+// are dropped. Trailing spaces and runs of blank lines are removed, and the text ends
+// with a newline.
+func Convert(page string, opts Options) string {
+	c := &converter{opts: opts}
+	c.convert(page)
+
+	lines := strings.Split(c.out.String(), "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(line, " \t")
+	}
+	text := blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
+
+// list is a list being converted; ordered lists number their items.
+type list struct {
+	ordered bool
+	next    int
+}
+
+// converter writes the text of HTML markup.
+type converter struct {
+	opts      Options
+	out       strings.Builder
+	skip      string // Name of the element being left out
+	depth     int    // Nesting of elements named skip, while skipping
+	pre       int    // Nesting of <pre> elements, whose whitespace is kept
+	lists     []list
+	cells     int    // Cells written in the current table row
+	heading   int    // Level of the heading being written, 0 outside headings
+	headStart int    // Output offset where the heading starts
+	href      string // Target of the link being written
+	linkStart int    // Output offset where the link text starts
+	pendingWS bool   // Whitespace was seen after the last text
+}
+
+// skipped reports whether the options leave an element out.
+func (c *converter) skipped(name string, attrs map[string]string) bool {
+	return c.opts.Skip != nil && c.opts.Skip(name, attrs)
+}
+
+// convert writes the text of page.
+func (c *converter) convert(page string) {
+	for len(page) > 0 {
+		lt := strings.IndexByte(page, '<')
+		if lt < 0 {
+			c.text(page)
+			return
+		}
+		c.text(page[:lt])
+		page = page[lt:]
+
+		switch {
+		case strings.HasPrefix(page, "<!--"):
+			end := strings.Index(page, "-->")
+			if end < 0 {
+				return
+			}
+			page = page[end+3:]
+		case strings.HasPrefix(page, "<!") || strings.HasPrefix(page, "<?"):
+			end := strings.IndexByte(page, '>')
+			if end < 0 {
+				return
+			}
+			page = page[end+1:]
+		default:
+			end := TagEnd(page)
+			if end < 0 {
+				c.text(page)
+				return
+			}
+			tag := page[1:end]
+			name, closing, attrs := parseTag(tag)
+			if name == "" {
+				// A < that starts no tag, as in "a < b"
+				c.text("<")
+				page = page[1:]
+				continue
+			}
+			page = page[end+1:]
+			if closing {
+				c.endTag(name)
+				continue
+			}
+			if rawTextElements[name] {
+				closeIdx := strings.Index(strings.ToLower(page), "</"+name)
+				raw := page
+				if closeIdx >= 0 {
+					raw, page = page[:closeIdx], page[closeIdx:]
+				} else {
+					page = ""
+				}
+				if c.skip == "" && !c.skipped(name, attrs) {
+					c.text(raw)
+				}
+				continue
+			}
+			c.startTag(name, attrs, strings.HasSuffix(tag, "/"))
+		}
+	}
+}
+
+// TagEnd returns the index of the > ending the tag at the start of page, skipping
+// quoted attribute values, or -1 when the tag is not closed.
+func TagEnd(page string) int {
+	var quote byte
+	for idx := 1; idx < len(page); idx++ {
+		switch ch := page[idx]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '>':
+			return idx
+		}
+	}
+	return -1
+}
+
+// TagName returns the lower-cased name of the tag with content tag (between < and >)
+// and whether it is a closing tag, or an empty name when tag is no tag, as the "< b"
+// of "a < b".
+func TagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	end := strings.IndexAny(tag, " \t\r\n/")
+	if end < 0 {
+		end = len(tag)
+	}
+	name := strings.ToLower(tag[:end])
+	if name == "" {
+		return "", false
+	}
+	for _, ch := range name {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') && ch != '-' {
+			return "", false
+		}
+	}
+	return name, closing
+}
+
+// Attribute is an attribute of a start tag.
+type Attribute struct {
+	Name  string // Lower-cased
+	Value string // Unescaped
+}
+
+// Attributes returns the attributes of the tag with content tag, in order.
+func Attributes(tag string) []Attribute {
+	var attrs []Attribute
+	for _, match := range attributePattern.FindAllStringSubmatch(tag, -1) {
+		attrs = append(attrs, Attribute{Name: strings.ToLower(match[1]), Value: html.UnescapeString(match[2] + match[3] + match[4])})
+	}
+	return attrs
+}
+
+// IsVoid reports whether elements named name have no closing tag.
+func IsVoid(name string) bool {
+	return voidElements[name]
+}
+
+// parseTag returns the name of the tag with content tag, whether it is a closing tag
+// and, for start tags, its attributes.
+func parseTag(tag string) (name string, closing bool, attrs map[string]string) {
+	name, closing = TagName(tag)
+	if name == "" || closing {
+		return name, closing, nil
+	}
+	attrs = make(map[string]string)
+	for _, attr := range Attributes(tag) {
+		attrs[attr.Name] = attr.Value
+	}
+	return name, false, attrs
+}
+
+// startTag handles the start tag of an element; a self-closing tag such as <svg/> has
+// no content.
+func (c *converter) startTag(name string, attrs map[string]string, selfClosing bool) {
+	empty := selfClosing || voidElements[name]
+	if c.skip != "" {
+		if name == c.skip && !empty {
+			c.depth++
+		}
+		return
+	}
+	if c.skipped(name, attrs) {
+		if !empty {
+			c.skip, c.depth = name, 1
+		}
+		return
+	}
+	markdown := c.opts.Style == Markdown
+
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.newlines(2)
+		c.heading = int(name[1] - '0')
+		if markdown {
+			c.out.WriteString(strings.Repeat("#", c.heading) + " ")
+		}
+		c.headStart = c.out.Len()
+	case "ul", "ol":
+		if len(c.lists) == 0 {
+			c.newlines(2)
+		}
+		c.lists = append(c.lists, list{ordered: name == "ol", next: 1})
+	case "li":
+		c.newlines(1)
+		marker := "- "
+		if len(c.lists) > 0 && !markdown {
+			c.out.WriteString(strings.Repeat("  ", len(c.lists)-1))
+			if list := &c.lists[len(c.lists)-1]; list.ordered {
+				marker = strconv.Itoa(list.next) + ". "
+				list.next++
+			}
+		}
+		c.out.WriteString(marker)
+	case "br":
+		c.newlines(1)
+	case "hr":
+		c.newlines(2)
+		if markdown {
+			c.out.WriteString("---")
+		} else {
+			c.out.WriteString("----")
+		}
+		c.newlines(2)
+	case "pre":
+		c.newlines(2)
+		if markdown {
+			c.out.WriteString("```\n")
+		}
+		c.pre++
+	case "code":
+		if markdown && c.pre == 0 {
+			c.flushSpace()
+			c.out.WriteString("`")
+		}
+	case "tr":
+		c.newlines(1)
+		c.cells = 0
+	case "td", "th":
+		c.flushSpace()
+		if c.cells > 0 {
+			c.out.WriteString(" | ")
+		}
+		c.cells++
+	case "dt":
+		c.newlines(1)
+	case "dd":
+		c.newlines(1)
+		if !markdown {
+			c.out.WriteString("  ")
+		}
+	case "a":
+		c.href = attrs["href"]
+		c.linkStart = c.out.Len()
+	case "img":
+		if alt := strings.TrimSpace(attrs["alt"]); alt != "" {
+			c.text("[" + alt + "]")
+		}
+	default:
+		if blockElements[name] {
+			c.newlines(2)
+		}
+	}
+}
+
+// endTag handles the closing tag of an element.
+func (c *converter) endTag(name string) {
+	if c.skip != "" {
+		if name == c.skip {
+			c.depth--
+			if c.depth == 0 {
+				c.skip = ""
+			}
+		}
+		return
+	}
+	markdown := c.opts.Style == Markdown
+
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if !markdown && (c.heading == 1 || c.heading == 2) {
+			underline := "="
+			if c.heading == 2 {
+				underline = "-"
+			}
+			width := utf8.RuneCountInString(strings.TrimSpace(c.out.String()[c.headStart:]))
+			c.newlines(1)
+			c.out.WriteString(strings.Repeat(underline, width))
+		}
+		c.heading = 0
+		c.newlines(2)
+	case "ul", "ol":
+		if len(c.lists) > 0 {
+			c.lists = c.lists[:len(c.lists)-1]
+		}
+		if len(c.lists) == 0 {
+			c.newlines(2)
+		}
+	case "pre":
+		if c.pre > 0 {
+			c.pre--
+			if markdown {
+				c.newlines(1)
+				c.out.WriteString("```")
+			}
+			c.newlines(2)
+		}
+	case "code":
+		if markdown && c.pre == 0 {
+			c.out.WriteString("`")
+		}
+	case "a":
+		label := strings.TrimSpace(c.out.String()[c.linkStart:])
+		if !markdown && c.href != "" && !strings.HasPrefix(c.href, "#") && !strings.HasPrefix(c.href, "javascript:") &&
+			label != c.href && label != strings.TrimPrefix(c.href, "mailto:") {
+			c.flushSpace()
+			c.out.WriteString(" (" + c.href + ")")
+		}
+		c.href = ""
+	case "li", "tr", "dt", "dd":
+		c.newlines(1)
+	default:
+		if blockElements[name] {
+			c.newlines(2)
+		}
+	}
+}
+
+// text writes character data, collapsing whitespace outside <pre>.
+func (c *converter) text(data string) {
+	if c.skip != "" || data == "" {
+		return
+	}
+	data = html.UnescapeString(data)
+	if c.pre > 0 {
+		c.out.WriteString(data)
+		return
+	}
+	words := strings.Fields(data)
+	if len(words) == 0 {
+		c.pendingWS = true
+		return
+	}
+	if strings.TrimLeft(data, " \t\r\n") != data {
+		c.pendingWS = true
+	}
+	c.flushSpace()
+	c.out.WriteString(strings.Join(words, " "))
+	c.pendingWS = strings.TrimRight(data, " \t\r\n") != data
+}
+
+// flushSpace writes the whitespace seen since the last text, unless at the start of
+// a line or after a marker such as "- ".
+func (c *converter) flushSpace() {
+	out := c.out.String()
+	if c.pendingWS && out != "" && !strings.HasSuffix(out, "\n") && !strings.HasSuffix(out, " ") {
+		c.out.WriteString(" ")
+	}
+	c.pendingWS = false
+}
+
+// newlines ends the current line, leaving n-1 blank lines, unless nothing has been
+// written yet.
+func (c *converter) newlines(n int) {
+	c.pendingWS = false
+	out := c.out.String()
+	if strings.TrimSpace(out) == "" {
+		return
+	}
+	have := len(out) - len(strings.TrimRight(out, "\n"))
+	for ; have < n; have++ {
+		c.out.WriteString("\n")
+	}
+}
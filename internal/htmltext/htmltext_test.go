@@ -0,0 +1,60 @@
+package htmltext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const page = `<h1>Payments</h1>
+<p>The   service settles <code>card</code> payments &amp; refunds, see <a href="https://docs.example.com">the docs</a>.</p>
+<ol><li>Ledger<ul><li>Balances</li></ul></li><li>Gateway</li></ol>
+<table><tr><th>Name</th><th>Owner</th></tr><tr><td>ledger</td><td>team-a</td></tr></table>
+<pre>run()
+  done()</pre>
+<script>if (a < b) {}</script><nav>Home</nav><p>1 < 2</p>`
+
+func TestConvert_Markdown(t *testing.T) {
+	text := Convert(page, Options{Style: Markdown, Skip: func(name string, _ map[string]string) bool {
+		return name == "nav" || name == "script"
+	}})
+
+	assert.Equal(t, "# Payments\n\n"+
+		"The service settles `card` payments & refunds, see the docs.\n\n"+
+		"- Ledger\n- Balances\n- Gateway\n\n"+
+		"Name | Owner\nledger | team-a\n\n"+
+		"```\nrun()\n  done()\n```\n\n"+
+		"1 < 2\n", text)
+}
+
+func TestConvert_Plain(t *testing.T) {
+	text := Convert(page, Options{Style: Plain, Skip: func(name string, _ map[string]string) bool {
+		return name == "script"
+	}})
+
+	assert.Equal(t, "Payments\n========\n\n"+
+		"The service settles card payments & refunds, see the docs (https://docs.example.com).\n\n"+
+		"1. Ledger\n  - Balances\n2. Gateway\n\n"+
+		"Name | Owner\nledger | team-a\n\n"+
+		"run()\n  done()\n\n"+
+		"Home\n\n1 < 2\n", text)
+}
+
+func TestTagHelpers(t *testing.T) {
+	tag := `a HREF="/x?a=1&amp;b=2" title='a > b' data-x=y`
+	assert.Equal(t, len(`<`+tag), TagEnd(`<`+tag+`>rest`))
+	assert.Equal(t, -1, TagEnd(`<a href="x>`))
+
+	name, closing := TagName(tag)
+	assert.Equal(t, "a", name)
+	assert.False(t, closing)
+	name, closing = TagName("/DIV")
+	assert.Equal(t, "div", name)
+	assert.True(t, closing)
+	name, _ = TagName(" b")
+	assert.Empty(t, name)
+
+	assert.Equal(t, []Attribute{{Name: "href", Value: "/x?a=1&b=2"}, {Name: "title", Value: "a > b"}, {Name: "data-x", Value: "y"}}, Attributes(tag))
+	assert.True(t, IsVoid("br"))
+	assert.False(t, IsVoid("p"))
+}
@@ -0,0 +1,103 @@
+package i18n
+
+// german is the German catalog.
+var german = map[string]string{
+	// generate
+	"generate.success":        "Dokument erfolgreich erstellt: %s",
+	"generate.ensemble":       "Abweichungen im Ensemble abgeglichen: %s",
+	"generate.quality":        "Qualitätsbewertung: %.1f/10 (siehe %s)",
+	"generate.groundedness":   "Belegbarkeit: %d von %d geprüften Aussagen belegt (siehe %s)",
+	"generate.confidence":     "Konfidenz: %d von %d Feldern mit niedriger Bewertung: %s (siehe %s)",
+	"generate.source_errors":  "%d nicht lesbare Quelle(n) übersprungen (siehe %s)",
+	"generate.streaming":      "Antwort wird empfangen: %d Tokens (%.1f KB, %.0fs)",
+	"generate.agent_running":  "Agent '%s' wird auf Quelle ausgeführt: %s",
+	"generate.agent_done":     "Agent abgeschlossen. Verwende Artefakte aus: %s",
+	"generate.triage_written": "Triage-Paket zu diesem Fehler geschrieben: %s",
+	"generate.usage":          "Verbrauch: %d Prompt- + %d Antwort-Tokens in %d Anfrage(n), Kosten $%.4f",
+	"generate.usage_unpriced": "Kein Preis bekannt für %s; die Kosten sind nicht enthalten",
+
+	// review
+	"review.field":             "[%d/%d] %s",
+	"review.choices":           "Übernehmen (a, Enter), bearbeiten (e), neu erzeugen (r), alle übrigen übernehmen (A) oder beenden (q)? ",
+	"review.instructions":      "Anweisungen für das Modell (optional): ",
+	"review.invalid":           "Das geprüfte Dokument ist ungültig, bitte erneut prüfen: %v",
+	"review.edit_failed":       "Bearbeitung verworfen: %v",
+	"review.regenerate_failed": "Neuerzeugung fehlgeschlagen: %v",
+	"review.unknown_choice":    "Unbekannte Auswahl %q",
+
+	// dry run
+	"dryrun.banner":           "=== PROBELAUF ===",
+	"dryrun.template":         "Vorlage: %s",
+	"dryrun.sources":          "Quellen: %v",
+	"dryrun.output":           "Ausgabe: %s",
+	"dryrun.model":            "Modell: %s",
+	"dryrun.tokens":           "Geschätzte Tokens: %d",
+	"dryrun.source_stats":     "=== QUELLEN ===",
+	"dryrun.source_files":     "Dateien: %d (%d Bytes): %s",
+	"dryrun.source_languages": "Sprachen: %s",
+	"dryrun.source_folder":    "  %5.1f%%  %s (%d Tokens, %d Datei(en))",
+	"dryrun.prompt_preview":   "=== PROMPT-VORSCHAU (erste 1000 Zeichen) ===",
+	"dryrun.schema":           "=== SCHEMA ===",
+
+	// errors
+	"error.output_exists":     "Ausgabedatei %s existiert bereits (mit --force überschreiben)",
+	"error.template_required": "Vorlagentyp ist erforderlich",
+	"error.source_required":   "mindestens eine Quelle ist erforderlich",
+	"error.output_required":   "Ausgabedatei ist erforderlich",
+	"error.api_key_required":  "API-Schlüssel ist erforderlich (--api-key oder Umgebungsvariable OPENAI_API_KEY verwenden)",
+
+	// other commands
+	"agents.created":                "Agent %s erstellt (%s-Runner):",
+	"agents.none":                   "Keine Agenten gefunden. Agentendefinitionen in .docloom/agents/ oder ~/.docloom/agents/ ablegen",
+	"docs.none":                     "Keine Dokumente erfasst.",
+	"status.regenerating":           "%s wird neu erstellt (%s)...",
+	"status.failed":                 "  fehlgeschlagen: %v",
+	"status.updated":                "  aktualisiert: %s",
+	"schedule.none":                 "Keine Zeitpläne konfiguriert. Einen Abschnitt 'schedules' in der Konfigurationsdatei anlegen.",
+	"schedule.completed":            "Zeitplan %s abgeschlossen: %s",
+	"schedule.no_runs":              "Keine geplanten Läufe erfasst.",
+	"pack.written":                  "Kontextpaket nach %s geschrieben (%d Quelldateien, etwa %d Prompt-Tokens)",
+	"batch.started":                 "Führe %d Auftrag/Aufträge aus, %d gleichzeitig",
+	"import.rendered":               "%s nach %s gerendert",
+	"regenerate.rendered":           "%s in %s neu generiert",
+	"templates.available":           "Verfügbare Vorlagen:",
+	"templates.search_none":         "Keine Vorlagen zu %q gefunden.",
+	"templates.fixture_ok":          "  ok           %s",
+	"templates.fixture_missing":     "  ohne Fixture %s (%s anlegen)",
+	"templates.fixture_updated":     "  aktualisiert %s (%s)",
+	"templates.fixture_differs":     "  abweichend   %s: %s",
+	"templates.fixture_failed":      "  fehlerhaft   %s: %v",
+	"templates.diff_header":         "Änderungen von %s zu %s:",
+	"templates.diff_none":           "Keine Änderungen an Feldern, Prompt oder Platzhaltern.",
+	"templates.diff_fields":         "Schemafelder:",
+	"templates.diff_prompt":         "Prompt:",
+	"templates.diff_placeholders":   "HTML-Platzhalter:",
+	"templates.describe_header":     "Vorlage %s",
+	"templates.describe_output":     "Ausgabe: %s",
+	"templates.describe_models":     "Modelle: %s",
+	"templates.placeholder_unknown": "(kein Schemafeld)",
+	"templates.placeholder_warning": "  Warnung  %s: Platzhalter %s entspricht keinem Schemafeld",
+	"templates.unused_warning":      "  Warnung  %s: Schemafeld %s hat keinen Platzhalter",
+	"templates.placeholder_failed":  "  fehlerhaft   %s: Platzhalter %s entspricht keinem Schemafeld",
+	"templates.unused_failed":       "  fehlerhaft   %s: Schemafeld %s hat keinen Platzhalter",
+	"templates.prompt_more":         "  ... %d weitere Zeile(n)",
+	"templates.dev_serving":         "Vorschau von %s unter %s (Strg+C zum Beenden)",
+	"templates.dev_rendered":        "  gerendert   %s",
+	"templates.dev_failed":          "  fehlerhaft   %s: %v",
+	"cache.gc_result":               "%s-Cache: %d Einträge entfernt, %s freigegeben",
+	"trust.key_written":             "Privater Schlüssel geschrieben nach %s (geheim halten). Vertrauen Sie dem Herausgeber, indem Sie der Konfiguration hinzufügen:\n\ntrust:\n  keys:\n    - name: %s\n      public_key: %s",
+	"trust.signed":                  "%s als %s signiert: %s",
+	"trust.verified":                "%s: signiert vom vertrauenswürdigen Herausgeber %s",
+	"trends.written":                "Trendbericht über %d Läufe in %s geschrieben",
+	"auth.prompt":                   "API-Schlüssel für %s: ",
+	"auth.stored":                   "API-Schlüssel %s in %s gespeichert",
+	"auth.deleted":                  "API-Schlüssel %s aus %s gelöscht",
+	"config.files":                  "Konfigurationsdateien, niedrigste Priorität zuerst:",
+	"config.no_files":               "Keine Konfigurationsdatei gefunden; Standardwerte und Umgebungsvariablen werden angezeigt",
+	"track.updated":                 "Commit %s: %d Dokument(e) neu erzeugt, %d nicht betroffen, %d fehlgeschlagen",
+	"export.no_items":               "Keine technischen Schulden gefunden.",
+	"export.dry_run":                "Probelauf: Es wurden keine Tickets erstellt oder aktualisiert.",
+	"experiment.report_written":     "Bericht geschrieben nach %s",
+	"smoke.passed":                  "PASS: DocLoom funktioniert auf diesem Rechner (%s)",
+	"smoke.failed":                  "FAIL: %d von %d Prüfungen des Smoke-Tests fehlgeschlagen",
+}
@@ -0,0 +1,103 @@
+package i18n
+
+// english is the reference catalog; every message ID must be present here.
+var english = map[string]string{
+	// generate
+	"generate.success":        "Successfully generated document: %s",
+	"generate.ensemble":       "Ensemble disagreements reconciled: %s",
+	"generate.quality":        "Quality score: %.1f/10 (see %s)",
+	"generate.groundedness":   "Groundedness: %d of %d sampled claims supported (see %s)",
+	"generate.confidence":     "Confidence: %d of %d fields scored low: %s (see %s)",
+	"generate.source_errors":  "Skipped %d source(s) that could not be read (see %s)",
+	"generate.streaming":      "Receiving response: %d tokens (%.1f KB, %.0fs)",
+	"generate.agent_running":  "Running agent '%s' on source: %s",
+	"generate.agent_done":     "Agent completed. Using artifacts from: %s",
+	"generate.triage_written": "Triage bundle for this failure written to: %s",
+	"generate.usage":          "Usage: %d prompt + %d completion tokens in %d request(s), cost $%.4f",
+	"generate.usage_unpriced": "No price is known for %s; its cost is not included",
+
+	// review
+	"review.field":             "[%d/%d] %s",
+	"review.choices":           "Accept (a, Enter), edit (e), regenerate (r), accept all remaining (A) or quit (q)? ",
+	"review.instructions":      "Instructions for the model (optional): ",
+	"review.invalid":           "The reviewed document is invalid, review it again: %v",
+	"review.edit_failed":       "Edit discarded: %v",
+	"review.regenerate_failed": "Regeneration failed: %v",
+	"review.unknown_choice":    "Unknown choice %q",
+
+	// dry run
+	"dryrun.banner":           "=== DRY RUN MODE ===",
+	"dryrun.template":         "Template: %s",
+	"dryrun.sources":          "Sources: %v",
+	"dryrun.output":           "Output: %s",
+	"dryrun.model":            "Model: %s",
+	"dryrun.tokens":           "Estimated tokens: %d",
+	"dryrun.source_stats":     "=== SOURCES ===",
+	"dryrun.source_files":     "Files: %d (%d bytes): %s",
+	"dryrun.source_languages": "Languages: %s",
+	"dryrun.source_folder":    "  %5.1f%%  %s (%d tokens, %d file(s))",
+	"dryrun.prompt_preview":   "=== PROMPT PREVIEW (first 1000 chars) ===",
+	"dryrun.schema":           "=== SCHEMA ===",
+
+	// errors
+	"error.output_exists":     "output file %s already exists (use --force to overwrite)",
+	"error.template_required": "template type is required",
+	"error.source_required":   "at least one source is required",
+	"error.output_required":   "output file is required",
+	"error.api_key_required":  "API key is required (use --api-key or OPENAI_API_KEY env var)",
+
+	// other commands
+	"agents.created":                "Created %s agent (%s runner):",
+	"agents.none":                   "No agents found. Place agent definition files in .docloom/agents/ or ~/.docloom/agents/",
+	"docs.none":                     "No documents recorded.",
+	"status.regenerating":           "Regenerating %s (%s)...",
+	"status.failed":                 "  failed: %v",
+	"status.updated":                "  updated %s",
+	"schedule.none":                 "No schedules configured. Add a 'schedules' section to your config file.",
+	"schedule.completed":            "Schedule %s completed: %s",
+	"schedule.no_runs":              "No scheduled runs recorded.",
+	"pack.written":                  "Context pack written to %s (%d source files, about %d prompt tokens)",
+	"batch.started":                 "Running %d job(s), %d at a time",
+	"import.rendered":               "Rendered %s to %s",
+	"regenerate.rendered":           "Regenerated %s in %s",
+	"templates.available":           "Available templates:",
+	"templates.search_none":         "No templates found matching %q.",
+	"templates.fixture_ok":          "  ok       %s",
+	"templates.fixture_missing":     "  no fixture %s (add %s)",
+	"templates.fixture_updated":     "  updated  %s (%s)",
+	"templates.fixture_differs":     "  differs  %s: %s",
+	"templates.fixture_failed":      "  failed   %s: %v",
+	"templates.diff_header":         "Changes from %s to %s:",
+	"templates.diff_none":           "No changes to fields, prompt or placeholders.",
+	"templates.diff_fields":         "Schema fields:",
+	"templates.diff_prompt":         "Prompt:",
+	"templates.diff_placeholders":   "HTML placeholders:",
+	"templates.describe_header":     "Template %s",
+	"templates.describe_output":     "Output: %s",
+	"templates.describe_models":     "Models: %s",
+	"templates.placeholder_unknown": "(no schema field)",
+	"templates.placeholder_warning": "  warning  %s: placeholder %s names no schema field",
+	"templates.unused_warning":      "  warning  %s: schema field %s has no placeholder",
+	"templates.placeholder_failed":  "  failed   %s: placeholder %s names no schema field",
+	"templates.unused_failed":       "  failed   %s: schema field %s has no placeholder",
+	"templates.prompt_more":         "  ... %d more line(s)",
+	"templates.dev_serving":         "Previewing %s at %s (press Ctrl+C to stop)",
+	"templates.dev_rendered":        "  rendered %s",
+	"templates.dev_failed":          "  failed   %s: %v",
+	"cache.gc_result":               "%s cache: removed %d entries, reclaimed %s",
+	"trust.key_written":             "Private key written to %s (keep it secret). Trust the publisher by adding to the configuration:\n\ntrust:\n  keys:\n    - name: %s\n      public_key: %s",
+	"trust.signed":                  "Signed %s as %s: %s",
+	"trust.verified":                "%s: signed by trusted publisher %s",
+	"trends.written":                "Trend report of %d runs written to %s",
+	"auth.prompt":                   "API key for %s: ",
+	"auth.stored":                   "API key %s stored in the %s",
+	"auth.deleted":                  "API key %s deleted from the %s",
+	"config.files":                  "Configuration files, lowest precedence first:",
+	"config.no_files":               "No configuration file found; showing defaults and environment overrides",
+	"track.updated":                 "Commit %s: %d document(s) regenerated, %d unaffected, %d failed",
+	"export.no_items":               "No debt items found.",
+	"export.dry_run":                "Dry run: no issues were created or updated.",
+	"experiment.report_written":     "Report written to %s",
+	"smoke.passed":                  "PASS: DocLoom works on this machine (%s)",
+	"smoke.failed":                  "FAIL: %d of %d smoke test checks failed",
+}
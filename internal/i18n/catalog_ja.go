@@ -0,0 +1,103 @@
+package i18n
+
+// japanese is the Japanese catalog.
+var japanese = map[string]string{
+	// generate
+	"generate.success":        "ドキュメントを生成しました: %s",
+	"generate.ensemble":       "アンサンブルの相違を調整しました: %s",
+	"generate.quality":        "品質スコア: %.1f/10 (%s を参照)",
+	"generate.groundedness":   "根拠性: 抽出した %[2]d 件の主張のうち %[1]d 件が裏付けられました (%[3]s を参照)",
+	"generate.confidence":     "確信度: %[2]d 件中 %[1]d 件のフィールドが低評価: %[3]s (%[4]s を参照)",
+	"generate.source_errors":  "読み込めなかったソース %d 件をスキップしました (%s を参照)",
+	"generate.streaming":      "応答を受信中: %d トークン (%.1f KB, %.0f 秒)",
+	"generate.agent_running":  "エージェント '%s' をソースに対して実行しています: %s",
+	"generate.agent_done":     "エージェントが完了しました。成果物を使用します: %s",
+	"generate.triage_written": "この失敗のトリアージバンドルを書き出しました: %s",
+	"generate.usage":          "使用量: プロンプト %d + 応答 %d トークン、%d 件のリクエスト、コスト $%.4f",
+	"generate.usage_unpriced": "%s の価格が不明なため、そのコストは含まれていません",
+
+	// review
+	"review.field":             "[%d/%d] %s",
+	"review.choices":           "承認 (a, Enter)、編集 (e)、再生成 (r)、残りをすべて承認 (A)、終了 (q)? ",
+	"review.instructions":      "モデルへの指示 (任意): ",
+	"review.invalid":           "レビューしたドキュメントが無効です。もう一度レビューしてください: %v",
+	"review.edit_failed":       "編集を破棄しました: %v",
+	"review.regenerate_failed": "再生成に失敗しました: %v",
+	"review.unknown_choice":    "不明な選択 %q",
+
+	// dry run
+	"dryrun.banner":           "=== ドライランモード ===",
+	"dryrun.template":         "テンプレート: %s",
+	"dryrun.sources":          "ソース: %v",
+	"dryrun.output":           "出力: %s",
+	"dryrun.model":            "モデル: %s",
+	"dryrun.tokens":           "推定トークン数: %d",
+	"dryrun.source_stats":     "=== ソース ===",
+	"dryrun.source_files":     "ファイル: %d (%d バイト): %s",
+	"dryrun.source_languages": "言語: %s",
+	"dryrun.source_folder":    "  %5.1f%%  %s (%d トークン, %d ファイル)",
+	"dryrun.prompt_preview":   "=== プロンプトのプレビュー (先頭 1000 文字) ===",
+	"dryrun.schema":           "=== スキーマ ===",
+
+	// errors
+	"error.output_exists":     "出力ファイル %s は既に存在します (上書きするには --force を指定してください)",
+	"error.template_required": "テンプレートの種類を指定してください",
+	"error.source_required":   "ソースを 1 つ以上指定してください",
+	"error.output_required":   "出力ファイルを指定してください",
+	"error.api_key_required":  "API キーが必要です (--api-key または環境変数 OPENAI_API_KEY を使用してください)",
+
+	// other commands
+	"agents.created":                "エージェント %s を作成しました (%s ランナー):",
+	"agents.none":                   "エージェントが見つかりません。エージェント定義ファイルを .docloom/agents/ または ~/.docloom/agents/ に配置してください",
+	"docs.none":                     "記録されたドキュメントはありません。",
+	"status.regenerating":           "%s を再生成しています (%s)...",
+	"status.failed":                 "  失敗しました: %v",
+	"status.updated":                "  更新しました: %s",
+	"schedule.none":                 "スケジュールが設定されていません。設定ファイルに 'schedules' セクションを追加してください。",
+	"schedule.completed":            "スケジュール %s が完了しました: %s",
+	"schedule.no_runs":              "記録されたスケジュール実行はありません。",
+	"pack.written":                  "コンテキストパックを %s に書き込みました (ソースファイル %d 件、プロンプト約 %d トークン)",
+	"batch.started":                 "%d 件のジョブを実行します (同時に %d 件)",
+	"import.rendered":               "%s を %s にレンダリングしました",
+	"regenerate.rendered":           "%s を %s で再生成しました",
+	"templates.available":           "利用可能なテンプレート:",
+	"templates.search_none":         "%q に一致するテンプレートは見つかりませんでした。",
+	"templates.fixture_ok":          "  OK       %s",
+	"templates.fixture_missing":     "  フィクスチャなし %s (%s を追加してください)",
+	"templates.fixture_updated":     "  更新     %s (%s)",
+	"templates.fixture_differs":     "  差分あり %s: %s",
+	"templates.fixture_failed":      "  失敗     %s: %v",
+	"templates.diff_header":         "%s から %s への変更:",
+	"templates.diff_none":           "フィールド、プロンプト、プレースホルダーに変更はありません。",
+	"templates.diff_fields":         "スキーマフィールド:",
+	"templates.diff_prompt":         "プロンプト:",
+	"templates.diff_placeholders":   "HTML プレースホルダー:",
+	"templates.describe_header":     "テンプレート %s",
+	"templates.describe_output":     "出力: %s",
+	"templates.describe_models":     "モデル: %s",
+	"templates.placeholder_unknown": "(スキーマフィールドなし)",
+	"templates.placeholder_warning": "  警告  %s: プレースホルダー %s に対応するスキーマフィールドがありません",
+	"templates.unused_warning":      "  警告  %s: スキーマフィールド %s に対応するプレースホルダーがありません",
+	"templates.placeholder_failed":  "  失敗     %s: プレースホルダー %s に対応するスキーマフィールドがありません",
+	"templates.unused_failed":       "  失敗     %s: スキーマフィールド %s に対応するプレースホルダーがありません",
+	"templates.prompt_more":         "  ... 残り %d 行",
+	"templates.dev_serving":         "%s を %s でプレビュー中 (Ctrl+C で終了)",
+	"templates.dev_rendered":        "  描画     %s",
+	"templates.dev_failed":          "  失敗     %s: %v",
+	"cache.gc_result":               "%s キャッシュ: %d 件を削除し、%s を解放しました",
+	"trust.key_written":             "秘密鍵を %s に書き込みました (秘密にしてください)。設定に次を追加して発行者を信頼します:\n\ntrust:\n  keys:\n    - name: %s\n      public_key: %s",
+	"trust.signed":                  "%s に %s として署名しました: %s",
+	"trust.verified":                "%s: 信頼された発行者 %s の署名があります",
+	"trends.written":                "%d 回の実行のトレンドレポートを %s に書き込みました",
+	"auth.prompt":                   "%s の API キー: ",
+	"auth.stored":                   "API キー %s を %s に保存しました",
+	"auth.deleted":                  "API キー %s を %s から削除しました",
+	"config.files":                  "設定ファイル（優先度の低い順）:",
+	"config.no_files":               "設定ファイルが見つかりません。既定値と環境変数による上書きを表示します",
+	"track.updated":                 "コミット %s: %d 件のドキュメントを再生成、%d 件は影響なし、%d 件は失敗",
+	"export.no_items":               "技術的負債の項目が見つかりません。",
+	"export.dry_run":                "ドライラン: 課題は作成も更新もされていません。",
+	"experiment.report_written":     "レポートを %s に書き出しました",
+	"smoke.passed":                  "PASS: このマシンで DocLoom は正常に動作します (%s)",
+	"smoke.failed":                  "FAIL: スモークテストの %d / %d 件のチェックが失敗しました",
+}
@@ -0,0 +1,99 @@
+// Package i18n provides translated user-facing CLI messages.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is used when no supported language is configured.
+const DefaultLanguage = "en"
+
+// catalogs maps a language code to its messages, keyed by message ID.
+var catalogs = map[string]map[string]string{
+	"en": english,
+	"de": german,
+	"ja": japanese,
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultLanguage
+)
+
+// Languages returns the supported language codes.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// SetLanguage selects the language for T. It accepts locale names such as
+// "de_DE.UTF-8" and returns an error for unsupported languages.
+func SetLanguage(lang string) error {
+	code := normalize(lang)
+	if _, ok := catalogs[code]; !ok {
+		return fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	mu.Lock()
+	current = code
+	mu.Unlock()
+	return nil
+}
+
+// Language returns the selected language code.
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Detect returns the language to use: the explicit flag value when set, otherwise the
+// first supported language from LC_ALL, LC_MESSAGES or LANG, otherwise English.
+func Detect(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		// The first variable set decides, as with POSIX locale resolution
+		if _, ok := catalogs[normalize(value)]; ok {
+			return normalize(value)
+		}
+		return DefaultLanguage
+	}
+	return DefaultLanguage
+}
+
+// T returns the message with the given ID in the selected language, formatted with
+// args. Messages missing from a catalog fall back to English, then to the ID itself.
+func T(id string, args ...interface{}) string {
+	message, ok := catalogs[Language()][id]
+	if !ok {
+		if message, ok = english[id]; !ok {
+			message = id
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// normalize reduces a locale name such as "ja_JP.UTF-8" to its language code.
+func normalize(lang string) string {
+	code := strings.ToLower(strings.TrimSpace(lang))
+	if idx := strings.IndexAny(code, "_-.@"); idx >= 0 {
+		code = code[:idx]
+	}
+	return code
+}
@@ -0,0 +1,52 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogsCoverEnglish(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for id := range english {
+			assert.Contains(t, catalog, id, "%s catalog is missing %s", lang, id)
+		}
+		for id := range catalog {
+			assert.Contains(t, english, id, "%s catalog has unknown message %s", lang, id)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	t.Cleanup(func() { _ = SetLanguage(DefaultLanguage) })
+
+	assert.Equal(t, "Successfully generated document: out.html", T("generate.success", "out.html"))
+
+	require.NoError(t, SetLanguage("de_DE.UTF-8"))
+	assert.Equal(t, "de", Language())
+	assert.Equal(t, "Dokument erfolgreich erstellt: out.html", T("generate.success", "out.html"))
+
+	require.NoError(t, SetLanguage("ja"))
+	assert.Equal(t, "根拠性: 抽出した 5 件の主張のうち 4 件が裏付けられました (r.json を参照)", T("generate.groundedness", 4, 5, "r.json"))
+
+	// Unknown IDs are returned unchanged
+	assert.Equal(t, "no.such.message", T("no.such.message"))
+
+	assert.Error(t, SetLanguage("fr"))
+	assert.Equal(t, "ja", Language(), "an unsupported language keeps the current selection")
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "ja_JP.UTF-8")
+	assert.Equal(t, "ja", Detect(""))
+	assert.Equal(t, "de", Detect("de"), "the flag wins over the environment")
+
+	t.Setenv("LC_ALL", "de_AT.UTF-8")
+	assert.Equal(t, "de", Detect(""), "LC_ALL wins over LANG")
+
+	t.Setenv("LC_ALL", "C")
+	assert.Equal(t, DefaultLanguage, Detect(""), "unsupported locales fall back to English")
+}
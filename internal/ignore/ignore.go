@@ -0,0 +1,257 @@
+// Package ignore implements .docloomignore and .gitignore files, which exclude paths
+// below a source root from ingestion and agent analysis using gitignore syntax.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the name of the ignore file read from a source root.
+const FileName = ".docloomignore"
+
+// GitignoreFileName is the name of git's ignore files, which are honored in a
+// source root and the directories below it.
+const GitignoreFileName = ".gitignore"
+
+// Matcher matches paths relative to a source root against ignore patterns. A nil
+// Matcher ignores nothing.
+type Matcher struct {
+	gitRules []rule // From .gitignore files
+	rules    []rule // From .docloomignore, applied after gitRules so ! can re-include
+}
+
+// rule is a single compiled pattern.
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	base    string // Directory of the .gitignore file relative to the root, or empty
+}
+
+// Load reads the .gitignore and .docloomignore files in root. It returns a nil
+// Matcher when neither exists. Patterns of .docloomignore take precedence, so it
+// can re-include files git ignores.
+func Load(root string) (*Matcher, error) {
+	m, err := LoadNested(nil, root, ".")
+	if err != nil {
+		return nil, err
+	}
+	data, err := readIgnoreFile(filepath.Join(root, FileName))
+	if err != nil || data == nil {
+		return m, err
+	}
+	if m == nil {
+		m = &Matcher{}
+	}
+	if err := m.parse(data, FileName); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadNested adds the patterns of the .gitignore file in the directory relDir below
+// root, which apply to paths inside that directory, to m. It returns m, or a new
+// Matcher when m is nil and the file exists; walks call it for each directory they
+// enter.
+func LoadNested(m *Matcher, root, relDir string) (*Matcher, error) {
+	data, err := readIgnoreFile(filepath.Join(root, relDir, GitignoreFileName))
+	if err != nil || data == nil {
+		return m, err
+	}
+	base := filepath.ToSlash(filepath.Clean(relDir))
+	if base == "." {
+		base = ""
+	}
+	gitignore := &Matcher{}
+	if err := gitignore.parse(data, filepath.ToSlash(filepath.Join(relDir, GitignoreFileName))); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		m = &Matcher{}
+	}
+	for _, r := range gitignore.rules {
+		r.base = base
+		m.gitRules = append(m.gitRules, r)
+	}
+	return m, nil
+}
+
+// readIgnoreFile reads an ignore file, returning nil when it does not exist.
+func readIgnoreFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - source roots are user-provided
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// Parse compiles ignore patterns in gitignore syntax: blank lines and lines starting
+// with # are skipped, a leading ! re-includes, a trailing / matches directories only,
+// patterns containing a / are anchored to the root, and * ? [...] and ** are supported.
+func Parse(data []byte) (*Matcher, error) {
+	m := &Matcher{}
+	if err := m.parse(data, FileName); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parse adds the patterns of an ignore file named name.
+func (m *Matcher) parse(data []byte, name string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if err := m.add(scanner.Text()); err != nil {
+			return fmt.Errorf("%s line %d: %w", name, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// Compile compiles patterns given one per element, such as those of command line
+// flags, with the syntax of Parse.
+func Compile(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		if err := m.add(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// add compiles a pattern line and appends its rule; blank lines and comments add none.
+func (m *Matcher) add(pattern string) error {
+	line := strings.TrimRight(pattern, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// Escaped leading ! or #
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	re, err := regexp.Compile(prefix + globToRegexp(line) + "$")
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+	return nil
+}
+
+// Match reports whether the path, relative to the root, is ignored. The last matching
+// pattern wins. Paths inside an ignored directory are ignored as well.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	if m == nil || len(m.gitRules)+len(m.rules) == 0 {
+		return false
+	}
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == "." || strings.HasPrefix(relPath, "../") {
+		return false
+	}
+	// A file cannot be re-included once a parent directory is excluded
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchPath(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.matchPath(relPath, isDir)
+}
+
+// matchPath applies the rules to a single path.
+func (m *Matcher) matchPath(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rules := range [][]rule{m.gitRules, m.rules} {
+		for _, r := range rules {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			path := relPath
+			if r.base != "" {
+				var inside bool
+				if path, inside = strings.CutPrefix(relPath, r.base+"/"); !inside {
+					continue
+				}
+			}
+			if r.re.MatchString(path) {
+				ignored = !r.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// globToRegexp converts a gitignore glob to a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			// Zero or more directories
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			// Everything inside
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
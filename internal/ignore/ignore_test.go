@@ -0,0 +1,101 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	m, err := Parse([]byte(`
+# Generated code and fixtures
+*.generated.cs
+/build
+vendor/
+docs/**/draft-*.md
+testdata/**
+!testdata/keep.md
+\#notes.md
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"src/Api.generated.cs", false, true},
+		{"src/Api.cs", false, false},
+		{"build", true, true},
+		{"build/out.md", false, true},
+		{"src/build", true, false},
+		{"vendor", true, true},
+		{"src/vendor/lib.md", false, true},
+		{"vendor", false, false},
+		{"docs/draft-1.md", false, true},
+		{"docs/a/b/draft-2.md", false, true},
+		{"docs/final.md", false, false},
+		{"testdata/fixture.md", false, true},
+		{"testdata/keep.md", false, false},
+		{"#notes.md", false, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ignored, m.Match(tt.path, tt.isDir), tt.path)
+	}
+}
+
+func TestMatcher_ParentDirectoryExcluded(t *testing.T) {
+	m, err := Parse([]byte("generated/\n!generated/keep.md\n"))
+	require.NoError(t, err)
+	assert.True(t, m.Match("generated/keep.md", false), "files in an excluded directory cannot be re-included")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(dir)
+	require.NoError(t, err)
+	assert.Nil(t, m)
+	assert.False(t, m.Match("anything.md", false), "a nil matcher ignores nothing")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("*.log\n"), 0644))
+	m, err = Load(dir)
+	require.NoError(t, err)
+	assert.True(t, m.Match("logs/app.log", false))
+}
+
+func TestLoad_Gitignore(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, GitignoreFileName), []byte("bin/\nobj/\n*.generated.md\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", GitignoreFileName), []byte("node_modules/\n/dist\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("!api.generated.md\n"), 0644))
+
+	m, err := Load(dir)
+	require.NoError(t, err)
+	assert.True(t, m.Match("src/bin", true))
+	assert.True(t, m.Match("notes.generated.md", false))
+	assert.False(t, m.Match("api.generated.md", false), ".docloomignore re-includes what .gitignore excludes")
+	assert.False(t, m.Match("web/node_modules", true), "nested .gitignore files are loaded by the walk")
+
+	m, err = LoadNested(m, dir, "web")
+	require.NoError(t, err)
+	assert.True(t, m.Match("web/app/node_modules/lib.md", false))
+	assert.True(t, m.Match("web/dist", true))
+	assert.False(t, m.Match("dist", true), "patterns of a nested .gitignore apply below its directory")
+	assert.False(t, m.Match("web/src/dist", true), "anchored patterns are relative to the .gitignore")
+
+	m, err = LoadNested(nil, dir, "docs")
+	require.NoError(t, err)
+	assert.Nil(t, m)
+}
+
+func TestCompile(t *testing.T) {
+	m, err := Compile([]string{"docs/adr/*.md", "README.md", ""})
+	require.NoError(t, err)
+	assert.True(t, m.Match("docs/adr/0001-record.md", false))
+	assert.True(t, m.Match("service/README.md", false))
+	assert.False(t, m.Match("docs/guide.md", false))
+}
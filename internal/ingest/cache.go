@@ -0,0 +1,162 @@
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultCacheMaxBytes is the default size limit of the extraction cache.
+const DefaultCacheMaxBytes = 512 << 20
+
+// pdfExtractorVersion is part of every PDF cache key so that changing the extractor
+// invalidates earlier results.
+const pdfExtractorVersion = "docloom-pdf-v1"
+
+// Cache stores extracted text keyed by the hash of the source file, so unchanged
+// files are not extracted again. When the cache grows beyond its size limit, the
+// least recently used entries are removed.
+type Cache struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex // Serializes pruning by concurrent writers
+}
+
+// NewCache returns a cache in dir limited to maxBytes (DefaultCacheMaxBytes when not
+// positive). The directory is created on first write.
+func NewCache(dir string, maxBytes int64) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultCacheMaxBytes
+	}
+	return &Cache{dir: dir, maxBytes: maxBytes}
+}
+
+// DefaultCacheDir returns the default extraction cache directory below the user's
+// cache directory, falling back to the system temp directory.
+func DefaultCacheDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "docloom", "ingest")
+}
+
+// cacheKey returns the key of a file's extracted text.
+func cacheKey(content []byte, extractor string) string {
+	hash := sha256.New()
+	hash.Write([]byte(extractor))
+	hash.Write([]byte{0})
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the cached text for key and marks the entry as recently used.
+func (c *Cache) Get(key string) (string, bool) {
+	path := c.entryPath(key)
+	data, err := os.ReadFile(path) // #nosec G304 - path is derived from a hex hash
+	if err != nil {
+		return "", false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return string(data), true
+}
+
+// Put stores text under key and prunes the cache to its size limit.
+func (c *Cache) Put(key, text string) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create ingest cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write ingest cache entry: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if _, err := tmp.WriteString(text); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write ingest cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write ingest cache entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.entryPath(key)); err != nil {
+		return fmt.Errorf("failed to write ingest cache entry: %w", err)
+	}
+	return c.prune()
+}
+
+// entryPath returns the file holding a cache entry.
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".txt")
+}
+
+// prune removes the least recently used entries until the cache fits its size limit.
+func (c *Cache) prune() error {
+	_, _, err := c.Prune(0)
+	return err
+}
+
+// Prune removes entries not used for longer than maxAge (no age limit when zero), then
+// the least recently used entries until the cache fits its size limit. It returns the
+// number of entries removed and the bytes reclaimed.
+func (c *Cache) Prune(maxAge time.Duration) (int, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("failed to read ingest cache directory: %w", err)
+	}
+
+	type cached struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cached
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".txt" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cached{filepath.Join(c.dir, entry.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	var reclaimed int64
+	for _, file := range files {
+		expired := maxAge > 0 && file.modTime.Before(cutoff)
+		if !expired && total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(file.path); err != nil {
+			log.Warn().Err(err).Str("file", file.path).Msg("Failed to evict ingest cache entry")
+			continue
+		}
+		total -= file.size
+		removed++
+		reclaimed += file.size
+		log.Debug().Str("file", file.path).Bool("expired", expired).Msg("Evicted ingest cache entry")
+	}
+	return removed, reclaimed, nil
+}
@@ -0,0 +1,84 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetPut(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "ingest"), 0)
+
+	_, ok := cache.Get("missing")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Put("key", "extracted text"))
+	text, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "extracted text", text)
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, 25)
+
+	require.NoError(t, cache.Put("old", strings.Repeat("a", 10)))
+	require.NoError(t, cache.Put("used", strings.Repeat("b", 10)))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(cache.entryPath("old"), past, past))
+	require.NoError(t, os.Chtimes(cache.entryPath("used"), past.Add(time.Minute), past.Add(time.Minute)))
+
+	// Reading an entry makes it the most recently used
+	_, ok := cache.Get("old")
+	require.True(t, ok)
+
+	require.NoError(t, cache.Put("new", strings.Repeat("c", 10)))
+	_, ok = cache.Get("used")
+	assert.False(t, ok, "the least recently used entry is evicted")
+	_, ok = cache.Get("old")
+	assert.True(t, ok)
+	_, ok = cache.Get("new")
+	assert.True(t, ok)
+}
+
+func TestCache_PruneExpired(t *testing.T) {
+	cache := NewCache(t.TempDir(), 0)
+	require.NoError(t, cache.Put("stale", strings.Repeat("a", 10)))
+	require.NoError(t, cache.Put("fresh", strings.Repeat("b", 5)))
+	past := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(cache.entryPath("stale"), past, past))
+
+	removed, reclaimed, err := cache.Prune(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, int64(10), reclaimed)
+	_, ok := cache.Get("stale")
+	assert.False(t, ok)
+	_, ok = cache.Get("fresh")
+	assert.True(t, ok)
+}
+
+func TestIngester_UsesPDFCache(t *testing.T) {
+	tempDir := t.TempDir()
+	pdfFile := filepath.Join(tempDir, "manual.pdf")
+	content := []byte("%PDF-1.4 fake")
+	require.NoError(t, os.WriteFile(pdfFile, content, 0644))
+
+	// A cached extraction is used without running pdftotext
+	cache := NewCache(filepath.Join(tempDir, "cache"), 0)
+	require.NoError(t, cache.Put(cacheKey(content, pdfExtractorVersion), "Cached manual text"))
+
+	ingester := NewIngester()
+	ingester.SetCache(cache)
+	result, err := ingester.IngestSources([]string{pdfFile})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Cached manual text")
+
+	// Changing the file changes the key
+	assert.NotEqual(t, cacheKey(content, pdfExtractorVersion), cacheKey([]byte("%PDF-1.4 changed"), pdfExtractorVersion))
+}
@@ -0,0 +1,50 @@
+package ingest
+
+import (
+	"fmt"
+
+	"github.com/karolswdev/docloom/internal/ignore"
+)
+
+// Filter selects the files ingested below source directories by globs in gitignore
+// syntax, relative to each directory: docs/adr/*.md, vendor/**, *.generated.md.
+type Filter struct {
+	include *ignore.Matcher // nil includes every file
+	exclude *ignore.Matcher
+}
+
+// NewFilter returns a filter ingesting only files matching an include glob, when
+// any are given, and no file matching an exclude glob. It returns nil when no globs
+// are given.
+func NewFilter(include, exclude []string) (*Filter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	filter := &Filter{}
+	var err error
+	if len(include) > 0 {
+		if filter.include, err = ignore.Compile(include); err != nil {
+			return nil, fmt.Errorf("invalid include glob: %w", err)
+		}
+	}
+	if filter.exclude, err = ignore.Compile(exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude glob: %w", err)
+	}
+	return filter, nil
+}
+
+// skipDir reports whether a directory is excluded with everything below it.
+func (f *Filter) skipDir(relPath string) bool {
+	return f != nil && f.exclude.Match(relPath, true)
+}
+
+// keepFile reports whether a file is ingested.
+func (f *Filter) keepFile(relPath string) bool {
+	if f == nil {
+		return true
+	}
+	if f.include != nil && !f.include.Match(relPath, false) {
+		return false
+	}
+	return !f.exclude.Match(relPath, false)
+}
@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// scpLikePattern matches scp-like SSH repository addresses such as
+	// git@github.com:org/repo.git
+	scpLikePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/]`)
+
+	// commitPattern matches abbreviated and full commit hashes
+	commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+)
+
+// IsGitURL reports whether a source path is a git repository URL rather than a
+// local path or web page: an ssh, git or file URL, an http(s) URL ending in .git, or
+// an scp-like address such as git@github.com:org/repo.git. Other http(s) URLs may
+// still be repositories; see FetchRemoteSources.
+func IsGitURL(path string) bool {
+	lower := strings.ToLower(path)
+	for _, scheme := range []string{"ssh://", "git://", "file://"} {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	if IsWebURL(path) {
+		base, _, _ := strings.Cut(lower, "#")
+		return strings.HasSuffix(strings.TrimRight(base, "/"), ".git")
+	}
+	return scpLikePattern.MatchString(path)
+}
+
+// cloneGitSource clones the repository at path, which may name a ref after a #,
+// into dir and returns the clone's path.
+func cloneGitSource(ctx context.Context, path, ref, dir string) (string, error) {
+	repoURL, repoRef := path, ref
+	if base, fragment, found := strings.Cut(path, "#"); found && fragment != "" {
+		repoURL, repoRef = base, fragment
+	}
+	clonePath := filepath.Join(dir, repositoryName(repoURL))
+	if err := cloneRepository(ctx, repoURL, repoRef, clonePath); err != nil {
+		return "", err
+	}
+	return clonePath, nil
+}
+
+// repositoryName returns the name of the repository at a git URL, such as billing
+// for https://github.com/acme/billing.git.
+func repositoryName(repoURL string) string {
+	name := strings.TrimRight(repoURL, "/")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, ".git")
+	if name == "" || name == "." || name == ".." {
+		return "repository"
+	}
+	return name
+}
+
+// cloneRepository clones repoURL into path with a history of depth one. A ref is
+// fetched on its own, which works for branches, tags and, on servers that allow
+// it, commits; a commit the server does not serve directly is checked out from a
+// full clone.
+func cloneRepository(ctx context.Context, repoURL, ref, path string) error {
+	log.Info().Str("repository", RedactURL(repoURL)).Str("ref", ref).Msg("Cloning git source")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create clone directory: %w", err)
+	}
+	if ref == "" {
+		return runGit(ctx, "", repoURL, "clone", "--depth", "1", "--quiet", repoURL, path)
+	}
+
+	if err := runGit(ctx, "", repoURL, "init", "--quiet", path); err != nil {
+		return err
+	}
+	if err := runGit(ctx, path, repoURL, "remote", "add", "origin", repoURL); err != nil {
+		return err
+	}
+	fetchErr := runGit(ctx, path, repoURL, "fetch", "--depth", "1", "--quiet", "origin", ref)
+	if fetchErr != nil {
+		if !commitPattern.MatchString(ref) {
+			return fmt.Errorf("ref %s of %s: %w", ref, RedactURL(repoURL), fetchErr)
+		}
+		if err := runGit(ctx, path, repoURL, "fetch", "--quiet", "origin"); err != nil {
+			return err
+		}
+		return runGit(ctx, path, repoURL, "checkout", "--quiet", ref)
+	}
+	return runGit(ctx, path, repoURL, "checkout", "--quiet", "FETCH_HEAD")
+}
+
+// runGit runs a git command in dir, never prompting for credentials; access comes
+// from the user's SSH agent and credential helpers.
+func runGit(ctx context.Context, dir, repoURL string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(strings.ReplaceAll(string(output), repoURL, RedactURL(repoURL)))
+		return fmt.Errorf("git %s %s failed: %w: %s", args[0], RedactURL(repoURL), err, message)
+	}
+	return nil
+}
+
+// RedactURL hides the password or token of a URL with credentials.
+func RedactURL(repoURL string) string {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.User == nil {
+		return repoURL
+	}
+	return parsed.Redacted()
+}
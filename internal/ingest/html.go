@@ -0,0 +1,70 @@
+package ingest
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/htmltext"
+)
+
+var (
+	// boilerplateElements are left out of the text of HTML pages with their content:
+	// navigation, page chrome, scripts and forms.
+	boilerplateElements = map[string]bool{
+		"head": true, "script": true, "style": true, "noscript": true, "template": true,
+		"nav": true, "header": true, "footer": true, "aside": true, "form": true,
+		"button": true, "select": true, "iframe": true, "svg": true, "canvas": true,
+	}
+
+	// boilerplateRoles are the ARIA roles of page chrome, and boilerplatePattern the ids
+	// and classes of the page chrome of wikis such as Confluence
+	boilerplateRoles   = map[string]bool{"navigation": true, "banner": true, "contentinfo": true, "search": true, "complementary": true}
+	boilerplatePattern = regexp.MustCompile(`(?i)(^|[\s_-])(breadcrumbs?|sidebar|footer|navigation|navbar|cookie-banner)($|[\s_-])`)
+)
+
+// HTMLToText converts an HTML page to Markdown-like text for a prompt: headings
+// become # lines, list items - lines and table cells are separated by |. Scripts,
+// styles, navigation, headers, footers and other page chrome are dropped, and when
+// the page marks its content with <main> or <article>, only that content is kept.
+func HTMLToText(page string) string {
+	return htmltext.Convert(mainContent(page), htmltext.Options{Style: htmltext.Markdown, Skip: isBoilerplate})
+}
+
+// mainContent returns the <main> element of page, or its <article> elements, when it
+// has them, and otherwise the whole page.
+func mainContent(page string) string {
+	lower := strings.ToLower(page)
+	for _, tag := range []string{"main", "article"} {
+		start := indexStartTag(lower, tag, 0)
+		end := strings.LastIndex(lower, "</"+tag)
+		if start >= 0 && end > start {
+			return page[start:end]
+		}
+	}
+	return page
+}
+
+// indexStartTag returns the index of the first start tag named tag in lower, a
+// lower-cased page, at or after from, or -1.
+func indexStartTag(lower, tag string, from int) int {
+	for {
+		idx := strings.Index(lower[from:], "<"+tag)
+		if idx < 0 {
+			return -1
+		}
+		idx += from
+		next := idx + len(tag) + 1
+		if next < len(lower) && strings.ContainsRune(" \t\r\n>/", rune(lower[next])) {
+			return idx
+		}
+		from = next
+	}
+}
+
+// isBoilerplate reports whether an element is page chrome.
+func isBoilerplate(name string, attrs map[string]string) bool {
+	return boilerplateElements[name] ||
+		boilerplateRoles[strings.ToLower(attrs["role"])] ||
+		boilerplatePattern.MatchString(attrs["id"]) ||
+		boilerplatePattern.MatchString(attrs["class"])
+}
@@ -0,0 +1,47 @@
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToText(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<head><title>Payments</title><style>body { color: red; }</style></head>
+<body>
+  <nav><a href="/">Home</a> &gt; <a href="/eng">Engineering</a></nav>
+  <div id="breadcrumb-section"><ol><li>Space</li><li>Page</li></ol></div>
+  <h1>Payments   Architecture</h1>
+  <p>The service <b>settles</b> card payments &amp; refunds.<br>It runs in two regions.</p>
+  <!-- a comment -->
+  <ul>
+    <li>Ledger</li>
+    <li>Gateway <code>v2</code></li>
+  </ul>
+  <table><tr><th>Name</th><th>Owner</th></tr><tr><td>ledger</td><td>team-a</td></tr></table>
+  <pre>func main() {
+    run()
+}</pre>
+  <script>if (a < b) { alert("x") }</script>
+  <svg/><p>After the icon, 1 < 2.</p>
+  <footer>Copyright</footer>
+</body>
+</html>`
+
+	expected := "# Payments Architecture\n\n" +
+		"The service settles card payments & refunds.\nIt runs in two regions.\n\n" +
+		"- Ledger\n- Gateway `v2`\n\n" +
+		"Name | Owner\nledger | team-a\n\n" +
+		"```\nfunc main() {\n    run()\n}\n```\n\n" +
+		"After the icon, 1 < 2.\n"
+	assert.Equal(t, expected, HTMLToText(page))
+}
+
+func TestHTMLToText_MainContent(t *testing.T) {
+	page := `<body><div class="sidebar-menu">Menu</div><p>Site banner</p>
+<main id="content"><h2>Runbook</h2><p>Restart the <em>worker</em>.</p></main>
+<p>Related pages</p></body>`
+	assert.Equal(t, "## Runbook\n\nRestart the worker.\n", HTMLToText(page))
+}
@@ -2,19 +2,29 @@
 package ingest
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ignore"
+	"github.com/karolswdev/docloom/internal/pdf"
 )
 
+// maxReadWorkers bounds the number of files read concurrently.
+const maxReadWorkers = 8
+
 // Ingester handles the ingestion of source files.
 type Ingester struct {
+	cache  *Cache  // Optional cache of extracted PDF text
+	ocr    pdf.OCR // Optional recognition of PDF pages without text
+	filter *Filter // Optional include and exclude globs for source directories
+
 	// SupportedExtensions defines the file extensions that will be ingested.
 	SupportedExtensions []string
 }
@@ -22,82 +32,233 @@ type Ingester struct {
 // NewIngester creates a new Ingester with default supported extensions.
 func NewIngester() *Ingester {
 	return &Ingester{
-		SupportedExtensions: []string{".md", ".txt", ".pdf"},
+		SupportedExtensions: []string{".md", ".txt", ".pdf", ".html", ".htm"},
 	}
 }
 
+// SetCache enables caching of extracted PDF text. A nil cache disables it.
+func (i *Ingester) SetCache(cache *Cache) {
+	i.cache = cache
+}
+
+// SetOCR sets the hook that recognizes the text of PDF pages without extractable
+// text, such as scans. A nil hook leaves those pages empty.
+func (i *Ingester) SetOCR(ocr pdf.OCR) {
+	i.ocr = ocr
+}
+
+// SetFilter restricts the files ingested below source directories to those the
+// filter keeps. Files given directly as sources are always ingested. A nil filter
+// keeps every file.
+func (i *Ingester) SetFilter(filter *Filter) {
+	i.filter = filter
+}
+
 // IngestSources recursively walks the provided paths and reads the content
-// of all supported files into a single concatenated string.
+// of all supported files into a single concatenated string, warning about sources
+// that cannot be read.
 func (i *Ingester) IngestSources(paths []string) (string, error) {
-	var contentBuilder strings.Builder
-	filesProcessed := 0
+	result, err := i.Ingest(paths, PolicyWarn)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
 
-	for _, path := range paths {
-		info, err := os.Stat(path)
+// Ingest recursively walks the provided paths and reads the content of all supported
+// files. Files excluded by a .docloomignore file in a source directory, by
+// .gitignore files or by the filter are skipped.
+// Sources that cannot be read are handled according to the policy and listed in the
+// result. Files are read concurrently but appear in the content in source order. A
+// path may carry a label ("label=path"); when several paths are given, the content of
+// each is wrapped in a section naming its label so prompts can tell the roots apart.
+func (i *Ingester) Ingest(paths []string, policy ErrorPolicy) (*Result, error) {
+	collector := &errorCollector{policy: policy}
+	result := &Result{Roots: sourceRoots(paths)}
+
+	rootFiles := make([][]string, len(result.Roots))
+	var files []string
+	for idx, root := range result.Roots {
+		expanded, err := i.expandSource(root.Path, collector)
 		if err != nil {
-			return "", fmt.Errorf("failed to stat path %s: %w", path, err)
+			return nil, err
 		}
+		rootFiles[idx] = expanded
+		files = append(files, expanded...)
+	}
+	contents := i.readFiles(files)
 
-		if info.IsDir() {
-			// Recursively walk the directory
-			err = filepath.Walk(path, func(filePath string, fileInfo os.FileInfo, err error) error {
-				if err != nil {
-					return err
+	labeled := len(result.Roots) > 1
+	var contentBuilder strings.Builder
+	next := 0
+	for idx := range result.Roots {
+		root := &result.Roots[idx]
+		var rootBuilder strings.Builder
+		var rootExtents []Extent
+		for _, filePath := range rootFiles[idx] {
+			read := contents[next]
+			next++
+			if read.err != nil {
+				if err := collector.add(filePath, fmt.Errorf("failed to read file %s: %w", filePath, read.err)); err != nil {
+					return nil, err
 				}
+				continue
+			}
 
-				if fileInfo.IsDir() {
-					return nil
-				}
+			if rootBuilder.Len() > 0 {
+				rootBuilder.WriteString("\n\n")
+			}
+			rootBuilder.WriteString(fmt.Sprintf("--- File: %s ---\n", filePath))
+			rootExtents = append(rootExtents, Extent{Start: rootBuilder.Len(), End: rootBuilder.Len() + len(read.content)})
+			rootBuilder.WriteString(read.content)
+			root.Files = append(root.Files, filePath)
+			result.Files = append(result.Files, filePath)
+			log.Debug().Str("file", filePath).Str("source", root.Label).Int("bytes", len(read.content)).Msg("Ingested file")
+		}
+		if rootBuilder.Len() == 0 {
+			continue
+		}
 
-				if i.isSupportedFile(filePath) {
-					content, err := i.readFile(filePath)
-					if err != nil {
-						log.Warn().Err(err).Str("file", filePath).Msg("Failed to read file, skipping")
-						return nil // Continue processing other files
-					}
-
-					if contentBuilder.Len() > 0 {
-						contentBuilder.WriteString("\n\n")
-					}
-					contentBuilder.WriteString(fmt.Sprintf("--- File: %s ---\n", filePath))
-					contentBuilder.WriteString(content)
-					filesProcessed++
-					log.Debug().Str("file", filePath).Int("bytes", len(content)).Msg("Ingested file")
-				}
+		if contentBuilder.Len() > 0 {
+			contentBuilder.WriteString("\n\n")
+		}
+		if labeled {
+			contentBuilder.WriteString(fmt.Sprintf("=== Source: %s (%s) ===\n", root.Label, root.Path))
+		}
+		offset := contentBuilder.Len()
+		for _, extent := range rootExtents {
+			result.Extents = append(result.Extents, Extent{Start: offset + extent.Start, End: offset + extent.End})
+		}
+		contentBuilder.WriteString(rootBuilder.String())
+	}
+	result.Errors = collector.errors
 
-				return nil
-			})
+	if len(result.Files) == 0 {
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("no supported files found in the provided paths (%d source error(s), first: %s)", len(result.Errors), result.Errors[0].Error)
+		}
+		return nil, fmt.Errorf("no supported files found in the provided paths")
+	}
 
-			if err != nil {
-				return "", fmt.Errorf("failed to walk directory %s: %w", path, err)
-			}
-		} else {
-			// Single file
-			if i.isSupportedFile(path) {
-				content, err := i.readFile(path)
-				if err != nil {
-					return "", fmt.Errorf("failed to read file %s: %w", path, err)
-				}
+	result.Content = contentBuilder.String()
+	log.Info().Int("files", len(result.Files)).Int("errors", len(result.Errors)).Int("total_bytes", len(result.Content)).Msg("Ingestion complete")
+	return result, nil
+}
 
-				if contentBuilder.Len() > 0 {
-					contentBuilder.WriteString("\n\n")
-				}
-				contentBuilder.WriteString(fmt.Sprintf("--- File: %s ---\n", path))
-				contentBuilder.WriteString(content)
-				filesProcessed++
-				log.Debug().Str("file", path).Int("bytes", len(content)).Msg("Ingested file")
-			} else {
-				log.Warn().Str("file", path).Msg("File type not supported for ingestion")
+// fileContent is the outcome of reading one file.
+type fileContent struct {
+	content string
+	err     error
+}
+
+// readFiles reads files with a bounded number of workers and returns their contents
+// in the order of files.
+func (i *Ingester) readFiles(files []string) []fileContent {
+	contents := make([]fileContent, len(files))
+	workers := min(runtime.GOMAXPROCS(0), maxReadWorkers, len(files))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				content, err := i.readFile(files[idx])
+				contents[idx] = fileContent{content: content, err: err}
 			}
+		}()
+	}
+	for idx := range files {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+	return contents
+}
+
+// ResolveSources returns the supported files the given paths expand to, in the
+// order Ingest reads them, without reading their content. Sources that cannot be
+// resolved are handled according to the policy.
+func (i *Ingester) ResolveSources(paths []string, policy ErrorPolicy) ([]string, error) {
+	collector := &errorCollector{policy: policy}
+	var files []string
+	for _, path := range SourcePaths(paths) {
+		sourceFiles, err := i.expandSource(path, collector)
+		if err != nil {
+			return nil, err
 		}
+		files = append(files, sourceFiles...)
 	}
+	return files, nil
+}
 
-	if filesProcessed == 0 {
-		return "", fmt.Errorf("no supported files found in the provided paths")
+// expandSource returns the supported files of a source path: the file itself or the
+// files below a directory.
+func (i *Ingester) expandSource(path string, collector *errorCollector) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, collector.add(path, fmt.Errorf("failed to stat path %s: %w", path, err))
+	}
+	if info.IsDir() {
+		return i.walkDirectory(path, collector)
 	}
+	if !i.isSupportedFile(path) {
+		return nil, collector.add(path, fmt.Errorf("file type not supported for ingestion: %s", path))
+	}
+	return []string{path}, nil
+}
 
-	log.Info().Int("files", filesProcessed).Int("total_bytes", contentBuilder.Len()).Msg("Ingestion complete")
-	return contentBuilder.String(), nil
+// walkDirectory returns the supported files below root in lexical order, skipping
+// paths excluded by the root's .docloomignore file, the .gitignore files of the
+// root and the directories below it, or the filter.
+func (i *Ingester) walkDirectory(root string, collector *errorCollector) ([]string, error) {
+	matcher, err := ignore.Load(root)
+	if err != nil {
+		return nil, collector.add(root, err)
+	}
+
+	var files []string
+	err = filepath.Walk(root, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			// Unreadable entries are skipped unless the policy fails the run
+			return collector.add(filePath, fmt.Errorf("failed to walk %s: %w", filePath, err))
+		}
+		rel, relErr := filepath.Rel(root, filePath)
+		if relErr == nil && matcher.Match(rel, fileInfo.IsDir()) {
+			log.Debug().Str("path", filePath).Msg("Excluded by " + ignore.FileName + " or " + ignore.GitignoreFileName)
+			if fileInfo.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fileInfo.IsDir() {
+			if relErr != nil || rel == "." {
+				return nil
+			}
+			if i.filter.skipDir(rel) {
+				log.Debug().Str("path", filePath).Msg("Excluded by an exclude glob")
+				return filepath.SkipDir
+			}
+			if matcher, err = ignore.LoadNested(matcher, root, rel); err != nil {
+				return collector.add(filePath, err)
+			}
+			return nil
+		}
+		if !i.isSupportedFile(filePath) {
+			return nil
+		}
+		if relErr == nil && !i.filter.keepFile(rel) {
+			log.Debug().Str("path", filePath).Msg("Not selected by the include and exclude globs")
+			return nil
+		}
+		files = append(files, filePath)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory %s: %w", root, err)
+	}
+	return files, nil
 }
 
 // isSupportedFile checks if a file has a supported extension.
@@ -111,11 +272,24 @@ func (i *Ingester) isSupportedFile(path string) bool {
 	return false
 }
 
-// readFile reads the entire content of a file, with special handling for PDFs.
+// ReadText returns the text of a single file as Ingest includes it, such as the
+// extracted text of a PDF or an HTML page.
+func (i *Ingester) ReadText(path string) (string, error) {
+	return i.readFile(path)
+}
+
+// readFile reads the entire content of a file, with special handling for PDFs and
+// HTML pages.
 func (i *Ingester) readFile(path string) (string, error) {
-	// Check if it's a PDF file
-	if strings.ToLower(filepath.Ext(path)) == ".pdf" {
-		return i.extractPDFText(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return i.extractPDFTextCached(path)
+	case ".html", ".htm":
+		content, err := os.ReadFile(path) // #nosec G304 - path is a user-provided source file
+		if err != nil {
+			return "", err
+		}
+		return HTMLToText(string(content)), nil
 	}
 
 	// Regular file reading for non-PDF files
@@ -133,37 +307,58 @@ func (i *Ingester) readFile(path string) (string, error) {
 	return string(content), nil
 }
 
-// extractPDFText extracts text from a PDF file using pdftotext.
-func (i *Ingester) extractPDFText(path string) (string, error) {
-	// Check if pdftotext is available
-	if _, err := exec.LookPath("pdftotext"); err != nil {
-		log.Warn().Str("file", path).Msg("pdftotext not found in PATH, attempting basic extraction")
-		// Fall back to reading the file as-is (will likely produce garbled output)
-		// In a production system, we might want to use a Go PDF library here
-		return "", fmt.Errorf("pdftotext not available: install poppler-utils to enable PDF extraction")
+// extractPDFTextCached returns the text of a PDF file from the cache, extracting and
+// caching it when the file's content has not been seen before.
+func (i *Ingester) extractPDFTextCached(path string) (string, error) {
+	content, err := os.ReadFile(path) // #nosec G304 - path is a user-provided source file
+	if err != nil {
+		return "", err
+	}
+	if i.cache == nil {
+		return i.extractPDFText(path, content)
 	}
 
-	// Use pdftotext to extract text from PDF
-	cmd := exec.Command("pdftotext", "-layout", "-nopgbrk", path, "-")
+	version := pdfExtractorVersion
+	if i.ocr != nil {
+		version += "+ocr"
+	}
+	key := cacheKey(content, version)
+	if text, ok := i.cache.Get(key); ok {
+		log.Debug().Str("file", path).Msg("Using cached PDF text")
+		return text, nil
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	text, err := i.extractPDFText(path, content)
+	if err != nil {
+		return "", err
+	}
+	if err := i.cache.Put(key, text); err != nil {
+		log.Warn().Err(err).Str("file", path).Msg("Failed to cache extracted PDF text")
+	}
+	return text, nil
+}
 
-	if err := cmd.Run(); err != nil {
-		log.Error().
-			Err(err).
-			Str("file", path).
-			Str("stderr", stderr.String()).
-			Msg("Failed to extract text from PDF")
-		return "", fmt.Errorf("failed to extract PDF text: %w (stderr: %s)", err, stderr.String())
+// extractPDFText extracts the text of a PDF file, with a marker before every page.
+func (i *Ingester) extractPDFText(path string, content []byte) (string, error) {
+	doc, err := pdf.Extract(content, pdf.Options{OCR: i.ocr})
+	if err != nil {
+		return "", fmt.Errorf("failed to extract PDF text: %w", err)
+	}
+	for _, warning := range doc.Warnings {
+		log.Warn().Str("file", path).Str("problem", warning).Msg("PDF text extraction incomplete")
 	}
 
-	text := stdout.String()
-	if text == "" {
+	text := doc.Text()
+	empty := true
+	for _, page := range doc.Pages {
+		if strings.TrimSpace(page.Text) != "" {
+			empty = false
+			break
+		}
+	}
+	if empty {
 		log.Warn().Str("file", path).Msg("PDF extraction produced empty text")
 	}
-
 	return text, nil
 }
 
@@ -0,0 +1,73 @@
+package ingest
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrorPolicy controls how sources that cannot be read are handled.
+type ErrorPolicy string
+
+// Source error policies.
+const (
+	PolicyFail   ErrorPolicy = "fail"   // Abort ingestion on the first error
+	PolicyWarn   ErrorPolicy = "warn"   // Skip the source with a warning
+	PolicyIgnore ErrorPolicy = "ignore" // Skip the source silently
+)
+
+// ParseErrorPolicy validates a policy name. An empty name selects PolicyWarn.
+func ParseErrorPolicy(name string) (ErrorPolicy, error) {
+	switch policy := ErrorPolicy(name); policy {
+	case "":
+		return PolicyWarn, nil
+	case PolicyFail, PolicyWarn, PolicyIgnore:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown source error policy %q (expected fail, warn or ignore)", name)
+	}
+}
+
+// SourceError records a source that was not ingested.
+type SourceError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// Result is the outcome of ingesting a set of sources.
+type Result struct {
+	Content string        // Concatenated content of the ingested files
+	Files   []string      // Files included in Content
+	Roots   []Root        // Source roots in the order given, with the files read from each
+	Errors  []SourceError // Sources skipped because of errors
+
+	// Location of the text of each file in Content, in the order of Files
+	Extents []Extent
+}
+
+// Extent is the location of a file's text in the ingested content.
+type Extent struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// errorCollector records source errors and applies the policy to them.
+type errorCollector struct {
+	policy ErrorPolicy
+	errors []SourceError
+}
+
+// add records an error for path. It returns the error when the policy fails the run
+// and nil when the source should be skipped.
+func (c *errorCollector) add(path string, err error) error {
+	c.errors = append(c.errors, SourceError{Path: path, Error: err.Error()})
+	switch c.policy {
+	case PolicyFail:
+		return err
+	case PolicyIgnore:
+		log.Debug().Err(err).Str("path", path).Msg("Skipping source")
+	default:
+		log.Warn().Err(err).Str("path", path).Msg("Skipping source")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// labelPattern matches the label of a labeled source such as "api=../billing".
+var labelPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Root is one source root of an ingestion and the files read from it.
+type Root struct {
+	Label string   `json:"label"`
+	Path  string   `json:"path"`
+	Files []string `json:"files,omitempty"`
+}
+
+// SplitSource splits a source of the form "label=path" into its label and path. A
+// source without a label returns an empty label and the source unchanged.
+func SplitSource(source string) (label, path string) {
+	name, rest, found := strings.Cut(source, "=")
+	if !found || rest == "" || !labelPattern.MatchString(name) {
+		return "", source
+	}
+	return name, rest
+}
+
+// SourcePaths returns the paths of sources, dropping their labels.
+func SourcePaths(sources []string) []string {
+	paths := make([]string, len(sources))
+	for idx, source := range sources {
+		_, paths[idx] = SplitSource(source)
+	}
+	return paths
+}
+
+// sourceRoots returns the roots of sources. Unlabeled roots are labeled with the base
+// name of their path; repeated labels get a numeric suffix.
+func sourceRoots(sources []string) []Root {
+	roots := make([]Root, len(sources))
+	seen := make(map[string]int)
+	for idx, source := range sources {
+		label, path := SplitSource(source)
+		if label == "" {
+			label = filepath.Base(filepath.Clean(path))
+		}
+		seen[label]++
+		if count := seen[label]; count > 1 {
+			label = fmt.Sprintf("%s-%d", label, count)
+		}
+		roots[idx] = Root{Label: label, Path: path}
+	}
+	return roots
+}
+
+// FetchRemoteSources downloads the remote sources into a temporary directory and
+// returns the sources with each URL replaced by its local copy, keeping labels. Git
+// repositories are shallow-cloned, checking out ref (a branch, tag or commit) when it
+// is set, unless the URL names its own ref after a # (https://host/org/repo.git#v1.2).
+// Other http(s) URLs are cloned when they serve a git repository and otherwise
+// downloaded as web pages. Unlabeled clones are labeled with the repository name. The
+// returned function removes the downloads.
+func FetchRemoteSources(ctx context.Context, sources []string, ref string) ([]string, func(), error) {
+	resolved := make([]string, len(sources))
+	copy(resolved, sources)
+	var dir string
+	cleanup := func() {
+		if dir != "" {
+			_ = os.RemoveAll(dir)
+		}
+	}
+
+	for idx, source := range sources {
+		label, path := SplitSource(source)
+		gitURL := IsGitURL(path)
+		if !gitURL && !IsWebURL(path) {
+			continue
+		}
+		if dir == "" {
+			var err error
+			if dir, err = os.MkdirTemp("", "docloom-sources-"); err != nil {
+				return nil, cleanup, fmt.Errorf("failed to create download directory: %w", err)
+			}
+		}
+
+		var local string
+		var err error
+		target := filepath.Join(dir, fmt.Sprintf("%d", idx))
+		if gitURL || isGitRepository(ctx, path) {
+			local, err = cloneGitSource(ctx, path, ref, target)
+		} else {
+			local, err = fetchWebPage(ctx, path, target)
+		}
+		if err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		if label != "" {
+			resolved[idx] = label + "=" + local
+		} else {
+			resolved[idx] = local
+		}
+	}
+	return resolved, cleanup, nil
+}
@@ -0,0 +1,200 @@
+package ingest
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Stats describes what an ingestion gives the model: the files by extension and
+// language, the bytes by directory and the share of tokens of each top-level folder
+// of the source roots.
+type Stats struct {
+	Files  int `json:"files"`
+	Bytes  int `json:"bytes"`
+	Tokens int `json:"tokens"`
+
+	Extensions    map[string]int `json:"extensions"`               // Files per extension
+	Directories   map[string]int `json:"directories"`              // Bytes of text per directory
+	Languages     map[string]int `json:"languages,omitempty"`      // Files per detected natural language
+	CodeLanguages map[string]int `json:"code_languages,omitempty"` // Files per programming language
+
+	// Top-level folders of the source roots, with the most tokens first
+	Folders []FolderShare `json:"folders"`
+}
+
+// FolderShare is the part of the ingested content that comes from one top-level
+// folder of a source root, named "<root label>/<folder>". Files directly in a root,
+// and roots that are files, count as the root's label.
+type FolderShare struct {
+	Folder string  `json:"folder"`
+	Files  int     `json:"files"`
+	Tokens int     `json:"tokens"`
+	Share  float64 `json:"share"` // Fraction of all tokens
+}
+
+// codeLanguages maps file extensions to programming languages.
+var codeLanguages = map[string]string{
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++", ".cs": "C#",
+	".go": "Go", ".java": "Java", ".js": "JavaScript", ".jsx": "JavaScript", ".kt": "Kotlin",
+	".php": "PHP", ".py": "Python", ".rb": "Ruby", ".rs": "Rust", ".scala": "Scala",
+	".sh": "Shell", ".sql": "SQL", ".swift": "Swift", ".ts": "TypeScript", ".tsx": "TypeScript",
+}
+
+// stopwords holds frequent words of the Latin-script languages that are detected.
+var stopwords = map[string][]string{
+	"English":    {"the", "and", "of", "to", "is", "in", "that", "for", "with", "are"},
+	"German":     {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "auf", "für"},
+	"French":     {"le", "la", "les", "et", "est", "des", "une", "pour", "dans", "que"},
+	"Spanish":    {"el", "la", "los", "y", "es", "las", "una", "para", "con", "que"},
+	"Italian":    {"il", "di", "che", "è", "la", "per", "una", "sono", "gli", "con"},
+	"Portuguese": {"o", "de", "que", "não", "uma", "para", "com", "os", "é", "da"},
+	"Dutch":      {"de", "het", "een", "en", "van", "is", "niet", "op", "met", "voor"},
+}
+
+// languageSample is the number of bytes of a file examined to detect its language.
+const languageSample = 16 << 10
+
+// Stats computes the statistics of the ingestion, counting tokens with count.
+// Results without extents, such as those restored from older checkpoints, count
+// their files but not the text of each.
+func (r *Result) Stats(count func(string) int) *Stats {
+	stats := &Stats{
+		Files:         len(r.Files),
+		Bytes:         len(r.Content),
+		Extensions:    make(map[string]int),
+		Directories:   make(map[string]int),
+		Languages:     make(map[string]int),
+		CodeLanguages: make(map[string]int),
+	}
+	folders := make(map[string]*FolderShare)
+	next := 0
+	for _, root := range r.Roots {
+		for _, file := range root.Files {
+			text := ""
+			if next < len(r.Extents) {
+				extent := r.Extents[next]
+				text = r.Content[extent.Start:extent.End]
+			}
+			next++
+
+			ext := strings.ToLower(filepath.Ext(file))
+			stats.Extensions[ext]++
+			stats.Directories[filepath.Dir(file)] += len(text)
+			if language, ok := codeLanguages[ext]; ok {
+				stats.CodeLanguages[language]++
+			} else if language := detectLanguage(text); language != "" {
+				stats.Languages[language]++
+			}
+
+			name := topLevelFolder(root, file)
+			folder, ok := folders[name]
+			if !ok {
+				folder = &FolderShare{Folder: name}
+				folders[name] = folder
+			}
+			tokens := count(text)
+			folder.Files++
+			folder.Tokens += tokens
+			stats.Tokens += tokens
+		}
+	}
+
+	for _, folder := range folders {
+		if stats.Tokens > 0 {
+			folder.Share = float64(folder.Tokens) / float64(stats.Tokens)
+		}
+		stats.Folders = append(stats.Folders, *folder)
+	}
+	sort.Slice(stats.Folders, func(i, j int) bool {
+		if stats.Folders[i].Tokens != stats.Folders[j].Tokens {
+			return stats.Folders[i].Tokens > stats.Folders[j].Tokens
+		}
+		return stats.Folders[i].Folder < stats.Folders[j].Folder
+	})
+	return stats
+}
+
+// topLevelFolder names the top-level folder of root that file is in.
+func topLevelFolder(root Root, file string) string {
+	rel, err := filepath.Rel(root.Path, file)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return root.Label
+	}
+	first, _, nested := strings.Cut(filepath.ToSlash(rel), "/")
+	if !nested {
+		return root.Label
+	}
+	return root.Label + "/" + first
+}
+
+// detectLanguage guesses the natural language of text from its script and, for
+// Latin script, from the frequency of common words. It returns an empty string when
+// the text has too few letters or words to tell.
+func detectLanguage(text string) string {
+	if len(text) > languageSample {
+		text = text[:languageSample]
+	}
+	var letters, latin, kana, han, hangul, cyrillic, greek, arabic int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		}
+	}
+	if letters < 20 {
+		return ""
+	}
+	switch {
+	case kana > letters/10:
+		return "Japanese"
+	case hangul > letters/4:
+		return "Korean"
+	case han > letters/4:
+		return "Chinese"
+	case cyrillic > letters/2:
+		return "Russian"
+	case greek > letters/2:
+		return "Greek"
+	case arabic > letters/2:
+		return "Arabic"
+	case latin < letters/2:
+		return ""
+	}
+
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		counts[word]++
+	}
+	best, bestScore := "", 0
+	for language, words := range stopwords {
+		score := 0
+		for _, word := range words {
+			score += counts[word]
+		}
+		if score > bestScore || (score == bestScore && language < best) {
+			best, bestScore = language, score
+		}
+	}
+	if bestScore < 3 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,59 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResult_Stats tests that ingestion statistics count files by extension and
+// language and the token share of each top-level folder.
+func TestResult_Stats(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"docs/guide/intro.md": "The ledger service is the owner of the balances and the source of truth for the accounts that are in use.",
+		"docs/guide/setup.md": "Der Dienst ist nicht für die Abrechnung zuständig, und die Daten werden mit dem Konto auf den Server geschrieben.",
+		"docs/readme.txt":     "short",
+		"docs/api/main.go":    "package main\n\nfunc main() {}\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+	notes := filepath.Join(tempDir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("Notes"), 0644))
+
+	ingester := NewIngester()
+	ingester.AddSupportedExtension(".go")
+	result, err := ingester.Ingest([]string{filepath.Join(tempDir, "docs"), "extra=" + notes}, PolicyFail)
+	require.NoError(t, err)
+	require.Len(t, result.Extents, 5)
+	for idx, extent := range result.Extents {
+		content, err := os.ReadFile(result.Files[idx])
+		require.NoError(t, err)
+		assert.Equal(t, string(content), result.Content[extent.Start:extent.End])
+	}
+
+	stats := result.Stats(func(text string) int { return len(text) })
+	assert.Equal(t, 5, stats.Files)
+	assert.Equal(t, map[string]int{".md": 2, ".txt": 2, ".go": 1}, stats.Extensions)
+	assert.Equal(t, map[string]int{"English": 1, "German": 1}, stats.Languages)
+	assert.Equal(t, map[string]int{"Go": 1}, stats.CodeLanguages)
+	assert.Equal(t, len(files["docs/guide/intro.md"])+len(files["docs/guide/setup.md"]), stats.Directories[filepath.Join(tempDir, "docs", "guide")])
+
+	require.Len(t, stats.Folders, 4)
+	assert.Equal(t, "docs/guide", stats.Folders[0].Folder)
+	assert.Equal(t, 2, stats.Folders[0].Files)
+	assert.Equal(t, "docs/api", stats.Folders[1].Folder)
+	assert.Equal(t, "docs", stats.Folders[2].Folder)
+	assert.Equal(t, "extra", stats.Folders[3].Folder)
+	total := 0.0
+	for _, folder := range stats.Folders {
+		total += folder.Share
+	}
+	assert.InDelta(t, 1.0, total, 1e-9)
+}
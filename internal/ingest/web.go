@@ -0,0 +1,132 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxWebPageBytes bounds the size of a downloaded web page.
+const maxWebPageBytes = 20 << 20
+
+var (
+	// webClient downloads web page sources
+	webClient = &http.Client{Timeout: 60 * time.Second}
+
+	// unsafeNamePattern matches the characters replaced in the file names of pages
+	unsafeNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+	// webPageExtensions maps the content types of web pages that can be ingested to the
+	// extension they are saved with
+	webPageExtensions = map[string]string{
+		"text/html":             ".html",
+		"application/xhtml+xml": ".html",
+		"text/plain":            ".txt",
+		"text/markdown":         ".md",
+		"text/x-markdown":       ".md",
+		"application/pdf":       ".pdf",
+	}
+)
+
+// IsWebURL reports whether a source path is an http(s) URL.
+func IsWebURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// isGitRepository reports whether an http(s) URL is a git repository served over the
+// smart HTTP protocol, such as https://github.com/org/repo, by asking for its refs as
+// git does.
+func isGitRepository(ctx context.Context, repoURL string) bool {
+	base, _, _ := strings.Cut(repoURL, "#")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := webClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = resp.Body.Close() }()
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return resp.StatusCode == http.StatusOK && mediaType == "application/x-git-upload-pack-advertisement"
+}
+
+// fetchWebPage downloads the web page at pageURL into dir and returns the path of the
+// file, named after the page with the extension of its content type, so that it is
+// ingested like a local file.
+func fetchWebPage(ctx context.Context, pageURL, dir string) (string, error) {
+	log.Info().Str("url", RedactURL(pageURL)).Msg("Fetching web page source")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid web source %s: %w", RedactURL(pageURL), err)
+	}
+	req.Header.Set("User-Agent", "docloom")
+	req.Header.Set("Accept", "text/html, application/xhtml+xml, text/markdown, text/plain;q=0.9, application/pdf;q=0.8")
+
+	resp, err := webClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", RedactURL(pageURL), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to fetch %s: %s", RedactURL(pageURL), resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext, ok := webPageExtensions[mediaType]
+	if !ok {
+		return "", fmt.Errorf("content type %q of %s is not supported for ingestion", mediaType, RedactURL(pageURL))
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxWebPageBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", RedactURL(pageURL), err)
+	}
+	if len(content) > maxWebPageBytes {
+		return "", fmt.Errorf("%s is larger than %d MB", RedactURL(pageURL), maxWebPageBytes>>20)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+	path := filepath.Join(dir, pageName(pageURL)+ext)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return "", fmt.Errorf("failed to save %s: %w", RedactURL(pageURL), err)
+	}
+	return path, nil
+}
+
+// pageName returns a file name for the page at pageURL: the last segment of its path
+// without extension, such as Architecture+Overview for
+// https://wiki.example.com/display/ENG/Architecture+Overview, or its host name.
+func pageName(pageURL string) string {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return "page"
+	}
+	name := strings.Trim(parsed.Path, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.Trim(unsafeNamePattern.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		name = strings.Trim(unsafeNamePattern.ReplaceAllString(parsed.Hostname(), "-"), "-.")
+	}
+	if name == "" {
+		return "page"
+	}
+	return name
+}
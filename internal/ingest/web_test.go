@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/display/ENG/Architecture+Overview", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><body><nav>Spaces</nav><h1>Overview</h1><p>Three services.</p></body></html>`))
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("plain notes"))
+	})
+	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	})
+	mux.HandleFunc("/acme/billing/info/refs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "git-upload-pack", r.URL.Query().Get("service"))
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIsWebURL(t *testing.T) {
+	assert.True(t, IsWebURL("https://wiki.example.com/page"))
+	assert.True(t, IsWebURL("HTTP://intranet/docs"))
+	assert.False(t, IsWebURL("./docs/page.html"))
+	assert.False(t, IsWebURL("git@github.com:acme/billing.git"))
+}
+
+func TestIsGitRepository(t *testing.T) {
+	server := newWebServer(t)
+	assert.True(t, isGitRepository(context.Background(), server.URL+"/acme/billing"))
+	assert.True(t, isGitRepository(context.Background(), server.URL+"/acme/billing/#main"))
+	assert.False(t, isGitRepository(context.Background(), server.URL+"/display/ENG/Architecture+Overview"))
+}
+
+func TestPageName(t *testing.T) {
+	assert.Equal(t, "Architecture-Overview", pageName("https://wiki.example.com/display/ENG/Architecture%20Overview"))
+	assert.Equal(t, "runbook", pageName("https://docs.example.com/ops/runbook.html?version=2"))
+	assert.Equal(t, "docs.example.com", pageName("https://docs.example.com/"))
+}
+
+func TestFetchRemoteSources_WebPages(t *testing.T) {
+	server := newWebServer(t)
+
+	sources, cleanup, err := FetchRemoteSources(context.Background(), []string{
+		"./docs",
+		"wiki=" + server.URL + "/display/ENG/Architecture+Overview",
+		server.URL + "/notes.txt",
+	}, "")
+	require.NoError(t, err)
+
+	require.Len(t, sources, 3)
+	assert.Equal(t, "./docs", sources[0])
+	label, page := SplitSource(sources[1])
+	assert.Equal(t, "wiki", label)
+	assert.Equal(t, "Architecture-Overview.html", filepath.Base(page))
+	assert.Equal(t, "notes.txt", filepath.Base(sources[2]))
+
+	// Downloaded pages are ingested like local files, without the page chrome
+	result, err := NewIngester().Ingest(sources[1:], PolicyFail)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content, "# Overview\n\nThree services.")
+	assert.NotContains(t, result.Content, "Spaces")
+	assert.Contains(t, result.Content, "plain notes")
+
+	cleanup()
+	_, statErr := os.Stat(page)
+	assert.True(t, os.IsNotExist(statErr), "downloads are removed")
+}
+
+func TestFetchRemoteSources_WebPageErrors(t *testing.T) {
+	server := newWebServer(t)
+
+	_, cleanup, err := FetchRemoteSources(context.Background(), []string{server.URL + "/missing"}, "")
+	cleanup()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404 Not Found")
+
+	_, cleanup, err = FetchRemoteSources(context.Background(), []string{server.URL + "/logo.png"}, "")
+	cleanup()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `content type "image/png"`)
+}
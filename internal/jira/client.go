@@ -0,0 +1,166 @@
+// Package jira exports structured technical-debt items from generated documents into Jira issues.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is a minimal, rate-limited Jira REST (v2) client.
+type Client struct {
+	lastRequest time.Time
+	httpClient  *http.Client
+	baseURL     string
+	user        string
+	token       string
+	interval    time.Duration
+	mu          sync.Mutex
+}
+
+// NewClient creates a Jira client. When user is empty the token is sent as a bearer
+// token (Jira Data Center PAT), otherwise basic auth is used (Jira Cloud API token).
+// requestsPerSecond limits the request rate; zero disables limiting.
+func NewClient(baseURL, user, token string, requestsPerSecond float64) *Client {
+	var interval time.Duration
+	if requestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		user:       user,
+		token:      token,
+		interval:   interval,
+	}
+}
+
+// issueFields is the subset of Jira issue fields written by the exporter.
+type issueFields struct {
+	Project     *keyRef  `json:"project,omitempty"`
+	IssueType   *nameRef `json:"issuetype,omitempty"`
+	Priority    *nameRef `json:"priority,omitempty"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+type keyRef struct {
+	Key string `json:"key"`
+}
+
+type nameRef struct {
+	Name string `json:"name"`
+}
+
+// FindByLabel returns the key of the first issue in the project carrying the label,
+// or an empty string when none exists.
+func (c *Client) FindByLabel(ctx context.Context, project, label string) (string, error) {
+	jql := fmt.Sprintf(`project = "%s" AND labels = "%s"`, project, label)
+	query := url.Values{"jql": {jql}, "fields": {"key"}, "maxResults": {"1"}}
+
+	var result struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &result); err != nil {
+		return "", err
+	}
+	if len(result.Issues) == 0 {
+		return "", nil
+	}
+	return result.Issues[0].Key, nil
+}
+
+// Create creates a new issue and returns its key.
+func (c *Client) Create(ctx context.Context, project, issueType string, fields issueFields) (string, error) {
+	fields.Project = &keyRef{Key: project}
+	fields.IssueType = &nameRef{Name: issueType}
+
+	var result struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &result); err != nil {
+		return "", err
+	}
+	return result.Key, nil
+}
+
+// Update overwrites the exporter-managed fields of an existing issue.
+func (c *Client) Update(ctx context.Context, key string, fields issueFields) error {
+	return c.do(ctx, http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(key), map[string]interface{}{"fields": fields}, nil)
+}
+
+// do performs a rate-limited JSON request.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal Jira request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create Jira request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.token)
+	} else if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("jira %s %s returned status %d: %s", method, strings.SplitN(path, "?", 2)[0], resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode Jira response: %w", err)
+		}
+	}
+	return nil
+}
+
+// wait blocks until the next request is allowed by the rate limit.
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.interval > 0 && !c.lastRequest.IsZero() {
+		if delay := c.interval - time.Since(c.lastRequest); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	c.lastRequest = time.Now()
+	return nil
+}
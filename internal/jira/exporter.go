@@ -0,0 +1,195 @@
+package jira
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultItemsPath is where the technical-debt-summary template stores its debt items.
+const DefaultItemsPath = "summary.items"
+
+// Operations planned for each debt item.
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+)
+
+// Item is a single technical-debt item extracted from a JSON sidecar.
+type Item struct {
+	StableKey   string // Label identifying the item across runs
+	Title       string
+	Description string
+	Priority    string // Jira priority name (empty when unknown)
+}
+
+// Action describes what the exporter does (or would do) for an item.
+type Action struct {
+	Operation string
+	IssueKey  string // Existing or created issue key
+	Item      Item
+}
+
+// ExtractItems reads debt items from the field path in the generated fields.
+// Items may be plain strings or objects with title/name/summary, description and
+// priority/severity properties.
+func ExtractItems(fields map[string]interface{}, path string) ([]Item, error) {
+	var current interface{} = fields
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field path %s not found in document", path)
+		}
+		current, ok = object[part]
+		if !ok {
+			return nil, fmt.Errorf("field path %s not found in document", path)
+		}
+	}
+
+	rawItems, ok := current.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("field %s is not an array", path)
+	}
+
+	items := make([]Item, 0, len(rawItems))
+	for i, raw := range rawItems {
+		item, err := toItem(raw)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// toItem converts a raw JSON value into an Item.
+func toItem(raw interface{}) (Item, error) {
+	var item Item
+	switch v := raw.(type) {
+	case string:
+		item.Title = v
+	case map[string]interface{}:
+		item.Title = firstString(v, "title", "name", "summary")
+		item.Description = firstString(v, "description", "details", "impact")
+		item.Priority = mapPriority(firstString(v, "priority", "severity"))
+	default:
+		return item, fmt.Errorf("unsupported item type %T", raw)
+	}
+
+	item.Title = strings.TrimSpace(item.Title)
+	if item.Title == "" {
+		return item, fmt.Errorf("item has no title")
+	}
+	item.StableKey = StableKey(item.Title)
+	return item, nil
+}
+
+// firstString returns the first non-empty string property among the keys.
+func firstString(object map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := object[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// mapPriority maps common severity words onto Jira's default priority scheme.
+func mapPriority(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "critical", "blocker", "highest":
+		return "Highest"
+	case "high", "major":
+		return "High"
+	case "medium", "moderate":
+		return "Medium"
+	case "low", "minor":
+		return "Low"
+	default:
+		return ""
+	}
+}
+
+// StableKey derives a label that identifies a debt item across regenerations.
+func StableKey(title string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(title)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return "docloom-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// Exporter turns debt items into Jira issues.
+type Exporter struct {
+	client    *Client
+	project   string
+	issueType string
+	labels    []string
+}
+
+// NewExporter creates an exporter for the given project.
+func NewExporter(client *Client, project, issueType string, labels []string) *Exporter {
+	if issueType == "" {
+		issueType = "Task"
+	}
+	return &Exporter{client: client, project: project, issueType: issueType, labels: labels}
+}
+
+// Plan looks up existing issues and decides whether each item is created or updated.
+// Plan only reads from Jira and is used for dry-run previews.
+func (e *Exporter) Plan(ctx context.Context, items []Item) ([]Action, error) {
+	actions := make([]Action, 0, len(items))
+	for _, item := range items {
+		key, err := e.client.FindByLabel(ctx, e.project, item.StableKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up %q: %w", item.Title, err)
+		}
+		action := Action{Operation: OperationCreate, Item: item}
+		if key != "" {
+			action.Operation = OperationUpdate
+			action.IssueKey = key
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// Apply executes the planned actions, returning them with created issue keys filled in.
+func (e *Exporter) Apply(ctx context.Context, actions []Action) ([]Action, error) {
+	for i := range actions {
+		action := &actions[i]
+		fields := e.fieldsFor(action.Item)
+
+		switch action.Operation {
+		case OperationUpdate:
+			if err := e.client.Update(ctx, action.IssueKey, fields); err != nil {
+				return actions[:i], fmt.Errorf("failed to update %s: %w", action.IssueKey, err)
+			}
+		default:
+			key, err := e.client.Create(ctx, e.project, e.issueType, fields)
+			if err != nil {
+				return actions[:i], fmt.Errorf("failed to create issue for %q: %w", action.Item.Title, err)
+			}
+			action.IssueKey = key
+		}
+
+		log.Info().Str("operation", action.Operation).Str("issue", action.IssueKey).Str("title", action.Item.Title).Msg("Exported debt item to Jira")
+	}
+	return actions, nil
+}
+
+// fieldsFor builds the Jira fields for an item.
+func (e *Exporter) fieldsFor(item Item) issueFields {
+	labels := append([]string{item.StableKey}, e.labels...)
+	fields := issueFields{
+		Summary:     item.Title,
+		Description: item.Description,
+		Labels:      labels,
+	}
+	if item.Priority != "" {
+		fields.Priority = &nameRef{Name: item.Priority}
+	}
+	return fields
+}
@@ -0,0 +1,131 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractItems(t *testing.T) {
+	fields := map[string]interface{}{
+		"summary": map[string]interface{}{
+			"items": []interface{}{
+				"Replace legacy auth module",
+				map[string]interface{}{"title": "Upgrade database driver", "description": "Driver is EOL", "severity": "High"},
+				map[string]interface{}{"name": "Remove dead feature flags", "priority": "unknown"},
+			},
+		},
+	}
+
+	items, err := ExtractItems(fields, DefaultItemsPath)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	assert.Equal(t, "Replace legacy auth module", items[0].Title)
+	assert.Equal(t, "Upgrade database driver", items[1].Title)
+	assert.Equal(t, "Driver is EOL", items[1].Description)
+	assert.Equal(t, "High", items[1].Priority)
+	assert.Equal(t, "Remove dead feature flags", items[2].Title)
+	assert.Empty(t, items[2].Priority)
+	assert.True(t, strings.HasPrefix(items[0].StableKey, "docloom-"))
+}
+
+func TestExtractItems_Errors(t *testing.T) {
+	_, err := ExtractItems(map[string]interface{}{}, DefaultItemsPath)
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = ExtractItems(map[string]interface{}{"summary": map[string]interface{}{"items": "text"}}, DefaultItemsPath)
+	assert.ErrorContains(t, err, "not an array")
+
+	_, err = ExtractItems(map[string]interface{}{"summary": map[string]interface{}{"items": []interface{}{map[string]interface{}{}}}}, DefaultItemsPath)
+	assert.ErrorContains(t, err, "no title")
+}
+
+func TestStableKey_IgnoresCaseAndWhitespace(t *testing.T) {
+	assert.Equal(t, StableKey("Upgrade  database driver"), StableKey(" upgrade database DRIVER "))
+	assert.NotEqual(t, StableKey("Upgrade database driver"), StableKey("Upgrade web framework"))
+}
+
+// fakeJira records requests and serves a single existing issue.
+type fakeJira struct {
+	existingLabel string
+	created       []map[string]interface{}
+	updated       []string
+	mu            sync.Mutex
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/search":
+		issues := []map[string]string{}
+		if strings.Contains(r.URL.Query().Get("jql"), f.existingLabel) {
+			issues = append(issues, map[string]string{"key": "DEBT-1"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues})
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.created = append(f.created, body["fields"].(map[string]interface{}))
+		_ = json.NewEncoder(w).Encode(map[string]string{"key": "DEBT-2"})
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/rest/api/2/issue/"):
+		f.updated = append(f.updated, strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func TestExporter_PlanAndApply(t *testing.T) {
+	items := []Item{
+		{Title: "Upgrade database driver", StableKey: StableKey("Upgrade database driver"), Priority: "High"},
+		{Title: "Remove dead feature flags", StableKey: StableKey("Remove dead feature flags")},
+	}
+	fake := &fakeJira{existingLabel: items[0].StableKey}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	exporter := NewExporter(NewClient(server.URL, "user", "token", 0), "DEBT", "", []string{"tech-debt"})
+
+	actions, err := exporter.Plan(context.Background(), items)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, OperationUpdate, actions[0].Operation)
+	assert.Equal(t, "DEBT-1", actions[0].IssueKey)
+	assert.Equal(t, OperationCreate, actions[1].Operation)
+
+	// Planning must not modify anything
+	assert.Empty(t, fake.created)
+	assert.Empty(t, fake.updated)
+
+	applied, err := exporter.Apply(context.Background(), actions)
+	require.NoError(t, err)
+	assert.Equal(t, "DEBT-2", applied[1].IssueKey)
+	assert.Equal(t, []string{"DEBT-1"}, fake.updated)
+	require.Len(t, fake.created, 1)
+
+	created := fake.created[0]
+	assert.Equal(t, "Remove dead feature flags", created["summary"])
+	assert.Equal(t, map[string]interface{}{"key": "DEBT"}, created["project"])
+	assert.Equal(t, map[string]interface{}{"name": "Task"}, created["issuetype"])
+	assert.Equal(t, []interface{}{items[1].StableKey, "tech-debt"}, created["labels"])
+}
+
+func TestClient_ReportsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "", "token", 0).FindByLabel(context.Background(), "DEBT", "docloom-x")
+	assert.ErrorContains(t, err, "status 401")
+}
@@ -0,0 +1,189 @@
+// Package notify posts generation status messages to chat webhooks (Slack, Microsoft Teams).
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/config"
+)
+
+// Status represents the outcome of a generation run.
+type Status string
+
+const (
+	// StatusSuccess indicates the document was generated.
+	StatusSuccess Status = "success"
+	// StatusFailure indicates the generation run failed.
+	StatusFailure Status = "failure"
+)
+
+// Supported webhook types.
+const (
+	TypeSlack   = "slack"
+	TypeTeams   = "teams"
+	TypeGeneric = "generic"
+)
+
+// Event describes a completed generation run.
+type Event struct {
+	Title    string        `json:"title"`
+	Template string        `json:"template"`
+	Status   Status        `json:"status"`
+	Output   string        `json:"output"`
+	Link     string        `json:"link,omitempty"`
+	Cost     string        `json:"cost,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Notifier sends events to the configured webhooks.
+type Notifier struct {
+	client   *http.Client
+	webhooks []config.WebhookConfig
+}
+
+// NewNotifier creates a notifier for the configured webhooks.
+func NewNotifier(cfg config.NotificationsConfig) *Notifier {
+	return &Notifier{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		webhooks: cfg.Webhooks,
+	}
+}
+
+// Enabled reports whether any webhooks are configured.
+func (n *Notifier) Enabled() bool {
+	return len(n.webhooks) > 0
+}
+
+// Notify posts the event to every webhook subscribed to its status.
+// All webhooks are attempted; failures are joined into the returned error.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, webhook := range n.webhooks {
+		if !subscribed(webhook, event.Status) {
+			continue
+		}
+		if err := n.post(ctx, webhook, event); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Debug().Str("type", webhookType(webhook)).Str("status", string(event.Status)).Msg("Notification sent")
+	}
+	return errors.Join(errs...)
+}
+
+// post delivers a single webhook payload.
+func (n *Notifier) post(ctx context.Context, webhook config.WebhookConfig, event Event) error {
+	payload, err := json.Marshal(buildPayload(webhookType(webhook), event))
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", webhookType(webhook), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s notification rejected with status %d", webhookType(webhook), resp.StatusCode)
+	}
+	return nil
+}
+
+// buildPayload creates the provider-specific message body.
+func buildPayload(kind string, event Event) interface{} {
+	switch kind {
+	case TypeSlack:
+		return map[string]interface{}{"text": formatText(event, "*")}
+	case TypeTeams:
+		color := "2EB886"
+		if event.Status == StatusFailure {
+			color = "D00000"
+		}
+		return map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    headline(event),
+			"themeColor": color,
+			"title":      headline(event),
+			"text":       strings.ReplaceAll(formatText(event, "**"), "\n", "<br>"),
+		}
+	default:
+		return event
+	}
+}
+
+// headline returns a one-line summary of the event.
+func headline(event Event) string {
+	if event.Status == StatusFailure {
+		return fmt.Sprintf("DocLoom generation failed: %s", event.Title)
+	}
+	return fmt.Sprintf("DocLoom generated: %s", event.Title)
+}
+
+// formatText renders the event as a short markdown message using the given bold marker.
+func formatText(event Event, bold string) string {
+	lines := []string{bold + headline(event) + bold}
+	lines = append(lines, fmt.Sprintf("Template: %s", event.Template))
+	lines = append(lines, fmt.Sprintf("Status: %s", event.Status))
+	if event.Cost != "" {
+		lines = append(lines, fmt.Sprintf("Cost: %s", event.Cost))
+	}
+	lines = append(lines, fmt.Sprintf("Duration: %s", event.Duration.Round(time.Second)))
+	if event.Link != "" {
+		lines = append(lines, fmt.Sprintf("Output: %s", event.Link))
+	} else if event.Output != "" {
+		lines = append(lines, fmt.Sprintf("Output: %s", event.Output))
+	}
+	if event.Error != "" {
+		lines = append(lines, fmt.Sprintf("Error: %s", event.Error))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// webhookType returns the configured type, defaulting to generic.
+func webhookType(webhook config.WebhookConfig) string {
+	if webhook.Type == "" {
+		return TypeGeneric
+	}
+	return strings.ToLower(webhook.Type)
+}
+
+// subscribed reports whether a webhook wants events with the given status.
+func subscribed(webhook config.WebhookConfig, status Status) bool {
+	if len(webhook.On) == 0 {
+		return true
+	}
+	for _, on := range webhook.On {
+		if strings.EqualFold(on, string(status)) {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputLink builds a link to the output file using the configured base URL.
+// Without a base URL the output path is returned unchanged.
+func OutputLink(linkBaseURL, outputFile string) string {
+	if linkBaseURL == "" {
+		return outputFile
+	}
+	return strings.TrimRight(linkBaseURL, "/") + "/" + filepath.Base(outputFile)
+}
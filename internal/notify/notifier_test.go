@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/config"
+)
+
+// captureServer records request bodies sent to it.
+func captureServer(t *testing.T, status int) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &body))
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func TestNotifier_SlackAndTeamsPayloads(t *testing.T) {
+	slack, slackBodies := captureServer(t, http.StatusOK)
+	teams, teamsBodies := captureServer(t, http.StatusOK)
+
+	notifier := NewNotifier(config.NotificationsConfig{
+		Webhooks: []config.WebhookConfig{
+			{URL: slack.URL, Type: "slack"},
+			{URL: teams.URL, Type: "teams"},
+		},
+	})
+	require.True(t, notifier.Enabled())
+
+	event := Event{
+		Title:    "Payments Architecture",
+		Template: "architecture-vision",
+		Status:   StatusSuccess,
+		Output:   "out/payments.html",
+		Link:     "https://docs.example.com/payments.html",
+		Cost:     "$0.42",
+		Duration: 90 * time.Second,
+	}
+	require.NoError(t, notifier.Notify(context.Background(), event))
+
+	require.Len(t, *slackBodies, 1)
+	text := (*slackBodies)[0]["text"].(string)
+	assert.Contains(t, text, "Payments Architecture")
+	assert.Contains(t, text, "Cost: $0.42")
+	assert.Contains(t, text, "https://docs.example.com/payments.html")
+
+	require.Len(t, *teamsBodies, 1)
+	assert.Equal(t, "MessageCard", (*teamsBodies)[0]["@type"])
+	assert.Equal(t, "2EB886", (*teamsBodies)[0]["themeColor"])
+}
+
+func TestNotifier_StatusSubscription(t *testing.T) {
+	server, bodies := captureServer(t, http.StatusOK)
+	notifier := NewNotifier(config.NotificationsConfig{
+		Webhooks: []config.WebhookConfig{{URL: server.URL, On: []string{"failure"}}},
+	})
+
+	require.NoError(t, notifier.Notify(context.Background(), Event{Title: "doc", Status: StatusSuccess}))
+	assert.Empty(t, *bodies, "success events should not reach failure-only webhooks")
+
+	require.NoError(t, notifier.Notify(context.Background(), Event{Title: "doc", Status: StatusFailure, Error: "boom"}))
+	require.Len(t, *bodies, 1)
+	assert.Equal(t, "boom", (*bodies)[0]["error"], "generic webhooks receive the raw event")
+}
+
+func TestNotifier_RejectedWebhook(t *testing.T) {
+	server, _ := captureServer(t, http.StatusInternalServerError)
+	notifier := NewNotifier(config.NotificationsConfig{
+		Webhooks: []config.WebhookConfig{{URL: server.URL, Type: "slack"}},
+	})
+
+	err := notifier.Notify(context.Background(), Event{Title: "doc", Status: StatusSuccess})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 500")
+}
+
+func TestOutputLink(t *testing.T) {
+	assert.Equal(t, "out/doc.html", OutputLink("", "out/doc.html"))
+	assert.Equal(t, "https://docs.example.com/doc.html", OutputLink("https://docs.example.com/", "out/doc.html"))
+}
@@ -0,0 +1,310 @@
+package pdf
+
+import (
+	"bytes"
+	"math"
+)
+
+// matrix is a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func translate(tx, ty float64) matrix {
+	return matrix{1, 0, 0, 1, tx, ty}
+}
+
+// glyph is a character placed on the page, in device space.
+type glyph struct {
+	x, y  float64 // Origin on the baseline
+	width float64 // Horizontal advance
+	size  float64 // Effective font size
+	text  string
+}
+
+// graphicsState holds the parts of the graphics state that affect text placement.
+type graphicsState struct {
+	ctm       matrix
+	font      *font
+	fontSize  float64
+	charSpace float64
+	wordSpace float64
+	scale     float64 // Horizontal scaling, 1 = 100%
+	leading   float64
+	rise      float64
+}
+
+// interpreter runs content streams and collects the glyphs they show.
+type interpreter struct {
+	file   *file
+	fonts  map[interface{}]*font // Loaded fonts by dictionary reference or name
+	glyphs []glyph
+	forms  map[int]bool // Form XObjects being run, to stop recursion
+	depth  int
+}
+
+// maxFormDepth bounds nested form XObjects.
+const maxFormDepth = 8
+
+func newInterpreter(f *file) *interpreter {
+	return &interpreter{file: f, fonts: make(map[interface{}]*font), forms: make(map[int]bool)}
+}
+
+// run interprets a content stream with the given resources and initial CTM.
+func (in *interpreter) run(content []byte, resources dict, ctm matrix) {
+	gs := graphicsState{ctm: ctm, scale: 1}
+	var stack []graphicsState
+	var tm, tlm matrix
+
+	l := &lexer{data: content}
+	var operands []interface{}
+	for {
+		obj, err := l.readObject()
+		if err != nil {
+			if err == errSyntax {
+				operands = operands[:0]
+				continue
+			}
+			return
+		}
+		op, ok := obj.(keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		nums := func(n int) ([]float64, bool) {
+			if len(operands) < n {
+				return nil, false
+			}
+			values := make([]float64, n)
+			for i, o := range operands[len(operands)-n:] {
+				v, ok := in.file.number(o)
+				if !ok {
+					return nil, false
+				}
+				values[i] = v
+			}
+			return values, true
+		}
+
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if v, ok := nums(6); ok {
+				gs.ctm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}.mul(gs.ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) >= 2 {
+				if fontName, ok := operands[len(operands)-2].(name); ok {
+					gs.font = in.font(resources, fontName)
+				}
+				gs.fontSize, _ = in.file.number(operands[len(operands)-1])
+			}
+		case "Tc":
+			if v, ok := nums(1); ok {
+				gs.charSpace = v[0]
+			}
+		case "Tw":
+			if v, ok := nums(1); ok {
+				gs.wordSpace = v[0]
+			}
+		case "Tz":
+			if v, ok := nums(1); ok {
+				gs.scale = v[0] / 100
+			}
+		case "TL":
+			if v, ok := nums(1); ok {
+				gs.leading = v[0]
+			}
+		case "Ts":
+			if v, ok := nums(1); ok {
+				gs.rise = v[0]
+			}
+		case "Td", "TD":
+			if v, ok := nums(2); ok {
+				if op == "TD" {
+					gs.leading = -v[1]
+				}
+				tlm = translate(v[0], v[1]).mul(tlm)
+				tm = tlm
+			}
+		case "Tm":
+			if v, ok := nums(6); ok {
+				tlm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}
+				tm = tlm
+			}
+		case "T*":
+			tlm = translate(0, -gs.leading).mul(tlm)
+			tm = tlm
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				if op == "\"" {
+					if v, ok := nums(3); ok {
+						gs.wordSpace, gs.charSpace = v[0], v[1]
+					}
+				}
+				tlm = translate(0, -gs.leading).mul(tlm)
+				tm = tlm
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					in.show(&gs, &tm, s)
+				}
+			}
+		case "TJ":
+			if len(operands) == 0 {
+				break
+			}
+			items, _ := operands[len(operands)-1].(array)
+			for _, item := range items {
+				switch v := item.(type) {
+				case pdfString:
+					in.show(&gs, &tm, v)
+				case int64, float64:
+					adjust, _ := in.file.number(v)
+					tm = translate(-adjust/1000*gs.fontSize*gs.scale, 0).mul(tm)
+				}
+			}
+		case "Do":
+			if len(operands) > 0 {
+				if xname, ok := operands[len(operands)-1].(name); ok {
+					in.runForm(resources, xname, gs.ctm)
+				}
+			}
+		case "BI":
+			skipInlineImage(l)
+		}
+		operands = operands[:0]
+	}
+}
+
+// show places the glyphs of a string and advances the text matrix.
+func (in *interpreter) show(gs *graphicsState, tm *matrix, s pdfString) {
+	ft := gs.font
+	if ft == nil {
+		ft = &font{encoding: standard, defaultW: 500, widthScale: 0.001}
+	}
+	for _, c := range ft.codes(s) {
+		textToDevice := tm.mul(gs.ctm)
+		trm := matrix{gs.fontSize * gs.scale, 0, 0, gs.fontSize, 0, gs.rise}.mul(textToDevice)
+		advance := ft.width(c)*gs.fontSize + gs.charSpace
+		if c.size == 1 && c.value == ' ' {
+			advance += gs.wordSpace
+		}
+		advance *= gs.scale
+
+		if text := ft.text(c); text != "" {
+			in.glyphs = append(in.glyphs, glyph{
+				x:     trm[4],
+				y:     trm[5],
+				width: math.Abs(advance) * math.Hypot(textToDevice[0], textToDevice[1]),
+				size:  math.Hypot(trm[2], trm[3]),
+				text:  text,
+			})
+		}
+		*tm = translate(advance, 0).mul(*tm)
+	}
+}
+
+// font returns the font named in the resources, loading it on first use.
+func (in *interpreter) font(resources dict, fontName name) *font {
+	fonts := in.file.dict(resources["Font"])
+	if fonts == nil {
+		return nil
+	}
+	obj := fonts[fontName]
+	key := interface{}(obj)
+	if _, isRef := obj.(ref); !isRef {
+		key = fontName
+	}
+	if ft, ok := in.fonts[key]; ok {
+		return ft
+	}
+	d := in.file.dict(obj)
+	if d == nil {
+		return nil
+	}
+	ft := in.file.loadFont(d)
+	in.fonts[key] = ft
+	return ft
+}
+
+// runForm runs a form XObject, whose text belongs to the page showing it.
+func (in *interpreter) runForm(resources dict, xname name, ctm matrix) {
+	xobjects := in.file.dict(resources["XObject"])
+	if xobjects == nil {
+		return
+	}
+	r, _ := xobjects[xname].(ref)
+	s, ok := in.file.resolve(xobjects[xname]).(*stream)
+	if !ok || s.dict["Subtype"] != name("Form") || in.forms[r.num] || in.depth >= maxFormDepth {
+		return
+	}
+	content, err := in.file.decodeStream(s)
+	if err != nil {
+		return
+	}
+
+	formCTM := ctm
+	if m := in.file.array(s.dict["Matrix"]); len(m) == 6 {
+		var fm matrix
+		for i := range fm {
+			fm[i], _ = in.file.number(m[i])
+		}
+		formCTM = fm.mul(ctm)
+	}
+	formResources := in.file.dict(s.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+
+	in.forms[r.num] = true
+	in.depth++
+	in.run(content, formResources, formCTM)
+	in.depth--
+	delete(in.forms, r.num)
+}
+
+// skipInlineImage moves the lexer past the data of an inline image (BI ... ID data EI).
+func skipInlineImage(l *lexer) {
+	id := bytes.Index(l.data[l.pos:], []byte("ID"))
+	if id < 0 {
+		l.pos = len(l.data)
+		return
+	}
+	pos := l.pos + id + 3
+	for pos < len(l.data) {
+		ei := bytes.Index(l.data[pos:], []byte("EI"))
+		if ei < 0 {
+			break
+		}
+		end := pos + ei
+		if isWhite(l.data[end-1]) && (end+2 == len(l.data) || isWhite(l.data[end+2])) {
+			l.pos = end + 2
+			return
+		}
+		pos = end + 2
+	}
+	l.pos = len(l.data)
+}
@@ -0,0 +1,157 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Simple fonts map single-byte codes to glyphs through an encoding. The tables
+// below map codes to Unicode for the predefined encodings; 0 means no glyph.
+
+// winAnsi is WinAnsiEncoding (Windows code page 1252).
+var winAnsi = func() (t [256]rune) {
+	for c := 0x20; c < 0x7f; c++ {
+		t[c] = rune(c)
+	}
+	high := []rune("€\x00‚ƒ„…†‡ˆ‰Š‹Œ\x00Ž\x00\x00‘’“”•–—˜™š›œ\x00žŸ")
+	for i, r := range high {
+		t[0x80+i] = r
+	}
+	for c := 0xa0; c <= 0xff; c++ {
+		t[c] = rune(c)
+	}
+	t[0xad] = '-' // Soft hyphen renders as a hyphen
+	return t
+}()
+
+// macRoman is MacRomanEncoding.
+var macRoman = func() (t [256]rune) {
+	for c := 0x20; c < 0x7f; c++ {
+		t[c] = rune(c)
+	}
+	high := []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄¤‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔ\x00ÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+	for i, r := range high {
+		t[0x80+i] = r
+	}
+	return t
+}()
+
+// standard is StandardEncoding, the built-in encoding of most Type 1 fonts.
+var standard = func() (t [256]rune) {
+	for c := 0x20; c < 0x7f; c++ {
+		t[c] = rune(c)
+	}
+	t['\''] = '’'
+	t['`'] = '‘'
+	high := map[int]rune{
+		0xa1: '¡', 0xa2: '¢', 0xa3: '£', 0xa4: '⁄', 0xa5: '¥', 0xa6: 'ƒ', 0xa7: '§',
+		0xa8: '¤', 0xa9: '\'', 0xaa: '“', 0xab: '«', 0xac: '‹', 0xad: '›', 0xae: 'ﬁ',
+		0xaf: 'ﬂ', 0xb1: '–', 0xb2: '†', 0xb3: '‡', 0xb4: '·', 0xb6: '¶', 0xb7: '•',
+		0xb8: '‚', 0xb9: '„', 0xba: '”', 0xbb: '»', 0xbc: '…', 0xbd: '‰', 0xbf: '¿',
+		0xc1: '`', 0xc2: '´', 0xc3: 'ˆ', 0xc4: '˜', 0xc5: '¯', 0xc6: '˘', 0xc7: '˙',
+		0xc8: '¨', 0xca: '˚', 0xcb: '¸', 0xcd: '˝', 0xce: '˛', 0xcf: 'ˇ', 0xd0: '—',
+		0xe1: 'Æ', 0xe3: 'ª', 0xe8: 'Ł', 0xe9: 'Ø', 0xea: 'Œ', 0xeb: 'º', 0xf1: 'æ',
+		0xf5: 'ı', 0xf8: 'ł', 0xf9: 'ø', 0xfa: 'œ', 0xfb: 'ß',
+	}
+	for c, r := range high {
+		t[c] = r
+	}
+	return t
+}()
+
+// glyphNames maps the glyph names used in /Differences arrays that are not single
+// characters or uniXXXX names to Unicode.
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "parenleft": '(',
+	"parenright": ')', "asterisk": '*', "plus": '+', "comma": ',', "hyphen": '-',
+	"period": '.', "slash": '/', "zero": '0', "one": '1', "two": '2', "three": '3',
+	"four": '4', "five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>',
+	"question": '?', "at": '@', "bracketleft": '[', "backslash": '\\',
+	"bracketright": ']', "asciicircum": '^', "underscore": '_', "grave": '`',
+	"braceleft": '{', "bar": '|', "braceright": '}', "asciitilde": '~',
+	"quoteleft": '‘', "quoteright": '’', "quotedblleft": '“', "quotedblright": '”',
+	"quotesinglbase": '‚', "quotedblbase": '„', "guillemotleft": '«',
+	"guillemotright": '»', "guilsinglleft": '‹', "guilsinglright": '›',
+	"bullet": '•', "endash": '–', "emdash": '—', "ellipsis": '…', "minus": '−',
+	"dagger": '†', "daggerdbl": '‡', "trademark": '™', "copyright": '©',
+	"registered": '®', "degree": '°', "section": '§', "paragraph": '¶',
+	"periodcentered": '·', "multiply": '×', "divide": '÷', "plusminus": '±',
+	"nbspace": ' ', "sfthyphen": '-', "exclamdown": '¡', "questiondown": '¿',
+	"cent": '¢', "sterling": '£', "yen": '¥', "Euro": '€', "currency": '¤',
+	"florin": 'ƒ', "perthousand": '‰', "fraction": '⁄', "mu": 'µ', "germandbls": 'ß',
+	"dotlessi": 'ı', "ordfeminine": 'ª', "ordmasculine": 'º', "brokenbar": '¦',
+	"logicalnot": '¬', "onehalf": '½', "onequarter": '¼', "threequarters": '¾',
+	"onesuperior": '¹', "twosuperior": '²', "threesuperior": '³',
+	"fi": 'ﬁ', "fl": 'ﬂ', "ae": 'æ', "AE": 'Æ', "oe": 'œ', "OE": 'Œ',
+	"oslash": 'ø', "Oslash": 'Ø', "lslash": 'ł', "Lslash": 'Ł', "eth": 'ð',
+	"Eth": 'Ð', "thorn": 'þ', "Thorn": 'Þ', "arrowright": '→', "arrowleft": '←',
+	"checkmark": '✓',
+}
+
+// accents maps the accent suffixes of Latin glyph names (eacute, Udieresis) to
+// combining characters, composed with their base letter by composeAccent.
+var accents = map[string]string{
+	"grave": "̀", "acute": "́", "circumflex": "̂", "tilde": "̃",
+	"dieresis": "̈", "ring": "̊", "cedilla": "̧", "caron": "̌",
+}
+
+// precomposed holds the Latin-1 and Latin Extended-A letters with accents, keyed by
+// base letter and combining character.
+var precomposed = func() map[string]rune {
+	m := make(map[string]rune)
+	add := func(bases, composed string, accent string) {
+		c := []rune(composed)
+		for i, b := range bases {
+			m[string(b)+accent] = c[i]
+		}
+	}
+	add("AEIOUaeiou", "ÀÈÌÒÙàèìòù", "̀")
+	add("AEIOUYaeiouy", "ÁÉÍÓÚÝáéíóúý", "́")
+	add("AEIOUaeiou", "ÂÊÎÔÛâêîôû", "̂")
+	add("ANOano", "ÃÑÕãñõ", "̃")
+	add("AEIOUaeiouyY", "ÄËÏÖÜäëïöüÿŸ", "̈")
+	add("Aa", "Åå", "̊")
+	add("CcSs", "ÇçŞş", "̧")
+	add("CcSsZzEeRrNnDdTt", "ČčŠšŽžĚěŘřŇňĎďŤť", "̌")
+	return m
+}()
+
+// glyphToUnicode returns the text of a glyph name, or "" when it is unknown.
+func glyphToUnicode(glyph string) string {
+	if i := strings.IndexByte(glyph, '.'); i > 0 {
+		glyph = glyph[:i] // Variants such as "a.sc"
+	}
+	if r, ok := glyphNames[glyph]; ok {
+		return string(r)
+	}
+	if len(glyph) == 1 {
+		return glyph
+	}
+	if strings.HasPrefix(glyph, "uni") && len(glyph) >= 7 && (len(glyph)-3)%4 == 0 {
+		var units []uint16
+		for i := 3; i < len(glyph); i += 4 {
+			v, err := strconv.ParseUint(glyph[i:i+4], 16, 16)
+			if err != nil {
+				return ""
+			}
+			units = append(units, uint16(v))
+		}
+		return string(utf16.Decode(units))
+	}
+	if strings.HasPrefix(glyph, "u") && len(glyph) >= 5 && len(glyph) <= 7 {
+		if v, err := strconv.ParseUint(glyph[1:], 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+	for suffix, accent := range accents {
+		if base := strings.TrimSuffix(glyph, suffix); len(base) == 1 && base != glyph {
+			if r, ok := precomposed[base+accent]; ok {
+				return string(r)
+			}
+		}
+	}
+	return ""
+}
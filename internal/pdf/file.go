@@ -0,0 +1,316 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// objHeader matches the start of an indirect object definition.
+var objHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// file holds the objects of a parsed PDF.
+type file struct {
+	objects map[int]interface{}
+	trailer dict
+
+	// inflated counts the bytes its streams have been decompressed to, and warnings
+	// are the problems met decoding them
+	inflated int
+	warnings []string
+}
+
+// warn records a warning of the document, once.
+func (f *file) warn(warning string) {
+	if !slices.Contains(f.warnings, warning) {
+		f.warnings = append(f.warnings, warning)
+	}
+}
+
+// parseFile reads every object in data. Rather than trusting the cross-reference
+// table, which is often damaged, objects are found by scanning the file; later
+// definitions replace earlier ones, as incremental updates do. Objects inside
+// object streams are added afterwards.
+func parseFile(data []byte) (*file, error) {
+	header := bytes.Index(data[:min(len(data), 1024)], []byte("%PDF-"))
+	if header < 0 {
+		return nil, errors.New("not a PDF file")
+	}
+
+	f := &file{objects: make(map[int]interface{})}
+	var xrefStream dict
+	pos := header
+	for {
+		loc := objHeader.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num := atoi(data[pos+loc[2] : pos+loc[3]])
+		l := &lexer{data: data, pos: pos + loc[1]}
+		obj, err := l.readObject()
+		if err != nil {
+			pos += loc[1]
+			continue
+		}
+		if d, ok := obj.(dict); ok {
+			l.skipSpace()
+			if bytes.HasPrefix(data[l.pos:], []byte("stream")) {
+				s := readStreamData(data, l, d)
+				obj = s
+				if d["Type"] == name("XRef") {
+					xrefStream = d
+				}
+			}
+		}
+		f.objects[num] = obj
+		pos = l.pos
+		l.skipSpace()
+		if bytes.HasPrefix(data[l.pos:], []byte("endobj")) {
+			pos = l.pos + len("endobj")
+		}
+	}
+
+	f.trailer = findTrailer(data)
+	if f.trailer == nil {
+		f.trailer = xrefStream
+	}
+	if f.trailer != nil {
+		if _, ok := f.trailer["Encrypt"]; ok {
+			return nil, ErrEncrypted
+		}
+	}
+	f.loadObjectStreams()
+	return f, nil
+}
+
+// readStreamData reads the data of a stream whose dictionary has just been read,
+// leaving the lexer after "endstream". A /Length that does not end at "endstream"
+// is ignored in favor of searching for it.
+func readStreamData(data []byte, l *lexer, d dict) *stream {
+	start := l.pos + len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+
+	if length, ok := d["Length"].(int64); ok && length >= 0 && start+int(length) <= len(data) {
+		end := start + int(length)
+		rest := &lexer{data: data, pos: end}
+		rest.skipSpace()
+		if bytes.HasPrefix(data[rest.pos:], []byte("endstream")) {
+			l.pos = rest.pos + len("endstream")
+			return &stream{dict: d, data: data[start:end]}
+		}
+	}
+
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		l.pos = len(data)
+		return &stream{dict: d, data: data[start:]}
+	}
+	l.pos = start + end + len("endstream")
+	content := data[start : start+end]
+	content = bytes.TrimSuffix(content, []byte("\n"))
+	content = bytes.TrimSuffix(content, []byte("\r"))
+	return &stream{dict: d, data: content}
+}
+
+// findTrailer returns the last trailer dictionary that names a document catalog.
+func findTrailer(data []byte) dict {
+	var trailer dict
+	marker := []byte("trailer")
+	for pos := 0; ; {
+		i := bytes.Index(data[pos:], marker)
+		if i < 0 {
+			return trailer
+		}
+		l := &lexer{data: data, pos: pos + i + len(marker)}
+		if obj, err := l.readObject(); err == nil {
+			if d, ok := obj.(dict); ok && d["Root"] != nil {
+				trailer = d
+			}
+		}
+		pos += i + len(marker)
+	}
+}
+
+// loadObjectStreams adds the objects stored in object streams. Objects defined
+// directly in the file take precedence.
+func (f *file) loadObjectStreams() {
+	nums := make([]int, 0, len(f.objects))
+	for num, obj := range f.objects {
+		if s, ok := obj.(*stream); ok && s.dict["Type"] == name("ObjStm") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+
+	for _, num := range nums {
+		s := f.objects[num].(*stream)
+		data, err := f.decodeStream(s)
+		if err != nil {
+			continue
+		}
+		count, _ := f.resolve(s.dict["N"]).(int64)
+		first, _ := f.resolve(s.dict["First"]).(int64)
+		if first < 0 || int(first) > len(data) {
+			continue
+		}
+
+		header := &lexer{data: data[:first]}
+		for i := int64(0); i < count; i++ {
+			objNum, err1 := header.readToken()
+			offset, err2 := header.readToken()
+			n, ok1 := objNum.(int64)
+			off, ok2 := offset.(int64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+			if _, exists := f.objects[int(n)]; exists || int(first+off) >= len(data) {
+				continue
+			}
+			l := &lexer{data: data, pos: int(first + off)}
+			if obj, err := l.readObject(); err == nil {
+				f.objects[int(n)] = obj
+			}
+		}
+	}
+}
+
+// resolve follows indirect references.
+func (f *file) resolve(obj interface{}) interface{} {
+	for depth := 0; depth < 32; depth++ {
+		r, ok := obj.(ref)
+		if !ok {
+			return obj
+		}
+		obj = f.objects[r.num]
+	}
+	return nil
+}
+
+// dict resolves obj to a dictionary; the dictionary of a stream is returned for
+// streams.
+func (f *file) dict(obj interface{}) dict {
+	switch v := f.resolve(obj).(type) {
+	case dict:
+		return v
+	case *stream:
+		return v.dict
+	}
+	return nil
+}
+
+// array resolves obj to an array.
+func (f *file) array(obj interface{}) array {
+	a, _ := f.resolve(obj).(array)
+	return a
+}
+
+// number resolves obj to a number.
+func (f *file) number(obj interface{}) (float64, bool) {
+	switch v := f.resolve(obj).(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// page is a page dictionary with its inherited resources.
+type page struct {
+	dict      dict
+	resources dict
+}
+
+// pages returns the pages in document order. When the page tree is missing or
+// broken, every page object in the file is returned in object order.
+func (f *file) pages() []page {
+	var pages []page
+	if catalog := f.dict(f.trailer["Root"]); catalog != nil {
+		visited := make(map[int]bool)
+		f.walkPages(catalog["Pages"], nil, visited, &pages)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	nums := make([]int, 0, len(f.objects))
+	for num, obj := range f.objects {
+		if d, ok := obj.(dict); ok && d["Type"] == name("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		d := f.objects[num].(dict)
+		pages = append(pages, page{dict: d, resources: f.dict(d["Resources"])})
+	}
+	return pages
+}
+
+func (f *file) walkPages(node interface{}, resources dict, visited map[int]bool, pages *[]page) {
+	if r, ok := node.(ref); ok {
+		if visited[r.num] {
+			return
+		}
+		visited[r.num] = true
+	}
+	d := f.dict(node)
+	if d == nil {
+		return
+	}
+	if res := f.dict(d["Resources"]); res != nil {
+		resources = res
+	}
+	if kids, ok := f.resolve(d["Kids"]).(array); ok && d["Type"] != name("Page") {
+		for _, kid := range kids {
+			f.walkPages(kid, resources, visited, pages)
+		}
+		return
+	}
+	if d["Type"] == name("Pages") {
+		return
+	}
+	*pages = append(*pages, page{dict: d, resources: resources})
+}
+
+// contents returns the decoded content streams of a page, concatenated.
+func (f *file) contents(p page) ([]byte, error) {
+	var streams []interface{}
+	switch v := f.resolve(p.dict["Contents"]).(type) {
+	case *stream:
+		streams = append(streams, v)
+	case array:
+		streams = v
+	}
+
+	var buf bytes.Buffer
+	for _, obj := range streams {
+		s, ok := f.resolve(obj).(*stream)
+		if !ok {
+			continue
+		}
+		data, err := f.decodeStream(s)
+		if err != nil {
+			return buf.Bytes(), fmt.Errorf("content stream: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func atoi(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
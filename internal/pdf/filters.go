@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"fmt"
+	"io"
+)
+
+// Limits of decompressed data, which guard against decompression bombs: a stream of
+// a few kilobytes can inflate to gigabytes. Streams are cut at maxStreamSize, and once
+// the streams of a document have inflated to maxDocumentSize, no more are inflated.
+var (
+	maxStreamSize   = 64 << 20
+	maxDocumentSize = 256 << 20
+)
+
+// decodeStream applies the stream's filters to its data. Image filters are not
+// supported; text never needs them.
+func (f *file) decodeStream(s *stream) ([]byte, error) {
+	var filters, params array
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case name:
+		filters = array{v}
+		params = array{s.dict["DecodeParms"]}
+	case array:
+		filters = v
+		params = f.array(s.dict["DecodeParms"])
+	}
+
+	data := s.data
+	for i, filter := range filters {
+		var parms dict
+		if i < len(params) {
+			parms = f.dict(params[i])
+		}
+		var err error
+		switch f.resolve(filter) {
+		case name("FlateDecode"), name("Fl"):
+			data, err = f.inflate(data)
+			if err == nil {
+				data, err = f.unpredict(data, parms)
+			}
+		case name("ASCIIHexDecode"), name("AHx"):
+			data = decodeASCIIHex(data)
+		case name("ASCII85Decode"), name("A85"):
+			data, err = decodeASCII85(data)
+		case name("RunLengthDecode"), name("RL"):
+			data = decodeRunLength(data)
+		default:
+			return nil, fmt.Errorf("unsupported filter %v", f.resolve(filter))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data within the size limits, with a warning when a limit
+// cuts it. Truncated or corrupt streams yield whatever was decompressed before the
+// damage, as most of the text is usually intact.
+func (f *file) inflate(data []byte) ([]byte, error) {
+	limit := min(maxStreamSize, maxDocumentSize-f.inflated)
+	documentLimit := fmt.Sprintf("decompressed streams exceed the limit of %d MB per document; the remaining text is skipped", maxDocumentSize>>20)
+	if limit <= 0 {
+		f.warn(documentLimit)
+		return nil, fmt.Errorf("FlateDecode: document size limit reached")
+	}
+
+	var r io.ReadCloser
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err == nil {
+		r = zr
+	} else {
+		// Some writers omit the zlib header
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	defer func() { _ = r.Close() }()
+
+	out, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("FlateDecode: %w", err)
+	}
+	if len(out) > limit {
+		out = out[:limit]
+		if limit < maxStreamSize {
+			f.warn(documentLimit)
+		} else {
+			f.warn(fmt.Sprintf("a stream decompresses to more than %d MB; its text beyond that is skipped", maxStreamSize>>20))
+		}
+	}
+	f.inflated += len(out)
+	return out, nil
+}
+
+// unpredict reverses PNG predictors, used mostly by cross-reference and object
+// streams.
+func (f *file) unpredict(data []byte, parms dict) ([]byte, error) {
+	predictor, _ := f.number(parms["Predictor"])
+	if predictor < 10 {
+		if predictor == 2 {
+			return nil, fmt.Errorf("unsupported TIFF predictor")
+		}
+		return data, nil
+	}
+	colors, columns, bpc := 1.0, 1.0, 8.0
+	if v, ok := f.number(parms["Colors"]); ok && v > 0 {
+		colors = v
+	}
+	if v, ok := f.number(parms["Columns"]); ok && v > 0 {
+		columns = v
+	}
+	if v, ok := f.number(parms["BitsPerComponent"]); ok && v > 0 {
+		bpc = v
+	}
+	bpp := max(1, int(colors*bpc)/8)
+	rowLen := (int(colors*bpc*columns) + 7) / 8
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowLen)
+	for pos := 0; pos+1+rowLen <= len(data); pos += 1 + rowLen {
+		filter := data[pos]
+		row := append([]byte(nil), data[pos+1:pos+1+rowLen]...)
+		for i := range row {
+			var left, up, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up = prev[i]
+			switch filter {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func decodeASCIIHex(data []byte) []byte {
+	l := &lexer{data: append(append([]byte{'<'}, data...), '>')}
+	return []byte(l.readHexString())
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("ASCII85Decode: %w", err)
+	}
+	return out[:n], nil
+}
+
+func decodeRunLength(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+		switch {
+		case n == 128:
+			return out
+		case n < 128:
+			end := min(i+n+1, len(data))
+			out = append(out, data[i:end]...)
+			i = end
+		case i < len(data):
+			out = append(out, bytes.Repeat(data[i:i+1], 257-n)...)
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,333 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// codeRange is a codespace range: codes of n bytes whose bytes each lie between the
+// corresponding bytes of lo and hi.
+type codeRange struct {
+	lo, hi []byte
+}
+
+// font decodes the strings shown with a font into text and glyph widths.
+type font struct {
+	composite  bool               // Type0 font with multi-byte codes
+	ucs2       bool               // Composite font whose codes are Unicode (UCS-2 CMaps)
+	codespace  []codeRange        // From the ToUnicode CMap
+	toUnicode  map[uint32]string  // From the ToUnicode CMap
+	encoding   [256]rune          // Simple fonts without a ToUnicode mapping
+	names      map[uint32]string  // Differences that are not single runes
+	widths     map[uint32]float64 // Glyph widths in glyph space
+	defaultW   float64            // Width of glyphs not in widths
+	widthScale float64            // Glyph space to text space, 1/1000 except for Type3
+}
+
+// code is a character code of a shown string.
+type code struct {
+	value uint32
+	size  int
+}
+
+// loadFont reads a font dictionary.
+func (f *file) loadFont(d dict) *font {
+	ft := &font{widths: make(map[uint32]float64), widthScale: 0.001, defaultW: 500}
+	subtype := f.resolve(d["Subtype"])
+
+	if subtype == name("Type3") {
+		if matrix := f.array(d["FontMatrix"]); len(matrix) > 0 {
+			if v, ok := f.number(matrix[0]); ok {
+				ft.widthScale = v
+			}
+		}
+	}
+
+	if subtype == name("Type0") {
+		ft.composite = true
+		ft.defaultW = 1000
+		if enc, ok := f.resolve(d["Encoding"]).(name); ok && strings.HasPrefix(string(enc), "Uni") &&
+			(strings.Contains(string(enc), "UCS2") || strings.Contains(string(enc), "UTF16")) {
+			ft.ucs2 = true
+		}
+		if descendants := f.array(d["DescendantFonts"]); len(descendants) > 0 {
+			f.loadCIDWidths(ft, f.dict(descendants[0]))
+		}
+	} else {
+		f.loadSimpleWidths(ft, d)
+		f.loadEncoding(ft, d, subtype)
+	}
+
+	if s, ok := f.resolve(d["ToUnicode"]).(*stream); ok {
+		if data, err := f.decodeStream(s); err == nil {
+			ft.parseCMap(data)
+		}
+	}
+	return ft
+}
+
+func (f *file) loadSimpleWidths(ft *font, d dict) {
+	if desc := f.dict(d["FontDescriptor"]); desc != nil {
+		if v, ok := f.number(desc["MissingWidth"]); ok && v > 0 {
+			ft.defaultW = v
+		}
+	}
+	first, _ := f.number(d["FirstChar"])
+	for i, w := range f.array(d["Widths"]) {
+		if v, ok := f.number(w); ok {
+			ft.widths[uint32(int(first)+i)] = v
+		}
+	}
+}
+
+func (f *file) loadCIDWidths(ft *font, d dict) {
+	if d == nil {
+		return
+	}
+	if v, ok := f.number(d["DW"]); ok {
+		ft.defaultW = v
+	}
+	// W is a sequence of "first [w1 w2 ...]" and "first last w" entries
+	w := f.array(d["W"])
+	for i := 0; i < len(w); {
+		first, ok := f.number(w[i])
+		if !ok || i+1 >= len(w) {
+			return
+		}
+		if list, ok := f.resolve(w[i+1]).(array); ok {
+			for j, width := range list {
+				if v, ok := f.number(width); ok {
+					ft.widths[uint32(int(first)+j)] = v
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		last, _ := f.number(w[i+1])
+		width, _ := f.number(w[i+2])
+		for c := int(first); c <= int(last) && c-int(first) < 65536; c++ {
+			ft.widths[uint32(c)] = width
+		}
+		i += 3
+	}
+}
+
+func (f *file) loadEncoding(ft *font, d dict, subtype interface{}) {
+	ft.encoding = standard
+	if subtype == name("TrueType") {
+		ft.encoding = winAnsi
+	}
+
+	enc := f.resolve(d["Encoding"])
+	base := enc
+	if ed, ok := enc.(dict); ok {
+		base = f.resolve(ed["BaseEncoding"])
+	}
+	switch base {
+	case name("WinAnsiEncoding"):
+		ft.encoding = winAnsi
+	case name("MacRomanEncoding"):
+		ft.encoding = macRoman
+	case name("StandardEncoding"):
+		ft.encoding = standard
+	}
+
+	ed, ok := enc.(dict)
+	if !ok {
+		return
+	}
+	c := 0
+	for _, item := range f.array(ed["Differences"]) {
+		switch v := f.resolve(item).(type) {
+		case int64:
+			c = int(v)
+		case float64:
+			c = int(v)
+		case name:
+			if c >= 0 && c < 256 {
+				text := glyphToUnicode(string(v))
+				runes := []rune(text)
+				switch len(runes) {
+				case 0:
+					ft.encoding[c] = 0
+				case 1:
+					ft.encoding[c] = runes[0]
+				default:
+					if ft.names == nil {
+						ft.names = make(map[uint32]string)
+					}
+					ft.names[uint32(c)] = text
+				}
+			}
+			c++
+		}
+	}
+}
+
+// parseCMap reads the codespace ranges and Unicode mappings of a ToUnicode CMap.
+func (ft *font) parseCMap(data []byte) {
+	ft.toUnicode = make(map[uint32]string)
+	l := &lexer{data: data}
+	var operands []interface{}
+	for {
+		obj, err := l.readObject()
+		if err != nil {
+			return
+		}
+		kw, ok := obj.(keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 {
+					ft.codespace = append(ft.codespace, codeRange{lo: []byte(lo), hi: []byte(hi)})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok := operands[i].(pdfString)
+				if !ok {
+					continue
+				}
+				ft.toUnicode[codeValue(src)] = cmapText(operands[i+1])
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				first, last := codeValue(lo), codeValue(hi)
+				if last < first || last-first > 65535 {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case array:
+					for j, item := range dst {
+						if first+uint32(j) > last {
+							break
+						}
+						ft.toUnicode[first+uint32(j)] = cmapText(item)
+					}
+				case pdfString:
+					// Destinations increment in their last UTF-16 unit
+					units := utf16Units(dst)
+					if len(units) == 0 {
+						continue
+					}
+					for c := first; c <= last; c++ {
+						ft.toUnicode[c] = string(utf16.Decode(units))
+						units[len(units)-1]++
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// codeValue returns the big-endian value of a character code.
+func codeValue(s pdfString) uint32 {
+	var v uint32
+	for i := 0; i < len(s); i++ {
+		v = v<<8 | uint32(s[i])
+	}
+	return v
+}
+
+func utf16Units(s pdfString) []uint16 {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	if len(s)%2 == 1 {
+		units = append(units, uint16(s[len(s)-1]))
+	}
+	return units
+}
+
+// cmapText decodes a bfchar or bfrange destination: UTF-16BE text or a glyph name.
+func cmapText(obj interface{}) string {
+	switch v := obj.(type) {
+	case pdfString:
+		return string(utf16.Decode(utf16Units(v)))
+	case name:
+		return glyphToUnicode(string(v))
+	}
+	return ""
+}
+
+// codes splits a shown string into character codes.
+func (ft *font) codes(s pdfString) []code {
+	var codes []code
+	for i := 0; i < len(s); {
+		size := ft.codeSize(s[i:])
+		if i+size > len(s) {
+			size = len(s) - i
+		}
+		codes = append(codes, code{value: codeValue(s[i : i+size]), size: size})
+		i += size
+	}
+	return codes
+}
+
+// codeSize returns the length of the code at the start of s.
+func (ft *font) codeSize(s pdfString) int {
+	for _, r := range ft.codespace {
+		n := len(r.lo)
+		if n > len(s) {
+			continue
+		}
+		match := true
+		for i := 0; i < n; i++ {
+			if s[i] < r.lo[i] || s[i] > r.hi[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return n
+		}
+	}
+	if ft.composite {
+		return 2
+	}
+	return 1
+}
+
+// text returns the Unicode text of a code, or "" when it cannot be mapped.
+func (ft *font) text(c code) string {
+	if text, ok := ft.toUnicode[c.value]; ok {
+		return text
+	}
+	if ft.composite {
+		if ft.ucs2 {
+			return string(rune(c.value))
+		}
+		return ""
+	}
+	if text, ok := ft.names[c.value]; ok {
+		return text
+	}
+	if c.value < 256 && ft.encoding[c.value] != 0 {
+		return string(ft.encoding[c.value])
+	}
+	return ""
+}
+
+// width returns the advance of a code in text space units per unit of font size.
+func (ft *font) width(c code) float64 {
+	if w, ok := ft.widths[c.value]; ok {
+		return w * ft.widthScale
+	}
+	return ft.defaultW * ft.widthScale
+}
@@ -0,0 +1,89 @@
+package pdf
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Layout thresholds, in multiples of the font size.
+const (
+	sameLineTolerance = 0.4 // Baselines closer than this form one line
+	wordGap           = 0.15
+	columnGap         = 2.0 // Wider gaps are kept as runs of spaces, like columns
+	paragraphGap      = 1.5 // Wider line spacing is kept as a blank line
+	maxColumnSpaces   = 40
+)
+
+// line is a row of glyphs sharing a baseline.
+type line struct {
+	y, size float64
+	glyphs  []glyph
+}
+
+// layoutText arranges glyphs into lines of text in reading order: top to bottom,
+// then left to right. Gaps between words become spaces, wide gaps between columns
+// runs of spaces, and wide gaps between lines blank lines.
+func layoutText(glyphs []glyph) string {
+	if len(glyphs) == 0 {
+		return ""
+	}
+	sorted := make([]glyph, len(glyphs))
+	copy(sorted, glyphs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].y > sorted[j].y })
+
+	var lines []*line
+	for _, g := range sorted {
+		if n := len(lines); n > 0 {
+			last := lines[n-1]
+			if math.Abs(last.y-g.y) <= sameLineTolerance*math.Max(last.size, g.size) {
+				last.glyphs = append(last.glyphs, g)
+				last.size = math.Max(last.size, g.size)
+				continue
+			}
+		}
+		lines = append(lines, &line{y: g.y, size: g.size, glyphs: []glyph{g}})
+	}
+
+	var b strings.Builder
+	for i, ln := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+			if lines[i-1].y-ln.y > paragraphGap*math.Max(ln.size, lines[i-1].size) {
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(ln.text())
+	}
+	return b.String()
+}
+
+// text joins the glyphs of a line from left to right.
+func (ln *line) text() string {
+	sort.SliceStable(ln.glyphs, func(i, j int) bool { return ln.glyphs[i].x < ln.glyphs[j].x })
+
+	var b strings.Builder
+	var prev *glyph
+	for i := range ln.glyphs {
+		g := &ln.glyphs[i]
+		if prev != nil {
+			size := math.Max(math.Max(prev.size, g.size), 1)
+			// Text drawn twice for a bold effect
+			if g.text == prev.text && math.Abs(g.x-prev.x) < 0.1*size {
+				continue
+			}
+			gap := g.x - (prev.x + prev.width)
+			spaced := strings.HasSuffix(prev.text, " ") || strings.HasPrefix(g.text, " ")
+			switch {
+			case gap > columnGap*size:
+				spaces := int(gap / (0.5 * size))
+				b.WriteString(strings.Repeat(" ", min(spaces, maxColumnSpaces)))
+			case gap > wordGap*size && !spaced:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(g.text)
+		prev = g
+	}
+	return strings.TrimRight(b.String(), " \t")
+}
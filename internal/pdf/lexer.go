@@ -0,0 +1,342 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// PDF objects are represented as nil (null), bool, int64, float64, name, pdfString,
+// array, dict, ref and *stream.
+type (
+	name      string
+	pdfString string
+	array     []interface{}
+	dict      map[name]interface{}
+	keyword   string // Bare word: an operator in content streams, obj/endobj/R in files
+	delimiter string // [ ] << >> { }
+)
+
+// ref is an indirect object reference.
+type ref struct {
+	num, gen int
+}
+
+// stream is a dictionary with encoded data.
+type stream struct {
+	dict dict
+	data []byte
+}
+
+var errSyntax = errors.New("syntax error")
+
+// lexer reads PDF tokens and objects from data.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isWhite(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isWhite(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readToken returns the next token: a delimiter, keyword, name, string or number.
+func (l *lexer) readToken() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch c {
+	case '[', ']', '{', '}':
+		l.pos++
+		return delimiter(c), nil
+	case '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return delimiter("<<"), nil
+		}
+		return l.readHexString(), nil
+	case '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return delimiter(">>"), nil
+		}
+		l.pos++
+		return nil, errSyntax
+	case '(':
+		return l.readLiteralString(), nil
+	case '/':
+		return l.readName(), nil
+	case ')':
+		l.pos++
+		return nil, errSyntax
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isWhite(l.data[l.pos]) && !isDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	word := l.data[start:l.pos]
+	if isNumber(word) {
+		if i, err := strconv.ParseInt(string(word), 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(string(word), 64); err == nil {
+			return f, nil
+		}
+		return parseLooseFloat(word), nil
+	}
+	switch string(word) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return keyword(word), nil
+}
+
+// isNumber reports whether word looks like a PDF number.
+func isNumber(word []byte) bool {
+	if len(word) == 0 {
+		return false
+	}
+	digits := 0
+	for i, c := range word {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+		case (c == '-' || c == '+') && i == 0:
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+// parseLooseFloat parses malformed numbers such as "1.2.3" or "--5" as well as a
+// PDF reader would, keeping the longest valid prefix.
+func parseLooseFloat(word []byte) float64 {
+	for end := len(word); end > 0; end-- {
+		if f, err := strconv.ParseFloat(string(word[:end]), 64); err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+func (l *lexer) readName() name {
+	l.pos++ // Skip '/'
+	var b bytes.Buffer
+	for l.pos < len(l.data) && !isWhite(l.data[l.pos]) && !isDelim(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+	return name(b.String())
+}
+
+func (l *lexer) readHexString() pdfString {
+	l.pos++ // Skip '<'
+	var b bytes.Buffer
+	var digit byte
+	half := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := hexValue(c)
+		if !ok {
+			continue
+		}
+		if half {
+			b.WriteByte(digit<<4 | v)
+		} else {
+			digit = v
+		}
+		half = !half
+	}
+	if half {
+		b.WriteByte(digit << 4)
+	}
+	return pdfString(b.String())
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (l *lexer) readLiteralString() pdfString {
+	l.pos++ // Skip '('
+	var b bytes.Buffer
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(b.String())
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				continue
+			}
+			c = l.data[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// Line continuation
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					v := c - '0'
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v<<3 | (l.data[l.pos] - '0')
+						l.pos++
+					}
+					c = v
+				}
+			}
+		}
+		b.WriteByte(c)
+	}
+	return pdfString(b.String())
+}
+
+// readObject reads a complete object, including arrays, dictionaries and indirect
+// references. Keywords are returned as is.
+func (l *lexer) readObject() (interface{}, error) {
+	tok, err := l.readToken()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case delimiter:
+		switch tok {
+		case "[":
+			arr := array{}
+			for {
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == ']' {
+					l.pos++
+					return arr, nil
+				}
+				obj, err := l.readObject()
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				arr = append(arr, obj)
+			}
+		case "<<":
+			d := dict{}
+			for {
+				key, err := l.readToken()
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				if key == delimiter(">>") {
+					return d, nil
+				}
+				k, ok := key.(name)
+				if !ok {
+					return nil, errSyntax
+				}
+				value, err := l.readObject()
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				d[k] = value
+			}
+		}
+		return nil, errSyntax
+	case int64:
+		// An indirect reference is "num gen R"
+		save := l.pos
+		if gen, err := l.readToken(); err == nil {
+			if g, ok := gen.(int64); ok {
+				if r, err := l.readToken(); err == nil && r == keyword("R") {
+					return ref{num: int(tok), gen: int(g)}, nil
+				}
+			}
+		}
+		l.pos = save
+		return tok, nil
+	}
+	return tok, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
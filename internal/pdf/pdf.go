@@ -0,0 +1,102 @@
+// Package pdf extracts text from PDF documents without external tools, so PDF
+// sources are ingested identically on every platform.
+//
+// Text is recovered from the fonts' Unicode mappings and encodings and laid out by
+// position: lines in reading order, spaces for gaps between words and columns, and
+// blank lines between paragraphs. Pages without extractable text, such as scans,
+// can be passed to an OCR hook.
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEncrypted is returned for encrypted documents, whose text cannot be read.
+var ErrEncrypted = errors.New("encrypted PDFs are not supported")
+
+// OCR recognizes the text of a page that has no extractable text, such as a
+// scanned page. It receives the whole document and the 1-based page number.
+type OCR func(data []byte, page int) (string, error)
+
+// Options configures extraction.
+type Options struct {
+	OCR OCR // Optional; pages without text are left empty when nil
+}
+
+// Page is the text of one page.
+type Page struct {
+	Number int
+	Text   string
+	OCR    bool // The text was recognized by the OCR hook
+}
+
+// Document is the text extracted from a PDF.
+type Document struct {
+	Pages    []Page
+	Warnings []string // Problems that left some text unextracted
+}
+
+// pageMarker starts the text of every page.
+const pageMarker = "--- Page %d ---"
+
+// Extract extracts the text of every page of a PDF. Damaged content is skipped
+// with a warning; only unreadable or encrypted documents fail.
+func Extract(data []byte, opts Options) (*Document, error) {
+	f, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	pages := f.pages()
+	if len(pages) == 0 {
+		return nil, errors.New("no pages found")
+	}
+
+	doc := &Document{}
+	in := newInterpreter(f)
+	for i, p := range pages {
+		page := Page{Number: i + 1}
+		content, err := f.contents(p)
+		if err != nil {
+			doc.Warnings = append(doc.Warnings, fmt.Sprintf("page %d: %v", page.Number, err))
+		}
+		in.glyphs = in.glyphs[:0]
+		in.run(content, p.resources, identity)
+		page.Text = layoutText(in.glyphs)
+
+		if strings.TrimSpace(page.Text) == "" && opts.OCR != nil {
+			text, err := opts.OCR(data, page.Number)
+			if err != nil {
+				doc.Warnings = append(doc.Warnings, fmt.Sprintf("page %d: OCR failed: %v", page.Number, err))
+			} else {
+				page.Text = strings.TrimSpace(text)
+				page.OCR = true
+			}
+		}
+		if strings.TrimSpace(page.Text) == "" {
+			doc.Warnings = append(doc.Warnings, fmt.Sprintf("page %d has no extractable text", page.Number))
+		}
+		doc.Pages = append(doc.Pages, page)
+	}
+	doc.Warnings = append(doc.Warnings, f.warnings...)
+	return doc, nil
+}
+
+// Text returns the text of all pages, each starting with a "--- Page N ---" marker
+// so that the model can cite pages.
+func (d *Document) Text() string {
+	var b strings.Builder
+	for i, page := range d.Pages {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, pageMarker, page.Number)
+		if page.Text != "" {
+			b.WriteString("\n")
+			b.WriteString(page.Text)
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
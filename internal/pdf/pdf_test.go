@@ -0,0 +1,289 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pdfBuilder assembles PDF files for tests. Objects are numbered from 1 in the order
+// they are added.
+type pdfBuilder struct {
+	objects []string
+}
+
+func (b *pdfBuilder) add(obj string) int {
+	b.objects = append(b.objects, obj)
+	return len(b.objects)
+}
+
+func (b *pdfBuilder) addStream(dict string, data []byte) int {
+	return b.add(fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data))
+}
+
+func (b *pdfBuilder) addFlateStream(dict string, data []byte) int {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return b.addStream(dict+" /Filter /FlateDecode", buf.Bytes())
+}
+
+// build writes the file with a cross-reference table and a trailer.
+func (b *pdfBuilder) build(trailer string) []byte {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(b.objects))
+	for i, obj := range b.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(b.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(b.objects)+1, trailer, xref)
+	return out.Bytes()
+}
+
+// helvetica is the font resource used by simplePDF pages.
+const helvetica = "<< /Font << /F1 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >> >> >>"
+
+// simplePDF returns a document with one page per content stream, all using helvetica.
+func simplePDF(contents ...string) []byte {
+	b := &pdfBuilder{}
+	b.add("<< /Type /Catalog /Pages 2 0 R >>")
+	b.add("") // Page tree, written once the pages are known
+	var kids []string
+	for _, content := range contents {
+		contentNum := b.addStream("", []byte(content))
+		page := b.add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R >>", contentNum))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	b.objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /Resources %s >>", strings.Join(kids, " "), len(kids), helvetica)
+	return b.build("/Root 1 0 R")
+}
+
+func TestExtract_SimpleText(t *testing.T) {
+	data := simplePDF(`BT
+/F1 12 Tf
+50 750 Td
+(Sample PDF Content) Tj
+0 -20 Td
+(Special characters & symbols: caf\351 \(test\)) Tj
+0 -14 Td
+(DocLoom should extract this.) Tj
+ET`)
+
+	doc, err := Extract(data, Options{})
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 1)
+	assert.Equal(t, "Sample PDF Content\n\nSpecial characters & symbols: café (test)\nDocLoom should extract this.", doc.Pages[0].Text)
+	assert.Equal(t, "--- Page 1 ---\n"+doc.Pages[0].Text+"\n", doc.Text())
+	assert.Empty(t, doc.Warnings)
+}
+
+func TestExtract_Layout(t *testing.T) {
+	// Lines are shown out of order, words are separated by positioning only and the
+	// second line has two columns
+	data := simplePDF(`BT
+/F1 10 Tf
+1 0 0 1 50 700 Tm
+[(Second)] TJ
+1 0 0 1 50 714 Tm
+[(Hel) 20 (lo) -300 (W) 80 (orld)] TJ
+ET
+BT
+/F1 10 Tf
+300 700 Td
+(Right column) Tj
+ET`)
+
+	doc, err := Extract(data, Options{})
+	require.NoError(t, err)
+	lines := strings.Split(doc.Pages[0].Text, "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "Hello World", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "Second    "), lines[1])
+	assert.True(t, strings.HasSuffix(lines[1], "    Right column"), lines[1])
+}
+
+func TestExtract_CompositeFontWithToUnicode(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+2 beginbfchar
+<0001> <0047>
+<0005> <00DF>
+endbfchar
+1 beginbfrange
+<0002> <0004> <0072>
+endbfrange
+1 beginbfrange
+<0006> <0007> [<0065> <FB01>]
+endbfrange
+endcmap
+end end`
+	b := &pdfBuilder{}
+	b.add("<< /Type /Catalog /Pages 2 0 R >>")
+	b.add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	b.add("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Contents 7 0 R >>")
+	b.add("<< /Type /Font /Subtype /Type0 /BaseFont /Noto /Encoding /Identity-H /DescendantFonts [5 0 R] /ToUnicode 6 0 R >>")
+	b.add("<< /Type /Font /Subtype /CIDFontType2 /DW 600 /W [1 [700 500 600] 5 7 550] >>")
+	b.addFlateStream("", []byte(cmap))
+	// Codes 1-3 and 5-7: G r s ß e ﬁ
+	b.addFlateStream("", []byte("BT /F1 11 Tf 72 720 Td <000100020003000500060007> Tj ET"))
+	data := b.build("/Root 1 0 R")
+
+	doc, err := Extract(data, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Grsßeﬁ", doc.Pages[0].Text)
+}
+
+func TestExtract_DifferencesAndForms(t *testing.T) {
+	b := &pdfBuilder{}
+	b.add("<< /Type /Catalog /Pages 2 0 R >>")
+	b.add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	b.add("<< /Type /Page /Parent 2 0 R /Resources << /XObject << /X1 5 0 R >> >> /Contents 4 0 R >>")
+	b.addStream("", []byte("q 1 0 0 1 0 -100 cm /X1 Do Q"))
+	b.addStream("/Type /XObject /Subtype /Form /BBox [0 0 612 792] /Resources << /Font << /F2 6 0 R >> >>",
+		[]byte("BT /F2 12 Tf 72 700 Td (\\101\\102 \\103) Tj ET /X1 Do"))
+	b.add("<< /Type /Font /Subtype /Type1 /BaseFont /Custom /Encoding << /Differences [65 /eacute /fi 67 /uni00FC] >> >>")
+	data := b.build("/Root 1 0 R")
+
+	doc, err := Extract(data, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "éﬁ ü", doc.Pages[0].Text, "the recursive form is run once")
+}
+
+func TestExtract_ObjectStream(t *testing.T) {
+	// The catalog, page tree and page live in a compressed object stream, without a
+	// trailer dictionary: the root is named by the cross-reference stream
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources " + helvetica + " /Contents 4 0 R >>",
+	}
+	var header, body bytes.Buffer
+	for i, obj := range objects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+	}
+	b := &pdfBuilder{}
+	b.add("null")
+	b.add("null")
+	b.add("null")
+	b.addFlateStream("", []byte("BT /F1 12 Tf 72 700 Td (Compressed objects) Tj ET"))
+	b.addFlateStream(fmt.Sprintf("/Type /ObjStm /N 3 /First %d", header.Len()), append(header.Bytes(), body.Bytes()...))
+	b.addStream("/Type /XRef /Root 1 0 R /Size 7", []byte("unused"))
+
+	data := b.build("")
+	// Remove the placeholder definitions and the trailer so only the streams remain
+	data = bytes.ReplaceAll(data, []byte("obj\nnull\nendobj"), []byte("obj\nendobj"))
+	for i := 1; i <= 3; i++ {
+		data = bytes.Replace(data, []byte(fmt.Sprintf("%d 0 obj\nendobj\n", i)), nil, 1)
+	}
+	data = data[:bytes.Index(data, []byte("xref\n"))]
+
+	doc, err := Extract(data, Options{})
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 1)
+	assert.Equal(t, "Compressed objects", doc.Pages[0].Text)
+}
+
+func TestExtract_OCRHook(t *testing.T) {
+	data := simplePDF(
+		"BT /F1 12 Tf 72 700 Td (Typed page) Tj ET",
+		"q 612 0 0 792 0 0 cm /Im1 Do Q",
+	)
+
+	doc, err := Extract(data, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"page 2 has no extractable text"}, doc.Warnings)
+	assert.Equal(t, "--- Page 1 ---\nTyped page\n\n--- Page 2 ---\n", doc.Text())
+
+	var pages []int
+	ocr := func(pdfData []byte, page int) (string, error) {
+		assert.Equal(t, data, pdfData)
+		pages = append(pages, page)
+		return "Scanned text\n", nil
+	}
+	doc, err = Extract(data, Options{OCR: ocr})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, pages)
+	assert.Empty(t, doc.Warnings)
+	assert.False(t, doc.Pages[0].OCR)
+	assert.True(t, doc.Pages[1].OCR)
+	assert.Equal(t, "Scanned text", doc.Pages[1].Text)
+
+	doc, err = Extract(data, Options{OCR: func([]byte, int) (string, error) { return "", errors.New("no engine") }})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"page 2: OCR failed: no engine", "page 2 has no extractable text"}, doc.Warnings)
+}
+
+func TestExtract_Errors(t *testing.T) {
+	_, err := Extract([]byte("just text"), Options{})
+	assert.ErrorContains(t, err, "not a PDF file")
+
+	b := &pdfBuilder{}
+	b.add("<< /Type /Catalog /Pages 2 0 R >>")
+	b.add("<< /Type /Pages /Kids [] /Count 0 >>")
+	b.add("<< /Filter /Standard /V 2 /R 3 >>")
+	_, err = Extract(b.build("/Root 1 0 R /Encrypt 3 0 R"), Options{})
+	assert.ErrorIs(t, err, ErrEncrypted)
+
+	_, err = Extract(b.build("/Root 1 0 R"), Options{})
+	assert.ErrorContains(t, err, "no pages found")
+}
+
+func TestExtract_DamagedContent(t *testing.T) {
+	b := &pdfBuilder{}
+	b.add("<< /Type /Catalog /Pages 2 0 R >>")
+	b.add("<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 /Resources " + helvetica + " >>")
+	b.add("<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>")
+	b.addStream("/Filter /LZWDecode", []byte("binary"))
+	b.add("<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>")
+	// Wrong length and an unbalanced array
+	b.add("<< /Length 999 >>\nstream\nBT /F1 12 Tf 72 700 Td [(Still ) (readable)] TJ ] ET\nendstream")
+
+	doc, err := Extract(b.build("/Root 1 0 R"), Options{})
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 2)
+	assert.Equal(t, "Still readable", doc.Pages[1].Text)
+	assert.Contains(t, doc.Warnings, "page 1: content stream: unsupported filter LZWDecode")
+}
+
+func TestExtract_DecompressionLimits(t *testing.T) {
+	streamLimit, documentLimit := maxStreamSize, maxDocumentSize
+	maxStreamSize, maxDocumentSize = 1<<20, 2<<20
+	t.Cleanup(func() { maxStreamSize, maxDocumentSize = streamLimit, documentLimit })
+
+	// Each content stream of a few kilobytes inflates to 4 MB
+	b := &pdfBuilder{}
+	b.add("<< /Type /Catalog /Pages 2 0 R >>")
+	b.add("<< /Type /Pages /Kids [3 0 R 5 0 R 7 0 R] /Count 3 /Resources " + helvetica + " >>")
+	for i, text := range []string{"First", "Second", "Third"} {
+		b.add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Contents %d 0 R >>", 4+2*i))
+		b.addFlateStream("", []byte("BT /F1 12 Tf 72 700 Td ("+text+") Tj ET\n"+strings.Repeat(" ", 4<<20)))
+	}
+
+	doc, err := Extract(b.build("/Root 1 0 R"), Options{})
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 3)
+	assert.Equal(t, "First", doc.Pages[0].Text)
+	assert.Equal(t, "Second", doc.Pages[1].Text)
+	assert.Empty(t, doc.Pages[2].Text)
+	assert.Contains(t, doc.Warnings, "a stream decompresses to more than 1 MB; its text beyond that is skipped")
+	assert.Contains(t, doc.Warnings, "decompressed streams exceed the limit of 2 MB per document; the remaining text is skipped")
+	assert.Contains(t, doc.Warnings, "page 3: content stream: FlateDecode: document size limit reached")
+}
@@ -5,14 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"text/template"
 )
 
-// Builder is responsible for constructing prompts for the AI model.
-type Builder struct{}
+// Builder is responsible for constructing prompts for the AI model. The generation
+// and repair prompts are rendered from templates that can be overridden with
+// LoadOverrides.
+type Builder struct {
+	templates *template.Template
+}
 
-// NewBuilder creates a new prompt builder.
+// NewBuilder creates a new prompt builder with the built-in prompt templates.
 func NewBuilder() *Builder {
-	return &Builder{}
+	return &Builder{templates: defaultTemplates}
 }
 
 // BuildGenerationPrompt assembles a prompt for generating JSON content based on source documents and a template.
@@ -32,36 +37,15 @@ func (b *Builder) BuildGenerationPrompt(sourceContent string, templatePrompt str
 		schemaJSON = string(schemaBytes)
 	}
 
-	// Build the prompt with clear sections
-	var promptBuilder strings.Builder
-
-	promptBuilder.WriteString("You are a technical documentation generator. ")
-	promptBuilder.WriteString("Your task is to generate structured JSON content based on the provided source documents and template requirements.\n\n")
-
-	promptBuilder.WriteString("## Template Instructions\n")
-	promptBuilder.WriteString(templatePrompt)
-	promptBuilder.WriteString("\n\n")
-
-	promptBuilder.WriteString("## JSON Schema\n")
-	promptBuilder.WriteString("Your response MUST conform to the following JSON schema:\n")
-	promptBuilder.WriteString("```json\n")
-	promptBuilder.WriteString(schemaJSON)
-	promptBuilder.WriteString("\n```\n\n")
-
-	promptBuilder.WriteString("## Source Documents\n")
-	promptBuilder.WriteString("Use the following source content to generate the JSON fields:\n")
-	promptBuilder.WriteString("```\n")
-	promptBuilder.WriteString(sourceContent)
-	promptBuilder.WriteString("\n```\n\n")
+	var sourceMapping strings.Builder
+	writeSourceMapping(&sourceMapping, FieldSources(schemaJSON))
 
-	promptBuilder.WriteString("## Instructions\n")
-	promptBuilder.WriteString("1. Analyze the source documents carefully\n")
-	promptBuilder.WriteString("2. Generate JSON that matches the schema exactly\n")
-	promptBuilder.WriteString("3. Use information from the source documents to populate the fields\n")
-	promptBuilder.WriteString("4. Ensure all required fields are present\n")
-	promptBuilder.WriteString("5. Return ONLY valid JSON, no additional text or markdown formatting\n")
-
-	return promptBuilder.String(), nil
+	return b.render(GenerationTemplate, generationData{
+		Instructions:  templatePrompt,
+		SourceMapping: sourceMapping.String(),
+		Schema:        schemaJSON,
+		Sources:       sourceContent,
+	})
 }
 
 // BuildRepairPrompt creates a prompt for repairing invalid JSON based on validation errors.
@@ -81,46 +65,261 @@ func (b *Builder) BuildRepairPrompt(originalPrompt string, invalidJSON string, v
 		schemaJSON = string(schemaBytes)
 	}
 
+	return b.render(RepairTemplate, repairData{
+		Error:          validationError,
+		InvalidJSON:    invalidJSON,
+		Schema:         schemaJSON,
+		OriginalPrompt: originalPrompt,
+	})
+}
+
+// RepairIssue is a validation failure of a single field for a targeted repair prompt.
+type RepairIssue struct {
+	Field   string // JSON pointer of the field, e.g. "/risks/0/level"
+	Problem string // The violated constraint
+	Example string // JSON of a valid value for the field, or empty
+	Remove  bool   // The field is not allowed and must be removed
+}
+
+// BuildTargetedRepairPrompt creates a repair prompt for a document that failed
+// validation on a few fields. Instead of the full schema and original prompt it
+// names each failing field with its violated constraint and an example of a valid
+// value, which keeps repairs of near-valid documents small for large templates.
+// originalPrompt is included only when not empty, for fields whose content must be
+// written from the sources.
+func (b *Builder) BuildTargetedRepairPrompt(invalidJSON string, issues []RepairIssue, originalPrompt string) (string, error) {
+	fields := make([]RepairIssue, len(issues))
+	for i, issue := range issues {
+		if issue.Field == "" {
+			issue.Field = "/"
+		}
+		fields[i] = issue
+	}
+	return b.render(TargetedRepairTemplate, targetedRepairData{
+		Issues:         fields,
+		InvalidJSON:    invalidJSON,
+		OriginalPrompt: originalPrompt,
+	})
+}
+
+// AppendPitfalls extends a generation prompt with validation failures that recurred
+// in earlier documents of the same template, so the model avoids them up front.
+func (b *Builder) AppendPitfalls(generationPrompt string, pitfalls []string) string {
 	var promptBuilder strings.Builder
 
-	promptBuilder.WriteString("The previously generated JSON failed validation. Please fix the issues and generate valid JSON.\n\n")
+	promptBuilder.WriteString(generationPrompt)
+	promptBuilder.WriteString("\n## Common Pitfalls\n")
+	promptBuilder.WriteString("Earlier documents generated from this template repeatedly failed validation as follows. Avoid these mistakes:\n")
+	for _, pitfall := range pitfalls {
+		promptBuilder.WriteString("- " + pitfall + "\n")
+	}
 
-	promptBuilder.WriteString("## Validation Error\n")
-	promptBuilder.WriteString("The following validation error occurred:\n")
-	promptBuilder.WriteString("```\n")
-	promptBuilder.WriteString(validationError)
+	return promptBuilder.String()
+}
+
+// BuildDerivationPrompt creates a prompt for deriving a single field (such as an executive
+// summary) from an already generated document rather than from the raw sources.
+func (b *Builder) BuildDerivationPrompt(documentJSON string, fieldPath string, derivationPrompt string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString("You are finalizing a generated technical document. ")
+	promptBuilder.WriteString("Derive the requested field strictly from the document content below so that it reflects what the document actually says.\n\n")
+
+	promptBuilder.WriteString("## Derivation Instructions\n")
+	promptBuilder.WriteString(derivationPrompt)
+	promptBuilder.WriteString("\n\n")
+
+	promptBuilder.WriteString("## Generated Document\n")
+	promptBuilder.WriteString("```json\n")
+	promptBuilder.WriteString(documentJSON)
 	promptBuilder.WriteString("\n```\n\n")
 
-	promptBuilder.WriteString("## Invalid JSON\n")
-	promptBuilder.WriteString("This was the invalid JSON that was generated:\n")
+	promptBuilder.WriteString("## Output Format\n")
+	promptBuilder.WriteString(fmt.Sprintf("Return ONLY a JSON object of the form {\"value\": ...} containing the value for the field '%s'.\n", fieldPath))
+	promptBuilder.WriteString("Do not introduce facts that are not present in the generated document.\n")
+
+	return promptBuilder.String()
+}
+
+// BuildFieldPrompt extends a generation prompt to request a single top-level field.
+// inputsJSON holds the already generated fields the field depends on, or is empty.
+func (b *Builder) BuildFieldPrompt(generationPrompt string, field string, fieldSchema json.RawMessage, inputsJSON string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString(generationPrompt)
+	promptBuilder.WriteString("\n\n")
+
+	promptBuilder.WriteString("## Field Generation\n")
+	promptBuilder.WriteString(fmt.Sprintf("Generate only the field '%s' of the document now. Its value must conform to this schema:\n", field))
 	promptBuilder.WriteString("```json\n")
-	promptBuilder.WriteString(invalidJSON)
+	promptBuilder.Write(fieldSchema)
 	promptBuilder.WriteString("\n```\n\n")
 
-	promptBuilder.WriteString("## Required Schema\n")
-	promptBuilder.WriteString("The JSON MUST conform to this schema:\n")
+	if inputsJSON != "" {
+		promptBuilder.WriteString("The field builds on these already generated fields. Keep it consistent with them:\n")
+		promptBuilder.WriteString("```json\n")
+		promptBuilder.WriteString(inputsJSON)
+		promptBuilder.WriteString("\n```\n\n")
+	}
+
+	promptBuilder.WriteString("## Output Format\n")
+	promptBuilder.WriteString(fmt.Sprintf("Instead of the whole document, return ONLY a JSON object of the form {\"value\": ...} containing the value for the field '%s'.\n", field))
+
+	return promptBuilder.String()
+}
+
+// AppendReviewFeedback extends a field prompt with the value a reviewer rejected and
+// their instructions, which may be empty, for regenerating the field.
+func (b *Builder) AppendReviewFeedback(fieldPrompt string, rejectedJSON string, instructions string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString(fieldPrompt)
+	promptBuilder.WriteString("\n## Reviewer Feedback\n")
+	promptBuilder.WriteString("A reviewer rejected this value of the field. Write a better one:\n")
+	promptBuilder.WriteString("```json\n")
+	promptBuilder.WriteString(rejectedJSON)
+	promptBuilder.WriteString("\n```\n")
+	if instructions != "" {
+		promptBuilder.WriteString("\nThe reviewer's instructions:\n")
+		promptBuilder.WriteString(instructions)
+		promptBuilder.WriteString("\n")
+	}
+
+	return promptBuilder.String()
+}
+
+// BuildSectionPrompt extends a generation prompt to request the top-level fields of a
+// section together. sectionSchema is an object schema of those fields, and inputsJSON
+// holds the already generated fields the section depends on, or is empty.
+func (b *Builder) BuildSectionPrompt(generationPrompt string, section string, sectionSchema json.RawMessage, inputsJSON string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString(generationPrompt)
+	promptBuilder.WriteString("\n\n")
+
+	promptBuilder.WriteString("## Section Generation\n")
+	promptBuilder.WriteString(fmt.Sprintf("Generate only the fields of the section '%s' of the document now. Together they must conform to this schema:\n", section))
 	promptBuilder.WriteString("```json\n")
-	promptBuilder.WriteString(schemaJSON)
+	promptBuilder.Write(sectionSchema)
 	promptBuilder.WriteString("\n```\n\n")
 
-	promptBuilder.WriteString("## Original Context\n")
-	promptBuilder.WriteString(originalPrompt)
+	if inputsJSON != "" {
+		promptBuilder.WriteString("The section builds on these already generated fields. Keep it consistent with them:\n")
+		promptBuilder.WriteString("```json\n")
+		promptBuilder.WriteString(inputsJSON)
+		promptBuilder.WriteString("\n```\n\n")
+	}
+
+	promptBuilder.WriteString("## Output Format\n")
+	promptBuilder.WriteString(fmt.Sprintf("Instead of the whole document, return ONLY a JSON object containing the fields of the section '%s'.\n", section))
+
+	return promptBuilder.String()
+}
+
+// BuildEvaluationPrompt creates a prompt asking a judge model to score a generated
+// document against a rubric: completeness per schema field, groundedness against the
+// sources and clarity.
+func (b *Builder) BuildEvaluationPrompt(sourceContent string, documentJSON string, fieldNames []string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString("You are a strict reviewer of generated technical documentation. ")
+	promptBuilder.WriteString("Score the generated document below against the source documents it was written from.\n\n")
+
+	promptBuilder.WriteString("## Rubric\n")
+	promptBuilder.WriteString("Score each criterion from 0 (unacceptable) to 10 (excellent):\n")
+	promptBuilder.WriteString("- completeness: every schema field is filled with substantive, specific content\n")
+	promptBuilder.WriteString("- groundedness: every statement is supported by the source documents\n")
+	promptBuilder.WriteString("- clarity: the content is clear, well organized and free of filler\n")
+	promptBuilder.WriteString("Also score the completeness of each of these top-level fields: ")
+	promptBuilder.WriteString(strings.Join(fieldNames, ", "))
 	promptBuilder.WriteString("\n\n")
 
-	promptBuilder.WriteString("## Repair Instructions\n")
-	promptBuilder.WriteString("1. Identify the validation error in the JSON\n")
-	promptBuilder.WriteString("2. Fix the specific issue mentioned in the error\n")
-	promptBuilder.WriteString("3. Ensure the repaired JSON matches the schema exactly\n")
-	promptBuilder.WriteString("4. Preserve all valid content from the original JSON\n")
-	promptBuilder.WriteString("5. Return ONLY the repaired JSON, no additional text\n")
+	promptBuilder.WriteString("## Source Documents\n")
+	promptBuilder.WriteString("```\n")
+	promptBuilder.WriteString(sourceContent)
+	promptBuilder.WriteString("\n```\n\n")
 
-	return promptBuilder.String(), nil
+	promptBuilder.WriteString("## Generated Document\n")
+	promptBuilder.WriteString("```json\n")
+	promptBuilder.WriteString(documentJSON)
+	promptBuilder.WriteString("\n```\n\n")
+
+	promptBuilder.WriteString("## Output Format\n")
+	promptBuilder.WriteString("Return ONLY a JSON object of the form:\n")
+	promptBuilder.WriteString(`{"completeness": 0-10, "groundedness": 0-10, "clarity": 0-10, "fields": {"<field>": 0-10}, "comments": ["<short finding>"]}`)
+	promptBuilder.WriteString("\n")
+
+	return promptBuilder.String()
+}
+
+// BuildConfidencePrompt creates a prompt asking the model how confident it is in
+// each top-level field of a generated document, given the sources it was written from.
+func (b *Builder) BuildConfidencePrompt(sourceContent string, documentJSON string, fieldNames []string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString("You wrote the document below from the source documents. ")
+	promptBuilder.WriteString("Rate how confident you are in each of its fields so that a reviewer knows where to look first.\n\n")
+
+	promptBuilder.WriteString("## Scoring\n")
+	promptBuilder.WriteString("Score each of these top-level fields from 0 (guessed, not supported by the sources) to 1 (stated directly in the sources): ")
+	promptBuilder.WriteString(strings.Join(fieldNames, ", "))
+	promptBuilder.WriteString("\nFor every field scoring below 0.7, give a short reason.\n\n")
+
+	promptBuilder.WriteString("## Source Documents\n")
+	promptBuilder.WriteString("```\n")
+	promptBuilder.WriteString(sourceContent)
+	promptBuilder.WriteString("\n```\n\n")
+
+	promptBuilder.WriteString("## Generated Document\n")
+	promptBuilder.WriteString("```json\n")
+	promptBuilder.WriteString(documentJSON)
+	promptBuilder.WriteString("\n```\n\n")
+
+	promptBuilder.WriteString("## Output Format\n")
+	promptBuilder.WriteString("Return ONLY a JSON object of the form:\n")
+	promptBuilder.WriteString(`{"fields": {"<field>": 0-1}, "reasons": {"<field>": "<short reason>"}}`)
+	promptBuilder.WriteString("\n")
+
+	return promptBuilder.String()
 }
 
-// EstimateTokens provides a rough estimate of the number of tokens in a prompt.
-// This is a simple heuristic and not exact.
-func (b *Builder) EstimateTokens(prompt string) int {
-	// Rough estimate: ~1 token per 4 characters or ~0.75 tokens per word
-	// Using character-based estimation for simplicity
-	return len(prompt) / 4
+// BuildReconciliationPrompt creates a prompt that merges two independently generated
+// candidates of the same document into one, field by field.
+func (b *Builder) BuildReconciliationPrompt(candidateA string, candidateB string, disagreements []string, schema interface{}) (string, error) {
+	schemaBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString("Two models independently generated the same technical document from the same sources. ")
+	promptBuilder.WriteString("Reconcile them into a single, most accurate document.\n\n")
+
+	promptBuilder.WriteString("## Candidate A\n")
+	promptBuilder.WriteString("```json\n")
+	promptBuilder.WriteString(candidateA)
+	promptBuilder.WriteString("\n```\n\n")
+
+	promptBuilder.WriteString("## Candidate B\n")
+	promptBuilder.WriteString("```json\n")
+	promptBuilder.WriteString(candidateB)
+	promptBuilder.WriteString("\n```\n\n")
+
+	promptBuilder.WriteString("## Fields in Disagreement\n")
+	promptBuilder.WriteString(strings.Join(disagreements, ", "))
+	promptBuilder.WriteString("\n\n")
+
+	promptBuilder.WriteString("## JSON Schema\n")
+	promptBuilder.WriteString("```json\n")
+	promptBuilder.Write(schemaBytes)
+	promptBuilder.WriteString("\n```\n\n")
+
+	promptBuilder.WriteString("## Reconciliation Instructions\n")
+	promptBuilder.WriteString("1. Keep fields on which both candidates agree unchanged\n")
+	promptBuilder.WriteString("2. For each field in disagreement, choose the more specific and accurate value or merge both\n")
+	promptBuilder.WriteString("3. Never introduce content that appears in neither candidate\n")
+	promptBuilder.WriteString("4. Return ONLY the reconciled JSON document conforming to the schema\n")
+
+	return promptBuilder.String(), nil
 }
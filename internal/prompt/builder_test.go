@@ -256,52 +256,85 @@ func TestBuildRepairPrompt(t *testing.T) {
 	}
 }
 
-// TestEstimateTokens tests the token estimation functionality
-func TestEstimateTokens(t *testing.T) {
+// TestBuildTargetedRepairPrompt tests the repair prompt for near-valid documents
+func TestBuildTargetedRepairPrompt(t *testing.T) {
 	builder := NewBuilder()
+	invalidJSON := `{"title": "Payments", "risks": [{"level": "severe"}], "draft": true}`
+
+	prompt, err := builder.BuildTargetedRepairPrompt(invalidJSON, []RepairIssue{
+		{Field: "/risks/0/level", Problem: "value must be one of \"low\", \"high\"", Example: `"low"`},
+		{Field: "/owner", Problem: "required property is missing", Example: `"text"`},
+		{Field: "/draft", Problem: "property is not allowed", Remove: true},
+		{Problem: "end date must not be before start date"},
+	}, "")
+	require.NoError(t, err)
+
+	assert.Contains(t, prompt, "## Fields to Fix")
+	assert.Contains(t, prompt, "- `/risks/0/level`: value must be one of \"low\", \"high\". Example of a valid value: `\"low\"`")
+	assert.Contains(t, prompt, "- `/owner`: required property is missing. Example of a valid value: `\"text\"`")
+	assert.Contains(t, prompt, "- `/draft`: property is not allowed. Remove this field.")
+	assert.Contains(t, prompt, "- `/`: end date must not be before start date\n")
+	assert.Contains(t, prompt, invalidJSON)
+
+	// The targeted prompt leaves out the schema and the original context
+	assert.NotContains(t, prompt, "## Required Schema")
+	assert.NotContains(t, prompt, "## Original Context")
+
+	// Content for missing fields is written from the original context
+	prompt, err = builder.BuildTargetedRepairPrompt(invalidJSON, []RepairIssue{{Field: "/owner", Problem: "required property is missing"}}, "Original generation prompt")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "## Original Context\nOriginal generation prompt")
+	assert.NotContains(t, prompt, "## Required Schema")
+}
 
-	tests := []struct {
-		name        string
-		prompt      string
-		expectedMin int
-		expectedMax int
-	}{
-		{
-			name:        "Empty prompt",
-			prompt:      "",
-			expectedMin: 0,
-			expectedMax: 0,
-		},
-		{
-			name:        "Short prompt",
-			prompt:      "Hello world",
-			expectedMin: 2, // 11 chars / 4 = 2.75, rounded down
-			expectedMax: 3,
-		},
-		{
-			name:        "Medium prompt",
-			prompt:      strings.Repeat("This is a test. ", 10),
-			expectedMin: 35, // 160 chars / 4 = 40
-			expectedMax: 45,
-		},
-		{
-			name:        "Long prompt",
-			prompt:      strings.Repeat("Generate a comprehensive technical document. ", 50),
-			expectedMin: 500, // 2250 chars / 4 = 562
-			expectedMax: 600,
-		},
-	}
+// TestBuildDerivationPrompt tests the derivation prompt for derived fields
+func TestBuildDerivationPrompt(t *testing.T) {
+	builder := NewBuilder()
+	documentJSON := `{"title": "Payments Platform", "sections": ["Ingress", "Ledger"]}`
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tokens := builder.EstimateTokens(tt.prompt)
+	prompt := builder.BuildDerivationPrompt(documentJSON, "summary", "Write a two sentence executive summary.")
 
-			assert.GreaterOrEqual(t, tokens, tt.expectedMin,
-				"Token count should be at least %d", tt.expectedMin)
-			assert.LessOrEqual(t, tokens, tt.expectedMax,
-				"Token count should be at most %d", tt.expectedMax)
-		})
-	}
+	assert.Contains(t, prompt, "## Derivation Instructions")
+	assert.Contains(t, prompt, "Write a two sentence executive summary.")
+	assert.Contains(t, prompt, "## Generated Document")
+	assert.Contains(t, prompt, documentJSON)
+	assert.Contains(t, prompt, `{"value": ...}`)
+	assert.Contains(t, prompt, "'summary'")
+
+	// Derivation must not be based on raw sources
+	assert.NotContains(t, prompt, "## Source Documents")
+}
+
+// TestBuildEvaluationPrompt tests the judge prompt used by the evaluation stage
+func TestBuildEvaluationPrompt(t *testing.T) {
+	builder := NewBuilder()
+	documentJSON := `{"title": "Payments Platform", "overview": "Ledger owns balances."}`
+
+	prompt := builder.BuildEvaluationPrompt("# Source notes", documentJSON, []string{"title", "overview"})
+
+	assert.Contains(t, prompt, "## Rubric")
+	assert.Contains(t, prompt, "completeness")
+	assert.Contains(t, prompt, "groundedness")
+	assert.Contains(t, prompt, "clarity")
+	assert.Contains(t, prompt, "title, overview")
+	assert.Contains(t, prompt, "# Source notes")
+	assert.Contains(t, prompt, documentJSON)
+}
+
+// TestBuildReconciliationPrompt tests the ensemble reconciliation prompt
+func TestBuildReconciliationPrompt(t *testing.T) {
+	builder := NewBuilder()
+	schema := map[string]interface{}{"type": "object"}
+
+	prompt, err := builder.BuildReconciliationPrompt(`{"body": "A"}`, `{"body": "B"}`, []string{"body"}, schema)
+	require.NoError(t, err)
+
+	assert.Contains(t, prompt, "## Candidate A")
+	assert.Contains(t, prompt, `{"body": "A"}`)
+	assert.Contains(t, prompt, "## Candidate B")
+	assert.Contains(t, prompt, `{"body": "B"}`)
+	assert.Contains(t, prompt, "## Fields in Disagreement\nbody")
+	assert.Contains(t, prompt, `"type": "object"`)
 }
 
 // TestPromptStructure tests that prompts have the expected structure
@@ -450,6 +483,31 @@ func TestPromptConsistency(t *testing.T) {
 	assert.Equal(t, prompt1, prompt2, "Prompts should be consistent for the same inputs")
 }
 
+// TestBuildGenerationPrompt_SourceMapping tests that fields declaring x-source are
+// mapped to their source roots in the prompt.
+func TestBuildGenerationPrompt_SourceMapping(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"endpoints": {"type": "string", "x-source": "provider"},
+			"walkthrough": {"type": "string", "x-source": ["consumer", "provider"]},
+			"title": {"type": "string"}
+		}
+	}`
+
+	generated, err := NewBuilder().BuildGenerationPrompt("content", "Write an integration guide", schema)
+	require.NoError(t, err)
+
+	assert.Contains(t, generated, "## Source Mapping\n")
+	assert.Contains(t, generated, "- `endpoints`: provider\n- `walkthrough`: consumer, provider\n")
+	assert.NotContains(t, generated, "- `title`")
+	assert.Less(t, strings.Index(generated, "## Source Mapping"), strings.Index(generated, "## JSON Schema"))
+
+	plain, err := NewBuilder().BuildGenerationPrompt("content", "Write a guide", `{"type": "object"}`)
+	require.NoError(t, err)
+	assert.NotContains(t, plain, "## Source Mapping")
+}
+
 // BenchmarkBuildGenerationPrompt benchmarks prompt generation
 func BenchmarkBuildGenerationPrompt(b *testing.B) {
 	builder := NewBuilder()
@@ -472,14 +530,3 @@ func BenchmarkBuildGenerationPrompt(b *testing.B) {
 		_, _ = builder.BuildGenerationPrompt(sourceContent, templatePrompt, schema)
 	}
 }
-
-// BenchmarkEstimateTokens benchmarks token estimation
-func BenchmarkEstimateTokens(b *testing.B) {
-	builder := NewBuilder()
-	prompt := strings.Repeat("This is a test prompt for token estimation. ", 100)
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_ = builder.EstimateTokens(prompt)
-	}
-}
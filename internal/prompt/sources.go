@@ -0,0 +1,60 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SourceKeyword is the schema extension naming the source roots a field is generated
+// from, for example {"type": "string", "x-source": ["provider", "consumer"]}. A single
+// label may be given as a string.
+const SourceKeyword = "x-source"
+
+// FieldSources returns the source labels of the top-level schema properties that
+// declare SourceKeyword, keyed by property name.
+func FieldSources(schemaJSON string) map[string][]string {
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		return nil
+	}
+
+	sources := make(map[string][]string)
+	for field, property := range schema.Properties {
+		switch value := property[SourceKeyword].(type) {
+		case string:
+			sources[field] = []string{value}
+		case []interface{}:
+			for _, label := range value {
+				if label, ok := label.(string); ok {
+					sources[field] = append(sources[field], label)
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// writeSourceMapping writes the section telling the model which source roots feed
+// which fields. Nothing is written when no field declares its sources.
+func writeSourceMapping(b *strings.Builder, fieldSources map[string][]string) {
+	if len(fieldSources) == 0 {
+		return
+	}
+	fields := make([]string, 0, len(fieldSources))
+	for field := range fieldSources {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	b.WriteString("## Source Mapping\n")
+	b.WriteString("The source documents are grouped by source under \"=== Source: <label> (<path>) ===\" headers. ")
+	b.WriteString("Populate these fields only from the named sources:\n")
+	for _, field := range fields {
+		fmt.Fprintf(b, "- `%s`: %s\n", field, strings.Join(fieldSources[field], ", "))
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,120 @@
+package prompt
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Names of the prompt templates, which are also the file names that override them.
+const (
+	GenerationTemplate     = "generation.tmpl"
+	RepairTemplate         = "repair.tmpl"
+	TargetedRepairTemplate = "targeted-repair.tmpl"
+)
+
+// defaultTemplatesFS holds the built-in prompt templates.
+//
+//go:embed templates/*.tmpl
+var defaultTemplatesFS embed.FS
+
+// defaultTemplates are the parsed built-in prompt templates.
+var defaultTemplates = template.Must(template.ParseFS(defaultTemplatesFS, "templates/*.tmpl"))
+
+// generationData is the data of the generation prompt template.
+type generationData struct {
+	Instructions  string // The template's prompt
+	SourceMapping string // The section naming the sources of fields, or empty
+	Schema        string
+	Sources       string
+}
+
+// repairData is the data of the repair prompt template.
+type repairData struct {
+	Error          string
+	InvalidJSON    string
+	Schema         string
+	OriginalPrompt string
+}
+
+// targetedRepairData is the data of the targeted repair prompt template.
+type targetedRepairData struct {
+	Issues         []RepairIssue
+	InvalidJSON    string
+	OriginalPrompt string // Empty unless fields must be written from the sources
+}
+
+// sampleData holds data of every prompt template, for checking overrides.
+var sampleData = map[string]interface{}{
+	GenerationTemplate:     generationData{},
+	RepairTemplate:         repairData{},
+	TargetedRepairTemplate: targetedRepairData{Issues: []RepairIssue{{}}},
+}
+
+// DefaultPromptDir returns the directory of prompt template overrides,
+// ~/.docloom/prompts, or an empty string when there is no home directory.
+func DefaultPromptDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".docloom", "prompts")
+}
+
+// LoadOverrides replaces built-in prompt templates with the files of the same name
+// in dir, such as generation.tmpl, written in Go text/template syntax. Templates
+// without a file keep their built-in text. A directory that does not exist is
+// skipped.
+func (b *Builder) LoadOverrides(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read prompt directory: %w", err)
+	}
+
+	templates, err := b.templates.Clone()
+	if err != nil {
+		return fmt.Errorf("failed to copy prompt templates: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".tmpl" {
+			continue
+		}
+		data, ok := sampleData[name]
+		if !ok {
+			return fmt.Errorf("unknown prompt template %s in %s (expected %s, %s or %s)", name, dir, GenerationTemplate, RepairTemplate, TargetedRepairTemplate)
+		}
+		text, err := os.ReadFile(filepath.Join(dir, name)) // #nosec G304 -- prompt directories are user-provided
+		if err != nil {
+			return fmt.Errorf("failed to read prompt template %s: %w", name, err)
+		}
+		override, err := templates.New(name).Parse(string(text))
+		if err != nil {
+			return fmt.Errorf("invalid prompt template %s: %w", name, err)
+		}
+		// Catch references to unknown fields now rather than in the middle of a run
+		if err := override.Execute(io.Discard, data); err != nil {
+			return fmt.Errorf("invalid prompt template %s: %w", name, err)
+		}
+	}
+	b.templates = templates
+	return nil
+}
+
+// render executes the named prompt template.
+func (b *Builder) render(name string, data interface{}) (string, error) {
+	var prompt strings.Builder
+	if err := b.templates.ExecuteTemplate(&prompt, name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	return prompt.String(), nil
+}
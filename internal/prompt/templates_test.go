@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadOverrides tests that prompt templates in the override directory replace
+// the built-in ones.
+func TestLoadOverrides(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, GenerationTemplate), []byte("Write in German.\n{{.Instructions}}\n{{.Schema}}\n{{.Sources}}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a template"), 0644))
+
+	builder := NewBuilder()
+	require.NoError(t, builder.LoadOverrides(dir))
+
+	generated, err := builder.BuildGenerationPrompt("source text", "Describe the system", `{"type": "object"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "Write in German.\nDescribe the system\n{\"type\": \"object\"}\nsource text\n", generated)
+
+	// Templates without an override keep their built-in text
+	repair, err := builder.BuildRepairPrompt("original", "{}", "missing title", `{"type": "object"}`)
+	require.NoError(t, err)
+	assert.Contains(t, repair, "## Repair Instructions")
+
+	// Other builders are not affected
+	generated, err = NewBuilder().BuildGenerationPrompt("source text", "Describe the system", `{"type": "object"}`)
+	require.NoError(t, err)
+	assert.Contains(t, generated, "## Template Instructions")
+}
+
+// TestLoadOverrides_Invalid tests that broken overrides are reported when loaded.
+func TestLoadOverrides_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		errMsg   string
+	}{
+		{"unknown template", "summary.tmpl", "text", "unknown prompt template summary.tmpl"},
+		{"syntax error", RepairTemplate, "{{.Error", "invalid prompt template repair.tmpl"},
+		{"unknown field", TargetedRepairTemplate, "{{range .Issues}}{{.Path}}{{end}}", "invalid prompt template targeted-repair.tmpl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.contents), 0644))
+
+			builder := NewBuilder()
+			err := builder.LoadOverrides(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+
+			// The built-in templates stay in use
+			generated, err := builder.BuildGenerationPrompt("source text", "Describe the system", `{"type": "object"}`)
+			require.NoError(t, err)
+			assert.Contains(t, generated, "## Template Instructions")
+		})
+	}
+}
+
+// TestLoadOverrides_MissingDirectory tests that a missing override directory is skipped.
+func TestLoadOverrides_MissingDirectory(t *testing.T) {
+	assert.NoError(t, NewBuilder().LoadOverrides(filepath.Join(t.TempDir(), "prompts")))
+}
@@ -0,0 +1,133 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fingerprintLength is the number of hex digits of the content hash put in the names
+// of copied assets.
+const fingerprintLength = 8
+
+// assetReferencePattern matches the references a document or stylesheet makes to other
+// files: href and src attributes and CSS url() values. The path is the second, fourth
+// or seventh group, without any query string or fragment.
+var assetReferencePattern = regexp.MustCompile(`((?:href|src)\s*=\s*)(?:"([^"?#]*)([?#][^"]*)?"|'([^'?#]*)([?#][^']*)?')|(url\(\s*["']?)([^"')?#]*)([?#][^"')]*)?(["']?\s*\))`)
+
+// FingerprintName returns name with the first hex digits of the SHA-256 hash of
+// content inserted before its extension, e.g. "css/style.1a2b3c4d.css", so that a
+// changed asset gets a new URL and caches never serve the old one.
+func FingerprintName(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])[:fingerprintLength]
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// FingerprintAssets returns the template assets, keyed by slash-separated path relative
+// to the template directory, under fingerprinted names, and the rewritten name of each
+// asset. References between assets, such as a stylesheet's url() of an image, are
+// rewritten first, so a stylesheet's fingerprint changes when an image it uses does.
+func FingerprintAssets(assets map[string][]byte) (files map[string][]byte, names map[string]string) {
+	files = make(map[string][]byte, len(assets))
+	names = make(map[string]string, len(assets))
+
+	// Stylesheets last, once the names they may refer to are known
+	var order []string
+	for name := range assets {
+		order = append(order, name)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		iCSS, jCSS := path.Ext(order[i]) == ".css", path.Ext(order[j]) == ".css"
+		if iCSS != jCSS {
+			return jCSS
+		}
+		return order[i] < order[j]
+	})
+	for _, name := range order {
+		content := assets[name]
+		if path.Ext(name) == ".css" {
+			content = []byte(rewriteReferences(string(content), path.Dir(name), names))
+		}
+		fingerprinted := FingerprintName(name, content)
+		files[fingerprinted] = content
+		names[name] = fingerprinted
+	}
+	return files, names
+}
+
+// RewriteAssetReferences replaces the references of the rendered HTML to template
+// assets with their fingerprinted names. Query strings and fragments are kept;
+// references to other files and absolute URLs are left alone.
+func RewriteAssetReferences(html string, names map[string]string) string {
+	return rewriteReferences(html, ".", names)
+}
+
+// rewriteReferences rewrites the asset references of content, a file in directory dir
+// relative to the template directory.
+func rewriteReferences(content, dir string, names map[string]string) string {
+	if len(names) == 0 {
+		return content
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range assetReferencePattern.FindAllStringSubmatchIndex(content, -1) {
+		// The path is group 2 (double-quoted attribute), 4 (single-quoted) or 7 (url())
+		for _, group := range []int{2, 4, 7} {
+			start, end := loc[2*group], loc[2*group+1]
+			if start < 0 {
+				continue
+			}
+			if renamed, ok := renameReference(content[start:end], dir, names); ok {
+				b.WriteString(content[last:start])
+				b.WriteString(renamed)
+				last = end
+			}
+			break
+		}
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// renameReference returns the fingerprinted form of ref, a relative reference made by
+// a file in dir, when it names an asset.
+func renameReference(ref, dir string, names map[string]string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.Contains(ref, ":") || strings.HasPrefix(ref, "/") {
+		return "", false
+	}
+	target := path.Join(dir, ref)
+	renamed, ok := names[target]
+	if !ok {
+		return "", false
+	}
+	// Keep the reference relative to the referring file
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(renamed))
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// WriteAssets writes the fingerprinted assets into dir, the directory of the output
+// document. Fingerprinted names change with their content, so an existing file of the
+// same name already holds the same asset and is simply replaced.
+func WriteAssets(dir string, files map[string][]byte) ([]string, error) {
+	written := make([]string, 0, len(files))
+	for name, content := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := WriteFileAtomic(target, content); err != nil {
+			return nil, fmt.Errorf("failed to write template asset %s: %w", name, err)
+		}
+		written = append(written, target)
+	}
+	sort.Strings(written)
+	return written, nil
+}
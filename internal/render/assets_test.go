@@ -0,0 +1,89 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFingerprintName(t *testing.T) {
+	name := FingerprintName("css/style.css", []byte("body {}"))
+	if !strings.HasPrefix(name, "css/style.") || !strings.HasSuffix(name, ".css") || len(name) != len("css/style..css")+fingerprintLength {
+		t.Errorf("Unexpected fingerprinted name %q", name)
+	}
+	if FingerprintName("css/style.css", []byte("body {}")) != name {
+		t.Error("Expected the same content to get the same name")
+	}
+	if FingerprintName("css/style.css", []byte("body { color: red; }")) == name {
+		t.Error("Expected changed content to get a new name")
+	}
+}
+
+func TestFingerprintAssets_RewritesStylesheetReferences(t *testing.T) {
+	assets := map[string][]byte{
+		"css/style.css": []byte(`h1 { background: url("../img/logo.svg"); } p { background: url(missing.png); }`),
+		"img/logo.svg":  []byte(`<svg/>`),
+	}
+	files, names := FingerprintAssets(assets)
+
+	logo := names["img/logo.svg"]
+	style := names["css/style.css"]
+	if logo == "" || style == "" || len(files) != 2 {
+		t.Fatalf("Expected both assets to be fingerprinted, got %v", names)
+	}
+	css := string(files[style])
+	if !strings.Contains(css, `url("../`+logo+`")`) {
+		t.Errorf("Expected the stylesheet to refer to the fingerprinted image, got %s", css)
+	}
+	if !strings.Contains(css, "url(missing.png)") {
+		t.Errorf("Expected references to other files to be kept, got %s", css)
+	}
+
+	// A changed image changes the name of the stylesheet that uses it
+	assets["img/logo.svg"] = []byte(`<svg></svg>`)
+	_, changed := FingerprintAssets(assets)
+	if changed["css/style.css"] == style {
+		t.Error("Expected the stylesheet's name to change with the image it refers to")
+	}
+}
+
+func TestRewriteAssetReferences(t *testing.T) {
+	names := map[string]string{"style.css": "style.1a2b3c4d.css", "js/fill.js": "js/fill.5e6f7a8b.js"}
+	html := `<link href="style.css"><link href='./style.css?v=1'><script src="js/fill.js#x"></script>` +
+		`<a href="https://example.com/style.css">x</a><img src="other.png"><div style="background: url('style.css')"></div>`
+	want := `<link href="style.1a2b3c4d.css"><link href='style.1a2b3c4d.css?v=1'><script src="js/fill.5e6f7a8b.js#x"></script>` +
+		`<a href="https://example.com/style.css">x</a><img src="other.png"><div style="background: url('style.1a2b3c4d.css')"></div>`
+	if got := RewriteAssetReferences(html, names); got != want {
+		t.Errorf("Unexpected rewrite:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestRenderWithOptions_CopiesFingerprintedAssets(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "docs", "index.html")
+	assets := map[string][]byte{"style.css": []byte("body {}")}
+
+	renderer := NewRenderer(dir)
+	err := renderer.RenderWithOptions(`<html><link href="style.css"><!-- data-field="title" --></html>`,
+		map[string]interface{}{"title": "Hello"}, outputPath, Options{Assets: assets})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	fingerprinted := FingerprintName("style.css", assets["style.css"])
+	css, err := os.ReadFile(filepath.Join(dir, "docs", fingerprinted))
+	if err != nil {
+		t.Fatalf("Expected the fingerprinted asset next to the output: %v", err)
+	}
+	if string(css) != "body {}" {
+		t.Errorf("Unexpected asset content %q", css)
+	}
+	html, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if !strings.Contains(string(html), `href="`+fingerprinted+`"`) {
+		t.Errorf("Expected the output to refer to the fingerprinted asset, got %s", html)
+	}
+}
@@ -0,0 +1,34 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+// confidenceStyle styles the badges of low-confidence fields. Badges are printed too,
+// since printed drafts are reviewed as well.
+const confidenceStyle = `
+<style id="docloom-confidence-style">
+.docloom-low-confidence { background: #fef3c7; box-shadow: inset 3px 0 0 #d97706; }
+.docloom-low-confidence::before {
+  content: attr(data-docloom-confidence); display: inline-block; margin-right: 4px; padding: 0 4px;
+  border-radius: 3px; background: #d97706; color: #fff; font: bold 11px/1.6 sans-serif;
+}
+</style>
+`
+
+// badgeLowConfidence wraps a rendered field value in a badge showing the model's
+// confidence in it.
+func badgeLowConfidence(score float64, value string) string {
+	label := fmt.Sprintf("Low confidence %.0f%%", score*100)
+	return fmt.Sprintf(`<span class="docloom-low-confidence" data-docloom-confidence="%s" title="%s: review this section">%s</span>`, label, label, value)
+}
+
+// topLevelField returns the top-level field of a field path, e.g. "risks" for
+// "risks[0].title".
+func topLevelField(fieldPath string) string {
+	if idx := strings.IndexAny(fieldPath, ".["); idx >= 0 {
+		return fieldPath[:idx]
+	}
+	return fieldPath
+}
@@ -0,0 +1,303 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Field paths address values in the field data: map keys separated by dots, with
+// [N] indexing arrays, as in components[0].name or matrix[1][2]. Keys may contain
+// dots themselves; the longest key that matches wins.
+//
+// Repeat blocks render their content once per element of an array or entry of a
+// map:
+//
+//	<!-- data-repeat="components[*]" -->
+//	  <li><!-- data-field="@index" -->: <!-- data-field="components[*].name" --></li>
+//	<!-- /data-repeat -->
+//
+//	<!-- data-repeat="owners.*" -->
+//	  <dt><!-- data-field="@key" --></dt><dd><!-- data-field="owners.*.email" --></dd>
+//	<!-- /data-repeat -->
+//
+// Within a block, paths starting with the repeated path refer to the current
+// element, @index is its position (from 0) and @key its map key. Blocks nest, and
+// map entries are repeated in key order.
+//
+// Arrays can also be repeated with data-each blocks naming the array, in which []
+// addresses the current element; they are shorthand for the equivalent repeat
+// blocks:
+//
+//	<!-- data-each="risks" -->
+//	  <tr><td><!-- data-field="risks[].title" --></td></tr>
+//	<!-- /data-each -->
+
+// Placeholders of the current element of a repeat block.
+const (
+	repeatIndex = "@index"
+	repeatKey   = "@key"
+)
+
+var (
+	// repeatOpenPattern and repeatClosePattern match the comments delimiting repeat blocks
+	repeatOpenPattern  = regexp.MustCompile(`<!--\s*data-repeat="([^"]+)"\s*-->`)
+	repeatClosePattern = regexp.MustCompile(`<!--\s*/data-repeat\s*-->`)
+
+	// eachOpenPattern and eachClosePattern match the comments delimiting data-each blocks
+	eachOpenPattern  = regexp.MustCompile(`<!--\s*data-each="([^"]+)"\s*-->`)
+	eachClosePattern = regexp.MustCompile(`<!--\s*/data-each\s*-->`)
+
+	// pathAttributePattern matches the field paths of data-field, data-field-raw and
+	// data-repeat comments
+	pathAttributePattern = regexp.MustCompile(`(<!--\s*data-(?:field|field-raw|repeat)=")([^"]+)(")`)
+
+	// arrayIndexPattern matches the array indices of a field path
+	arrayIndexPattern = regexp.MustCompile(`\[\d+\]`)
+)
+
+// resolvePath returns the value at path in value.
+func resolvePath(value interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return value, true
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return resolveKey(path, func(key string) (interface{}, bool) {
+			entry, ok := v[key]
+			return entry, ok
+		})
+	case map[interface{}]interface{}:
+		// Maps decoded from YAML may have non-string keys, matched by their text
+		return resolveKey(path, func(key string) (interface{}, bool) {
+			for k, entry := range v {
+				if fmt.Sprint(k) == key {
+					return entry, true
+				}
+			}
+			return nil, false
+		})
+	case []interface{}:
+		if !strings.HasPrefix(path, "[") {
+			return nil, false
+		}
+		end := strings.IndexByte(path, ']')
+		if end < 0 {
+			return nil, false
+		}
+		index, err := strconv.Atoi(path[1:end])
+		if err != nil || index < 0 || index >= len(v) {
+			return nil, false
+		}
+		return resolvePath(v[index], strings.TrimPrefix(path[end+1:], "."))
+	}
+	return nil, false
+}
+
+// resolveKey resolves path in a map, trying the longest key first so that keys
+// containing dots or brackets can be addressed.
+func resolveKey(path string, lookup func(key string) (interface{}, bool)) (interface{}, bool) {
+	ends := []int{len(path)}
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] == '.' || path[i] == '[' {
+			ends = append(ends, i)
+		}
+	}
+	for _, end := range ends {
+		entry, ok := lookup(path[:end])
+		if !ok {
+			continue
+		}
+		if value, ok := resolvePath(entry, strings.TrimPrefix(path[end:], ".")); ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// repeatItem is one element of a repeated array or map.
+type repeatItem struct {
+	path  string // Path of the element, replacing the repeated path
+	index int
+	key   string // Map key; the index for arrays
+}
+
+// repeatItems returns the elements addressed by a repeat path ending in [*] (array
+// elements) or .* (map entries, in key order).
+func repeatItems(fields map[string]interface{}, path string) ([]repeatItem, bool) {
+	var base string
+	switch {
+	case strings.HasSuffix(path, "[*]"):
+		base = strings.TrimSuffix(path, "[*]")
+	case strings.HasSuffix(path, ".*"):
+		base = strings.TrimSuffix(path, ".*")
+	default:
+		return nil, false
+	}
+	collection, ok := resolvePath(fields, base)
+	if !ok {
+		return nil, false
+	}
+
+	var items []repeatItem
+	switch v := collection.(type) {
+	case []interface{}:
+		for i := range v {
+			items = append(items, repeatItem{path: fmt.Sprintf("%s[%d]", base, i), index: i, key: strconv.Itoa(i)})
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		items = mapItems(base, keys)
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, fmt.Sprint(key))
+		}
+		items = mapItems(base, keys)
+	default:
+		return nil, false
+	}
+	return items, true
+}
+
+// mapItems returns the repeat items of a map's keys, in key order.
+func mapItems(base string, keys []string) []repeatItem {
+	sort.Strings(keys)
+	items := make([]repeatItem, len(keys))
+	for i, key := range keys {
+		items[i] = repeatItem{path: base + "." + key, index: i, key: key}
+	}
+	return items
+}
+
+// normalizeEachBlocks rewrites the data-each blocks of htmlTemplate as repeat
+// blocks over the elements of the named arrays, and the [] of field paths, which
+// address the current element, as [*].
+func normalizeEachBlocks(htmlTemplate string) string {
+	if !strings.Contains(htmlTemplate, "data-each") && !strings.Contains(htmlTemplate, "[]") {
+		return htmlTemplate
+	}
+	htmlTemplate = eachOpenPattern.ReplaceAllStringFunc(htmlTemplate, func(match string) string {
+		path := strings.TrimSuffix(eachOpenPattern.FindStringSubmatch(match)[1], "[]")
+		return `<!-- data-repeat="` + path + `[*]" -->`
+	})
+	htmlTemplate = eachClosePattern.ReplaceAllString(htmlTemplate, "<!-- /data-repeat -->")
+	return pathAttributePattern.ReplaceAllStringFunc(htmlTemplate, func(match string) string {
+		return strings.ReplaceAll(match, "[]", "[*]")
+	})
+}
+
+// expandRepeats replaces the repeat blocks of htmlTemplate with their content
+// repeated for every element, rewriting the repeated path in the content's field
+// paths to the element's path. Blocks over missing or non-repeatable values render
+// nothing; a block without its closing comment is left unchanged.
+func expandRepeats(htmlTemplate string, fields map[string]interface{}) string {
+	var b strings.Builder
+	rest := htmlTemplate
+	for {
+		open := repeatOpenPattern.FindStringSubmatchIndex(rest)
+		if open == nil {
+			break
+		}
+		bodyStart := open[1]
+		bodyEnd, closeEnd, ok := matchingRepeatClose(rest[bodyStart:])
+		if !ok {
+			break
+		}
+		path := rest[open[2]:open[3]]
+		body := rest[bodyStart : bodyStart+bodyEnd]
+		b.WriteString(rest[:open[0]])
+		rest = rest[bodyStart+closeEnd:]
+
+		items, ok := repeatItems(fields, path)
+		if !ok {
+			log.Debug().Str("field", path).Msg("Repeated field not found in data, rendering nothing")
+			continue
+		}
+		for _, item := range items {
+			content := expandRepeats(rewritePaths(body, path, item.path), fields)
+			b.WriteString(fieldPattern.ReplaceAllStringFunc(content, func(match string) string {
+				switch fieldPattern.FindStringSubmatch(match)[2] {
+				case repeatIndex:
+					return strconv.Itoa(item.index)
+				case repeatKey:
+					return html.EscapeString(item.key)
+				}
+				return match
+			}))
+		}
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// matchingRepeatClose returns the start and end of the comment closing the repeat
+// block whose content starts s, skipping nested blocks.
+func matchingRepeatClose(s string) (start, end int, ok bool) {
+	depth := 0
+	offset := 0
+	for {
+		closing := repeatClosePattern.FindStringIndex(s[offset:])
+		if closing == nil {
+			return 0, 0, false
+		}
+		opening := repeatOpenPattern.FindStringIndex(s[offset:])
+		if opening != nil && opening[0] < closing[0] {
+			depth++
+			offset += opening[1]
+			continue
+		}
+		if depth == 0 {
+			return offset + closing[0], offset + closing[1], true
+		}
+		depth--
+		offset += closing[1]
+	}
+}
+
+// rewritePaths replaces the repeated path at the start of the field paths in
+// content with the path of the current element.
+func rewritePaths(content, repeated, element string) string {
+	return pathAttributePattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := pathAttributePattern.FindStringSubmatch(match)
+		path := parts[2]
+		if path == repeated {
+			return parts[1] + element + parts[3]
+		}
+		if strings.HasPrefix(path, repeated) && (path[len(repeated)] == '.' || path[len(repeated)] == '[') {
+			return parts[1] + element + path[len(repeated):] + parts[3]
+		}
+		return match
+	})
+}
+
+// fieldMatches reports whether a field path matches pattern, a path in which [*] or
+// [] matches any array index and a * segment any map key.
+func fieldMatches(pattern, path string) bool {
+	pattern = strings.ReplaceAll(pattern, "[]", "[*]")
+	if pattern == path {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+	patternSegments := strings.Split(pattern, ".")
+	pathSegments := strings.Split(arrayIndexPattern.ReplaceAllString(path, "[*]"), ".")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/karolswdev/docloom/internal/htmltext"
+)
+
+// glossarySkippedElements are elements whose text is never linked: existing links,
+// code, and content that is not document text
+var glossarySkippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true, "noscript": true,
+	"a": true, "code": true, "pre": true, "kbd": true, "samp": true, "textarea": true,
+	"button": true, "svg": true, "h1": true,
+}
+
+// GlossaryEntry is a known entity of the organization, such as a system, a team or
+// an acronym, with its canonical page.
+type GlossaryEntry struct {
+	Name        string   `yaml:"name" json:"name"`
+	URL         string   `yaml:"url" json:"url"`
+	Aliases     []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`         // Other names and acronyms of the entity
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"` // Shown as the link's tooltip
+}
+
+// Glossary links the first occurrence of each known entity in a rendered document
+// to its canonical page.
+type Glossary struct {
+	Entries []GlossaryEntry `yaml:"entries" json:"entries"`
+
+	pattern *regexp.Regexp
+	terms   map[string]int // Entry index of each name and alias
+}
+
+// LoadGlossary reads a glossary file, YAML or JSON, holding a list of entries:
+//
+//	entries:
+//	  - name: Payments Gateway
+//	    url: https://wiki.example.com/systems/payments-gateway
+//	    aliases: [PGW]
+//	    description: Card and wallet payment processing
+func LoadGlossary(path string) (*Glossary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary: %w", err)
+	}
+	var glossary Glossary
+	if err := yaml.Unmarshal(data, &glossary); err != nil {
+		return nil, fmt.Errorf("failed to parse glossary %s: %w", path, err)
+	}
+	if err := glossary.compile(); err != nil {
+		return nil, fmt.Errorf("invalid glossary %s: %w", path, err)
+	}
+	return &glossary, nil
+}
+
+// compile checks the entries and builds the pattern matching their names and
+// aliases, longest first so that "Payments Gateway API" wins over "Payments Gateway".
+func (g *Glossary) compile() error {
+	g.terms = make(map[string]int)
+	for idx, entry := range g.Entries {
+		if strings.TrimSpace(entry.Name) == "" {
+			return fmt.Errorf("entry %d has no name", idx+1)
+		}
+		if strings.TrimSpace(entry.URL) == "" {
+			return fmt.Errorf("entry %s has no url", entry.Name)
+		}
+		for _, term := range append([]string{entry.Name}, entry.Aliases...) {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+			if other, ok := g.terms[term]; ok && other != idx {
+				return fmt.Errorf("%q names both %s and %s", term, g.Entries[other].Name, entry.Name)
+			}
+			g.terms[term] = idx
+		}
+	}
+	if len(g.terms) == 0 {
+		return nil
+	}
+
+	terms := make([]string, 0, len(g.terms))
+	for term := range g.terms {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+	alternatives := make([]string, len(terms))
+	for idx, term := range terms {
+		// Document text is HTML-escaped, so is the term
+		alternatives[idx] = regexp.QuoteMeta(html.EscapeString(term))
+	}
+	g.pattern = regexp.MustCompile(strings.Join(alternatives, "|"))
+	return nil
+}
+
+// Link links the first occurrence of each entry in the body of document to its
+// page. Names and aliases match case-sensitively on word boundaries; text in links,
+// code, headings of level 1 and the document head is left alone. It returns the
+// document and the names of the linked entries.
+func (g *Glossary) Link(document string) (string, []string) {
+	if g == nil || g.pattern == nil {
+		return document, nil
+	}
+	linked := make(map[int]bool)
+	var names []string
+
+	start := bodyOffset(document)
+	var b strings.Builder
+	b.WriteString(document[:start])
+	page := document[start:]
+	var skip string // Name of the element being left alone
+	depth := 0      // Nesting of elements named skip, while skipping
+	for len(page) > 0 {
+		lt := strings.IndexByte(page, '<')
+		if lt < 0 {
+			lt = len(page)
+		}
+		if skip == "" {
+			b.WriteString(g.linkText(page[:lt], linked, &names))
+		} else {
+			b.WriteString(page[:lt])
+		}
+		page = page[lt:]
+		if page == "" {
+			break
+		}
+
+		end := strings.IndexByte(page, '>')
+		if strings.HasPrefix(page, "<!--") {
+			end = strings.Index(page, "-->")
+			if end >= 0 {
+				end += 2
+			}
+		}
+		if end < 0 {
+			b.WriteString(page)
+			break
+		}
+		tag := page[1:end]
+		b.WriteString(page[:end+1])
+		page = page[end+1:]
+
+		name, closing := htmltext.TagName(tag)
+		switch {
+		case name == "":
+		case skip != "":
+			if name == skip {
+				if closing {
+					depth--
+				} else if !strings.HasSuffix(tag, "/") {
+					depth++
+				}
+				if depth == 0 {
+					skip = ""
+				}
+			}
+		case !closing && glossarySkippedElements[name] && !strings.HasSuffix(tag, "/"):
+			skip, depth = name, 1
+		}
+	}
+	return b.String(), names
+}
+
+// linkText links the entries in a run of text that have not been linked yet.
+func (g *Glossary) linkText(text string, linked map[int]bool, names *[]string) string {
+	if strings.TrimSpace(text) == "" || len(linked) == len(g.Entries) {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range g.pattern.FindAllStringIndex(text, -1) {
+		idx := g.terms[html.UnescapeString(text[loc[0]:loc[1]])]
+		if linked[idx] || !wordBoundary(text, loc[0], loc[1]) {
+			continue
+		}
+		linked[idx] = true
+		*names = append(*names, g.Entries[idx].Name)
+
+		entry := g.Entries[idx]
+		b.WriteString(text[last:loc[0]])
+		fmt.Fprintf(&b, `<a class="docloom-entity" href="%s"`, html.EscapeString(entry.URL))
+		if entry.Description != "" {
+			fmt.Fprintf(&b, ` title="%s"`, html.EscapeString(entry.Description))
+		}
+		fmt.Fprintf(&b, ">%s</a>", text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// wordBoundary reports whether text[start:end] is neither preceded nor followed by a
+// letter or digit.
+func wordBoundary(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
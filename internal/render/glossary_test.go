@@ -0,0 +1,93 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeGlossary(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "glossary.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestGlossary_Link(t *testing.T) {
+	glossary, err := LoadGlossary(writeGlossary(t, `
+entries:
+  - name: Payments Gateway
+    url: https://wiki.example.com/pgw
+    aliases: [PGW]
+    description: Card & wallet payments
+  - name: Payments Gateway API
+    url: https://wiki.example.com/pgw-api
+  - name: SRE
+    url: https://wiki.example.com/teams/sre?tab=1&lang=en
+  - name: R&D
+    url: https://wiki.example.com/rnd
+`))
+	require.NoError(t, err)
+
+	document := `<html><head><title>Payments Gateway</title></head><body>
+<h1>Payments Gateway</h1>
+<p>The <a href="/x">Payments Gateway</a> calls the Payments Gateway API. PGWS is no alias.</p>
+<p>The Payments Gateway is run by SRE; PGW again. R&amp;D owns <code>SRE</code>.</p>
+</body></html>`
+	linked, names := glossary.Link(document)
+
+	// Head, h1, existing links and code are left alone; longest names win
+	assert.Contains(t, linked, `<title>Payments Gateway</title>`)
+	assert.Contains(t, linked, `<h1>Payments Gateway</h1>`)
+	assert.Contains(t, linked, `<a href="/x">Payments Gateway</a>`)
+	assert.Contains(t, linked, `the <a class="docloom-entity" href="https://wiki.example.com/pgw-api">Payments Gateway API</a>.`)
+	assert.Contains(t, linked, `PGWS is no alias`)
+	assert.Contains(t, linked, `The <a class="docloom-entity" href="https://wiki.example.com/pgw" title="Card &amp; wallet payments">Payments Gateway</a> is run by`)
+	assert.Contains(t, linked, `<a class="docloom-entity" href="https://wiki.example.com/teams/sre?tab=1&amp;lang=en">SRE</a>;`)
+	assert.Contains(t, linked, `<a class="docloom-entity" href="https://wiki.example.com/rnd">R&amp;D</a> owns <code>SRE</code>`)
+
+	// Only first occurrences are linked, aliases included
+	assert.Contains(t, linked, `PGW again`)
+	assert.Equal(t, 4, strings.Count(linked, `class="docloom-entity"`))
+	assert.Equal(t, []string{"Payments Gateway API", "Payments Gateway", "SRE", "R&D"}, names)
+}
+
+func TestLoadGlossary_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing url":    "entries:\n  - name: SRE\n",
+		"missing name":   "entries:\n  - url: https://example.com\n",
+		"duplicate term": "entries:\n  - name: SRE\n    url: a\n  - name: Reliability\n    url: b\n    aliases: [SRE]\n",
+		"not yaml":       "entries: [",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadGlossary(writeGlossary(t, content))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadGlossary(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestRenderWithOptions_Glossary(t *testing.T) {
+	glossary, err := LoadGlossary(writeGlossary(t, `{"entries": [{"name": "Ledger", "url": "https://wiki.example.com/ledger"}]}`))
+	require.NoError(t, err)
+
+	tmpl := `<html><body><p><!-- data-field="summary" --></p></body></html>`
+	outputPath := filepath.Join(t.TempDir(), "doc.html")
+	require.NoError(t, NewRenderer("").RenderWithOptions(tmpl, map[string]interface{}{"summary": "The Ledger settles."}, outputPath, Options{Glossary: glossary}))
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `The <a class="docloom-entity" href="https://wiki.example.com/ledger">Ledger</a> settles.`)
+
+	// The JSON sidecar keeps the plain field values
+	sidecar, err := os.ReadFile(SidecarPath(outputPath, ".json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sidecar), "docloom-entity")
+}
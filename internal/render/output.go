@@ -0,0 +1,99 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/i18n"
+)
+
+// Default permissions of generated files and the directories created for them.
+const (
+	DefaultOutputFileMode os.FileMode = 0600
+	DefaultOutputDirMode  os.FileMode = 0755
+)
+
+// Permissions of generated files and directories, before the umask is applied.
+var (
+	outputFileMode = DefaultOutputFileMode
+	outputDirMode  = DefaultOutputDirMode
+)
+
+// SetOutputModes sets the permissions of the files and directories written from now
+// on, e.g. 0644 and 0755 for outputs published by later steps run as another user.
+// The process umask is applied to both, as for any file the process creates.
+func SetOutputModes(file, dir os.FileMode) {
+	outputFileMode = file.Perm()
+	outputDirMode = dir.Perm()
+}
+
+// SidecarPath returns the path of a file written alongside an output document, such as
+// its JSON sidecar (".json") or run report (".report.json").
+func SidecarPath(outputPath string, suffix string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + suffix
+}
+
+// CheckOverwrite returns an error when any of the paths already exists and force is not set.
+func CheckOverwrite(force bool, paths ...string) error {
+	if force {
+		return nil
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return errors.New(i18n.T("error.output_exists", path))
+		}
+	}
+	return nil
+}
+
+// WriteFileAtomic writes data to path through a temporary file in the same directory
+// and renames it into place, so readers never observe a partially written file. The
+// parent directory is created when missing. Both get the configured output
+// permissions less the umask.
+func WriteFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, outputDirMode); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tmp, err := createTemp(dir, "."+filepath.Base(path)+".", ".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		// No-op once the rename has succeeded
+		_ = os.Remove(tmpPath)
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// createTemp creates a new file in dir named prefix, a random number and suffix. Unlike
+// os.CreateTemp, the file is created with the output file mode, so the umask applies.
+func createTemp(dir, prefix, suffix string) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix) // #nosec G404 -- temporary names need no cryptographic randomness
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, outputFileMode)         // #nosec G304 -- the output path is user-provided
+		if !errors.Is(err, fs.ErrExist) {
+			return file, err
+		}
+	}
+	return nil, fmt.Errorf("failed to find an unused temporary name in %s", dir)
+}
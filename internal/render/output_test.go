@@ -0,0 +1,70 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSidecarPath(t *testing.T) {
+	assert.Equal(t, "out/doc.json", SidecarPath("out/doc.html", ".json"))
+	assert.Equal(t, "out/doc.report.json", SidecarPath("out/doc.htm", ".report.json"))
+	assert.Equal(t, "doc.json", SidecarPath("doc", ".json"))
+}
+
+func TestCheckOverwrite(t *testing.T) {
+	tempDir := t.TempDir()
+	htmlFile := filepath.Join(tempDir, "doc.html")
+	jsonFile := filepath.Join(tempDir, "doc.json")
+
+	assert.NoError(t, CheckOverwrite(false, htmlFile, jsonFile))
+
+	// An existing sidecar blocks the run just like an existing HTML file
+	require.NoError(t, os.WriteFile(jsonFile, []byte("{}"), 0600))
+	err := CheckOverwrite(false, htmlFile, jsonFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doc.json already exists")
+
+	assert.NoError(t, CheckOverwrite(true, htmlFile, jsonFile))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "nested", "doc.html")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first")))
+	require.NoError(t, WriteFileAtomic(path, []byte("second")))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFileAtomic_OutputModes(t *testing.T) {
+	SetOutputModes(0640, 0750)
+	t.Cleanup(func() { SetOutputModes(DefaultOutputFileMode, DefaultOutputDirMode) })
+
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "shared", "doc.html")
+	require.NoError(t, WriteFileAtomic(path, []byte("content")))
+
+	// Neither mode has bits a common umask (022 or 027) clears
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	info, err = os.Stat(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+}
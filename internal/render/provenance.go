@@ -0,0 +1,74 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Provenance identifies the run that produced a document. When passed to
+// RenderWithProvenance, every rendered field is wrapped in an element carrying its
+// field path, run ID and model, and a small overlay reveals them on hover.
+type Provenance struct {
+	RunID  string
+	Model  string
+	Report string // Run report file name, shown in the overlay
+	CI     string // CI pipeline run that produced the document, shown in the overlay
+}
+
+// annotate wraps a rendered field value in a provenance element.
+func (p *Provenance) annotate(fieldPath, value string) string {
+	return fmt.Sprintf(`<span class="docloom-field" data-docloom-field="%s" data-docloom-run="%s" data-docloom-model="%s">%s</span>`,
+		html.EscapeString(fieldPath), html.EscapeString(p.RunID), html.EscapeString(p.Model), value)
+}
+
+// provenanceOverlay is the toggle button, styles and script added to annotated
+// documents. Provenance is hidden until toggled with the button or Alt+P, so printed
+// documents are unaffected.
+const provenanceOverlay = `
+<style id="docloom-provenance-style">
+.docloom-provenance-on .docloom-field { outline: 1px dashed #d97706; position: relative; }
+.docloom-provenance-on .docloom-field:hover::after {
+  content: attr(data-docloom-field) " \00b7 " attr(data-docloom-model) " \00b7 run " attr(data-docloom-run);
+  position: absolute; left: 0; top: 100%%; z-index: 1000; white-space: nowrap;
+  background: #1f2937; color: #f9fafb; font: 12px/1.4 monospace; padding: 2px 6px; border-radius: 3px;
+}
+#docloom-provenance-toggle { position: fixed; right: 12px; bottom: 12px; z-index: 1001; font: 12px sans-serif; opacity: 0.7; }
+@media print { #docloom-provenance-toggle { display: none; } }
+</style>
+<button id="docloom-provenance-toggle" type="button" title="Run %s (%s)%s">Provenance</button>
+<script id="docloom-provenance-script">
+(function () {
+  function toggle() { document.body.classList.toggle('docloom-provenance-on'); }
+  document.getElementById('docloom-provenance-toggle').addEventListener('click', toggle);
+  document.addEventListener('keydown', function (e) { if (e.altKey && (e.key === 'p' || e.key === 'P')) toggle(); });
+})();
+</script>
+`
+
+// injectOverlay adds the provenance overlay before </body>, or at the end of
+// documents without one.
+func injectOverlay(document string, p *Provenance) string {
+	pipeline := ""
+	if p.CI != "" {
+		pipeline = ", " + p.CI
+	}
+	overlay := fmt.Sprintf(provenanceOverlay, html.EscapeString(p.RunID), html.EscapeString(p.Report), html.EscapeString(pipeline))
+	return injectBeforeBody(document, overlay)
+}
+
+// injectBeforeBody adds markup before </body>, or at the end of documents without one.
+func injectBeforeBody(document, markup string) string {
+	if idx := strings.LastIndex(strings.ToLower(document), "</body>"); idx >= 0 {
+		return document[:idx] + markup + document[idx:]
+	}
+	return document + markup
+}
+
+// bodyOffset returns the position of the <body> tag, or 0 when the template has none.
+func bodyOffset(document string) int {
+	if idx := strings.Index(strings.ToLower(document), "<body"); idx >= 0 {
+		return idx
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderWithProvenance(t *testing.T) {
+	tmpl := `<html><head><title><!-- data-field="title" --></title></head>
+<body><h1><!-- data-field="title" --></h1><p><!-- data-field="section.body" --></p></body></html>`
+	fields := map[string]interface{}{
+		"title":   "Payments",
+		"section": map[string]interface{}{"body": "Ledger"},
+	}
+	provenance := &Provenance{RunID: "run-1", Model: "gpt-4", Report: "doc.report.json"}
+
+	outputPath := filepath.Join(t.TempDir(), "doc.html")
+	require.NoError(t, NewRenderer("").RenderWithProvenance(tmpl, fields, outputPath, provenance))
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	output := string(content)
+
+	// Body fields are annotated, the <title> is left as plain text
+	assert.Contains(t, output, `<title>Payments</title>`)
+	assert.Contains(t, output, `<h1><span class="docloom-field" data-docloom-field="title" data-docloom-run="run-1" data-docloom-model="gpt-4">Payments</span></h1>`)
+	assert.Contains(t, output, `data-docloom-field="section.body"`)
+
+	// The overlay is added once, inside the body
+	assert.Equal(t, 1, strings.Count(output, `id="docloom-provenance-toggle"`))
+	assert.Contains(t, output, `title="Run run-1 (doc.report.json)"`)
+	assert.Less(t, strings.Index(output, "docloom-provenance-script"), strings.Index(output, "</body>"))
+}
+
+func TestInjectOverlay_CI(t *testing.T) {
+	p := &Provenance{RunID: "run-1", Report: "doc.report.json", CI: "github-actions run 42 at 1a2b3c4 on main"}
+	output := injectOverlay("<body></body>", p)
+	assert.Contains(t, output, `title="Run run-1 (doc.report.json), github-actions run 42 at 1a2b3c4 on main"`)
+}
+
+func TestRender_WithoutProvenance(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "doc.html")
+	require.NoError(t, NewRenderer("").Render(`<body><!-- data-field="title" --></body>`, map[string]interface{}{"title": "Plain"}, outputPath))
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, `<body>Plain</body>`, string(content))
+}
+
+func TestProvenance_EscapesAttributes(t *testing.T) {
+	p := &Provenance{RunID: `r"1`, Model: "<m>"}
+	assert.Equal(t, `<span class="docloom-field" data-docloom-field="a.b" data-docloom-run="r&#34;1" data-docloom-model="&lt;m&gt;">v</span>`, p.annotate("a.b", "v"))
+}
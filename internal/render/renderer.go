@@ -3,10 +3,12 @@ package render
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -24,87 +26,188 @@ func NewRenderer(outputDir string) *Renderer {
 	}
 }
 
+// fieldPattern matches data-field comments such as <!-- data-field="document.title" -->
+// and their raw form, <!-- data-field-raw="summary" -->
+var fieldPattern = regexp.MustCompile(`<!--\s*data-field(-raw)?="([^"]+)"\s*-->`)
+
+// Placeholders returns the field paths referenced by data-field placeholders in
+// htmlTemplate, sorted and without duplicates. Paths within repeat blocks keep
+// their wildcards, with the [] of data-each blocks written as [*]; @index and @key
+// are left out.
+func Placeholders(htmlTemplate string) []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, match := range fieldPattern.FindAllStringSubmatch(normalizeEachBlocks(htmlTemplate), -1) {
+		if match[2] == repeatIndex || match[2] == repeatKey {
+			continue
+		}
+		if !seen[match[2]] {
+			seen[match[2]] = true
+			fields = append(fields, match[2])
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
 // HTML takes an HTML template and field data, replacing placeholders with actual values
 // This function is pure - it has no side effects other than returning the rendered string
 func HTML(htmlTemplate string, fields map[string]interface{}) (string, error) {
-	// Create a flat map of field paths to values
-	flatFields := flattenMap(fields, "")
-
-	// Regular expression to match data-field comments
-	// Matches patterns like: <!-- data-field="document.title" -->
-	fieldPattern := regexp.MustCompile(`<!--\s*data-field="([^"]+)"\s*-->`)
-
-	// Replace each placeholder with its corresponding value
-	rendered := fieldPattern.ReplaceAllStringFunc(htmlTemplate, func(match string) string {
-		// Extract the field path from the match
-		matches := fieldPattern.FindStringSubmatch(match)
-		if len(matches) < 2 {
-			return match // Return unchanged if no field path found
-		}
+	return renderHTML(htmlTemplate, fields, Options{}), nil
+}
+
+// Options controls how field values are rendered into a template.
+type Options struct {
+	Provenance *Provenance       // Annotate values with the run that produced them; nil for plain HTML
+	Transforms []Transform       // Presentation rules declared by the template
+	Assets     map[string][]byte // Template stylesheets, scripts and images by relative path, copied fingerprinted next to the output
+	Text       bool              // Also write a plain-text version of the document (<name>.txt); see Text
+
+	// Confidence scores (0-1) of top-level fields to badge for reviewer attention
+	LowConfidence map[string]float64
+
+	// Entities whose first occurrences in the HTML link to their canonical pages
+	Glossary *Glossary
+}
 
-		fieldPath := matches[1]
-
-		// Look up the value in our flattened fields
-		if value, exists := flatFields[fieldPath]; exists {
-			// Convert the value to string
-			switch v := value.(type) {
-			case string:
-				return v
-			case []byte:
-				return string(v)
-			default:
-				// For other types, use JSON encoding for proper representation
-				jsonBytes, err := json.Marshal(v)
-				if err != nil {
-					log.Warn().Err(err).Str("field", fieldPath).Msg("Failed to marshal field value")
-					return match
-				}
-				// If it's a string in JSON, remove the quotes
-				str := string(jsonBytes)
-				if strings.HasPrefix(str, `"`) && strings.HasSuffix(str, `"`) {
-					str = str[1 : len(str)-1]
-				}
-				return str
+// HTMLWithOptions is HTML with the field transforms and provenance annotations of opts.
+// The provenance overlay is only added by RenderWithOptions.
+func HTMLWithOptions(htmlTemplate string, fields map[string]interface{}, opts Options) (string, error) {
+	return renderHTML(htmlTemplate, fields, opts), nil
+}
+
+// renderHTML replaces the placeholders of htmlTemplate with field values, applying
+// the declared transforms. When provenance is set, values in the document body are
+// annotated with it.
+func renderHTML(htmlTemplate string, fields map[string]interface{}, opts Options) string {
+	htmlTemplate = expandRepeats(normalizeEachBlocks(htmlTemplate), fields)
+	bodyStart := bodyOffset(htmlTemplate)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range fieldPattern.FindAllStringSubmatchIndex(htmlTemplate, -1) {
+		b.WriteString(htmlTemplate[last:loc[0]])
+		last = loc[1]
+		match := htmlTemplate[loc[0]:loc[1]]
+		raw := loc[2] >= 0
+		fieldPath := htmlTemplate[loc[4]:loc[5]]
+
+		value, exists := resolvePath(fields, fieldPath)
+		if !exists {
+			log.Debug().Str("field", fieldPath).Msg("Field not found in data, leaving placeholder")
+			b.WriteString(match) // Leave unchanged if field not found
+			continue
+		}
+		rendered, ok := fieldValue(fieldPath, applyTransforms(opts.Transforms, fieldPath, value))
+		if !ok {
+			b.WriteString(match)
+			continue
+		}
+		if raw {
+			rendered = SanitizeHTML(rendered)
+		} else {
+			rendered = html.EscapeString(rendered)
+		}
+		// Placeholders in <head> (e.g. <title>) cannot hold markup
+		if loc[0] >= bodyStart {
+			if score, ok := opts.LowConfidence[topLevelField(fieldPath)]; ok {
+				rendered = badgeLowConfidence(score, rendered)
+			}
+			if opts.Provenance != nil {
+				rendered = opts.Provenance.annotate(fieldPath, rendered)
 			}
 		}
+		b.WriteString(rendered)
+	}
+	b.WriteString(htmlTemplate[last:])
+	return b.String()
+}
 
-		log.Debug().Str("field", fieldPath).Msg("Field not found in data, leaving placeholder")
-		return match // Return unchanged if field not found
-	})
-
-	return rendered, nil
+// fieldValue converts a field value to its rendered string.
+func fieldValue(fieldPath string, value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	default:
+		// For other types, use JSON encoding for proper representation
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			log.Warn().Err(err).Str("field", fieldPath).Msg("Failed to marshal field value")
+			return "", false
+		}
+		// If it's a string in JSON, remove the quotes
+		str := string(jsonBytes)
+		if strings.HasPrefix(str, `"`) && strings.HasSuffix(str, `"`) {
+			str = str[1 : len(str)-1]
+		}
+		return str, true
+	}
 }
 
 // Render renders an HTML template with the given fields and saves both HTML and JSON outputs
 func (r *Renderer) Render(templateHTML string, fields map[string]interface{}, outputPath string) error {
+	return r.RenderWithProvenance(templateHTML, fields, outputPath, nil)
+}
+
+// RenderWithProvenance is Render with the rendered fields annotated with provenance
+// and the provenance overlay added to the document. A nil provenance renders plain HTML.
+func (r *Renderer) RenderWithProvenance(templateHTML string, fields map[string]interface{}, outputPath string, provenance *Provenance) error {
+	return r.RenderWithOptions(templateHTML, fields, outputPath, Options{Provenance: provenance})
+}
+
+// RenderWithOptions is Render with the field transforms and provenance of opts. The
+// JSON sidecar always holds the untransformed field values.
+func (r *Renderer) RenderWithOptions(templateHTML string, fields map[string]interface{}, outputPath string, opts Options) error {
 	// Render the HTML
-	renderedHTML, err := HTML(templateHTML, fields)
-	if err != nil {
-		return fmt.Errorf("failed to render HTML: %w", err)
+	renderedHTML := renderHTML(templateHTML, fields, opts)
+	if opts.Glossary != nil {
+		var linked []string
+		renderedHTML, linked = opts.Glossary.Link(renderedHTML)
+		log.Debug().Strs("entities", linked).Msg("Linked glossary entities")
+	}
+	if opts.Provenance != nil {
+		renderedHTML = injectOverlay(renderedHTML, opts.Provenance)
+	}
+	if len(opts.LowConfidence) > 0 {
+		renderedHTML = injectBeforeBody(renderedHTML, confidenceStyle)
 	}
 
-	// Ensure output directory exists
-	outputDir := filepath.Dir(outputPath)
-	if mkdirErr := os.MkdirAll(outputDir, 0755); mkdirErr != nil {
-		return fmt.Errorf("failed to create output directory: %w", mkdirErr)
+	// Copy the template assets before the HTML that refers to them
+	if len(opts.Assets) > 0 {
+		files, names := FingerprintAssets(opts.Assets)
+		written, err := WriteAssets(filepath.Dir(outputPath), files)
+		if err != nil {
+			return err
+		}
+		renderedHTML = RewriteAssetReferences(renderedHTML, names)
+		log.Debug().Strs("assets", written).Msg("Copied fingerprinted template assets")
 	}
 
 	// Write the rendered HTML
-	if writeErr := os.WriteFile(outputPath, []byte(renderedHTML), 0600); writeErr != nil {
-		return fmt.Errorf("failed to write HTML output: %w", writeErr)
+	if err := WriteFileAtomic(outputPath, []byte(renderedHTML)); err != nil {
+		return fmt.Errorf("failed to write HTML output: %w", err)
 	}
 
-	// Generate JSON sidecar path (same name, .json extension)
-	jsonPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".json"
+	// Write the plain-text version, without provenance annotations or assets
+	if opts.Text {
+		textPath := SidecarPath(outputPath, TextSuffix)
+		if err := WriteFileAtomic(textPath, []byte(Text(templateHTML, fields, opts))); err != nil {
+			return fmt.Errorf("failed to write text output: %w", err)
+		}
+		log.Debug().Str("text", textPath).Msg("Wrote plain-text version")
+	}
 
-	// Marshal fields to JSON
+	// Marshal fields to JSON; this is the document's only JSON sidecar
+	jsonPath := SidecarPath(outputPath, ".json")
 	jsonData, err := json.MarshalIndent(fields, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal fields to JSON: %w", err)
 	}
 
 	// Write the JSON sidecar
-	if err := os.WriteFile(jsonPath, jsonData, 0600); err != nil {
+	if err := WriteFileAtomic(jsonPath, jsonData); err != nil {
 		return fmt.Errorf("failed to write JSON sidecar: %w", err)
 	}
 
@@ -162,28 +265,3 @@ func ToWriter(htmlTemplate string, fields map[string]interface{}, htmlWriter, js
 
 	return nil
 }
-
-// flattenMap flattens a nested map into a single-level map with dot-separated keys
-func flattenMap(m map[string]interface{}, prefix string) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	for key, value := range m {
-		fullKey := key
-		if prefix != "" {
-			fullKey = prefix + "." + key
-		}
-
-		switch v := value.(type) {
-		case map[string]interface{}:
-			// Recursively flatten nested maps
-			for k, val := range flattenMap(v, fullKey) {
-				result[k] = val
-			}
-		default:
-			// Add non-map values directly
-			result[fullKey] = value
-		}
-	}
-
-	return result
-}
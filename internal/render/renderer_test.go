@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -266,3 +267,118 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// TestRenderer_NestedPaths_Golden tests array indices, repeat blocks over arrays and
+// maps, and wildcard transforms against a golden file.
+func TestRenderer_NestedPaths_Golden(t *testing.T) {
+	template, err := os.ReadFile(filepath.Join("testdata", "nested.html"))
+	if err != nil {
+		t.Fatalf("Failed to read template file: %v", err)
+	}
+	fieldsBytes, err := os.ReadFile(filepath.Join("testdata", "nested_fields.json"))
+	if err != nil {
+		t.Fatalf("Failed to read fields file: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(fieldsBytes, &fields); err != nil {
+		t.Fatalf("Failed to parse fields JSON: %v", err)
+	}
+	expected, err := os.ReadFile(filepath.Join("testdata", "nested_expected.html"))
+	if err != nil {
+		t.Fatalf("Failed to read expected output file: %v", err)
+	}
+
+	rendered, err := HTMLWithOptions(string(template), fields, Options{Transforms: []Transform{
+		{Field: "owners.*.since", Type: TransformDate, Format: "Jan 2006"},
+		{Field: "components[*].name", Type: TransformUpper},
+	}})
+	if err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	if rendered != string(expected) {
+		t.Errorf("Rendered HTML does not match %s\nGot:\n%s", filepath.Join("testdata", "nested_expected.html"), rendered)
+	}
+}
+
+func TestHTML_FieldPaths(t *testing.T) {
+	fields := map[string]interface{}{
+		"items":     []interface{}{map[string]interface{}{"name": "first"}, "second"},
+		"a.b":       "dotted key",
+		"a":         map[string]interface{}{"c": "nested"},
+		"yaml":      map[interface{}]interface{}{2024: "numeric key", "x": map[interface{}]interface{}{true: "bool key"}},
+		"empty":     []interface{}{},
+		"notAnList": "text",
+	}
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"array index", `<!-- data-field="items[0].name" -->`, `first`},
+		{"array element", `<!-- data-field="items[1]" -->`, `second`},
+		{"index out of range", `<!-- data-field="items[2]" -->`, `<!-- data-field="items[2]" -->`},
+		{"index into non-array", `<!-- data-field="notAnList[0]" -->`, `<!-- data-field="notAnList[0]" -->`},
+		{"key with dot", `<!-- data-field="a.b" -->`, `dotted key`},
+		{"nested beside dotted key", `<!-- data-field="a.c" -->`, `nested`},
+		{"non-string map keys", `<!-- data-field="yaml.2024" -->|<!-- data-field="yaml.x.true" -->`, `numeric key|bool key`},
+		{"empty repeat", `<ul><!-- data-repeat="empty[*]" --><li>x</li><!-- /data-repeat --></ul>`, `<ul></ul>`},
+		{"missing repeat", `<ul><!-- data-repeat="missing[*]" --><li>x</li><!-- /data-repeat --></ul>`, `<ul></ul>`},
+		{"unclosed repeat", `<!-- data-repeat="items[*]" --><!-- data-field="a.c" -->`, `<!-- data-repeat="items[*]" -->nested`},
+		{"non-string key repeat", `<!-- data-repeat="yaml.*" --><!-- data-field="@key" -->;<!-- /data-repeat -->`, `2024;x;`},
+		{"each over array", `<!-- data-each="items" --><!-- data-field="@index" -->=<!-- data-field="items[]" -->;<!-- /data-each -->`, `0={&#34;name&#34;:&#34;first&#34;};1=second;`},
+		{"empty each", `<ul><!-- data-each="empty" --><li>x</li><!-- /data-each --></ul>`, `<ul></ul>`},
+		{"repeat over scalars", `<!-- data-repeat="items[*]" --><!-- data-field="@index" -->=<!-- data-field="items[*].name" --> <!-- /data-repeat -->`, `0=first 1=<!-- data-field="items[1].name" --> `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HTML(tt.template, fields)
+			if err != nil {
+				t.Fatalf("HTML failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestPlaceholders_RepeatBlocks(t *testing.T) {
+	template := `<!-- data-repeat="items[*]" --><!-- data-field="@index" --><!-- data-field="items[*].name" --><!-- /data-repeat --><!-- data-field="title" -->`
+
+	got := Placeholders(template)
+
+	expected := []string{"items[*].name", "title"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestHTML_EachBlocks(t *testing.T) {
+	fields := map[string]interface{}{
+		"sections": []interface{}{
+			map[string]interface{}{"title": "Risks", "items": []interface{}{
+				map[string]interface{}{"title": "Lock-in", "impact": "high"},
+				map[string]interface{}{"title": "Cost", "impact": "low"},
+			}},
+			map[string]interface{}{"title": "Debt", "items": []interface{}{}},
+		},
+	}
+	template := `<!-- data-each="sections" --><h2><!-- data-field="sections[].title" --></h2><table>` +
+		`<!-- data-each="sections[].items" --><tr><td><!-- data-field="sections[].items[].title" --></td><td><!-- data-field="sections[].items[].impact" --></td></tr><!-- /data-each -->` +
+		`</table><!-- /data-each -->`
+
+	result, err := HTMLWithOptions(template, fields, Options{Transforms: []Transform{{Field: "sections[].items[].impact", Type: TransformUpper}}})
+	if err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	expected := `<h2>Risks</h2><table><tr><td>Lock-in</td><td>HIGH</td></tr><tr><td>Cost</td><td>LOW</td></tr></table><h2>Debt</h2><table></table>`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	got := Placeholders(template)
+	want := []string{"sections[*].items[*].impact", "sections[*].items[*].title", "sections[*].title"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected placeholders %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,193 @@
+package render
+
+import (
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/htmltext"
+)
+
+var (
+	// sanitizeAllowedElements are the elements kept in the values of raw fields, with
+	// the attributes allowed on each besides sanitizeGlobalAttributes
+	sanitizeAllowedElements = map[string][]string{
+		"a": {"href"}, "img": {"src", "alt", "width", "height"},
+		"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+		"h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "s": nil, "del": nil, "ins": nil,
+		"sub": nil, "sup": nil, "small": nil, "mark": nil, "abbr": nil, "cite": nil, "q": nil,
+		"code": nil, "pre": nil, "kbd": nil, "samp": nil, "blockquote": nil,
+		"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+		"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+		"th": {"colspan", "rowspan", "scope"}, "td": {"colspan", "rowspan"},
+		"figure": nil, "figcaption": nil, "details": nil, "summary": nil,
+	}
+
+	// sanitizeGlobalAttributes are allowed on every kept element
+	sanitizeGlobalAttributes = []string{"title", "class", "lang", "dir"}
+
+	// sanitizeDroppedElements are removed with their content; other elements that are
+	// not allowed are removed but keep their content
+	sanitizeDroppedElements = map[string]bool{
+		"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+		"object": true, "embed": true, "applet": true, "template": true, "noscript": true,
+		"svg": true, "math": true, "textarea": true, "select": true, "title": true, "head": true,
+	}
+
+	// sanitizeURLSchemes are the schemes allowed in href and src; relative URLs and
+	// fragments are allowed as well
+	sanitizeURLSchemes = []string{"http:", "https:", "mailto:"}
+)
+
+// SanitizeHTML returns markup stripped to an allowlist of formatting, list, table,
+// link and image elements, for the values of data-field-raw placeholders. Scripts,
+// styles, frames and embedded objects are removed with their content, and other
+// elements with only their tags. Event handlers, style and all other attributes not
+// on the allowlist are dropped, as are href and src values with schemes other than
+// http, https and mailto (such as javascript:). Comments are removed, unmatched
+// closing tags dropped and elements left open closed, so that a value cannot break
+// the document around it.
+func SanitizeHTML(markup string) string {
+	var b strings.Builder
+	var open []string // Kept elements not closed yet
+	var skip string   // Name of the element being removed with its content
+	depth := 0        // Nesting of elements named skip, while skipping
+	for len(markup) > 0 {
+		lt := strings.IndexByte(markup, '<')
+		if lt < 0 {
+			lt = len(markup)
+		}
+		if skip == "" {
+			b.WriteString(markup[:lt])
+		}
+		markup = markup[lt:]
+		if markup == "" {
+			break
+		}
+
+		if strings.HasPrefix(markup, "<!--") {
+			end := strings.Index(markup, "-->")
+			if end < 0 {
+				break
+			}
+			markup = markup[end+3:]
+			continue
+		}
+		end := htmltext.TagEnd(markup)
+		if end < 0 {
+			if skip == "" {
+				b.WriteString(html.EscapeString(markup))
+			}
+			break
+		}
+		tag := markup[1:end]
+		name, closing := htmltext.TagName(tag)
+		if name == "" && !strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "?") {
+			// A < that starts no tag, as in "a < b"
+			if skip == "" {
+				b.WriteString("&lt;")
+			}
+			markup = markup[1:]
+			continue
+		}
+		markup = markup[end+1:]
+		selfClosing := strings.HasSuffix(tag, "/")
+
+		switch {
+		case name == "":
+			// Declarations and processing instructions
+		case skip != "":
+			if name == skip && !selfClosing {
+				if closing {
+					depth--
+				} else {
+					depth++
+				}
+				if depth == 0 {
+					skip = ""
+				}
+			}
+		case sanitizeDroppedElements[name]:
+			if !closing && !selfClosing {
+				skip, depth = name, 1
+			}
+		case !isAllowedElement(name):
+			// Removed, keeping its content
+		case closing:
+			for idx := len(open) - 1; idx >= 0; idx-- {
+				if open[idx] == name {
+					closeElements(&b, open[idx:])
+					open = open[:idx]
+					break
+				}
+			}
+		default:
+			b.WriteString(sanitizeStartTag(name, tag))
+			if !htmltext.IsVoid(name) && !selfClosing {
+				open = append(open, name)
+			}
+		}
+	}
+	closeElements(&b, open)
+	return b.String()
+}
+
+// isAllowedElement reports whether elements named name are kept.
+func isAllowedElement(name string) bool {
+	_, ok := sanitizeAllowedElements[name]
+	return ok
+}
+
+// sanitizeStartTag rebuilds the start tag of an allowed element with its allowed
+// attributes, escaped.
+func sanitizeStartTag(name, tag string) string {
+	allowed := append(append([]string(nil), sanitizeGlobalAttributes...), sanitizeAllowedElements[name]...)
+	var b strings.Builder
+	b.WriteString("<" + name)
+	seen := make(map[string]bool)
+	for _, attr := range htmltext.Attributes(tag) {
+		attribute, value := attr.Name, attr.Value
+		if seen[attribute] || !slices.Contains(allowed, attribute) {
+			continue
+		}
+		if (attribute == "href" || attribute == "src") && !safeURL(value) {
+			continue
+		}
+		seen[attribute] = true
+		b.WriteString(" " + attribute + `="` + html.EscapeString(value) + `"`)
+	}
+	if name == "a" && seen["href"] {
+		b.WriteString(` rel="noopener noreferrer"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// safeURL reports whether url is relative or has an allowed scheme. Browsers ignore
+// whitespace and control characters in schemes, so they are ignored here too.
+func safeURL(url string) bool {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.ToLower(url))
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 || strings.ContainsAny(cleaned[:colon], "/?#") {
+		return true
+	}
+	for _, scheme := range sanitizeURLSchemes {
+		if strings.HasPrefix(cleaned, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// closeElements writes the closing tags of the open elements, innermost first.
+func closeElements(b *strings.Builder, open []string) {
+	for idx := len(open) - 1; idx >= 0; idx-- {
+		b.WriteString("</" + open[idx] + ">")
+	}
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML_EscapesValues(t *testing.T) {
+	tmpl := `<html><head><title><!-- data-field="title" --></title></head><body>
+<p><!-- data-field="summary" --></p><p><!-- data-field-raw="details" --></p>
+<ul><!-- data-repeat="owners.*" --><li><!-- data-field="@key" --></li><!-- /data-repeat --></ul>
+<ol><!-- data-each="notes" --><li><!-- data-field-raw="notes[]" --></li><!-- /data-each --></ol>
+</body></html>`
+	fields := map[string]interface{}{
+		"title":   "Latency < 5ms & more",
+		"summary": `<script>alert("x")</script> a < b`,
+		"details": `<p onclick="steal()">Use <strong>mTLS</strong><script>alert(1)</script></p>`,
+		"owners":  map[string]interface{}{"<b>ops</b>": "a@example.com"},
+		"notes":   []interface{}{"<em>first</em>", "<img src=x onerror=alert(1)>"},
+	}
+
+	rendered, err := HTML(tmpl, fields)
+	assert.NoError(t, err)
+
+	// data-field values are text
+	assert.Contains(t, rendered, `<title>Latency &lt; 5ms &amp; more</title>`)
+	assert.Contains(t, rendered, `<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; a &lt; b</p>`)
+	assert.Contains(t, rendered, `<li>&lt;b&gt;ops&lt;/b&gt;</li>`)
+
+	// data-field-raw values keep their sanitized markup, in repeat blocks too
+	assert.Contains(t, rendered, `<p><p>Use <strong>mTLS</strong></p></p>`)
+	assert.Contains(t, rendered, `<li><em>first</em></li><li><img src="x"></li>`)
+	assert.NotContains(t, rendered, "<script")
+	assert.NotContains(t, rendered, "onclick")
+	assert.NotContains(t, rendered, "onerror")
+
+	assert.Equal(t, []string{"details", "notes[*]", "summary", "title"}, Placeholders(tmpl))
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		markup   string
+		expected string
+	}{
+		{"plain text", "Rollout in Q3", "Rollout in Q3"},
+		{"formatting", `<p class="lead">A <em>b</em><br/>c</p>`, `<p class="lead">A <em>b</em><br>c</p>`},
+		{"scripts and styles removed with content", `a<script>x()</script><style>p{}</style>b`, "ab"},
+		{"nested dropped elements", `<svg><svg></svg><text>x</text></svg>after`, "after"},
+		{"unknown elements keep content", `<font color="red"><blink>hot</blink></font>`, "hot"},
+		{"event handlers and styles dropped", `<div onmouseover="x()" style="position:fixed" title="t">d</div>`, `<div title="t">d</div>`},
+		{"safe links", `<a href="https://example.com/a?b=1&amp;c=2" target="_blank">x</a>`, `<a href="https://example.com/a?b=1&amp;c=2" rel="noopener noreferrer">x</a>`},
+		{"relative links", `<a href="../adr/0001.html#context">ADR</a>`, `<a href="../adr/0001.html#context" rel="noopener noreferrer">ADR</a>`},
+		{"javascript links", `<a href="java&#x09;script:alert(1)">x</a><a href=" JavaScript:alert(1)">y</a>`, `<a>x</a><a>y</a>`},
+		{"data images", `<img src="data:image/svg+xml,<svg onload=alert(1)>" alt="d">`, `<img alt="d">`},
+		{"quoted >", `<abbr title="a > b">gt</abbr>`, `<abbr title="a &gt; b">gt</abbr>`},
+		{"comments removed", `a<!-- data-field="secret" -->b`, "ab"},
+		{"unmatched closing tags dropped", `</div></td>text</p>`, "text"},
+		{"open elements closed", `<ul><li><strong>one`, `<ul><li><strong>one</strong></li></ul>`},
+		{"misnested elements", `<em><strong>x</em>y</strong>`, `<em><strong>x</strong></em>y`},
+		{"stray <", `a < b and 1<2`, `a &lt; b and 1&lt;2`},
+		{"unclosed tag", `ok <a href="x`, `ok &lt;a href=&#34;x`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SanitizeHTML(tt.markup))
+		})
+	}
+}
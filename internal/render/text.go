@@ -0,0 +1,29 @@
+package render
+
+import "github.com/karolswdev/docloom/internal/htmltext"
+
+// TextSuffix is the suffix of the plain-text version of a document, written next to
+// its HTML.
+const TextSuffix = ".txt"
+
+// textSkippedElements are left out of the plain-text version with their content
+var textSkippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true, "noscript": true,
+	"svg": true, "canvas": true, "iframe": true, "button": true,
+}
+
+// Text renders a plain-text version of a document for email and screen readers: the
+// template is rendered with the field transforms of opts and its structure kept as
+// text. Headings are underlined (= for h1, - for h2), list items start with - or
+// their number, table cells are separated by |, links are followed by their URL and
+// images replaced by their alt text. The document head, scripts and styles are left
+// out, as are placeholders of missing fields.
+func Text(htmlTemplate string, fields map[string]interface{}, opts Options) string {
+	rendered := renderHTML(htmlTemplate, fields, Options{Transforms: opts.Transforms})
+	return htmltext.Convert(rendered, htmltext.Options{
+		Style: htmltext.Plain,
+		Skip: func(name string, _ map[string]string) bool {
+			return textSkippedElements[name]
+		},
+	})
+}
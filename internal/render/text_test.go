@@ -0,0 +1,73 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestText(t *testing.T) {
+	templateHTML := `<!DOCTYPE html>
+<html><head><title><!-- data-field="title" --></title><style>h1 { color: red; }</style></head>
+<body>
+  <h1><!-- data-field="title" --></h1>
+  <p>Owned by <a href="mailto:team@example.com">team@example.com</a>, see <a href="https://example.com/adr">the ADRs</a>.</p>
+  <h2>Components</h2>
+  <ol>
+  <!-- data-repeat="components[*]" -->
+    <li><!-- data-field="components[*].name" -->
+      <ul><li>&lt;<!-- data-field="components[*].role" -->&gt;</li></ul>
+    </li>
+  <!-- /data-repeat -->
+  </ol>
+  <table><tr><th>Risk</th><th>Impact</th></tr><tr><td>Lock-in</td><td><!-- data-field="impact" --></td></tr></table>
+  <p><img src="diagram.png" alt="Context diagram"><br>Missing: <!-- data-field="missing" --></p>
+  <script>document.title = "x";</script>
+</body></html>`
+	fields := map[string]interface{}{
+		"title":  "Payments",
+		"impact": "high",
+		"components": []interface{}{
+			map[string]interface{}{"name": "API", "role": "entry"},
+			map[string]interface{}{"name": "Ledger", "role": "storage"},
+		},
+	}
+	opts := Options{Transforms: []Transform{{Field: "impact", Type: TransformUpper}}}
+
+	expected := `Payments
+========
+
+Owned by team@example.com, see the ADRs (https://example.com/adr).
+
+Components
+----------
+
+1. API
+  - <entry>
+2. Ledger
+  - <storage>
+
+Risk | Impact
+Lock-in | HIGH
+
+[Context diagram]
+Missing:
+`
+	assert.Equal(t, expected, Text(templateHTML, fields, opts))
+}
+
+func TestRenderWithOptions_Text(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "out.html")
+	templateHTML := `<html><body><h1><!-- data-field="title" --></h1></body></html>`
+	fields := map[string]interface{}{"title": "Payments"}
+
+	require.NoError(t, NewRenderer("").RenderWithOptions(templateHTML, fields, outputPath, Options{Text: true}))
+
+	text, err := os.ReadFile(SidecarPath(outputPath, TextSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, "Payments\n========\n", string(text))
+	assert.FileExists(t, outputPath)
+}
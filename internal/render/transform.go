@@ -0,0 +1,230 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Transform types
+const (
+	TransformDate     = "date"     // Reformat an ISO 8601 date with Format (a Go layout)
+	TransformNumber   = "number"   // Format a number with Locale separators and Decimals
+	TransformUpper    = "upper"    // UPPERCASE
+	TransformLower    = "lower"    // lowercase
+	TransformTitle    = "title"    // Capitalize Each Word
+	TransformSentence = "sentence" // Sentence case
+	TransformJoin     = "join"     // Join an array with Separator
+)
+
+// DefaultDateFormat is the layout used by date transforms without a format.
+const DefaultDateFormat = "2 January 2006"
+
+// Transform is a presentation rule a template declares for a field, applied when the
+// field is rendered into HTML. Models output canonical values (ISO dates, plain
+// numbers, arrays) and the JSON sidecar keeps them; the template decides how they
+// are displayed. Several transforms for one field are applied in order.
+type Transform struct {
+	Field     string `json:"field"` // Field path; [*] and * segments match any index or key
+	Type      string `json:"type"`
+	Format    string `json:"format,omitempty"`    // date: Go layout, e.g. "02.01.2006"
+	Locale    string `json:"locale,omitempty"`    // number: en, de, fr, ...
+	Decimals  *int   `json:"decimals,omitempty"`  // number: fixed decimal places
+	Separator string `json:"separator,omitempty"` // join: defaults to ", "
+}
+
+// numberSeparators are the thousands and decimal separators of supported locales;
+// French groups digits with a narrow no-break space, Polish and Swedish with a
+// no-break space.
+var numberSeparators = map[string][2]string{
+	"en": {",", "."},
+	"ja": {",", "."},
+	"de": {".", ","},
+	"es": {".", ","},
+	"it": {".", ","},
+	"nl": {".", ","},
+	"fr": {"\u202f", ","},
+	"pl": {"\u00a0", ","},
+	"sv": {"\u00a0", ","},
+}
+
+// dateLayouts are the accepted input formats of date transforms.
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// Validate checks that the transform is complete and of a known type.
+func (t Transform) Validate() error {
+	if t.Field == "" {
+		return fmt.Errorf("transform without field")
+	}
+	switch t.Type {
+	case TransformDate, TransformUpper, TransformLower, TransformTitle, TransformSentence, TransformJoin:
+	case TransformNumber:
+		if _, ok := numberSeparators[localeLanguage(t.Locale)]; t.Locale != "" && !ok {
+			return fmt.Errorf("transform for %s: unsupported locale %q", t.Field, t.Locale)
+		}
+		if t.Decimals != nil && (*t.Decimals < 0 || *t.Decimals > 20) {
+			return fmt.Errorf("transform for %s: decimals must be between 0 and 20", t.Field)
+		}
+	default:
+		return fmt.Errorf("transform for %s: unknown type %q", t.Field, t.Type)
+	}
+	return nil
+}
+
+// apply transforms a field value. Values the transform cannot handle, such as a
+// date that does not parse, are returned unchanged.
+func (t Transform) apply(value interface{}) interface{} {
+	switch t.Type {
+	case TransformDate:
+		s, ok := value.(string)
+		if !ok {
+			return value
+		}
+		for _, layout := range dateLayouts {
+			if parsed, err := time.Parse(layout, s); err == nil {
+				format := t.Format
+				if format == "" {
+					format = DefaultDateFormat
+				}
+				return parsed.Format(format)
+			}
+		}
+		log.Warn().Str("field", t.Field).Str("value", s).Msg("Date transform skipped: value is not an ISO 8601 date")
+		return value
+	case TransformNumber:
+		n, ok := toNumber(value)
+		if !ok {
+			log.Warn().Str("field", t.Field).Msg("Number transform skipped: value is not a number")
+			return value
+		}
+		decimals := -1
+		if t.Decimals != nil {
+			decimals = *t.Decimals
+		}
+		return formatNumber(n, decimals, t.Locale)
+	case TransformJoin:
+		items, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		separator := t.Separator
+		if separator == "" {
+			separator = ", "
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			if s, ok := fieldValue(t.Field, item); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, separator)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	switch t.Type {
+	case TransformUpper:
+		return strings.ToUpper(s)
+	case TransformLower:
+		return strings.ToLower(s)
+	case TransformTitle:
+		return titleCase(s)
+	case TransformSentence:
+		return sentenceCase(s)
+	}
+	return value
+}
+
+// applyTransforms applies the transforms declared for fieldPath in order. Transforms
+// declared with wildcards, such as components[*].date, apply to every element.
+func applyTransforms(transforms []Transform, fieldPath string, value interface{}) interface{} {
+	for _, t := range transforms {
+		if fieldMatches(t.Field, fieldPath) {
+			value = t.apply(value)
+		}
+	}
+	return value
+}
+
+// toNumber converts a JSON number or numeric string to a float.
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// localeLanguage returns the language of a locale such as "de-CH" or "de_DE".
+func localeLanguage(locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return strings.ToLower(lang)
+}
+
+// formatNumber formats n with the separators of locale (English when unknown) and
+// the given number of decimals, or as few as needed when decimals is negative.
+func formatNumber(n float64, decimals int, locale string) string {
+	separators, ok := numberSeparators[localeLanguage(locale)]
+	if !ok {
+		separators = numberSeparators["en"]
+	}
+
+	formatted := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	if n < 0 {
+		b.WriteString("-")
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(separators[0])
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(separators[1])
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// titleCase capitalizes the first letter of every word.
+func titleCase(s string) string {
+	runes := []rune(s)
+	start := true
+	for i, r := range runes {
+		if unicode.IsSpace(r) || r == '-' {
+			start = true
+			continue
+		}
+		if start {
+			runes[i] = unicode.ToUpper(r)
+			start = false
+		}
+	}
+	return string(runes)
+}
+
+// sentenceCase lowercases s and capitalizes its first letter.
+func sentenceCase(s string) string {
+	s = strings.ToLower(s)
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
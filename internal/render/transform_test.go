@@ -0,0 +1,83 @@
+package render
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestTransform_Apply(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		value     interface{}
+		expected  interface{}
+	}{
+		{"date default format", Transform{Type: TransformDate}, "2024-03-05", "5 March 2024"},
+		{"date custom format", Transform{Type: TransformDate, Format: "02.01.2006"}, "2024-03-05T10:00:00Z", "05.03.2024"},
+		{"date unparseable", Transform{Type: TransformDate}, "next spring", "next spring"},
+		{"number english", Transform{Type: TransformNumber}, 1234567.5, "1,234,567.5"},
+		{"number german decimals", Transform{Type: TransformNumber, Locale: "de-DE", Decimals: intPtr(2)}, 1234567.5, "1.234.567,50"},
+		{"number negative rounded", Transform{Type: TransformNumber, Decimals: intPtr(0)}, -9876.6, "-9,877"},
+		{"number from string", Transform{Type: TransformNumber, Locale: "fr"}, "2500", "2\u202f500"},
+		{"number not numeric", Transform{Type: TransformNumber}, "many", "many"},
+		{"upper", Transform{Type: TransformUpper}, "draft", "DRAFT"},
+		{"lower", Transform{Type: TransformLower}, "HIGH", "high"},
+		{"title", Transform{Type: TransformTitle}, "payment service overview", "Payment Service Overview"},
+		{"sentence", Transform{Type: TransformSentence}, "CRITICAL RISK", "Critical risk"},
+		{"join default separator", Transform{Type: TransformJoin}, []interface{}{"api", "worker", 3.0}, "api, worker, 3"},
+		{"join custom separator", Transform{Type: TransformJoin, Separator: " | "}, []interface{}{"a", "b"}, "a | b"},
+		{"case on non-string", Transform{Type: TransformUpper}, 42.0, 42.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.transform.apply(tt.value))
+		})
+	}
+}
+
+func TestTransform_Validate(t *testing.T) {
+	assert.NoError(t, Transform{Field: "a", Type: TransformNumber, Locale: "de_CH"}.Validate())
+	assert.Error(t, Transform{Type: TransformUpper}.Validate(), "field is required")
+	assert.Error(t, Transform{Field: "a", Type: "reverse"}.Validate())
+	assert.Error(t, Transform{Field: "a", Type: TransformNumber, Locale: "xx"}.Validate())
+	assert.Error(t, Transform{Field: "a", Type: TransformNumber, Decimals: intPtr(-1)}.Validate())
+}
+
+func TestRenderWithOptions_Transforms(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "out.html")
+	templateHTML := `<html><body><p><!-- data-field="project.start" --></p><p><!-- data-field="team" --></p><p><!-- data-field="status" --></p></body></html>`
+	fields := map[string]interface{}{
+		"project": map[string]interface{}{"start": "2024-01-15"},
+		"team":    []interface{}{"alice", "bob"},
+		"status":  "on track",
+	}
+	opts := Options{Transforms: []Transform{
+		{Field: "project.start", Type: TransformDate, Format: "Jan 2006"},
+		{Field: "team", Type: TransformJoin, Separator: " & "},
+		{Field: "team", Type: TransformTitle},
+		{Field: "status", Type: TransformUpper},
+	}}
+
+	require.NoError(t, NewRenderer("").RenderWithOptions(templateHTML, fields, outputPath, opts))
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<p>Jan 2024</p>")
+	assert.Contains(t, string(content), "<p>Alice &amp; Bob</p>")
+	assert.Contains(t, string(content), "<p>ON TRACK</p>")
+
+	// The sidecar keeps the canonical values
+	sidecar, err := os.ReadFile(SidecarPath(outputPath, ".json"))
+	require.NoError(t, err)
+	var saved map[string]interface{}
+	require.NoError(t, json.Unmarshal(sidecar, &saved))
+	assert.Equal(t, "2024-01-15", saved["project"].(map[string]interface{})["start"])
+	assert.Equal(t, "on track", saved["status"])
+}
@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/config"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	base := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 10, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 6 * * 1", time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 8-10 * * 1-5", time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)},
+		{"30 10 * * 3", time.Date(2026, 10, 21, 10, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cron.Next(base))
+		})
+	}
+}
+
+func TestParseCron_NextInHalfHourZone(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+30*60)
+	cron, err := ParseCron("0 9 * * *")
+	require.NoError(t, err)
+
+	next := cron.Next(time.Date(2026, 10, 14, 6, 10, 0, 0, kolkata))
+	assert.Equal(t, time.Date(2026, 10, 14, 9, 0, 0, 0, kolkata), next)
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, "expected %q to be rejected", expr)
+	}
+}
+
+func TestService_RunDueRecordsHistory(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+	history := NewHistory(historyPath)
+
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	run := func(ctx context.Context, job config.ScheduleConfig) error {
+		mu.Lock()
+		ran = append(ran, job.Name)
+		mu.Unlock()
+		if job.Name == "broken" {
+			return errors.New("template missing")
+		}
+		return nil
+	}
+
+	defs := []config.ScheduleConfig{
+		{Name: "weekly", Cron: "0 6 * * 1", Type: "architecture-vision", Sources: []string{"docs"}, Out: "weekly.html"},
+		{Name: "broken", Cron: "0 6 * * *", Type: "missing", Sources: []string{"docs"}, Out: "broken.html"},
+		{Name: "monthly", Cron: "@monthly", Type: "architecture-vision", Sources: []string{"docs"}, Out: "monthly.html"},
+	}
+	service, err := NewService(defs, run, history)
+	require.NoError(t, err)
+
+	// Monday 06:00 - weekly and daily jobs are due, the monthly job is not
+	service.RunDue(context.Background(), time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC))
+	service.Wait()
+	assert.ElementsMatch(t, []string{"weekly", "broken"}, ran)
+
+	entries, err := history.Load()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	statuses := map[string]Entry{}
+	for _, entry := range entries {
+		statuses[entry.Name] = entry
+	}
+	assert.Equal(t, StatusSuccess, statuses["weekly"].Status)
+	assert.Equal(t, StatusFailure, statuses["broken"].Status)
+	assert.Equal(t, "template missing", statuses["broken"].Error)
+}
+
+func TestService_RunDueDoesNotWaitForLongJobs(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	run := func(ctx context.Context, job config.ScheduleConfig) error {
+		mu.Lock()
+		ran = append(ran, job.Name)
+		mu.Unlock()
+		if job.Name == "slow" {
+			<-release
+		}
+		return nil
+	}
+
+	defs := []config.ScheduleConfig{
+		{Name: "slow", Cron: "0 * * * *", Type: "t", Sources: []string{"s"}, Out: "slow.html"},
+		{Name: "fast", Cron: "1 * * * *", Type: "t", Sources: []string{"s"}, Out: "fast.html"},
+	}
+	service, err := NewService(defs, run, nil)
+	require.NoError(t, err)
+
+	// The slow job is still running at 06:01 and 07:00: the fast job runs at 06:01,
+	// the slow one is not started a second time at 07:00
+	service.RunDue(context.Background(), time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC))
+	service.RunDue(context.Background(), time.Date(2026, 10, 19, 6, 1, 0, 0, time.UTC))
+	service.RunDue(context.Background(), time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(ran) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	service.Wait()
+	assert.ElementsMatch(t, []string{"slow", "fast"}, ran)
+}
+
+func TestNewService_Validation(t *testing.T) {
+	noop := func(ctx context.Context, job config.ScheduleConfig) error { return nil }
+
+	_, err := NewService([]config.ScheduleConfig{{Cron: "@daily", Type: "t", Sources: []string{"s"}, Out: "o"}}, noop, nil)
+	assert.ErrorContains(t, err, "missing a name")
+
+	_, err = NewService([]config.ScheduleConfig{{Name: "a", Cron: "bad", Type: "t", Sources: []string{"s"}, Out: "o"}}, noop, nil)
+	assert.ErrorContains(t, err, "invalid cron expression")
+
+	_, err = NewService([]config.ScheduleConfig{{Name: "a", Cron: "@daily", Type: "t"}}, noop, nil)
+	assert.ErrorContains(t, err, "requires type, sources and out")
+}
@@ -0,0 +1,162 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/config"
+)
+
+// Run statuses recorded in the history.
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// RunFunc executes a single scheduled generation job.
+type RunFunc func(ctx context.Context, job config.ScheduleConfig) error
+
+// Job is a schedule definition with its parsed cron expression.
+type Job struct {
+	Cron   *Cron
+	Config config.ScheduleConfig
+}
+
+// Service executes scheduled jobs when their cron expressions are due.
+type Service struct {
+	run     RunFunc
+	history *History
+	now     func() time.Time
+	jobs    []Job
+
+	// running holds the names of the jobs started by RunDue that have not finished,
+	// and wg waits for them
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// NewService validates the schedule definitions and creates a service.
+func NewService(defs []config.ScheduleConfig, run RunFunc, history *History) (*Service, error) {
+	jobs := make([]Job, 0, len(defs))
+	names := make(map[string]bool, len(defs))
+	for i, def := range defs {
+		if def.Name == "" {
+			return nil, fmt.Errorf("schedule #%d is missing a name", i+1)
+		}
+		if names[def.Name] {
+			return nil, fmt.Errorf("duplicate schedule name: %s", def.Name)
+		}
+		names[def.Name] = true
+
+		if def.Type == "" || def.Out == "" || len(def.Sources) == 0 {
+			return nil, fmt.Errorf("schedule %s requires type, sources and out", def.Name)
+		}
+
+		cron, err := ParseCron(def.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", def.Name, err)
+		}
+		jobs = append(jobs, Job{Cron: cron, Config: def})
+	}
+
+	return &Service{
+		run:     run,
+		history: history,
+		now:     time.Now,
+		jobs:    jobs,
+		running: make(map[string]bool),
+	}, nil
+}
+
+// Jobs returns the configured jobs.
+func (s *Service) Jobs() []Job {
+	return s.jobs
+}
+
+// RunDue starts every job whose schedule matches the given minute, each in its own
+// goroutine, so that a long job neither delays the others nor makes the scheduler
+// miss the following minutes. A job still running from an earlier minute is not
+// started again, and a failing job does not prevent the others from running. Wait
+// waits for the started jobs.
+func (s *Service) RunDue(ctx context.Context, t time.Time) {
+	for _, job := range s.jobs {
+		if !job.Cron.Matches(t) {
+			continue
+		}
+
+		s.mu.Lock()
+		if s.running[job.Config.Name] {
+			s.mu.Unlock()
+			log.Warn().Str("schedule", job.Config.Name).Msg("Skipping scheduled generation, the previous run has not finished")
+			continue
+		}
+		s.running[job.Config.Name] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.RunJob(ctx, job)
+
+			s.mu.Lock()
+			delete(s.running, job.Config.Name)
+			s.mu.Unlock()
+		}(job)
+	}
+}
+
+// Wait waits for the jobs started by RunDue to finish.
+func (s *Service) Wait() {
+	s.wg.Wait()
+}
+
+// RunJob executes a single job immediately and records the outcome.
+func (s *Service) RunJob(ctx context.Context, job Job) Entry {
+	entry := Entry{
+		Name:     job.Config.Name,
+		Template: job.Config.Type,
+		Output:   job.Config.Out,
+		Started:  s.now(),
+		Status:   StatusSuccess,
+	}
+
+	log.Info().Str("schedule", job.Config.Name).Str("template", job.Config.Type).Msg("Running scheduled generation")
+	if err := s.run(ctx, job.Config); err != nil {
+		entry.Status = StatusFailure
+		entry.Error = err.Error()
+		log.Error().Err(err).Str("schedule", job.Config.Name).Msg("Scheduled generation failed")
+	}
+	entry.Finished = s.now()
+
+	if s.history != nil {
+		if err := s.history.Append(entry); err != nil {
+			log.Warn().Err(err).Str("schedule", job.Config.Name).Msg("Failed to record schedule history")
+		}
+	}
+	return entry
+}
+
+// Start runs the scheduler loop until the context is canceled, checking jobs at
+// the start of every minute. When the context is canceled, it waits for the running
+// jobs, which see the cancellation, before returning.
+func (s *Service) Start(ctx context.Context) error {
+	log.Info().Int("jobs", len(s.jobs)).Msg("Scheduler started")
+	for {
+		now := s.now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+
+		select {
+		case <-ctx.Done():
+			s.Wait()
+			log.Info().Msg("Scheduler stopped")
+			return nil
+		case <-time.After(wait):
+			s.RunDue(ctx, s.now().Truncate(time.Minute))
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Package secrets reads API keys from secret stores other than files and the
+// environment: the operating system's keychain and external commands such as
+// password manager CLIs.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Service is the service name the keychain entries of DocLoom are stored under.
+const Service = "docloom"
+
+// DefaultAccount is the keychain entry of the API key when no name is given.
+const DefaultAccount = "default"
+
+// commandTimeout bounds the time a secret command or keychain tool may take, which
+// includes unlocking a password manager interactively.
+const commandTimeout = 2 * time.Minute
+
+var (
+	// ErrNotFound is returned when the keychain has no entry of the name.
+	ErrNotFound = errors.New("secret not found in keychain")
+
+	// ErrUnsupported is returned when no keychain is available on the system.
+	ErrUnsupported = errors.New("no supported keychain on this system")
+)
+
+// Keychain stores secrets in the operating system's credential store.
+type Keychain interface {
+	// Name describes the credential store.
+	Name() string
+	Get(ctx context.Context, account string) (string, error)
+	Set(ctx context.Context, account, secret string) error
+	Delete(ctx context.Context, account string) error
+}
+
+// runner runs a keychain tool with stdin and returns its standard output; it is
+// replaced in tests.
+type runner func(ctx context.Context, stdin string, name string, args ...string) (string, error)
+
+// NewKeychain returns the keychain of the operating system: the macOS Keychain, the
+// Windows Credential Manager, or the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool elsewhere.
+func NewKeychain() (Keychain, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return &macKeychain{run: runTool}, nil
+	case "windows":
+		return &windowsCredentials{run: runTool}, nil
+	default:
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, fmt.Errorf("%w: install secret-tool (libsecret-tools) to use the Secret Service", ErrUnsupported)
+		}
+		return &secretService{run: runTool}, nil
+	}
+}
+
+// FromCommand runs command with the shell and returns its output without surrounding
+// whitespace, such as an API key read by `op read op://vault/openai/key`.
+func FromCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	name, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		name, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, name, flag, command) // #nosec G204 - command configured by the user
+	cmd.Stdin = os.Stdin                                 // Password managers may prompt
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("API key command failed: %w", err)
+	}
+	secret := strings.TrimSpace(string(output))
+	if secret == "" {
+		return "", fmt.Errorf("API key command printed nothing")
+	}
+	return secret, nil
+}
+
+// runTool runs a keychain tool; its standard error is included in errors.
+func runTool(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 - fixed keychain tools
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", &toolError{code: exitErr.ExitCode(), message: strings.TrimSpace(stderr.String())}
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return string(output), nil
+}
+
+// toolError is the failure of a keychain tool.
+type toolError struct {
+	code    int
+	message string
+}
+
+func (e *toolError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return fmt.Sprintf("exit status %d: %s", e.code, e.message)
+}
+
+// exitCode returns the exit code of a keychain tool error, or -1.
+func exitCode(err error) int {
+	var toolErr *toolError
+	if errors.As(err, &toolErr) {
+		return toolErr.code
+	}
+	return -1
+}
+
+// macKeychain stores secrets as generic passwords with the security tool.
+type macKeychain struct {
+	run runner
+}
+
+func (k *macKeychain) Name() string { return "macOS Keychain" }
+
+func (k *macKeychain) Get(ctx context.Context, account string) (string, error) {
+	output, err := k.run(ctx, "", "security", "find-generic-password", "-s", Service, "-a", account, "-w")
+	if exitCode(err) == 44 { // errSecItemNotFound
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read from the macOS Keychain: %w", err)
+	}
+	return strings.TrimRight(output, "\r\n"), nil
+}
+
+func (k *macKeychain) Set(ctx context.Context, account, secret string) error {
+	// -U updates an existing entry. The security tool only takes the password as an
+	// argument, so it is briefly visible in the process list.
+	if _, err := k.run(ctx, "", "security", "add-generic-password", "-U", "-s", Service, "-a", account, "-l", Service+" "+account, "-w", secret); err != nil {
+		return fmt.Errorf("failed to write to the macOS Keychain: %w", err)
+	}
+	return nil
+}
+
+func (k *macKeychain) Delete(ctx context.Context, account string) error {
+	_, err := k.run(ctx, "", "security", "delete-generic-password", "-s", Service, "-a", account)
+	if exitCode(err) == 44 {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete from the macOS Keychain: %w", err)
+	}
+	return nil
+}
+
+// secretService stores secrets in the Secret Service with secret-tool.
+type secretService struct {
+	run runner
+}
+
+func (k *secretService) Name() string { return "Secret Service" }
+
+func (k *secretService) Get(ctx context.Context, account string) (string, error) {
+	output, err := k.run(ctx, "", "secret-tool", "lookup", "service", Service, "account", account)
+	var toolErr *toolError
+	if errors.As(err, &toolErr) && toolErr.code == 1 && toolErr.message == "" {
+		return "", ErrNotFound // secret-tool fails silently when nothing matches
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read from the Secret Service: %w", err)
+	}
+	if output == "" {
+		return "", ErrNotFound
+	}
+	return strings.TrimRight(output, "\r\n"), nil
+}
+
+func (k *secretService) Set(ctx context.Context, account, secret string) error {
+	// The secret is read from stdin, keeping it out of the process list
+	if _, err := k.run(ctx, secret, "secret-tool", "store", "--label", Service+" "+account, "service", Service, "account", account); err != nil {
+		return fmt.Errorf("failed to write to the Secret Service: %w", err)
+	}
+	return nil
+}
+
+func (k *secretService) Delete(ctx context.Context, account string) error {
+	if _, err := k.Get(ctx, account); err != nil {
+		return err
+	}
+	if _, err := k.run(ctx, "", "secret-tool", "clear", "service", Service, "account", account); err != nil {
+		return fmt.Errorf("failed to delete from the Secret Service: %w", err)
+	}
+	return nil
+}
+
+// windowsCredentials stores secrets as generic credentials of the Windows Credential
+// Manager, through the Win32 credential API called from PowerShell.
+type windowsCredentials struct {
+	run runner
+}
+
+// credentialScript declares the Win32 credential functions; the target name is read
+// from the first line of stdin and the secret from the second.
+const credentialScript = `$ErrorActionPreference = 'Stop'
+Add-Type -TypeDefinition @"
+using System;
+using System.Runtime.InteropServices;
+public static class DocloomCred {
+  [StructLayout(LayoutKind.Sequential, CharSet = CharSet.Unicode)]
+  public struct CREDENTIAL {
+    public int Flags; public int Type; public string TargetName; public string Comment;
+    public System.Runtime.InteropServices.ComTypes.FILETIME LastWritten;
+    public int CredentialBlobSize; public IntPtr CredentialBlob; public int Persist;
+    public int AttributeCount; public IntPtr Attributes; public string TargetAlias; public string UserName;
+  }
+  [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+  public static extern bool CredReadW(string target, int type, int flags, out IntPtr credential);
+  [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+  public static extern bool CredWriteW(ref CREDENTIAL credential, int flags);
+  [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+  public static extern bool CredDeleteW(string target, int type, int flags);
+  [DllImport("advapi32.dll")]
+  public static extern void CredFree(IntPtr credential);
+}
+"@
+$target = [Console]::In.ReadLine()
+`
+
+// credentialActions are the PowerShell statements of each operation; missing
+// credentials exit with status 3.
+var credentialActions = map[string]string{
+	"get": `$ptr = [IntPtr]::Zero
+if (-not [DocloomCred]::CredReadW($target, 1, 0, [ref]$ptr)) { exit 3 }
+$cred = [Runtime.InteropServices.Marshal]::PtrToStructure($ptr, [type][DocloomCred+CREDENTIAL])
+[Console]::Out.Write([Runtime.InteropServices.Marshal]::PtrToStringUni($cred.CredentialBlob, $cred.CredentialBlobSize / 2))
+[DocloomCred]::CredFree($ptr)`,
+	"set": `$secret = [Console]::In.ReadLine()
+$cred = New-Object DocloomCred+CREDENTIAL
+$cred.Type = 1; $cred.Persist = 2; $cred.TargetName = $target; $cred.UserName = $env:USERNAME
+$cred.CredentialBlobSize = $secret.Length * 2
+$cred.CredentialBlob = [Runtime.InteropServices.Marshal]::StringToCoTaskMemUni($secret)
+if (-not [DocloomCred]::CredWriteW([ref]$cred, 0)) { throw "CredWrite failed: $([Runtime.InteropServices.Marshal]::GetLastWin32Error())" }`,
+	"delete": `if (-not [DocloomCred]::CredDeleteW($target, 1, 0)) { exit 3 }`,
+}
+
+func (k *windowsCredentials) Name() string { return "Windows Credential Manager" }
+
+func (k *windowsCredentials) invoke(ctx context.Context, action, account, secret string) (string, error) {
+	stdin := Service + ":" + account + "\n" + secret + "\n"
+	return k.run(ctx, stdin, "powershell", "-NoProfile", "-NonInteractive", "-EncodedCommand", encodePowerShell(credentialScript+credentialActions[action]))
+}
+
+// encodePowerShell encodes a script for -EncodedCommand, as base64 of UTF-16LE, which
+// avoids quoting it on the command line.
+func encodePowerShell(script string) string {
+	units := utf16.Encode([]rune(script))
+	data := make([]byte, 2*len(units))
+	for idx, unit := range units {
+		binary.LittleEndian.PutUint16(data[2*idx:], unit)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func (k *windowsCredentials) Get(ctx context.Context, account string) (string, error) {
+	output, err := k.invoke(ctx, "get", account, "")
+	if exitCode(err) == 3 {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read from the Windows Credential Manager: %w", err)
+	}
+	return output, nil
+}
+
+func (k *windowsCredentials) Set(ctx context.Context, account, secret string) error {
+	if _, err := k.invoke(ctx, "set", account, secret); err != nil {
+		return fmt.Errorf("failed to write to the Windows Credential Manager: %w", err)
+	}
+	return nil
+}
+
+func (k *windowsCredentials) Delete(ctx context.Context, account string) error {
+	_, err := k.invoke(ctx, "delete", account, "")
+	if exitCode(err) == 3 {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete from the Windows Credential Manager: %w", err)
+	}
+	return nil
+}
+
+// Mask returns secret with all but its first and last four characters hidden, for
+// showing which key is stored.
+func Mask(secret string) string {
+	if len(secret) <= 12 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", len(secret)-8) + secret[len(secret)-4:]
+}
@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTool records the calls of a keychain tool and answers with output and err.
+type fakeTool struct {
+	calls  [][]string
+	stdin  []string
+	output string
+	err    error
+}
+
+func (f *fakeTool) run(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	f.stdin = append(f.stdin, stdin)
+	return f.output, f.err
+}
+
+func TestMacKeychain(t *testing.T) {
+	tool := &fakeTool{output: "sk-secret\n"}
+	keychain := &macKeychain{run: tool.run}
+
+	key, err := keychain.Get(context.Background(), "gateway")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", key)
+	assert.Equal(t, []string{"security", "find-generic-password", "-s", Service, "-a", "gateway", "-w"}, tool.calls[0])
+
+	require.NoError(t, keychain.Set(context.Background(), "gateway", "sk-new"))
+	assert.Contains(t, tool.calls[1], "-U")
+	assert.Equal(t, "sk-new", tool.calls[1][len(tool.calls[1])-1])
+
+	tool.err = &toolError{code: 44}
+	_, err = keychain.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, keychain.Delete(context.Background(), "missing"), ErrNotFound)
+
+	tool.err = &toolError{code: 51, message: "user interaction is not allowed"}
+	_, err = keychain.Get(context.Background(), "gateway")
+	assert.ErrorContains(t, err, "user interaction is not allowed")
+	assert.False(t, errors.Is(err, ErrNotFound))
+}
+
+func TestSecretService(t *testing.T) {
+	tool := &fakeTool{output: "sk-secret\n"}
+	keychain := &secretService{run: tool.run}
+
+	key, err := keychain.Get(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", key)
+	assert.Equal(t, []string{"secret-tool", "lookup", "service", Service, "account", "default"}, tool.calls[0])
+
+	// The secret is passed on stdin, not as an argument
+	require.NoError(t, keychain.Set(context.Background(), "default", "sk-new"))
+	assert.Equal(t, "sk-new", tool.stdin[1])
+	assert.NotContains(t, tool.calls[1], "sk-new")
+
+	tool.err = &toolError{code: 1}
+	_, err = keychain.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, keychain.Delete(context.Background(), "missing"), ErrNotFound)
+
+	tool.err = &toolError{code: 1, message: "Cannot autolaunch D-Bus without X11"}
+	_, err = keychain.Get(context.Background(), "default")
+	assert.ErrorContains(t, err, "D-Bus")
+	assert.False(t, errors.Is(err, ErrNotFound))
+}
+
+func TestWindowsCredentials(t *testing.T) {
+	tool := &fakeTool{output: "sk-secret"}
+	keychain := &windowsCredentials{run: tool.run}
+
+	key, err := keychain.Get(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", key)
+	assert.Equal(t, "powershell", tool.calls[0][0])
+	assert.Equal(t, "docloom:default\n\n", tool.stdin[0])
+
+	require.NoError(t, keychain.Set(context.Background(), "default", "sk-new"))
+	assert.Equal(t, "docloom:default\nsk-new\n", tool.stdin[1])
+	assert.NotContains(t, strings.Join(tool.calls[1], " "), "sk-new")
+
+	tool.err = &toolError{code: 3}
+	_, err = keychain.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestEncodePowerShell(t *testing.T) {
+	// "ab" in UTF-16LE is 61 00 62 00
+	assert.Equal(t, "YQBiAA==", encodePowerShell("ab"))
+}
+
+func TestFromCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	key, err := FromCommand(context.Background(), "echo '  sk-from-command  '")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-from-command", key)
+
+	_, err = FromCommand(context.Background(), "true")
+	assert.ErrorContains(t, err, "printed nothing")
+
+	_, err = FromCommand(context.Background(), "exit 2")
+	assert.ErrorContains(t, err, "API key command failed")
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "sk-a*****************wxyz", Mask("sk-abcdefghijklmnopqrwxyz"))
+	assert.Equal(t, "********", Mask("short123"))
+	assert.Equal(t, "", Mask(""))
+}
@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+// Field describes one field of a template schema.
+type Field struct {
+	Path      string `json:"path"`      // Dot-separated path; array items end in "[]"
+	Signature string `json:"signature"` // Type, format and requiredness, e.g. "string (date), required"
+}
+
+// Description summarizes what a template expects: the fields of its schema, the
+// placeholders its HTML fills and its prompt.
+type Description struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Version     string  `json:"version,omitempty"`
+	Output      string  `json:"output,omitempty"`
+	Fields      []Field `json:"fields"`
+
+	// Placeholders are the data-field paths referenced by the HTML
+	Placeholders []string `json:"placeholders"`
+	// UnknownPlaceholders are placeholders that name no schema field
+	UnknownPlaceholders []string `json:"unknown_placeholders,omitempty"`
+	// UnusedFields are schema fields that no placeholder renders
+	UnusedFields []string `json:"unused_fields,omitempty"`
+
+	Prompt string `json:"prompt"`
+
+	ModelRequirements *ModelRequirements `json:"model_requirements,omitempty"`
+}
+
+// Describe returns the description of a template.
+func Describe(tmpl *Template) (*Description, error) {
+	fields, err := schemaFields(tmpl.Schema)
+	if err != nil {
+		return nil, err
+	}
+	description := &Description{
+		Name:         tmpl.Name,
+		Description:  tmpl.Description,
+		Version:      tmpl.Version,
+		Output:       tmpl.Output,
+		Fields:       make([]Field, 0, len(fields)),
+		Placeholders: render.Placeholders(tmpl.HTMLContent),
+		Prompt:       tmpl.Prompt,
+
+		ModelRequirements: tmpl.ModelRequirements,
+	}
+	for path, signature := range fields {
+		description.Fields = append(description.Fields, Field{Path: path, Signature: signature})
+	}
+	sort.Slice(description.Fields, func(i, j int) bool { return description.Fields[i].Path < description.Fields[j].Path })
+
+	var verification *PlaceholderError
+	if errors.As(VerifyPlaceholders(tmpl), &verification) {
+		description.UnknownPlaceholders = verification.Unmapped
+		description.UnusedFields = verification.Unused
+	}
+	return description, nil
+}
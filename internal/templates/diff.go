@@ -0,0 +1,212 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+// TemplateDiff describes what changes between two versions of a template: the fields
+// of the schema, the prompt and the placeholders of the HTML.
+type TemplateDiff struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+
+	FieldsAdded   []string      `json:"fields_added,omitempty"`
+	FieldsRemoved []string      `json:"fields_removed,omitempty"`
+	FieldsChanged []FieldChange `json:"fields_changed,omitempty"`
+
+	PromptChanged bool     `json:"prompt_changed"`
+	PromptDiff    []string `json:"prompt_diff,omitempty"` // Changed lines prefixed with "-" or "+"
+
+	PlaceholdersAdded   []string `json:"placeholders_added,omitempty"`
+	PlaceholdersRemoved []string `json:"placeholders_removed,omitempty"`
+}
+
+// FieldChange is a schema field whose type, format or requiredness changed.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Empty reports whether the versions have the same fields, prompt and placeholders.
+func (d *TemplateDiff) Empty() bool {
+	return len(d.FieldsAdded) == 0 && len(d.FieldsRemoved) == 0 && len(d.FieldsChanged) == 0 &&
+		!d.PromptChanged && len(d.PlaceholdersAdded) == 0 && len(d.PlaceholdersRemoved) == 0
+}
+
+// Diff compares two versions of a template.
+func Diff(oldTmpl, newTmpl *Template) (*TemplateDiff, error) {
+	diff := &TemplateDiff{Old: templateLabel(oldTmpl), New: templateLabel(newTmpl)}
+
+	oldFields, err := schemaFields(oldTmpl.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", diff.Old, err)
+	}
+	newFields, err := schemaFields(newTmpl.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", diff.New, err)
+	}
+	for field, signature := range newFields {
+		oldSignature, exists := oldFields[field]
+		switch {
+		case !exists:
+			diff.FieldsAdded = append(diff.FieldsAdded, field)
+		case oldSignature != signature:
+			diff.FieldsChanged = append(diff.FieldsChanged, FieldChange{Field: field, Old: oldSignature, New: signature})
+		}
+	}
+	for field := range oldFields {
+		if _, exists := newFields[field]; !exists {
+			diff.FieldsRemoved = append(diff.FieldsRemoved, field)
+		}
+	}
+	sort.Strings(diff.FieldsAdded)
+	sort.Strings(diff.FieldsRemoved)
+	sort.Slice(diff.FieldsChanged, func(i, j int) bool { return diff.FieldsChanged[i].Field < diff.FieldsChanged[j].Field })
+
+	if strings.TrimSpace(oldTmpl.Prompt) != strings.TrimSpace(newTmpl.Prompt) {
+		diff.PromptChanged = true
+		diff.PromptDiff = diffLines(oldTmpl.Prompt, newTmpl.Prompt)
+	}
+
+	diff.PlaceholdersAdded, diff.PlaceholdersRemoved = difference(render.Placeholders(oldTmpl.HTMLContent), render.Placeholders(newTmpl.HTMLContent))
+	return diff, nil
+}
+
+// templateLabel names a template as name@version, or just name when unversioned.
+func templateLabel(tmpl *Template) string {
+	if tmpl.Version != "" {
+		return tmpl.Name + "@" + tmpl.Version
+	}
+	return tmpl.Name
+}
+
+// schemaFields flattens a JSON schema into dot-separated field paths mapped to a
+// signature of their type, format and requiredness. Array items are addressed as
+// "field[]".
+func schemaFields(schema json.RawMessage) (map[string]string, error) {
+	var root map[string]interface{}
+	if len(schema) > 0 {
+		if err := json.Unmarshal(schema, &root); err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
+	}
+	fields := make(map[string]string)
+	collectSchemaFields(root, "", fields)
+	return fields, nil
+}
+
+func collectSchemaFields(schema map[string]interface{}, prefix string, fields map[string]string) {
+	required := make(map[string]bool)
+	if names, ok := schema["required"].([]interface{}); ok {
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, property := range properties {
+		sub, _ := property.(map[string]interface{})
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fields[path] = fieldSignature(sub, required[name])
+		collectSchemaFields(sub, path, fields)
+		if items, ok := sub["items"].(map[string]interface{}); ok {
+			collectSchemaFields(items, path+"[]", fields)
+		}
+	}
+}
+
+// fieldSignature describes a field schema, e.g. "string (date), required".
+func fieldSignature(schema map[string]interface{}, required bool) string {
+	var signature string
+	switch t := schema["type"].(type) {
+	case string:
+		signature = t
+	case []interface{}:
+		names := make([]string, 0, len(t))
+		for _, name := range t {
+			names = append(names, fmt.Sprint(name))
+		}
+		signature = strings.Join(names, "|")
+	default:
+		signature = "any"
+	}
+	if format, ok := schema["format"].(string); ok {
+		signature += " (" + format + ")"
+	}
+	if required {
+		signature += ", required"
+	}
+	return signature
+}
+
+// difference returns the entries only in newItems and those only in oldItems.
+func difference(oldItems, newItems []string) (added, removed []string) {
+	oldSet := make(map[string]bool, len(oldItems))
+	for _, item := range oldItems {
+		oldSet[item] = true
+	}
+	newSet := make(map[string]bool, len(newItems))
+	for _, item := range newItems {
+		newSet[item] = true
+		if !oldSet[item] {
+			added = append(added, item)
+		}
+	}
+	for _, item := range oldItems {
+		if !newSet[item] {
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
+
+// diffLines returns the lines removed from and added to a text, prefixed with "-"
+// and "+", in the order of a longest-common-subsequence line diff. Unchanged lines
+// are omitted.
+func diffLines(oldText, newText string) []string {
+	a := strings.Split(strings.TrimSpace(oldText), "\n")
+	b := strings.Split(strings.TrimSpace(newText), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldTmpl := &Template{
+		Name:        "runbook",
+		Version:     "1.0.0",
+		Prompt:      "Write a runbook.\nList the alerts.",
+		Schema:      json.RawMessage(`{"type":"object","required":["title"],"properties":{"title":{"type":"string"},"owner":{"type":"string"},"alerts":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"}}}}}}`),
+		HTMLContent: `<h1><!-- data-field="title" --></h1><p><!-- data-field="owner" --></p>`,
+	}
+	newTmpl := &Template{
+		Name:        "runbook",
+		Version:     "2.0.0",
+		Prompt:      "Write a runbook.\nList the alerts and their severity.",
+		Schema:      json.RawMessage(`{"type":"object","required":["title","reviewed"],"properties":{"title":{"type":"string"},"reviewed":{"type":"string","format":"date"},"alerts":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"},"severity":{"type":"integer"}}}}}}`),
+		HTMLContent: `<h1><!-- data-field="title" --></h1><p><!-- data-field="reviewed" --></p>`,
+	}
+
+	diff, err := Diff(oldTmpl, newTmpl)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if diff.Old != "runbook@1.0.0" || diff.New != "runbook@2.0.0" {
+		t.Errorf("Unexpected labels %q, %q", diff.Old, diff.New)
+	}
+	if want := []string{"alerts[].severity", "reviewed"}; !reflect.DeepEqual(diff.FieldsAdded, want) {
+		t.Errorf("Expected added fields %v, got %v", want, diff.FieldsAdded)
+	}
+	if want := []string{"owner"}; !reflect.DeepEqual(diff.FieldsRemoved, want) {
+		t.Errorf("Expected removed fields %v, got %v", want, diff.FieldsRemoved)
+	}
+	if len(diff.FieldsChanged) != 0 {
+		t.Errorf("Expected no changed fields, got %v", diff.FieldsChanged)
+	}
+	if want := []string{"- List the alerts.", "+ List the alerts and their severity."}; !diff.PromptChanged || !reflect.DeepEqual(diff.PromptDiff, want) {
+		t.Errorf("Expected prompt diff %v, got %v", want, diff.PromptDiff)
+	}
+	if !reflect.DeepEqual(diff.PlaceholdersAdded, []string{"reviewed"}) || !reflect.DeepEqual(diff.PlaceholdersRemoved, []string{"owner"}) {
+		t.Errorf("Unexpected placeholder changes +%v -%v", diff.PlaceholdersAdded, diff.PlaceholdersRemoved)
+	}
+}
+
+func TestDiff_ChangedFieldAndNoChanges(t *testing.T) {
+	base := &Template{Name: "brief", Schema: json.RawMessage(`{"properties":{"count":{"type":"string"}}}`)}
+	changed := &Template{Name: "brief", Schema: json.RawMessage(`{"required":["count"],"properties":{"count":{"type":"integer"}}}`)}
+
+	diff, err := Diff(base, changed)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	want := []FieldChange{{Field: "count", Old: "string", New: "integer, required"}}
+	if !reflect.DeepEqual(diff.FieldsChanged, want) {
+		t.Errorf("Expected %v, got %v", want, diff.FieldsChanged)
+	}
+
+	diff, err = Diff(base, base)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("Expected no differences, got %+v", diff)
+	}
+}
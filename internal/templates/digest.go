@@ -0,0 +1,51 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+// Digest returns a SHA-256 over everything of the template that affects generated
+// documents: its prompt, schema, version, derivations, transforms, rules and analysis
+// settings, its HTML with partials included and its assets. It changes whenever a
+// document generated with the template could come out differently.
+func (t *Template) Digest() string {
+	h := sha256.New()
+	writePart(h, "version", []byte(t.Version))
+	writePart(h, "prompt", []byte(t.Prompt))
+	writePart(h, "schema", t.Schema)
+	writePart(h, "html", []byte(t.HTMLContent))
+
+	// Plain data without raw JSON, so marshaling does not fail
+	settings, _ := json.Marshal(struct {
+		Analysis   *Analysis
+		Derived    []DerivedField
+		Transforms []render.Transform
+		Rules      []validate.Rule
+	}{t.Analysis, t.Derived, t.Transforms, t.Rules})
+	writePart(h, "settings", settings)
+
+	names := make([]string, 0, len(t.Assets))
+	for name := range t.Assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writePart(h, "asset "+name, t.Assets[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writePart writes a labeled, length-prefixed part of a digest, so that no two
+// templates share the input of their digests.
+func writePart(w io.Writer, label string, data []byte) {
+	fmt.Fprintf(w, "%s %d\n", label, len(data))
+	_, _ = w.Write(data)
+}
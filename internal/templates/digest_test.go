@@ -0,0 +1,50 @@
+package templates
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTemplateDigest(t *testing.T) {
+	newTemplate := func() *Template {
+		return &Template{
+			Name:        "runbook",
+			Version:     "1.0.0",
+			Prompt:      "Write a runbook.",
+			Schema:      json.RawMessage(`{"type":"object","properties":{"title":{"type":"string"}}}`),
+			HTMLContent: `<h1><!-- data-field="title" --></h1>`,
+			Assets:      map[string][]byte{"style.css": []byte("h1 { color: red; }")},
+		}
+	}
+
+	digest := newTemplate().Digest()
+	if len(digest) != 64 {
+		t.Fatalf("Expected a SHA-256 hex digest, got %q", digest)
+	}
+	if again := newTemplate().Digest(); again != digest {
+		t.Errorf("Expected equal templates to have equal digests, got %s and %s", digest, again)
+	}
+
+	changes := map[string]func(*Template){
+		"version": func(tmpl *Template) { tmpl.Version = "1.0.1" },
+		"prompt":  func(tmpl *Template) { tmpl.Prompt += " Be brief." },
+		"schema":  func(tmpl *Template) { tmpl.Schema = json.RawMessage(`{"type":"object"}`) },
+		"html":    func(tmpl *Template) { tmpl.HTMLContent = "<h2></h2>" },
+		"asset":   func(tmpl *Template) { tmpl.Assets["style.css"] = []byte("h1 { color: blue; }") },
+		"derived": func(tmpl *Template) { tmpl.Derived = []DerivedField{{Field: "summary", Prompt: "Summarize."}} },
+	}
+	for name, change := range changes {
+		tmpl := newTemplate()
+		change(tmpl)
+		if tmpl.Digest() == digest {
+			t.Errorf("Expected a %s change to change the digest", name)
+		}
+	}
+
+	// The description does not affect generated documents
+	tmpl := newTemplate()
+	tmpl.Description = "Operational runbook"
+	if tmpl.Digest() != digest {
+		t.Error("Expected the description not to change the digest")
+	}
+}
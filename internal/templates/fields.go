@@ -0,0 +1,194 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DependsOnKeyword is the schema extension listing the top-level fields a field is
+// generated from, for example {"type": "string", "x-dependsOn": ["sections"]}. In
+// per-field generation a field is generated after its dependencies and receives their
+// values as context.
+const DependsOnKeyword = "x-dependsOn"
+
+// SectionKeyword is the schema extension naming the section a top-level field belongs
+// to, for example {"type": "array", "x-section": "risks"}. In per-field generation the
+// fields of a section are generated together with one request.
+const SectionKeyword = "x-section"
+
+// FieldOrder returns the top-level properties of a schema in generation order and the
+// dependencies each declares. Fields come in schema order, except that every field
+// follows the fields it depends on. Unknown dependencies and cycles are errors.
+func FieldOrder(schema json.RawMessage) ([]string, map[string][]string, error) {
+	var parsed struct {
+		Properties map[string]struct {
+			DependsOn []string `json:"x-dependsOn"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	names, err := propertyNames(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dependencies := make(map[string][]string)
+	for _, name := range names {
+		for _, dependency := range parsed.Properties[name].DependsOn {
+			if _, ok := parsed.Properties[dependency]; !ok {
+				return nil, nil, fmt.Errorf("field %s depends on unknown field %s", name, dependency)
+			}
+			dependencies[name] = append(dependencies[name], dependency)
+		}
+	}
+
+	order := make([]string, 0, len(names))
+	state := make(map[string]int) // 1 while visiting, 2 once ordered
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("field dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dependency := range dependencies[name] {
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return order, dependencies, nil
+}
+
+// FieldGroup is a set of top-level fields generated with one request in per-field
+// generation.
+type FieldGroup struct {
+	Section   string   // Section of the fields, or empty for a field without one
+	Fields    []string // Fields in generation order
+	DependsOn []string // Fields of earlier groups the group is generated from
+}
+
+// FieldGroups returns the top-level properties of a schema grouped by section in
+// generation order. A field without a section forms a group of its own. Groups come in
+// the order of their first field, except that every group follows the groups of the
+// fields it depends on. Unknown dependencies and cycles, also between sections, are
+// errors.
+func FieldGroups(schema json.RawMessage) ([]FieldGroup, error) {
+	order, dependencies, err := FieldOrder(schema)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Properties map[string]struct {
+			Section string `json:"x-section"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	names, err := propertyNames(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// Groups are keyed by section, or by field for fields without one
+	key := func(field string) string {
+		if section := parsed.Properties[field].Section; section != "" {
+			return "section " + section
+		}
+		return "field " + field
+	}
+	var keys []string
+	groups := make(map[string]*FieldGroup)
+	for _, name := range names {
+		if _, ok := groups[key(name)]; !ok {
+			keys = append(keys, key(name))
+			groups[key(name)] = &FieldGroup{Section: parsed.Properties[name].Section}
+		}
+	}
+	for _, field := range order {
+		group := groups[key(field)]
+		group.Fields = append(group.Fields, field)
+		for _, dependency := range dependencies[field] {
+			if key(dependency) != key(field) && !slices.Contains(group.DependsOn, dependency) {
+				group.DependsOn = append(group.DependsOn, dependency)
+			}
+		}
+	}
+
+	ordered := make([]FieldGroup, 0, len(keys))
+	state := make(map[string]int) // 1 while visiting, 2 once ordered
+	var visit func(k string, path []string) error
+	visit = func(k string, path []string) error {
+		switch state[k] {
+		case 1:
+			return fmt.Errorf("dependency cycle between field groups: %s", strings.Join(append(path, k), " -> "))
+		case 2:
+			return nil
+		}
+		state[k] = 1
+		for _, dependency := range groups[k].DependsOn {
+			if err := visit(key(dependency), append(path, k)); err != nil {
+				return err
+			}
+		}
+		state[k] = 2
+		ordered = append(ordered, *groups[k])
+		return nil
+	}
+	for _, k := range keys {
+		if err := visit(k, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// propertyNames returns the names of a schema's top-level properties in the order
+// they are written.
+func propertyNames(schema json.RawMessage) ([]string, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(schema, &top); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	properties, ok := top["properties"]
+	if !ok {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(properties))
+	if _, err := decoder.Token(); err != nil { // Opening brace
+		return nil, fmt.Errorf("failed to parse schema properties: %w", err)
+	}
+	var names []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema properties: %w", err)
+		}
+		name, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("schema properties must be an object")
+		}
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return nil, fmt.Errorf("failed to parse schema property %s: %w", name, err)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -0,0 +1,73 @@
+package templates
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFieldOrder(t *testing.T) {
+	order, dependencies, err := FieldOrder(json.RawMessage(`{"properties":{
+		"summary":{"x-dependsOn":["risks","sections"]},
+		"sections":{},
+		"risks":{"x-dependsOn":["sections"]},
+		"title":{}
+	}}`))
+	if err != nil {
+		t.Fatalf("FieldOrder: %v", err)
+	}
+	if want := []string{"sections", "risks", "summary", "title"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if want := []string{"risks", "sections"}; !reflect.DeepEqual(dependencies["summary"], want) {
+		t.Errorf("summary dependencies = %v, want %v", dependencies["summary"], want)
+	}
+}
+
+func TestFieldOrder_Invalid(t *testing.T) {
+	tests := map[string]string{
+		`{"properties":{"a":{"x-dependsOn":["b"]},"b":{"x-dependsOn":["a"]}}}`: "cycle: a -> b -> a",
+		`{"properties":{"a":{"x-dependsOn":["missing"]}}}`:                     "unknown field missing",
+	}
+	for schema, want := range tests {
+		if _, _, err := FieldOrder(json.RawMessage(schema)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("FieldOrder(%s) error = %v, want %q", schema, err, want)
+		}
+	}
+}
+
+func TestFieldGroups(t *testing.T) {
+	groups, err := FieldGroups(json.RawMessage(`{"properties":{
+		"title":{},
+		"summary":{"x-dependsOn":["risks"]},
+		"components":{"x-section":"architecture"},
+		"risks":{"x-section":"assessment","x-dependsOn":["components"]},
+		"mitigations":{"x-section":"assessment","x-dependsOn":["risks"]},
+		"interfaces":{"x-section":"architecture"}
+	}}`))
+	if err != nil {
+		t.Fatalf("FieldGroups: %v", err)
+	}
+	want := []FieldGroup{
+		{Fields: []string{"title"}},
+		{Section: "architecture", Fields: []string{"components", "interfaces"}},
+		{Section: "assessment", Fields: []string{"risks", "mitigations"}, DependsOn: []string{"components"}},
+		{Fields: []string{"summary"}, DependsOn: []string{"risks"}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("groups = %+v, want %+v", groups, want)
+	}
+}
+
+func TestFieldGroups_SectionCycle(t *testing.T) {
+	_, err := FieldGroups(json.RawMessage(`{"properties":{
+		"a1":{"x-section":"a","x-dependsOn":["b1"]},
+		"b1":{"x-section":"b"},
+		"b2":{"x-section":"b","x-dependsOn":["a2"]},
+		"a2":{"x-section":"a"}
+	}}`))
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle between field groups: section a -> section b -> section a") {
+		t.Errorf("FieldGroups error = %v, want a section cycle", err)
+	}
+}
@@ -0,0 +1,111 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+// Fixture files of a template directory: fixtures/fields.json holds sample field
+// values and fixtures/expected.html the golden rendering of them.
+const (
+	FixturesDir         = "fixtures"
+	FixtureFieldsFile   = "fields.json"
+	FixtureExpectedFile = "expected.html"
+)
+
+// FixtureResult is the outcome of checking a template against its fixtures.
+type FixtureResult struct {
+	Template string
+	Dir      string
+	Missing  bool   // The template has no fixtures/fields.json
+	Updated  bool   // expected.html was (re)written from the rendering
+	Diff     string // Description of the first difference from expected.html; empty when it matches
+}
+
+// CheckFixture renders a template's fixture fields and compares the result with the
+// golden expected.html after normalization (line endings, indentation, trailing
+// whitespace and blank lines are ignored). The fixture fields must conform to the
+// template schema. With update set, the golden file is written from the rendering
+// instead.
+func CheckFixture(tmpl *Template, dir string, update bool) (*FixtureResult, error) {
+	result := &FixtureResult{Template: tmpl.Name, Dir: dir}
+	fixtures := filepath.Join(dir, FixturesDir)
+
+	fieldsData, err := os.ReadFile(filepath.Join(fixtures, FixtureFieldsFile)) // #nosec G304 - template directories are user-provided
+	if os.IsNotExist(err) {
+		result.Missing = true
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("template %s: failed to read fixture fields: %w", tmpl.Name, err)
+	}
+	if err := validate.NewValidator().Validate(string(fieldsData), string(tmpl.Schema)); err != nil {
+		return nil, fmt.Errorf("template %s: fixture fields do not match the schema: %w", tmpl.Name, err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(fieldsData, &fields); err != nil {
+		return nil, fmt.Errorf("template %s: failed to parse fixture fields: %w", tmpl.Name, err)
+	}
+	rendered, err := render.HTMLWithOptions(tmpl.HTMLContent, fields, render.Options{Transforms: tmpl.Transforms})
+	if err != nil {
+		return nil, fmt.Errorf("template %s: failed to render fixture: %w", tmpl.Name, err)
+	}
+
+	expectedPath := filepath.Join(fixtures, FixtureExpectedFile)
+	if update {
+		if err := render.WriteFileAtomic(expectedPath, []byte(rendered)); err != nil {
+			return nil, fmt.Errorf("template %s: failed to write golden output: %w", tmpl.Name, err)
+		}
+		result.Updated = true
+		return result, nil
+	}
+
+	expected, err := os.ReadFile(expectedPath) // #nosec G304 - template directories are user-provided
+	if os.IsNotExist(err) {
+		result.Diff = FixturesDir + "/" + FixtureExpectedFile + " is missing; run with --update to create it"
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("template %s: failed to read golden output: %w", tmpl.Name, err)
+	}
+
+	result.Diff = diffNormalized(string(expected), rendered)
+	return result, nil
+}
+
+// normalizeHTML returns the non-blank lines of html with surrounding whitespace removed.
+func normalizeHTML(html string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(html, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// diffNormalized describes the first difference between the normalized expected and
+// actual HTML, or returns an empty string when they are equal.
+func diffNormalized(expected, actual string) string {
+	want, got := normalizeHTML(expected), normalizeHTML(actual)
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d: expected %q, got %q", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package templates
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fixtureTemplate() *Template {
+	return &Template{
+		Name:        "fixture-template",
+		HTMLContent: "<html>\n  <body>\n    <h1><!-- data-field=\"title\" --></h1>\n  </body>\n</html>",
+		Schema:      json.RawMessage(`{"type": "object", "properties": {"title": {"type": "string"}}, "required": ["title"]}`),
+	}
+}
+
+func TestCheckFixture(t *testing.T) {
+	t.Run("matches golden output after normalization", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplateFiles(t, filepath.Join(dir, FixturesDir), map[string]string{
+			FixtureFieldsFile:   `{"title": "Payments"}`,
+			FixtureExpectedFile: "<html>\r\n<body>\r\n\r\n<h1>Payments</h1>   \r\n</body>\r\n</html>\r\n",
+		})
+
+		result, err := CheckFixture(fixtureTemplate(), dir, false)
+		if err != nil {
+			t.Fatalf("CheckFixture returned error: %v", err)
+		}
+		if result.Diff != "" {
+			t.Errorf("Expected no difference, got %s", result.Diff)
+		}
+	})
+
+	t.Run("reports the first differing line", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplateFiles(t, filepath.Join(dir, FixturesDir), map[string]string{
+			FixtureFieldsFile:   `{"title": "Payments"}`,
+			FixtureExpectedFile: "<html>\n<body>\n<h1>Billing</h1>\n</body>\n</html>",
+		})
+
+		result, err := CheckFixture(fixtureTemplate(), dir, false)
+		if err != nil {
+			t.Fatalf("CheckFixture returned error: %v", err)
+		}
+		if !strings.Contains(result.Diff, "line 3") || !strings.Contains(result.Diff, "<h1>Payments</h1>") {
+			t.Errorf("Unexpected diff: %s", result.Diff)
+		}
+	})
+
+	t.Run("update writes the golden output", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplateFiles(t, filepath.Join(dir, FixturesDir), map[string]string{FixtureFieldsFile: `{"title": "Payments"}`})
+
+		result, err := CheckFixture(fixtureTemplate(), dir, false)
+		if err != nil {
+			t.Fatalf("CheckFixture returned error: %v", err)
+		}
+		if !strings.Contains(result.Diff, "missing") {
+			t.Errorf("Expected a missing golden output to be reported, got %q", result.Diff)
+		}
+
+		result, err = CheckFixture(fixtureTemplate(), dir, true)
+		if err != nil {
+			t.Fatalf("CheckFixture returned error: %v", err)
+		}
+		if !result.Updated {
+			t.Error("Expected the golden output to be written")
+		}
+		golden, err := os.ReadFile(filepath.Join(dir, FixturesDir, FixtureExpectedFile))
+		if err != nil {
+			t.Fatalf("Failed to read golden output: %v", err)
+		}
+		if !strings.Contains(string(golden), "<h1>Payments</h1>") {
+			t.Errorf("Unexpected golden output: %s", golden)
+		}
+	})
+
+	t.Run("fixture fields must match the schema", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplateFiles(t, filepath.Join(dir, FixturesDir), map[string]string{FixtureFieldsFile: `{"heading": "Payments"}`})
+
+		if _, err := CheckFixture(fixtureTemplate(), dir, false); err == nil {
+			t.Error("Expected an error for fixture fields violating the schema")
+		}
+	})
+
+	t.Run("templates without fixtures are reported", func(t *testing.T) {
+		result, err := CheckFixture(fixtureTemplate(), t.TempDir(), false)
+		if err != nil {
+			t.Fatalf("CheckFixture returned error: %v", err)
+		}
+		if !result.Missing {
+			t.Error("Expected the fixture to be reported as missing")
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxIndexBytes bounds the size of a template index document.
+const maxIndexBytes = 10 << 20
+
+// Index is a template collection published for discovery, for example by a platform
+// team sharing templates across an organization:
+//
+//	{"templates": [{"name": "runbook", "description": "Service runbook",
+//	  "tags": ["ops"], "source": "https://git.example.com/templates/runbook", "version": "1.2.0"}]}
+type Index struct {
+	Templates []IndexEntry `json:"templates"`
+}
+
+// IndexEntry describes a template listed in an index.
+type IndexEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Source      string   `json:"source"` // Where to get the template, e.g. a git repository URL
+	Version     string   `json:"version,omitempty"`
+
+	// Index is the location of the index that lists the entry; set by SearchIndexes
+	Index string `json:"index,omitempty"`
+}
+
+// matches reports whether the entry's name, description or tags contain keyword,
+// ignoring case. An empty keyword matches every entry.
+func (e IndexEntry) matches(keyword string) bool {
+	keyword = strings.ToLower(keyword)
+	if strings.Contains(strings.ToLower(e.Name), keyword) || strings.Contains(strings.ToLower(e.Description), keyword) {
+		return true
+	}
+	for _, tag := range e.Tags {
+		if strings.Contains(strings.ToLower(tag), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexClient fetches template indexes from HTTP(S) URLs or local files.
+type IndexClient struct {
+	httpClient *http.Client
+}
+
+// NewIndexClient creates an index client.
+func NewIndexClient() *IndexClient {
+	return &IndexClient{httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Fetch reads the index at location, an http(s) URL or a file path.
+func (c *IndexClient) Fetch(ctx context.Context, location string) (*Index, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		data, err = c.download(ctx, location)
+	} else {
+		data, err = os.ReadFile(strings.TrimPrefix(location, "file://")) // #nosec G304 - index locations are user-configured
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template index %s: %w", location, err)
+	}
+
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid template index %s: %w", location, err)
+	}
+	return &index, nil
+}
+
+// download returns the body of a GET request to url.
+func (c *IndexClient) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxIndexBytes))
+}
+
+// Search returns the entries of the indexes at locations whose name, description or
+// tags contain keyword, sorted by name. Indexes that cannot be fetched are skipped
+// with a warning; Search fails only when none of them can be fetched.
+func (c *IndexClient) Search(ctx context.Context, locations []string, keyword string) ([]IndexEntry, error) {
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("no template indexes configured (set template_indexes or use --index)")
+	}
+
+	var matches []IndexEntry
+	var lastErr error
+	fetched := 0
+	for _, location := range locations {
+		index, err := c.Fetch(ctx, location)
+		if err != nil {
+			log.Warn().Err(err).Str("index", location).Msg("Skipping template index")
+			lastErr = err
+			continue
+		}
+		fetched++
+		for _, entry := range index.Templates {
+			if entry.matches(keyword) {
+				entry.Index = location
+				matches = append(matches, entry)
+			}
+		}
+	}
+	if fetched == 0 {
+		return nil, lastErr
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches, nil
+}
@@ -0,0 +1,97 @@
+package templates
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testIndex = `{"templates": [
+	{"name": "runbook", "description": "Service runbook", "tags": ["ops", "sre"], "source": "https://git.example.com/templates/runbook", "version": "1.2.0"},
+	{"name": "adr", "description": "Architecture decision record", "tags": ["architecture"], "source": "https://git.example.com/templates/adr", "version": "0.3.0"}
+]}`
+
+func TestIndexClient_Search(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, testIndex)
+	}))
+	defer server.Close()
+
+	localIndex := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(localIndex, []byte(`{"templates": [{"name": "incident-review", "description": "Postmortem", "tags": ["ops"], "source": "./incident-review"}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+
+	client := NewIndexClient()
+	ctx := context.Background()
+
+	tests := []struct {
+		keyword  string
+		expected []string
+	}{
+		{"OPS", []string{"incident-review", "runbook"}},     // tag match, case-insensitive
+		{"decision", []string{"adr"}},                       // description match
+		{"", []string{"adr", "incident-review", "runbook"}}, // everything
+		{"billing", nil},
+	}
+	for _, tt := range tests {
+		matches, err := client.Search(ctx, []string{server.URL, localIndex}, tt.keyword)
+		if err != nil {
+			t.Fatalf("Search(%q) returned error: %v", tt.keyword, err)
+		}
+		var names []string
+		for _, match := range matches {
+			names = append(names, match.Name)
+		}
+		if len(names) != len(tt.expected) {
+			t.Fatalf("Search(%q): expected %v, got %v", tt.keyword, tt.expected, names)
+		}
+		for i := range names {
+			if names[i] != tt.expected[i] {
+				t.Errorf("Search(%q): expected %v, got %v", tt.keyword, tt.expected, names)
+			}
+		}
+	}
+
+	matches, err := client.Search(ctx, []string{server.URL}, "runbook")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Version != "1.2.0" || matches[0].Index != server.URL {
+		t.Errorf("Unexpected match: %+v", matches)
+	}
+}
+
+func TestIndexClient_SearchUnavailableIndexes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewIndexClient()
+	ctx := context.Background()
+
+	if _, err := client.Search(ctx, nil, "ops"); err == nil {
+		t.Error("Expected an error without configured indexes")
+	}
+	if _, err := client.Search(ctx, []string{server.URL}, "ops"); err == nil {
+		t.Error("Expected an error when no index can be fetched")
+	}
+
+	// An unavailable index is skipped when another one can be searched
+	localIndex := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(localIndex, []byte(testIndex), 0644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+	matches, err := client.Search(ctx, []string{server.URL, localIndex}, "ops")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Name != "runbook" {
+		t.Errorf("Unexpected matches: %+v", matches)
+	}
+}
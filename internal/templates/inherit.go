@@ -0,0 +1,203 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PartialsDir is the directory of a template holding its partials, named HTML
+// fragments (<name>.html) that the template's HTML includes with
+// <!-- data-include="name" -->.
+const PartialsDir = "partials"
+
+// maxIncludeDepth bounds the nesting of partials including other partials.
+const maxIncludeDepth = 10
+
+// includePattern matches include comments such as <!-- data-include="header" -->
+var includePattern = regexp.MustCompile(`<!--\s*data-include="([^"]+)"\s*-->`)
+
+// missingBaseError reports a template extending a template that is not loaded (yet).
+type missingBaseError struct {
+	Template string
+	Base     string
+}
+
+func (e *missingBaseError) Error() string {
+	return fmt.Sprintf("template %s extends unknown template %s", e.Template, e.Base)
+}
+
+// readPartials reads the partials of the template in fsys by name.
+func readPartials(fsys fs.FS) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, PartialsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partials: %w", err)
+	}
+	partials := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".html" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(PartialsDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read partial %s: %w", entry.Name(), err)
+		}
+		partials[strings.TrimSuffix(entry.Name(), ".html")] = string(data)
+	}
+	return partials, nil
+}
+
+// includePartials replaces the include comments of html with the named partials,
+// which may include further partials. Unknown partials and include cycles are errors.
+func includePartials(html string, partials map[string]string) (string, error) {
+	var expand func(html string, stack []string) (string, error)
+	expand = func(html string, stack []string) (string, error) {
+		var expandErr error
+		result := includePattern.ReplaceAllStringFunc(html, func(match string) string {
+			if expandErr != nil {
+				return match
+			}
+			name := includePattern.FindStringSubmatch(match)[1]
+			partial, ok := partials[name]
+			switch {
+			case !ok:
+				expandErr = fmt.Errorf("unknown partial %s", name)
+			case slices.Contains(stack, name):
+				expandErr = fmt.Errorf("partial include cycle: %s", strings.Join(append(stack, name), " -> "))
+			case len(stack) >= maxIncludeDepth:
+				expandErr = fmt.Errorf("partials nested deeper than %d levels: %s", maxIncludeDepth, strings.Join(append(stack, name), " -> "))
+			default:
+				var expanded string
+				expanded, expandErr = expand(partial, append(stack, name))
+				return expanded
+			}
+			return match
+		})
+		return result, expandErr
+	}
+	return expand(html, nil)
+}
+
+// mergeSchemas returns the schema of a template extending base with own: the
+// properties of both, with those of own replacing base properties of the same name
+// in place and new ones following, the required fields of both, and the other
+// keywords of own taking precedence.
+func mergeSchemas(base, own json.RawMessage) (json.RawMessage, error) {
+	var baseTop, ownTop map[string]json.RawMessage
+	if err := json.Unmarshal(base, &baseTop); err != nil {
+		return nil, fmt.Errorf("failed to parse base schema: %w", err)
+	}
+	if err := json.Unmarshal(own, &ownTop); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	merged := make(map[string]json.RawMessage, len(baseTop)+len(ownTop))
+	for key, value := range baseTop {
+		merged[key] = value
+	}
+	for key, value := range ownTop {
+		merged[key] = value
+	}
+
+	// Properties keep the base order, so inherited fields are generated first
+	var baseProperties, ownProperties map[string]json.RawMessage
+	if err := json.Unmarshal(orEmptyObject(baseTop["properties"]), &baseProperties); err != nil {
+		return nil, fmt.Errorf("base schema properties must be an object")
+	}
+	if err := json.Unmarshal(orEmptyObject(ownTop["properties"]), &ownProperties); err != nil {
+		return nil, fmt.Errorf("schema properties must be an object")
+	}
+	baseNames, err := propertyNames(base)
+	if err != nil {
+		return nil, err
+	}
+	ownNames, err := propertyNames(own)
+	if err != nil {
+		return nil, err
+	}
+	names := slices.Clone(baseNames)
+	for _, name := range ownNames {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		var properties bytes.Buffer
+		properties.WriteString("{")
+		for idx, name := range names {
+			value, ok := ownProperties[name]
+			if !ok {
+				value = baseProperties[name]
+			}
+			if idx > 0 {
+				properties.WriteString(",")
+			}
+			properties.Write(quoted(name))
+			properties.WriteString(":")
+			properties.Write(value)
+		}
+		properties.WriteString("}")
+		merged["properties"] = properties.Bytes()
+	}
+
+	var baseRequired, ownRequired []string
+	_ = json.Unmarshal(baseTop["required"], &baseRequired)
+	_ = json.Unmarshal(ownTop["required"], &ownRequired)
+	if len(baseRequired)+len(ownRequired) > 0 {
+		required := slices.Clone(baseRequired)
+		for _, name := range ownRequired {
+			if !slices.Contains(required, name) {
+				required = append(required, name)
+			}
+		}
+		data, err := json.Marshal(required)
+		if err != nil {
+			return nil, err
+		}
+		merged["required"] = data
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge schemas: %w", err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return data, nil
+	}
+	return indented.Bytes(), nil
+}
+
+// orEmptyObject returns data, or an empty JSON object when data is empty.
+func orEmptyObject(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return json.RawMessage("{}")
+	}
+	return data
+}
+
+// quoted returns name as a JSON string.
+func quoted(name string) []byte {
+	data, _ := json.Marshal(name)
+	return data
+}
+
+// mergePrompts returns the prompt of a template extending a template with the base
+// prompt: the base prompt followed by the template's own, if it has one.
+func mergePrompts(base, own string) string {
+	switch {
+	case own == "":
+		return base
+	case base == "":
+		return own
+	}
+	return strings.TrimRight(base, "\n") + "\n\n" + own
+}
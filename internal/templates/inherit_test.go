@@ -0,0 +1,109 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTemplateFiles creates a template directory with the given files.
+func writeTemplateFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestTemplateRegistry_LoadFromDirectory_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+	// The child sorts before its base, so it has to wait for the base to load
+	writeTemplateFiles(t, filepath.Join(tmpDir, "a-incident-report"), map[string]string{
+		"template.json":      `{"name": "incident-report", "extends": "base-report", "prompt": "Describe the incident."}`,
+		"schema.json":        `{"type": "object", "properties": {"impact": {"type": "string"}, "title": {"type": "string", "maxLength": 80}}, "required": ["impact"]}`,
+		"partials/body.html": `<section><!-- data-field="impact" --></section>`,
+	})
+	writeTemplateFiles(t, filepath.Join(tmpDir, "base-report"), map[string]string{
+		"template.json":        `{"description": "Branded report", "prompt": "Write a report for ACME."}`,
+		"template.html":        `<html><!-- data-include="header" --><!-- data-include="body" --></html>`,
+		"schema.json":          `{"type": "object", "properties": {"title": {"type": "string"}, "owner": {"type": "string"}}, "required": ["title"]}`,
+		"partials/header.html": `<header>ACME <!-- data-field="title" --></header>`,
+		"partials/body.html":   `<section>Generic</section>`,
+		"brand.css":            `body {}`,
+	})
+
+	registry := NewRegistry()
+	if err := registry.LoadFromDirectory(tmpDir); err != nil {
+		t.Fatalf("Failed to load templates: %v", err)
+	}
+	tmpl, err := registry.Get("incident-report")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+
+	if want := `<html><header>ACME <!-- data-field="title" --></header><section><!-- data-field="impact" --></section></html>`; tmpl.HTMLContent != want {
+		t.Errorf("Expected the base layout with the child's partial, got %s", tmpl.HTMLContent)
+	}
+	if tmpl.Prompt != "Write a report for ACME.\n\nDescribe the incident." {
+		t.Errorf("Expected the prompts to be merged, got %q", tmpl.Prompt)
+	}
+	if tmpl.Description != "Branded report" || string(tmpl.Assets["brand.css"]) != "body {}" {
+		t.Errorf("Expected the base description and assets, got %q and %v", tmpl.Description, tmpl.Assets)
+	}
+	names, err := propertyNames(tmpl.Schema)
+	if err != nil {
+		t.Fatalf("Failed to read merged schema: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"title", "owner", "impact"}) {
+		t.Errorf("Expected base properties first, got %v", names)
+	}
+	if !strings.Contains(string(tmpl.Schema), `"maxLength": 80`) || !strings.Contains(string(tmpl.Schema), `"required": [`) {
+		t.Errorf("Expected the child's title and the merged required fields, got %s", tmpl.Schema)
+	}
+	for _, field := range []string{`"title"`, `"impact"`} {
+		if !strings.Contains(string(tmpl.Schema)[strings.Index(string(tmpl.Schema), `"required"`):], field) {
+			t.Errorf("Expected %s to be required, got %s", field, tmpl.Schema)
+		}
+	}
+
+	// Loading the child on its own finds the base next to it
+	single, err := LoadTemplateDir(filepath.Join(tmpDir, "a-incident-report"))
+	if err != nil {
+		t.Fatalf("Failed to load the template directory: %v", err)
+	}
+	if single.HTMLContent != tmpl.HTMLContent {
+		t.Errorf("Expected the same template, got %s", single.HTMLContent)
+	}
+}
+
+func TestTemplateRegistry_LoadFromDirectory_ExtendsErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTemplateFiles(t, filepath.Join(tmpDir, "orphan"), map[string]string{
+		"template.json": `{"extends": "missing", "prompt": "p"}`,
+		"schema.json":   `{"type": "object"}`,
+	})
+	writeTemplateFiles(t, filepath.Join(tmpDir, "looping"), map[string]string{
+		"template.json":   `{"prompt": "p"}`,
+		"template.html":   `<html><!-- data-include="a" --></html>`,
+		"schema.json":     `{"type": "object"}`,
+		"partials/a.html": `<!-- data-include="b" -->`,
+		"partials/b.html": `<!-- data-include="a" -->`,
+	})
+
+	err := NewRegistry().LoadFromDirectory(tmpDir)
+	if err == nil {
+		t.Fatal("Expected errors for the unknown base and the include cycle")
+	}
+	for _, want := range []string{"extends unknown template missing", "partial include cycle: a -> b -> a"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+}
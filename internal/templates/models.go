@@ -0,0 +1,72 @@
+package templates
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ModelRequirements are what a template needs from the model that generates it,
+// declared under "model_requirements" in template.json and checked before a run
+// spends any tokens.
+type ModelRequirements struct {
+	// Allowed lists the models that may generate the template, by name or by pattern
+	// such as "gpt-4o*"; empty allows every model
+	Allowed []string `json:"allowed,omitempty"`
+
+	MinContextWindow int  `json:"min_context_window,omitempty"` // In tokens
+	Tools            bool `json:"tools,omitempty"`              // Tool calling is required
+	Vision           bool `json:"vision,omitempty"`             // Image input is required
+}
+
+// Validate checks the allowed model patterns and the context window.
+func (m *ModelRequirements) Validate() error {
+	if m == nil {
+		return nil
+	}
+	for _, pattern := range m.Allowed {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("invalid allowed model pattern %q", pattern)
+		}
+	}
+	if m.MinContextWindow < 0 {
+		return fmt.Errorf("min_context_window must not be negative, got %d", m.MinContextWindow)
+	}
+	return nil
+}
+
+// Allows reports whether model matches one of the allowed models, or whether no
+// models are listed.
+func (m *ModelRequirements) Allows(model string) bool {
+	if m == nil || len(m.Allowed) == 0 {
+		return true
+	}
+	for _, pattern := range m.Allowed {
+		if matched, _ := path.Match(pattern, model); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// String summarizes the requirements, e.g. "gpt-4o*; context window of 32000+ tokens;
+// tool calling".
+func (m *ModelRequirements) String() string {
+	var parts []string
+	if len(m.Allowed) > 0 {
+		parts = append(parts, strings.Join(m.Allowed, ", "))
+	}
+	if m.MinContextWindow > 0 {
+		parts = append(parts, fmt.Sprintf("context window of %d+ tokens", m.MinContextWindow))
+	}
+	if m.Tools {
+		parts = append(parts, "tool calling")
+	}
+	if m.Vision {
+		parts = append(parts, "vision")
+	}
+	if len(parts) == 0 {
+		return "any model"
+	}
+	return strings.Join(parts, "; ")
+}
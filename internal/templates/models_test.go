@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRegistry_LoadFromDirectory_ModelRequirements(t *testing.T) {
+	writeTemplate := func(t *testing.T, definition string) string {
+		t.Helper()
+		tmpDir := t.TempDir()
+		templateDir := filepath.Join(tmpDir, "report")
+		if err := os.MkdirAll(templateDir, 0755); err != nil {
+			t.Fatalf("Failed to create template dir: %v", err)
+		}
+		files := map[string]string{
+			"template.json": definition,
+			"template.html": `<html><!-- data-field="title" --></html>`,
+			"schema.json":   `{"type": "object"}`,
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+		return tmpDir
+	}
+
+	registry := NewRegistry()
+	dir := writeTemplate(t, `{"prompt": "p", "model_requirements": {"allowed": ["gpt-4o*", "claude-3-5-sonnet"], "min_context_window": 32000, "tools": true}}`)
+	if err := registry.LoadFromDirectory(dir); err != nil {
+		t.Fatalf("Failed to load template: %v", err)
+	}
+	tmpl, err := registry.Get("report")
+	if err != nil {
+		t.Fatalf("Expected template to be loaded: %v", err)
+	}
+	requirements := tmpl.ModelRequirements
+	if requirements == nil || requirements.MinContextWindow != 32000 || !requirements.Tools || requirements.Vision {
+		t.Fatalf("Unexpected model requirements: %+v", requirements)
+	}
+	for model, allowed := range map[string]bool{"gpt-4o": true, "gpt-4o-mini": true, "claude-3-5-sonnet": true, "gpt-4": false} {
+		if got := requirements.Allows(model); got != allowed {
+			t.Errorf("Allows(%q): expected %v, got %v", model, allowed, got)
+		}
+	}
+
+	dir = writeTemplate(t, `{"prompt": "p", "model_requirements": {"allowed": ["gpt-4o["]}}`)
+	err = NewRegistry().LoadFromDirectory(dir)
+	if err == nil || !strings.Contains(err.Error(), "invalid allowed model pattern") {
+		t.Errorf("Expected invalid pattern error, got %v", err)
+	}
+}
+
+func TestModelRequirements_AllowsWithoutList(t *testing.T) {
+	var requirements *ModelRequirements
+	if !requirements.Allows("any-model") {
+		t.Error("Templates without requirements should allow every model")
+	}
+	if !(&ModelRequirements{MinContextWindow: 8000}).Allows("any-model") {
+		t.Error("An empty allowed list should allow every model")
+	}
+}
@@ -0,0 +1,115 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Assumptions of EstimateOutputTokens where the schema gives no bounds.
+const (
+	charsPerToken       = 4   // Characters per token of English text
+	defaultStringTokens = 150 // A string without maxLength, about a paragraph
+	defaultArrayItems   = 5   // Items of an array without maxItems
+	scalarTokens        = 2   // A number, boolean or null
+	structureTokens     = 3   // Quotes, colon and comma around a property
+)
+
+// outputSchema is the part of a JSON schema that bounds the size of its instances.
+type outputSchema struct {
+	Type       interface{}              `json:"type"` // A type or a list of types
+	Properties map[string]*outputSchema `json:"properties"`
+	Items      *outputSchema            `json:"items"`
+	MaxLength  int                      `json:"maxLength"`
+	MaxItems   int                      `json:"maxItems"`
+	MinItems   int                      `json:"minItems"`
+	Enum       []interface{}            `json:"enum"`
+	AnyOf      []*outputSchema          `json:"anyOf"`
+	OneOf      []*outputSchema          `json:"oneOf"`
+}
+
+// EstimateOutputTokens estimates the tokens a model writes for each top-level field
+// of a schema, including the field's name. Strings are bounded by maxLength, arrays
+// by maxItems (or minItems when larger than the default), and alternatives count as
+// the largest one. Unbounded strings and arrays take typical sizes, so the estimate
+// flags schemas that are predictably too large rather than bounding any response.
+func EstimateOutputTokens(schema json.RawMessage) (map[string]int, error) {
+	var parsed outputSchema
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	estimates := make(map[string]int, len(parsed.Properties))
+	for name, property := range parsed.Properties {
+		estimates[name] = propertyTokens(name, property)
+	}
+	return estimates, nil
+}
+
+// propertyTokens estimates the tokens of a property with its name.
+func propertyTokens(name string, schema *outputSchema) int {
+	return (len(name)+charsPerToken-1)/charsPerToken + structureTokens + valueTokens(schema)
+}
+
+// valueTokens estimates the tokens of an instance of schema.
+func valueTokens(schema *outputSchema) int {
+	if schema == nil {
+		return defaultStringTokens
+	}
+	if alternatives := append(schema.AnyOf, schema.OneOf...); len(alternatives) > 0 {
+		largest := 0
+		for _, alternative := range alternatives {
+			largest = max(largest, valueTokens(alternative))
+		}
+		return largest
+	}
+	if len(schema.Enum) > 0 {
+		largest := 0
+		for _, value := range schema.Enum {
+			data, _ := json.Marshal(value)
+			largest = max(largest, (len(data)+charsPerToken-1)/charsPerToken)
+		}
+		return largest
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		tokens := 2 // Braces
+		for name, property := range schema.Properties {
+			tokens += propertyTokens(name, property)
+		}
+		return tokens
+	case "array":
+		items := defaultArrayItems
+		if schema.MaxItems > 0 {
+			items = schema.MaxItems
+		} else if schema.MinItems > items {
+			items = schema.MinItems
+		}
+		return 2 + items*(valueTokens(schema.Items)+1) // Brackets and commas
+	case "number", "integer", "boolean", "null":
+		return scalarTokens
+	default:
+		if schema.MaxLength > 0 {
+			return (schema.MaxLength+charsPerToken-1)/charsPerToken + 2
+		}
+		return defaultStringTokens
+	}
+}
+
+// schemaType returns the type of schema, the first non-null one of a list of types,
+// or object for a schema with properties and no type.
+func schemaType(schema *outputSchema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, value := range t {
+			if name, ok := value.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if schema.Properties != nil {
+		return "object"
+	}
+	return ""
+}
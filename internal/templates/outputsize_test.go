@@ -0,0 +1,35 @@
+package templates
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEstimateOutputTokens(t *testing.T) {
+	estimates, err := EstimateOutputTokens(json.RawMessage(`{"type":"object","properties":{
+		"title":{"type":"string","maxLength":40},
+		"count":{"type":"integer"},
+		"level":{"enum":["low","medium","high"]},
+		"tags":{"type":"array","items":{"type":"string","maxLength":8},"maxItems":3},
+		"body":{"type":"string"},
+		"owner":{"type":["string","null"],"maxLength":20},
+		"detail":{"anyOf":[{"type":"string","maxLength":4},{"type":"object","properties":{"id":{"type":"integer"}}}]}
+	}}`))
+	if err != nil {
+		t.Fatalf("EstimateOutputTokens: %v", err)
+	}
+	want := map[string]int{
+		"title":  2 + 3 + 12, // Name, structure, 40 characters and quotes
+		"count":  2 + 3 + scalarTokens,
+		"level":  2 + 3 + 2,           // "medium" with quotes
+		"tags":   1 + 3 + 2 + 3*(4+1), // Three items of 8 characters
+		"body":   1 + 3 + defaultStringTokens,
+		"owner":  2 + 3 + 7,               // The string of a nullable string
+		"detail": 2 + 3 + 2 + (1 + 3 + 2), // The object alternative
+	}
+	for field, tokens := range want {
+		if estimates[field] != tokens {
+			t.Errorf("estimate of %s = %d, want %d", field, estimates[field], tokens)
+		}
+	}
+}
@@ -1,14 +1,22 @@
 package templates
 
 import (
-	// "embed" // Currently using programmatic templates
+	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/trust"
+	"github.com/karolswdev/docloom/internal/validate"
 )
 
 // Analysis contains prompts for AI-driven analysis
@@ -17,6 +25,13 @@ type Analysis struct {
 	InitialUserPrompt string `json:"initial_user_prompt"`
 }
 
+// DerivedField declares a field that is derived from the fully generated document
+// (for example an executive summary) rather than from the raw sources.
+type DerivedField struct {
+	Field  string `json:"field"`  // Dot-separated field path (e.g., "document.summary")
+	Prompt string `json:"prompt"` // Derivation instructions for the model
+}
+
 // Template represents a document template with its assets
 type Template struct {
 	Assets       map[string][]byte `json:"-"`
@@ -28,128 +43,451 @@ type Template struct {
 	Schema       json.RawMessage   `json:"schema"`
 	FieldSchema  json.RawMessage   `json:"-"` // Alias for Schema
 	Analysis     *Analysis         `json:"analysis,omitempty"`
+	Derived      []DerivedField    `json:"derived,omitempty"`
+
+	// Output is the default output filename pattern, e.g. "{{project}}-{{template}}-{{date}}.html"
+	Output string `json:"output,omitempty"`
+
+	// Transforms format field values when rendering, e.g. dates and numbers
+	Transforms []render.Transform `json:"transforms,omitempty"`
+
+	// Rules are cross-field consistency checks applied after schema validation
+	Rules []validate.Rule `json:"rules,omitempty"`
+
+	// Version identifies the template revision, e.g. "2.0.0"
+	Version string `json:"version,omitempty"`
+
+	// ModelRequirements restrict the models that may generate the template
+	ModelRequirements *ModelRequirements `json:"model_requirements,omitempty"`
+
+	// Extends names the base template this template builds on
+	Extends string `json:"extends,omitempty"`
+
+	// Partials are the HTML fragments the template can include by name, its own and
+	// those of its base template
+	Partials map[string]string `json:"-"`
+
+	layout string // HTML before partials are included, for templates extending this one
+}
+
+// layoutHTML returns the HTML of the template before partials are included.
+func (t *Template) layoutHTML() string {
+	if t.layout != "" {
+		return t.layout
+	}
+	return t.HTMLContent
 }
 
+// OriginBuiltIn is the origin reported for templates compiled into the binary.
+const OriginBuiltIn = "built-in"
+
 // Registry manages available templates
 type Registry struct {
 	templates map[string]*Template
+	origins   map[string]string    // Template name to the directory it was loaded from
+	versions  map[string]*Template // "name@version" to every versioned template loaded
 }
 
-// Embed default templates into the binary
-// Note: Currently using programmatic templates
-// //go:embed defaults/*
-// var defaultTemplatesFS embed.FS
+// defaultTemplatesFS holds the built-in templates, one directory per template in the
+// same layout as user templates.
+//
+//go:embed defaults
+var defaultTemplatesFS embed.FS
+
+// defaultTemplatesDir is the directory of defaultTemplatesFS holding the templates.
+const defaultTemplatesDir = "defaults"
 
 // NewRegistry creates a new template registry
 func NewRegistry() *Registry {
 	return &Registry{
 		templates: make(map[string]*Template),
+		origins:   make(map[string]string),
+		versions:  make(map[string]*Template),
 	}
 }
 
-// LoadDefaults loads the default embedded templates
+// LoadDefaults loads the built-in templates embedded in the binary. They go through
+// the same loading and validation as templates on disk.
 func (r *Registry) LoadDefaults() error {
 	log.Debug().Msg("Loading default embedded templates")
 
-	// For now, we'll create sample templates programmatically
-	// In production, these would be loaded from the embedded FS
-	r.registerDefaultTemplates()
-
-	// Add analysis prompts to templates
-	r.UpdateDefaultTemplatesWithAnalysis()
-
+	entries, err := fs.ReadDir(defaultTemplatesFS, defaultTemplatesDir)
+	if err != nil {
+		return fmt.Errorf("failed to read built-in templates: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		fsys, err := fs.Sub(defaultTemplatesFS, path.Join(defaultTemplatesDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read built-in template %s: %w", entry.Name(), err)
+		}
+		if _, err := r.loadTemplateFS(fsys, entry.Name(), OriginBuiltIn); err != nil {
+			return fmt.Errorf("built-in template %s: %w", entry.Name(), err)
+		}
+	}
 	return nil
 }
 
-// LoadFromDirectory loads templates from a directory
+// LoadFromDirectory loads templates from a directory. Every subdirectory containing a
+// template.json is loaded as a template; user templates replace built-in templates
+// with the same name. A template that cannot be loaded does not stop the others from
+// loading; the returned error lists every template that failed.
 func (r *Registry) LoadFromDirectory(dir string) error {
 	log.Debug().Str("dir", dir).Msg("Loading templates from directory")
 
-	// Walk the directory looking for template definitions
-	err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+	var pending []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Skip directories and non-template files
-		if info.IsDir() {
+		if entry.IsDir() || entry.Name() != "template.json" {
 			return nil
 		}
+		pending = append(pending, filepath.Dir(path))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		// Look for template directories with required files
-		if strings.HasSuffix(path, "template.json") {
-			templateDir := filepath.Dir(path)
-			r.loadTemplate(templateDir)
-			// TODO: When file reading is implemented, handle errors here
+	// Under a trust policy, templates of packs without a trusted signature are refused
+	var failures []error
+	pending = slices.DeleteFunc(pending, func(templateDir string) bool {
+		if err := trust.CheckDir(trust.PackDir(templateDir, dir)); err != nil {
+			log.Warn().Err(err).Str("dir", templateDir).Msg("Failed to load template")
+			failures = append(failures, fmt.Errorf("%s: %w", templateDir, err))
+			return true
 		}
-
-		return nil
+		return false
 	})
 
-	return err
+	// Templates extending a template of the directory are loaded after their base
+	for len(pending) > 0 {
+		var deferred []string
+		var missing []error
+		for _, templateDir := range pending {
+			_, err := r.loadTemplate(templateDir)
+			var missingBase *missingBaseError
+			switch {
+			case errors.As(err, &missingBase):
+				deferred = append(deferred, templateDir)
+				missing = append(missing, fmt.Errorf("%s: %w", templateDir, err))
+			case err != nil:
+				log.Warn().Err(err).Str("dir", templateDir).Msg("Failed to load template")
+				failures = append(failures, fmt.Errorf("%s: %w", templateDir, err))
+			}
+		}
+		if len(deferred) == len(pending) {
+			for _, err := range missing {
+				log.Warn().Err(err).Msg("Failed to load template")
+			}
+			failures = append(failures, missing...)
+			break
+		}
+		pending = deferred
+	}
+	return errors.Join(failures...)
+}
+
+// templateDefinition is the content of a template directory's template.json.
+type templateDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Prompt      string         `json:"prompt"`
+	Analysis    *Analysis      `json:"analysis,omitempty"`
+	Derived     []DerivedField `json:"derived,omitempty"`
+	Output      string         `json:"output,omitempty"`
+	Version     string         `json:"version,omitempty"`
+
+	Transforms []render.Transform `json:"transforms,omitempty"`
+	Rules      []validate.Rule    `json:"rules,omitempty"`
+
+	ModelRequirements *ModelRequirements `json:"model_requirements,omitempty"`
+
+	// Extends names a loaded template whose HTML, schema, prompt, partials and
+	// assets the template builds on
+	Extends string `json:"extends,omitempty"`
+}
+
+// loadTemplate loads a single template from a directory on disk.
+func (r *Registry) loadTemplate(dir string) (*Template, error) {
+	return r.loadTemplateFS(os.DirFS(dir), filepath.Base(dir), dir)
 }
 
-// loadTemplate loads a single template from a directory
-func (r *Registry) loadTemplate(dir string) {
-	templateName := filepath.Base(dir)
+// loadTemplateFS loads a single template from fsys, the template's directory, which
+// holds template.json, the HTML (<name>.html or template.html), schema.json, the
+// partials and, unless the definition carries the prompt, prompt.txt. A template
+// replacing one that is already registered under the same name falls back to that
+// template's HTML, schema, prompt, description and analysis for whatever it does not
+// provide itself, so a user template can override only the parts it changes.
+//
+// A template that extends a base template instead inherits the base HTML unless it
+// has its own, merges its schema into the base schema and appends its prompt to the
+// base prompt. Partials and assets of the template replace those of the base with the
+// same name; transforms, rules and derived fields add to those of the base. The base
+// must already be registered.
+func (r *Registry) loadTemplateFS(fsys fs.FS, dirName, origin string) (*Template, error) {
+	definitionData, err := fs.ReadFile(fsys, "template.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template definition: %w", err)
+	}
+	var def templateDefinition
+	if err := json.Unmarshal(definitionData, &def); err != nil {
+		return nil, fmt.Errorf("invalid template definition in %s: %w", origin, err)
+	}
+	if def.Name == "" {
+		def.Name = dirName
+	}
+	replaced := r.templates[def.Name]
+	var base *Template
+	if def.Extends != "" {
+		if def.Extends == def.Name {
+			return nil, fmt.Errorf("template %s cannot extend itself", def.Name)
+		}
+		if base = r.templates[def.Extends]; base == nil {
+			return nil, &missingBaseError{Template: def.Name, Base: def.Extends}
+		}
+		// The base provides what the template leaves out
+		replaced = nil
+	}
+
+	layout, err := readFirst(fsys, def.Name+".html", "template.html")
+	if err != nil {
+		switch {
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("template %s: %w", def.Name, err)
+		case base != nil:
+			layout = base.layoutHTML()
+		case replaced != nil:
+			layout = replaced.layoutHTML()
+		default:
+			return nil, fmt.Errorf("template %s: %w", def.Name, err)
+		}
+	}
+	schema, err := fs.ReadFile(fsys, "schema.json")
+	if err != nil {
+		switch {
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("template %s: failed to read schema: %w", def.Name, err)
+		case base != nil:
+			schema = base.Schema
+		case replaced != nil:
+			schema = replaced.Schema
+		default:
+			return nil, fmt.Errorf("template %s: failed to read schema: %w", def.Name, err)
+		}
+	} else if !json.Valid(schema) {
+		return nil, fmt.Errorf("template %s: schema.json is not valid JSON", def.Name)
+	} else if base != nil {
+		if schema, err = mergeSchemas(base.Schema, schema); err != nil {
+			return nil, fmt.Errorf("template %s: %w", def.Name, err)
+		}
+	}
+	if _, err := FieldGroups(schema); err != nil {
+		return nil, fmt.Errorf("template %s: %w", def.Name, err)
+	}
+	for _, transform := range def.Transforms {
+		if err := transform.Validate(); err != nil {
+			return nil, fmt.Errorf("template %s: %w", def.Name, err)
+		}
+	}
+	for _, rule := range def.Rules {
+		if err := rule.Compile(); err != nil {
+			return nil, fmt.Errorf("template %s: %w", def.Name, err)
+		}
+	}
+	if err := def.ModelRequirements.Validate(); err != nil {
+		return nil, fmt.Errorf("template %s: %w", def.Name, err)
+	}
+	if def.Prompt == "" {
+		prompt, promptErr := fs.ReadFile(fsys, "prompt.txt")
+		switch {
+		case promptErr == nil:
+			def.Prompt = string(prompt)
+		case (base != nil || replaced != nil) && errors.Is(promptErr, fs.ErrNotExist):
+			if replaced != nil {
+				def.Prompt = replaced.Prompt
+			}
+		default:
+			return nil, fmt.Errorf("template %s: no prompt in template.json and failed to read prompt.txt: %w", def.Name, promptErr)
+		}
+	}
+	assets, err := readAssets(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", def.Name, err)
+	}
+	partials, err := readPartials(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", def.Name, err)
+	}
+	if replaced != nil {
+		if len(assets) == 0 {
+			assets = replaced.Assets
+		}
+		if len(partials) == 0 {
+			partials = replaced.Partials
+		}
+		if def.Description == "" {
+			def.Description = replaced.Description
+		}
+		if def.Analysis == nil {
+			def.Analysis = replaced.Analysis
+		}
+	}
+	if base != nil {
+		def.Prompt = mergePrompts(base.Prompt, def.Prompt)
+		assets = overlay(base.Assets, assets)
+		partials = overlay(base.Partials, partials)
+		def.Transforms = append(slices.Clone(base.Transforms), def.Transforms...)
+		def.Rules = append(slices.Clone(base.Rules), def.Rules...)
+		def.Derived = append(slices.Clone(base.Derived), def.Derived...)
+		if def.Description == "" {
+			def.Description = base.Description
+		}
+		if def.Analysis == nil {
+			def.Analysis = base.Analysis
+		}
+		if def.Output == "" {
+			def.Output = base.Output
+		}
+		if def.ModelRequirements == nil {
+			def.ModelRequirements = base.ModelRequirements
+		}
+	}
+	htmlContent, err := includePartials(layout, partials)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", def.Name, err)
+	}
+
+	if previous, ok := r.origins[def.Name]; ok && r.templates[def.Name] != nil {
+		log.Info().Str("name", def.Name).Str("dir", origin).Str("replaces", previous).Msg("User template overrides existing template")
+	}
+	log.Debug().Str("name", def.Name).Str("dir", origin).Str("extends", def.Extends).Msg("Loaded template")
 
-	// Initialize template
 	tmpl := &Template{
-		Name:   templateName,
-		Assets: make(map[string][]byte),
+		Name:        def.Name,
+		Description: def.Description,
+		HTMLContent: htmlContent,
+		Prompt:      def.Prompt,
+		Schema:      json.RawMessage(schema),
+		Analysis:    def.Analysis,
+		Derived:     def.Derived,
+		Output:      def.Output,
+		Transforms:  def.Transforms,
+		Rules:       def.Rules,
+		Version:     def.Version,
+		Assets:      assets,
+		Extends:     def.Extends,
+		Partials:    partials,
+		layout:      layout,
+
+		ModelRequirements: def.ModelRequirements,
 	}
+	setCompatibilityFields(tmpl)
+	r.templates[def.Name] = tmpl
+	r.origins[def.Name] = origin
+	if def.Version != "" {
+		r.versions[def.Name+"@"+def.Version] = tmpl
+	}
+	return tmpl, nil
+}
 
-	// Check for required files
-	htmlPath := filepath.Join(dir, templateName+".html")
-	schemaPath := filepath.Join(dir, "schema.json")
-	promptPath := filepath.Join(dir, "prompt.txt")
+// overlay returns the entries of base with those of own added, replacing entries
+// with the same key.
+func overlay[V any](base, own map[string]V) map[string]V {
+	if len(base) == 0 {
+		return own
+	}
+	merged := make(map[string]V, len(base)+len(own))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range own {
+		merged[key] = value
+	}
+	return merged
+}
 
-	// For testing purposes, we'll accept templates even if files don't exist yet
-	// In production, we would read these files
-	log.Debug().
-		Str("name", templateName).
-		Str("html", htmlPath).
-		Str("schema", schemaPath).
-		Str("prompt", promptPath).
-		Msg("Loading template")
+// assetExtensions are the extensions of the files in a template directory that its
+// HTML may refer to and that are copied next to every document rendered from it.
+var assetExtensions = map[string]bool{
+	".css": true, ".js": true, ".svg": true, ".png": true, ".jpg": true, ".jpeg": true,
+	".gif": true, ".webp": true, ".ico": true, ".woff": true, ".woff2": true, ".ttf": true,
+}
 
-	// Register the template
-	r.templates[templateName] = tmpl
+// readAssets reads the stylesheets, scripts, images and fonts of the template in fsys,
+// keyed by slash-separated path relative to the template directory. Fixtures are not
+// assets.
+func readAssets(fsys fs.FS) (map[string][]byte, error) {
+	assets := make(map[string][]byte)
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && name == FixturesDir {
+			return fs.SkipDir
+		}
+		if entry.IsDir() || !assetExtensions[strings.ToLower(path.Ext(name))] {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read asset %s: %w", name, err)
+		}
+		assets[name] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
 
-	// TODO: Return errors when file reading is implemented
+// LoadTemplateDir loads the template in dir, a directory containing template.json.
+// A template extending another finds its base among the built-in templates and the
+// templates next to dir.
+func LoadTemplateDir(dir string) (*Template, error) {
+	if err := trust.CheckDir(trust.PackDir(dir, filepath.Dir(filepath.Clean(dir)))); err != nil {
+		return nil, err
+	}
+	r := NewRegistry()
+	tmpl, err := r.loadTemplate(dir)
+	var missingBase *missingBaseError
+	if !errors.As(err, &missingBase) {
+		return tmpl, err
+	}
+	if err := r.LoadDefaults(); err != nil {
+		return nil, err
+	}
+	if err := r.LoadFromDirectory(filepath.Dir(filepath.Clean(dir))); err != nil {
+		// Other templates next to dir need not load
+		log.Debug().Err(err).Str("dir", dir).Msg("Some templates next to the template failed to load")
+	}
+	return r.loadTemplate(dir)
 }
 
-// registerDefaultTemplates registers the built-in templates
-func (r *Registry) registerDefaultTemplates() {
-	// Architecture Vision template
-	r.templates["architecture-vision"] = &Template{
-		Name:        "architecture-vision",
-		Description: "Architecture Vision document template",
-		HTMLContent: architectureVisionHTML,
-		Schema:      json.RawMessage(architectureVisionSchema),
-		Prompt:      architectureVisionPrompt,
-		Assets:      make(map[string][]byte),
-	}
-
-	// Technical Debt Summary template
-	r.templates["technical-debt-summary"] = &Template{
-		Name:        "technical-debt-summary",
-		Description: "Technical Debt Summary template",
-		HTMLContent: technicalDebtHTML,
-		Schema:      json.RawMessage(technicalDebtSchema),
-		Prompt:      technicalDebtPrompt,
-		Assets:      make(map[string][]byte),
-	}
-
-	// Reference Architecture template
-	r.templates["reference-architecture"] = &Template{
-		Name:        "reference-architecture",
-		Description: "Reference Architecture template",
-		HTMLContent: referenceArchHTML,
-		Schema:      json.RawMessage(referenceArchSchema),
-		Prompt:      referenceArchPrompt,
-		Assets:      make(map[string][]byte),
+// readFirst returns the content of the first of the named files that exists in fsys.
+func readFirst(fsys fs.FS, names ...string) (string, error) {
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
 	}
+	return "", fmt.Errorf("none of %s found: %w", strings.Join(names, ", "), fs.ErrNotExist)
+}
+
+// Origin returns where a template came from: OriginBuiltIn, the directory it was
+// loaded from, or an empty string for templates added with Register.
+func (r *Registry) Origin(name string) string {
+	return r.origins[name]
 }
 
 // Get retrieves a template by name
@@ -158,13 +496,28 @@ func (r *Registry) Get(name string) (*Template, error) {
 	if !exists {
 		return nil, fmt.Errorf("template '%s' not found", name)
 	}
-	// Set compatibility fields
+	return tmpl, nil
+}
+
+// setCompatibilityFields fills the alias fields of a template when it is added, so
+// that Get only reads and concurrent runs can share the registry.
+func setCompatibilityFields(tmpl *Template) {
 	if tmpl.HTMLTemplate == "" {
 		tmpl.HTMLTemplate = tmpl.HTMLContent
 	}
 	if tmpl.FieldSchema == nil {
 		tmpl.FieldSchema = tmpl.Schema
 	}
+}
+
+// GetVersion retrieves a template by name and the version declared in its
+// template.json. Several versions of a template can be kept side by side in the
+// template directory; Get returns the one loaded last.
+func (r *Registry) GetVersion(name, version string) (*Template, error) {
+	tmpl, exists := r.versions[name+"@"+version]
+	if !exists {
+		return nil, fmt.Errorf("template '%s' version '%s' not found", name, version)
+	}
 	return tmpl, nil
 }
 
@@ -178,6 +531,7 @@ func (r *Registry) Register(name string, tmpl *Template) error {
 	if _, exists := r.templates[name]; exists {
 		return fmt.Errorf("template '%s' already exists", name)
 	}
+	setCompatibilityFields(tmpl)
 	r.templates[name] = tmpl
 	return nil
 }
@@ -202,78 +556,3 @@ func (r *Registry) ListWithDescriptions() []struct{ Name, Description string } {
 	}
 	return result
 }
-
-// Sample template content (simplified for testing)
-const (
-	architectureVisionHTML = `<!DOCTYPE html>
-<html>
-<head><title>Architecture Vision</title></head>
-<body>
-<!-- data-field="document.title" -->
-<!-- data-field="document.content" -->
-</body>
-</html>`
-
-	architectureVisionSchema = `{
-  "type": "object",
-  "properties": {
-    "document": {
-      "type": "object",
-      "properties": {
-        "title": {"type": "string"},
-        "content": {"type": "string"}
-      }
-    }
-  }
-}`
-
-	architectureVisionPrompt = `Generate an architecture vision document based on the provided sources.`
-
-	technicalDebtHTML = `<!DOCTYPE html>
-<html>
-<head><title>Technical Debt Summary</title></head>
-<body>
-<!-- data-field="summary.title" -->
-<!-- data-field="summary.items" -->
-</body>
-</html>`
-
-	technicalDebtSchema = `{
-  "type": "object",
-  "properties": {
-    "summary": {
-      "type": "object",
-      "properties": {
-        "title": {"type": "string"},
-        "items": {"type": "array"}
-      }
-    }
-  }
-}`
-
-	technicalDebtPrompt = `Analyze technical debt from the provided sources.`
-
-	referenceArchHTML = `<!DOCTYPE html>
-<html>
-<head><title>Reference Architecture</title></head>
-<body>
-<!-- data-field="architecture.name" -->
-<!-- data-field="architecture.components" -->
-</body>
-</html>`
-
-	referenceArchSchema = `{
-  "type": "object",
-  "properties": {
-    "architecture": {
-      "type": "object",
-      "properties": {
-        "name": {"type": "string"},
-        "components": {"type": "array"}
-      }
-    }
-  }
-}`
-
-	referenceArchPrompt = `Create a reference architecture based on the provided sources.`
-)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -197,3 +198,222 @@ func TestTemplateRegistry_GetNonExistent(t *testing.T) {
 		t.Error("Template should be nil for non-existent template")
 	}
 }
+
+func TestTemplateRegistry_LoadFromDirectory_OverridesBuiltIn(t *testing.T) {
+	tmpDir := t.TempDir()
+	templateDir := filepath.Join(tmpDir, "architecture-vision")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	files := map[string]string{
+		"template.json": `{"description": "Company architecture vision", "prompt": "Use the company style", "output": "{{project}}-{{date}}.html"}`,
+		"template.html": `<html><!-- data-field="title" --></html>`,
+		"schema.json":   `{"type": "object", "properties": {"title": {"type": "string"}}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	registry := NewRegistry()
+	if err := registry.LoadDefaults(); err != nil {
+		t.Fatalf("Failed to load defaults: %v", err)
+	}
+	if origin := registry.Origin("architecture-vision"); origin != OriginBuiltIn {
+		t.Errorf("Expected built-in origin before override, got %q", origin)
+	}
+
+	if err := registry.LoadFromDirectory(tmpDir); err != nil {
+		t.Fatalf("Failed to load templates from directory: %v", err)
+	}
+
+	tmpl, err := registry.Get("architecture-vision")
+	if err != nil {
+		t.Fatalf("Expected template to be available: %v", err)
+	}
+	if tmpl.Description != "Company architecture vision" || tmpl.Prompt != "Use the company style" {
+		t.Errorf("Expected user template to replace the built-in, got description %q prompt %q", tmpl.Description, tmpl.Prompt)
+	}
+	if tmpl.HTMLContent != files["template.html"] {
+		t.Errorf("Expected HTML from template.html, got %q", tmpl.HTMLContent)
+	}
+	if tmpl.Output != "{{project}}-{{date}}.html" {
+		t.Errorf("Expected output pattern from template.json, got %q", tmpl.Output)
+	}
+	if origin := registry.Origin("architecture-vision"); origin != templateDir {
+		t.Errorf("Expected origin %q, got %q", templateDir, origin)
+	}
+}
+
+func TestTemplateRegistry_LoadFromDirectory_MissingSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	templateDir := filepath.Join(tmpDir, "broken")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "template.json"), []byte(`{"prompt": "p"}`), 0644); err != nil {
+		t.Fatalf("Failed to write template.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "broken.html"), []byte("<html></html>"), 0644); err != nil {
+		t.Fatalf("Failed to write HTML: %v", err)
+	}
+
+	registry := NewRegistry()
+	err := registry.LoadFromDirectory(tmpDir)
+	if err == nil || !strings.Contains(err.Error(), "failed to read schema") {
+		t.Errorf("Expected missing schema error, got %v", err)
+	}
+}
+
+func TestTemplateRegistry_LoadFromDirectory_Transforms(t *testing.T) {
+	writeTemplate := func(t *testing.T, definition string) string {
+		t.Helper()
+		tmpDir := t.TempDir()
+		templateDir := filepath.Join(tmpDir, "report")
+		files := map[string]string{
+			"template.json": definition,
+			"template.html": `<html><!-- data-field="start" --></html>`,
+			"schema.json":   `{"type": "object"}`,
+		}
+		if err := os.MkdirAll(templateDir, 0755); err != nil {
+			t.Fatalf("Failed to create template dir: %v", err)
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+		return tmpDir
+	}
+
+	registry := NewRegistry()
+	dir := writeTemplate(t, `{"prompt": "p", "transforms": [{"field": "start", "type": "date", "format": "Jan 2006"}]}`)
+	if err := registry.LoadFromDirectory(dir); err != nil {
+		t.Fatalf("Failed to load template: %v", err)
+	}
+	tmpl, err := registry.Get("report")
+	if err != nil {
+		t.Fatalf("Expected template to be loaded: %v", err)
+	}
+	if len(tmpl.Transforms) != 1 || tmpl.Transforms[0].Type != "date" || tmpl.Transforms[0].Format != "Jan 2006" {
+		t.Errorf("Unexpected transforms: %+v", tmpl.Transforms)
+	}
+
+	dir = writeTemplate(t, `{"prompt": "p", "transforms": [{"field": "start", "type": "reverse"}]}`)
+	err = NewRegistry().LoadFromDirectory(dir)
+	if err == nil || !strings.Contains(err.Error(), `unknown type "reverse"`) {
+		t.Errorf("Expected unknown transform error, got %v", err)
+	}
+}
+
+func TestTemplateRegistry_LoadFromDirectory_FallsBackToReplaced(t *testing.T) {
+	tmpDir := t.TempDir()
+	templateDir := filepath.Join(tmpDir, "architecture-vision")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	// Only the HTML is overridden; everything else comes from the built-in
+	html := `<html><body class="branded"><!-- data-field="document.title" --></body></html>`
+	if err := os.WriteFile(filepath.Join(templateDir, "template.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatalf("Failed to write template.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "template.html"), []byte(html), 0644); err != nil {
+		t.Fatalf("Failed to write HTML: %v", err)
+	}
+
+	registry := NewRegistry()
+	if err := registry.LoadDefaults(); err != nil {
+		t.Fatalf("Failed to load defaults: %v", err)
+	}
+	builtIn, _ := registry.Get("architecture-vision")
+	builtInSchema, builtInPrompt, builtInAnalysis := string(builtIn.Schema), builtIn.Prompt, builtIn.Analysis
+
+	if err := registry.LoadFromDirectory(tmpDir); err != nil {
+		t.Fatalf("Failed to load templates from directory: %v", err)
+	}
+	tmpl, _ := registry.Get("architecture-vision")
+	if tmpl.HTMLContent != html {
+		t.Errorf("Expected the user HTML, got %q", tmpl.HTMLContent)
+	}
+	if string(tmpl.Schema) != builtInSchema || tmpl.Prompt != builtInPrompt || tmpl.Analysis != builtInAnalysis {
+		t.Error("Expected schema, prompt and analysis to fall back to the built-in template")
+	}
+
+	// Without a template to replace, the missing files are errors
+	if _, err := LoadTemplateDir(templateDir); err == nil || !strings.Contains(err.Error(), "failed to read schema") {
+		t.Errorf("Expected missing schema error, got %v", err)
+	}
+}
+
+func TestTemplateRegistry_LoadFromDirectory_ReportsEveryFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"good/template.json":   `{"prompt": "p"}`,
+		"good/template.html":   `<html></html>`,
+		"good/schema.json":     `{"type": "object"}`,
+		"broken/template.json": `{"prompt": "p"`,
+		"cyclic/template.json": `{"prompt": "p"}`,
+		"cyclic/template.html": `<html></html>`,
+		"cyclic/schema.json":   `{"properties": {"a": {"x-dependsOn": ["a"]}}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	registry := NewRegistry()
+	err := registry.LoadFromDirectory(tmpDir)
+	if err == nil {
+		t.Fatal("Expected an error for the broken templates")
+	}
+	for _, want := range []string{filepath.Join(tmpDir, "broken"), "invalid template definition", "dependency cycle"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+	if _, err := registry.Get("good"); err != nil {
+		t.Errorf("Expected the valid template to load despite the failures: %v", err)
+	}
+}
+
+func TestTemplateRegistry_LoadFromDirectory_Assets(t *testing.T) {
+	tmpDir := t.TempDir()
+	templateDir := filepath.Join(tmpDir, "site")
+	files := map[string]string{
+		"template.json":          `{"prompt": "p"}`,
+		"template.html":          `<html><link href="style.css"></html>`,
+		"schema.json":            `{"type": "object"}`,
+		"style.css":              `body { background: url(img/logo.svg); }`,
+		"img/logo.svg":           `<svg/>`,
+		"README.md":              "# Site",
+		"fixtures/fields.json":   `{}`,
+		"fixtures/fixture.css":   `p {}`,
+		"fixtures/expected.html": `<html></html>`,
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tmpl, err := LoadTemplateDir(templateDir)
+	if err != nil {
+		t.Fatalf("Failed to load template: %v", err)
+	}
+	if len(tmpl.Assets) != 2 {
+		t.Fatalf("Expected the stylesheet and the image as assets, got %v", tmpl.Assets)
+	}
+	if string(tmpl.Assets["style.css"]) != files["style.css"] || string(tmpl.Assets["img/logo.svg"]) != files["img/logo.svg"] {
+		t.Errorf("Unexpected assets: %v", tmpl.Assets)
+	}
+}
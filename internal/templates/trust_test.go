@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karolswdev/docloom/internal/trust"
+)
+
+func TestTemplateRegistry_LoadFromDirectory_TrustPolicy(t *testing.T) {
+	publicKey, privateKey, err := trust.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to decode key: %v", err)
+	}
+	verifier, err := trust.NewVerifier(trust.PolicyStrict, []trust.Key{{Name: "platform", PublicKey: publicKey}})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	trust.SetDefault(verifier)
+	t.Cleanup(func() { trust.SetDefault(nil) })
+
+	tmpDir := t.TempDir()
+	template := func(name string) map[string]string {
+		return map[string]string{
+			"template.json": `{"name": "` + name + `", "description": "d", "prompt": "p"}`,
+			"template.html": `<h1><!-- data-field="title" --></h1>`,
+			"schema.json":   `{"type": "object", "properties": {"title": {"type": "string"}}}`,
+		}
+	}
+	// A pack of two templates signed as a whole, and an unsigned template
+	writeTemplateFiles(t, filepath.Join(tmpDir, "ops", "runbook"), template("runbook"))
+	writeTemplateFiles(t, filepath.Join(tmpDir, "ops", "postmortem"), template("postmortem"))
+	writeTemplateFiles(t, filepath.Join(tmpDir, "adhoc"), template("adhoc"))
+	if _, err := trust.Sign(filepath.Join(tmpDir, "ops"), "platform", key); err != nil {
+		t.Fatalf("Failed to sign pack: %v", err)
+	}
+
+	registry := NewRegistry()
+	err = registry.LoadFromDirectory(tmpDir)
+	if err == nil || !strings.Contains(err.Error(), "refusing template pack") {
+		t.Errorf("Expected the unsigned template to be refused, got %v", err)
+	}
+	for _, name := range []string{"runbook", "postmortem"} {
+		if _, err := registry.Get(name); err != nil {
+			t.Errorf("Expected signed template %s to load: %v", name, err)
+		}
+	}
+	if _, err := registry.Get("adhoc"); err == nil {
+		t.Error("Expected the unsigned template not to load")
+	}
+
+	if _, err := LoadTemplateDir(filepath.Join(tmpDir, "adhoc")); err == nil {
+		t.Error("Expected LoadTemplateDir to refuse the unsigned template")
+	}
+}
@@ -0,0 +1,145 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/render"
+)
+
+// RenderKeyword is the schema extension marking a field that the template HTML does
+// not render, for example {"type": "string", "x-render": false} for a field that
+// only names the output file or feeds a rule. Placeholder verification does not
+// report such fields, nor the fields within them, as unused.
+const RenderKeyword = "x-render"
+
+// placeholderIndexPattern matches the array indices and wildcards of a placeholder path
+var placeholderIndexPattern = regexp.MustCompile(`\[(\d+|\*)\]`)
+
+// PlaceholderError reports the data-field placeholders of a template's HTML that
+// name no schema field, which would be left in the rendered document, and the
+// schema fields that no placeholder renders, which the model would generate for
+// nothing.
+type PlaceholderError struct {
+	Template string
+	Unmapped []string // Placeholders naming no schema field
+	Unused   []string // Schema fields rendered by no placeholder, outermost only
+}
+
+func (e *PlaceholderError) Error() string {
+	var problems []string
+	if len(e.Unmapped) > 0 {
+		problems = append(problems, "placeholders without schema fields: "+strings.Join(e.Unmapped, ", "))
+	}
+	if len(e.Unused) > 0 {
+		problems = append(problems, "schema fields without placeholders: "+strings.Join(e.Unused, ", "))
+	}
+	return fmt.Sprintf("template %s: %s", e.Template, strings.Join(problems, "; "))
+}
+
+// VerifyPlaceholders cross-checks the data-field placeholders of the template HTML
+// against its schema. A placeholder must name a schema field; array indices and
+// wildcards ([0], [*]) address the array's items and map wildcards (.*) the
+// schema's additionalProperties. A schema field is rendered by a placeholder naming
+// it, a field within it or one containing it, such as a placeholder rendering a
+// whole object. It returns a *PlaceholderError when the two do not match.
+func VerifyPlaceholders(tmpl *Template) error {
+	var schema map[string]interface{}
+	if len(tmpl.Schema) > 0 {
+		if err := json.Unmarshal(tmpl.Schema, &schema); err != nil {
+			return fmt.Errorf("template %s: invalid schema: %w", tmpl.Name, err)
+		}
+	}
+	fields := make(map[string]bool) // Whether each field path is rendered
+	collectRenderedFields(schema, "", true, fields)
+
+	placeholders := render.Placeholders(tmpl.HTMLContent)
+	paths := make([]string, len(placeholders))
+	verification := &PlaceholderError{Template: tmpl.Name}
+	for idx, placeholder := range placeholders {
+		paths[idx] = placeholderIndexPattern.ReplaceAllString(placeholder, "[]")
+		if _, ok := fields[paths[idx]]; !ok {
+			verification.Unmapped = append(verification.Unmapped, placeholder)
+		}
+	}
+
+	unused := make(map[string]bool)
+	for field, rendered := range fields {
+		if rendered && !placeholderRenders(paths, field) {
+			unused[field] = true
+		}
+	}
+	for field := range unused {
+		if !unused[parentField(field)] {
+			verification.Unused = append(verification.Unused, field)
+		}
+	}
+	sort.Strings(verification.Unused)
+
+	if len(verification.Unmapped)+len(verification.Unused) > 0 {
+		return verification
+	}
+	return nil
+}
+
+// collectRenderedFields records the field paths of schema below prefix, with
+// whether the HTML is expected to render each. Array items are addressed as
+// path[] and map entries as path.*.
+func collectRenderedFields(schema map[string]interface{}, prefix string, rendered bool, fields map[string]bool) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, property := range properties {
+		sub, _ := property.(map[string]interface{})
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		subRendered := rendered && sub[RenderKeyword] != false
+		fields[path] = subRendered
+		collectNestedFields(sub, path, subRendered, fields)
+	}
+}
+
+// collectNestedFields records the fields within the field schema at path: its
+// properties, array items and map entries.
+func collectNestedFields(schema map[string]interface{}, path string, rendered bool, fields map[string]bool) {
+	collectRenderedFields(schema, path, rendered, fields)
+	if items, ok := schema["items"].(map[string]interface{}); ok || schema["type"] == "array" {
+		fields[path+"[]"] = rendered
+		collectNestedFields(items, path+"[]", rendered, fields)
+	}
+	if entries, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+		fields[path+".*"] = rendered
+		collectNestedFields(entries, path+".*", rendered, fields)
+	}
+}
+
+// placeholderRenders reports whether one of the placeholder paths renders field:
+// names it, a field within it or a field containing it.
+func placeholderRenders(paths []string, field string) bool {
+	for _, path := range paths {
+		if path == field || isWithin(path, field) || isWithin(field, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether the field at path is nested in the field at parent.
+func isWithin(path, parent string) bool {
+	return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+}
+
+// parentField returns the path of the field containing the field at path, or an
+// empty string for a top-level field.
+func parentField(path string) string {
+	if strings.HasSuffix(path, "[]") {
+		return strings.TrimSuffix(path, "[]")
+	}
+	if idx := strings.LastIndexByte(path, '.'); idx >= 0 {
+		return path[:idx]
+	}
+	return ""
+}
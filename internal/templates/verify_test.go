@@ -0,0 +1,46 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyPlaceholders(t *testing.T) {
+	schema := json.RawMessage(`{"type": "object", "properties": {
+		"title": {"type": "string"},
+		"project": {"type": "object", "properties": {"name": {"type": "string"}, "start": {"type": "string"}}},
+		"components": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string"}, "role": {"type": "string"}}}},
+		"owners": {"type": "object", "additionalProperties": {"type": "object", "properties": {"email": {"type": "string"}}}},
+		"slug": {"type": "string", "x-render": false},
+		"risks": {"type": "array", "items": {"type": "object", "properties": {"impact": {"type": "string"}}}}
+	}}`)
+
+	t.Run("matching placeholders", func(t *testing.T) {
+		tmpl := &Template{Name: "complete", Schema: schema, HTMLContent: `<h1><!-- data-field="title" --></h1>
+<!-- data-field="project" -->
+<!-- data-repeat="components[*]" --><!-- data-field="@index" --><!-- data-field="components[*].name" --><!-- data-field="components[0].role" --><!-- /data-repeat -->
+<!-- data-repeat="owners.*" --><!-- data-field="@key" --><!-- data-field="owners.*.email" --><!-- /data-repeat -->
+<!-- data-field="risks" -->`}
+		if err := VerifyPlaceholders(tmpl); err != nil {
+			t.Errorf("Expected placeholders to match the schema, got %v", err)
+		}
+	})
+
+	t.Run("reports unmapped placeholders and outermost unused fields", func(t *testing.T) {
+		tmpl := &Template{Name: "partial", Schema: schema, HTMLContent: `<h1><!-- data-field="title" --></h1>
+<!-- data-field="project.name" --><!-- data-field="project.owner" --><!-- data-field="components[*].version" -->`}
+		err := VerifyPlaceholders(tmpl)
+		var verification *PlaceholderError
+		if !errors.As(err, &verification) {
+			t.Fatalf("Expected a PlaceholderError, got %v", err)
+		}
+		if want := []string{"components[*].version", "project.owner"}; !reflect.DeepEqual(verification.Unmapped, want) {
+			t.Errorf("Expected unmapped placeholders %v, got %v", want, verification.Unmapped)
+		}
+		if want := []string{"components[].name", "components[].role", "owners", "project.start", "risks"}; !reflect.DeepEqual(verification.Unused, want) {
+			t.Errorf("Expected unused fields %v, got %v", want, verification.Unused)
+		}
+	})
+}
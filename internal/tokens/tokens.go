@@ -0,0 +1,112 @@
+// Package tokens counts the tokens of texts with the BPE tokenizers of OpenAI
+// models, selected by model name.
+package tokens
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultEncoding is the tokenizer of models tiktoken does not know, such as those
+// of other providers, whose counts it approximates.
+const DefaultEncoding = "cl100k_base"
+
+// modelPrefixes maps newer models tiktoken does not know to their encodings.
+var modelPrefixes = map[string]string{
+	"gpt-5": "o200k_base",
+	"o1":    "o200k_base",
+	"o3":    "o200k_base",
+	"o4":    "o200k_base",
+}
+
+func init() {
+	// Use the encodings embedded in the binary rather than downloading them
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// Counter counts tokens with a BPE encoding. A nil Counter estimates counts at
+// about four characters per token.
+type Counter struct {
+	encoding string
+	bpe      *tiktoken.Tiktoken
+}
+
+var (
+	countersMu sync.Mutex
+	counters   = make(map[string]*Counter) // By encoding; loading one is expensive
+)
+
+// ForModel returns the counter of model's tokenizer. Provider prefixes such as
+// openai/ are ignored, and unknown models use DefaultEncoding. When the encoding
+// cannot be loaded, the returned nil Counter estimates counts instead.
+func ForModel(model string) *Counter {
+	return forEncoding(EncodingForModel(model))
+}
+
+// EncodingForModel returns the name of model's BPE encoding, or DefaultEncoding.
+func EncodingForModel(model string) string {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	if encoding, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return encoding
+	}
+	longest, encoding := "", DefaultEncoding
+	for _, prefixes := range []map[string]string{tiktoken.MODEL_PREFIX_TO_ENCODING, modelPrefixes} {
+		for prefix, prefixEncoding := range prefixes {
+			if strings.HasPrefix(model, prefix) && len(prefix) > len(longest) {
+				longest, encoding = prefix, prefixEncoding
+			}
+		}
+	}
+	return encoding
+}
+
+// forEncoding returns the cached counter of an encoding, loading it on first use.
+func forEncoding(encoding string) *Counter {
+	countersMu.Lock()
+	defer countersMu.Unlock()
+	if counter, ok := counters[encoding]; ok {
+		return counter
+	}
+	bpe, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		log.Warn().Err(err).Str("encoding", encoding).Msg("Failed to load tokenizer, estimating token counts")
+		counters[encoding] = nil
+		return nil
+	}
+	counter := &Counter{encoding: encoding, bpe: bpe}
+	counters[encoding] = counter
+	return counter
+}
+
+// Encoding returns the name of the counter's encoding, or an empty string for the
+// estimating nil Counter.
+func (c *Counter) Encoding() string {
+	if c == nil {
+		return ""
+	}
+	return c.encoding
+}
+
+// Count returns the number of tokens in text. Special tokens such as <|endoftext|>
+// are counted as ordinary text.
+func (c *Counter) Count(text string) int {
+	if c == nil {
+		return Estimate(text)
+	}
+	if text == "" {
+		return 0
+	}
+	return len(c.bpe.EncodeOrdinary(text))
+}
+
+// Estimate estimates the tokens of text at about four characters per token, for
+// when no tokenizer is available.
+func Estimate(text string) int {
+	return (len(text) + 3) / 4
+}
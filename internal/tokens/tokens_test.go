@@ -0,0 +1,45 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodingForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4":                  "cl100k_base",
+		"gpt-4-0613":             "cl100k_base",
+		"gpt-4o":                 "o200k_base",
+		"gpt-4o-mini":            "o200k_base",
+		"openai/gpt-4o":          "o200k_base",
+		"o3-mini":                "o200k_base",
+		"text-embedding-3-small": "cl100k_base",
+		"llama-3-70b":            DefaultEncoding,
+		"":                       DefaultEncoding,
+	}
+	for model, encoding := range tests {
+		assert.Equal(t, encoding, EncodingForModel(model), model)
+	}
+}
+
+func TestCounter_Count(t *testing.T) {
+	counter := ForModel("gpt-4")
+	assert.Equal(t, "cl100k_base", counter.Encoding())
+	assert.Equal(t, 0, counter.Count(""))
+	assert.Equal(t, 2, counter.Count("Hello world"))
+	assert.Equal(t, 51, counter.Count(strings.Repeat("This is a test. ", 10)))
+	assert.Equal(t, 7, counter.Count("<|endoftext|>"), "special tokens count as text")
+
+	assert.Same(t, counter, ForModel("gpt-3.5-turbo"), "counters are shared per encoding")
+	assert.Equal(t, "o200k_base", ForModel("gpt-4o").Encoding())
+	assert.Greater(t, ForModel("gpt-4o").Count("Special chars: © ™ 🚀 中文字符"), 0)
+}
+
+func TestCounter_NilEstimates(t *testing.T) {
+	var counter *Counter
+	assert.Equal(t, "", counter.Encoding())
+	assert.Equal(t, 3, counter.Count("Hello world"))
+	assert.Equal(t, 0, Estimate(""))
+}
@@ -0,0 +1,248 @@
+// Package track keeps documents up to date with a git branch: it follows the
+// branch in a working copy, by polling or on push webhooks, and regenerates the
+// documents affected by each new commit.
+package track
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/karolswdev/docloom/internal/ingest"
+)
+
+// Document is a document kept up to date with the tracked branch.
+type Document struct {
+	Type    string
+	Out     string
+	Sources []string // Paths in the working copy; the whole working copy when empty
+}
+
+// RunFunc regenerates a document from its sources in the working copy at commit.
+type RunFunc func(ctx context.Context, doc Document, sources []string, commit string) error
+
+// AffectedFunc reports whether a document is affected by the changes in its sources,
+// that is, whether it is missing or stale.
+type AffectedFunc func(doc Document, sources []string) (bool, error)
+
+// Update is the outcome of checking the branch for a new commit.
+type Update struct {
+	Commit      string
+	NewCommit   bool     // Whether the commit differs from the last one processed
+	Regenerated []string // Outputs of the documents regenerated
+	Unaffected  []string // Outputs of the documents whose sources did not change
+	Failed      []string // Outputs of the documents that failed to regenerate
+}
+
+// Changed reports whether the update processed a new commit or retried documents.
+func (u Update) Changed() bool {
+	return u.NewCommit || len(u.Regenerated) > 0 || len(u.Failed) > 0
+}
+
+// Tracker follows a branch and regenerates the documents affected by its commits.
+type Tracker struct {
+	checkout *Checkout
+	docs     []Document
+	run      RunFunc
+	affected AffectedFunc
+	trigger  chan struct{}
+
+	mu         sync.Mutex
+	lastCommit string
+	pending    map[string]bool // Outputs of the documents that failed to regenerate
+}
+
+// NewTracker creates a tracker keeping docs up to date with checkout's branch.
+func NewTracker(checkout *Checkout, docs []Document, run RunFunc, affected AffectedFunc) (*Tracker, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no documents to track")
+	}
+	for _, doc := range docs {
+		if doc.Type == "" || doc.Out == "" {
+			return nil, fmt.Errorf("tracked documents require a type and an output")
+		}
+	}
+	return &Tracker{
+		checkout: checkout,
+		docs:     docs,
+		run:      run,
+		affected: affected,
+		trigger:  make(chan struct{}, 1),
+		pending:  make(map[string]bool),
+	}, nil
+}
+
+// Update brings the working copy to the head of the branch and, when it moved to a
+// new commit, regenerates the affected documents. A failing document does not
+// prevent the others from being regenerated; it stays pending and is regenerated on
+// the next update, even when the branch did not move.
+func (t *Tracker) Update(ctx context.Context) (Update, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	commit, err := t.checkout.Sync(ctx)
+	if err != nil {
+		return Update{}, err
+	}
+	update := Update{Commit: commit, NewCommit: commit != t.lastCommit}
+	if !update.NewCommit && len(t.pending) == 0 {
+		return update, nil
+	}
+
+	if update.NewCommit {
+		log.Info().Str("branch", t.checkout.Branch).Str("commit", commit).Msg("Tracking new commit")
+	}
+	for _, doc := range t.docs {
+		if !update.NewCommit && !t.pending[doc.Out] {
+			continue
+		}
+		sources := t.checkout.Sources(doc.Sources)
+		affected := t.pending[doc.Out]
+		if !affected {
+			var err error
+			if affected, err = t.affected(doc, sources); err != nil {
+				log.Warn().Err(err).Str("output", doc.Out).Msg("Failed to check document; regenerating it")
+				affected = true
+			}
+		}
+		if !affected {
+			update.Unaffected = append(update.Unaffected, doc.Out)
+			continue
+		}
+		if err := t.run(ctx, doc, sources, commit); err != nil {
+			log.Error().Err(err).Str("output", doc.Out).Str("commit", commit).Msg("Failed to regenerate tracked document")
+			update.Failed = append(update.Failed, doc.Out)
+			t.pending[doc.Out] = true
+			continue
+		}
+		delete(t.pending, doc.Out)
+		update.Regenerated = append(update.Regenerated, doc.Out)
+	}
+	t.lastCommit = commit
+	return update, nil
+}
+
+// Trigger requests an update, such as on a push webhook, without waiting for it.
+func (t *Tracker) Trigger() {
+	select {
+	case t.trigger <- struct{}{}:
+	default: // An update is already pending
+	}
+}
+
+// Start updates the documents now, then on every trigger and, when interval is
+// positive, every interval, until the context is canceled. Failed updates are
+// logged and retried at the next opportunity.
+func (t *Tracker) Start(ctx context.Context, interval time.Duration, report func(Update)) error {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	log.Info().Str("repository", ingest.RedactURL(t.checkout.Repo)).Str("branch", t.checkout.Branch).
+		Int("documents", len(t.docs)).Dur("interval", interval).Msg("Tracking started")
+
+	t.Trigger()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Tracking stopped")
+			return nil
+		case <-tick:
+		case <-t.trigger:
+		}
+		update, err := t.Update(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			log.Error().Err(err).Str("branch", t.checkout.Branch).Msg("Failed to update tracked branch")
+			continue
+		}
+		if report != nil {
+			report(update)
+		}
+	}
+}
+
+// Checkout is a working copy of a branch of a repository, kept between updates.
+type Checkout struct {
+	Repo   string
+	Branch string
+	Dir    string
+}
+
+// DefaultDir returns the default directory of the working copy of repoURL.
+func DefaultDir(repoURL string) string {
+	name := strings.TrimSuffix(strings.TrimRight(repoURL, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || name == "." || name == ".." {
+		name = "repository"
+	}
+	return filepath.Join(".docloom", "track", name)
+}
+
+// Sync clones the branch into the working copy, or fetches it and resets the working
+// copy to its head, and returns the head commit. Local changes are discarded.
+func (c *Checkout) Sync(ctx context.Context) (string, error) {
+	if _, err := os.Stat(filepath.Join(c.Dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(c.Dir), 0755); err != nil {
+			return "", fmt.Errorf("failed to create working copy directory: %w", err)
+		}
+		log.Info().Str("repository", ingest.RedactURL(c.Repo)).Str("branch", c.Branch).Str("dir", c.Dir).Msg("Cloning tracked branch")
+		if _, err := c.git(ctx, "", "clone", "--quiet", "--depth", "1", "--single-branch", "--branch", c.Branch, c.Repo, c.Dir); err != nil {
+			return "", err
+		}
+	} else {
+		if _, err := c.git(ctx, c.Dir, "fetch", "--quiet", "--depth", "1", "origin", c.Branch); err != nil {
+			return "", err
+		}
+		if _, err := c.git(ctx, c.Dir, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+		if _, err := c.git(ctx, c.Dir, "clean", "--quiet", "-d", "--force"); err != nil {
+			return "", err
+		}
+	}
+	commit, err := c.git(ctx, c.Dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// Sources resolves document source paths against the working copy.
+func (c *Checkout) Sources(paths []string) []string {
+	if len(paths) == 0 {
+		return []string{c.Dir}
+	}
+	sources := make([]string, len(paths))
+	for idx, path := range paths {
+		sources[idx] = filepath.Join(c.Dir, filepath.FromSlash(path))
+	}
+	return sources
+}
+
+// git runs a git command in dir, never prompting for credentials, and returns its
+// trimmed output.
+func (c *Checkout) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204 - fixed git commands
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(strings.ReplaceAll(string(output), c.Repo, ingest.RedactURL(c.Repo)))
+		return "", fmt.Errorf("git %s %s failed: %w: %s", args[0], ingest.RedactURL(c.Repo), err, message)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
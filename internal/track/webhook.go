@@ -0,0 +1,76 @@
+package track
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxWebhookBody limits the size of webhook payloads read.
+const maxWebhookBody = 5 << 20
+
+// pushEvent is the part of a GitHub, GitLab or Gitea push payload used to ignore
+// pushes to other branches.
+type pushEvent struct {
+	Ref string `json:"ref"`
+}
+
+// WebhookHandler returns a handler of push webhooks that triggers an update when the
+// tracked branch is pushed to. When secret is set, requests must carry a GitHub-style
+// X-Hub-Signature-256 HMAC of the payload or a GitLab-style X-Gitlab-Token equal to it.
+func (t *Tracker) WebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "failed to read payload", http.StatusBadRequest)
+			return
+		}
+		if secret != "" && !authenticWebhook(r, body, secret) {
+			log.Warn().Str("remote", r.RemoteAddr).Msg("Rejected webhook with an invalid signature")
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		var event pushEvent
+		if err := json.Unmarshal(body, &event); err == nil && event.Ref != "" && event.Ref != "refs/heads/"+t.checkout.Branch {
+			log.Debug().Str("ref", event.Ref).Msg("Ignored push to another branch")
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, "ignored\n")
+			return
+		}
+		t.Trigger()
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, "update triggered\n")
+	})
+}
+
+// authenticWebhook reports whether a webhook request is signed with, or carries,
+// secret.
+func authenticWebhook(r *http.Request, body []byte, secret string) bool {
+	if token := r.Header.Get("X-Gitlab-Token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	signature, found := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+	if !found {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
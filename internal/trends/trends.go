@@ -0,0 +1,428 @@
+// Package trends compares the artifacts of an agent's runs over time, such as the
+// metrics and dependencies of a codebase analysis, and reports how they changed.
+package trends
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karolswdev/docloom/internal/agent"
+)
+
+// Artifacts compared across runs.
+const (
+	MetricsFile      = "metrics.json"
+	DependenciesFile = "dependencies.json"
+)
+
+// Directions of a metric over the period.
+const (
+	Growing   = "growing"
+	Shrinking = "shrinking"
+	Stable    = "stable"
+)
+
+// sincePattern matches periods in days or weeks, such as 90d or 12w
+var sincePattern = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// Report describes how an agent's analysis changed over a period.
+type Report struct {
+	Agent        string           `json:"agent"`
+	Since        *time.Time       `json:"since,omitempty"`
+	Runs         []Run            `json:"runs"`
+	Metrics      []MetricTrend    `json:"metrics"`
+	Dependencies *DependencyChurn `json:"dependencies,omitempty"`
+}
+
+// Run identifies a run compared in a report.
+type Run struct {
+	Time   time.Time `json:"time"`
+	Commit string    `json:"commit,omitempty"`
+}
+
+// MetricTrend is the change of a numeric metric between the first and last runs
+// reporting it. Nested metrics are named by their dot-separated path; arrays count
+// their elements.
+type MetricTrend struct {
+	Name      string    `json:"name"`
+	First     float64   `json:"first"`
+	Last      float64   `json:"last"`
+	Delta     float64   `json:"delta"`
+	Percent   *float64  `json:"percent,omitempty"` // Change relative to First; nil when First is 0
+	Direction string    `json:"direction"`
+	Series    []float64 `json:"series"` // Values in run order
+}
+
+// DependencyChurn is the change of the dependencies between the first and last runs,
+// and between consecutive runs.
+type DependencyChurn struct {
+	First   int             `json:"first"` // Number of dependencies in the first run
+	Last    int             `json:"last"`
+	Added   []string        `json:"added,omitempty"`
+	Removed []string        `json:"removed,omitempty"`
+	Changed []VersionChange `json:"changed,omitempty"`
+	Steps   []ChurnStep     `json:"steps"`
+}
+
+// VersionChange is a dependency whose version changed.
+type VersionChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ChurnStep counts the dependency changes of a run since the previous one.
+type ChurnStep struct {
+	Run     Run `json:"run"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Changed int `json:"changed"`
+}
+
+// ParseSince returns the start of the period described by value relative to now: a
+// number of days or weeks (90d, 12w), a duration (36h) or a date (2024-01-31). An
+// empty value returns the zero time, for all runs.
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if match := sincePattern.FindStringSubmatch(value); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		if match[2] == "w" {
+			n *= 7
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid period %q (use e.g. 90d, 12w, 36h or 2024-01-31)", value)
+}
+
+// Build compares the metrics and dependencies of runs, oldest first, of agentName.
+// At least two runs are needed.
+func Build(agentName string, runs []agent.HistoryRun, since time.Time) (*Report, error) {
+	if len(runs) < 2 {
+		return nil, fmt.Errorf("found %d run(s) of agent %s in the period; at least two are needed to compare", len(runs), agentName)
+	}
+	report := &Report{Agent: agentName}
+	if !since.IsZero() {
+		report.Since = &since
+	}
+	metrics := make([]map[string]float64, len(runs))
+	var dependencies []map[string]string
+	var dependencyRuns []Run
+	for idx, run := range runs {
+		report.Runs = append(report.Runs, Run{Time: run.Time, Commit: run.Commit})
+
+		if data, ok, err := readArtifact(run, MetricsFile); err != nil {
+			return nil, err
+		} else if ok {
+			var value interface{}
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, fmt.Errorf("invalid %s of run %s: %w", MetricsFile, run.Dir, err)
+			}
+			metrics[idx] = make(map[string]float64)
+			flattenMetrics("", value, metrics[idx])
+		}
+
+		if data, ok, err := readArtifact(run, DependenciesFile); err != nil {
+			return nil, err
+		} else if ok {
+			parsed, err := parseDependencies(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s of run %s: %w", DependenciesFile, run.Dir, err)
+			}
+			dependencies = append(dependencies, parsed)
+			dependencyRuns = append(dependencyRuns, report.Runs[idx])
+		}
+	}
+	report.Metrics = metricTrends(metrics)
+	if len(dependencies) >= 2 {
+		report.Dependencies = dependencyChurn(dependencies, dependencyRuns)
+	}
+	return report, nil
+}
+
+// readArtifact returns the content of the run's artifact named name, at any depth,
+// and whether the run has one.
+func readArtifact(run agent.HistoryRun, name string) ([]byte, bool, error) {
+	for _, file := range run.Files {
+		if path.Base(file) == name {
+			data, err := os.ReadFile(filepath.Join(run.Dir, filepath.FromSlash(file))) // #nosec G304 - history artifacts
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to read %s of run %s: %w", name, run.Dir, err)
+			}
+			return data, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// flattenMetrics records the numeric values below value by path; arrays count their
+// elements.
+func flattenMetrics(prefix string, value interface{}, metrics map[string]float64) {
+	switch v := value.(type) {
+	case float64:
+		if prefix != "" {
+			metrics[prefix] = v
+		}
+	case []interface{}:
+		if prefix != "" {
+			metrics[prefix] = float64(len(v))
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenMetrics(name, child, metrics)
+		}
+	}
+}
+
+// metricTrends returns the trends of the metrics reported by at least one run,
+// sorted by name.
+func metricTrends(runs []map[string]float64) []MetricTrend {
+	names := make(map[string]bool)
+	for _, metrics := range runs {
+		for name := range metrics {
+			names[name] = true
+		}
+	}
+	trends := make([]MetricTrend, 0, len(names))
+	for name := range names {
+		trend := MetricTrend{Name: name}
+		for _, metrics := range runs {
+			if value, ok := metrics[name]; ok {
+				trend.Series = append(trend.Series, value)
+			}
+		}
+		trend.First, trend.Last = trend.Series[0], trend.Series[len(trend.Series)-1]
+		trend.Delta = trend.Last - trend.First
+		if trend.First != 0 {
+			percent := math.Round(trend.Delta/math.Abs(trend.First)*1000) / 10
+			trend.Percent = &percent
+		}
+		switch {
+		case trend.Delta > 0:
+			trend.Direction = Growing
+		case trend.Delta < 0:
+			trend.Direction = Shrinking
+		default:
+			trend.Direction = Stable
+		}
+		trends = append(trends, trend)
+	}
+	sort.Slice(trends, func(i, j int) bool { return trends[i].Name < trends[j].Name })
+	return trends
+}
+
+// parseDependencies reads dependencies as a map from name to version (empty when
+// unknown). It accepts an object of names to versions, an array of names (with an
+// optional @version) or of objects with name and version, either of them optionally
+// wrapped in an object under "dependencies" or "packages".
+func parseDependencies(data []byte) (map[string]string, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	if wrapper, ok := value.(map[string]interface{}); ok {
+		for _, key := range []string{"dependencies", "packages"} {
+			if inner, ok := wrapper[key]; ok {
+				value = inner
+				break
+			}
+		}
+	}
+
+	dependencies := make(map[string]string)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, version := range v {
+			dependencies[name] = versionString(version)
+		}
+	case []interface{}:
+		for _, item := range v {
+			switch dependency := item.(type) {
+			case string:
+				name, version, _ := strings.Cut(dependency, "@")
+				if name == "" { // Scoped names such as @angular/core
+					name, version, _ = strings.Cut(dependency[1:], "@")
+					name = "@" + name
+				}
+				dependencies[name] = version
+			case map[string]interface{}:
+				name := firstString(dependency, "name", "id", "package", "include")
+				if name == "" {
+					return nil, fmt.Errorf("dependency without a name: %v", dependency)
+				}
+				dependencies[name] = versionString(dependency["version"])
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected an object or array of dependencies")
+	}
+	return dependencies, nil
+}
+
+// versionString returns a version value as a string.
+func versionString(version interface{}) string {
+	switch v := version.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// firstString returns the first non-empty string value of keys in object.
+func firstString(object map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := object[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// dependencyChurn compares the dependencies of runs, oldest first.
+func dependencyChurn(runs []map[string]string, runInfo []Run) *DependencyChurn {
+	first, last := runs[0], runs[len(runs)-1]
+	churn := &DependencyChurn{First: len(first), Last: len(last)}
+	churn.Added, churn.Removed, churn.Changed = compareDependencies(first, last)
+	for idx := 1; idx < len(runs); idx++ {
+		added, removed, changed := compareDependencies(runs[idx-1], runs[idx])
+		churn.Steps = append(churn.Steps, ChurnStep{Run: runInfo[idx], Added: len(added), Removed: len(removed), Changed: len(changed)})
+	}
+	return churn
+}
+
+// compareDependencies returns the dependencies added, removed and changed from
+// before to after, sorted by name.
+func compareDependencies(before, after map[string]string) (added, removed []string, changed []VersionChange) {
+	for name, version := range after {
+		previous, ok := before[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case previous != version:
+			changed = append(changed, VersionChange{Name: name, From: previous, To: version})
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Name < changed[j].Name })
+	return added, removed, changed
+}
+
+// Markdown renders the report as a Markdown document, which can be used as a source
+// for generating a trend report from a template.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	first, last := r.Runs[0], r.Runs[len(r.Runs)-1]
+	fmt.Fprintf(&b, "# Analysis Trends: %s\n\n", r.Agent)
+	fmt.Fprintf(&b, "Compared %d runs from %s to %s.\n", len(r.Runs), describeRun(first), describeRun(last))
+
+	b.WriteString("\n## Metrics\n\n")
+	if len(r.Metrics) == 0 {
+		fmt.Fprintf(&b, "No run has a %s.\n", MetricsFile)
+	} else {
+		for _, direction := range []string{Growing, Shrinking} {
+			var names []string
+			for _, metric := range r.Metrics {
+				if metric.Direction == direction {
+					names = append(names, metric.Name)
+				}
+			}
+			if len(names) > 0 {
+				fmt.Fprintf(&b, "%s: %s\n\n", capitalize(direction), strings.Join(names, ", "))
+			}
+		}
+		b.WriteString("| Metric | First | Last | Change | Trend |\n|---|---:|---:|---:|---|\n")
+		for _, metric := range r.Metrics {
+			change := formatNumber(metric.Delta)
+			if metric.Delta > 0 {
+				change = "+" + change
+			}
+			if metric.Percent != nil {
+				change += fmt.Sprintf(" (%+.1f%%)", *metric.Percent)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", metric.Name, formatNumber(metric.First), formatNumber(metric.Last), change, metric.Direction)
+		}
+	}
+
+	b.WriteString("\n## Dependency Churn\n\n")
+	churn := r.Dependencies
+	if churn == nil {
+		fmt.Fprintf(&b, "Fewer than two runs have a %s.\n", DependenciesFile)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Dependencies went from %d to %d: %d added, %d removed and %d changed version.\n",
+		churn.First, churn.Last, len(churn.Added), len(churn.Removed), len(churn.Changed))
+	if len(churn.Added) > 0 {
+		fmt.Fprintf(&b, "\nAdded: %s\n", strings.Join(churn.Added, ", "))
+	}
+	if len(churn.Removed) > 0 {
+		fmt.Fprintf(&b, "\nRemoved: %s\n", strings.Join(churn.Removed, ", "))
+	}
+	if len(churn.Changed) > 0 {
+		b.WriteString("\nVersion changes:\n\n")
+		for _, change := range churn.Changed {
+			fmt.Fprintf(&b, "- %s: %s → %s\n", change.Name, orNone(change.From), orNone(change.To))
+		}
+	}
+	b.WriteString("\n| Run | Added | Removed | Changed |\n|---|---:|---:|---:|\n")
+	for _, step := range churn.Steps {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d |\n", describeRun(step.Run), step.Added, step.Removed, step.Changed)
+	}
+	return b.String()
+}
+
+// describeRun returns the date and, when known, the abbreviated commit of a run.
+func describeRun(run Run) string {
+	description := run.Time.Format("2006-01-02")
+	if run.Commit != "" {
+		commit := run.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		description += " (" + commit + ")"
+	}
+	return description
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func orNone(version string) string {
+	if version == "" {
+		return "unversioned"
+	}
+	return version
+}
@@ -0,0 +1,136 @@
+package trends
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/karolswdev/docloom/internal/agent"
+)
+
+// record adds a run of the csharp-analyzer agent with artifacts to history.
+func record(t *testing.T, history *agent.History, when time.Time, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	_, err := history.Record("csharp-analyzer", t.TempDir(), dir, when)
+	require.NoError(t, err)
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	for value, expected := range map[string]time.Time{
+		"":           {},
+		"90d":        now.AddDate(0, 0, -90),
+		"2w":         now.AddDate(0, 0, -14),
+		"36h":        now.Add(-36 * time.Hour),
+		"2024-01-31": time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	} {
+		since, err := ParseSince(value, now)
+		require.NoError(t, err, value)
+		assert.True(t, expected.Equal(since), "%s: %v", value, since)
+	}
+	_, err := ParseSince("last quarter", now)
+	assert.ErrorContains(t, err, "invalid period")
+}
+
+func TestBuild(t *testing.T) {
+	history := agent.NewHistory(t.TempDir())
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	record(t, history, start, map[string]string{
+		"metrics.json":      `{"technical_debt": {"hours": 40, "todos": 12}, "coverage": 61.5, "hotspots": ["A", "B"], "name": "api"}`,
+		"dependencies.json": `[{"name": "Newtonsoft.Json", "version": "12.0.3"}, {"name": "Serilog", "version": "2.10.0"}, {"name": "Dapper", "version": "2.0.123"}]`,
+	})
+	record(t, history, start.AddDate(0, 0, 30), map[string]string{
+		"metrics.json":      `{"technical_debt": {"hours": 44, "todos": 12}, "coverage": 60}`,
+		"dependencies.json": `{"dependencies": {"Newtonsoft.Json": "13.0.1", "Serilog": "2.10.0", "Dapper": "2.0.123"}}`,
+	})
+	record(t, history, start.AddDate(0, 0, 60), map[string]string{
+		"metrics.json":      `{"technical_debt": {"hours": 52, "todos": 9}, "coverage": 58, "hotspots": ["A"]}`,
+		"dependencies.json": `["Newtonsoft.Json@13.0.1", "Serilog@3.0.0", "Polly@8.0.0"]`,
+	})
+
+	runs, err := history.Runs("csharp-analyzer", time.Time{})
+	require.NoError(t, err)
+	report, err := Build("csharp-analyzer", runs, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, report.Runs, 3)
+
+	metrics := make(map[string]MetricTrend)
+	for _, metric := range report.Metrics {
+		metrics[metric.Name] = metric
+	}
+	assert.Len(t, metrics, 4) // Strings are not metrics
+	hours := metrics["technical_debt.hours"]
+	assert.Equal(t, []float64{40, 44, 52}, hours.Series)
+	assert.Equal(t, 12.0, hours.Delta)
+	assert.Equal(t, Growing, hours.Direction)
+	require.NotNil(t, hours.Percent)
+	assert.Equal(t, 30.0, *hours.Percent)
+	assert.Equal(t, Shrinking, metrics["technical_debt.todos"].Direction)
+	assert.Equal(t, Shrinking, metrics["coverage"].Direction)
+	// Metrics missing from some runs compare the runs reporting them
+	assert.Equal(t, []float64{2, 1}, metrics["hotspots"].Series)
+
+	churn := report.Dependencies
+	require.NotNil(t, churn)
+	assert.Equal(t, 3, churn.First)
+	assert.Equal(t, 3, churn.Last)
+	assert.Equal(t, []string{"Polly"}, churn.Added)
+	assert.Equal(t, []string{"Dapper"}, churn.Removed)
+	assert.Equal(t, []VersionChange{
+		{Name: "Newtonsoft.Json", From: "12.0.3", To: "13.0.1"},
+		{Name: "Serilog", From: "2.10.0", To: "3.0.0"},
+	}, churn.Changed)
+	require.Len(t, churn.Steps, 2)
+	assert.Equal(t, ChurnStep{Run: report.Runs[1], Changed: 1}, churn.Steps[0])
+	assert.Equal(t, ChurnStep{Run: report.Runs[2], Added: 1, Removed: 1, Changed: 1}, churn.Steps[1])
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "# Analysis Trends: csharp-analyzer")
+	assert.Contains(t, markdown, "Compared 3 runs from 2024-03-01 to 2024-04-30.")
+	assert.Contains(t, markdown, "Growing: technical_debt.hours")
+	assert.Contains(t, markdown, "| technical_debt.hours | 40 | 52 | +12 (+30.0%) | growing |")
+	assert.Contains(t, markdown, "Dependencies went from 3 to 3: 1 added, 1 removed and 2 changed version.")
+	assert.Contains(t, markdown, "- Serilog: 2.10.0 → 3.0.0")
+}
+
+func TestBuild_Errors(t *testing.T) {
+	history := agent.NewHistory(t.TempDir())
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	record(t, history, start, map[string]string{"metrics.json": `{"debt": 1}`})
+
+	runs, err := history.Runs("csharp-analyzer", time.Time{})
+	require.NoError(t, err)
+	_, err = Build("csharp-analyzer", runs, time.Time{})
+	assert.ErrorContains(t, err, "at least two are needed")
+
+	record(t, history, start.AddDate(0, 0, 1), map[string]string{"metrics.json": `{"debt": `})
+	runs, err = history.Runs("csharp-analyzer", time.Time{})
+	require.NoError(t, err)
+	_, err = Build("csharp-analyzer", runs, time.Time{})
+	assert.ErrorContains(t, err, "invalid metrics.json")
+}
+
+func TestParseDependencies(t *testing.T) {
+	for input, expected := range map[string]map[string]string{
+		`{"Serilog": "3.0.0", "Polly": null}`:                 {"Serilog": "3.0.0", "Polly": ""},
+		`["@angular/core@17.0.0", "lodash", "react@18.2.0"]`:  {"@angular/core": "17.0.0", "lodash": "", "react": "18.2.0"},
+		`{"packages": [{"id": "Serilog", "version": "3.0"}]}`: {"Serilog": "3.0"},
+		`[{"include": "Polly", "version": 8}]`:                {"Polly": "8"},
+	} {
+		dependencies, err := parseDependencies([]byte(input))
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, dependencies, input)
+	}
+	_, err := parseDependencies([]byte(`[{"version": "1.0"}]`))
+	assert.ErrorContains(t, err, "without a name")
+	_, err = parseDependencies([]byte(`"Serilog"`))
+	assert.Error(t, err)
+}
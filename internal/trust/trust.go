@@ -0,0 +1,329 @@
+// Package trust verifies publisher signatures of template packs and agent
+// definitions against the keys an organization trusts, under a trust policy.
+// Templates and agents carry prompts and commands that affect outputs and security,
+// so a strict policy refuses those that are unsigned or signed by an unknown key.
+package trust
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Trust policies.
+const (
+	PolicyOff    = "off"    // Signatures are not checked (default)
+	PolicyWarn   = "warn"   // Unsigned or untrusted packs are used with a warning
+	PolicyStrict = "strict" // Unsigned or untrusted packs are refused
+)
+
+// SignatureFile is the signature of a template pack, in the pack directory. An agent
+// definition file is signed by <file>.sig next to it.
+const SignatureFile = "docloom.sig"
+
+// SignatureSuffix is appended to the name of a signed file to name its signature.
+const SignatureSuffix = ".sig"
+
+// signatureAlgorithm is the only signature algorithm supported.
+const signatureAlgorithm = "ed25519"
+
+// digestHeader starts the digest of the signed files, versioning its format.
+const digestHeader = "docloom-signature-v1\n"
+
+// ErrUnsigned is returned for packs and files without a signature.
+var ErrUnsigned = errors.New("no signature")
+
+// Signature is the content of a signature file.
+type Signature struct {
+	Publisher string `json:"publisher"` // Name of the publisher's key
+	Algorithm string `json:"algorithm"`
+	Signature string `json:"signature"` // Base64-encoded signature of the files' digest
+}
+
+// Key is a publisher key trusted by the organization.
+type Key struct {
+	Name      string `yaml:"name" json:"name"`
+	PublicKey string `yaml:"public_key" json:"public_key"` // Base64-encoded Ed25519 public key
+}
+
+// Verifier checks signatures against trusted keys under a policy.
+type Verifier struct {
+	policy string
+	keys   map[string]ed25519.PublicKey
+}
+
+// NewVerifier returns a verifier applying policy (off when empty) with keys.
+func NewVerifier(policy string, keys []Key) (*Verifier, error) {
+	if policy == "" {
+		policy = PolicyOff
+	}
+	if policy != PolicyOff && policy != PolicyWarn && policy != PolicyStrict {
+		return nil, fmt.Errorf("invalid trust policy %q (use %s, %s or %s)", policy, PolicyOff, PolicyWarn, PolicyStrict)
+	}
+	v := &Verifier{policy: policy, keys: make(map[string]ed25519.PublicKey, len(keys))}
+	for _, key := range keys {
+		if key.Name == "" {
+			return nil, fmt.Errorf("trusted key without a name")
+		}
+		publicKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key.PublicKey))
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("trusted key %s: public key must be a base64-encoded Ed25519 key", key.Name)
+		}
+		v.keys[key.Name] = ed25519.PublicKey(publicKey)
+	}
+	return v, nil
+}
+
+// Policy returns the verifier's trust policy.
+func (v *Verifier) Policy() string {
+	return v.policy
+}
+
+// VerifyDir checks the signature of the template pack in dir, covering every file
+// below it, and returns the publisher.
+func (v *Verifier) VerifyDir(dir string) (string, error) {
+	files, err := packFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	return v.verify(filepath.Join(dir, SignatureFile), dir, files)
+}
+
+// VerifyFile checks the signature of a single file, such as an agent definition, and
+// returns the publisher.
+func (v *Verifier) VerifyFile(path string) (string, error) {
+	return v.verify(path+SignatureSuffix, filepath.Dir(path), []string{filepath.Base(path)})
+}
+
+// Verify checks the signature of the template pack or file at path.
+func (v *Verifier) Verify(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return v.VerifyDir(path)
+	}
+	return v.VerifyFile(path)
+}
+
+// verify checks the signature in signaturePath of files, relative to dir.
+func (v *Verifier) verify(signaturePath, dir string, files []string) (string, error) {
+	data, err := os.ReadFile(signaturePath) // #nosec G304 - signatures are next to user-installed packs
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrUnsigned
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read signature: %w", err)
+	}
+	var signature Signature
+	if err := json.Unmarshal(data, &signature); err != nil {
+		return "", fmt.Errorf("invalid signature %s: %w", signaturePath, err)
+	}
+	if signature.Algorithm != signatureAlgorithm {
+		return "", fmt.Errorf("unsupported signature algorithm %q", signature.Algorithm)
+	}
+	key, ok := v.keys[signature.Publisher]
+	if !ok {
+		return "", fmt.Errorf("signed by untrusted publisher %q", signature.Publisher)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature %s: %w", signaturePath, err)
+	}
+	message, err := digest(dir, files)
+	if err != nil {
+		return "", err
+	}
+	if !ed25519.Verify(key, message, sig) {
+		return "", fmt.Errorf("signature by %s does not match the content; it was modified after signing", signature.Publisher)
+	}
+	return signature.Publisher, nil
+}
+
+// Check applies the policy to the result of a verification of what, a description
+// such as "template pack ./templates/runbook": it returns nil when the signature is
+// valid or the policy is off or warn, logging a warning for the latter.
+func (v *Verifier) Check(what string, publisher string, err error) error {
+	switch {
+	case v == nil || v.policy == PolicyOff:
+		return nil
+	case err == nil:
+		log.Debug().Str("publisher", publisher).Msgf("Verified signature of %s", what)
+		return nil
+	case v.policy == PolicyWarn:
+		log.Warn().Err(err).Msgf("Using %s without a trusted signature", what)
+		return nil
+	}
+	return fmt.Errorf("refusing %s under the strict trust policy: %w", what, err)
+}
+
+// Sign writes the signature of the template pack in dir, covering every file below
+// it, or of the single file at path, with the publisher's private key.
+func Sign(path, publisher string, privateKey ed25519.PrivateKey) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	dir, files, signaturePath := filepath.Dir(path), []string{filepath.Base(path)}, path+SignatureSuffix
+	if info.IsDir() {
+		dir, signaturePath = path, filepath.Join(path, SignatureFile)
+		if files, err = packFiles(path); err != nil {
+			return "", err
+		}
+	}
+	message, err := digest(dir, files)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(Signature{
+		Publisher: publisher,
+		Algorithm: signatureAlgorithm,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, message)),
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(signaturePath, append(data, '\n'), 0o644); err != nil { // #nosec G306 - signatures are published
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+	return signaturePath, nil
+}
+
+// GenerateKey returns a new publisher key pair, base64-encoded.
+func GenerateKey() (publicKey, privateKey string, err error) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(public), base64.StdEncoding.EncodeToString(private), nil
+}
+
+// LoadPrivateKey reads a base64-encoded private key written by GenerateKey.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - the key path is given by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%s is not a base64-encoded Ed25519 private key", path)
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+// packFiles returns the files of the pack in dir, slash-separated and sorted. Hidden
+// files and directories such as .git, and signature files, are not part of a pack.
+func packFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || entry.Name() == SignatureFile {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pack files: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// digest returns the signed message for files relative to dir: their SHA-256 hashes
+// and paths, one per line.
+func digest(dir string, files []string) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(digestHeader)
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file))) // #nosec G304 - files of the pack being verified
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		sum := sha256.Sum256(data)
+		b.WriteString(hex.EncodeToString(sum[:]) + "  " + file + "\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// PackDir returns the directory of the pack holding dir: the closest of dir and its
+// parents up to root that holds a signature file, or dir when none does. A pack can
+// thus be signed as a whole, for example a repository of several templates.
+func PackDir(dir, root string) string {
+	dir, root = filepath.Clean(dir), filepath.Clean(root)
+	for candidate := dir; ; candidate = filepath.Dir(candidate) {
+		if _, err := os.Stat(filepath.Join(candidate, SignatureFile)); err == nil {
+			return candidate
+		}
+		if candidate == root || filepath.Dir(candidate) == candidate || !strings.HasPrefix(candidate, root) {
+			return dir
+		}
+	}
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultVerifier *Verifier
+)
+
+// SetDefault sets the verifier applied when template packs and agents are loaded;
+// nil turns verification off.
+func SetDefault(v *Verifier) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultVerifier = v
+}
+
+// Default returns the verifier set by SetDefault, or nil.
+func Default() *Verifier {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultVerifier
+}
+
+// CheckDir verifies the template pack in dir with the default verifier and applies
+// its policy; see Check.
+func CheckDir(dir string) error {
+	v := Default()
+	if v == nil || v.policy == PolicyOff {
+		return nil
+	}
+	publisher, err := v.VerifyDir(dir)
+	return v.Check("template pack "+dir, publisher, err)
+}
+
+// CheckFile verifies the file at path, described by kind such as "agent definition",
+// with the default verifier and applies its policy; see Check.
+func CheckFile(kind, path string) error {
+	v := Default()
+	if v == nil || v.policy == PolicyOff {
+		return nil
+	}
+	publisher, err := v.VerifyFile(path)
+	return v.Check(kind+" "+path, publisher, err)
+}
@@ -0,0 +1,700 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file implements the small expression language of consistency rules:
+//
+//	sum(components[*].count) == total
+//	risk.level != "high" || status in ["red", "amber"]
+//	count(items) > 0 && min(items[*].score) >= 1
+//
+// Expressions combine field paths (dot-separated, with [n] indexes and [*] to map
+// over arrays), number, string, boolean and null literals, list literals, the
+// operators || && ! == != < <= > >= in + - * / and the functions sum, count, min,
+// max, any, all and lower. Paths that do not exist evaluate to null.
+
+// expr is a compiled expression node.
+type expr interface {
+	eval(root interface{}) (interface{}, error)
+}
+
+// compileExpr parses an expression.
+func compileExpr(source string) (expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return e, nil
+}
+
+// token kinds
+const (
+	tokNumber = iota
+	tokString
+	tokIdent
+	tokOp
+	tokEOF
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+// tokenize splits an expression into tokens.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i])})
+		case r == '"' || r == '\'':
+			start := i + 1
+			i++
+			for i < len(runes) && runes[i] != r {
+				i++
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{tokString, string(runes[start:i])})
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i])})
+		default:
+			if i+1 < len(runes) {
+				if two := string(runes[i : i+2]); two == "==" || two == "!=" || two == "<=" || two == ">=" || two == "&&" || two == "||" {
+					tokens = append(tokens, token{tokOp, two})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("<>!+-*/()[].,", r) {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			tokens = append(tokens, token{tokOp, string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// parser is a recursive descent parser over tokens.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{kind: tokEOF, text: "end of expression"}
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// accept consumes the next token if it is the operator or keyword text.
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); (t.kind == tokOp || t.kind == tokIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("expected %q, found %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.accept(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &compareExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek().text
+		if p.peek().kind != tokOp || (op != "+" && op != "-") {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek().text
+		if p.peek().kind != tokOp || (op != "*" && op != "/") {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.accept("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmeticExpr{op: "-", left: &literalExpr{value: 0.0}, right: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return &literalExpr{value: n}, nil
+	case tokString:
+		return &literalExpr{value: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			e, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		case "[":
+			list := &listExpr{}
+			for !p.accept("]") {
+				if len(list.items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+			}
+			return list, nil
+		}
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalExpr{value: true}, nil
+		case "false":
+			return &literalExpr{value: false}, nil
+		case "null":
+			return &literalExpr{value: nil}, nil
+		}
+		if p.accept("(") {
+			return p.parseCall(t.text)
+		}
+		return p.parsePath(t.text)
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+func (p *parser) parseCall(name string) (expr, error) {
+	if _, ok := functions[name]; !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	call := &callExpr{name: name}
+	for !p.accept(")") {
+		if len(call.args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+	}
+	if len(call.args) != 1 {
+		return nil, fmt.Errorf("%s takes one argument", name)
+	}
+	return call, nil
+}
+
+// pathSegment is a field name, an array index, or the [*] wildcard (index -1).
+type pathSegment struct {
+	field string
+	index int
+}
+
+func (p *parser) parsePath(first string) (expr, error) {
+	path := &pathExpr{segments: []pathSegment{{field: first}}}
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name after '.', found %q", t.text)
+			}
+			path.segments = append(path.segments, pathSegment{field: t.text})
+		case p.accept("["):
+			segment := pathSegment{index: -1}
+			if !p.accept("*") {
+				t := p.next()
+				n, err := strconv.Atoi(t.text)
+				if t.kind != tokNumber || err != nil || n < 0 {
+					return nil, fmt.Errorf("expected index or * in brackets, found %q", t.text)
+				}
+				segment.index = n
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			path.segments = append(path.segments, segment)
+		default:
+			return path, nil
+		}
+	}
+}
+
+type literalExpr struct{ value interface{} }
+
+func (e *literalExpr) eval(interface{}) (interface{}, error) { return e.value, nil }
+
+type listExpr struct{ items []expr }
+
+func (e *listExpr) eval(root interface{}) (interface{}, error) {
+	values := make([]interface{}, 0, len(e.items))
+	for _, item := range e.items {
+		v, err := item.eval(root)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type pathExpr struct{ segments []pathSegment }
+
+// eval resolves the path. After a [*] wildcard the remaining segments are applied to
+// every array element and the results collected into a list.
+func (e *pathExpr) eval(root interface{}) (interface{}, error) {
+	return resolvePath(root, e.segments), nil
+}
+
+func resolvePath(value interface{}, segments []pathSegment) interface{} {
+	for i, segment := range segments {
+		if segment.field != "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = object[segment.field]
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		if segment.index >= 0 {
+			if segment.index >= len(items) {
+				return nil
+			}
+			value = items[segment.index]
+			continue
+		}
+		mapped := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			mapped = append(mapped, resolvePath(item, segments[i+1:]))
+		}
+		return mapped
+	}
+	return value
+}
+
+type notExpr struct{ operand expr }
+
+func (e *notExpr) eval(root interface{}) (interface{}, error) {
+	v, err := e.operand.eval(root)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+type logicalExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *logicalExpr) eval(root interface{}) (interface{}, error) {
+	left, err := e.left.eval(root)
+	if err != nil {
+		return nil, err
+	}
+	if e.op == "&&" && !truthy(left) {
+		return false, nil
+	}
+	if e.op == "||" && truthy(left) {
+		return true, nil
+	}
+	right, err := e.right.eval(root)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(right), nil
+}
+
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *compareExpr) eval(root interface{}) (interface{}, error) {
+	left, err := e.left.eval(root)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(root)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		items, ok := right.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("right side of 'in' is not a list")
+		}
+		for _, item := range items {
+			if equal(left, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	// Ordering compares two numbers or two strings
+	var cmp int
+	if l, ok := left.(float64); ok {
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare number with %s", describe(right))
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	} else if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare string with %s", describe(right))
+		}
+		cmp = strings.Compare(l, r)
+	} else {
+		return nil, fmt.Errorf("cannot order %s", describe(left))
+	}
+
+	switch e.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type arithmeticExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *arithmeticExpr) eval(root interface{}) (interface{}, error) {
+	left, err := e.left.eval(root)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(root)
+	if err != nil {
+		return nil, err
+	}
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("'%s' needs numbers, got %s and %s", e.op, describe(left), describe(right))
+	}
+	switch e.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	}
+}
+
+type callExpr struct {
+	name string
+	args []expr
+}
+
+func (e *callExpr) eval(root interface{}) (interface{}, error) {
+	arg, err := e.args[0].eval(root)
+	if err != nil {
+		return nil, err
+	}
+	return functions[e.name](arg)
+}
+
+// functions are the built-in functions of rule expressions.
+var functions = map[string]func(interface{}) (interface{}, error){
+	"sum": func(v interface{}) (interface{}, error) {
+		numbers, err := numberList(v, "sum")
+		total := 0.0
+		for _, n := range numbers {
+			total += n
+		}
+		return total, err
+	},
+	"count": func(v interface{}) (interface{}, error) {
+		switch val := v.(type) {
+		case []interface{}:
+			return float64(len(val)), nil
+		case map[string]interface{}:
+			return float64(len(val)), nil
+		case string:
+			return float64(len([]rune(val))), nil
+		case nil:
+			return 0.0, nil
+		}
+		return nil, fmt.Errorf("count needs a list, object or string, got %s", describe(v))
+	},
+	"min": func(v interface{}) (interface{}, error) {
+		return extreme(v, "min", func(a, b float64) bool { return a < b })
+	},
+	"max": func(v interface{}) (interface{}, error) {
+		return extreme(v, "max", func(a, b float64) bool { return a > b })
+	},
+	"any": func(v interface{}) (interface{}, error) {
+		items, _ := v.([]interface{})
+		for _, item := range items {
+			if truthy(item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+	"all": func(v interface{}) (interface{}, error) {
+		items, _ := v.([]interface{})
+		for _, item := range items {
+			if !truthy(item) {
+				return false, nil
+			}
+		}
+		return true, nil
+	},
+	"lower": func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("lower needs a string, got %s", describe(v))
+		}
+		return strings.ToLower(s), nil
+	},
+}
+
+// numberList returns the numbers of a list; null entries are skipped.
+func numberList(v interface{}, fn string) ([]float64, error) {
+	if v == nil {
+		return nil, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s needs a list, got %s", fn, describe(v))
+	}
+	numbers := make([]float64, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		n, ok := item.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s needs numbers, got %s", fn, describe(item))
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// extreme returns the smallest or largest number of a list, or null for an empty list.
+func extreme(v interface{}, fn string, better func(a, b float64) bool) (interface{}, error) {
+	numbers, err := numberList(v, fn)
+	if err != nil || len(numbers) == 0 {
+		return nil, err
+	}
+	result := numbers[0]
+	for _, n := range numbers[1:] {
+		if better(n, result) {
+			result = n
+		}
+	}
+	return result, nil
+}
+
+// truthy reports whether a value counts as true: false, null, 0, "" and empty lists do not.
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return val != ""
+	case []interface{}:
+		return len(val) > 0
+	}
+	return true
+}
+
+// equal compares two values; numbers compare by value.
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// describe names the type of a value for error messages.
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
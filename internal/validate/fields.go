@@ -0,0 +1,335 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// maxExampleDepth bounds the nesting of synthesized examples, e.g. for recursive schemas.
+const maxExampleDepth = 6
+
+// FieldIssue is a schema violation narrowed to a single field, for repair prompts that
+// name what to fix instead of repeating the whole schema.
+type FieldIssue struct {
+	Field   string `json:"field"`             // JSON pointer of the field, e.g. "/risks/0/level"
+	Problem string `json:"problem"`           // The violated constraint
+	Example string `json:"example,omitempty"` // JSON of a value valid for the field; empty when none can be synthesized
+	Missing bool   `json:"missing,omitempty"` // The field is required but absent, so its content must be written
+	Remove  bool   `json:"remove,omitempty"`  // The field is not allowed and must be removed
+}
+
+// quotedNames matches the property names quoted in required and additionalProperties
+// errors, e.g. "missing properties: 'title', 'owner'".
+var quotedNames = regexp.MustCompile(`'((?:[^'\\]|\\.)*)'`)
+
+// pointerEscaper escapes a property name for use in a JSON pointer.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// FieldIssues validates jsonStr against the schema and returns its violations by
+// field, each with an example of a valid value synthesized from the field's schema.
+// ok is false when the violations cannot all be narrowed to fields, such as when the
+// JSON does not parse or a value fails an anyOf or oneOf; callers should then fall
+// back to the full schema. A valid document has no issues.
+func (v *Validator) FieldIssues(jsonStr string, schemaStr string) (issues []FieldIssue, ok bool, err error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+		return nil, false, nil
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal([]byte(schemaStr), &root); err != nil {
+		return nil, false, fmt.Errorf("invalid schema: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	if err := compiler.AddResource("schema.json", bytes.NewReader([]byte(schemaStr))); err != nil {
+		return nil, false, fmt.Errorf("failed to add schema resource: %w", err)
+	}
+	schema, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	validationErr := schema.Validate(data)
+	if validationErr == nil {
+		return nil, true, nil
+	}
+	var schemaErr *jsonschema.ValidationError
+	if !errors.As(validationErr, &schemaErr) {
+		return nil, false, nil
+	}
+	for _, leaf := range leafErrors(schemaErr) {
+		leafIssues, narrowed := fieldIssues(leaf, root)
+		if !narrowed {
+			return nil, false, nil
+		}
+		issues = append(issues, leafIssues...)
+	}
+	return issues, true, nil
+}
+
+// fieldIssues narrows a leaf validation error to the fields it concerns.
+func fieldIssues(leaf *jsonschema.ValidationError, root map[string]interface{}) ([]FieldIssue, bool) {
+	if strings.Contains(leaf.KeywordLocation, "/anyOf/") || strings.Contains(leaf.KeywordLocation, "/oneOf/") {
+		return nil, false
+	}
+	location := leaf.AbsoluteKeywordLocation
+	idx := strings.LastIndex(location, "#")
+	if idx < 0 {
+		return nil, false
+	}
+	location = location[idx+1:]
+	keywordIdx := strings.LastIndex(location, "/")
+	if keywordIdx < 0 {
+		return nil, false
+	}
+	keyword, schemaPointer := location[keywordIdx+1:], location[:keywordIdx]
+
+	switch keyword {
+	case "required":
+		var issues []FieldIssue
+		for _, name := range quotedPropertyNames(leaf.Message) {
+			escaped := pointerEscaper.Replace(name)
+			issue := FieldIssue{Field: leaf.InstanceLocation + "/" + escaped, Problem: "required property is missing", Missing: true}
+			issue.Example = exampleJSON(resolvePointer(root, schemaPointer+"/properties/"+escaped), root)
+			issues = append(issues, issue)
+		}
+		return issues, len(issues) > 0
+	case "additionalProperties":
+		var issues []FieldIssue
+		for _, name := range quotedPropertyNames(leaf.Message) {
+			issues = append(issues, FieldIssue{Field: leaf.InstanceLocation + "/" + pointerEscaper.Replace(name), Problem: "property is not allowed", Remove: true})
+		}
+		return issues, len(issues) > 0
+	}
+	issue := FieldIssue{Field: leaf.InstanceLocation, Problem: leaf.Message}
+	issue.Example = exampleJSON(resolvePointer(root, schemaPointer), root)
+	return []FieldIssue{issue}, true
+}
+
+// quotedPropertyNames returns the property names quoted in a validation message.
+func quotedPropertyNames(message string) []string {
+	var names []string
+	for _, match := range quotedNames.FindAllStringSubmatch(message, -1) {
+		names = append(names, strings.ReplaceAll(match[1], `\'`, `'`))
+	}
+	return names
+}
+
+// resolvePointer returns the schema object at a JSON pointer into root, or nil.
+func resolvePointer(root map[string]interface{}, pointer string) map[string]interface{} {
+	var node interface{} = root
+	for _, segment := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		switch val := node.(type) {
+		case map[string]interface{}:
+			node = val[segment]
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(val) {
+				return nil
+			}
+			node = val[idx]
+		default:
+			return nil
+		}
+	}
+	schema, _ := node.(map[string]interface{})
+	return schema
+}
+
+// exampleJSON returns the JSON of an example value for schema, or "" when none can
+// be synthesized.
+func exampleJSON(schema, root map[string]interface{}) string {
+	if schema == nil {
+		return ""
+	}
+	value, ok := exampleValue(schema, root, 0)
+	if !ok {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// exampleValue synthesizes a value satisfying schema: its const, first enum value,
+// default or first example when declared, else a value of its type within its
+// bounds. Local $refs are resolved against root. ok is false when no value can be
+// synthesized, such as for strings constrained by a pattern.
+func exampleValue(schema, root map[string]interface{}, depth int) (interface{}, bool) {
+	if depth > maxExampleDepth {
+		return nil, false
+	}
+	if value, ok := schema["const"]; ok {
+		return value, true
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0], true
+	}
+	if value, ok := schema["default"]; ok {
+		return value, true
+	}
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[0], true
+	}
+	if ref, ok := schema["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+		if target := resolvePointer(root, strings.TrimPrefix(ref, "#")); target != nil {
+			return exampleValue(target, root, depth+1)
+		}
+		return nil, false
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		if branches, ok := schema[keyword].([]interface{}); ok && len(branches) > 0 {
+			if branch, ok := branches[0].(map[string]interface{}); ok {
+				return exampleValue(branch, root, depth+1)
+			}
+		}
+	}
+
+	switch schemaType(schema) {
+	case "string":
+		return exampleString(schema)
+	case "integer":
+		return math.Ceil(exampleNumber(schema, 1)), true
+	case "number":
+		return exampleNumber(schema, 0.5), true
+	case "boolean":
+		return true, true
+	case "null":
+		return nil, true
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		count := 1
+		if minItems, ok := schema["minItems"].(float64); ok && int(minItems) > count {
+			count = int(minItems)
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && int(maxItems) < count {
+			count = int(maxItems)
+		}
+		if items == nil {
+			return []interface{}{}, true
+		}
+		item, ok := exampleValue(items, root, depth+1)
+		if !ok {
+			return nil, false
+		}
+		array := make([]interface{}, count)
+		for i := range array {
+			array[i] = item
+		}
+		return array, true
+	case "object":
+		properties, _ := schema["properties"].(map[string]interface{})
+		names := requiredNames(schema)
+		if len(names) == 0 {
+			for name := range properties {
+				names = append(names, name)
+			}
+		}
+		object := make(map[string]interface{}, len(names))
+		for _, name := range names {
+			property, _ := properties[name].(map[string]interface{})
+			if property == nil {
+				continue
+			}
+			if value, ok := exampleValue(property, root, depth+1); ok {
+				object[name] = value
+			}
+		}
+		return object, true
+	}
+	return nil, false
+}
+
+// schemaType returns the type of schema, the first non-null type when several are
+// allowed, or the type implied by its keywords.
+func schemaType(schema map[string]interface{}) string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ
+	case []interface{}:
+		for _, candidate := range typ {
+			if name, ok := candidate.(string); ok && name != "null" {
+				return name
+			}
+		}
+		return "null"
+	}
+	switch {
+	case schema["properties"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	}
+	return ""
+}
+
+// requiredNames returns the required property names of an object schema.
+func requiredNames(schema map[string]interface{}) []string {
+	required, _ := schema["required"].([]interface{})
+	names := make([]string, 0, len(required))
+	for _, name := range required {
+		if s, ok := name.(string); ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// exampleString returns a string of the schema's format within its length bounds.
+func exampleString(schema map[string]interface{}) (interface{}, bool) {
+	formats := map[string]string{
+		"date":      "2025-01-31",
+		"date-time": "2025-01-31T09:00:00Z",
+		"time":      "09:00:00Z",
+		"email":     "name@example.com",
+		"uri":       "https://example.com",
+		"url":       "https://example.com",
+		"uuid":      "123e4567-e89b-12d3-a456-426614174000",
+	}
+	if format, ok := schema["format"].(string); ok {
+		if value, known := formats[format]; known {
+			return value, true
+		}
+	}
+	if _, ok := schema["pattern"]; ok {
+		return nil, false
+	}
+	value := "text"
+	if minLength, ok := schema["minLength"].(float64); ok && int(minLength) > len(value) {
+		value += strings.Repeat("x", int(minLength)-len(value))
+	}
+	if maxLength, ok := schema["maxLength"].(float64); ok && int(maxLength) < len(value) {
+		value = value[:int(maxLength)]
+	}
+	return value, true
+}
+
+// exampleNumber returns a number within the schema's bounds, step above an exclusive
+// minimum.
+func exampleNumber(schema map[string]interface{}, step float64) float64 {
+	value := 0.0
+	if minimum, ok := schema["minimum"].(float64); ok {
+		value = minimum
+	}
+	if exclusive, ok := schema["exclusiveMinimum"].(float64); ok && value <= exclusive {
+		value = exclusive + step
+	}
+	if maximum, ok := schema["maximum"].(float64); ok && value > maximum {
+		value = maximum
+	}
+	return value
+}
@@ -0,0 +1,96 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fieldsSchema = `{
+	"type": "object",
+	"definitions": {
+		"risk": {
+			"type": "object",
+			"properties": {
+				"level": {"type": "string", "enum": ["low", "medium", "high"]},
+				"score": {"type": "integer", "minimum": 1, "maximum": 5}
+			},
+			"required": ["level"]
+		}
+	},
+	"properties": {
+		"title": {"type": "string", "minLength": 8},
+		"owner": {"type": "string", "format": "email"},
+		"reviewed": {"type": "string", "format": "date"},
+		"code": {"type": "string", "pattern": "^[A-Z]{3}$"},
+		"risks": {"type": "array", "items": {"$ref": "#/definitions/risk"}, "minItems": 1},
+		"status": {"anyOf": [{"type": "string"}, {"type": "null"}]}
+	},
+	"required": ["title", "owner", "risks"],
+	"additionalProperties": false
+}`
+
+func TestValidator_FieldIssues(t *testing.T) {
+	validator := NewValidator()
+
+	t.Run("narrows violations to fields with examples", func(t *testing.T) {
+		document := `{"title": "Payments platform", "risks": [{"level": "severe", "score": 9}], "reviewed": "soon", "draft": true}`
+
+		issues, ok, err := validator.FieldIssues(document, fieldsSchema)
+
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.ElementsMatch(t, []FieldIssue{
+			{Field: "/owner", Problem: "required property is missing", Example: `"name@example.com"`, Missing: true},
+			{Field: "/draft", Problem: "property is not allowed", Remove: true},
+			{Field: "/reviewed", Problem: `'soon' is not valid 'date'`, Example: `"2025-01-31"`},
+			{Field: "/risks/0/level", Problem: `value must be one of "low", "medium", "high"`, Example: `"low"`},
+			{Field: "/risks/0/score", Problem: "must be <= 5 but found 9", Example: "1"},
+		}, issues)
+	})
+
+	t.Run("synthesizes objects for missing required fields", func(t *testing.T) {
+		issues, ok, err := validator.FieldIssues(`{"title": "Payments platform", "owner": "a@b.c"}`, fieldsSchema)
+
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, []FieldIssue{{Field: "/risks", Problem: "required property is missing", Example: `[{"level":"low"}]`, Missing: true}}, issues)
+	})
+
+	t.Run("omits examples that cannot be synthesized", func(t *testing.T) {
+		issues, ok, err := validator.FieldIssues(`{"title": "Payments platform", "owner": "a@b.c", "risks": [{"level": "low"}], "code": "abc"}`, fieldsSchema)
+
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, issues, 1)
+		assert.Equal(t, "/code", issues[0].Field)
+		assert.Empty(t, issues[0].Example)
+	})
+
+	t.Run("valid document has no issues", func(t *testing.T) {
+		issues, ok, err := validator.FieldIssues(`{"title": "Payments platform", "owner": "a@b.c", "risks": [{"level": "low"}]}`, fieldsSchema)
+
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, issues)
+	})
+
+	t.Run("cannot narrow alternatives or unparsable JSON", func(t *testing.T) {
+		_, ok, err := validator.FieldIssues(`{"title": "Payments platform", "owner": "a@b.c", "risks": [{"level": "low"}], "status": 3}`, fieldsSchema)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, ok, err = validator.FieldIssues(`{"title": `, fieldsSchema)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("escapes property names in pointers", func(t *testing.T) {
+		issues, ok, err := validator.FieldIssues(`{}`, `{"type": "object", "properties": {"a/b": {"type": "boolean"}}, "required": ["a/b"]}`)
+
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, []FieldIssue{{Field: "/a~1b", Problem: "required property is missing", Example: "true", Missing: true}}, issues)
+	})
+}
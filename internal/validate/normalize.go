@@ -0,0 +1,221 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnitKeyword is the schema extension declaring the unit of a numeric field, e.g.
+// {"type": "number", "x-unit": "ms"}. Normalize strips the unit from values such as
+// "250 ms"; values carrying another unit are left for validation to reject.
+const UnitKeyword = "x-unit"
+
+// Normalization records a value rewritten by Normalize.
+type Normalization struct {
+	Field string      `json:"field"` // JSON pointer of the value
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// fuzzyDateLayouts are the date formats Normalize recognizes besides ISO 8601.
+// Ambiguous slash formats (01/02/2006) are deliberately not guessed.
+var fuzzyDateLayouts = []string{
+	"2006-1-2",
+	"2006/01/02",
+	"2006.01.02",
+	"02.01.2006",
+	"2.1.2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"Monday, January 2, 2006",
+	"Mon, 2 Jan 2006",
+}
+
+// fuzzyDateTimeLayouts are the date-time formats Normalize recognizes besides RFC 3339.
+// Values without a zone are taken as UTC.
+var fuzzyDateTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.ANSIC,
+}
+
+// thousandsGroups matches integers grouped by a single separator, e.g. 1,234,567.
+var thousandsGroups = regexp.MustCompile(`^\d{1,3}([,.]\d{3})+$`)
+
+// Normalize rewrites values the schema types as numbers or dates into canonical
+// form before validation, avoiding repair rounds for formatting slips: numeric
+// strings such as "1,250.5" or "250 ms" (with the field's declared x-unit) become
+// numbers, and dates in common formats become ISO 8601 for fields with format
+// "date" or "date-time". Values that cannot be normalized unambiguously are left
+// unchanged. It returns the normalized JSON and the changes made.
+func (v *Validator) Normalize(jsonStr string, schemaStr string) (string, []Normalization, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+		return jsonStr, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(schemaStr), &schema); err != nil {
+		return jsonStr, nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	var changes []Normalization
+	data = normalizeValue(data, schema, "", &changes)
+	if len(changes) == 0 {
+		return jsonStr, nil, nil
+	}
+
+	normalized, err := json.Marshal(data)
+	if err != nil {
+		return jsonStr, nil, fmt.Errorf("failed to encode normalized JSON: %w", err)
+	}
+	return string(normalized), changes, nil
+}
+
+// normalizeValue normalizes value according to schema, recursing into object
+// properties and array items.
+func normalizeValue(value interface{}, schema map[string]interface{}, pointer string, changes *[]Normalization) interface{} {
+	if schema == nil {
+		return value
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, propertySchema := range properties {
+			if child, ok := val[name]; ok {
+				sub, _ := propertySchema.(map[string]interface{})
+				val[name] = normalizeValue(child, sub, pointer+"/"+name, changes)
+			}
+		}
+		return val
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range val {
+			val[i] = normalizeValue(item, items, fmt.Sprintf("%s/%d", pointer, i), changes)
+		}
+		return val
+	case string:
+		var normalized interface{}
+		var ok bool
+		switch {
+		case schemaAllows(schema, "integer"):
+			var n float64
+			n, ok = parseNumber(val, schemaUnit(schema))
+			ok = ok && n == math.Trunc(n)
+			normalized = n
+		case schemaAllows(schema, "number"):
+			normalized, ok = parseNumber(val, schemaUnit(schema))
+		case schema["format"] == "date":
+			normalized, ok = parseDate(val, fuzzyDateLayouts, "2006-01-02")
+		case schema["format"] == "date-time":
+			normalized, ok = parseDate(val, fuzzyDateTimeLayouts, time.RFC3339)
+		}
+		if ok && normalized != value {
+			*changes = append(*changes, Normalization{Field: pointer, From: value, To: normalized})
+			return normalized
+		}
+	}
+	return value
+}
+
+// schemaAllows reports whether the schema's type is, or includes, typeName and not
+// also string, where a string value would already be valid.
+func schemaAllows(schema map[string]interface{}, typeName string) bool {
+	switch t := schema["type"].(type) {
+	case string:
+		return t == typeName
+	case []interface{}:
+		allowed := false
+		for _, name := range t {
+			if name == "string" {
+				return false
+			}
+			if name == typeName {
+				allowed = true
+			}
+		}
+		return allowed
+	}
+	return false
+}
+
+// schemaUnit returns the unit declared with x-unit, if any.
+func schemaUnit(schema map[string]interface{}) string {
+	unit, _ := schema[UnitKeyword].(string)
+	return unit
+}
+
+// parseNumber parses a numeric string, removing whitespace, thousands separators and
+// the declared unit. When both "," and "." occur, the last one is the decimal
+// separator; a single kind of separator is taken as thousands grouping only when it
+// separates groups of exactly three digits.
+func parseNumber(s string, unit string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if unit != "" {
+		s = strings.TrimSpace(strings.TrimSuffix(s, unit))
+	}
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "_", "", "'", "").Replace(s)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	comma, dot := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+	switch {
+	case comma >= 0 && dot >= 0:
+		decimal, thousands := ".", ","
+		if comma > dot {
+			decimal, thousands = ",", "."
+		}
+		s = strings.ReplaceAll(s, thousands, "")
+		s = strings.Replace(s, decimal, ".", 1)
+	case thousandsGroups.MatchString(s):
+		if strings.Count(s, ".") == 1 {
+			// "1.250" is a decimal in English and a thousand elsewhere
+			return 0, false
+		}
+		s = strings.NewReplacer(",", "", ".", "").Replace(s)
+	case comma >= 0:
+		if strings.Count(s, ",") > 1 {
+			return 0, false
+		}
+		s = strings.Replace(s, ",", ".", 1)
+	}
+
+	n, err := strconv.ParseFloat(sign+s, 64)
+	if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseDate parses s as an ISO 8601 value or one of layouts and formats it with
+// canonical.
+func parseDate(s string, layouts []string, canonical string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if _, err := time.Parse(canonical, s); err == nil {
+		return s, true
+	}
+	for _, layout := range layouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			return parsed.Format(canonical), true
+		}
+	}
+	return "", false
+}
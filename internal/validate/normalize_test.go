@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidator_Normalize tests canonicalizing numeric and date values before validation.
+func TestValidator_Normalize(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"users": {"type": "integer"},
+			"budget": {"type": "number"},
+			"latency": {"type": "number", "x-unit": "ms"},
+			"label": {"type": "string"},
+			"start": {"type": "string", "format": "date"},
+			"deployed": {"type": "string", "format": "date-time"},
+			"costs": {"type": "array", "items": {"type": "object", "properties": {"amount": {"type": "number"}}}}
+		}
+	}`
+	input := `{
+		"users": "12,500",
+		"budget": "1.250.000,50",
+		"latency": "250 ms",
+		"label": "1,000",
+		"start": "March 3, 2025",
+		"deployed": "2025-03-03 14:30",
+		"costs": [{"amount": "-1,234.5"}, {"amount": 7}]
+	}`
+
+	normalized, changes, err := NewValidator().Normalize(input, schema)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"users": 12500,
+		"budget": 1250000.5,
+		"latency": 250,
+		"label": "1,000",
+		"start": "2025-03-03",
+		"deployed": "2025-03-03T14:30:00Z",
+		"costs": [{"amount": -1234.5}, {"amount": 7}]
+	}`, normalized)
+	assert.Len(t, changes, 6)
+	assert.NoError(t, NewValidator().Validate(normalized, schema))
+}
+
+// TestValidator_Normalize_LeavesAmbiguousValues tests that values are only rewritten
+// when their meaning is clear.
+func TestValidator_Normalize_LeavesAmbiguousValues(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"count": {"type": "integer"},
+			"amount": {"type": "number"},
+			"latency": {"type": "number", "x-unit": "ms"},
+			"due": {"type": "string", "format": "date"}
+		}
+	}`
+	input := `{"count": "2.5", "amount": "1.250", "latency": "2 s", "due": "03/04/2025"}`
+
+	normalized, changes, err := NewValidator().Normalize(input, schema)
+
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, input, normalized)
+}
@@ -0,0 +1,103 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Rule is a cross-field consistency check a template declares, evaluated after schema
+// validation. The expression must be true for the document to be consistent:
+//
+//	{"name": "component-total", "expr": "sum(components[*].count) == total",
+//	 "message": "total must equal the sum of the component counts"}
+type Rule struct {
+	Name    string `json:"name"`
+	Expr    string `json:"expr"`
+	Message string `json:"message,omitempty"`
+}
+
+// Compile checks that the rule's expression parses.
+func (r Rule) Compile() error {
+	if strings.TrimSpace(r.Expr) == "" {
+		return fmt.Errorf("rule %s: empty expression", r.label())
+	}
+	if _, err := compileExpr(r.Expr); err != nil {
+		return fmt.Errorf("rule %s: invalid expression %q: %w", r.label(), r.Expr, err)
+	}
+	return nil
+}
+
+// label names the rule in messages, falling back to its expression.
+func (r Rule) label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Expr
+}
+
+// RuleViolation is a rule the document does not satisfy.
+type RuleViolation struct {
+	Rule   Rule
+	Reason string // Why the expression could not be evaluated, if it could not
+}
+
+// String describes the violation for the repair prompt.
+func (v RuleViolation) String() string {
+	message := v.Rule.Message
+	if message == "" {
+		message = "expected " + v.Rule.Expr
+	}
+	if v.Reason != "" {
+		message += " (" + v.Reason + ")"
+	}
+	return fmt.Sprintf("%s: %s", v.Rule.label(), message)
+}
+
+// RuleViolationError lists the consistency rules a document violates.
+type RuleViolationError struct {
+	Violations []RuleViolation
+}
+
+// Error implements the error interface.
+func (e *RuleViolationError) Error() string {
+	lines := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		lines = append(lines, "- "+v.String())
+	}
+	return fmt.Sprintf("consistency rules violated:\n%s", strings.Join(lines, "\n"))
+}
+
+// CheckRules evaluates the consistency rules against a JSON document and returns a
+// *RuleViolationError listing every rule that is false or cannot be evaluated.
+func (v *Validator) CheckRules(jsonStr string, rules []Rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var violations []RuleViolation
+	for _, rule := range rules {
+		e, err := compileExpr(rule.Expr)
+		if err != nil {
+			return fmt.Errorf("rule %s: invalid expression %q: %w", rule.label(), rule.Expr, err)
+		}
+		result, err := e.eval(data)
+		if err != nil {
+			violations = append(violations, RuleViolation{Rule: rule, Reason: err.Error()})
+			continue
+		}
+		if !truthy(result) {
+			violations = append(violations, RuleViolation{Rule: rule})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &RuleViolationError{Violations: violations}
+	}
+	return nil
+}
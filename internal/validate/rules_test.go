@@ -0,0 +1,72 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidator_CheckRules tests evaluating consistency rules against documents.
+func TestValidator_CheckRules(t *testing.T) {
+	document := `{
+		"total": 5,
+		"status": "red",
+		"risk": {"level": "high"},
+		"components": [{"name": "api", "count": 2}, {"name": "db", "count": 3}]
+	}`
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"sum over wildcard", "sum(components[*].count) == total", true},
+		{"arithmetic", "components[0].count + components[1].count * 2 == 8", true},
+		{"implication as or", `risk.level != "high" || status in ["red", "amber"]`, true},
+		{"violated enum", `risk.level == "high" && status == "green"`, false},
+		{"count and min", "count(components) == 2 && min(components[*].count) >= 2", true},
+		{"missing field is null", "owner == null", true},
+		{"not", `!(lower("RED") == status)`, false},
+		{"all", `all(components[*].name)`, true},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.CheckRules(document, []Rule{{Name: "rule", Expr: tt.expr}})
+			if tt.want {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+// TestValidator_CheckRules_Violations tests the violation report fed to repairs.
+func TestValidator_CheckRules_Violations(t *testing.T) {
+	rules := []Rule{
+		{Name: "component-total", Expr: "sum(components[*].count) == total", Message: "total must equal the sum of the component counts"},
+		{Name: "positive", Expr: "total > 0"},
+		{Name: "typed", Expr: "status > 1"},
+	}
+
+	err := NewValidator().CheckRules(`{"total": 4, "status": "red", "components": [{"count": 2}, {"count": 3}]}`, rules)
+
+	require.Error(t, err)
+	var violationErr *RuleViolationError
+	require.ErrorAs(t, err, &violationErr)
+	require.Len(t, violationErr.Violations, 2)
+	assert.Contains(t, err.Error(), "component-total: total must equal the sum of the component counts")
+	assert.Contains(t, err.Error(), "typed: expected status > 1 (cannot compare string with number)")
+}
+
+// TestRule_Compile tests that malformed expressions are rejected.
+func TestRule_Compile(t *testing.T) {
+	assert.NoError(t, Rule{Expr: `sum(items[*].n) <= 10 && name != ""`}.Compile())
+
+	for _, expr := range []string{"", "total ==", "median(items)", "items[x]", `"open`, "total # 2"} {
+		assert.Error(t, Rule{Name: "bad", Expr: expr}.Compile(), expr)
+	}
+}
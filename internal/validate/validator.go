@@ -4,6 +4,7 @@ package validate
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -138,26 +139,43 @@ func (v *Validator) ValidateWithDetails(jsonStr string, schemaStr string) (*Vali
 		return nil, fmt.Errorf("failed to compile schema: %w", err)
 	}
 
-	// Validate
+	// Validate, reporting every failing field rather than only the first
 	if err := schema.Validate(jsonData); err != nil {
 		result.Valid = false
-		// Extract validation issues
-		issue := ValidationIssue{
-			Type:    "validation_error",
-			Message: err.Error(),
+		var schemaErr *jsonschema.ValidationError
+		if !errors.As(err, &schemaErr) {
+			result.Errors = append(result.Errors, ValidationIssue{Type: "validation_error", Message: err.Error()})
+			return result, nil
 		}
-
-		// Try to extract field information
-		if validErr, ok := err.(*ValidationError); ok {
-			issue.Field = validErr.Field
+		for _, leaf := range leafErrors(schemaErr) {
+			field := leaf.InstanceLocation
+			if field == "" {
+				field = "/"
+			}
+			result.Errors = append(result.Errors, ValidationIssue{
+				Type:    "validation_error",
+				Field:   field,
+				Message: fmt.Sprintf("%s: %s", field, leaf.Message),
+			})
 		}
-
-		result.Errors = append(result.Errors, issue)
 	}
 
 	return result, nil
 }
 
+// leafErrors returns the innermost causes of a schema validation error, which name
+// the individual fields that failed.
+func leafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, leafErrors(cause)...)
+	}
+	return leaves
+}
+
 // ValidationResult contains the outcome of a validation check.
 type ValidationResult struct {
 	Errors []ValidationIssue `json:"errors,omitempty"`
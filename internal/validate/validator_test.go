@@ -282,6 +282,28 @@ func TestValidator_ValidateWithDetails(t *testing.T) {
 	assert.Contains(t, result.Errors[0].Message, "title")
 }
 
+// TestValidator_ValidateWithDetails_EveryField tests that each failing field is reported.
+func TestValidator_ValidateWithDetails_EveryField(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"title": {"type": "string"},
+			"items": {"type": "array", "items": {"type": "object", "required": ["name"]}}
+		},
+		"required": ["title", "summary"]
+	}`
+
+	result, err := NewValidator().ValidateWithDetails(`{"title": 1, "items": [{"name": "a"}, {}]}`, schema)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+
+	fields := make([]string, 0, len(result.Errors))
+	for _, issue := range result.Errors {
+		fields = append(fields, issue.Field)
+	}
+	assert.ElementsMatch(t, []string{"/", "/title", "/items/1"}, fields)
+}
+
 // TestValidator_Validate_AdditionalProperties tests handling of additional properties.
 func TestValidator_Validate_AdditionalProperties(t *testing.T) {
 	// Test with additionalProperties: false
@@ -0,0 +1,61 @@
+// Package agentsdk helps Go authors write docloom Research Agents.
+//
+// An agent is an executable that docloom runs in one of two ways:
+//
+//   - As a runner, with the source and output directories as arguments
+//     (agent <source> <output>). The agent writes Markdown and JSON artifacts into
+//     the output directory; at least one Markdown file is required.
+//   - As a tool, with the tool name and its arguments (agent <tool> <args...>). The
+//     tool writes a single JSON document to stdout, or {"error": "..."} on failure.
+//
+// In both modes parameters arrive as PARAM_<NAME> environment variables and anything
+// written to stderr is logged by docloom. The helpers here implement that contract:
+//
+//	func main() {
+//		source, output, err := agentsdk.RunnerArgs(os.Args[1:])
+//		if err != nil {
+//			agentsdk.Fatalf("%v", err)
+//		}
+//		params := agentsdk.Params()
+//		out, err := agentsdk.NewOutput(output)
+//		...
+//		_ = out.WriteMarkdown("Summary.md", summary)
+//		if err := out.Close(); err != nil {
+//			agentsdk.Fatalf("%v", err)
+//		}
+//	}
+package agentsdk
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Stderr is where Logf and Fatalf write; docloom logs every line an agent writes to
+// stderr. Tests may replace it.
+var Stderr io.Writer = os.Stderr
+
+// exit terminates the process; replaced in tests.
+var exit = os.Exit
+
+// Logf writes a diagnostic line to stderr. Agents must not log to stdout, which
+// carries tool results.
+func Logf(format string, args ...interface{}) {
+	fmt.Fprintf(Stderr, format+"\n", args...)
+}
+
+// Fatalf logs the message and exits with status 1, which docloom reports as a
+// failed agent run.
+func Fatalf(format string, args ...interface{}) {
+	Logf(format, args...)
+	exit(1)
+}
+
+// RunnerArgs returns the source and output directories of a runner invocation.
+func RunnerArgs(args []string) (source, output string, err error) {
+	if len(args) < 2 {
+		return "", "", fmt.Errorf("usage: <source_path> <output_path>")
+	}
+	return args[0], args[1], nil
+}
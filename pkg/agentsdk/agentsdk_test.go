@@ -0,0 +1,114 @@
+package agentsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams(t *testing.T) {
+	params := ParamsFrom(map[string]string{
+		"PARAM_INCLUDE-INTERNAL": "true",
+		"PARAM_MAX_DEPTH":        "4",
+		"PARAM_EXTRACT_METRICS":  "no",
+		"PARAM_LABEL":            "",
+		"PARAM_MAX_FILES":        "many",
+	})
+
+	assert.True(t, params.Bool("include-internal", false))
+	assert.Equal(t, 4, params.Int("max-depth", 10), "dashes match underscores")
+	assert.False(t, params.Bool("extract-metrics", true))
+	assert.Equal(t, "fallback", params.String("label", "fallback"), "empty values count as unset")
+	assert.Equal(t, ".", params.SourceRoot())
+
+	limits := params.WalkLimits()
+	assert.Equal(t, 20, limits.MaxDepth)
+	assert.Equal(t, 10000, limits.MaxFiles, "invalid numbers fall back to the default")
+}
+
+func TestOutput(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	out, err := NewOutput(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, out.WriteJSON("api-surface.json", map[string]interface{}{"namespaces": []string{"Ledger"}}))
+	assert.EqualError(t, out.Close(), "agent did not produce any markdown files")
+
+	require.NoError(t, out.WriteMarkdown("Summary", "# Summary\n"))
+	require.NoError(t, out.Close())
+	assert.FileExists(t, filepath.Join(dir, "Summary.md"))
+
+	var surface map[string][]string
+	data, err := os.ReadFile(filepath.Join(dir, "api-surface.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &surface))
+	assert.Equal(t, []string{"Ledger"}, surface["namespaces"])
+
+	assert.Error(t, out.WriteFile("../escape.md", []byte("x")))
+}
+
+func TestRespond(t *testing.T) {
+	var stdout bytes.Buffer
+	Stdout = &stdout
+	t.Cleanup(func() { Stdout = os.Stdout })
+
+	Respond(map[string]int{"projectCount": 2})
+	RespondError("File not found: %s", "a.cs")
+
+	assert.Equal(t, "{\"projectCount\":2}\n{\"error\":\"File not found: a.cs\"}\n", stdout.String())
+}
+
+func TestFatalf(t *testing.T) {
+	var stderr bytes.Buffer
+	Stderr = &stderr
+	code := 0
+	exit = func(c int) { code = c }
+	t.Cleanup(func() {
+		Stderr = os.Stderr
+		exit = os.Exit
+	})
+
+	Fatalf("failed: %s", "boom")
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "failed: boom\n", stderr.String())
+}
+
+func TestWalk_FileLimit(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.cs", "b.cs", "c.cs"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte("class A {}"), 0644))
+	}
+	Stderr = &bytes.Buffer{}
+	t.Cleanup(func() { Stderr = os.Stderr })
+
+	var files []string
+	err := Walk(root, WalkLimits{MaxFiles: 2}, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, d.Name())
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	_, err = ConfinePath(root, "../outside.cs")
+	assert.ErrorIs(t, err, ErrOutsideRoot)
+}
+
+func TestRunnerArgs(t *testing.T) {
+	source, output, err := RunnerArgs([]string{"./src", "./out"})
+	require.NoError(t, err)
+	assert.Equal(t, "./src", source)
+	assert.Equal(t, "./out", output)
+
+	_, _, err = RunnerArgs([]string{"./src"})
+	assert.Error(t, err)
+}
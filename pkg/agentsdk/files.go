@@ -0,0 +1,34 @@
+package agentsdk
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/karolswdev/docloom/internal/agent"
+)
+
+// WalkLimits bounds a directory walk; zero values fall back to docloom's defaults.
+type WalkLimits = agent.WalkLimits
+
+// ErrOutsideRoot is returned by ConfinePath for paths that escape the source root.
+var ErrOutsideRoot = agent.ErrOutsideRoot
+
+// ConfinePath resolves path against root, following symlinks, and fails with an
+// error wrapping ErrOutsideRoot when it escapes root. Tools that read files named by
+// the model must confine them this way.
+func ConfinePath(root, path string) (string, error) {
+	return agent.ConfinePath(root, path)
+}
+
+// Walk walks root like filepath.WalkDir without leaving it, skipping paths excluded
+// by .docloomignore or .gitignore files and directories deeper than limits.MaxDepth.
+// Reaching limits.MaxFiles ends the walk early without an error, so agents analyze a
+// bounded part of very large repositories instead of failing.
+func Walk(root string, limits WalkLimits, fn fs.WalkDirFunc) error {
+	err := agent.WalkConfined(root, limits, fn)
+	if errors.Is(err, agent.ErrWalkLimit) {
+		Logf("Warning: file limit reached, results are truncated")
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package agentsdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Output writes the artifacts of a runner invocation into its output directory.
+type Output struct {
+	dir      string
+	markdown int
+}
+
+// NewOutput prepares the output directory docloom passed to the agent.
+func NewOutput(dir string) (*Output, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return &Output{dir: dir}, nil
+}
+
+// Dir returns the output directory.
+func (o *Output) Dir() string {
+	return o.dir
+}
+
+// WriteFile writes an artifact. Names are relative to the output directory and may
+// not leave it.
+func (o *Output) WriteFile(name string, data []byte) error {
+	clean := filepath.Clean(name)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("artifact %s is outside the output directory", name)
+	}
+	path := filepath.Join(o.dir, clean)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if strings.EqualFold(filepath.Ext(clean), ".md") {
+		o.markdown++
+	}
+	return nil
+}
+
+// WriteMarkdown writes a Markdown artifact, the material docloom feeds to the model.
+func (o *Output) WriteMarkdown(name, content string) error {
+	if !strings.EqualFold(filepath.Ext(name), ".md") {
+		name += ".md"
+	}
+	return o.WriteFile(name, []byte(content))
+}
+
+// WriteJSON writes v as an indented JSON artifact, for example structured data that
+// postconditions in the agent manifest check.
+func (o *Output) WriteJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return o.WriteFile(name, append(data, '\n'))
+}
+
+// Close checks the output contract: docloom rejects runs that produce no Markdown
+// artifact, so Close fails when none was written.
+func (o *Output) Close() error {
+	if o.markdown == 0 {
+		return fmt.Errorf("agent did not produce any markdown files")
+	}
+	return nil
+}
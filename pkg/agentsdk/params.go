@@ -0,0 +1,92 @@
+package agentsdk
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/agent"
+)
+
+// ParamSet reads agent parameters from PARAM_<NAME> environment variables.
+type ParamSet struct {
+	lookup func(string) (string, bool)
+}
+
+// Params returns the parameters passed to the agent through the environment.
+func Params() ParamSet {
+	return ParamSet{lookup: os.LookupEnv}
+}
+
+// ParamsFrom returns parameters read from a map of PARAM_* variables, for tests.
+func ParamsFrom(env map[string]string) ParamSet {
+	return ParamSet{lookup: func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}}
+}
+
+// Lookup returns the value of the named parameter. Names are matched as docloom
+// exports them (PARAM_ plus the upper-cased name) and with dashes replaced by
+// underscores, so "include-internal" is found in PARAM_INCLUDE_INTERNAL as well.
+func (p ParamSet) Lookup(name string) (string, bool) {
+	upper := strings.ToUpper(name)
+	if value, ok := p.lookup("PARAM_" + upper); ok && value != "" {
+		return value, true
+	}
+	if value, ok := p.lookup("PARAM_" + strings.ReplaceAll(upper, "-", "_")); ok && value != "" {
+		return value, true
+	}
+	return "", false
+}
+
+// String returns the named parameter, or defaultValue when it is not set.
+func (p ParamSet) String(name, defaultValue string) string {
+	if value, ok := p.Lookup(name); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// Bool returns the named parameter as a boolean ("true", "1", "yes" are true), or
+// defaultValue when it is not set.
+func (p ParamSet) Bool(name string, defaultValue bool) bool {
+	value, ok := p.Lookup(name)
+	if !ok {
+		return defaultValue
+	}
+	switch strings.ToLower(value) {
+	case "true", "1", "yes":
+		return true
+	}
+	return false
+}
+
+// Int returns the named parameter as an integer, or defaultValue when it is not set
+// or not a number.
+func (p ParamSet) Int(name string, defaultValue int) int {
+	value, ok := p.Lookup(name)
+	if !ok {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+// SourceRoot returns the source directory docloom passes to tools as SOURCE_PATH,
+// or "." when it is not set.
+func (p ParamSet) SourceRoot() string {
+	return p.String("SOURCE_PATH", ".")
+}
+
+// WalkLimits returns the directory walk limits set with the MAX_WALK_DEPTH and
+// MAX_FILES parameters, falling back to docloom's defaults.
+func (p ParamSet) WalkLimits() WalkLimits {
+	return WalkLimits{
+		MaxDepth: p.Int("MAX_WALK_DEPTH", agent.DefaultMaxWalkDepth),
+		MaxFiles: p.Int("MAX_FILES", agent.DefaultMaxWalkFiles),
+	}
+}
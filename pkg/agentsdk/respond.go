@@ -0,0 +1,31 @@
+package agentsdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Stdout is where tool results are written. Tests may replace it.
+var Stdout io.Writer = os.Stdout
+
+// ErrorEnvelope is the JSON document a tool prints when it cannot produce a result.
+// docloom passes it to the model like any other result, so the model can correct
+// its call.
+type ErrorEnvelope struct {
+	Error string `json:"error"`
+}
+
+// Respond prints a tool result as a single JSON document. An encoding failure is
+// fatal, as docloom could not read a partial result.
+func Respond(v interface{}) {
+	if err := json.NewEncoder(Stdout).Encode(v); err != nil {
+		Fatalf("Error encoding output: %v", err)
+	}
+}
+
+// RespondError prints an error envelope with the formatted message.
+func RespondError(format string, args ...interface{}) {
+	Respond(ErrorEnvelope{Error: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,161 @@
+// Package docrender validates document fields and renders docloom templates for
+// services that produce the fields themselves and need no generation.
+//
+// It depends on no AI client, model provider or tokenizer; its imports are limited to
+// the template loader, the JSON Schema validator and the renderer, so importing it
+// keeps a service's dependency tree small:
+//
+//	tmpl, err := docrender.Load("templates/runbook")
+//	if err != nil {
+//		return err
+//	}
+//	html, err := tmpl.Render(fieldsJSON)
+//	var invalid *docrender.ValidationError
+//	if errors.As(err, &invalid) {
+//		for _, issue := range invalid.Issues {
+//			log.Printf("%s: %s", issue.Field, issue.Message)
+//		}
+//	}
+//
+// Templates use the layout of docloom template directories: template.json,
+// template.html, schema.json and optional assets.
+//
+// The package is part of the docloom module rather than a module of its own. It
+// shares the template loader, validator and renderer with the CLI, and BuiltIn
+// serves the templates embedded from the module's templates directory, which a
+// nested module could not embed; a separate module would need copies of all of
+// them, kept in sync by hand. The docloom module's requirements therefore appear in
+// an importing module's graph, but with module graph pruning (go 1.17 and later)
+// the source of only the modules providing packages it builds, listed by go list
+// -deps and guarded by TestDependencies, is downloaded and compiled.
+package docrender
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/karolswdev/docloom/internal/render"
+	"github.com/karolswdev/docloom/internal/templates"
+	"github.com/karolswdev/docloom/internal/validate"
+)
+
+// Template is a docloom template ready to validate and render fields.
+type Template struct {
+	tmpl *templates.Template
+}
+
+// Load loads the template in dir, a directory containing template.json.
+func Load(dir string) (*Template, error) {
+	tmpl, err := templates.LoadTemplateDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// BuiltIn returns the built-in template with the given name, such as
+// "architecture-vision".
+func BuiltIn(name string) (*Template, error) {
+	registry := templates.NewRegistry()
+	if err := registry.LoadDefaults(); err != nil {
+		return nil, err
+	}
+	tmpl, err := registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Name returns the name of the template.
+func (t *Template) Name() string {
+	return t.tmpl.Name
+}
+
+// Schema returns the JSON Schema of the template's fields.
+func (t *Template) Schema() json.RawMessage {
+	return t.tmpl.Schema
+}
+
+// Placeholders returns the field paths the template's HTML refers to.
+func (t *Template) Placeholders() []string {
+	return render.Placeholders(t.tmpl.HTMLContent)
+}
+
+// Assets returns the template's stylesheets, scripts and images by their path
+// relative to the template directory. Rendered documents refer to them by these
+// paths, so they are served or copied alongside.
+func (t *Template) Assets() map[string][]byte {
+	return t.tmpl.Assets
+}
+
+// Issue is a single problem found in document fields.
+type Issue struct {
+	Field   string `json:"field,omitempty"` // JSON pointer of the field, if known
+	Message string `json:"message"`
+}
+
+// ValidationError reports fields that do not conform to the template.
+type ValidationError struct {
+	Template string
+	Issues   []Issue
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for idx, issue := range e.Issues {
+		messages[idx] = issue.Message
+	}
+	return fmt.Sprintf("fields do not conform to template %s (%d issue(s)):\n  %s", e.Template, len(e.Issues), strings.Join(messages, "\n  "))
+}
+
+// Validate checks fieldsJSON against the template's schema and consistency rules and
+// returns the fields with numeric and date values normalized, as docloom does for
+// generated documents. Fields that do not conform are reported together as a
+// *ValidationError.
+func (t *Template) Validate(fieldsJSON []byte) ([]byte, error) {
+	validator := validate.NewValidator()
+	schema := string(t.tmpl.Schema)
+	normalized, _, err := validator.Normalize(string(fieldsJSON), schema)
+	if err != nil {
+		// Unparsable JSON is reported by the validation below
+		normalized = string(fieldsJSON)
+	}
+
+	result, err := validator.ValidateWithDetails(normalized, schema)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", t.tmpl.Name, err)
+	}
+	if !result.Valid {
+		issues := make([]Issue, len(result.Errors))
+		for idx, issue := range result.Errors {
+			issues[idx] = Issue{Field: issue.Field, Message: issue.Message}
+		}
+		return nil, &ValidationError{Template: t.tmpl.Name, Issues: issues}
+	}
+	if err := validator.CheckRules(normalized, t.tmpl.Rules); err != nil {
+		return nil, &ValidationError{Template: t.tmpl.Name, Issues: []Issue{{Message: err.Error()}}}
+	}
+	return []byte(normalized), nil
+}
+
+// Render validates fieldsJSON like Validate and renders the template's HTML with the
+// fields, applying the template's transforms.
+func (t *Template) Render(fieldsJSON []byte) (string, error) {
+	normalized, err := t.Validate(fieldsJSON)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(normalized, &fields); err != nil {
+		return "", fmt.Errorf("failed to parse fields: %w", err)
+	}
+	return t.RenderFields(fields)
+}
+
+// RenderFields renders the template's HTML with fields, applying the template's
+// transforms, without validating them.
+func (t *Template) RenderFields(fields map[string]interface{}) (string, error) {
+	return render.HTMLWithOptions(t.tmpl.HTMLContent, fields, render.Options{Transforms: t.tmpl.Transforms})
+}
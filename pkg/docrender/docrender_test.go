@@ -0,0 +1,57 @@
+package docrender
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "template.json"), []byte(`{"name": "runbook", "prompt": "Write a runbook"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "template.html"), []byte(`<html><h1><!-- data-field="title" --></h1><p><!-- data-field="owners" --></p></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.json"), []byte(`{"type": "object", "properties": {"title": {"type": "string"}, "owners": {"type": "integer"}}, "required": ["title", "owners"]}`), 0644))
+
+	tmpl, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "runbook", tmpl.Name())
+	assert.ElementsMatch(t, []string{"title", "owners"}, tmpl.Placeholders())
+
+	html, err := tmpl.Render([]byte(`{"title": "Payments", "owners": "1,200"}`))
+	require.NoError(t, err)
+	assert.Contains(t, html, "<h1>Payments</h1>")
+	assert.Contains(t, html, "<p>1200</p>", "numbers are normalized before validation")
+
+	_, err = tmpl.Render([]byte(`{"owners": "many"}`))
+	var invalid *ValidationError
+	require.True(t, errors.As(err, &invalid))
+	assert.Len(t, invalid.Issues, 2)
+}
+
+func TestBuiltIn(t *testing.T) {
+	tmpl, err := BuiltIn("architecture-vision")
+	require.NoError(t, err)
+	assert.NotEmpty(t, tmpl.Schema())
+	assert.NotEmpty(t, tmpl.Placeholders())
+}
+
+// TestDependencies guards the purpose of the package: importing it must not pull in
+// AI clients, tokenizers or the CLI.
+func TestDependencies(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	out, err := exec.Command("go", "list", "-deps", ".").Output()
+	require.NoError(t, err)
+	for _, dependency := range strings.Fields(string(out)) {
+		for _, forbidden := range []string{"/internal/ai", "/internal/generate", "go-openai", "tiktoken", "go-tree-sitter", "spf13/cobra"} {
+			assert.NotContains(t, dependency, forbidden)
+		}
+	}
+}